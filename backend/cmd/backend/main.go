@@ -3,20 +3,35 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"os"
+	"strconv"
+	"strings"
 	"time"
+	// Snooze presets need the IANA time zones, which slim images lack.
+	_ "time/tzdata"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/controllers"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/db"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/middleware"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/telemetry"
 )
 
@@ -62,14 +77,27 @@ func main() {
 	router.RedirectTrailingSlash = false
 	router.RedirectFixedPath = false
 
+	// Only trust X-Forwarded-For from known proxies so per-IP login
+	// throttling cannot be bypassed with a spoofed header.
+	var trustedProxies []string
+	if v := os.Getenv("TRUSTED_PROXIES"); v != "" {
+		trustedProxies = strings.Split(v, ",")
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
-	// Apply CORS middleware
+	// Apply CORS middleware. The collaboration socket accepts the same
+	// origins.
+	allowedOrigins := []string{"http://localhost:5173"}
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
+		AllowOrigins:     allowedOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.WorkspaceHeader, "Last-Event-ID"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -78,29 +106,383 @@ func main() {
 	// Apply OpenTelemetry Gin middleware
 	router.Use(telemetry.GinMiddleware())
 
+	// Initialize token signing keys
+	keys, err := signingKeysFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Failed to load token signing keys", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize mailer
+	mail, err := mailer.NewFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Failed to initialize mailer", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize Auth layers
+	emailVerificationURL := os.Getenv("EMAIL_VERIFICATION_URL")
+	if emailVerificationURL == "" {
+		emailVerificationURL = "http://localhost:5173/verify-email"
+	}
+	unverifiedPolicy, err := unverifiedPolicyFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid unverified login policy", "error", err)
+		os.Exit(1)
+	}
+	loginGuardConfig, err := loginGuardConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid login guard configuration", "error", err)
+		os.Exit(1)
+	}
+	passwordPolicy, err := passwordPolicyFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid password policy", "error", err)
+		os.Exit(1)
+	}
+	passwordHasher, err := passwordHasherFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid password hashing configuration", "error", err)
+		os.Exit(1)
+	}
 	authRepo := repositories.NewPostgresAuthRepository(dbConn)
-	authService := services.NewAuthService(authRepo)
+	loginAttemptRepo := repositories.NewPostgresLoginAttemptRepository(dbConn)
+	taskListRepo := repositories.NewPostgresTaskListRepository(dbConn)
+
+	// Initialize invitation layers. Signup needs them to redeem invitations.
+	invitationURL := os.Getenv("INVITATION_URL")
+	if invitationURL == "" {
+		invitationURL = "http://localhost:5173/invitation"
+	}
+	invitationRepo := repositories.NewPostgresInvitationRepository(dbConn)
+	invitationService := services.NewInvitationService(invitationRepo, taskListRepo, keys, mail, invitationURL)
+	invitationController := controllers.NewInvitationController(invitationService)
+
+	authService := services.NewAuthService(authRepo, keys,
+		services.WithMailer(mail, emailVerificationURL),
+		services.WithUnverifiedPolicy(unverifiedPolicy),
+		services.WithLoginGuard(services.NewLoginGuard(loginAttemptRepo, loginGuardConfig)),
+		services.WithPasswordPolicy(passwordPolicy),
+		services.WithPasswordHasher(passwordHasher),
+		services.WithInvitations(invitationService),
+	)
 	authController := controllers.NewAuthController(authService)
 
-	// Initialize Task layers
-	taskRepo := repositories.NewPostgresTaskRepository(dbConn)
-	taskService := services.NewTaskService(taskRepo)
+	// Initialize Password layers
+	passwordResetURL := os.Getenv("PASSWORD_RESET_URL")
+	if passwordResetURL == "" {
+		passwordResetURL = "http://localhost:5173/reset-password"
+	}
+	passwordService := services.NewPasswordService(authRepo, mail, passwordPolicy, passwordHasher, passwordResetURL)
+	passwordController := controllers.NewPasswordController(passwordService)
+
+	// Initialize notification layers. New delivery channels are added here.
+	notificationRepo := repositories.NewPostgresNotificationRepository(dbConn)
+	notificationChannels, err := notificationChannelsFromEnv(notificationRepo, authRepo, mail)
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid notification channel configuration", "error", err)
+		os.Exit(1)
+	}
+	notifier := services.NewNotificationDispatcher(notificationRepo, notificationChannels...)
+	notificationService := services.NewNotificationService(notificationRepo, notificationChannels)
+	notificationController := controllers.NewNotificationController(notificationService)
+
+	// Initialize webhook layers. Task events relayed from the outbox are
+	// queued for the webhooks of their workspace and sent by the dispatcher.
+	webhookConfig, err := webhookDispatcherConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid webhook dispatcher configuration", "error", err)
+		os.Exit(1)
+	}
+	webhookRepo := repositories.NewPostgresWebhookRepository(dbConn)
+	webhookDispatcher := services.NewWebhookDispatcher(webhookRepo, webhookConfig)
+
+	// Initialize the event outbox. Task changes record their events in it and
+	// the relay publishes them to the event sinks.
+	outboxConfig, err := outboxRelayConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid outbox relay configuration", "error", err)
+		os.Exit(1)
+	}
+	outboxRepo := repositories.NewPostgresOutboxRepository(dbConn)
+	eventBus := services.NewEventBus()
+
+	// Initialize the event stream. Relayed events are announced to every
+	// replica with Postgres NOTIFY and pushed to the clients as SSE.
+	streamConfig, err := streamConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid event stream configuration", "error", err)
+		os.Exit(1)
+	}
+	streamService := services.NewStreamService(repositories.NewPostgresStreamRepository(dbConn, dbURL), streamConfig)
+	streamController := controllers.NewStreamController(streamService, streamConfig.Heartbeat)
+
+	// Initialize live collaboration. Sessions see the task events of the
+	// stream, and presence travels between replicas with Postgres NOTIFY.
+	collabConfig, err := collabConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid collaboration configuration", "error", err)
+		os.Exit(1)
+	}
+	presenceRepo := repositories.NewPostgresPresenceRepository(dbConn, dbURL)
+	collabService, err := services.NewCollabService(taskListRepo, authRepo, presenceRepo, streamService, collabConfig)
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Failed to initialize collaboration", "error", err)
+		os.Exit(1)
+	}
+	collabController := controllers.NewCollabController(collabService, allowedOrigins)
+
+	// Initialize Task layers. Concurrent edits are merged by hybrid logical
+	// clock timestamps, and every replica stamps with a node ID of its own.
+	clockNode, err := crdt.RandomNode()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Failed to generate a clock node ID", "error", err)
+		os.Exit(1)
+	}
+	taskClock, err := crdt.NewClock(clockNode)
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Failed to initialize the task clock", "error", err)
+		os.Exit(1)
+	}
+	taskRepo := repositories.NewPostgresTaskRepository(dbConn, taskClock)
+	taskService := services.NewTaskService(taskRepo, taskListRepo, notifier)
 	taskController := controllers.NewTaskController(taskService)
+	snoozeService := services.NewSnoozeService(taskRepo, authRepo)
+	snoozeController := controllers.NewSnoozeController(snoozeService)
+	syncService := services.NewSyncService(repositories.NewPostgresSyncRepository(dbConn, taskClock), taskListRepo)
+	syncController := controllers.NewSyncController(syncService)
+	importRepo := repositories.NewPostgresImportRepository(dbConn, taskClock)
+	importRunner := services.NewImportRunner(importRepo, services.DefaultImportRunnerConfig())
+	transferService := services.NewTransferService(taskRepo, importRepo, taskListRepo, importRunner)
+	transferController := controllers.NewTransferController(transferService)
+	calendarFeedURL := os.Getenv("CALENDAR_FEED_URL")
+	if calendarFeedURL == "" {
+		calendarFeedURL = "http://localhost:8080/calendar"
+	}
+	calendarService := services.NewCalendarService(repositories.NewPostgresCalendarFeedRepository(dbConn), transferService, calendarFeedURL)
+	calendarController := controllers.NewCalendarController(calendarService)
+	taskListService := services.NewTaskListService(taskListRepo, authRepo)
+	taskListController := controllers.NewTaskListController(taskListService)
+	commentRepo := repositories.NewPostgresCommentRepository(dbConn)
+	commentService := services.NewCommentService(commentRepo, notifier)
+	commentController := controllers.NewCommentController(commentService)
+	reminderRepo := repositories.NewPostgresReminderRepository(dbConn)
+	reminderService := services.NewReminderService(reminderRepo)
+	reminderController := controllers.NewReminderController(reminderService)
+	workspaceRepo := repositories.NewPostgresWorkspaceRepository(dbConn)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, authRepo)
+	workspaceController := controllers.NewWorkspaceController(workspaceService)
+	webhookService := services.NewWebhookService(webhookRepo, workspaceRepo, webhookDispatcher)
+	webhookController := controllers.NewWebhookController(webhookService)
+
+	// Initialize external identity provider layers
+	oidcProviders, err := oidcProvidersFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid OIDC provider configuration", "error", err)
+		os.Exit(1)
+	}
+	identityRepo := repositories.NewPostgresIdentityRepository(dbConn)
+	oidcService := services.NewOIDCService(identityRepo, authRepo, keys, oidcProviders)
+	oidcController := controllers.NewOIDCController(oidcService, os.Getenv("OIDC_FRONTEND_CALLBACK_URL"))
+
+	// Initialize OAuth authorization server layers
+	oauthRepo := repositories.NewPostgresOAuthRepository(dbConn)
+	oauthService := services.NewOAuthService(oauthRepo, keys)
+	oauthController := controllers.NewOAuthController(oauthService)
+
+	// Initialize admin layers
+	adminRepo := repositories.NewPostgresAdminRepository(dbConn)
+	adminService := services.NewAdminService(adminRepo)
+	adminController := controllers.NewAdminController(adminService)
+
+	wellKnownController := controllers.NewWellKnownController(keys)
+
+	// Start the reminder scheduler. Every replica runs one; leases keep them
+	// from firing the same reminder.
+	reminderConfig, err := reminderSchedulerConfigFromEnv()
+	if err != nil {
+		logging.ContextLogger(context.Background()).Error("Invalid reminder scheduler configuration", "error", err)
+		os.Exit(1)
+	}
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go services.NewReminderScheduler(reminderRepo, notifier, reminderConfig).Run(schedulerCtx)
+
+	// Wake snoozed tasks once their time has come.
+	snoozeInterval := services.DefaultSnoozeWakeInterval
+	if v := os.Getenv("SNOOZE_POLL_INTERVAL"); v != "" {
+		snoozeInterval, err = time.ParseDuration(v)
+		if err != nil || snoozeInterval <= 0 {
+			logging.ContextLogger(context.Background()).Error("Invalid SNOOZE_POLL_INTERVAL", "value", v)
+			os.Exit(1)
+		}
+	}
+	go services.NewSnoozeWaker(taskRepo, snoozeInterval).Run(schedulerCtx)
+
+	// Apply imports too large to run within their request. Leases keep
+	// replicas from applying the same import twice.
+	go importRunner.Run(schedulerCtx)
+
+	// Send queued webhook deliveries. Leases keep replicas from sending the
+	// same delivery twice at once.
+	go webhookDispatcher.Run(schedulerCtx)
+
+	// Relay domain events from the outbox. A broker sink, such as
+	// services.NewBrokerSink("nats", broker, "todo"), is added to this list.
+	go services.NewOutboxRelay(outboxRepo, outboxConfig, eventBus, services.NewWebhookSink(webhookRepo), streamService).Run(schedulerCtx)
+
+	// Listen for the events announced to the event stream.
+	go streamService.Run(schedulerCtx)
+
+	// Share presence with the other replicas.
+	go collabService.Run(schedulerCtx)
 
 	// Public routes
+	router.GET("/.well-known/jwks.json", wellKnownController.JWKS)
 	router.POST("/signup", authController.Signup)
 	router.POST("/login", authController.Login)
+	router.POST("/verify-email", authController.VerifyEmail)
+	router.POST("/password/forgot", passwordController.ForgotPassword)
+	router.POST("/password/reset", passwordController.ResetPassword)
+	router.POST("/invitations/preview", invitationController.PreviewInvitation)
+	router.POST("/invitations/decline", invitationController.DeclineInvitation)
+	router.GET("/auth/oidc/providers", oidcController.Providers)
+	router.GET("/auth/oidc/:provider/login", oidcController.Login)
+	router.GET("/auth/oidc/:provider/callback", oidcController.Callback)
+	router.GET("/calendar/:token", calendarController.Feed)
+
+	// OAuth endpoints called by third-party clients
+	router.POST("/oauth/token", oauthController.Token)
+	router.POST("/oauth/introspect", oauthController.Introspect)
+	router.POST("/oauth/revoke", oauthController.Revoke)
 
-	// Protected routes
+	// Protected routes. Tokens issued to third-party clients only reach the
+	// task routes, and only with the matching scope. Disabled users are
+	// rejected on every route. The X-Workspace-ID header scopes lists and
+	// tasks to one workspace.
 	protected := router.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(keys, oauthService, authService), middleware.WorkspaceScope(workspaceService))
 	{
 		// Task routes
-		protected.GET("/tasks", taskController.GetTasks)
-		protected.POST("/tasks", taskController.CreateTask)
-		protected.PUT("/tasks/:id", taskController.UpdateTask)
-		protected.DELETE("/tasks/:id", taskController.DeleteTask)
+		protected.GET("/tasks", middleware.RequireScope(scope.TasksRead), taskController.GetTasks)
+		protected.POST("/tasks", middleware.RequireScope(scope.TasksWrite), taskController.CreateTask)
+		protected.PUT("/tasks/:id", middleware.RequireScope(scope.TasksWrite), taskController.UpdateTask)
+		protected.DELETE("/tasks/:id", middleware.RequireScope(scope.TasksWrite), taskController.DeleteTask)
+		protected.PUT("/tasks/:id/assignee", middleware.RequireScope(scope.TasksWrite), taskController.AssignTask)
+		protected.DELETE("/tasks/:id/assignee", middleware.RequireScope(scope.TasksWrite), taskController.UnassignTask)
+		protected.GET("/tasks/:id/assignments", middleware.RequireScope(scope.TasksRead), taskController.ListAssignments)
+		protected.PUT("/tasks/:id/position", middleware.RequireScope(scope.TasksWrite), taskController.MoveTask)
+		protected.PUT("/tasks/:id/snooze", middleware.RequireScope(scope.TasksWrite), snoozeController.SnoozeTask)
+		protected.DELETE("/tasks/:id/snooze", middleware.RequireScope(scope.TasksWrite), snoozeController.UnsnoozeTask)
+		protected.GET("/tasks/:id/comments", middleware.RequireScope(scope.TasksRead), commentController.ListComments)
+		protected.POST("/tasks/:id/comments", middleware.RequireScope(scope.TasksWrite), commentController.CreateComment)
+		protected.PUT("/tasks/:id/comments/:comment_id", middleware.RequireScope(scope.TasksWrite), commentController.UpdateComment)
+		protected.DELETE("/tasks/:id/comments/:comment_id", middleware.RequireScope(scope.TasksWrite), commentController.DeleteComment)
+		protected.GET("/tasks/:id/reminders", middleware.RequireScope(scope.TasksRead), reminderController.ListReminders)
+		protected.POST("/tasks/:id/reminders", middleware.RequireScope(scope.TasksWrite), reminderController.CreateReminder)
+		protected.DELETE("/tasks/:id/reminders/:reminder_id", middleware.RequireScope(scope.TasksWrite), reminderController.DeleteReminder)
+
+		// Delta sync for offline clients
+		protected.GET("/sync", middleware.RequireScope(scope.TasksRead), syncController.Changes)
+		protected.POST("/sync", middleware.RequireScope(scope.TasksWrite), syncController.Apply)
+
+		// Task export and import
+		protected.GET("/export", middleware.RequireScope(scope.TasksRead), transferController.Export)
+		protected.POST("/import", middleware.RequireScope(scope.TasksWrite), transferController.Import)
+		protected.GET("/import/jobs/:id", middleware.RequireScope(scope.TasksRead), transferController.GetImportJob)
+
+		// Real-time task events
+		protected.GET("/stream", middleware.RequireScope(scope.TasksRead), streamController.Stream)
+		protected.GET("/ws", middleware.RequireScope(scope.TasksRead), collabController.Connect)
+
+		// Task list routes
+		protected.GET("/lists", middleware.RequireScope(scope.TasksRead), taskListController.ListLists)
+		protected.GET("/lists/:id", middleware.RequireScope(scope.TasksRead), taskListController.GetList)
+		protected.GET("/lists/:id/members", middleware.RequireScope(scope.MembersRead), taskListController.ListMembers)
+
+		// Workspace routes
+		protected.GET("/workspaces", middleware.RequireScope(scope.TasksRead), workspaceController.ListWorkspaces)
+		protected.GET("/workspaces/:id", middleware.RequireScope(scope.TasksRead), workspaceController.GetWorkspace)
+		protected.GET("/workspaces/:id/members", middleware.RequireScope(scope.MembersRead), workspaceController.ListMembers)
+	}
+
+	firstParty := protected.Group("")
+	firstParty.Use(middleware.FirstPartyOnly())
+	{
+		// Account routes
+		firstParty.POST("/account/password", passwordController.ChangePassword)
+		firstParty.DELETE("/account/password", oidcController.DisablePasswordLogin)
+		firstParty.POST("/account/email/verification", authController.ResendVerification)
+		firstParty.PUT("/account/time-zone", authController.SetTimeZone)
+		firstParty.GET("/account/calendar-feed", calendarController.GetFeed)
+		firstParty.POST("/account/calendar-feed", calendarController.CreateFeed)
+		firstParty.DELETE("/account/calendar-feed", calendarController.DeleteFeed)
+		firstParty.GET("/account/identities", oidcController.ListIdentities)
+		firstParty.POST("/account/identities/:provider", oidcController.StartLink)
+		firstParty.DELETE("/account/identities/:provider", oidcController.UnlinkIdentity)
+
+		// Task list management and sharing
+		firstParty.POST("/lists", taskListController.CreateList)
+		firstParty.PUT("/lists/:id", taskListController.RenameList)
+		firstParty.DELETE("/lists/:id", taskListController.DeleteList)
+		firstParty.POST("/lists/:id/members", taskListController.AddMember)
+		firstParty.PUT("/lists/:id/members/:user_id", taskListController.UpdateMember)
+		firstParty.DELETE("/lists/:id/members/:user_id", taskListController.RemoveMember)
+		firstParty.POST("/lists/:id/invitations", invitationController.CreateInvitation)
+		firstParty.GET("/lists/:id/invitations", invitationController.ListInvitations)
+		firstParty.DELETE("/lists/:id/invitations/:invitation_id", invitationController.RevokeInvitation)
+		firstParty.POST("/invitations/accept", invitationController.AcceptInvitation)
+
+		// Workspace management
+		firstParty.POST("/workspaces", workspaceController.CreateWorkspace)
+		firstParty.PUT("/workspaces/:id", workspaceController.RenameWorkspace)
+		firstParty.DELETE("/workspaces/:id", workspaceController.DeleteWorkspace)
+		firstParty.POST("/workspaces/:id/members", workspaceController.AddMember)
+		firstParty.PUT("/workspaces/:id/members/:user_id", workspaceController.UpdateMember)
+		firstParty.DELETE("/workspaces/:id/members/:user_id", workspaceController.RemoveMember)
+
+		// Notification center
+		firstParty.GET("/notifications", notificationController.ListNotifications)
+		firstParty.GET("/notifications/unread-count", notificationController.UnreadCount)
+		firstParty.POST("/notifications/read-all", notificationController.MarkAllRead)
+		firstParty.POST("/notifications/:id/read", notificationController.MarkRead)
+		firstParty.GET("/notifications/preferences", notificationController.GetPreferences)
+		firstParty.PUT("/notifications/preferences", notificationController.UpdatePreferences)
+
+		// Webhooks of the current workspace
+		firstParty.GET("/webhooks", webhookController.ListWebhooks)
+		firstParty.POST("/webhooks", webhookController.CreateWebhook)
+		firstParty.GET("/webhooks/:id", webhookController.GetWebhook)
+		firstParty.PUT("/webhooks/:id", webhookController.UpdateWebhook)
+		firstParty.DELETE("/webhooks/:id", webhookController.DeleteWebhook)
+		firstParty.GET("/webhooks/:id/deliveries", webhookController.ListDeliveries)
+		firstParty.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookController.Redeliver)
+		firstParty.POST("/webhooks/:id/test", webhookController.SendTestEvent)
+
+		// OAuth client management and consent
+		firstParty.POST("/oauth/clients", oauthController.RegisterClient)
+		firstParty.GET("/oauth/clients", oauthController.ListClients)
+		firstParty.DELETE("/oauth/clients/:id", oauthController.DeleteClient)
+		firstParty.GET("/oauth/authorize", oauthController.PrepareConsent)
+		firstParty.POST("/oauth/authorize", oauthController.Authorize)
+		firstParty.GET("/oauth/consents", oauthController.ListConsents)
+		firstParty.DELETE("/oauth/consents/:client_id", oauthController.RevokeConsent)
+	}
+
+	// Admin routes, each guarded by the permission it needs
+	admin := firstParty.Group("/admin")
+	{
+		admin.GET("/users", middleware.RequirePermission(rbac.UsersRead), adminController.ListUsers)
+		admin.GET("/users/:id", middleware.RequirePermission(rbac.UsersRead), adminController.GetUser)
+		admin.POST("/users/:id/disable", middleware.RequirePermission(rbac.UsersManage), adminController.DisableUser)
+		admin.POST("/users/:id/enable", middleware.RequirePermission(rbac.UsersManage), adminController.EnableUser)
+		admin.PUT("/users/:id/role", middleware.RequirePermission(rbac.UsersManage), adminController.SetUserRole)
+		admin.DELETE("/users/:id", middleware.RequirePermission(rbac.UsersManage), adminController.DeleteUser)
+		admin.GET("/roles", middleware.RequirePermission(rbac.RolesManage), adminController.ListRoles)
+		admin.POST("/roles", middleware.RequirePermission(rbac.RolesManage), adminController.CreateRole)
+		admin.PUT("/roles/:name", middleware.RequirePermission(rbac.RolesManage), adminController.UpdateRole)
+		admin.DELETE("/roles/:name", middleware.RequirePermission(rbac.RolesManage), adminController.DeleteRole)
+		admin.GET("/stats", middleware.RequirePermission(rbac.StatsRead), adminController.Stats)
 	}
 
 	logging.ContextLogger(context.Background()).Info("Backend Service starting on port 8080")
@@ -109,3 +491,383 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// unverifiedPolicyFromEnv reads the login policy for accounts with an
+// unverified email address from UNVERIFIED_LOGIN_POLICY ("allow", "grace" or
+// "deny") and UNVERIFIED_GRACE_PERIOD (a Go duration, default 72h).
+func unverifiedPolicyFromEnv() (services.UnverifiedPolicy, error) {
+	policy := services.UnverifiedPolicy{
+		Mode:        services.UnverifiedLoginMode(os.Getenv("UNVERIFIED_LOGIN_POLICY")),
+		GracePeriod: 72 * time.Hour,
+	}
+	switch policy.Mode {
+	case "":
+		policy.Mode = services.UnverifiedLoginAllow
+	case services.UnverifiedLoginAllow, services.UnverifiedLoginGrace, services.UnverifiedLoginDeny:
+	default:
+		return policy, fmt.Errorf("unknown policy %q", policy.Mode)
+	}
+
+	if v := os.Getenv("UNVERIFIED_GRACE_PERIOD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid UNVERIFIED_GRACE_PERIOD: %w", err)
+		}
+		policy.GracePeriod = d
+	}
+
+	return policy, nil
+}
+
+// loginGuardConfigFromEnv starts from the default brute-force protection
+// settings and applies LOGIN_LOCKOUT_THRESHOLD, LOGIN_IP_LOCKOUT_THRESHOLD,
+// LOGIN_LOCKOUT_DURATION and LOGIN_CAPTCHA_THRESHOLD when set.
+func loginGuardConfigFromEnv() (services.LoginGuardConfig, error) {
+	cfg := services.DefaultLoginGuardConfig()
+
+	ints := map[string]*int{
+		"LOGIN_LOCKOUT_THRESHOLD":    &cfg.UserLockoutThreshold,
+		"LOGIN_IP_LOCKOUT_THRESHOLD": &cfg.IPLockoutThreshold,
+		"LOGIN_CAPTCHA_THRESHOLD":    &cfg.CaptchaThreshold,
+	}
+	for name, dst := range ints {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return cfg, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = n
+		}
+	}
+
+	if v := os.Getenv("LOGIN_LOCKOUT_DURATION"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid LOGIN_LOCKOUT_DURATION: %w", err)
+		}
+		cfg.LockoutDuration = d
+	}
+
+	return cfg, nil
+}
+
+// notificationChannelsFromEnv builds the delivery channels named in
+// NOTIFICATION_CHANNELS, a comma-separated list of "in_app", "email", "log"
+// and "webhook" that defaults to "in_app,email". The webhook channel posts to
+// NOTIFICATION_WEBHOOK_URL.
+func notificationChannelsFromEnv(repo repositories.NotificationRepository, users repositories.AuthRepository, mail mailer.Mailer) ([]services.NotificationChannel, error) {
+	names := os.Getenv("NOTIFICATION_CHANNELS")
+	if names == "" {
+		names = "in_app,email"
+	}
+	var channels []services.NotificationChannel
+	for _, name := range strings.Split(names, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "in_app":
+			channels = append(channels, services.NewInAppChannel(repo))
+		case "email":
+			channels = append(channels, services.NewMailChannel(users, mail))
+		case "log":
+			channels = append(channels, services.NewLogChannel())
+		case "webhook":
+			url := os.Getenv("NOTIFICATION_WEBHOOK_URL")
+			if url == "" {
+				return nil, errors.New("NOTIFICATION_WEBHOOK_URL is required for the webhook channel")
+			}
+			channels = append(channels, services.NewWebhookChannel(url))
+		default:
+			return nil, fmt.Errorf("unknown notification channel %q", name)
+		}
+	}
+	return channels, nil
+}
+
+// reminderSchedulerConfigFromEnv starts from the default scheduler settings
+// and applies REMINDER_POLL_INTERVAL, REMINDER_LEASE and REMINDER_BATCH_SIZE
+// when set.
+func reminderSchedulerConfigFromEnv() (services.ReminderSchedulerConfig, error) {
+	cfg := services.DefaultReminderSchedulerConfig()
+
+	durations := map[string]*time.Duration{
+		"REMINDER_POLL_INTERVAL": &cfg.Interval,
+		"REMINDER_LEASE":         &cfg.Lease,
+	}
+	for name, dst := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = d
+		}
+	}
+	if v := os.Getenv("REMINDER_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid REMINDER_BATCH_SIZE: %q", v)
+		}
+		cfg.BatchSize = n
+	}
+
+	return cfg, nil
+}
+
+// webhookDispatcherConfigFromEnv starts from the default webhook dispatcher
+// configuration and applies WEBHOOK_POLL_INTERVAL, WEBHOOK_TIMEOUT,
+// WEBHOOK_MAX_ATTEMPTS and WEBHOOK_ALLOWED_HOSTS when set.
+func webhookDispatcherConfigFromEnv() (services.WebhookDispatcherConfig, error) {
+	cfg := services.DefaultWebhookDispatcherConfig()
+
+	durations := map[string]*time.Duration{
+		"WEBHOOK_POLL_INTERVAL": &cfg.Interval,
+		"WEBHOOK_TIMEOUT":       &cfg.Timeout,
+	}
+	for name, dst := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = d
+		}
+	}
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %q", v)
+		}
+		cfg.MaxAttempts = n
+	}
+	if v := os.Getenv("WEBHOOK_ALLOWED_HOSTS"); v != "" {
+		cfg.AllowedHosts = strings.Split(v, ",")
+	}
+	// The lease must outlast a batch of timed out deliveries.
+	if batch := time.Duration(cfg.BatchSize) * cfg.Timeout; cfg.Lease <= batch {
+		cfg.Lease = 2 * batch
+	}
+
+	return cfg, nil
+}
+
+// outboxRelayConfigFromEnv starts from the default outbox relay
+// configuration and applies OUTBOX_POLL_INTERVAL, OUTBOX_RETENTION and
+// OUTBOX_BATCH_SIZE when set.
+func outboxRelayConfigFromEnv() (services.OutboxRelayConfig, error) {
+	cfg := services.DefaultOutboxRelayConfig()
+
+	durations := map[string]*time.Duration{
+		"OUTBOX_POLL_INTERVAL": &cfg.Interval,
+		"OUTBOX_RETENTION":     &cfg.Retention,
+	}
+	for name, dst := range durations {
+		if v := os.Getenv(name); v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return cfg, fmt.Errorf("invalid %s: %q", name, v)
+			}
+			*dst = d
+		}
+	}
+	if v := os.Getenv("OUTBOX_BATCH_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid OUTBOX_BATCH_SIZE: %q", v)
+		}
+		cfg.BatchSize = n
+	}
+
+	return cfg, nil
+}
+
+// streamConfigFromEnv starts from the default event stream configuration and
+// applies STREAM_HEARTBEAT and STREAM_REPLAY_SIZE when set.
+func streamConfigFromEnv() (services.StreamConfig, error) {
+	cfg := services.DefaultStreamConfig()
+
+	if v := os.Getenv("STREAM_HEARTBEAT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid STREAM_HEARTBEAT: %q", v)
+		}
+		cfg.Heartbeat = d
+	}
+	if v := os.Getenv("STREAM_REPLAY_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid STREAM_REPLAY_SIZE: %q", v)
+		}
+		cfg.ReplaySize = n
+	}
+
+	return cfg, nil
+}
+
+// collabConfigFromEnv starts from the default collaboration configuration
+// and applies COLLAB_MAX_CONNECTIONS when set.
+func collabConfigFromEnv() (services.CollabConfig, error) {
+	cfg := services.DefaultCollabConfig()
+
+	if v := os.Getenv("COLLAB_MAX_CONNECTIONS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid COLLAB_MAX_CONNECTIONS: %q", v)
+		}
+		cfg.MaxPerUser = n
+	}
+
+	return cfg, nil
+}
+
+// passwordPolicyFromEnv starts from the default password policy and applies
+// PASSWORD_MIN_LENGTH, PASSWORD_REQUIRE_LOWERCASE, PASSWORD_REQUIRE_UPPERCASE,
+// PASSWORD_REQUIRE_DIGIT, PASSWORD_REQUIRE_SYMBOL and
+// PASSWORD_DISALLOW_USERNAME when set. PASSWORD_BREACHED_LIST points to a
+// local Have I Been Pwned range file or directory.
+func passwordPolicyFromEnv() (password.Policy, error) {
+	policy := password.DefaultPolicy()
+
+	if v := os.Getenv("PASSWORD_MIN_LENGTH"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return policy, fmt.Errorf("invalid PASSWORD_MIN_LENGTH: %w", err)
+		}
+		policy.MinLength = n
+	}
+
+	bools := map[string]*bool{
+		"PASSWORD_REQUIRE_LOWERCASE": &policy.RequireLowercase,
+		"PASSWORD_REQUIRE_UPPERCASE": &policy.RequireUppercase,
+		"PASSWORD_REQUIRE_DIGIT":     &policy.RequireDigit,
+		"PASSWORD_REQUIRE_SYMBOL":    &policy.RequireSymbol,
+		"PASSWORD_DISALLOW_USERNAME": &policy.DisallowUsername,
+	}
+	for name, dst := range bools {
+		if v := os.Getenv(name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return policy, fmt.Errorf("invalid %s: %w", name, err)
+			}
+			*dst = b
+		}
+	}
+
+	// bcrypt only looks at the first 72 bytes of a password.
+	if os.Getenv("PASSWORD_HASH_ALGORITHM") == "bcrypt" && policy.MaxLength > 72 {
+		policy.MaxLength = 72
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		checker, err := password.NewRangeFileChecker(path)
+		if err != nil {
+			return policy, err
+		}
+		policy.Breached = checker
+	}
+
+	return policy, nil
+}
+
+// passwordHasherFromEnv builds the password hasher. PASSWORD_HASH_ALGORITHM
+// selects "argon2id" (the default) or "bcrypt" for new hashes; the other
+// algorithm is still accepted for verification so existing hashes keep
+// working and are upgraded on login. ARGON2_MEMORY (KiB), ARGON2_ITERATIONS,
+// ARGON2_PARALLELISM and BCRYPT_COST override the cost parameters. Every
+// login hashes a password, so ARGON2_MEMORY is limited to 8 MiB to 1 GiB and
+// ARGON2_ITERATIONS to 1 to 100; ARGON2_PARALLELISM must be 1 to 255.
+func passwordHasherFromEnv() (password.PasswordHasher, error) {
+	params := password.DefaultArgon2Params()
+	uints := map[string]struct {
+		min, max uint64
+		set      func(uint64)
+	}{
+		"ARGON2_MEMORY":      {8 * 1024, 1024 * 1024, func(n uint64) { params.Memory = uint32(n) }},
+		"ARGON2_ITERATIONS":  {1, 100, func(n uint64) { params.Iterations = uint32(n) }},
+		"ARGON2_PARALLELISM": {1, math.MaxUint8, func(n uint64) { params.Parallelism = uint8(n) }},
+	}
+	for name, limits := range uints {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err != nil || n < limits.min || n > limits.max {
+				return nil, fmt.Errorf("invalid %s: %q, must be between %d and %d", name, v, limits.min, limits.max)
+			}
+			limits.set(n)
+		}
+	}
+
+	cost := bcrypt.DefaultCost
+	if v := os.Getenv("BCRYPT_COST"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < bcrypt.MinCost || n > bcrypt.MaxCost {
+			return nil, fmt.Errorf("invalid BCRYPT_COST: %q", v)
+		}
+		cost = n
+	}
+
+	argon := password.NewArgon2idHasher(params)
+	bc := password.NewBcryptHasher(cost)
+	switch algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM"); algorithm {
+	case "", "argon2id":
+		return password.NewMultiHasher(argon, bc), nil
+	case "bcrypt":
+		return password.NewMultiHasher(bc, argon), nil
+	default:
+		return nil, fmt.Errorf("unknown PASSWORD_HASH_ALGORITHM %q", algorithm)
+	}
+}
+
+// signingKeysFromEnv loads the token signing keys from the keyset manifest at
+// JWT_KEYSET_FILE. Only when JWT_EPHEMERAL_KEY is "true" and no keyset is
+// configured is a throwaway key generated, which is meant for local
+// development. JWT_ISSUER sets the "iss" claim (default "todo-app").
+func signingKeysFromEnv() (*jwtkeys.Manager, error) {
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		issuer = "todo-app"
+	}
+
+	if path := os.Getenv("JWT_KEYSET_FILE"); path != "" {
+		return jwtkeys.LoadKeyset(path, issuer)
+	}
+
+	if os.Getenv("JWT_EPHEMERAL_KEY") == "true" {
+		logging.ContextLogger(context.Background()).Warn("Using an ephemeral token signing key; tokens will not survive a restart")
+		return jwtkeys.NewEphemeralManager(issuer)
+	}
+
+	return nil, errors.New("JWT_KEYSET_FILE is not set")
+}
+
+// oidcProvidersFromEnv reads the external identity providers named in the
+// comma-separated OIDC_PROVIDERS list. Each provider NAME is configured with
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET
+// (optional for public clients), OIDC_<NAME>_REDIRECT_URL and optionally
+// OIDC_<NAME>_SCOPES (space-separated, default "openid email profile").
+func oidcProvidersFromEnv() ([]*oidc.Provider, error) {
+	var providers []*oidc.Provider
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		for _, r := range name {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return nil, fmt.Errorf("invalid provider name %q", name)
+			}
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := oidc.Config{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			return nil, fmt.Errorf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL are required", prefix, prefix, prefix)
+		}
+		providers = append(providers, oidc.NewProvider(cfg, nil))
+	}
+	return providers, nil
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type AdminController struct {
+	service services.AdminServiceInterface
+}
+
+func NewAdminController(service services.AdminServiceInterface) *AdminController {
+	return &AdminController{service: service}
+}
+
+// adminErrorResponse maps an admin service error to a status code and body.
+func adminErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrRoleNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrInvalidRole):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrCannotModifySelf), errors.Is(err, services.ErrBuiltinRole):
+		return http.StatusForbidden, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrRoleExists), errors.Is(err, services.ErrRoleInUse):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": fallback}
+	}
+}
+
+// ListUsers returns one page of users, filtered by the "q" (username or
+// email substring) and "role" query parameters.
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.ListUsers")
+	defer span.End()
+
+	var query models.UserListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := ac.service.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+func (ac *AdminController) GetUser(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.GetUser")
+	defer span.End()
+
+	id, ok := pathID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	user, err := ac.service.GetUser(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to get user"))
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (ac *AdminController) DisableUser(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.DisableUser")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	id, ok := pathID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := ac.service.DisableUser(c.Request.Context(), uint(userID.(int)), id); err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to disable user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User disabled successfully"})
+}
+
+func (ac *AdminController) EnableUser(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.EnableUser")
+	defer span.End()
+
+	id, ok := pathID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := ac.service.EnableUser(c.Request.Context(), id); err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to enable user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User enabled successfully"})
+}
+
+func (ac *AdminController) DeleteUser(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.DeleteUser")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	id, ok := pathID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	if err := ac.service.DeleteUser(c.Request.Context(), uint(userID.(int)), id); err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to delete user"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+func (ac *AdminController) SetUserRole(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.SetUserRole")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	id, ok := pathID(c, "id", "user")
+	if !ok {
+		return
+	}
+
+	var req models.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.SetUserRole(c.Request.Context(), uint(userID.(int)), id, req.Role); err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to change role"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role changed successfully"})
+}
+
+func (ac *AdminController) ListRoles(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.ListRoles")
+	defer span.End()
+
+	roles, err := ac.service.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list roles"})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (ac *AdminController) CreateRole(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.CreateRole")
+	defer span.End()
+
+	var req models.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := ac.service.CreateRole(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to create role"))
+		return
+	}
+	c.JSON(http.StatusCreated, role)
+}
+
+func (ac *AdminController) UpdateRole(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.UpdateRole")
+	defer span.End()
+
+	var req models.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := ac.service.UpdateRole(c.Request.Context(), c.Param("name"), req)
+	if err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to update role"))
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+func (ac *AdminController) DeleteRole(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.DeleteRole")
+	defer span.End()
+
+	if err := ac.service.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		c.JSON(adminErrorResponse(err, "Failed to delete role"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
+
+func (ac *AdminController) Stats(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AdminController.Stats")
+	defer span.End()
+
+	stats, err := ac.service.UsageStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load usage statistics"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockAdminService is a mock implementation of the AdminServiceInterface
+type MockAdminService struct {
+	mock.Mock
+}
+
+var _ services.AdminServiceInterface = (*MockAdminService)(nil)
+
+func (m *MockAdminService) ListUsers(ctx context.Context, query models.UserListQuery) (*models.UserList, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserList), args.Error(1)
+}
+
+func (m *MockAdminService) GetUser(ctx context.Context, id int) (*models.AdminUser, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AdminUser), args.Error(1)
+}
+
+func (m *MockAdminService) DisableUser(ctx context.Context, actorID uint, id int) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) EnableUser(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) DeleteUser(ctx context.Context, actorID uint, id int) error {
+	args := m.Called(ctx, actorID, id)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) SetUserRole(ctx context.Context, actorID uint, id int, role string) error {
+	args := m.Called(ctx, actorID, id, role)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockAdminService) CreateRole(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockAdminService) UpdateRole(ctx context.Context, name string, req models.UpdateRoleRequest) (*models.Role, error) {
+	args := m.Called(ctx, name, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Role), args.Error(1)
+}
+
+func (m *MockAdminService) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockAdminService) UsageStats(ctx context.Context) (*models.UsageStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsageStats), args.Error(1)
+}
+
+func newAdminContext(method, target string, body any) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	c.Request, _ = http.NewRequest(method, target, &buf)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", 1)
+	return c, w
+}
+
+func TestAdminController_ListUsers(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/admin/users?q=bob&limit=10&offset=20", nil)
+
+	mockService.On("ListUsers", mock.Anything, models.UserListQuery{Search: "bob", Limit: 10, Offset: 20}).
+		Return(&models.UserList{Users: []models.AdminUser{{ID: 2, Username: "bob", Role: "user"}}, Total: 21, Limit: 10, Offset: 20}, nil)
+
+	adminController.ListUsers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list models.UserList
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 21, list.Total)
+	assert.Equal(t, "bob", list.Users[0].Username)
+}
+
+func TestAdminController_DisableUser(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/admin/users/2/disable", nil)
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+	mockService.On("DisableUser", mock.Anything, uint(1), 2).Return(nil)
+
+	adminController.DisableUser(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminController_DisableUser_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"self", services.ErrCannotModifySelf, http.StatusForbidden},
+		{"not found", services.ErrUserNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockAdminService)
+			adminController := NewAdminController(mockService)
+			c, w := newAdminContext(http.MethodPost, "/api/admin/users/2/disable", nil)
+			c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+			mockService.On("DisableUser", mock.Anything, uint(1), 2).Return(tt.err)
+
+			adminController.DisableUser(c)
+
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAdminController_DeleteUser_InvalidID(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodDelete, "/api/admin/users/abc", nil)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	adminController.DeleteUser(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminController_SetUserRole(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodPut, "/api/admin/users/2/role", models.SetRoleRequest{Role: "support"})
+	c.Params = gin.Params{{Key: "id", Value: "2"}}
+
+	mockService.On("SetUserRole", mock.Anything, uint(1), 2, "support").Return(services.ErrRoleNotFound)
+
+	adminController.SetUserRole(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAdminController_CreateRole(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	req := models.CreateRoleRequest{Name: "support", Permissions: []string{"users:read"}}
+	c, w := newAdminContext(http.MethodPost, "/api/admin/roles", req)
+
+	mockService.On("CreateRole", mock.Anything, req).Return(&models.Role{Name: "support", Permissions: req.Permissions}, nil)
+
+	adminController.CreateRole(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestAdminController_DeleteRole_Builtin(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodDelete, "/api/admin/roles/admin", nil)
+	c.Params = gin.Params{{Key: "name", Value: "admin"}}
+
+	mockService.On("DeleteRole", mock.Anything, "admin").Return(services.ErrBuiltinRole)
+
+	adminController.DeleteRole(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAdminController_Stats(t *testing.T) {
+	mockService := new(MockAdminService)
+	adminController := NewAdminController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/admin/stats", nil)
+
+	mockService.On("UsageStats", mock.Anything).Return(&models.UsageStats{Users: 3, Tasks: 10, CompletedTasks: 4}, nil)
+
+	adminController.Stats(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"completed_tasks":4`)
+}
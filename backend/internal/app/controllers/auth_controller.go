@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel"
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 )
@@ -19,7 +23,8 @@ func NewAuthController(service services.AuthServiceInterface) *AuthController {
 	return &AuthController{service: service}
 }
 
-// Login handles user login and returns a JWT token.
+// Login handles user login and returns a JWT token. The username field
+// accepts either a username or an email address.
 func (ac *AuthController) Login(c *gin.Context) {
 	_, span := otel.Tracer("").Start(c.Request.Context(), "AuthController.Login")
 	defer span.End()
@@ -31,31 +36,159 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := ac.service.Login(c.Request.Context(), user.Username, user.Password)
+	token, err := ac.service.Login(c.Request.Context(), user.Username, user.Password, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		respondLoginError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"token": token})
 }
 
+// respondLoginError maps a failed login to a response. Throttled and locked
+// logins carry a Retry-After header, and any login error may signal that the
+// client should present a CAPTCHA.
+func respondLoginError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrEmailNotVerified) || errors.Is(err, services.ErrAccountDisabled) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	var loginErr *services.LoginError
+	if !errors.As(err, &loginErr) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := gin.H{"error": loginErr.Error()}
+	if loginErr.CaptchaRequired {
+		body["captcha_required"] = true
+	}
+	if loginErr.RetryAfter > 0 {
+		seconds := int(math.Ceil(loginErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		body["retry_after"] = seconds
+	}
+
+	switch {
+	case errors.Is(err, services.ErrAccountLocked):
+		c.JSON(http.StatusLocked, body)
+	case errors.Is(err, services.ErrTooManyAttempts):
+		c.JSON(http.StatusTooManyRequests, body)
+	default:
+		c.JSON(http.StatusUnauthorized, body)
+	}
+}
+
 // Signup handles user registration.
 func (ac *AuthController) Signup(c *gin.Context) {
 	_, span := otel.Tracer("").Start(c.Request.Context(), "AuthController.Signup")
 	defer span.End()
 
 	utils.RandomSleep()
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var req models.SignupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := ac.service.Signup(c.Request.Context(), user.Username, user.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	list, err := ac.service.Signup(c.Request.Context(), req.Username, req.Email, req.Password, req.InvitationToken)
+	if err != nil {
+		if respondPolicyViolation(c, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidUsername), errors.Is(err, services.ErrInvalidInvitation):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, repositories.ErrUsernameTaken), errors.Is(err, repositories.ErrEmailTaken):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
+	// With an invitation the response names the joined list so the client
+	// can open it right after login.
+	if list != nil {
+		c.JSON(http.StatusCreated, gin.H{"message": "User created successfully", "list": list})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{"message": "User created successfully"})
 }
+
+// VerifyEmail confirms an email address using the token from a verification
+// link.
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AuthController.VerifyEmail")
+	defer span.End()
+
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+// ResendVerification sends a new verification link to the authenticated user.
+func (ac *AuthController) ResendVerification(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AuthController.ResendVerification")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := ac.service.ResendVerification(c.Request.Context(), uint(userID.(int))); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// SetTimeZone sets the time zone of the authenticated user.
+func (ac *AuthController) SetTimeZone(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "AuthController.SetTimeZone")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.SetTimeZoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.SetTimeZone(c.Request.Context(), uint(userID.(int)), req.TimeZone); err != nil {
+		if errors.Is(err, services.ErrInvalidTimeZone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set time zone"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"time_zone": req.TimeZone})
+}
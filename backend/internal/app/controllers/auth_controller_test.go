@@ -8,13 +8,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
 )
 
 // MockAuthService is a mock implementation of the AuthServiceInterface
@@ -25,13 +28,39 @@ type MockAuthService struct {
 // Statically assert that MockAuthService implements the interface.
 var _ services.AuthServiceInterface = (*MockAuthService)(nil)
 
-func (m *MockAuthService) Login(ctx context.Context, username, password string) (string, error) {
-	args := m.Called(ctx, username, password)
+func (m *MockAuthService) Login(ctx context.Context, username, password, clientIP string) (string, error) {
+	args := m.Called(ctx, username, password, clientIP)
 	return args.Get(0).(string), args.Error(1)
 }
 
-func (m *MockAuthService) Signup(ctx context.Context, username, password string) error {
-	args := m.Called(ctx, username, password)
+func (m *MockAuthService) Signup(ctx context.Context, username, email, password, invitationToken string) (*models.TaskList, error) {
+	args := m.Called(ctx, username, email, password, invitationToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResendVerification(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) AccountStatus(ctx context.Context, userID int) (*rbac.Account, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*rbac.Account), args.Error(1)
+}
+
+func (m *MockAuthService) SetTimeZone(ctx context.Context, userID uint, timeZone string) error {
+	args := m.Called(ctx, userID, timeZone)
 	return args.Error(0)
 }
 
@@ -43,12 +72,12 @@ func TestAuthController_Signup(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 
-	user := models.User{Username: "testuser", Password: "password123"}
+	user := models.SignupRequest{Username: "testuser", Email: "test@example.com", Password: "password123"}
 	jsonValue, _ := json.Marshal(user)
 	c.Request, _ = http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(jsonValue))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	mockService.On("Signup", mock.Anything, user.Username, user.Password).Return(nil)
+	mockService.On("Signup", mock.Anything, user.Username, user.Email, user.Password, "").Return(nil, nil)
 
 	authController.Signup(c)
 
@@ -56,6 +85,28 @@ func TestAuthController_Signup(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestAuthController_Signup_WithInvitation(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.SignupRequest{Username: "testuser", Email: "test@example.com", Password: "password123", InvitationToken: "tok"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Signup", mock.Anything, user.Username, user.Email, user.Password, "tok").
+		Return(&models.TaskList{ID: 3, Name: "Groceries", Role: models.ListRoleEditor}, nil)
+
+	authController.Signup(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"list":{"id":3`)
+}
+
 func TestAuthController_Signup_Error(t *testing.T) {
 	mockService := new(MockAuthService)
 	authController := NewAuthController(mockService)
@@ -64,12 +115,12 @@ func TestAuthController_Signup_Error(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 
-	user := models.User{Username: "testuser", Password: "password123"}
+	user := models.SignupRequest{Username: "testuser", Email: "test@example.com", Password: "password123"}
 	jsonValue, _ := json.Marshal(user)
 	c.Request, _ = http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(jsonValue))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	mockService.On("Signup", mock.Anything, user.Username, user.Password).Return(errors.New("service error"))
+	mockService.On("Signup", mock.Anything, user.Username, user.Email, user.Password, "").Return(nil, errors.New("service error"))
 
 	authController.Signup(c)
 
@@ -93,7 +144,7 @@ func TestAuthController_Login(t *testing.T) {
 	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	mockService.On("Login", mock.Anything, username, password).Return("dummy_token", nil)
+	mockService.On("Login", mock.Anything, username, password, mock.Anything).Return("dummy_token", nil)
 
 	authController.Login(c)
 
@@ -117,10 +168,185 @@ func TestAuthController_Login_InvalidCredentials(t *testing.T) {
 	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	mockService.On("Login", mock.Anything, username, password).Return("", errors.New("Invalid credentials"))
+	mockService.On("Login", mock.Anything, username, password, mock.Anything).Return("", errors.New("Invalid credentials"))
+
+	authController.Login(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_Signup_InvalidEmail(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.SignupRequest{Username: "testuser", Email: "not-an-email", Password: "password123"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	authController.Signup(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "Signup", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthController_Signup_EmailTaken(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.SignupRequest{Username: "testuser", Email: "Test@Example.com", Password: "password123"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/signup", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Signup", mock.Anything, user.Username, user.Email, user.Password, "").Return(nil, repositories.ErrEmailTaken)
+
+	authController.Signup(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_Login_EmailNotVerified(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.User{Username: "test@example.com", Password: "password123"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Login", mock.Anything, user.Username, user.Password, mock.Anything).Return("", services.ErrEmailNotVerified)
+
+	authController.Login(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_Login_AccountDisabled(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.User{Username: "testuser", Password: "password123"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Login", mock.Anything, user.Username, user.Password, mock.Anything).Return("", services.ErrAccountDisabled)
+
+	authController.Login(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrAccountDisabled.Error())
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_VerifyEmail(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req := models.VerifyEmailRequest{Token: "token"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/verify-email", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("VerifyEmail", mock.Anything, "token").Return(nil)
+
+	authController.VerifyEmail(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_VerifyEmail_InvalidToken(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req := models.VerifyEmailRequest{Token: "expired"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/verify-email", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("VerifyEmail", mock.Anything, "expired").Return(services.ErrInvalidVerificationToken)
+
+	authController.VerifyEmail(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_Login_Locked(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.User{Username: "testuser", Password: "password123"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.RemoteAddr = "203.0.113.7:1234"
+
+	loginErr := &services.LoginError{Err: services.ErrAccountLocked, RetryAfter: 90 * time.Second, CaptchaRequired: true}
+	mockService.On("Login", mock.Anything, user.Username, user.Password, "203.0.113.7").Return("", loginErr)
+
+	authController.Login(c)
+
+	assert.Equal(t, http.StatusLocked, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, true, body["captcha_required"])
+	mockService.AssertExpectations(t)
+}
+
+func TestAuthController_Login_InvalidCredentialsCaptcha(t *testing.T) {
+	mockService := new(MockAuthService)
+	authController := NewAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	user := models.User{Username: "testuser", Password: "wrongpassword"}
+	jsonValue, _ := json.Marshal(user)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	loginErr := &services.LoginError{Err: services.ErrInvalidCredentials, CaptchaRequired: true}
+	mockService.On("Login", mock.Anything, user.Username, user.Password, mock.Anything).Return("", loginErr)
 
 	authController.Login(c)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), `"captcha_required":true`)
 	mockService.AssertExpectations(t)
-}
\ No newline at end of file
+}
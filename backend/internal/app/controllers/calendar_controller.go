@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+)
+
+type CalendarController struct {
+	service services.CalendarServiceInterface
+}
+
+func NewCalendarController(service services.CalendarServiceInterface) *CalendarController {
+	return &CalendarController{service: service}
+}
+
+func calendarErrorResponse(err error, fallback string) (int, gin.H) {
+	if errors.Is(err, services.ErrCalendarFeedNotFound) {
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	}
+	return http.StatusInternalServerError, gin.H{"error": fallback}
+}
+
+// GetFeed tells whether the user has a calendar feed and since when. The
+// URL is not returned; a new one can be created instead.
+func (cc *CalendarController) GetFeed(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CalendarController.GetFeed")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	feed, err := cc.service.GetFeed(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(calendarErrorResponse(err, "Failed to get calendar feed"))
+		return
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+// CreateFeed creates the user's calendar feed, or replaces its URL, and
+// returns the URL.
+func (cc *CalendarController) CreateFeed(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CalendarController.CreateFeed")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	feed, err := cc.service.CreateFeed(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(calendarErrorResponse(err, "Failed to create calendar feed"))
+		return
+	}
+	c.JSON(http.StatusCreated, feed)
+}
+
+func (cc *CalendarController) DeleteFeed(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CalendarController.DeleteFeed")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := cc.service.DeleteFeed(c.Request.Context(), uint(userID.(int))); err != nil {
+		c.JSON(calendarErrorResponse(err, "Failed to delete calendar feed"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed deleted successfully"})
+}
+
+// Feed serves the calendar feed whose token is in the path, with or
+// without an ".ics" extension. It needs no login, since calendar apps
+// subscribe with the URL alone.
+func (cc *CalendarController) Feed(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CalendarController.Feed")
+	defer span.End()
+
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, no-cache")
+
+	if err := cc.service.WriteFeed(c.Request.Context(), token, c.Writer); err != nil {
+		if c.Writer.Written() {
+			logging.ContextLogger(c.Request.Context()).Error("Calendar feed failed after it started", "error", err)
+			return
+		}
+		c.Header("Content-Type", "")
+		c.JSON(calendarErrorResponse(err, "Failed to read calendar feed"))
+	}
+}
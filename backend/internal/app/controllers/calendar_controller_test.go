@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockCalendarService is a mock implementation of the CalendarServiceInterface
+type MockCalendarService struct {
+	mock.Mock
+}
+
+var _ services.CalendarServiceInterface = (*MockCalendarService)(nil)
+
+func (m *MockCalendarService) GetFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error) {
+	args := m.Called(ctx, userID)
+	feed, _ := args.Get(0).(*models.CalendarFeed)
+	return feed, args.Error(1)
+}
+
+func (m *MockCalendarService) CreateFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error) {
+	args := m.Called(ctx, userID)
+	feed, _ := args.Get(0).(*models.CalendarFeed)
+	return feed, args.Error(1)
+}
+
+func (m *MockCalendarService) DeleteFeed(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// WriteFeed writes the first return value to w.
+func (m *MockCalendarService) WriteFeed(ctx context.Context, token string, w io.Writer) error {
+	args := m.Called(ctx, token)
+	if out := args.String(0); out != "" {
+		_, _ = io.WriteString(w, out)
+	}
+	return args.Error(1)
+}
+
+func TestCalendarController_CreateFeed(t *testing.T) {
+	mockService := new(MockCalendarService)
+	calendarController := NewCalendarController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/account/calendar-feed", nil)
+	mockService.On("CreateFeed", mock.Anything, uint(1)).Return(&models.CalendarFeed{
+		URL:       "https://todo.example.com/calendar/abc.ics",
+		CreatedAt: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+	}, nil)
+
+	calendarController.CreateFeed(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var got models.CalendarFeed
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "https://todo.example.com/calendar/abc.ics", got.URL)
+}
+
+func TestCalendarController_GetFeed(t *testing.T) {
+	mockService := new(MockCalendarService)
+	calendarController := NewCalendarController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/account/calendar-feed", nil)
+	mockService.On("GetFeed", mock.Anything, uint(1)).Return(&models.CalendarFeed{
+		CreatedAt: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+	}, nil)
+
+	calendarController.GetFeed(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"created_at": "2026-03-01T08:00:00Z"}`, w.Body.String())
+
+	mockService = new(MockCalendarService)
+	calendarController = NewCalendarController(mockService)
+	c, w = newAdminContext(http.MethodGet, "/api/account/calendar-feed", nil)
+	mockService.On("GetFeed", mock.Anything, uint(1)).Return(nil, services.ErrCalendarFeedNotFound)
+
+	calendarController.GetFeed(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestCalendarController_DeleteFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"deleted", nil, http.StatusOK},
+		{"not found", services.ErrCalendarFeedNotFound, http.StatusNotFound},
+		{"internal", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCalendarService)
+			calendarController := NewCalendarController(mockService)
+			c, w := newAdminContext(http.MethodDelete, "/api/account/calendar-feed", nil)
+			mockService.On("DeleteFeed", mock.Anything, uint(1)).Return(tt.err)
+
+			calendarController.DeleteFeed(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestCalendarController_Feed(t *testing.T) {
+	mockService := new(MockCalendarService)
+	calendarController := NewCalendarController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/calendar/abc.ics", nil)
+	c.Params = gin.Params{{Key: "token", Value: "abc.ics"}}
+	mockService.On("WriteFeed", mock.Anything, "abc").Return("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", nil)
+
+	calendarController.Feed(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", w.Body.String())
+}
+
+func TestCalendarController_Feed_NotFound(t *testing.T) {
+	mockService := new(MockCalendarService)
+	calendarController := NewCalendarController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/calendar/wrong", nil)
+	c.Params = gin.Params{{Key: "token", Value: "wrong"}}
+	mockService.On("WriteFeed", mock.Anything, "wrong").Return("", services.ErrCalendarFeedNotFound)
+
+	calendarController.Feed(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/middleware"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+)
+
+const (
+	// CollabProtocol is the WebSocket subprotocol of the collaboration
+	// socket. Clients must offer it.
+	CollabProtocol = "todo.v1"
+
+	collabWriteWait  = 10 * time.Second
+	collabPongWait   = 60 * time.Second
+	collabPingPeriod = 25 * time.Second
+	collabMaxMessage = 4096
+	// collabReplies is how many error replies may wait for the writer
+	// before further ones are dropped.
+	collabReplies = 16
+)
+
+type CollabController struct {
+	service  services.CollabServiceInterface
+	upgrader websocket.Upgrader
+}
+
+// NewCollabController accepts WebSocket handshakes from pages on
+// allowedOrigins and from clients that send no Origin.
+func NewCollabController(service services.CollabServiceInterface, allowedOrigins []string) *CollabController {
+	return &CollabController{
+		service: service,
+		upgrader: websocket.Upgrader{
+			Subprotocols: []string{CollabProtocol},
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				return origin == "" || slices.Contains(allowedOrigins, origin)
+			},
+		},
+	}
+}
+
+// collabErrorMessage is the error reported to the client for err.
+func collabErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrInvalidCollabMessage), errors.Is(err, services.ErrTooManyCollabLists),
+		errors.Is(err, services.ErrNotSubscribed), errors.Is(err, services.ErrTaskListNotFound),
+		errors.Is(err, services.ErrPresenceNotAllowed):
+		return err.Error()
+	default:
+		return "Failed to process message"
+	}
+}
+
+// Connect upgrades the request to the collaboration socket. Clients
+// subscribe to lists and report their presence with JSON messages, and
+// receive the task events and the presence of everyone else in those
+// lists. Third-party apps need the members:read scope to receive presence
+// and tasks:write to report it; without tasks:write they are not shown to
+// others. The server pings every 25 seconds and closes connections that
+// do not answer within a minute, or that fall too far behind.
+func (cc *CollabController) Connect(c *gin.Context) {
+	session, ok := cc.connect(c)
+	if !ok {
+		return
+	}
+	defer cc.service.Disconnect(session)
+
+	conn, err := cc.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered with an HTTP error.
+		return
+	}
+	defer conn.Close()
+
+	replies := make(chan models.CollabMessage, collabReplies)
+	done := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		cc.write(conn, session, replies, done)
+	}()
+	cc.read(c, conn, session, replies)
+	close(done)
+	<-written
+}
+
+// connect opens the session of the request or writes the error response.
+func (cc *CollabController) connect(c *gin.Context) (*services.CollabSession, bool) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CollabController.Connect")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return nil, false
+	}
+	access := services.CollabAccess{
+		SeePresence:   middleware.HasScope(c, scope.MembersRead),
+		SharePresence: middleware.HasScope(c, scope.TasksWrite),
+	}
+	session, err := cc.service.Connect(c.Request.Context(), uint(userID.(int)), access)
+	if errors.Is(err, services.ErrTooManyConnections) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open collaboration session"})
+		return nil, false
+	}
+	return session, true
+}
+
+// read hands the client's messages to the service until the connection
+// fails or the client stops answering pings.
+func (cc *CollabController) read(c *gin.Context, conn *websocket.Conn, session *services.CollabSession, replies chan<- models.CollabMessage) {
+	conn.SetReadLimit(collabMaxMessage)
+	_ = conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(collabPongWait))
+	})
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg models.CollabMessage
+		if json.Unmarshal(data, &msg) != nil {
+			err = services.ErrInvalidCollabMessage
+		} else {
+			err = cc.service.Handle(c.Request.Context(), session, msg)
+		}
+		if err != nil {
+			select {
+			case replies <- models.CollabMessage{Type: models.CollabError, ListID: msg.ListID, Error: collabErrorMessage(err)}:
+			default:
+			}
+		}
+	}
+}
+
+// write sends the session's messages, the error replies and pings until
+// done is closed or the connection fails. It closes the connection if the
+// client fell too far behind.
+func (cc *CollabController) write(conn *websocket.Conn, session *services.CollabSession, replies <-chan models.CollabMessage, done <-chan struct{}) {
+	ping := time.NewTicker(collabPingPeriod)
+	defer ping.Stop()
+	// Closing the connection ends read as well.
+	defer conn.Close()
+
+	for {
+		var msg models.CollabMessage
+		select {
+		case <-done:
+			return
+		case m, ok := <-session.Out:
+			if !ok {
+				closing := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "Too far behind")
+				_ = conn.WriteControl(websocket.CloseMessage, closing, time.Now().Add(collabWriteWait))
+				return
+			}
+			msg = m
+		case msg = <-replies:
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(collabWriteWait)); err != nil {
+				return
+			}
+			continue
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(collabWriteWait))
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+)
+
+// MockCollabService is a mock implementation of the CollabServiceInterface
+type MockCollabService struct {
+	mock.Mock
+}
+
+var _ services.CollabServiceInterface = (*MockCollabService)(nil)
+
+func (m *MockCollabService) Connect(ctx context.Context, userID uint, access services.CollabAccess) (*services.CollabSession, error) {
+	args := m.Called(ctx, userID, access)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.CollabSession), args.Error(1)
+}
+
+func (m *MockCollabService) Handle(ctx context.Context, session *services.CollabSession, msg models.CollabMessage) error {
+	args := m.Called(ctx, session, msg)
+	return args.Error(0)
+}
+
+func (m *MockCollabService) Disconnect(session *services.CollabSession) {
+	m.Called(session)
+}
+
+// newCollabServer serves the collaboration socket of user 1.
+func newCollabServer(t *testing.T, service services.CollabServiceInterface) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	collabController := NewCollabController(service, []string{"http://app.example.com"})
+	router.GET("/api/ws", func(c *gin.Context) { c.Set("userID", 1) }, collabController.Connect)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+}
+
+func TestCollabController_Connect(t *testing.T) {
+	mockService := new(MockCollabService)
+	url := newCollabServer(t, mockService)
+
+	out := make(chan models.CollabMessage, 1)
+	session := &services.CollabSession{ID: "s1", Out: out}
+	disconnected := make(chan struct{})
+	mockService.On("Connect", mock.Anything, uint(1), services.FullCollabAccess).Return(session, nil)
+	mockService.On("Handle", mock.Anything, session, models.CollabMessage{Type: models.CollabSubscribe, ListID: 3}).Return(nil)
+	mockService.On("Handle", mock.Anything, session, models.CollabMessage{Type: models.CollabSubscribe, ListID: 4}).Return(services.ErrTaskListNotFound)
+	mockService.On("Disconnect", session).Run(func(mock.Arguments) { close(disconnected) }).Return()
+
+	dialer := websocket.Dialer{Subprotocols: []string{CollabProtocol}}
+	header := http.Header{"Origin": {"http://app.example.com"}}
+	conn, resp, err := dialer.Dial(url, header)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, CollabProtocol, resp.Header.Get("Sec-WebSocket-Protocol"))
+
+	require.NoError(t, conn.WriteJSON(models.CollabMessage{Type: models.CollabSubscribe, ListID: 3}))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("not json")))
+	require.NoError(t, conn.WriteJSON(models.CollabMessage{Type: models.CollabSubscribe, ListID: 4}))
+
+	var reply models.CollabMessage
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, models.CollabMessage{Type: models.CollabError, Error: services.ErrInvalidCollabMessage.Error()}, reply)
+	require.NoError(t, conn.ReadJSON(&reply))
+	assert.Equal(t, models.CollabMessage{Type: models.CollabError, ListID: 4, Error: "Task list not found"}, reply)
+
+	out <- models.CollabMessage{Type: models.CollabEvent, ListID: 3, Seq: 11, Event: &models.Event{ID: "a", Type: models.EventTaskCreated}}
+	var event models.CollabMessage
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, int64(11), event.Seq)
+
+	// The service gave up on the client.
+	close(out)
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseTryAgainLater))
+
+	select {
+	case <-disconnected:
+	case <-time.After(time.Second):
+		t.Fatal("session was not disconnected")
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestCollabController_Connect_ForeignOrigin(t *testing.T) {
+	mockService := new(MockCollabService)
+	url := newCollabServer(t, mockService)
+	session := &services.CollabSession{ID: "s1", Out: make(chan models.CollabMessage)}
+	mockService.On("Connect", mock.Anything, uint(1), services.FullCollabAccess).Return(session, nil)
+	mockService.On("Disconnect", session).Return()
+
+	dialer := websocket.Dialer{Subprotocols: []string{CollabProtocol}}
+	_, resp, err := dialer.Dial(url, http.Header{"Origin": {"http://evil.example.com"}})
+
+	assert.Error(t, err)
+	if assert.NotNil(t, resp) {
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+	mockService.AssertCalled(t, "Disconnect", session)
+}
+
+func TestCollabController_Connect_TooMany(t *testing.T) {
+	mockService := new(MockCollabService)
+	collabController := NewCollabController(mockService, nil)
+	c, w := newAdminContext(http.MethodGet, "/api/ws", nil)
+
+	mockService.On("Connect", mock.Anything, uint(1), services.FullCollabAccess).Return(nil, services.ErrTooManyConnections)
+
+	collabController.Connect(c)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	mockService.AssertNotCalled(t, "Disconnect", mock.Anything)
+}
+
+func TestCollabController_Connect_Scopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		access services.CollabAccess
+	}{
+		{"read only", []string{scope.TasksRead}, services.CollabAccess{}},
+		{"read and write", []string{scope.TasksRead, scope.TasksWrite}, services.CollabAccess{SharePresence: true}},
+		{"members", []string{scope.TasksRead, scope.MembersRead}, services.CollabAccess{SeePresence: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCollabService)
+			collabController := NewCollabController(mockService, nil)
+			c, w := newAdminContext(http.MethodGet, "/api/ws", nil)
+			c.Set("clientID", "planner")
+			c.Set("scopes", tt.scopes)
+			mockService.On("Connect", mock.Anything, uint(1), tt.access).Return(nil, services.ErrTooManyConnections)
+
+			collabController.Connect(c)
+
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/middleware"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+)
+
+type CommentController struct {
+	service services.CommentServiceInterface
+}
+
+func NewCommentController(service services.CommentServiceInterface) *CommentController {
+	return &CommentController{service: service}
+}
+
+// commentErrorResponse maps comment errors and falls back to the task errors
+// for everything else.
+func commentErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidComment):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrCommentPermissionDenied):
+		return http.StatusForbidden, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrCommentNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+// hideNames removes the names of users from comment for third-party apps
+// without the members:read scope. User IDs stay, as they do on tasks.
+func hideNames(c *gin.Context, comment *models.Comment) {
+	if middleware.HasScope(c, scope.MembersRead) {
+		return
+	}
+	comment.AuthorName = ""
+	mentions := make([]models.CommentMention, len(comment.Mentions))
+	for i, mention := range comment.Mentions {
+		mentions[i] = models.CommentMention{UserID: mention.UserID}
+	}
+	comment.Mentions = mentions
+}
+
+func (cc *CommentController) ListComments(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CommentController.ListComments")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	comments, err := cc.service.ListComments(c.Request.Context(), uint(userID.(int)), taskID)
+	if err != nil {
+		c.JSON(commentErrorResponse(err, "Failed to list comments"))
+		return
+	}
+	for i := range comments {
+		hideNames(c, &comments[i])
+	}
+	c.JSON(http.StatusOK, comments)
+}
+
+func (cc *CommentController) CreateComment(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CommentController.CreateComment")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := cc.service.CreateComment(c.Request.Context(), uint(userID.(int)), taskID, req)
+	if err != nil {
+		c.JSON(commentErrorResponse(err, "Failed to create comment"))
+		return
+	}
+	hideNames(c, comment)
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (cc *CommentController) UpdateComment(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CommentController.UpdateComment")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+	commentID, ok := pathID(c, "comment_id", "comment")
+	if !ok {
+		return
+	}
+
+	var req models.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := cc.service.UpdateComment(c.Request.Context(), uint(userID.(int)), taskID, commentID, req)
+	if err != nil {
+		c.JSON(commentErrorResponse(err, "Failed to update comment"))
+		return
+	}
+	hideNames(c, comment)
+	c.JSON(http.StatusOK, comment)
+}
+
+func (cc *CommentController) DeleteComment(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "CommentController.DeleteComment")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+	commentID, ok := pathID(c, "comment_id", "comment")
+	if !ok {
+		return
+	}
+
+	if err := cc.service.DeleteComment(c.Request.Context(), uint(userID.(int)), taskID, commentID); err != nil {
+		c.JSON(commentErrorResponse(err, "Failed to delete comment"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+)
+
+// MockCommentService is a mock implementation of the CommentServiceInterface
+type MockCommentService struct {
+	mock.Mock
+}
+
+var _ services.CommentServiceInterface = (*MockCommentService)(nil)
+
+func (m *MockCommentService) ListComments(ctx context.Context, userID uint, taskID int) ([]models.Comment, error) {
+	args := m.Called(ctx, userID, taskID)
+	return args.Get(0).([]models.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) CreateComment(ctx context.Context, userID uint, taskID int, req models.CommentRequest) (*models.Comment, error) {
+	args := m.Called(ctx, userID, taskID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) UpdateComment(ctx context.Context, userID uint, taskID, commentID int, req models.CommentRequest) (*models.Comment, error) {
+	args := m.Called(ctx, userID, taskID, commentID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) DeleteComment(ctx context.Context, userID uint, taskID, commentID int) error {
+	args := m.Called(ctx, userID, taskID, commentID)
+	return args.Error(0)
+}
+
+func TestCommentController_CreateComment(t *testing.T) {
+	mockService := new(MockCommentService)
+	commentController := NewCommentController(mockService)
+	req := models.CommentRequest{Body: "Ping @bob"}
+	c, w := newAdminContext(http.MethodPost, "/api/tasks/5/comments", req)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	mockService.On("CreateComment", mock.Anything, uint(1), 5, req).Return(&models.Comment{
+		ID: 7, TaskID: 5, AuthorID: 1, Body: req.Body,
+		Mentions: []models.CommentMention{{UserID: 2, Username: "bob"}},
+	}, nil)
+
+	commentController.CreateComment(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"mentions":[{"user_id":2,"username":"bob"}]`)
+}
+
+func TestCommentController_ListComments_HidesNames(t *testing.T) {
+	comments := func() []models.Comment {
+		return []models.Comment{{ID: 7, TaskID: 5, AuthorID: 2, AuthorName: "bob", Body: "Ping @alice",
+			Mentions: []models.CommentMention{{UserID: 1, Username: "alice"}}}}
+	}
+	tests := []struct {
+		name   string
+		scopes []string
+		want   string
+	}{
+		{"tasks:read", []string{scope.TasksRead},
+			`[{"id":7,"task_id":5,"author_id":2,"body":"Ping @alice","mentions":[{"user_id":1}],"created_at":"0001-01-01T00:00:00Z"}]`},
+		{"members:read", []string{scope.TasksRead, scope.MembersRead},
+			`[{"id":7,"task_id":5,"author_id":2,"author_name":"bob","body":"Ping @alice","mentions":[{"user_id":1,"username":"alice"}],"created_at":"0001-01-01T00:00:00Z"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCommentService)
+			commentController := NewCommentController(mockService)
+			c, w := newAdminContext(http.MethodGet, "/api/tasks/5/comments", nil)
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+			c.Set("clientID", "planner")
+			c.Set("scopes", tt.scopes)
+			mockService.On("ListComments", mock.Anything, uint(1), 5).Return(comments(), nil)
+
+			commentController.ListComments(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, tt.want, w.Body.String())
+		})
+	}
+}
+
+func TestCommentController_CreateComment_HidesNames(t *testing.T) {
+	mockService := new(MockCommentService)
+	commentController := NewCommentController(mockService)
+	req := models.CommentRequest{Body: "Ping @bob"}
+	c, w := newAdminContext(http.MethodPost, "/api/tasks/5/comments", req)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+	c.Set("clientID", "planner")
+	c.Set("scopes", []string{scope.TasksRead, scope.TasksWrite})
+	mockService.On("CreateComment", mock.Anything, uint(1), 5, req).Return(&models.Comment{
+		ID: 7, TaskID: 5, AuthorID: 1, AuthorName: "alice", Body: req.Body,
+		Mentions: []models.CommentMention{{UserID: 2, Username: "bob"}},
+	}, nil)
+
+	commentController.CreateComment(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"mentions":[{"user_id":2}]`)
+	assert.NotContains(t, w.Body.String(), "alice")
+}
+
+func TestCommentController_UpdateComment_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"empty body", services.ErrInvalidComment, http.StatusBadRequest},
+		{"not the author", services.ErrCommentPermissionDenied, http.StatusForbidden},
+		{"unknown comment", services.ErrCommentNotFound, http.StatusNotFound},
+		{"task not visible", repositories.ErrTaskNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockCommentService)
+			commentController := NewCommentController(mockService)
+			req := models.CommentRequest{Body: "edited"}
+			c, w := newAdminContext(http.MethodPut, "/api/tasks/5/comments/7", req)
+			c.Params = gin.Params{{Key: "id", Value: "5"}, {Key: "comment_id", Value: "7"}}
+
+			mockService.On("UpdateComment", mock.Anything, uint(1), 5, 7, req).Return(nil, tt.err)
+
+			commentController.UpdateComment(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestCommentController_DeleteComment_InvalidID(t *testing.T) {
+	mockService := new(MockCommentService)
+	commentController := NewCommentController(mockService)
+	c, w := newAdminContext(http.MethodDelete, "/api/tasks/5/comments/abc", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}, {Key: "comment_id", Value: "abc"}}
+
+	commentController.DeleteComment(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
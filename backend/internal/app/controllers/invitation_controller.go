@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type InvitationController struct {
+	service services.InvitationServiceInterface
+}
+
+func NewInvitationController(service services.InvitationServiceInterface) *InvitationController {
+	return &InvitationController{service: service}
+}
+
+// invitationErrorResponse maps invitation errors and falls back to the task
+// list errors for everything else.
+func invitationErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInvitation):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrInvitationNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+func (ic *InvitationController) CreateInvitation(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.CreateInvitation")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := ic.service.CreateInvitation(c.Request.Context(), uint(userID.(int)), listID, req)
+	if err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to create invitation"))
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusCreated, created)
+}
+
+func (ic *InvitationController) ListInvitations(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.ListInvitations")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	invitations, err := ic.service.ListInvitations(c.Request.Context(), uint(userID.(int)), listID)
+	if err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to list invitations"))
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (ic *InvitationController) RevokeInvitation(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.RevokeInvitation")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+	invitationID, ok := pathID(c, "invitation_id", "invitation")
+	if !ok {
+		return
+	}
+
+	if err := ic.service.RevokeInvitation(c.Request.Context(), uint(userID.(int)), listID, invitationID); err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to revoke invitation"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+// PreviewInvitation describes the invitation a token belongs to. It is
+// public so the invitee can decide before logging in or signing up.
+func (ic *InvitationController) PreviewInvitation(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.PreviewInvitation")
+	defer span.End()
+
+	var req models.InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	inv, err := ic.service.PreviewInvitation(c.Request.Context(), req.Token)
+	if err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to look up invitation"))
+		return
+	}
+	c.JSON(http.StatusOK, inv)
+}
+
+func (ic *InvitationController) AcceptInvitation(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.AcceptInvitation")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := ic.service.AcceptInvitation(c.Request.Context(), uint(userID.(int)), req.Token)
+	if err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to accept invitation"))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// DeclineInvitation rejects an invitation. It is public so that people
+// without an account can decline as well.
+func (ic *InvitationController) DeclineInvitation(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "InvitationController.DeclineInvitation")
+	defer span.End()
+
+	var req models.InvitationTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ic.service.DeclineInvitation(c.Request.Context(), req.Token); err != nil {
+		c.JSON(invitationErrorResponse(err, "Failed to decline invitation"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined successfully"})
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockInvitationService is a mock implementation of the InvitationServiceInterface
+type MockInvitationService struct {
+	mock.Mock
+}
+
+var _ services.InvitationServiceInterface = (*MockInvitationService)(nil)
+
+func (m *MockInvitationService) CreateInvitation(ctx context.Context, userID uint, listID int, req models.CreateInvitationRequest) (*models.CreatedInvitation, error) {
+	args := m.Called(ctx, userID, listID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedInvitation), args.Error(1)
+}
+
+func (m *MockInvitationService) ListInvitations(ctx context.Context, userID uint, listID int) ([]models.Invitation, error) {
+	args := m.Called(ctx, userID, listID)
+	return args.Get(0).([]models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) RevokeInvitation(ctx context.Context, userID uint, listID, invitationID int) error {
+	args := m.Called(ctx, userID, listID, invitationID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationService) PreviewInvitation(ctx context.Context, token string) (*models.Invitation, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationService) AcceptInvitation(ctx context.Context, userID uint, token string) (*models.TaskList, error) {
+	args := m.Called(ctx, userID, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockInvitationService) DeclineInvitation(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func TestInvitationController_CreateInvitation(t *testing.T) {
+	mockService := new(MockInvitationService)
+	invitationController := NewInvitationController(mockService)
+	req := models.CreateInvitationRequest{Email: "bob@example.com", Role: models.ListRoleViewer}
+	c, w := newAdminContext(http.MethodPost, "/api/lists/3/invitations", req)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+	mockService.On("CreateInvitation", mock.Anything, uint(1), 3, req).
+		Return(&models.CreatedInvitation{Invitation: models.Invitation{ID: 9, ListID: 3}, Token: "tok", URL: "http://localhost/invitation?token=tok"}, nil)
+
+	invitationController.CreateInvitation(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `"token":"tok"`)
+}
+
+func TestInvitationController_AcceptInvitation_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid or used token", services.ErrInvalidInvitation, http.StatusBadRequest},
+		{"already a member", services.ErrAlreadyListMember, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockInvitationService)
+			invitationController := NewInvitationController(mockService)
+			c, w := newAdminContext(http.MethodPost, "/api/invitations/accept", models.InvitationTokenRequest{Token: "tok"})
+
+			mockService.On("AcceptInvitation", mock.Anything, uint(1), "tok").Return(nil, tt.err)
+
+			invitationController.AcceptInvitation(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestInvitationController_RevokeInvitation_NotFound(t *testing.T) {
+	mockService := new(MockInvitationService)
+	invitationController := NewInvitationController(mockService)
+	c, w := newAdminContext(http.MethodDelete, "/api/lists/3/invitations/9", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "invitation_id", Value: "9"}}
+
+	mockService.On("RevokeInvitation", mock.Anything, uint(1), 3, 9).Return(services.ErrInvitationNotFound)
+
+	invitationController.RevokeInvitation(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
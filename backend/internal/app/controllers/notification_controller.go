@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type NotificationController struct {
+	service services.NotificationServiceInterface
+}
+
+func NewNotificationController(service services.NotificationServiceInterface) *NotificationController {
+	return &NotificationController{service: service}
+}
+
+// ListNotifications returns the user's notifications, newest first. The
+// "unread" query parameter limits them to unread ones; "limit" and "offset"
+// page through them.
+func (nc *NotificationController) ListNotifications(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.ListNotifications")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	var query models.NotificationQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifications, err := nc.service.ListNotifications(c.Request.Context(), uint(userID.(int)), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, notifications)
+}
+
+func (nc *NotificationController) UnreadCount(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.UnreadCount")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	unread, err := nc.service.UnreadCount(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread_count": unread})
+}
+
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.MarkRead")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	notificationID, ok := pathID(c, "id", "notification")
+	if !ok {
+		return
+	}
+
+	err := nc.service.MarkRead(c.Request.Context(), uint(userID.(int)), notificationID)
+	if errors.Is(err, services.ErrNotificationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification as read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read"})
+}
+
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.MarkAllRead")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	n, err := nc.service.MarkAllRead(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications as read"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
+
+func (nc *NotificationController) GetPreferences(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.GetPreferences")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	prefs, err := nc.service.GetPreferences(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+func (nc *NotificationController) UpdatePreferences(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "NotificationController.UpdatePreferences")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	var req models.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := nc.service.UpdatePreferences(c.Request.Context(), uint(userID.(int)), req.Preferences)
+	if errors.Is(err, services.ErrInvalidNotificationPreference) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockNotificationService is a mock implementation of the NotificationServiceInterface
+type MockNotificationService struct {
+	mock.Mock
+}
+
+var _ services.NotificationServiceInterface = (*MockNotificationService)(nil)
+
+func (m *MockNotificationService) ListNotifications(ctx context.Context, userID uint, query models.NotificationQuery) (*models.NotificationList, error) {
+	args := m.Called(ctx, userID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.NotificationList), args.Error(1)
+}
+
+func (m *MockNotificationService) UnreadCount(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkRead(ctx context.Context, userID uint, notificationID int) error {
+	args := m.Called(ctx, userID, notificationID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) MarkAllRead(ctx context.Context, userID uint) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotificationService) UpdatePreferences(ctx context.Context, userID uint, prefs []models.NotificationPreference) ([]models.NotificationPreference, error) {
+	args := m.Called(ctx, userID, prefs)
+	return args.Get(0).([]models.NotificationPreference), args.Error(1)
+}
+
+func TestNotificationController_ListNotifications(t *testing.T) {
+	mockService := new(MockNotificationService)
+	notificationController := NewNotificationController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/notifications?unread=true&limit=10", nil)
+
+	mockService.On("ListNotifications", mock.Anything, uint(1), models.NotificationQuery{Unread: true, Limit: 10}).Return(&models.NotificationList{
+		Notifications: []models.Notification{{ID: 9, UserID: 1, Type: models.NotificationMention, ActorName: "bob"}},
+		Total:         1,
+		UnreadCount:   1,
+		Limit:         10,
+	}, nil)
+
+	notificationController.ListNotifications(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"unread_count":1`)
+	assert.Contains(t, w.Body.String(), `"actor_name":"bob"`)
+}
+
+func TestNotificationController_MarkRead_NotFound(t *testing.T) {
+	mockService := new(MockNotificationService)
+	notificationController := NewNotificationController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/notifications/9/read", nil)
+	c.Params = gin.Params{{Key: "id", Value: "9"}}
+
+	mockService.On("MarkRead", mock.Anything, uint(1), 9).Return(services.ErrNotificationNotFound)
+
+	notificationController.MarkRead(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestNotificationController_UpdatePreferences_Invalid(t *testing.T) {
+	mockService := new(MockNotificationService)
+	notificationController := NewNotificationController(mockService)
+	req := models.UpdateNotificationPreferencesRequest{Preferences: []models.NotificationPreference{{Type: "digest", Channel: "email"}}}
+	c, w := newAdminContext(http.MethodPut, "/api/notifications/preferences", req)
+
+	mockService.On("UpdatePreferences", mock.Anything, uint(1), req.Preferences).
+		Return([]models.NotificationPreference(nil), services.ErrInvalidNotificationPreference)
+
+	notificationController.UpdatePreferences(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
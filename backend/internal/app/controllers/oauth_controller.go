@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type OAuthController struct {
+	service services.OAuthServiceInterface
+}
+
+func NewOAuthController(service services.OAuthServiceInterface) *OAuthController {
+	return &OAuthController{service: service}
+}
+
+// respondOAuthError writes err as an OAuth 2.0 error response if it is one.
+// It reports whether a response was written.
+func respondOAuthError(c *gin.Context, err error) bool {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		return false
+	}
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	c.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+	return true
+}
+
+// clientCredentials reads client authentication from HTTP Basic auth
+// (client_secret_basic) or the form body (client_secret_post / public
+// clients).
+func clientCredentials(c *gin.Context) services.ClientCredentials {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		// RFC 6749 section 2.3.1 form-encodes both values.
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return services.ClientCredentials{ID: id, Secret: secret}
+	}
+	return services.ClientCredentials{ID: c.PostForm("client_id"), Secret: c.PostForm("client_secret")}
+}
+
+// RegisterClient registers a third-party application owned by the
+// authenticated user.
+func (oc *OAuthController) RegisterClient(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.RegisterClient")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client, secret, err := oc.service.RegisterClient(c.Request.Context(), uint(userID.(int)), req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidClientMetadata) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register client"})
+		return
+	}
+
+	body := gin.H{
+		"client_id":     client.ID,
+		"name":          client.Name,
+		"redirect_uris": client.RedirectURIs,
+		"scopes":        client.Scopes,
+		"created_at":    client.CreatedAt,
+	}
+	if secret != "" {
+		body["client_secret"] = secret
+	}
+	c.JSON(http.StatusCreated, body)
+}
+
+// ListClients lists the applications registered by the authenticated user.
+func (oc *OAuthController) ListClients(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.ListClients")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	clients, err := oc.service.ListClients(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list clients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, clients)
+}
+
+// DeleteClient deletes an application registered by the authenticated user
+// together with all tokens issued to it.
+func (oc *OAuthController) DeleteClient(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.DeleteClient")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := oc.service.DeleteClient(c.Request.Context(), uint(userID.(int)), c.Param("id")); err != nil {
+		if errors.Is(err, services.ErrOAuthClientNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete client"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Client deleted successfully"})
+}
+
+// PrepareConsent validates an authorization request forwarded by the
+// frontend and returns the data for the consent screen.
+func (oc *OAuthController) PrepareConsent(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.PrepareConsent")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.AuthorizationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prompt, err := oc.service.PrepareConsent(c.Request.Context(), uint(userID.(int)), req)
+	if err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process authorization request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// Authorize records the user's consent decision. The frontend sends the
+// user agent to the returned redirect_to URL.
+func (oc *OAuthController) Authorize(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.Authorize")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var decision models.ConsentDecision
+	if err := c.ShouldBindJSON(&decision); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	redirectTo, err := oc.service.Authorize(c.Request.Context(), uint(userID.(int)), decision)
+	if err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process authorization request"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"redirect_to": redirectTo})
+}
+
+// Token is the token endpoint (RFC 6749 section 3.2).
+func (oc *OAuthController) Token(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.Token")
+	defer span.End()
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	req := services.TokenRequest{
+		GrantType:    c.PostForm("grant_type"),
+		Code:         c.PostForm("code"),
+		RedirectURI:  c.PostForm("redirect_uri"),
+		CodeVerifier: c.PostForm("code_verifier"),
+	}
+	token, err := oc.service.ExchangeCode(c.Request.Context(), clientCredentials(c), req)
+	if err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+// Introspect is the token introspection endpoint (RFC 7662).
+func (oc *OAuthController) Introspect(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.Introspect")
+	defer span.End()
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required"})
+		return
+	}
+
+	result, err := oc.service.Introspect(c.Request.Context(), clientCredentials(c), token)
+	if err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// Revoke is the token revocation endpoint (RFC 7009).
+func (oc *OAuthController) Revoke(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.Revoke")
+	defer span.End()
+
+	token := c.PostForm("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_request", "error_description": "token is required"})
+		return
+	}
+
+	if err := oc.service.Revoke(c.Request.Context(), clientCredentials(c), token); err != nil {
+		if respondOAuthError(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListConsents lists the applications the authenticated user has authorized.
+func (oc *OAuthController) ListConsents(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.ListConsents")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	consents, err := oc.service.ListConsents(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list authorized applications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, consents)
+}
+
+// RevokeConsent withdraws access from an application the authenticated user
+// has authorized.
+func (oc *OAuthController) RevokeConsent(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OAuthController.RevokeConsent")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := oc.service.RevokeConsent(c.Request.Context(), uint(userID.(int)), c.Param("client_id")); err != nil {
+		if errors.Is(err, services.ErrOAuthConsentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke access"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Access revoked successfully"})
+}
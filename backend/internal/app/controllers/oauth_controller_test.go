@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockOAuthService is a mock implementation of the OAuthServiceInterface
+type MockOAuthService struct {
+	mock.Mock
+}
+
+var _ services.OAuthServiceInterface = (*MockOAuthService)(nil)
+
+func (m *MockOAuthService) RegisterClient(ctx context.Context, ownerID uint, req models.CreateOAuthClientRequest) (*models.OAuthClient, string, error) {
+	args := m.Called(ctx, ownerID, req)
+	if args.Get(0) == nil {
+		return nil, "", args.Error(2)
+	}
+	return args.Get(0).(*models.OAuthClient), args.String(1), args.Error(2)
+}
+
+func (m *MockOAuthService) ListClients(ctx context.Context, ownerID uint) ([]models.OAuthClient, error) {
+	args := m.Called(ctx, ownerID)
+	return args.Get(0).([]models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthService) DeleteClient(ctx context.Context, ownerID uint, clientID string) error {
+	args := m.Called(ctx, ownerID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) PrepareConsent(ctx context.Context, userID uint, req models.AuthorizationRequest) (*models.ConsentPrompt, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ConsentPrompt), args.Error(1)
+}
+
+func (m *MockOAuthService) Authorize(ctx context.Context, userID uint, decision models.ConsentDecision) (string, error) {
+	args := m.Called(ctx, userID, decision)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOAuthService) ExchangeCode(ctx context.Context, creds services.ClientCredentials, req services.TokenRequest) (*models.TokenResponse, error) {
+	args := m.Called(ctx, creds, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) Introspect(ctx context.Context, creds services.ClientCredentials, token string) (*models.IntrospectionResponse, error) {
+	args := m.Called(ctx, creds, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IntrospectionResponse), args.Error(1)
+}
+
+func (m *MockOAuthService) Revoke(ctx context.Context, creds services.ClientCredentials, token string) error {
+	args := m.Called(ctx, creds, token)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) ListConsents(ctx context.Context, userID uint) ([]models.OAuthConsent, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.OAuthConsent), args.Error(1)
+}
+
+func (m *MockOAuthService) RevokeConsent(ctx context.Context, userID uint, clientID string) error {
+	args := m.Called(ctx, userID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	args := m.Called(ctx, jti)
+	return args.Bool(0), args.Error(1)
+}
+
+func newFormContext(target string, form url.Values) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c, w
+}
+
+func TestOAuthController_Token_BasicAuth(t *testing.T) {
+	mockService := new(MockOAuthService)
+	oauthController := NewOAuthController(mockService)
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {"abc"}, "redirect_uri": {"https://c.example/cb"}, "code_verifier": {"v"}}
+	c, w := newFormContext("/oauth/token", form)
+	c.Request.SetBasicAuth("client%3A1", "s%2Fcret")
+
+	mockService.On("ExchangeCode", mock.Anything,
+		services.ClientCredentials{ID: "client:1", Secret: "s/cret"},
+		services.TokenRequest{GrantType: "authorization_code", Code: "abc", RedirectURI: "https://c.example/cb", CodeVerifier: "v"},
+	).Return(&models.TokenResponse{AccessToken: "jwt", TokenType: "Bearer", ExpiresIn: 3600, Scope: "tasks:read"}, nil)
+
+	oauthController.Token(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"access_token":"jwt","token_type":"Bearer","expires_in":3600,"scope":"tasks:read"}`, w.Body.String())
+}
+
+func TestOAuthController_Token_Errors(t *testing.T) {
+	tests := map[string]int{
+		"invalid_client": http.StatusUnauthorized,
+		"invalid_grant":  http.StatusBadRequest,
+	}
+	for code, status := range tests {
+		t.Run(code, func(t *testing.T) {
+			mockService := new(MockOAuthService)
+			oauthController := NewOAuthController(mockService)
+			c, w := newFormContext("/oauth/token", url.Values{"grant_type": {"authorization_code"}, "client_id": {"client-1"}})
+
+			mockService.On("ExchangeCode", mock.Anything, services.ClientCredentials{ID: "client-1"}, mock.Anything).
+				Return(nil, &services.OAuthError{Code: code, Description: "nope"})
+
+			oauthController.Token(c)
+
+			assert.Equal(t, status, w.Code)
+			var body map[string]string
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, code, body["error"])
+		})
+	}
+}
+
+func TestOAuthController_Revoke(t *testing.T) {
+	mockService := new(MockOAuthService)
+	oauthController := NewOAuthController(mockService)
+	c, w := newFormContext("/oauth/revoke", url.Values{"token": {"jwt"}, "client_id": {"client-1"}})
+
+	mockService.On("Revoke", mock.Anything, services.ClientCredentials{ID: "client-1"}, "jwt").Return(nil)
+
+	oauthController.Revoke(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestOAuthController_Authorize(t *testing.T) {
+	mockService := new(MockOAuthService)
+	oauthController := NewOAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 1)
+
+	decision := models.ConsentDecision{AuthorizationRequest: models.AuthorizationRequest{ClientID: "client-1"}, Approve: true}
+	jsonValue, _ := json.Marshal(decision)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/oauth/authorize", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("Authorize", mock.Anything, uint(1), decision).Return("https://c.example/cb?code=abc", nil)
+
+	oauthController.Authorize(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"redirect_to":"https://c.example/cb?code=abc"}`, w.Body.String())
+}
+
+func TestOAuthController_RegisterClient_InvalidMetadata(t *testing.T) {
+	mockService := new(MockOAuthService)
+	oauthController := NewOAuthController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 1)
+
+	req := models.CreateOAuthClientRequest{Name: "x", RedirectURIs: []string{"http://evil"}, Scopes: []string{"tasks:read"}}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/oauth/clients", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("RegisterClient", mock.Anything, uint(1), req).Return(nil, "", services.ErrInvalidClientMetadata)
+
+	oauthController.RegisterClient(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
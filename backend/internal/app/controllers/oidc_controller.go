@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// oidcStateCookie binds a login to the browser that started it. The
+// __Host- prefix keeps other subdomains from setting it.
+const oidcStateCookie = "__Host-oidc_state"
+
+type OIDCController struct {
+	service     services.OIDCServiceInterface
+	frontendURL string
+}
+
+// NewOIDCController creates an OIDCController. When frontendURL is set the
+// callback redirects there with the outcome in the URL fragment; otherwise
+// it responds with JSON.
+func NewOIDCController(service services.OIDCServiceInterface, frontendURL string) *OIDCController {
+	return &OIDCController{service: service, frontendURL: frontendURL}
+}
+
+// Providers lists the configured identity providers.
+func (oc *OIDCController) Providers(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oc.service.Providers()})
+}
+
+// Login redirects the browser to the identity provider.
+func (oc *OIDCController) Login(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.Login")
+	defer span.End()
+
+	authURL, state, err := oc.service.StartLogin(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	setOIDCStateCookie(c, state, int(services.OIDCLoginStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes a login or link started by Login or StartLink.
+func (oc *OIDCController) Callback(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.Callback")
+	defer span.End()
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		oc.finishCallback(c, http.StatusUnauthorized, url.Values{"error": {providerErr}}, gin.H{"error": "Login was cancelled or denied by the identity provider"})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code or state"})
+		return
+	}
+
+	// A callback is only accepted in the browser that started the login or
+	// link, so that nobody can complete their own flow in someone else's
+	// browser.
+	cookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		err := services.ErrInvalidOIDCState
+		oc.finishCallback(c, http.StatusBadRequest, url.Values{"error": {err.Error()}}, gin.H{"error": err.Error()})
+		return
+	}
+	setOIDCStateCookie(c, "", -1)
+
+	result, err := oc.service.Callback(c.Request.Context(), c.Param("provider"), code, state)
+	if err != nil {
+		status, body := oidcErrorResponse(err)
+		oc.finishCallback(c, status, url.Values{"error": {body["error"].(string)}}, body)
+		return
+	}
+
+	if result.Linked {
+		oc.finishCallback(c, http.StatusOK, url.Values{"linked": {c.Param("provider")}}, gin.H{"linked": c.Param("provider")})
+		return
+	}
+	oc.finishCallback(c, http.StatusOK, url.Values{"token": {result.Token}}, gin.H{"token": result.Token, "created": result.Created})
+}
+
+// finishCallback redirects to the frontend with fragment, or writes body as
+// JSON when no frontend is configured. The fragment keeps the token out of
+// server logs and Referer headers.
+func (oc *OIDCController) finishCallback(c *gin.Context, status int, fragment url.Values, body gin.H) {
+	if oc.frontendURL == "" {
+		c.JSON(status, body)
+		return
+	}
+	c.Redirect(http.StatusFound, oc.frontendURL+"#"+fragment.Encode())
+}
+
+// StartLink returns the authorization URL for linking an identity from the
+// given provider to the authenticated user. Like Login it sets the state
+// cookie, so the request must be sent with credentials.
+func (oc *OIDCController) StartLink(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.StartLink")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	authURL, state, err := oc.service.StartLogin(c.Request.Context(), c.Param("provider"), uint(userID.(int)))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCStateCookie(c, state, int(services.OIDCLoginStateTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// ListIdentities lists the identities linked to the authenticated user.
+func (oc *OIDCController) ListIdentities(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.ListIdentities")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	identities, err := oc.service.ListIdentities(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+// UnlinkIdentity removes the authenticated user's identity at a provider.
+func (oc *OIDCController) UnlinkIdentity(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.UnlinkIdentity")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := oc.service.UnlinkIdentity(c.Request.Context(), uint(userID.(int)), c.Param("provider")); err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Identity unlinked successfully"})
+}
+
+// DisablePasswordLogin removes the authenticated user's password so that
+// only linked identities can be used to log in.
+func (oc *OIDCController) DisablePasswordLogin(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "OIDCController.DisablePasswordLogin")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	if err := oc.service.DisablePasswordLogin(c.Request.Context(), uint(userID.(int))); err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password login disabled"})
+}
+
+// setOIDCStateCookie sets the state cookie for maxAge seconds, or deletes
+// it when maxAge is negative. Lax cookies are sent on the redirect back from
+// the identity provider.
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	status, body := oidcErrorResponse(err)
+	c.JSON(status, body)
+}
+
+func oidcErrorResponse(err error) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrUnknownProvider), errors.Is(err, services.ErrIdentityNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrInvalidOIDCState):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrOIDCAuthenticationFailed):
+		return http.StatusUnauthorized, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrAccountDisabled):
+		return http.StatusForbidden, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrIdentityInUse), errors.Is(err, services.ErrProviderAlreadyLinked),
+		errors.Is(err, services.ErrAccountExists), errors.Is(err, services.ErrLastLoginMethod):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": "Identity provider login failed"}
+	}
+}
@@ -0,0 +1,243 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockOIDCService is a mock implementation of the OIDCServiceInterface
+type MockOIDCService struct {
+	mock.Mock
+}
+
+var _ services.OIDCServiceInterface = (*MockOIDCService)(nil)
+
+func (m *MockOIDCService) Providers() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *MockOIDCService) StartLogin(ctx context.Context, provider string, linkUserID uint) (string, string, error) {
+	args := m.Called(ctx, provider, linkUserID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockOIDCService) Callback(ctx context.Context, provider, code, state string) (*services.OIDCResult, error) {
+	args := m.Called(ctx, provider, code, state)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.OIDCResult), args.Error(1)
+}
+
+func (m *MockOIDCService) ListIdentities(ctx context.Context, userID uint) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockOIDCService) UnlinkIdentity(ctx context.Context, userID uint, provider string) error {
+	args := m.Called(ctx, userID, provider)
+	return args.Error(0)
+}
+
+func (m *MockOIDCService) DisablePasswordLogin(ctx context.Context, userID uint) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func newOIDCTestContext(method, target string, provider string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(method, target, nil)
+	c.Params = gin.Params{{Key: "provider", Value: provider}}
+	return c, w
+}
+
+func TestOIDCController_Login_Redirects(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/login", "google")
+
+	mockService.On("StartLogin", mock.Anything, "google", uint(0)).Return("https://idp.example/authorize?state=x", "x", nil)
+
+	oidcController.Login(c)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://idp.example/authorize?state=x", w.Header().Get("Location"))
+	assertOIDCStateCookie(t, w, "x")
+}
+
+// assertOIDCStateCookie checks that the response sets the state cookie to
+// state with the attributes that keep it private to this site.
+func assertOIDCStateCookie(t *testing.T, w *httptest.ResponseRecorder, state string) {
+	t.Helper()
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, oidcStateCookie, cookies[0].Name)
+	assert.Equal(t, state, cookies[0].Value)
+	assert.Equal(t, "/", cookies[0].Path)
+	assert.True(t, cookies[0].Secure)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+}
+
+func TestOIDCController_Login_UnknownProvider(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/nope/login", "nope")
+
+	mockService.On("StartLogin", mock.Anything, "nope", uint(0)).Return("", "", services.ErrUnknownProvider)
+
+	oidcController.Login(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOIDCController_Callback_JSON(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/callback?code=abc&state=xyz", "google")
+	c.Request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "xyz"})
+
+	mockService.On("Callback", mock.Anything, "google", "abc", "xyz").Return(&services.OIDCResult{Token: "jwt"}, nil)
+
+	oidcController.Callback(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"token":"jwt","created":false}`, w.Body.String())
+}
+
+func TestOIDCController_Callback_RedirectsToFrontend(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "http://localhost:5173/oidc/callback")
+	c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/callback?code=abc&state=xyz", "google")
+	c.Request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "xyz"})
+
+	mockService.On("Callback", mock.Anything, "google", "abc", "xyz").Return(&services.OIDCResult{Token: "jwt"}, nil)
+
+	oidcController.Callback(c)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Empty(t, location.RawQuery, "the token must not appear in the query string")
+	fragment, err := url.ParseQuery(location.Fragment)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt", fragment.Get("token"))
+}
+
+func TestOIDCController_Callback_Errors(t *testing.T) {
+	tests := map[error]int{
+		services.ErrInvalidOIDCState:         http.StatusBadRequest,
+		services.ErrOIDCAuthenticationFailed: http.StatusUnauthorized,
+		services.ErrAccountExists:            http.StatusConflict,
+	}
+	for serviceErr, status := range tests {
+		t.Run(serviceErr.Error(), func(t *testing.T) {
+			mockService := new(MockOIDCService)
+			oidcController := NewOIDCController(mockService, "")
+			c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/callback?code=abc&state=xyz", "google")
+			c.Request.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: "xyz"})
+
+			mockService.On("Callback", mock.Anything, "google", "abc", "xyz").Return(nil, serviceErr)
+
+			oidcController.Callback(c)
+
+			assert.Equal(t, status, w.Code)
+		})
+	}
+}
+
+func TestOIDCController_Callback_StateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		cookie *http.Cookie
+	}{
+		{"missing", nil},
+		{"mismatched", &http.Cookie{Name: oidcStateCookie, Value: "attacker-state"}},
+		{"empty", &http.Cookie{Name: oidcStateCookie, Value: ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockOIDCService)
+			oidcController := NewOIDCController(mockService, "")
+			c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/callback?code=abc&state=xyz", "google")
+			if tt.cookie != nil {
+				c.Request.AddCookie(tt.cookie)
+			}
+
+			oidcController.Callback(c)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.JSONEq(t, `{"error":"`+services.ErrInvalidOIDCState.Error()+`"}`, w.Body.String())
+			// The login state is not consumed, so the real flow can still
+			// finish in the browser that started it.
+			mockService.AssertNotCalled(t, "Callback", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestOIDCController_Callback_ProviderError(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodGet, "/auth/oidc/google/callback?error=access_denied&state=xyz", "google")
+
+	oidcController.Callback(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertNotCalled(t, "Callback", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCController_StartLink(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodPost, "/api/account/identities/google", "google")
+	c.Set("userID", 1)
+
+	mockService.On("StartLogin", mock.Anything, "google", uint(1)).Return("https://idp.example/authorize?state=x", "x", nil)
+
+	oidcController.StartLink(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"authorization_url":"https://idp.example/authorize?state=x"}`, w.Body.String())
+	assertOIDCStateCookie(t, w, "x")
+}
+
+func TestOIDCController_UnlinkIdentity_LastLoginMethod(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodDelete, "/api/account/identities/google", "google")
+	c.Set("userID", 1)
+
+	mockService.On("UnlinkIdentity", mock.Anything, uint(1), "google").Return(services.ErrLastLoginMethod)
+
+	oidcController.UnlinkIdentity(c)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestOIDCController_DisablePasswordLogin(t *testing.T) {
+	mockService := new(MockOIDCService)
+	oidcController := NewOIDCController(mockService, "")
+	c, w := newOIDCTestContext(http.MethodDelete, "/api/account/password", "")
+	c.Set("userID", 1)
+
+	mockService.On("DisablePasswordLogin", mock.Anything, uint(1)).Return(nil)
+
+	oidcController.DisablePasswordLogin(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+)
+
+// respondPolicyViolation writes a 422 response listing every violated rule if
+// err is a password policy error. It reports whether a response was written.
+func respondPolicyViolation(c *gin.Context, err error) bool {
+	var vErr *password.ValidationError
+	if !errors.As(err, &vErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":      "Password does not meet the password policy",
+		"violations": vErr.Violations,
+	})
+	return true
+}
+
+type PasswordController struct {
+	service services.PasswordServiceInterface
+}
+
+func NewPasswordController(service services.PasswordServiceInterface) *PasswordController {
+	return &PasswordController{service: service}
+}
+
+// ChangePassword changes the password of the authenticated user.
+func (pc *PasswordController) ChangePassword(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "PasswordController.ChangePassword")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := pc.service.ChangePassword(c.Request.Context(), uint(userID.(int)), req.CurrentPassword, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrInvalidCurrentPassword) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if respondPolicyViolation(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+// ForgotPassword starts the password recovery flow. The response is the same
+// whether or not the account exists.
+func (pc *PasswordController) ForgotPassword(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "PasswordController.ForgotPassword")
+	defer span.End()
+
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := pc.service.RequestPasswordReset(c.Request.Context(), req.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request password reset"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password using a token from a reset link.
+func (pc *PasswordController) ResetPassword(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "PasswordController.ResetPassword")
+	defer span.End()
+
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := pc.service.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrInvalidResetToken) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if respondPolicyViolation(c, err) {
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully"})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+)
+
+// MockPasswordService is a mock implementation of the PasswordServiceInterface
+type MockPasswordService struct {
+	mock.Mock
+}
+
+// Statically assert that MockPasswordService implements the interface.
+var _ services.PasswordServiceInterface = (*MockPasswordService)(nil)
+
+func (m *MockPasswordService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	args := m.Called(ctx, userID, currentPassword, newPassword)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) RequestPasswordReset(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
+
+func (m *MockPasswordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	args := m.Called(ctx, token, newPassword)
+	return args.Error(0)
+}
+
+func TestPasswordController_ChangePassword(t *testing.T) {
+	mockService := new(MockPasswordService)
+	passwordController := NewPasswordController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 1)
+
+	req := models.ChangePasswordRequest{CurrentPassword: "old", NewPassword: "new"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/account/password", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("ChangePassword", mock.Anything, uint(1), "old", "new").Return(nil)
+
+	passwordController.ChangePassword(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordController_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	mockService := new(MockPasswordService)
+	passwordController := NewPasswordController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 1)
+
+	req := models.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/account/password", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("ChangePassword", mock.Anything, uint(1), "wrong", "new").Return(services.ErrInvalidCurrentPassword)
+
+	passwordController.ChangePassword(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordController_ForgotPassword(t *testing.T) {
+	mockService := new(MockPasswordService)
+	passwordController := NewPasswordController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req := models.ForgotPasswordRequest{Username: "testuser"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/password/forgot", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("RequestPasswordReset", mock.Anything, "testuser").Return(nil)
+
+	passwordController.ForgotPassword(c)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordController_ResetPassword_InvalidToken(t *testing.T) {
+	mockService := new(MockPasswordService)
+	passwordController := NewPasswordController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	req := models.ResetPasswordRequest{Token: "expired", NewPassword: "new"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/password/reset", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	mockService.On("ResetPassword", mock.Anything, "expired", "new").Return(services.ErrInvalidResetToken)
+
+	passwordController.ResetPassword(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestPasswordController_ChangePassword_PolicyViolation(t *testing.T) {
+	mockService := new(MockPasswordService)
+	passwordController := NewPasswordController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 1)
+
+	req := models.ChangePasswordRequest{CurrentPassword: "old", NewPassword: "a"}
+	jsonValue, _ := json.Marshal(req)
+	c.Request, _ = http.NewRequest(http.MethodPost, "/api/account/password", bytes.NewBuffer(jsonValue))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	policyErr := &password.ValidationError{Violations: []password.Violation{
+		{Rule: password.RuleMinLength, Message: "Password must be at least 8 characters long", Params: map[string]any{"min": 8}},
+	}}
+	mockService.On("ChangePassword", mock.Anything, uint(1), "old", "a").Return(policyErr)
+
+	passwordController.ChangePassword(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var body struct {
+		Violations []password.Violation `json:"violations"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, password.RuleMinLength, body.Violations[0].Rule)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type ReminderController struct {
+	service services.ReminderServiceInterface
+}
+
+func NewReminderController(service services.ReminderServiceInterface) *ReminderController {
+	return &ReminderController{service: service}
+}
+
+// reminderErrorResponse maps reminder errors and falls back to the task
+// errors for everything else.
+func reminderErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidReminder), errors.Is(err, services.ErrReminderInPast):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrTooManyReminders):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrReminderNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+func (rc *ReminderController) ListReminders(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "ReminderController.ListReminders")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	reminders, err := rc.service.ListReminders(c.Request.Context(), uint(userID.(int)), taskID)
+	if err != nil {
+		c.JSON(reminderErrorResponse(err, "Failed to list reminders"))
+		return
+	}
+	c.JSON(http.StatusOK, reminders)
+}
+
+func (rc *ReminderController) CreateReminder(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "ReminderController.CreateReminder")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	var req models.ReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reminder, err := rc.service.CreateReminder(c.Request.Context(), uint(userID.(int)), taskID, req)
+	if err != nil {
+		c.JSON(reminderErrorResponse(err, "Failed to create reminder"))
+		return
+	}
+	c.JSON(http.StatusCreated, reminder)
+}
+
+func (rc *ReminderController) DeleteReminder(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "ReminderController.DeleteReminder")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+	reminderID, ok := pathID(c, "reminder_id", "reminder")
+	if !ok {
+		return
+	}
+
+	if err := rc.service.DeleteReminder(c.Request.Context(), uint(userID.(int)), taskID, reminderID); err != nil {
+		c.JSON(reminderErrorResponse(err, "Failed to delete reminder"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reminder deleted successfully"})
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockReminderService is a mock implementation of the ReminderServiceInterface
+type MockReminderService struct {
+	mock.Mock
+}
+
+var _ services.ReminderServiceInterface = (*MockReminderService)(nil)
+
+func (m *MockReminderService) ListReminders(ctx context.Context, userID uint, taskID int) ([]models.Reminder, error) {
+	args := m.Called(ctx, userID, taskID)
+	return args.Get(0).([]models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderService) CreateReminder(ctx context.Context, userID uint, taskID int, req models.ReminderRequest) (*models.Reminder, error) {
+	args := m.Called(ctx, userID, taskID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderService) DeleteReminder(ctx context.Context, userID uint, taskID, reminderID int) error {
+	args := m.Called(ctx, userID, taskID, reminderID)
+	return args.Error(0)
+}
+
+func TestReminderController_CreateReminder(t *testing.T) {
+	mockService := new(MockReminderService)
+	reminderController := NewReminderController(mockService)
+	offset := 60
+	c, w := newAdminContext(http.MethodPost, "/api/tasks/5/reminders", gin.H{"offset_minutes": offset})
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	mockService.On("CreateReminder", mock.Anything, uint(1), 5, models.ReminderRequest{OffsetMinutes: &offset}).
+		Return(&models.Reminder{ID: 3, TaskID: 5, UserID: 1, OffsetMinutes: &offset}, nil)
+
+	reminderController.CreateReminder(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"offset_minutes":60`)
+}
+
+func TestReminderController_CreateReminder_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"neither time nor offset", services.ErrInvalidReminder, http.StatusBadRequest},
+		{"in the past", services.ErrReminderInPast, http.StatusBadRequest},
+		{"limit reached", services.ErrTooManyReminders, http.StatusConflict},
+		{"task not visible", repositories.ErrTaskNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockReminderService)
+			reminderController := NewReminderController(mockService)
+			c, w := newAdminContext(http.MethodPost, "/api/tasks/5/reminders", gin.H{})
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+			mockService.On("CreateReminder", mock.Anything, uint(1), 5, mock.Anything).Return(nil, tt.err)
+
+			reminderController.CreateReminder(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type SnoozeController struct {
+	service services.SnoozeServiceInterface
+}
+
+func NewSnoozeController(service services.SnoozeServiceInterface) *SnoozeController {
+	return &SnoozeController{service: service}
+}
+
+// snoozeErrorResponse maps snooze errors and falls back to the task errors
+// for everything else.
+func snoozeErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSnooze), errors.Is(err, services.ErrUnknownSnoozePreset),
+		errors.Is(err, services.ErrSnoozeInPast), errors.Is(err, services.ErrSnoozeTooLong),
+		errors.Is(err, services.ErrInvalidTimeZone):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+func (sc *SnoozeController) SnoozeTask(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "SnoozeController.SnoozeTask")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	var req models.SnoozeTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := sc.service.SnoozeTask(c.Request.Context(), uint(userID.(int)), taskID, req)
+	if err != nil {
+		c.JSON(snoozeErrorResponse(err, "Failed to snooze task"))
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+func (sc *SnoozeController) UnsnoozeTask(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "SnoozeController.UnsnoozeTask")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	task, err := sc.service.UnsnoozeTask(c.Request.Context(), uint(userID.(int)), taskID)
+	if err != nil {
+		c.JSON(snoozeErrorResponse(err, "Failed to unsnooze task"))
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockSnoozeService is a mock implementation of the SnoozeServiceInterface
+type MockSnoozeService struct {
+	mock.Mock
+}
+
+var _ services.SnoozeServiceInterface = (*MockSnoozeService)(nil)
+
+func (m *MockSnoozeService) SnoozeTask(ctx context.Context, userID uint, taskID int, req models.SnoozeTaskRequest) (*models.Task, error) {
+	args := m.Called(ctx, userID, taskID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockSnoozeService) UnsnoozeTask(ctx context.Context, userID uint, taskID int) (*models.Task, error) {
+	args := m.Called(ctx, userID, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func TestSnoozeController_SnoozeTask(t *testing.T) {
+	mockService := new(MockSnoozeService)
+	snoozeController := NewSnoozeController(mockService)
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/5/snooze", gin.H{"preset": "next_week", "time_zone": "Europe/Berlin"})
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	until := time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC)
+	mockService.On("SnoozeTask", mock.Anything, uint(1), 5, models.SnoozeTaskRequest{Preset: "next_week", TimeZone: "Europe/Berlin"}).
+		Return(&models.Task{ID: 5, SnoozedUntil: &until}, nil)
+
+	snoozeController.SnoozeTask(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"snoozed_until":"2026-03-30T07:00:00Z"`)
+}
+
+func TestSnoozeController_SnoozeTask_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"unknown preset", services.ErrUnknownSnoozePreset, http.StatusBadRequest},
+		{"in the past", services.ErrSnoozeInPast, http.StatusBadRequest},
+		{"unknown time zone", services.ErrInvalidTimeZone, http.StatusBadRequest},
+		{"viewer", services.ErrListPermissionDenied, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockSnoozeService)
+			snoozeController := NewSnoozeController(mockService)
+			c, w := newAdminContext(http.MethodPut, "/api/tasks/5/snooze", gin.H{"preset": "someday"})
+			c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+			mockService.On("SnoozeTask", mock.Anything, uint(1), 5, mock.Anything).Return(nil, tt.err)
+
+			snoozeController.SnoozeTask(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestSnoozeController_UnsnoozeTask(t *testing.T) {
+	mockService := new(MockSnoozeService)
+	snoozeController := NewSnoozeController(mockService)
+	c, w := newAdminContext(http.MethodDelete, "/api/tasks/5/snooze", nil)
+	c.Params = gin.Params{{Key: "id", Value: "5"}}
+
+	mockService.On("UnsnoozeTask", mock.Anything, uint(1), 5).Return(&models.Task{ID: 5}, nil)
+
+	snoozeController.UnsnoozeTask(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "snoozed_until")
+}
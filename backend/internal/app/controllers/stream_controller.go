@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// streamRetry is the reconnection delay, in milliseconds, suggested to
+// clients.
+const streamRetry = 3000
+
+type StreamController struct {
+	service   services.StreamServiceInterface
+	heartbeat time.Duration
+}
+
+func NewStreamController(service services.StreamServiceInterface, heartbeat time.Duration) *StreamController {
+	return &StreamController{service: service, heartbeat: heartbeat}
+}
+
+// Stream sends the task events of the user's lists as Server-Sent Events.
+// Each event's id is its position in the outbox, which clients send back in
+// the Last-Event-ID header to resume. A "reset" event tells them that events
+// were missed and tasks should be reloaded, and a "heartbeat" event is sent
+// whenever the stream was idle for the heartbeat interval.
+func (sc *StreamController) Stream(c *gin.Context) {
+	sub, ok := sc.subscribe(c)
+	if !ok {
+		return
+	}
+	defer sc.service.Unsubscribe(sub)
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// Keep reverse proxies such as nginx from buffering the stream.
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry)
+	if sub.Reset {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range sub.Replay {
+		if err := writeStreamEvent(c.Writer, event); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(sc.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := writeStreamEvent(c.Writer, event); err != nil {
+				return
+			}
+			heartbeat.Reset(sc.heartbeat)
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(c.Writer, "event: heartbeat\ndata: {}\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+// subscribe opens the stream of the request or writes the error response.
+// It is traced on its own, as the stream may stay open for hours.
+func (sc *StreamController) subscribe(c *gin.Context) (*services.StreamSubscription, bool) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "StreamController.Stream")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return nil, false
+	}
+	var lastEventID int64
+	if v := c.GetHeader("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return nil, false
+		}
+		lastEventID = id
+	}
+
+	sub, err := sc.service.Subscribe(c.Request.Context(), uint(userID.(int)), lastEventID)
+	if errors.Is(err, services.ErrTooManyStreams) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open event stream"})
+		return nil, false
+	}
+	return sub, true
+}
+
+func writeStreamEvent(w io.Writer, event models.StreamEvent) error {
+	data, err := json.Marshal(event.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Event.Type, data)
+	return err
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockStreamService is a mock implementation of the StreamServiceInterface
+type MockStreamService struct {
+	mock.Mock
+}
+
+var _ services.StreamServiceInterface = (*MockStreamService)(nil)
+
+func (m *MockStreamService) Subscribe(ctx context.Context, userID uint, lastEventID int64) (*services.StreamSubscription, error) {
+	args := m.Called(ctx, userID, lastEventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*services.StreamSubscription), args.Error(1)
+}
+
+func (m *MockStreamService) Unsubscribe(sub *services.StreamSubscription) {
+	m.Called(sub)
+}
+
+func TestStreamController_Stream(t *testing.T) {
+	mockService := new(MockStreamService)
+	streamController := NewStreamController(mockService, time.Minute)
+	c, w := newAdminContext(http.MethodGet, "/api/stream", nil)
+	c.Request.Header.Set("Last-Event-ID", "10")
+
+	events := make(chan models.StreamEvent, 1)
+	events <- models.StreamEvent{Seq: 12, Event: models.Event{ID: "b", Type: models.EventTaskDeleted}}
+	close(events)
+	sub := &services.StreamSubscription{
+		Replay: []models.StreamEvent{{Seq: 11, Event: models.Event{ID: "a", Type: models.EventTaskUpdated}}},
+		Events: events,
+	}
+	mockService.On("Subscribe", mock.Anything, uint(1), int64(10)).Return(sub, nil)
+	mockService.On("Unsubscribe", sub).Return()
+
+	streamController.Stream(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	body := w.Body.String()
+	assert.Contains(t, body, "id: 11\nevent: task.updated\ndata: {\"id\":\"a\"")
+	assert.Contains(t, body, "id: 12\nevent: task.deleted\ndata: {\"id\":\"b\"")
+	assert.NotContains(t, body, "event: reset")
+	mockService.AssertExpectations(t)
+}
+
+func TestStreamController_Stream_ResetAndHeartbeat(t *testing.T) {
+	mockService := new(MockStreamService)
+	streamController := NewStreamController(mockService, time.Millisecond)
+	c, w := newAdminContext(http.MethodGet, "/api/stream", nil)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 50*time.Millisecond)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	sub := &services.StreamSubscription{Reset: true, Events: make(chan models.StreamEvent)}
+	mockService.On("Subscribe", mock.Anything, uint(1), int64(0)).Return(sub, nil)
+	mockService.On("Unsubscribe", sub).Return()
+
+	streamController.Stream(c)
+
+	assert.Contains(t, w.Body.String(), "event: reset\n")
+	assert.Contains(t, w.Body.String(), "event: heartbeat\n")
+	mockService.AssertExpectations(t)
+}
+
+func TestStreamController_Stream_Errors(t *testing.T) {
+	t.Run("invalid Last-Event-ID", func(t *testing.T) {
+		mockService := new(MockStreamService)
+		streamController := NewStreamController(mockService, time.Minute)
+		c, w := newAdminContext(http.MethodGet, "/api/stream", nil)
+		c.Request.Header.Set("Last-Event-ID", "abc")
+
+		streamController.Stream(c)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Subscribe", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("too many streams", func(t *testing.T) {
+		mockService := new(MockStreamService)
+		streamController := NewStreamController(mockService, time.Minute)
+		c, w := newAdminContext(http.MethodGet, "/api/stream", nil)
+
+		mockService.On("Subscribe", mock.Anything, uint(1), int64(0)).Return(nil, services.ErrTooManyStreams)
+
+		streamController.Stream(c)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		mockService.AssertNotCalled(t, "Unsubscribe", mock.Anything)
+	})
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type SyncController struct {
+	service services.SyncServiceInterface
+}
+
+func NewSyncController(service services.SyncServiceInterface) *SyncController {
+	return &SyncController{service: service}
+}
+
+func syncErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSyncToken), errors.Is(err, services.ErrTooManySyncChanges):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": fallback}
+	}
+}
+
+// Changes returns the changes to the user's tasks since the sync token in
+// the "since" query parameter, or every task without one.
+func (sc *SyncController) Changes(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "SyncController.Changes")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	changes, err := sc.service.Changes(c.Request.Context(), uint(userID.(int)), c.Query("since"))
+	if err != nil {
+		c.JSON(syncErrorResponse(err, "Failed to read changes"))
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// Apply applies a batch of changes the client made offline and reports
+// which were rejected and why.
+func (sc *SyncController) Apply(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "SyncController.Apply")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := sc.service.Apply(c.Request.Context(), uint(userID.(int)), req)
+	if err != nil {
+		c.JSON(syncErrorResponse(err, "Failed to apply changes"))
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
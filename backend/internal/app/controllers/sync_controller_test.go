@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockSyncService is a mock implementation of the SyncServiceInterface
+type MockSyncService struct {
+	mock.Mock
+}
+
+var _ services.SyncServiceInterface = (*MockSyncService)(nil)
+
+func (m *MockSyncService) Changes(ctx context.Context, userID uint, since string) (*models.SyncChanges, error) {
+	args := m.Called(ctx, userID, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SyncChanges), args.Error(1)
+}
+
+func (m *MockSyncService) Apply(ctx context.Context, userID uint, req models.SyncRequest) (*models.SyncResponse, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SyncResponse), args.Error(1)
+}
+
+func TestSyncController_Changes(t *testing.T) {
+	mockService := new(MockSyncService)
+	syncController := NewSyncController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/sync?since=abc", nil)
+
+	mockService.On("Changes", mock.Anything, uint(1), "abc").Return(&models.SyncChanges{
+		Token:   "def",
+		Created: []models.Task{{ID: 4, Title: "New"}},
+		Updated: []models.Task{},
+		Deleted: []int{2},
+	}, nil)
+
+	syncController.Changes(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got models.SyncChanges
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "def", got.Token)
+	assert.Equal(t, []int{2}, got.Deleted)
+	assert.Len(t, got.Created, 1)
+}
+
+func TestSyncController_Changes_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid token", services.ErrInvalidSyncToken, http.StatusBadRequest},
+		{"internal", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockSyncService)
+			syncController := NewSyncController(mockService)
+			c, w := newAdminContext(http.MethodGet, "/api/sync?since=x", nil)
+			mockService.On("Changes", mock.Anything, uint(1), "x").Return(nil, tt.err)
+
+			syncController.Changes(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestSyncController_Apply(t *testing.T) {
+	mockService := new(MockSyncService)
+	syncController := NewSyncController(mockService)
+	title := "Renamed"
+	c, w := newAdminContext(http.MethodPost, "/api/sync", gin.H{
+		"since":   "abc",
+		"changes": []gin.H{{"client_id": "c1", "op": "update", "task_id": 4, "title": title}},
+	})
+
+	req := models.SyncRequest{Since: "abc", Changes: []models.SyncChange{{ClientID: "c1", Op: models.SyncUpdate, TaskID: 4, Title: &title}}}
+	mockService.On("Apply", mock.Anything, uint(1), req).Return(&models.SyncResponse{Results: []models.SyncResult{{
+		ClientID:       "c1",
+		Status:         models.SyncRejected,
+		Reason:         models.SyncConflict,
+		RejectedFields: []string{models.SyncFieldTitle},
+		Task:           &models.Task{ID: 4, Title: "Theirs"},
+	}}}, nil)
+
+	syncController.Apply(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"rejected","reason":"conflict"`)
+	assert.Contains(t, w.Body.String(), `"rejected_fields":["title"]`)
+}
+
+func TestSyncController_Apply_BadRequest(t *testing.T) {
+	mockService := new(MockSyncService)
+	syncController := NewSyncController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/sync", gin.H{"since": "abc"})
+
+	syncController.Apply(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "Apply", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSyncController_Apply_TooMany(t *testing.T) {
+	mockService := new(MockSyncService)
+	syncController := NewSyncController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/sync", gin.H{"changes": []gin.H{}})
+
+	mockService.On("Apply", mock.Anything, uint(1), mock.Anything).Return(nil, services.ErrTooManySyncChanges)
+
+	syncController.Apply(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrTooManySyncChanges.Error())
+}
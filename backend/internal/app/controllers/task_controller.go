@@ -21,6 +21,33 @@ func NewTaskController(service services.TaskServiceInterface) *TaskController {
 	return &TaskController{service: service}
 }
 
+// taskErrorResponse maps a task or task list service error to a status code
+// and body.
+func taskErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, repositories.ErrTaskNotFound), errors.Is(err, services.ErrTaskListNotFound),
+		errors.Is(err, services.ErrListMemberNotFound), errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrWorkspaceNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrListPermissionDenied), errors.Is(err, services.ErrListOwner),
+		errors.Is(err, services.ErrPersonalList):
+		return http.StatusForbidden, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrInvalidListName), errors.Is(err, services.ErrInvalidListRole),
+		errors.Is(err, services.ErrInvalidAssignee), errors.Is(err, services.ErrListRequired),
+		errors.Is(err, services.ErrInvalidClock), errors.Is(err, services.ErrInvalidPosition):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrAlreadyListMember):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	default:
+		return http.StatusInternalServerError, gin.H{"error": fallback}
+	}
+}
+
+// GetTasks returns the tasks of all lists of the user. The "list_id" query
+// parameter limits them to one list, and "assignee" to the tasks assigned to
+// "me", to a user ID, or to "unassigned" tasks. Snoozed tasks are left out
+// unless "snoozed" is "include", or "only" to return nothing else. Tasks
+// are ordered by ID, or by the user's manual order if "order" is "manual".
 func (tc *TaskController) GetTasks(c *gin.Context) {
 	utils.RandomSleep()
 	_, span := otel.Tracer("TaskController").Start(c.Request.Context(), "TaskController.GetTasks")
@@ -32,9 +59,51 @@ func (tc *TaskController) GetTasks(c *gin.Context) {
 		return
 	}
 
-	tasks, err := tc.service.GetTasks(c.Request.Context(), uint(userID.(int)))
+	var filter models.TaskFilter
+	if raw := c.Query("list_id"); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 31)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid list ID"})
+			return
+		}
+		filter.ListID = int(id)
+	}
+	switch raw := c.Query("assignee"); raw {
+	case "":
+	case "me":
+		filter.AssigneeID = userID.(int)
+	case "unassigned":
+		filter.Unassigned = true
+	default:
+		id, err := strconv.ParseUint(raw, 10, 31)
+		if err != nil || id == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "assignee must be \"me\", \"unassigned\" or a user ID"})
+			return
+		}
+		filter.AssigneeID = int(id)
+	}
+	switch c.Query("snoozed") {
+	case "", "exclude":
+	case "include":
+		filter.IncludeSnoozed = true
+	case "only":
+		filter.SnoozedOnly = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "snoozed must be \"exclude\", \"include\" or \"only\""})
+		return
+	}
+	switch c.Query("order") {
+	case "", "id":
+	case "manual":
+		filter.ManualOrder = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "order must be \"id\" or \"manual\""})
+		return
+	}
+
+	tasks, err := tc.service.GetTasks(c.Request.Context(), uint(userID.(int)), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		c.JSON(taskErrorResponse(err, "Failed to retrieve tasks"))
 		return
 	}
 
@@ -60,7 +129,7 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 
 	createdTask, err := tc.service.CreateTask(c.Request.Context(), &task, uint(userID.(int)))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create task"})
+		c.JSON(taskErrorResponse(err, "Failed to create task"))
 		return
 	}
 
@@ -91,11 +160,7 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 	}
 
 	if err := tc.service.UpdateTask(c.Request.Context(), &task, uint(taskID), uint(userID.(int))); err != nil {
-		if errors.Is(err, repositories.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+		c.JSON(taskErrorResponse(err, "Failed to update task"))
 		return
 	}
 
@@ -120,13 +185,113 @@ func (tc *TaskController) DeleteTask(c *gin.Context) {
 	}
 
 	if err := tc.service.DeleteTask(c.Request.Context(), uint(taskID), uint(userID.(int))); err != nil {
-		if errors.Is(err, repositories.ErrTaskNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete task"})
+		c.JSON(taskErrorResponse(err, "Failed to delete task"))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Task deleted successfully"})
-}
\ No newline at end of file
+}
+
+// AssignTask sets the assignee of a task. The assignee must be a member of
+// the task's list.
+func (tc *TaskController) AssignTask(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskController.AssignTask")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	var req models.AssignTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := tc.service.AssignTask(c.Request.Context(), uint(taskID), uint(userID.(int)), req.AssigneeID)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to assign task"))
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+func (tc *TaskController) UnassignTask(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskController.UnassignTask")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	task, err := tc.service.UnassignTask(c.Request.Context(), uint(taskID), uint(userID.(int)))
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to unassign task"))
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// ListAssignments returns who assigned a task to whom and when.
+func (tc *TaskController) ListAssignments(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskController.ListAssignments")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+
+	assignments, err := tc.service.ListAssignments(c.Request.Context(), uint(taskID), uint(userID.(int)))
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to list assignments"))
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// MoveTask places a task in the user's manual order and returns it with
+// the position that won.
+func (tc *TaskController) MoveTask(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskController.MoveTask")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	taskID, ok := pathID(c, "id", "task")
+	if !ok {
+		return
+	}
+	var req models.MoveTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := tc.service.MoveTask(c.Request.Context(), uint(taskID), uint(userID.(int)), req)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to move task"))
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
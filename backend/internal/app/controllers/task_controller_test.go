@@ -14,6 +14,7 @@ import (
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
 )
 
 // MockTaskService is a mock that implements the TaskServiceInterface
@@ -24,8 +25,8 @@ type MockTaskService struct {
 // Statically assert that MockTaskService implements the interface.
 var _ services.TaskServiceInterface = (*MockTaskService)(nil)
 
-func (m *MockTaskService) GetTasks(ctx context.Context, userID uint) ([]models.Task, error) {
-	args := m.Called(ctx, userID)
+func (m *MockTaskService) GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error) {
+	args := m.Called(ctx, userID, filter)
 	return args.Get(0).([]models.Task), args.Error(1)
 }
 
@@ -47,6 +48,35 @@ func (m *MockTaskService) DeleteTask(ctx context.Context, taskID uint, userID ui
 	return args.Error(0)
 }
 
+func (m *MockTaskService) AssignTask(ctx context.Context, taskID uint, userID uint, assigneeID int) (*models.Task, error) {
+	args := m.Called(ctx, taskID, userID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskService) UnassignTask(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
+	args := m.Called(ctx, taskID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskService) ListAssignments(ctx context.Context, taskID uint, userID uint) ([]models.TaskAssignment, error) {
+	args := m.Called(ctx, taskID, userID)
+	return args.Get(0).([]models.TaskAssignment), args.Error(1)
+}
+
+func (m *MockTaskService) MoveTask(ctx context.Context, taskID uint, userID uint, req models.MoveTaskRequest) (*models.Task, error) {
+	args := m.Called(ctx, taskID, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
 func TestTaskController_GetTasks(t *testing.T) {
 	mockService := new(MockTaskService)
 	taskController := NewTaskController(mockService)
@@ -57,8 +87,8 @@ func TestTaskController_GetTasks(t *testing.T) {
 	c.Request, _ = http.NewRequest(http.MethodGet, "/tasks", nil)
 	c.Set("userID", 1)
 
-	tasks := []models.Task{{ID: 1, UserID: 1, Title: "Test Task"}}
-	mockService.On("GetTasks", mock.Anything, uint(1)).Return(tasks, nil)
+	tasks := []models.Task{{ID: 1, ListID: 1, CreatedBy: 1, Title: "Test Task"}}
+	mockService.On("GetTasks", mock.Anything, uint(1), models.TaskFilter{}).Return(tasks, nil)
 
 	taskController.GetTasks(c)
 
@@ -80,7 +110,7 @@ func TestTaskController_CreateTask(t *testing.T) {
 	c.Request, _ = http.NewRequest(http.MethodPost, "/tasks", bytes.NewBuffer(jsonValue))
 	c.Request.Header.Set("Content-Type", "application/json")
 
-	createdTask := &models.Task{ID: 1, UserID: 1, Title: "New Task", Completed: false}
+	createdTask := &models.Task{ID: 1, ListID: 1, CreatedBy: 1, Title: "New Task", Completed: false}
 	mockService.On("CreateTask", mock.Anything, mock.AnythingOfType("*models.Task"), uint(1)).Return(createdTask, nil)
 
 	taskController.CreateTask(c)
@@ -172,4 +202,212 @@ func TestTaskController_DeleteTask_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	mockService.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestTaskController_GetTasks_ByList(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/tasks?list_id=5", nil)
+	c.Set("userID", 1)
+
+	mockService.On("GetTasks", mock.Anything, uint(1), models.TaskFilter{ListID: 5}).Return([]models.Task(nil), services.ErrTaskListNotFound)
+
+	taskController.GetTasks(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskController_DeleteTask_ViewerForbidden(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Set("userID", 2)
+	c.Params = gin.Params{gin.Param{Key: "id", Value: "1"}}
+	c.Request, _ = http.NewRequest(http.MethodDelete, "/tasks/1", nil)
+
+	mockService.On("DeleteTask", mock.Anything, uint(1), uint(2)).Return(services.ErrListPermissionDenied)
+
+	taskController.DeleteTask(c)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskController_GetTasks_AssigneeFilter(t *testing.T) {
+	tests := []struct {
+		query  string
+		filter models.TaskFilter
+	}{
+		{"assignee=me", models.TaskFilter{AssigneeID: 1}},
+		{"assignee=unassigned&list_id=3", models.TaskFilter{ListID: 3, Unassigned: true}},
+		{"assignee=7", models.TaskFilter{AssigneeID: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			mockService := new(MockTaskService)
+			taskController := NewTaskController(mockService)
+
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+			c.Set("userID", 1)
+
+			mockService.On("GetTasks", mock.Anything, uint(1), tt.filter).Return([]models.Task{}, nil)
+
+			taskController.GetTasks(c)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTaskController_GetTasks_InvalidAssignee(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest(http.MethodGet, "/tasks?assignee=someone", nil)
+	c.Set("userID", 1)
+
+	taskController.GetTasks(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTaskController_AssignTask(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/4/assignee", models.AssignTaskRequest{AssigneeID: 5})
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+
+	mockService.On("AssignTask", mock.Anything, uint(4), uint(1), 5).Return(&models.Task{ID: 4, AssigneeID: 5, AssignedBy: 1}, nil)
+
+	taskController.AssignTask(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"assignee_id":5`)
+}
+
+func TestTaskController_AssignTask_NotAMember(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/4/assignee", models.AssignTaskRequest{AssigneeID: 9})
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+
+	mockService.On("AssignTask", mock.Anything, uint(4), uint(1), 9).Return(nil, services.ErrInvalidAssignee)
+
+	taskController.AssignTask(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTaskController_GetTasks_SnoozedFilter(t *testing.T) {
+	tests := []struct {
+		query  string
+		filter models.TaskFilter
+		status int
+	}{
+		{"snoozed=include", models.TaskFilter{IncludeSnoozed: true}, http.StatusOK},
+		{"snoozed=only&assignee=me", models.TaskFilter{AssigneeID: 1, SnoozedOnly: true}, http.StatusOK},
+		{"snoozed=exclude", models.TaskFilter{}, http.StatusOK},
+		{"snoozed=yes", models.TaskFilter{}, http.StatusBadRequest},
+		{"order=manual", models.TaskFilter{ManualOrder: true}, http.StatusOK},
+		{"order=title", models.TaskFilter{}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			mockService := new(MockTaskService)
+			taskController := NewTaskController(mockService)
+
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest(http.MethodGet, "/tasks?"+tt.query, nil)
+			c.Set("userID", 1)
+
+			mockService.On("GetTasks", mock.Anything, uint(1), tt.filter).Return([]models.Task{}, nil)
+
+			taskController.GetTasks(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusBadRequest {
+				mockService.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestTaskController_UpdateTask_Clock(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	body := map[string]any{"title": "Offline", "clock": map[string]string{"title": "018bcfe56800-00000000-phone"}}
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/1", body)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	stamp := crdt.Timestamp{Wall: 1_700_000_000_000, Node: "phone"}
+	mockService.On("UpdateTask", mock.Anything, mock.MatchedBy(func(task *models.Task) bool {
+		return task.Title == "Offline" && task.Clock.Title == stamp && task.Clock.Completed.IsZero()
+	}), uint(1), uint(1)).Return(services.ErrInvalidClock)
+
+	taskController.UpdateTask(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestTaskController_UpdateTask_InvalidClock(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	body := map[string]any{"title": "Offline", "clock": map[string]string{"title": "yesterday"}}
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/1", body)
+	c.Params = gin.Params{{Key: "id", Value: "1"}}
+
+	taskController.UpdateTask(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTaskController_MoveTask(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	req := models.MoveTaskRequest{Position: "k2phonei", Clock: crdt.Timestamp{Wall: 1_700_000_000_000, Node: "phone"}}
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/4/position", req)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+
+	task := &models.Task{ID: 4, Position: req.Position, Clock: models.TaskClock{Position: req.Clock}}
+	mockService.On("MoveTask", mock.Anything, uint(4), uint(1), req).Return(task, nil)
+
+	taskController.MoveTask(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"position":"k2phonei"`)
+	assert.Contains(t, w.Body.String(), `"position":"018bcfe56800-00000000-phone"`)
+}
+
+func TestTaskController_MoveTask_InvalidPosition(t *testing.T) {
+	mockService := new(MockTaskService)
+	taskController := NewTaskController(mockService)
+	req := models.MoveTaskRequest{Position: "K0"}
+	c, w := newAdminContext(http.MethodPut, "/api/tasks/4/position", req)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+
+	mockService.On("MoveTask", mock.Anything, uint(4), uint(1), req).Return(nil, services.ErrInvalidPosition)
+
+	taskController.MoveTask(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
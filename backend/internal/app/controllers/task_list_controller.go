@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type TaskListController struct {
+	service services.TaskListServiceInterface
+}
+
+func NewTaskListController(service services.TaskListServiceInterface) *TaskListController {
+	return &TaskListController{service: service}
+}
+
+// pathID parses the route parameter name as a positive ID. It writes a
+// response and returns false if the ID is invalid.
+func pathID(c *gin.Context, name, what string) (int, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 31)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + what + " ID"})
+		return 0, false
+	}
+	return int(id), true
+}
+
+func (lc *TaskListController) ListLists(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.ListLists")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	lists, err := lc.service.ListLists(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list task lists"})
+		return
+	}
+	c.JSON(http.StatusOK, lists)
+}
+
+func (lc *TaskListController) CreateList(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.CreateList")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.TaskListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := lc.service.CreateList(c.Request.Context(), uint(userID.(int)), req.Name)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to create task list"))
+		return
+	}
+	c.JSON(http.StatusCreated, list)
+}
+
+func (lc *TaskListController) GetList(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.GetList")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	list, err := lc.service.GetList(c.Request.Context(), uint(userID.(int)), listID)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to get task list"))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (lc *TaskListController) RenameList(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.RenameList")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	var req models.TaskListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := lc.service.RenameList(c.Request.Context(), uint(userID.(int)), listID, req.Name)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to rename task list"))
+		return
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+func (lc *TaskListController) DeleteList(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.DeleteList")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	if err := lc.service.DeleteList(c.Request.Context(), uint(userID.(int)), listID); err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to delete task list"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Task list deleted successfully"})
+}
+
+func (lc *TaskListController) ListMembers(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.ListMembers")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	members, err := lc.service.ListMembers(c.Request.Context(), uint(userID.(int)), listID)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to list members"))
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+func (lc *TaskListController) AddMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.AddMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+
+	var req models.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := lc.service.AddMember(c.Request.Context(), uint(userID.(int)), listID, req)
+	if err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to add member"))
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+func (lc *TaskListController) UpdateMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.UpdateMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id", "user")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := lc.service.UpdateMember(c.Request.Context(), uint(userID.(int)), listID, memberID, req.Role); err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to update member"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member updated successfully"})
+}
+
+// RemoveMember removes a member from a list. Members remove themselves to
+// leave a list.
+func (lc *TaskListController) RemoveMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TaskListController.RemoveMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	listID, ok := pathID(c, "id", "list")
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id", "user")
+	if !ok {
+		return
+	}
+
+	if err := lc.service.RemoveMember(c.Request.Context(), uint(userID.(int)), listID, memberID); err != nil {
+		c.JSON(taskErrorResponse(err, "Failed to remove member"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
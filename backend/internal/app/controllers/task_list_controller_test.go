@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockTaskListService is a mock implementation of the TaskListServiceInterface
+type MockTaskListService struct {
+	mock.Mock
+}
+
+var _ services.TaskListServiceInterface = (*MockTaskListService)(nil)
+
+func (m *MockTaskListService) ListLists(ctx context.Context, userID uint) ([]models.TaskList, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListService) CreateList(ctx context.Context, userID uint, name string) (*models.TaskList, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListService) GetList(ctx context.Context, userID uint, listID int) (*models.TaskList, error) {
+	args := m.Called(ctx, userID, listID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListService) RenameList(ctx context.Context, userID uint, listID int, name string) (*models.TaskList, error) {
+	args := m.Called(ctx, userID, listID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListService) DeleteList(ctx context.Context, userID uint, listID int) error {
+	args := m.Called(ctx, userID, listID)
+	return args.Error(0)
+}
+
+func (m *MockTaskListService) ListMembers(ctx context.Context, userID uint, listID int) ([]models.ListMember, error) {
+	args := m.Called(ctx, userID, listID)
+	return args.Get(0).([]models.ListMember), args.Error(1)
+}
+
+func (m *MockTaskListService) AddMember(ctx context.Context, userID uint, listID int, req models.AddMemberRequest) (*models.ListMember, error) {
+	args := m.Called(ctx, userID, listID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ListMember), args.Error(1)
+}
+
+func (m *MockTaskListService) UpdateMember(ctx context.Context, userID uint, listID, memberID int, role models.ListRole) error {
+	args := m.Called(ctx, userID, listID, memberID, role)
+	return args.Error(0)
+}
+
+func (m *MockTaskListService) RemoveMember(ctx context.Context, userID uint, listID, memberID int) error {
+	args := m.Called(ctx, userID, listID, memberID)
+	return args.Error(0)
+}
+
+func TestTaskListController_AddMember(t *testing.T) {
+	mockService := new(MockTaskListService)
+	listController := NewTaskListController(mockService)
+	req := models.AddMemberRequest{Login: "bob", Role: models.ListRoleEditor}
+	c, w := newAdminContext(http.MethodPost, "/api/lists/3/members", req)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+	mockService.On("AddMember", mock.Anything, uint(1), 3, req).
+		Return(&models.ListMember{UserID: 2, Username: "bob", Role: models.ListRoleEditor}, nil)
+
+	listController.AddMember(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"editor"`)
+}
+
+func TestTaskListController_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not a member", services.ErrTaskListNotFound, http.StatusNotFound},
+		{"role too low", services.ErrListPermissionDenied, http.StatusForbidden},
+		{"owner", services.ErrListOwner, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTaskListService)
+			listController := NewTaskListController(mockService)
+			c, w := newAdminContext(http.MethodDelete, "/api/lists/3/members/2", nil)
+			c.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "2"}}
+
+			mockService.On("RemoveMember", mock.Anything, uint(1), 3, 2).Return(tt.err)
+
+			listController.RemoveMember(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Contains(t, w.Body.String(), tt.err.Error())
+		})
+	}
+}
+
+func TestTaskListController_GetList_InvalidID(t *testing.T) {
+	mockService := new(MockTaskListService)
+	listController := NewTaskListController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/lists/abc", nil)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	listController.GetList(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "GetList", mock.Anything, mock.Anything, mock.Anything)
+}
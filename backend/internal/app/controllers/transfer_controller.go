@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+)
+
+type TransferController struct {
+	service services.TransferServiceInterface
+}
+
+func NewTransferController(service services.TransferServiceInterface) *TransferController {
+	return &TransferController{service: service}
+}
+
+func transferErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTransferFormat), errors.Is(err, services.ErrInvalidImportFile):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrImportTooLarge):
+		return http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrImportJobNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+// transferContentTypes are the media types of the transfer formats.
+var transferContentTypes = map[string]string{
+	models.TransferJSON: "application/json",
+	models.TransferCSV:  "text/csv",
+	models.TransferICS:  "text/calendar",
+}
+
+// Export downloads every task of the user's lists as a file in the format
+// of the "format" query parameter, json by default.
+func (tc *TransferController) Export(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TransferController.Export")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	format := c.DefaultQuery("format", models.TransferJSON)
+	contentType, ok := transferContentTypes[format]
+	if !ok {
+		c.JSON(transferErrorResponse(services.ErrInvalidTransferFormat, ""))
+		return
+	}
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="tasks.%s"`, format))
+
+	// Tasks are written as they are read, so an error after the first one
+	// can only cut the download short.
+	if err := tc.service.Export(c.Request.Context(), uint(userID.(int)), format, c.Writer); err != nil {
+		if c.Writer.Written() {
+			logging.ContextLogger(c.Request.Context()).Error("Export failed after it started", "error", err)
+			return
+		}
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		c.JSON(transferErrorResponse(err, "Failed to export tasks"))
+	}
+}
+
+// Import creates tasks from the file in the request body. Its format is
+// the "format" query parameter, or else inferred from the Content-Type. With
+// "dry_run=true" it only previews the import. Small imports are applied
+// before the response; larger ones answer 202 with the job that applies
+// them, which can be polled at the Location.
+func (tc *TransferController) Import(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TransferController.Import")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		for f, contentType := range transferContentTypes {
+			if mediaType == contentType {
+				format = f
+			}
+		}
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	resp, err := tc.service.Import(c.Request.Context(), uint(userID.(int)), format, c.Request.Body, dryRun)
+	if errors.Is(err, services.ErrInvalidImport) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "preview": resp.Preview})
+		return
+	}
+	if err != nil {
+		c.JSON(transferErrorResponse(err, "Failed to import tasks"))
+		return
+	}
+	if resp.Job != nil && resp.Job.Status != models.ImportCompleted && resp.Job.Status != models.ImportFailed {
+		c.Header("Location", fmt.Sprintf("/api/import/jobs/%d", resp.Job.ID))
+		c.JSON(http.StatusAccepted, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetImportJob reports the progress of an import.
+func (tc *TransferController) GetImportJob(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "TransferController.GetImportJob")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	jobID, ok := pathID(c, "id", "import job")
+	if !ok {
+		return
+	}
+
+	job, err := tc.service.GetImportJob(c.Request.Context(), uint(userID.(int)), jobID)
+	if err != nil {
+		c.JSON(transferErrorResponse(err, "Failed to get import job"))
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
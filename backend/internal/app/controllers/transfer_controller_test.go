@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockTransferService is a mock implementation of the TransferServiceInterface
+type MockTransferService struct {
+	mock.Mock
+}
+
+var _ services.TransferServiceInterface = (*MockTransferService)(nil)
+
+// Export writes the first return value to w.
+func (m *MockTransferService) Export(ctx context.Context, userID uint, format string, w io.Writer) error {
+	args := m.Called(ctx, userID, format)
+	if out := args.String(0); out != "" {
+		_, _ = io.WriteString(w, out)
+	}
+	return args.Error(1)
+}
+
+// Import reads r so that tests can check the body it was given.
+func (m *MockTransferService) Import(ctx context.Context, userID uint, format string, r io.Reader, dryRun bool) (*models.ImportResponse, error) {
+	body, _ := io.ReadAll(r)
+	args := m.Called(ctx, userID, format, string(body), dryRun)
+	resp, _ := args.Get(0).(*models.ImportResponse)
+	return resp, args.Error(1)
+}
+
+func (m *MockTransferService) GetImportJob(ctx context.Context, userID uint, jobID int) (*models.ImportJob, error) {
+	args := m.Called(ctx, userID, jobID)
+	job, _ := args.Get(0).(*models.ImportJob)
+	return job, args.Error(1)
+}
+
+func newImportContext(target, contentType, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	c, w := newAdminContext(http.MethodPost, target, nil)
+	c.Request.Body = io.NopCloser(strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", contentType)
+	return c, w
+}
+
+func TestTransferController_Export(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/export?format=csv", nil)
+	mockService.On("Export", mock.Anything, uint(1), models.TransferCSV).Return("id,list_id\n", nil)
+
+	transferController.Export(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="tasks.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,list_id\n", w.Body.String())
+}
+
+func TestTransferController_Export_DefaultsToJSON(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/export", nil)
+	mockService.On("Export", mock.Anything, uint(1), models.TransferJSON).Return("[]\n", nil)
+
+	transferController.Export(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestTransferController_Export_Errors(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/export?format=xml", nil)
+
+	transferController.Export(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "Export", mock.Anything, mock.Anything, mock.Anything)
+
+	// An error before anything was written is reported as JSON rather
+	// than as a download.
+	c, w = newAdminContext(http.MethodGet, "/api/export", nil)
+	mockService.On("Export", mock.Anything, uint(1), models.TransferJSON).Return("", errors.New("db down"))
+
+	transferController.Export(c)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	assert.JSONEq(t, `{"error": "Failed to export tasks"}`, w.Body.String())
+}
+
+func TestTransferController_Import(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		format   string
+		job      *models.ImportJob
+		status   int
+		location string
+	}{
+		{"dry run", "/api/import?dry_run=true", models.TransferCSV, nil, http.StatusOK, ""},
+		{"inline", "/api/import", models.TransferCSV, &models.ImportJob{ID: 3, Status: models.ImportCompleted}, http.StatusOK, ""},
+		{"background", "/api/import", models.TransferCSV, &models.ImportJob{ID: 3, Status: models.ImportPending}, http.StatusAccepted, "/api/import/jobs/3"},
+		{"format parameter", "/api/import?format=json&dry_run=true", models.TransferJSON, nil, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTransferService)
+			transferController := NewTransferController(mockService)
+			c, w := newImportContext(tt.target, "text/csv; charset=utf-8", "title\nMilk\n")
+			dryRun := tt.job == nil
+			mockService.On("Import", mock.Anything, uint(1), tt.format, "title\nMilk\n", dryRun).Return(&models.ImportResponse{
+				Preview: models.ImportPreview{Total: 1, Create: 1, Issues: []models.ImportIssue{}},
+				Job:     tt.job,
+			}, nil)
+
+			transferController.Import(c)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+			var got models.ImportResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+			assert.Equal(t, 1, got.Preview.Create)
+		})
+	}
+}
+
+func TestTransferController_Import_InfersICS(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	body := "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"
+	c, w := newImportContext("/api/import?dry_run=true", "text/calendar", body)
+	mockService.On("Import", mock.Anything, uint(1), models.TransferICS, body, true).Return(&models.ImportResponse{
+		Preview: models.ImportPreview{Issues: []models.ImportIssue{}},
+	}, nil)
+
+	transferController.Import(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestTransferController_Import_InvalidRows(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	c, w := newImportContext("/api/import", "application/json", `[{"title": ""}]`)
+	mockService.On("Import", mock.Anything, uint(1), models.TransferJSON, mock.Anything, false).Return(&models.ImportResponse{
+		Preview: models.ImportPreview{Total: 1, Invalid: 1, Issues: []models.ImportIssue{
+			{Row: 1, Kind: models.ImportInvalid, Error: "title is required"},
+		}},
+	}, services.ErrInvalidImport)
+
+	transferController.Import(c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var got struct {
+		Error   string               `json:"error"`
+		Preview models.ImportPreview `json:"preview"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, services.ErrInvalidImport.Error(), got.Error)
+	assert.Equal(t, 1, got.Preview.Invalid)
+}
+
+func TestTransferController_Import_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid format", services.ErrInvalidTransferFormat, http.StatusBadRequest},
+		{"invalid file", services.ErrInvalidImportFile, http.StatusBadRequest},
+		{"too large", services.ErrImportTooLarge, http.StatusRequestEntityTooLarge},
+		{"internal", errors.New("db down"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockTransferService)
+			transferController := NewTransferController(mockService)
+			c, w := newImportContext("/api/import", "text/plain", "Milk")
+			mockService.On("Import", mock.Anything, uint(1), "", "Milk", false).Return(nil, tt.err)
+
+			transferController.Import(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestTransferController_GetImportJob(t *testing.T) {
+	mockService := new(MockTransferService)
+	transferController := NewTransferController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/import/jobs/3", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+	mockService.On("GetImportJob", mock.Anything, uint(1), 3).Return(&models.ImportJob{
+		ID: 3, Status: models.ImportRunning, Total: 500, Processed: 200,
+	}, nil)
+
+	transferController.GetImportJob(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got models.ImportJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, 200, got.Processed)
+
+	c, w = newAdminContext(http.MethodGet, "/api/import/jobs/4", nil)
+	c.Params = gin.Params{{Key: "id", Value: "4"}}
+	mockService.On("GetImportJob", mock.Anything, uint(1), 4).Return(nil, services.ErrImportJobNotFound)
+
+	transferController.GetImportJob(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
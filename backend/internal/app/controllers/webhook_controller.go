@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type WebhookController struct {
+	service services.WebhookServiceInterface
+}
+
+func NewWebhookController(service services.WebhookServiceInterface) *WebhookController {
+	return &WebhookController{service: service}
+}
+
+// webhookErrorResponse maps webhook errors and falls back to the workspace
+// errors for everything else.
+func webhookErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrInvalidWebhookEvents),
+		errors.Is(err, services.ErrInvalidDeliveryQuery):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrWebhookDeliveryPending):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	default:
+		return workspaceErrorResponse(err, fallback)
+	}
+}
+
+func (wc *WebhookController) ListWebhooks(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.ListWebhooks")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	webhooks, err := wc.service.ListWebhooks(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to list webhooks"))
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// CreateWebhook registers a webhook. The response is the only one that
+// contains the webhook's signing secret.
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.CreateWebhook")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := wc.service.CreateWebhook(c.Request.Context(), uint(userID.(int)), req)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to create webhook"))
+		return
+	}
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (wc *WebhookController) GetWebhook(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.GetWebhook")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	webhook, err := wc.service.GetWebhook(c.Request.Context(), uint(userID.(int)), webhookID)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to get webhook"))
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.UpdateWebhook")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+	var req models.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := wc.service.UpdateWebhook(c.Request.Context(), uint(userID.(int)), webhookID, req)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to update webhook"))
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.DeleteWebhook")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	if err := wc.service.DeleteWebhook(c.Request.Context(), uint(userID.(int)), webhookID); err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to delete webhook"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// ListDeliveries returns the delivery log of a webhook, newest first. The
+// "status" query parameter limits it to pending, succeeded or dead
+// deliveries; "limit" and "offset" page through it.
+func (wc *WebhookController) ListDeliveries(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.ListDeliveries")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+	var query models.WebhookDeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := wc.service.ListDeliveries(c.Request.Context(), uint(userID.(int)), webhookID, query)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to list webhook deliveries"))
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (wc *WebhookController) Redeliver(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.Redeliver")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+	deliveryID, ok := pathID(c, "delivery_id", "delivery")
+	if !ok {
+		return
+	}
+
+	delivery, err := wc.service.Redeliver(c.Request.Context(), uint(userID.(int)), webhookID, deliveryID)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to redeliver webhook delivery"))
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// SendTestEvent sends a webhook.test event and returns the delivery with
+// the receiver's response. A failed test delivery is still a 200; its
+// status tells whether it will be retried.
+func (wc *WebhookController) SendTestEvent(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WebhookController.SendTestEvent")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	webhookID, ok := pathID(c, "id", "webhook")
+	if !ok {
+		return
+	}
+
+	delivery, err := wc.service.SendTestEvent(c.Request.Context(), uint(userID.(int)), webhookID)
+	if err != nil {
+		c.JSON(webhookErrorResponse(err, "Failed to send test event"))
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
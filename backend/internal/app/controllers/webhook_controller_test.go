@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockWebhookService is a mock implementation of the WebhookServiceInterface
+type MockWebhookService struct {
+	mock.Mock
+}
+
+var _ services.WebhookServiceInterface = (*MockWebhookService)(nil)
+
+func (m *MockWebhookService) ListWebhooks(ctx context.Context, userID uint) ([]models.Webhook, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, userID uint, req models.WebhookRequest) (*models.Webhook, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) GetWebhook(ctx context.Context, userID uint, webhookID int) (*models.Webhook, error) {
+	args := m.Called(ctx, userID, webhookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) UpdateWebhook(ctx context.Context, userID uint, webhookID int, req models.WebhookRequest) (*models.Webhook, error) {
+	args := m.Called(ctx, userID, webhookID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, userID uint, webhookID int) error {
+	args := m.Called(ctx, userID, webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, userID uint, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, userID, webhookID, query)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, userID uint, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, userID, webhookID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) SendTestEvent(ctx context.Context, userID uint, webhookID int) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, userID, webhookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func TestWebhookController_CreateWebhook(t *testing.T) {
+	mockService := new(MockWebhookService)
+	webhookController := NewWebhookController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/webhooks", gin.H{"url": "https://example.com/hook", "events": []string{"task.completed"}})
+
+	req := models.WebhookRequest{URL: "https://example.com/hook", Events: []models.EventType{models.EventTaskCompleted}}
+	mockService.On("CreateWebhook", mock.Anything, uint(1), req).
+		Return(&models.Webhook{ID: 3, URL: req.URL, Events: req.Events, Active: true, Secret: "s3cret"}, nil)
+
+	webhookController.CreateWebhook(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"s3cret"`)
+}
+
+func TestWebhookController_CreateWebhook_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"invalid url", services.ErrInvalidWebhookURL, http.StatusBadRequest},
+		{"invalid events", services.ErrInvalidWebhookEvents, http.StatusBadRequest},
+		{"not an admin", services.ErrWorkspacePermissionDenied, http.StatusForbidden},
+		{"unknown workspace", services.ErrWorkspaceNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWebhookService)
+			webhookController := NewWebhookController(mockService)
+			c, w := newAdminContext(http.MethodPost, "/api/webhooks", gin.H{"url": "ftp://example.com", "events": []string{"task.created"}})
+
+			mockService.On("CreateWebhook", mock.Anything, uint(1), mock.Anything).Return(nil, tt.err)
+
+			webhookController.CreateWebhook(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestWebhookController_ListDeliveries(t *testing.T) {
+	mockService := new(MockWebhookService)
+	webhookController := NewWebhookController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/webhooks/3/deliveries?status=dead&limit=10", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+	mockService.On("ListDeliveries", mock.Anything, uint(1), 3, models.WebhookDeliveryQuery{Status: models.DeliveryDead, Limit: 10}).
+		Return([]models.WebhookDelivery{{ID: 8, Status: models.DeliveryDead, Attempts: 10, LastStatusCode: 500}}, nil)
+
+	webhookController.ListDeliveries(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"last_status_code":500`)
+}
+
+func TestWebhookController_Redeliver(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"queued", nil, http.StatusAccepted},
+		{"still pending", services.ErrWebhookDeliveryPending, http.StatusConflict},
+		{"unknown delivery", services.ErrWebhookDeliveryNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWebhookService)
+			webhookController := NewWebhookController(mockService)
+			c, w := newAdminContext(http.MethodPost, "/api/webhooks/3/deliveries/8/redeliver", nil)
+			c.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "delivery_id", Value: "8"}}
+
+			if tt.err != nil {
+				mockService.On("Redeliver", mock.Anything, uint(1), 3, 8).Return(nil, tt.err)
+			} else {
+				mockService.On("Redeliver", mock.Anything, uint(1), 3, 8).Return(&models.WebhookDelivery{ID: 8, Status: models.DeliveryPending}, nil)
+			}
+
+			webhookController.Redeliver(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestWebhookController_SendTestEvent(t *testing.T) {
+	mockService := new(MockWebhookService)
+	webhookController := NewWebhookController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/webhooks/3/test", nil)
+	c.Params = gin.Params{{Key: "id", Value: "3"}}
+
+	mockService.On("SendTestEvent", mock.Anything, uint(1), 3).
+		Return(&models.WebhookDelivery{ID: 11, EventType: models.EventWebhookTest, Status: models.DeliverySucceeded, LastStatusCode: 204}, nil)
+
+	webhookController.SendTestEvent(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"succeeded"`)
+}
+
+func TestWebhookController_GetWebhook_InvalidID(t *testing.T) {
+	mockService := new(MockWebhookService)
+	webhookController := NewWebhookController(mockService)
+	c, w := newAdminContext(http.MethodGet, "/api/webhooks/abc", nil)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}}
+
+	webhookController.GetWebhook(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "GetWebhook", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+)
+
+type WellKnownController struct {
+	keys *jwtkeys.Manager
+}
+
+func NewWellKnownController(keys *jwtkeys.Manager) *WellKnownController {
+	return &WellKnownController{keys: keys}
+}
+
+// JWKS publishes the public keys used to sign access tokens.
+func (wc *WellKnownController) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, wc.keys.JWKS())
+}
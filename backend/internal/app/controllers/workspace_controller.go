@@ -0,0 +1,259 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+type WorkspaceController struct {
+	service services.WorkspaceServiceInterface
+}
+
+func NewWorkspaceController(service services.WorkspaceServiceInterface) *WorkspaceController {
+	return &WorkspaceController{service: service}
+}
+
+// workspaceErrorResponse maps workspace errors and falls back to the task
+// errors for everything else.
+func workspaceErrorResponse(err error, fallback string) (int, gin.H) {
+	switch {
+	case errors.Is(err, services.ErrWorkspaceMemberNotFound):
+		return http.StatusNotFound, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrWorkspacePermissionDenied), errors.Is(err, services.ErrWorkspaceOwner),
+		errors.Is(err, services.ErrPersonalWorkspace):
+		return http.StatusForbidden, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrInvalidWorkspaceName), errors.Is(err, services.ErrInvalidWorkspaceRole):
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	case errors.Is(err, services.ErrAlreadyWorkspaceMember), errors.Is(err, services.ErrOwnsWorkspaceLists):
+		return http.StatusConflict, gin.H{"error": err.Error()}
+	default:
+		return taskErrorResponse(err, fallback)
+	}
+}
+
+func (wc *WorkspaceController) ListWorkspaces(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.ListWorkspaces")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	workspaces, err := wc.service.ListWorkspaces(c.Request.Context(), uint(userID.(int)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list workspaces"})
+		return
+	}
+	c.JSON(http.StatusOK, workspaces)
+}
+
+func (wc *WorkspaceController) CreateWorkspace(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.CreateWorkspace")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+
+	var req models.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ws, err := wc.service.CreateWorkspace(c.Request.Context(), uint(userID.(int)), req.Name)
+	if err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to create workspace"))
+		return
+	}
+	c.JSON(http.StatusCreated, ws)
+}
+
+func (wc *WorkspaceController) GetWorkspace(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.GetWorkspace")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+
+	ws, err := wc.service.GetWorkspace(c.Request.Context(), uint(userID.(int)), workspaceID)
+	if err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to get workspace"))
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+func (wc *WorkspaceController) RenameWorkspace(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.RenameWorkspace")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+
+	var req models.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ws, err := wc.service.RenameWorkspace(c.Request.Context(), uint(userID.(int)), workspaceID, req.Name)
+	if err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to rename workspace"))
+		return
+	}
+	c.JSON(http.StatusOK, ws)
+}
+
+func (wc *WorkspaceController) DeleteWorkspace(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.DeleteWorkspace")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+
+	if err := wc.service.DeleteWorkspace(c.Request.Context(), uint(userID.(int)), workspaceID); err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to delete workspace"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+}
+
+func (wc *WorkspaceController) ListMembers(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.ListMembers")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+
+	members, err := wc.service.ListMembers(c.Request.Context(), uint(userID.(int)), workspaceID)
+	if err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to list members"))
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+func (wc *WorkspaceController) AddMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.AddMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+
+	var req models.AddWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := wc.service.AddMember(c.Request.Context(), uint(userID.(int)), workspaceID, req)
+	if err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to add member"))
+		return
+	}
+	c.JSON(http.StatusCreated, member)
+}
+
+func (wc *WorkspaceController) UpdateMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.UpdateMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id", "user")
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := wc.service.UpdateMember(c.Request.Context(), uint(userID.(int)), workspaceID, memberID, req.Role); err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to update member"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member updated successfully"})
+}
+
+// RemoveMember removes a member from a workspace and all of its lists.
+// Members remove themselves to leave a workspace.
+func (wc *WorkspaceController) RemoveMember(c *gin.Context) {
+	_, span := otel.Tracer("").Start(c.Request.Context(), "WorkspaceController.RemoveMember")
+	defer span.End()
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User ID not found in context"})
+		return
+	}
+	workspaceID, ok := pathID(c, "id", "workspace")
+	if !ok {
+		return
+	}
+	memberID, ok := pathID(c, "user_id", "user")
+	if !ok {
+		return
+	}
+
+	if err := wc.service.RemoveMember(c.Request.Context(), uint(userID.(int)), workspaceID, memberID); err != nil {
+		c.JSON(workspaceErrorResponse(err, "Failed to remove member"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
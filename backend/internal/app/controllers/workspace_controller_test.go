@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/services"
+)
+
+// MockWorkspaceService is a mock implementation of the WorkspaceServiceInterface
+type MockWorkspaceService struct {
+	mock.Mock
+}
+
+var _ services.WorkspaceServiceInterface = (*MockWorkspaceService)(nil)
+
+func (m *MockWorkspaceService) ListWorkspaces(ctx context.Context, userID uint) ([]models.Workspace, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceService) CreateWorkspace(ctx context.Context, userID uint, name string) (*models.Workspace, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceService) GetWorkspace(ctx context.Context, userID uint, workspaceID int) (*models.Workspace, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceService) RenameWorkspace(ctx context.Context, userID uint, workspaceID int, name string) (*models.Workspace, error) {
+	args := m.Called(ctx, userID, workspaceID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceService) DeleteWorkspace(ctx context.Context, userID uint, workspaceID int) error {
+	args := m.Called(ctx, userID, workspaceID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceService) ListMembers(ctx context.Context, userID uint, workspaceID int) ([]models.WorkspaceMember, error) {
+	args := m.Called(ctx, userID, workspaceID)
+	return args.Get(0).([]models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceService) AddMember(ctx context.Context, userID uint, workspaceID int, req models.AddWorkspaceMemberRequest) (*models.WorkspaceMember, error) {
+	args := m.Called(ctx, userID, workspaceID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceService) UpdateMember(ctx context.Context, userID uint, workspaceID, memberID int, role models.WorkspaceRole) error {
+	args := m.Called(ctx, userID, workspaceID, memberID, role)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceService) RemoveMember(ctx context.Context, userID uint, workspaceID, memberID int) error {
+	args := m.Called(ctx, userID, workspaceID, memberID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceService) IsWorkspaceMember(ctx context.Context, workspaceID, userID int) (bool, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestWorkspaceController_CreateWorkspace(t *testing.T) {
+	mockService := new(MockWorkspaceService)
+	workspaceController := NewWorkspaceController(mockService)
+	c, w := newAdminContext(http.MethodPost, "/api/workspaces", models.WorkspaceRequest{Name: "Team"})
+
+	mockService.On("CreateWorkspace", mock.Anything, uint(1), "Team").Return(&models.Workspace{
+		ID: 4, Name: "Team", OwnerID: 1, Role: models.WorkspaceRoleOwner,
+	}, nil)
+
+	workspaceController.CreateWorkspace(c)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"owner"`)
+}
+
+func TestWorkspaceController_RemoveMember_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not an admin", services.ErrWorkspacePermissionDenied, http.StatusForbidden},
+		{"owner", services.ErrWorkspaceOwner, http.StatusForbidden},
+		{"owns lists", services.ErrOwnsWorkspaceLists, http.StatusConflict},
+		{"unknown member", services.ErrWorkspaceMemberNotFound, http.StatusNotFound},
+		{"unknown workspace", services.ErrWorkspaceNotFound, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockWorkspaceService)
+			workspaceController := NewWorkspaceController(mockService)
+			c, w := newAdminContext(http.MethodDelete, "/api/workspaces/3/members/2", nil)
+			c.Params = gin.Params{{Key: "id", Value: "3"}, {Key: "user_id", Value: "2"}}
+
+			mockService.On("RemoveMember", mock.Anything, uint(1), 3, 2).Return(tt.err)
+
+			workspaceController.RemoveMember(c)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestWorkspaceController_UpdateMember_InvalidID(t *testing.T) {
+	mockService := new(MockWorkspaceService)
+	workspaceController := NewWorkspaceController(mockService)
+	req := models.UpdateWorkspaceMemberRequest{Role: models.WorkspaceRoleAdmin}
+	c, w := newAdminContext(http.MethodPut, "/api/workspaces/abc/members/2", req)
+	c.Params = gin.Params{{Key: "id", Value: "abc"}, {Key: "user_id", Value: "2"}}
+
+	workspaceController.UpdateMember(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "UpdateMember", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
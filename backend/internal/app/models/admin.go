@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// AdminUser is a user account as seen by administrators.
+type AdminUser struct {
+	ID            int        `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	HasPassword   bool       `json:"has_password"`
+	Role          string     `json:"role"`
+	Disabled      bool       `json:"disabled"`
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	TaskCount     int        `json:"task_count"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// UserListQuery filters and pages the admin user list.
+type UserListQuery struct {
+	Search string `form:"q"`
+	Role   string `form:"role"`
+	Limit  int    `form:"limit"`
+	Offset int    `form:"offset"`
+}
+
+// UserList is one page of the admin user list.
+type UserList struct {
+	Users  []AdminUser `json:"users"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// Role is a named set of permissions. Built-in roles cannot be deleted.
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// UsageStats summarizes how the application is used.
+type UsageStats struct {
+	Users            int `json:"users"`
+	DisabledUsers    int `json:"disabled_users"`
+	VerifiedUsers    int `json:"verified_users"`
+	SignupsLast7d    int `json:"signups_last_7d"`
+	SignupsLast30d   int `json:"signups_last_30d"`
+	Tasks            int `json:"tasks"`
+	CompletedTasks   int `json:"completed_tasks"`
+	OAuthClients     int `json:"oauth_clients"`
+	LinkedIdentities int `json:"linked_identities"`
+}
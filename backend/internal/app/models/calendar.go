@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// CalendarFeed is a user's iCalendar feed of their tasks. URL contains the
+// secret token and is only known right after the feed is created.
+type CalendarFeed struct {
+	URL       string    `json:"url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
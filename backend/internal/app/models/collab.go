@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// CollabMessageType names a message on the collaboration socket.
+type CollabMessageType string
+
+const (
+	// Sent by clients.
+	CollabSubscribe   CollabMessageType = "subscribe"
+	CollabUnsubscribe CollabMessageType = "unsubscribe"
+	// CollabPresence is sent by clients to say what they are doing in a
+	// list, and by the server when someone's presence changes.
+	CollabPresence CollabMessageType = "presence"
+
+	// Sent by the server.
+	CollabSubscribed   CollabMessageType = "subscribed"
+	CollabUnsubscribed CollabMessageType = "unsubscribed"
+	CollabEvent        CollabMessageType = "event"
+	// CollabReset tells the client that task events were missed and the
+	// lists it subscribed to should be reloaded.
+	CollabReset CollabMessageType = "reset"
+	CollabError CollabMessageType = "error"
+)
+
+// PresenceState is what a user is doing in a list.
+type PresenceState string
+
+const (
+	PresenceViewing PresenceState = "viewing"
+	PresenceEditing PresenceState = "editing"
+	// PresenceLeft is only sent by the server, when a user stops viewing.
+	PresenceLeft PresenceState = "left"
+)
+
+// CollabMessage is a message on the collaboration socket, in either
+// direction. Which fields are set depends on Type.
+type CollabMessage struct {
+	Type   CollabMessageType `json:"type"`
+	ListID int               `json:"list_id,omitempty"`
+	// State and TaskID are the presence a client reports.
+	State  PresenceState `json:"state,omitempty"`
+	TaskID int           `json:"task_id,omitempty"`
+	// Seq and Event are a task change in a subscribed list.
+	Seq   int64  `json:"seq,omitempty"`
+	Event *Event `json:"event,omitempty"`
+	// Presence is everyone in the list for subscribed messages, and the
+	// changed presence for presence messages.
+	Presence []Presence `json:"presence,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+// Presence is what a user is doing in a list in one connection. A user with
+// the list open in two tabs has two presences, told apart by SessionID.
+type Presence struct {
+	ListID    int           `json:"list_id"`
+	UserID    int           `json:"user_id"`
+	Username  string        `json:"username"`
+	SessionID string        `json:"session_id"`
+	State     PresenceState `json:"state"`
+	TaskID    int           `json:"task_id,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
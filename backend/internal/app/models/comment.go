@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Comment is a Markdown comment on a task. AuthorID is zero and AuthorName
+// empty if the author no longer exists. EditedAt is set once the body has
+// been changed.
+type Comment struct {
+	ID         int              `json:"id"`
+	TaskID     int              `json:"task_id"`
+	AuthorID   int              `json:"author_id,omitempty"`
+	AuthorName string           `json:"author_name,omitempty"`
+	Body       string           `json:"body"`
+	Mentions   []CommentMention `json:"mentions"`
+	CreatedAt  time.Time        `json:"created_at"`
+	EditedAt   *time.Time       `json:"edited_at,omitempty"`
+}
+
+// CommentMention is a user mentioned as @username in a comment.
+type CommentMention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username,omitempty"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required"`
+}
@@ -0,0 +1,132 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// EventType names something that happened to a task.
+type EventType string
+
+const (
+	EventTaskCreated   EventType = "task.created"
+	EventTaskUpdated   EventType = "task.updated"
+	EventTaskCompleted EventType = "task.completed"
+	EventTaskDeleted   EventType = "task.deleted"
+	// EventWebhookTest is only sent by the "send test event" endpoint.
+	EventWebhookTest EventType = "webhook.test"
+)
+
+// TaskEventTypes are the event types webhooks can subscribe to.
+var TaskEventTypes = []EventType{EventTaskCreated, EventTaskUpdated, EventTaskCompleted, EventTaskDeleted}
+
+// Valid reports whether t is an event type webhooks can subscribe to.
+func (t EventType) Valid() bool {
+	return slices.Contains(TaskEventTypes, t)
+}
+
+// Event is a change of a task. ID is unique per event, so consumers can
+// tell repeated deliveries apart from new events. Task is the task after the
+// change, or before it for task.deleted. Changes lists the changed fields of
+// task.updated events by their JSON name.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       EventType              `json:"type"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	ActorID    int                    `json:"actor_id,omitempty"`
+	ListID     int                    `json:"list_id,omitempty"`
+	Task       *Task                  `json:"task,omitempty"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is the old and new value of a changed field.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// NewTaskEvent returns an event of type t about task, caused by actorID. The
+// outbox sets its ID and time when it records it.
+func NewTaskEvent(t EventType, task *Task, actorID int) Event {
+	return Event{Type: t, ActorID: actorID, ListID: task.ListID, Task: task}
+}
+
+// TaskUpdateEvents returns the events for a change of a task from before to
+// after: task.updated with the changed fields, followed by task.completed if
+// the task was checked off. It returns nil if no field changed.
+func TaskUpdateEvents(before, after *Task, actorID int) []Event {
+	changes := TaskChanges(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	updated := NewTaskEvent(EventTaskUpdated, after, actorID)
+	updated.Changes = changes
+	events := []Event{updated}
+	if after.Completed && !before.Completed {
+		events = append(events, NewTaskEvent(EventTaskCompleted, after, actorID))
+	}
+	return events
+}
+
+// TaskChanges returns the fields users can change that differ between
+// before and after. Bookkeeping fields such as updated_at are left out.
+// Absent values, such as a missing assignee, are nil.
+func TaskChanges(before, after *Task) map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if before.Title != after.Title {
+		changes["title"] = FieldChange{From: before.Title, To: after.Title}
+	}
+	if before.Completed != after.Completed {
+		changes["completed"] = FieldChange{From: before.Completed, To: after.Completed}
+	}
+	if before.AssigneeID != after.AssigneeID {
+		changes["assignee_id"] = FieldChange{From: optionalID(before.AssigneeID), To: optionalID(after.AssigneeID)}
+	}
+	if !sameTime(before.SnoozedUntil, after.SnoozedUntil) {
+		changes["snoozed_until"] = FieldChange{From: optionalTime(before.SnoozedUntil), To: optionalTime(after.SnoozedUntil)}
+	}
+	return changes
+}
+
+func optionalID(id int) any {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+func optionalTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// OutboxEvent is an event claimed from the outbox for relaying. Seq orders
+// the outbox; PublishedSinks names the sinks that already have the event.
+type OutboxEvent struct {
+	Seq            int64
+	Event          Event
+	PublishedSinks []string
+	Attempts       int
+}
+
+// StreamEvent is a relayed event on its way to the clients of the event
+// stream. Seq is its number in the stream, which clients resume from.
+// Unlike the outbox sequence, it follows the order events are announced
+// in, see repositories.StreamRepository.Notify.
+// WorkspaceID is the workspace of the event's list and UserIDs are the
+// members of the list, who may see the event.
+type StreamEvent struct {
+	Seq         int64
+	Event       Event
+	WorkspaceID int
+	UserIDs     []int
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to an account at an external identity provider.
+type UserIdentity struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OIDCLoginState is the server-side half of an authorization request. It is
+// looked up by the hash of the state parameter when the provider redirects
+// back. LinkUserID is set when an authenticated user links a new identity.
+type OIDCLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	LinkUserID   int
+	ExpiresAt    time.Time
+}
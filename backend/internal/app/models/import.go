@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The formats tasks are exported and imported in. TransferICS is an
+// iCalendar file with a VTODO per task.
+const (
+	TransferJSON = "json"
+	TransferCSV  = "csv"
+	TransferICS  = "ics"
+)
+
+// ImportRow is a task to import. Row is its position in the file, counting
+// from 1 and leaving out the CSV header and components other than VTODOs.
+// ListID is 0 for the personal list until the import resolves it.
+type ImportRow struct {
+	Row       int    `json:"row"`
+	ListID    int    `json:"list_id"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+}
+
+// DuplicateKey identifies the tasks a row duplicates: those in the same list
+// with the same title, ignoring case and surrounding space.
+func (r ImportRow) DuplicateKey() string {
+	return fmt.Sprintf("%d:%s", r.ListID, strings.ToLower(strings.TrimSpace(r.Title)))
+}
+
+// ImportIssueKind tells why a row is not imported.
+type ImportIssueKind string
+
+const (
+	ImportInvalid   ImportIssueKind = "invalid"
+	ImportDuplicate ImportIssueKind = "duplicate"
+)
+
+type ImportIssue struct {
+	Row   int             `json:"row"`
+	Kind  ImportIssueKind `json:"kind"`
+	Error string          `json:"error"`
+}
+
+// ImportPreview is what an import would do: of Total rows, Create become
+// tasks, Duplicates are skipped and Invalid ones prevent the import. Issues
+// lists the rows that are not imported, up to a limit; the counts are
+// always complete.
+type ImportPreview struct {
+	Total      int           `json:"total"`
+	Create     int           `json:"create"`
+	Duplicates int           `json:"duplicates"`
+	Invalid    int           `json:"invalid"`
+	Issues     []ImportIssue `json:"issues"`
+}
+
+type ImportJobStatus string
+
+const (
+	ImportPending   ImportJobStatus = "pending"
+	ImportRunning   ImportJobStatus = "running"
+	ImportCompleted ImportJobStatus = "completed"
+	ImportFailed    ImportJobStatus = "failed"
+)
+
+// ImportJob applies the rows of an import in one transaction. Total is the
+// number of rows to create, of which Processed have been worked through.
+// Rows that turned out to be duplicates when the job ran are Skipped.
+// Nothing is created unless the job completes. Rows are the rows to
+// create, WorkspaceID the workspace the import was scoped to, and Attempts
+// how often a runner claimed the job.
+type ImportJob struct {
+	ID          int             `json:"id"`
+	UserID      int             `json:"-"`
+	WorkspaceID int             `json:"-"`
+	Status      ImportJobStatus `json:"status"`
+	Total       int             `json:"total"`
+	Processed   int             `json:"processed"`
+	Created     int             `json:"created"`
+	Skipped     int             `json:"skipped"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	Rows        []ImportRow     `json:"-"`
+	Attempts    int             `json:"-"`
+}
+
+// ImportResponse is the outcome of an import: its preview, and the job
+// that applies it unless it was a dry run.
+type ImportResponse struct {
+	Preview ImportPreview `json:"preview"`
+	Job     *ImportJob    `json:"job,omitempty"`
+}
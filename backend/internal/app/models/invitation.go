@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// InvitationStatus is the state of an invitation. Only pending invitations
+// can be accepted, declined or revoked.
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationDeclined InvitationStatus = "declined"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// Invitation invites someone to join a task list with Role. Email is the
+// address the invitation was mailed to, if any.
+type Invitation struct {
+	ID          int              `json:"id"`
+	ListID      int              `json:"list_id"`
+	ListName    string           `json:"list_name"`
+	InviterID   int              `json:"inviter_id"`
+	InviterName string           `json:"inviter_name"`
+	Email       string           `json:"email,omitempty"`
+	Role        ListRole         `json:"role"`
+	Status      InvitationStatus `json:"status"`
+	CreatedAt   time.Time        `json:"created_at"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+}
+
+type CreateInvitationRequest struct {
+	Email string   `json:"email" binding:"omitempty,email"`
+	Role  ListRole `json:"role" binding:"required"`
+}
+
+// CreatedInvitation is returned once when an invitation is created. The
+// token cannot be retrieved later.
+type CreatedInvitation struct {
+	Invitation
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type InvitationTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
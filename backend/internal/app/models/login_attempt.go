@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempt tracks failed logins for a single key, such as a username or
+// a client IP address.
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time // Zero when not locked
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// NotificationType says what a notification is about.
+type NotificationType string
+
+const (
+	// NotificationMention tells a user that they were mentioned in a
+	// comment.
+	NotificationMention NotificationType = "mention"
+	// NotificationAssigned tells a user that a task was assigned to them.
+	NotificationAssigned NotificationType = "assigned"
+	// NotificationReminder is a reminder the user set on a task.
+	NotificationReminder NotificationType = "reminder"
+	// NotificationDueSoon warns a user that a task of theirs is due soon.
+	NotificationDueSoon NotificationType = "due_soon"
+)
+
+// NotificationTypes lists every notification type.
+var NotificationTypes = []NotificationType{NotificationMention, NotificationAssigned, NotificationReminder, NotificationDueSoon}
+
+func (t NotificationType) Valid() bool {
+	return slices.Contains(NotificationTypes, t)
+}
+
+// Notification is a notification for UserID. ActorID is the user who caused
+// it; TaskID and CommentID refer to what it is about and are zero if they do
+// not apply. ActorName and TaskTitle are filled in when known.
+type Notification struct {
+	ID        int              `json:"id"`
+	UserID    int              `json:"user_id"`
+	Type      NotificationType `json:"type"`
+	ActorID   int              `json:"actor_id,omitempty"`
+	ActorName string           `json:"actor_name,omitempty"`
+	TaskID    int              `json:"task_id,omitempty"`
+	TaskTitle string           `json:"task_title,omitempty"`
+	CommentID int              `json:"comment_id,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+}
+
+// NotificationQuery filters and pages a user's notifications.
+type NotificationQuery struct {
+	Unread bool `form:"unread"`
+	Limit  int  `form:"limit"`
+	Offset int  `form:"offset"`
+}
+
+// NotificationList is one page of a user's notifications, newest first.
+// Total counts the notifications matching the query and UnreadCount all
+// unread notifications.
+type NotificationList struct {
+	Notifications []Notification `json:"notifications"`
+	Total         int            `json:"total"`
+	UnreadCount   int            `json:"unread_count"`
+	Limit         int            `json:"limit"`
+	Offset        int            `json:"offset"`
+}
+
+// NotificationPreference says whether a user gets notifications of Type
+// through Channel, for example "in_app" or "email".
+type NotificationPreference struct {
+	UserID  int              `json:"-"`
+	Type    NotificationType `json:"type"`
+	Channel string           `json:"channel"`
+	Enabled bool             `json:"enabled"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" binding:"required"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import "time"
+
+// OAuthClient is a third-party application registered by a user.
+// Confidential clients authenticate at the token endpoint with a secret;
+// public clients (SPAs, mobile apps) rely on PKCE alone.
+type OAuthClient struct {
+	ID           string    `json:"client_id"`
+	SecretHash   string    `json:"-"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	OwnerID      int       `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Confidential reports whether the client has a secret.
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris" binding:"required,min=1"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	Confidential bool     `json:"confidential"`
+}
+
+// OAuthConsent records which scopes a user granted to a client.
+type OAuthConsent struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AuthorizationRequest holds the parameters of an authorization request
+// (RFC 6749 section 4.1.1 with RFC 7636 PKCE).
+type AuthorizationRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+}
+
+// ConsentDecision is the user's answer on the consent screen.
+type ConsentDecision struct {
+	AuthorizationRequest
+	Approve bool `json:"approve"`
+}
+
+// ScopeDescription describes a requested scope on the consent screen.
+type ScopeDescription struct {
+	Scope       string `json:"scope"`
+	Description string `json:"description"`
+}
+
+// ConsentPrompt is what the consent screen shows for a valid authorization
+// request. AlreadyGranted is set when the user previously approved all of the
+// requested scopes for this client.
+type ConsentPrompt struct {
+	ClientID       string             `json:"client_id"`
+	ClientName     string             `json:"client_name"`
+	RedirectURI    string             `json:"redirect_uri"`
+	Scopes         []ScopeDescription `json:"scopes"`
+	AlreadyGranted bool               `json:"already_granted"`
+}
+
+// OAuthAuthorizationCode is a pending authorization code. Only its hash is
+// stored.
+type OAuthAuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+// OAuthAccessToken is the server-side record of an access token issued to a
+// client.
+type OAuthAccessToken struct {
+	JTI       string
+	ClientID  string
+	UserID    int
+	Scopes    []string
+	CodeHash  string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// TokenResponse is the successful token endpoint response (RFC 6749 section 5.1).
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// IntrospectionResponse is the token introspection response (RFC 7662).
+// Inactive tokens carry only Active=false.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+}
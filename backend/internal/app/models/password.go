@@ -0,0 +1,15 @@
+package models
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"` // Not needed when the account has no password yet
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"` // Either the username or the email address
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
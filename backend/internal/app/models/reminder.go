@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Reminder notifies UserID about a task at FireAt. It is set either for an
+// absolute time, RemindAt, or as OffsetMinutes after the task was created.
+// FiredAt is set once the reminder has gone out.
+type Reminder struct {
+	ID            int        `json:"id"`
+	TaskID        int        `json:"task_id"`
+	UserID        int        `json:"user_id"`
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty"`
+	FireAt        time.Time  `json:"fire_at"`
+	FiredAt       *time.Time `json:"fired_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ReminderRequest sets a reminder. Exactly one of RemindAt and
+// OffsetMinutes must be given.
+type ReminderRequest struct {
+	RemindAt      *time.Time `json:"remind_at"`
+	OffsetMinutes *int       `json:"offset_minutes"`
+}
+
+// DueReminder is a reminder claimed by the scheduler. Deliver is false if
+// the task was completed or the user can no longer see it; such reminders
+// are retired without a notification.
+type DueReminder struct {
+	ID        int
+	TaskID    int
+	UserID    int
+	TaskTitle string
+	Deliver   bool
+}
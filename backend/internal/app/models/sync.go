@@ -0,0 +1,90 @@
+package models
+
+// SyncChanges are the changes to the user's tasks since a sync token.
+// Created holds the tasks the client has not seen yet, including those of
+// lists the user joined; Updated the changed tasks it has seen. Deleted
+// holds the IDs of deleted tasks and of the tasks of lists the user left.
+// Token is passed as "since" to the next sync.
+type SyncChanges struct {
+	Token   string `json:"token"`
+	Created []Task `json:"created"`
+	Updated []Task `json:"updated"`
+	Deleted []int  `json:"deleted"`
+}
+
+// SyncOp is the kind of a client-side change.
+type SyncOp string
+
+const (
+	SyncCreate SyncOp = "create"
+	SyncUpdate SyncOp = "update"
+	SyncDelete SyncOp = "delete"
+)
+
+// The task fields a client can change offline.
+const (
+	SyncFieldTitle     = "title"
+	SyncFieldCompleted = "completed"
+)
+
+// SyncChange is a change the client made offline. ClientID identifies the
+// change in the result; for creates it also identifies the task, so that
+// retrying a batch does not create it twice. Updates set the fields that
+// changed and leave the others nil. Clock holds the timestamps of the
+// fields an update sets; a field without one keeps the server's value if
+// it changed since the sync token.
+type SyncChange struct {
+	ClientID  string    `json:"client_id"`
+	Op        SyncOp    `json:"op"`
+	TaskID    int       `json:"task_id,omitempty"`
+	ListID    int       `json:"list_id,omitempty"`
+	Title     *string   `json:"title,omitempty"`
+	Completed *bool     `json:"completed,omitempty"`
+	Clock     TaskClock `json:"clock"`
+}
+
+// SyncRequest applies the changes a client made since the sync token
+// Since, in order.
+type SyncRequest struct {
+	Since   string       `json:"since"`
+	Changes []SyncChange `json:"changes" binding:"required"`
+}
+
+// SyncStatus is the outcome of a client-side change. A partial update
+// kept the server's value of some fields.
+type SyncStatus string
+
+const (
+	SyncApplied  SyncStatus = "applied"
+	SyncPartial  SyncStatus = "partial"
+	SyncRejected SyncStatus = "rejected"
+)
+
+// SyncReason explains why a change or some of its fields were rejected.
+type SyncReason string
+
+const (
+	// SyncConflict means the task changed on the server since the sync
+	// token, or a field has a later timestamp there, and the server's
+	// version wins.
+	SyncConflict  SyncReason = "conflict"
+	SyncNotFound  SyncReason = "not_found"
+	SyncForbidden SyncReason = "forbidden"
+	SyncInvalid   SyncReason = "invalid"
+)
+
+// SyncResult is the outcome of a SyncChange. Task is the task as it is on
+// the server now, if the user can see it. RejectedFields lists the fields
+// of a partial update that kept the server's value.
+type SyncResult struct {
+	ClientID       string     `json:"client_id"`
+	Status         SyncStatus `json:"status"`
+	Reason         SyncReason `json:"reason,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	RejectedFields []string   `json:"rejected_fields,omitempty"`
+	Task           *Task      `json:"task,omitempty"`
+}
+
+type SyncResponse struct {
+	Results []SyncResult `json:"results"`
+}
@@ -1,8 +1,86 @@
 package models
 
+import (
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+)
+
+// Task is an item of a task list. CreatedBy and UpdatedBy are zero if the
+// user no longer exists or the task was never changed. AssigneeID is zero for
+// unassigned tasks. SnoozedUntil is set while the task is snoozed.
+// Position is the key of the task in the acting user's manual order, if
+// they placed it, see crdt.Order.
 type Task struct {
-	ID        int    `json:"id"`
-	UserID    int    `json:"user_id"`
-	Title     string `json:"title"`
-	Completed bool   `json:"completed"`
+	ID           int        `json:"id"`
+	ListID       int        `json:"list_id"`
+	Title        string     `json:"title"`
+	Completed    bool       `json:"completed"`
+	CreatedBy    int        `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedBy    int        `json:"updated_by,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	AssigneeID   int        `json:"assignee_id,omitempty"`
+	AssignedBy   int        `json:"assigned_by,omitempty"`
+	AssignedAt   *time.Time `json:"assigned_at,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	CommentCount int        `json:"comment_count"`
+	Position     string     `json:"position,omitempty"`
+	Clock        TaskClock  `json:"clock"`
+}
+
+// TaskClock holds the hybrid logical clock timestamps of the last writes of
+// a task's fields. Concurrent edits are merged field by field, and the write
+// with the later timestamp wins. Clients that edit offline send the
+// timestamps of their writes; the server stamps the fields an edit sets
+// without one, so that they win. A zero timestamp is encoded as "".
+type TaskClock struct {
+	Title     crdt.Timestamp `json:"title"`
+	Completed crdt.Timestamp `json:"completed"`
+	Position  crdt.Timestamp `json:"position"`
+}
+
+// TaskFilter narrows the tasks returned by GetTasks. Zero values do not
+// filter, except that snoozed tasks are left out unless IncludeSnoozed or
+// SnoozedOnly is set. ManualOrder orders the tasks by the user's manual
+// order, with the tasks the user did not place last, instead of by ID.
+type TaskFilter struct {
+	ListID         int
+	AssigneeID     int
+	Unassigned     bool
+	IncludeSnoozed bool
+	SnoozedOnly    bool
+	ManualOrder    bool
+}
+
+// TaskAssignment is an entry of a task's assignment history. AssigneeID is
+// zero when the task was unassigned, and AssignedBy is zero when that
+// happened automatically because the assignee left the list.
+type TaskAssignment struct {
+	ID         int       `json:"id"`
+	TaskID     int       `json:"task_id"`
+	AssigneeID int       `json:"assignee_id,omitempty"`
+	AssignedBy int       `json:"assigned_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type AssignTaskRequest struct {
+	AssigneeID int `json:"assignee_id" binding:"required"`
+}
+
+// SnoozeTaskRequest snoozes a task until an absolute time or until a preset
+// such as "tomorrow_morning". Presets are resolved in TimeZone, or in the
+// user's time zone if it is empty.
+type SnoozeTaskRequest struct {
+	Until    *time.Time `json:"until"`
+	Preset   string     `json:"preset"`
+	TimeZone string     `json:"time_zone"`
+}
+
+// MoveTaskRequest places a task in the user's manual order. Position is a
+// key between those of the task's new neighbours, see crdt.Between. Clock
+// is the timestamp of the move; the server takes one if it is empty.
+type MoveTaskRequest struct {
+	Position string         `json:"position" binding:"required"`
+	Clock    crdt.Timestamp `json:"clock"`
 }
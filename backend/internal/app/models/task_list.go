@@ -0,0 +1,78 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// ListRole is a member's role in a task list. Each role includes the rights
+// of the roles before it: viewers read and comment on tasks, editors also
+// change them, admins also manage members and the list name, and the owner
+// may also delete the list.
+type ListRole string
+
+const (
+	ListRoleViewer ListRole = "viewer"
+	ListRoleEditor ListRole = "editor"
+	ListRoleAdmin  ListRole = "admin"
+	ListRoleOwner  ListRole = "owner"
+)
+
+var listRoleOrder = []ListRole{ListRoleViewer, ListRoleEditor, ListRoleAdmin, ListRoleOwner}
+
+// Valid reports whether r is a known role.
+func (r ListRole) Valid() bool {
+	return slices.Contains(listRoleOrder, r)
+}
+
+// AtLeast reports whether r includes the rights of min.
+func (r ListRole) AtLeast(min ListRole) bool {
+	return r.Valid() && slices.Index(listRoleOrder, r) >= slices.Index(listRoleOrder, min)
+}
+
+// ListRolesAtLeast returns the names of all roles that include the rights of
+// min.
+func ListRolesAtLeast(min ListRole) []string {
+	var roles []string
+	for _, r := range listRoleOrder {
+		if r.AtLeast(min) {
+			roles = append(roles, string(r))
+		}
+	}
+	return roles
+}
+
+// TaskList is a list of tasks shared among its members. Role is the role of
+// the user who requested the list.
+type TaskList struct {
+	ID          int       `json:"id"`
+	WorkspaceID int       `json:"workspace_id"`
+	Name        string    `json:"name"`
+	OwnerID     int       `json:"owner_id"`
+	Personal    bool      `json:"personal"`
+	Role        ListRole  `json:"role"`
+	MemberCount int       `json:"member_count"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type TaskListRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ListMember is a user's membership in a task list.
+type ListMember struct {
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Role      ListRole  `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddMemberRequest adds the user identified by username or email.
+type AddMemberRequest struct {
+	Login string   `json:"login" binding:"required"`
+	Role  ListRole `json:"role" binding:"required"`
+}
+
+type UpdateMemberRequest struct {
+	Role ListRole `json:"role" binding:"required"`
+}
@@ -1,7 +1,32 @@
 package models
 
+import "time"
+
 type User struct {
-	ID       int    `json:"id"`
+	ID            int       `json:"id"`
+	Username      string    `json:"username" binding:"required"` // Either the username or the email address when logging in
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	Password      string    `json:"password" binding:"required"` // This will be the plain password from request, not hashed
+	Role          string    `json:"role,omitempty"`
+	Permissions   []string  `json:"-"` // Permissions of Role, loaded with the user
+	Disabled      bool      `json:"disabled,omitempty"`
+	TimeZone      string    `json:"time_zone,omitempty"` // IANA time zone; empty means UTC
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type SignupRequest struct {
 	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"` // This will be the plain password from request, not hashed
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	// InvitationToken optionally redeems a list invitation for the new user.
+	InvitationToken string `json:"invitation_token"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type SetTimeZoneRequest struct {
+	TimeZone string `json:"time_zone" binding:"required"`
 }
@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// WebhookDeliveryStatus is the state of a webhook delivery. Pending
+// deliveries are waiting for their next attempt; dead ones ran out of
+// attempts.
+type WebhookDeliveryStatus string
+
+const (
+	DeliveryPending   WebhookDeliveryStatus = "pending"
+	DeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	DeliveryDead      WebhookDeliveryStatus = "dead"
+)
+
+// Webhook posts the events of a workspace to URL. Secret signs the
+// deliveries; it is only returned when the webhook is created.
+type Webhook struct {
+	ID          int         `json:"id"`
+	WorkspaceID int         `json:"workspace_id"`
+	URL         string      `json:"url"`
+	Events      []EventType `json:"events"`
+	Active      bool        `json:"active"`
+	Secret      string      `json:"secret,omitempty"`
+	CreatedBy   int         `json:"created_by,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// WebhookRequest creates or replaces a webhook. Active defaults to true.
+type WebhookRequest struct {
+	URL    string      `json:"url" binding:"required"`
+	Events []EventType `json:"events" binding:"required"`
+	Active *bool       `json:"active"`
+}
+
+// WebhookDelivery is an entry of a webhook's delivery log.
+type WebhookDelivery struct {
+	ID             int                   `json:"id"`
+	WebhookID      int                   `json:"webhook_id"`
+	EventID        string                `json:"event_id"`
+	EventType      EventType             `json:"event_type"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	LastResponse   string                `json:"last_response,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+}
+
+// WebhookDeliveryQuery pages through a webhook's delivery log, newest
+// first. An empty Status returns every delivery.
+type WebhookDeliveryQuery struct {
+	Status WebhookDeliveryStatus `form:"status"`
+	Limit  int                   `form:"limit"`
+	Offset int                   `form:"offset"`
+}
+
+// PendingDelivery is a delivery claimed for an attempt, together with what
+// is needed to send it.
+type PendingDelivery struct {
+	ID        int
+	WebhookID int
+	URL       string
+	Secret    string
+	EventID   string
+	EventType EventType
+	Payload   []byte
+	Attempts  int
+}
+
+// DeliveryAttempt is the outcome of one attempt to send a delivery.
+// NextAttemptAt is set if the delivery stays pending.
+type DeliveryAttempt struct {
+	Status        WebhookDeliveryStatus
+	StatusCode    int
+	Error         string
+	Response      string
+	NextAttemptAt *time.Time
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// WorkspaceRole is a member's role in a workspace. Members see the workspace
+// and create lists in it, admins also rename it and manage its members, and
+// the owner may also delete it. Access to the lists of a workspace is
+// granted per list, see ListRole.
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleMember WorkspaceRole = "member"
+	WorkspaceRoleAdmin  WorkspaceRole = "admin"
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+)
+
+var workspaceRoleOrder = []WorkspaceRole{WorkspaceRoleMember, WorkspaceRoleAdmin, WorkspaceRoleOwner}
+
+// Valid reports whether r is a known role.
+func (r WorkspaceRole) Valid() bool {
+	return slices.Contains(workspaceRoleOrder, r)
+}
+
+// AtLeast reports whether r includes the rights of min.
+func (r WorkspaceRole) AtLeast(min WorkspaceRole) bool {
+	return r.Valid() && slices.Index(workspaceRoleOrder, r) >= slices.Index(workspaceRoleOrder, min)
+}
+
+// Workspace groups task lists, for example those of a team. Every user has
+// a personal workspace that holds their personal list. Role is the role of
+// the user who requested the workspace.
+type Workspace struct {
+	ID          int           `json:"id"`
+	Name        string        `json:"name"`
+	OwnerID     int           `json:"owner_id"`
+	Personal    bool          `json:"personal"`
+	Role        WorkspaceRole `json:"role"`
+	MemberCount int           `json:"member_count"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// WorkspaceMember is a user's membership in a workspace.
+type WorkspaceMember struct {
+	UserID    int           `json:"user_id"`
+	Username  string        `json:"username"`
+	Role      WorkspaceRole `json:"role"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// AddWorkspaceMemberRequest adds the user identified by username or email.
+type AddWorkspaceMemberRequest struct {
+	Login string        `json:"login" binding:"required"`
+	Role  WorkspaceRole `json:"role" binding:"required"`
+}
+
+type UpdateWorkspaceMemberRequest struct {
+	Role WorkspaceRole `json:"role" binding:"required"`
+}
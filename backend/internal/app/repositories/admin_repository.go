@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+// foreignKeyViolation is the Postgres error code for foreign key violations.
+const foreignKeyViolation = "23503"
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleExists   = errors.New("role already exists")
+	ErrRoleBuiltin  = errors.New("built-in roles cannot be changed")
+	ErrRoleInUse    = errors.New("role is assigned to users")
+)
+
+type AdminRepository interface {
+	ListUsers(ctx context.Context, query models.UserListQuery) ([]models.AdminUser, int, error)
+	GetUser(ctx context.Context, id int) (*models.AdminUser, error)
+	SetUserDisabled(ctx context.Context, id int, disabled bool) error
+	SetUserRole(ctx context.Context, id int, role string) error
+	// DeleteUser deletes a user together with everything the user owns and
+	// records task.deleted events by actorID for the tasks that go with it.
+	DeleteUser(ctx context.Context, id, actorID int) error
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	CreateRole(ctx context.Context, role *models.Role) error
+	UpdateRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	GetUsageStats(ctx context.Context) (*models.UsageStats, error)
+}
+
+type PostgresAdminRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresAdminRepository(db *sql.DB) *PostgresAdminRepository {
+	return &PostgresAdminRepository{db: db}
+}
+
+const adminUserColumns = `u.id, u.username, u.email, u.email_verified_at, u.password_hash IS NOT NULL,
+	u.role, u.disabled_at, (SELECT COUNT(*) FROM tasks t WHERE t.created_by = u.id), u.created_at`
+
+// adminUserFilter matches users by a case-insensitive substring of the
+// username or email ($1, empty matches all) and by role ($2, empty matches
+// all).
+const adminUserFilter = ` WHERE ($1 = '' OR STRPOS(LOWER(u.username), LOWER($1)) > 0 OR STRPOS(LOWER(COALESCE(u.email, '')), LOWER($1)) > 0)
+	AND ($2 = '' OR u.role = $2)`
+
+func scanAdminUser(row rowScanner) (*models.AdminUser, error) {
+	var user models.AdminUser
+	var email sql.NullString
+	var emailVerifiedAt, disabledAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &email, &emailVerifiedAt, &user.HasPassword,
+		&user.Role, &disabledAt, &user.TaskCount, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.Email = email.String
+	user.EmailVerified = emailVerifiedAt.Valid
+	if disabledAt.Valid {
+		user.Disabled = true
+		user.DisabledAt = &disabledAt.Time
+	}
+	return &user, nil
+}
+
+// ListUsers returns one page of users ordered by ID, and the number of users
+// matching the query across all pages.
+func (r *PostgresAdminRepository) ListUsers(ctx context.Context, query models.UserListQuery) ([]models.AdminUser, int, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.ListUsers")
+	defer span.End()
+
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users u"+adminUserFilter, query.Search, query.Role).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+adminUserColumns+" FROM users u"+adminUserFilter+" ORDER BY u.id LIMIT $3 OFFSET $4",
+		query.Search, query.Role, query.Limit, query.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []models.AdminUser{}
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, *user)
+	}
+	return users, total, rows.Err()
+}
+
+func (r *PostgresAdminRepository) GetUser(ctx context.Context, id int) (*models.AdminUser, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.GetUser")
+	defer span.End()
+
+	user, err := scanAdminUser(r.db.QueryRowContext(ctx, "SELECT "+adminUserColumns+" FROM users u WHERE u.id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// SetUserDisabled disables or re-enables a user. Disabling an already
+// disabled user keeps the original timestamp.
+func (r *PostgresAdminRepository) SetUserDisabled(ctx context.Context, id int, disabled bool) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.SetUserDisabled")
+	defer span.End()
+
+	query := "UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END WHERE id = $1"
+	return expectOneRow(r.db.ExecContext(ctx, query, id, disabled))
+}
+
+func (r *PostgresAdminRepository) SetUserRole(ctx context.Context, id int, role string) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.SetUserRole")
+	defer span.End()
+
+	err := expectOneRow(r.db.ExecContext(ctx, "UPDATE users SET role = $2 WHERE id = $1", id, role))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrRoleNotFound
+	}
+	return err
+}
+
+func (r *PostgresAdminRepository) DeleteUser(ctx context.Context, id, actorID int) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.DeleteUser")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The user's tasks, lists and workspaces cascade, and with the lists
+	// and workspaces every task in them.
+	where := `t.user_id = $1 OR t.list_id IN (SELECT l.id FROM task_lists l
+		LEFT JOIN workspaces w ON w.id = l.workspace_id WHERE l.owner_id = $1 OR w.owner_id = $1)`
+	if err := recordTaskDeletions(ctx, tx, actorID, where, id); err != nil {
+		return err
+	}
+	if err := expectOneRow(tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// expectOneRow turns the result of an UPDATE or DELETE of a single user into
+// ErrUserNotFound if no row was affected.
+func expectOneRow(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAdminRepository) ListRoles(ctx context.Context) ([]models.Role, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.ListRoles")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT name, description, permissions, builtin, created_at FROM roles ORDER BY builtin DESC, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions), &role.Builtin, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *PostgresAdminRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.CreateRole")
+	defer span.End()
+
+	query := "INSERT INTO roles (name, description, permissions) VALUES ($1, $2, $3) RETURNING created_at"
+	err := r.db.QueryRowContext(ctx, query, role.Name, role.Description, pq.Array(role.Permissions)).Scan(&role.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return ErrRoleExists
+	}
+	return err
+}
+
+// UpdateRole replaces the description and permissions of a custom role.
+func (r *PostgresAdminRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.UpdateRole")
+	defer span.End()
+
+	query := `UPDATE roles SET description = $2, permissions = $3 WHERE name = $1 AND NOT builtin
+		RETURNING created_at`
+	err := r.db.QueryRowContext(ctx, query, role.Name, role.Description, pq.Array(role.Permissions)).Scan(&role.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.missingRoleError(ctx, role.Name)
+	}
+	return err
+}
+
+// DeleteRole deletes a custom role that is not assigned to any user.
+func (r *PostgresAdminRepository) DeleteRole(ctx context.Context, name string) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.DeleteRole")
+	defer span.End()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM roles WHERE name = $1 AND NOT builtin", name)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrRoleInUse
+	}
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return r.missingRoleError(ctx, name)
+	}
+	return nil
+}
+
+// missingRoleError explains why a statement restricted to custom roles did
+// not match the role name.
+func (r *PostgresAdminRepository) missingRoleError(ctx context.Context, name string) error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", name).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrRoleBuiltin
+	}
+	return ErrRoleNotFound
+}
+
+func (r *PostgresAdminRepository) GetUsageStats(ctx context.Context) (*models.UsageStats, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminRepository.GetUsageStats")
+	defer span.End()
+
+	var stats models.UsageStats
+	query := `SELECT
+		(SELECT COUNT(*) FROM users),
+		(SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+		(SELECT COUNT(*) FROM users WHERE email_verified_at IS NOT NULL),
+		(SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL '7 days'),
+		(SELECT COUNT(*) FROM users WHERE created_at > NOW() - INTERVAL '30 days'),
+		(SELECT COUNT(*) FROM tasks),
+		(SELECT COUNT(*) FROM tasks WHERE completed),
+		(SELECT COUNT(*) FROM oauth_clients),
+		(SELECT COUNT(*) FROM user_identities)`
+	err := r.db.QueryRowContext(ctx, query).Scan(&stats.Users, &stats.DisabledUsers, &stats.VerifiedUsers,
+		&stats.SignupsLast7d, &stats.SignupsLast30d, &stats.Tasks, &stats.CompletedTasks,
+		&stats.OAuthClients, &stats.LinkedIdentities)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
@@ -3,15 +3,39 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 	"go.opentelemetry.io/otel"
 )
 
+// uniqueViolation is the Postgres error code for unique constraint violations.
+const uniqueViolation = "23505"
+
+var (
+	ErrUserNotFound             = errors.New("user not found")
+	ErrUsernameTaken            = errors.New("username is already taken")
+	ErrEmailTaken               = errors.New("email is already registered")
+	ErrResetTokenInvalid        = errors.New("reset token is invalid or expired")
+	ErrVerificationTokenInvalid = errors.New("verification token is invalid or expired")
+)
+
 type AuthRepository interface {
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
+	GetUserByID(ctx context.Context, id int) (*models.User, error)
 	CreateUser(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	CreatePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error)
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error
+	CreateEmailVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) error
+	// SetTimeZone stores the IANA time zone of userID.
+	SetTimeZone(ctx context.Context, userID int, timeZone string) error
 }
 
 type PostgresAuthRepository struct {
@@ -22,35 +46,231 @@ func NewPostgresAuthRepository(db *sql.DB) *PostgresAuthRepository {
 	return &PostgresAuthRepository{db: db}
 }
 
-func (r *PostgresAuthRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
-	_, span := otel.Tracer("").Start(ctx, "AuthRepository.GetUserByUsername")
-	defer span.End()
+// userColumns selects a user together with the permissions of its role. It
+// expects users aliased as u and roles as r, see fromUsers.
+const userColumns = "u.id, u.username, u.email, u.email_verified_at, u.password_hash, u.created_at, u.role, u.disabled_at, u.time_zone, r.permissions"
 
-	utils.RandomSleep()
+const fromUsers = " FROM users u JOIN roles r ON r.name = u.role"
+
+func scanUser(row *sql.Row) (*models.User, error) {
 	var user models.User
-	var storedPasswordHash string
-	query := "SELECT id, username, password_hash FROM users WHERE username = $1"
-	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &storedPasswordHash)
+	var email sql.NullString
+	var emailVerifiedAt sql.NullTime
+	var storedPasswordHash sql.NullString
+	var disabledAt sql.NullTime
+	var timeZone sql.NullString
+	err := row.Scan(&user.ID, &user.Username, &email, &emailVerifiedAt, &storedPasswordHash, &user.CreatedAt,
+		&user.Role, &disabledAt, &timeZone, pq.Array(&user.Permissions))
 	if err != nil {
 		return nil, err
 	}
-	user.Password = storedPasswordHash // Temporarily store hash in Password field
+	user.Email = email.String
+	user.EmailVerified = emailVerifiedAt.Valid
+	user.Disabled = disabledAt.Valid
+	user.TimeZone = timeZone.String
+	user.Password = storedPasswordHash.String // Temporarily store hash in Password field; empty if password login is disabled
 
 	return &user, nil
 }
 
+func (r *PostgresAuthRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.GetUserByUsername")
+	defer span.End()
+
+	utils.RandomSleep()
+	query := "SELECT " + userColumns + fromUsers + " WHERE u.username = $1"
+	return scanUser(r.db.QueryRowContext(ctx, query, username))
+}
+
+// GetUserByLogin looks a user up by username or, case-insensitively, by email.
+// It returns ErrUserNotFound if no account matches.
+func (r *PostgresAuthRepository) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.GetUserByLogin")
+	defer span.End()
+
+	query := "SELECT " + userColumns + fromUsers + " WHERE u.username = $1 OR LOWER(u.email) = LOWER($1)"
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, login))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+// CreateUser inserts user. An empty password hash creates an account without
+// password login, and EmailVerified marks the email address as verified.
 func (r *PostgresAuthRepository) CreateUser(ctx context.Context, user *models.User) error {
 	_, span := otel.Tracer("").Start(ctx, "AuthRepository.CreateUser")
 	defer span.End()
 
 	utils.RandomSleep()
-	query := "INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id"
-	var id int
-	err := r.db.QueryRowContext(ctx, query, user.Username, user.Password).Scan(&id)
+	return insertUser(ctx, r.db, user)
+}
+
+// queryRower is implemented by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertUser(ctx context.Context, q queryRower, user *models.User) error {
+	query := `INSERT INTO users (username, email, password_hash, email_verified_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), CASE WHEN $4 THEN NOW() END)
+		RETURNING id, created_at, role`
+	err := q.QueryRowContext(ctx, query, user.Username, user.Email, user.Password, user.EmailVerified).Scan(&user.ID, &user.CreatedAt, &user.Role)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == "idx_users_email_lower" {
+				return ErrEmailTaken
+			}
+			return ErrUsernameTaken
+		}
 		return err
 	}
-	user.ID = id
 
 	return nil
 }
+
+func (r *PostgresAuthRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.GetUserByID")
+	defer span.End()
+
+	query := "SELECT " + userColumns + fromUsers + " WHERE u.id = $1"
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *PostgresAuthRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.UpdatePassword")
+	defer span.End()
+
+	query := "UPDATE users SET password_hash = $1 WHERE id = $2"
+	result, err := r.db.ExecContext(ctx, query, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAuthRepository) CreatePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.CreatePasswordResetToken")
+	defer span.End()
+
+	query := "INSERT INTO password_reset_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)"
+	_, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+// GetUserByResetToken returns the owner of a reset token that is still
+// usable, without consuming it.
+func (r *PostgresAuthRepository) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.GetUserByResetToken")
+	defer span.End()
+
+	query := "SELECT " + userColumns + ` FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id JOIN roles r ON r.name = u.role
+		WHERE t.token_hash = $1 AND t.used_at IS NULL AND t.expires_at > NOW()`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrResetTokenInvalid
+	}
+	return user, err
+}
+
+// ResetPassword consumes the reset token identified by tokenHash and stores
+// the new password hash in a single transaction. Any other outstanding reset
+// tokens of the same user are invalidated as well.
+func (r *PostgresAuthRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.ResetPassword")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	query := `UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrResetTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET password_hash = $1 WHERE id = $2", passwordHash, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE password_reset_tokens SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresAuthRepository) CreateEmailVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.CreateEmailVerificationToken")
+	defer span.End()
+
+	query := "INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)"
+	_, err := r.db.ExecContext(ctx, query, userID, tokenHash, expiresAt)
+	return err
+}
+
+// VerifyEmail consumes the verification token identified by tokenHash and
+// marks the owning user's email address as verified.
+func (r *PostgresAuthRepository) VerifyEmail(ctx context.Context, tokenHash string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.VerifyEmail")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	query := `UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrVerificationTokenInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1", userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *PostgresAuthRepository) SetTimeZone(ctx context.Context, userID int, timeZone string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthRepository.SetTimeZone")
+	defer span.End()
+
+	n, err := rowsAffected(r.db.ExecContext(ctx, "UPDATE users SET time_zone = $2 WHERE id = $1", userID, timeZone))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// CalendarFeedRepository stores the calendar feeds of users by the hash of
+// their token, see utils.HashToken.
+type CalendarFeedRepository interface {
+	// SetFeed creates the user's feed, or replaces its token so that the
+	// old URL stops working.
+	SetFeed(ctx context.Context, userID int, tokenHash string) (*models.CalendarFeed, error)
+	GetFeed(ctx context.Context, userID int) (*models.CalendarFeed, error)
+	DeleteFeed(ctx context.Context, userID int) error
+	// FeedUser returns the user whose feed has tokenHash. The feeds of
+	// disabled users are not found.
+	FeedUser(ctx context.Context, tokenHash string) (int, error)
+}
+
+type PostgresCalendarFeedRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCalendarFeedRepository(db *sql.DB) *PostgresCalendarFeedRepository {
+	return &PostgresCalendarFeedRepository{db: db}
+}
+
+func (r *PostgresCalendarFeedRepository) SetFeed(ctx context.Context, userID int, tokenHash string) (*models.CalendarFeed, error) {
+	_, span := otel.Tracer("").Start(ctx, "CalendarFeedRepository.SetFeed")
+	defer span.End()
+
+	query := `INSERT INTO calendar_feeds (user_id, token_hash) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+		RETURNING created_at`
+	var feed models.CalendarFeed
+	if err := r.db.QueryRowContext(ctx, query, userID, tokenHash).Scan(&feed.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *PostgresCalendarFeedRepository) GetFeed(ctx context.Context, userID int) (*models.CalendarFeed, error) {
+	_, span := otel.Tracer("").Start(ctx, "CalendarFeedRepository.GetFeed")
+	defer span.End()
+
+	var feed models.CalendarFeed
+	err := r.db.QueryRowContext(ctx, "SELECT created_at FROM calendar_feeds WHERE user_id = $1", userID).Scan(&feed.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCalendarFeedNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func (r *PostgresCalendarFeedRepository) DeleteFeed(ctx context.Context, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "CalendarFeedRepository.DeleteFeed")
+	defer span.End()
+
+	n, err := rowsAffected(r.db.ExecContext(ctx, "DELETE FROM calendar_feeds WHERE user_id = $1", userID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+func (r *PostgresCalendarFeedRepository) FeedUser(ctx context.Context, tokenHash string) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "CalendarFeedRepository.FeedUser")
+	defer span.End()
+
+	query := `SELECT f.user_id FROM calendar_feeds f JOIN users u ON u.id = f.user_id
+		WHERE f.token_hash = $1 AND u.disabled_at IS NULL`
+	var userID int
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrCalendarFeedNotFound
+	}
+	return userID, err
+}
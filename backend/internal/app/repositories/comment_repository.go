@@ -0,0 +1,280 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrCommentNotFound  = errors.New("comment not found")
+	ErrNotCommentAuthor = errors.New("user is not the author of the comment")
+)
+
+// CommentRepository stores task comments and the users they mention. Every
+// member of a task's list may read and write comments; comments on tasks the
+// user cannot see are reported as ErrTaskNotFound. Mentions are given as
+// usernames and only recorded for members of the task's list.
+type CommentRepository interface {
+	// ListComments returns the comments of taskID, oldest first.
+	ListComments(ctx context.Context, taskID, userID int) ([]models.Comment, error)
+	// CreateComment adds comment to comment.TaskID on behalf of
+	// comment.AuthorID and fills in the generated fields and the mentions.
+	CreateComment(ctx context.Context, comment *models.Comment, mentions []string) error
+	// UpdateComment changes the body and mentions of comment.ID on behalf of
+	// comment.AuthorID, who must have written it. It returns the IDs of the
+	// users who were not mentioned before.
+	UpdateComment(ctx context.Context, comment *models.Comment, mentions []string) (added []int, err error)
+	// DeleteComment removes a comment. Besides its author, list admins may
+	// delete it.
+	DeleteComment(ctx context.Context, taskID, commentID, userID int) error
+}
+
+type PostgresCommentRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresCommentRepository(db *sql.DB) *PostgresCommentRepository {
+	return &PostgresCommentRepository{db: db}
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+const commentColumns = "c.id, c.task_id, c.author_id, u.username, c.body, c.created_at, c.edited_at"
+
+const fromComments = " FROM task_comments c LEFT JOIN users u ON u.id = c.author_id"
+
+func scanComment(row rowScanner) (*models.Comment, error) {
+	comment := models.Comment{Mentions: []models.CommentMention{}}
+	var authorID sql.NullInt64
+	var authorName sql.NullString
+	var editedAt sql.NullTime
+	err := row.Scan(&comment.ID, &comment.TaskID, &authorID, &authorName, &comment.Body, &comment.CreatedAt, &editedAt)
+	if err != nil {
+		return nil, err
+	}
+	comment.AuthorID = int(authorID.Int64)
+	comment.AuthorName = authorName.String
+	if editedAt.Valid {
+		comment.EditedAt = &editedAt.Time
+	}
+	return &comment, nil
+}
+
+// loadMentions fills in the mentions of comments with a single query.
+func loadMentions(ctx context.Context, q querier, comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	index := make(map[int]int, len(comments))
+	ids := make([]int64, len(comments))
+	for i, comment := range comments {
+		index[comment.ID] = i
+		ids[i] = int64(comment.ID)
+	}
+
+	query := `SELECT cm.comment_id, u.id, u.username FROM comment_mentions cm
+		JOIN users u ON u.id = cm.user_id
+		WHERE cm.comment_id = ANY($1)
+		ORDER BY u.username`
+	rows, err := q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentID int
+		var mention models.CommentMention
+		if err := rows.Scan(&commentID, &mention.UserID, &mention.Username); err != nil {
+			return err
+		}
+		comment := &comments[index[commentID]]
+		comment.Mentions = append(comment.Mentions, mention)
+	}
+	return rows.Err()
+}
+
+// insertMentions records the mentions of usernames that are members of the
+// list of taskID and returns the users that were not mentioned by commentID
+// before.
+func insertMentions(ctx context.Context, q querier, commentID, taskID int, usernames []string) ([]int, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+	query := `INSERT INTO comment_mentions (comment_id, user_id)
+		SELECT $1, u.id FROM users u
+		JOIN task_list_members m ON m.user_id = u.id
+		JOIN tasks t ON t.list_id = m.list_id
+		WHERE t.id = $2 AND u.username = ANY($3)
+		ON CONFLICT DO NOTHING
+		RETURNING user_id`
+	rows, err := q.QueryContext(ctx, query, commentID, taskID, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var added []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		added = append(added, userID)
+	}
+	return added, rows.Err()
+}
+
+func (r *PostgresCommentRepository) ListComments(ctx context.Context, taskID, userID int) ([]models.Comment, error) {
+	_, span := otel.Tracer("").Start(ctx, "CommentRepository.ListComments")
+	defer span.End()
+
+	if _, err := taskRole(ctx, r.db, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT " + commentColumns + fromComments + " WHERE c.task_id = $1 ORDER BY c.created_at, c.id"
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, loadMentions(ctx, r.db, comments)
+}
+
+func (r *PostgresCommentRepository) CreateComment(ctx context.Context, comment *models.Comment, mentions []string) error {
+	_, span := otel.Tracer("").Start(ctx, "CommentRepository.CreateComment")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(ctx, tx, comment.TaskID, comment.AuthorID); err != nil {
+		return err
+	}
+
+	query := `WITH c AS (
+			INSERT INTO task_comments (task_id, author_id, body) VALUES ($1, $2, $3)
+			RETURNING *
+		)
+		SELECT ` + commentColumns + " FROM c LEFT JOIN users u ON u.id = c.author_id"
+	created, err := scanComment(tx.QueryRowContext(ctx, query, comment.TaskID, comment.AuthorID, comment.Body))
+	if err != nil {
+		return err
+	}
+	if _, err := insertMentions(ctx, tx, created.ID, created.TaskID, mentions); err != nil {
+		return err
+	}
+	comments := []models.Comment{*created}
+	if err := loadMentions(ctx, tx, comments); err != nil {
+		return err
+	}
+	*comment = comments[0]
+
+	return tx.Commit()
+}
+
+func (r *PostgresCommentRepository) UpdateComment(ctx context.Context, comment *models.Comment, mentions []string) ([]int, error) {
+	_, span := otel.Tracer("").Start(ctx, "CommentRepository.UpdateComment")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(ctx, tx, comment.TaskID, comment.AuthorID); err != nil {
+		return nil, err
+	}
+	var authorID sql.NullInt64
+	query := "SELECT author_id FROM task_comments WHERE id = $1 AND task_id = $2 FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, comment.ID, comment.TaskID).Scan(&authorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int(authorID.Int64) != comment.AuthorID {
+		return nil, ErrNotCommentAuthor
+	}
+
+	query = "UPDATE task_comments SET body = $2, edited_at = NOW() WHERE id = $1"
+	if _, err := tx.ExecContext(ctx, query, comment.ID, comment.Body); err != nil {
+		return nil, err
+	}
+	query = `DELETE FROM comment_mentions cm USING users u
+		WHERE cm.comment_id = $1 AND u.id = cm.user_id AND u.username <> ALL(COALESCE($2::text[], '{}'))`
+	if _, err := tx.ExecContext(ctx, query, comment.ID, pq.Array(mentions)); err != nil {
+		return nil, err
+	}
+	added, err := insertMentions(ctx, tx, comment.ID, comment.TaskID, mentions)
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT " + commentColumns + fromComments + " WHERE c.id = $1"
+	updated, err := scanComment(tx.QueryRowContext(ctx, query, comment.ID))
+	if err != nil {
+		return nil, err
+	}
+	comments := []models.Comment{*updated}
+	if err := loadMentions(ctx, tx, comments); err != nil {
+		return nil, err
+	}
+	*comment = comments[0]
+
+	return added, tx.Commit()
+}
+
+func (r *PostgresCommentRepository) DeleteComment(ctx context.Context, taskID, commentID, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "CommentRepository.DeleteComment")
+	defer span.End()
+
+	role, err := taskRole(ctx, r.db, taskID, userID)
+	if err != nil {
+		return err
+	}
+	query := `DELETE FROM task_comments WHERE id = $1 AND task_id = $2 AND (author_id = $3 OR $4)`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, commentID, taskID, userID, role.AtLeast(models.ListRoleAdmin)))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	query = "SELECT EXISTS (SELECT 1 FROM task_comments WHERE id = $1 AND task_id = $2)"
+	if err := r.db.QueryRowContext(ctx, query, commentID, taskID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrCommentNotFound
+	}
+	return ErrNotCommentAuthor
+}
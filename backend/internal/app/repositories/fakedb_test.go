@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeDB is a database/sql driver for tests that answers queries from a
+// script instead of running them. handle is called with every query and
+// its arguments and returns the rows of a query, or nil for statements.
+// Transactions are recorded but not isolated.
+type fakeDB struct {
+	mu        sync.Mutex
+	handle    func(query string, args []driver.NamedValue) ([][]driver.Value, error)
+	queries   []string
+	commits   int
+	rollbacks int
+}
+
+func newFakeDB(handle func(query string, args []driver.NamedValue) ([][]driver.Value, error)) (*fakeDB, *sql.DB) {
+	f := &fakeDB{handle: handle}
+	return f, sql.OpenDB(f)
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{} }
+
+func (f *fakeDB) run(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, query)
+	f.mu.Unlock()
+	return f.handle(query, args)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("fakeDB is opened with sql.OpenDB")
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakeDB does not prepare statements")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return &fakeTx{db: c.db}, nil }
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return &fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rows, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(len(rows)), nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (t *fakeTx) Commit() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	t.db.commits++
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.db.mu.Lock()
+	defer t.db.mu.Unlock()
+	t.db.rollbacks++
+	return nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+// Columns names the columns c0, c1, ...; scanning only needs their count.
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return nil
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// fakeTaskRow returns a row of taskColumns for a task in list 3.
+func fakeTaskRow(id int, snoozedUntil *time.Time) []driver.Value {
+	var snoozed driver.Value
+	if snoozedUntil != nil {
+		snoozed = *snoozedUntil
+	}
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	return []driver.Value{int64(id), int64(3), "Task", false, int64(1), created, nil, nil,
+		nil, nil, nil, snoozed, "", "", int64(0)}
+}
+
+// isQuery reports whether query starts with prefix, ignoring leading
+// space.
+func isQuery(query, prefix string) bool {
+	return strings.HasPrefix(strings.TrimSpace(query), prefix)
+}
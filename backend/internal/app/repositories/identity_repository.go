@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrIdentityInUse         = errors.New("identity is linked to another user")
+	ErrProviderAlreadyLinked = errors.New("user already has an identity at this provider")
+	ErrLastLoginMethod       = errors.New("cannot remove the last login method")
+	ErrLoginStateInvalid     = errors.New("login state is invalid or expired")
+)
+
+type IdentityRepository interface {
+	CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error
+	ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error)
+	GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	CreateIdentity(ctx context.Context, identity *models.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error
+	ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error)
+	DeleteIdentity(ctx context.Context, userID int, provider string) error
+	DisablePasswordLogin(ctx context.Context, userID int) error
+}
+
+type PostgresIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresIdentityRepository(db *sql.DB) *PostgresIdentityRepository {
+	return &PostgresIdentityRepository{db: db}
+}
+
+func (r *PostgresIdentityRepository) CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.CreateLoginState")
+	defer span.End()
+
+	// Opportunistically drop abandoned states so the table stays small.
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM oidc_login_states WHERE expires_at < NOW()"); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_login_states (state_hash, provider, nonce, code_verifier, link_user_id, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6)`
+	_, err := r.db.ExecContext(ctx, query, state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.LinkUserID, state.ExpiresAt)
+	return err
+}
+
+// ConsumeLoginState deletes and returns the unexpired state identified by
+// stateHash, so each state can complete at most one login.
+func (r *PostgresIdentityRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.ConsumeLoginState")
+	defer span.End()
+
+	var state models.OIDCLoginState
+	var linkUserID sql.NullInt64
+	query := `DELETE FROM oidc_login_states WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING state_hash, provider, nonce, code_verifier, link_user_id, expires_at`
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(
+		&state.StateHash, &state.Provider, &state.Nonce, &state.CodeVerifier, &linkUserID, &state.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLoginStateInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	state.LinkUserID = int(linkUserID.Int64)
+	return &state, nil
+}
+
+func (r *PostgresIdentityRepository) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.GetUserByIdentity")
+	defer span.End()
+
+	query := "SELECT " + userColumns + ` FROM user_identities i
+		JOIN users u ON u.id = i.user_id JOIN roles r ON r.name = u.role
+		WHERE i.provider = $1 AND i.subject = $2`
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, provider, subject))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *PostgresIdentityRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.CreateIdentity")
+	defer span.End()
+
+	return insertIdentity(ctx, r.db, identity)
+}
+
+// CreateUserWithIdentity creates a user together with its first identity in
+// a single transaction.
+func (r *PostgresIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.CreateUserWithIdentity")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertUser(ctx, tx, user); err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	if err := insertIdentity(ctx, tx, identity); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertIdentity(ctx context.Context, q queryRower, identity *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, NULLIF($4, '')) RETURNING id, created_at`
+	err := q.QueryRowContext(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email).
+		Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == "user_identities_user_provider_key" {
+				return ErrProviderAlreadyLinked
+			}
+			return ErrIdentityInUse
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *PostgresIdentityRepository) ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.ListIdentities")
+	defer span.End()
+
+	query := `SELECT id, user_id, provider, subject, email, created_at
+		FROM user_identities WHERE user_id = $1 ORDER BY provider`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var identity models.UserIdentity
+		var email sql.NullString
+		if err := rows.Scan(&identity.ID, &identity.UserID, &identity.Provider, &identity.Subject, &email, &identity.CreatedAt); err != nil {
+			return nil, err
+		}
+		identity.Email = email.String
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// DeleteIdentity unlinks the user's identity at provider unless it is the
+// user's only way to log in.
+func (r *PostgresIdentityRepository) DeleteIdentity(ctx context.Context, userID int, provider string) error {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.DeleteIdentity")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the user row so concurrent unlinks cannot both pass the check.
+	var hasPassword bool
+	err = tx.QueryRowContext(ctx, "SELECT password_hash IS NOT NULL FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&hasPassword)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	var linked, others int
+	query := `SELECT COUNT(*) FILTER (WHERE provider = $2), COUNT(*) FILTER (WHERE provider <> $2)
+		FROM user_identities WHERE user_id = $1`
+	if err := tx.QueryRowContext(ctx, query, userID, provider).Scan(&linked, &others); err != nil {
+		return err
+	}
+	if linked == 0 {
+		return ErrIdentityNotFound
+	}
+	if !hasPassword && others == 0 {
+		return ErrLastLoginMethod
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_identities WHERE user_id = $1 AND provider = $2", userID, provider); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisablePasswordLogin removes the user's password. It requires at least one
+// linked identity so the account stays reachable.
+func (r *PostgresIdentityRepository) DisablePasswordLogin(ctx context.Context, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "IdentityRepository.DisablePasswordLogin")
+	defer span.End()
+
+	query := `UPDATE users SET password_hash = NULL
+		WHERE id = $1 AND EXISTS (SELECT 1 FROM user_identities WHERE user_id = $1)`
+	result, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrLastLoginMethod
+	}
+	return nil
+}
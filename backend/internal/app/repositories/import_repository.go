@@ -0,0 +1,276 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+// importProgressInterval is how many rows a job works through between
+// progress reports.
+const importProgressInterval = 100
+
+var (
+	ErrImportJobNotFound = errors.New("import job not found")
+	ErrImportLeaseLost   = errors.New("import job lease expired or was taken over")
+)
+
+// ImportRepository stores import jobs and applies them. Jobs are leased to
+// one runner at a time like reminders, see ReminderRepository.
+type ImportRepository interface {
+	// ListTitles returns the titles of the tasks in listIDs by list.
+	ListTitles(ctx context.Context, listIDs []int) (map[int][]string, error)
+	// CreateJob stores job as pending and sets its ID and CreatedAt.
+	CreateJob(ctx context.Context, job *models.ImportJob) error
+	// GetJob returns jobID if userID started it, without its rows.
+	GetJob(ctx context.Context, jobID, userID int) (*models.ImportJob, error)
+	// ClaimJob leases the oldest job that is pending or whose lease ran out
+	// to token for lease, or jobID if it is not 0 and claimable, and counts
+	// the attempt. It returns nil if there is none.
+	ClaimJob(ctx context.Context, token string, lease time.Duration, jobID int) (*models.ImportJob, error)
+	// ApplyJob creates the rows of job on behalf of job.UserID in one
+	// transaction, skipping duplicates, and completes the job in the same
+	// transaction. It reports progress and extends the lease as it goes.
+	// The acting user needs the editor role in every list. The returned
+	// job is the completed one.
+	ApplyJob(ctx context.Context, job *models.ImportJob, token string, lease time.Duration) (*models.ImportJob, error)
+	// FailJob marks job as failed with message.
+	FailJob(ctx context.Context, jobID int, token, message string) error
+}
+
+type PostgresImportRepository struct {
+	db    *sql.DB
+	clock *crdt.Clock
+}
+
+// NewPostgresImportRepository returns an import repository that stamps
+// the tasks it creates with clock.
+func NewPostgresImportRepository(db *sql.DB, clock *crdt.Clock) *PostgresImportRepository {
+	return &PostgresImportRepository{db: db, clock: clock}
+}
+
+const importJobColumns = `j.id, j.user_id, j.workspace_id, j.status, j.total, j.processed, j.created, j.skipped,
+	COALESCE(j.error, ''), j.created_at, j.started_at, j.finished_at`
+
+func scanImportJob(row rowScanner, extra ...any) (*models.ImportJob, error) {
+	var job models.ImportJob
+	var startedAt, finishedAt sql.NullTime
+	dest := []any{&job.ID, &job.UserID, &job.WorkspaceID, &job.Status, &job.Total, &job.Processed, &job.Created, &job.Skipped,
+		&job.Error, &job.CreatedAt, &startedAt, &finishedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
+
+func (r *PostgresImportRepository) ListTitles(ctx context.Context, listIDs []int) (map[int][]string, error) {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.ListTitles")
+	defer span.End()
+
+	return listTitles(ctx, r.db, listIDs)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func listTitles(ctx context.Context, q queryer, listIDs []int) (map[int][]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT list_id, title FROM tasks WHERE list_id = ANY($1)", pq.Array(listIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	titles := make(map[int][]string)
+	for rows.Next() {
+		var listID int
+		var title string
+		if err := rows.Scan(&listID, &title); err != nil {
+			return nil, err
+		}
+		titles[listID] = append(titles[listID], title)
+	}
+	return titles, rows.Err()
+}
+
+func (r *PostgresImportRepository) CreateJob(ctx context.Context, job *models.ImportJob) error {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.CreateJob")
+	defer span.End()
+
+	rows, err := json.Marshal(job.Rows)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO import_jobs (user_id, workspace_id, rows, total) VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at`
+	return r.db.QueryRowContext(ctx, query, job.UserID, job.WorkspaceID, string(rows), len(job.Rows)).
+		Scan(&job.ID, &job.Status, &job.CreatedAt)
+}
+
+func (r *PostgresImportRepository) GetJob(ctx context.Context, jobID, userID int) (*models.ImportJob, error) {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.GetJob")
+	defer span.End()
+
+	query := "SELECT " + importJobColumns + " FROM import_jobs j WHERE j.id = $1 AND j.user_id = $2"
+	job, err := scanImportJob(r.db.QueryRowContext(ctx, query, jobID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportJobNotFound
+	}
+	return job, err
+}
+
+func (r *PostgresImportRepository) ClaimJob(ctx context.Context, token string, lease time.Duration, jobID int) (*models.ImportJob, error) {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.ClaimJob")
+	defer span.End()
+
+	query := `WITH open AS (
+			SELECT id FROM import_jobs
+			WHERE ($3 = 0 OR id = $3)
+			AND (status = 'pending' OR (status = 'running' AND lease_until < NOW()))
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE import_jobs j
+		SET status = 'running', lease_token = $1, lease_until = NOW() + $2 * INTERVAL '1 millisecond',
+			attempts = j.attempts + 1, processed = 0, started_at = COALESCE(j.started_at, NOW())
+		FROM open WHERE j.id = open.id
+		RETURNING ` + importJobColumns + ", j.rows, j.attempts"
+	var rows []byte
+	var attempts int
+	job, err := scanImportJob(r.db.QueryRowContext(ctx, query, token, lease.Milliseconds(), jobID), &rows, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	job.Attempts = attempts
+	if err := json.Unmarshal(rows, &job.Rows); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *PostgresImportRepository) ApplyJob(ctx context.Context, job *models.ImportJob, token string, lease time.Duration) (*models.ImportJob, error) {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.ApplyJob")
+	defer span.End()
+
+	ctx = tenant.WithWorkspace(ctx, job.WorkspaceID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var listIDs []int
+	for _, row := range job.Rows {
+		if !slices.Contains(listIDs, row.ListID) {
+			listIDs = append(listIDs, row.ListID)
+		}
+	}
+	for _, listID := range listIDs {
+		role, err := memberRole(ctx, tx, listID, job.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if !role.AtLeast(models.ListRoleEditor) {
+			return nil, ErrListRoleTooLow
+		}
+	}
+	titles, err := listTitles(ctx, tx, listIDs)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for listID, list := range titles {
+		for _, title := range list {
+			seen[models.ImportRow{ListID: listID, Title: title}.DuplicateKey()] = true
+		}
+	}
+
+	created, skipped := 0, 0
+	stamp := r.clock.Now().String()
+	query := `INSERT INTO tasks AS t (list_id, created_by, title, completed, title_clock, completed_clock)
+		VALUES ($1, $2, $3, $4, $5, $5) RETURNING ` + taskColumns
+	for i, row := range job.Rows {
+		if key := row.DuplicateKey(); seen[key] {
+			skipped++
+		} else {
+			seen[key] = true
+			task, err := scanTask(tx.QueryRowContext(ctx, query, row.ListID, job.UserID, row.Title, row.Completed, stamp))
+			if err != nil {
+				return nil, err
+			}
+			if err := recordEvents(ctx, tx, models.NewTaskEvent(models.EventTaskCreated, task, job.UserID)); err != nil {
+				return nil, err
+			}
+			created++
+		}
+		if (i+1)%importProgressInterval == 0 && i+1 < len(job.Rows) {
+			if err := r.reportProgress(ctx, job.ID, token, i+1, lease); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	query = `UPDATE import_jobs j
+		SET status = 'completed', processed = j.total, created = $3, skipped = $4, error = NULL,
+			finished_at = NOW(), lease_token = NULL, lease_until = NULL
+		WHERE j.id = $1 AND j.lease_token = $2 AND j.status = 'running'
+		RETURNING ` + importJobColumns
+	completed, err := scanImportJob(tx.QueryRowContext(ctx, query, job.ID, token, created, skipped))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImportLeaseLost
+	}
+	if err != nil {
+		return nil, err
+	}
+	return completed, tx.Commit()
+}
+
+// reportProgress records progress outside the job's transaction, so that
+// it is visible while the job runs, and extends the lease.
+func (r *PostgresImportRepository) reportProgress(ctx context.Context, jobID int, token string, processed int, lease time.Duration) error {
+	query := `UPDATE import_jobs SET processed = $3, lease_until = NOW() + $4 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND lease_token = $2 AND status = 'running'`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, jobID, token, processed, lease.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrImportLeaseLost
+	}
+	return nil
+}
+
+func (r *PostgresImportRepository) FailJob(ctx context.Context, jobID int, token, message string) error {
+	_, span := otel.Tracer("").Start(ctx, "ImportRepository.FailJob")
+	defer span.End()
+
+	query := `UPDATE import_jobs SET status = 'failed', error = $3, finished_at = NOW(), lease_token = NULL, lease_until = NULL
+		WHERE id = $1 AND lease_token = $2 AND status = 'running'`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, jobID, token, message))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrImportLeaseLost
+	}
+	return nil
+}
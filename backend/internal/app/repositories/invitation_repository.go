@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrInvitationNotFound   = errors.New("invitation not found")
+	ErrInvitationNotPending = errors.New("invitation is not pending")
+)
+
+type InvitationRepository interface {
+	// CreateInvitation stores inv with the ID of its token and fills in the
+	// generated fields.
+	CreateInvitation(ctx context.Context, inv *models.Invitation, tokenID string) error
+	// GetInvitation returns an invitation in any state, provided tokenID
+	// matches.
+	GetInvitation(ctx context.Context, invitationID int, tokenID string) (*models.Invitation, error)
+	ListPendingInvitations(ctx context.Context, listID int) ([]models.Invitation, error)
+	// AcceptInvitation adds userID to the invited list and its workspace and
+	// marks the invitation accepted. It returns the list ID.
+	AcceptInvitation(ctx context.Context, invitationID int, tokenID string, userID int) (int, error)
+	DeclineInvitation(ctx context.Context, invitationID int, tokenID string) error
+	RevokeInvitation(ctx context.Context, listID, invitationID int) error
+}
+
+type PostgresInvitationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresInvitationRepository(db *sql.DB) *PostgresInvitationRepository {
+	return &PostgresInvitationRepository{db: db}
+}
+
+// pendingInvitation matches invitations, aliased as i, that can still be
+// accepted.
+const pendingInvitation = "i.accepted_at IS NULL AND i.declined_at IS NULL AND i.revoked_at IS NULL AND i.expires_at > NOW()"
+
+// invitationColumns selects an invitation aliased as i together with the
+// list name and the inviter's username, see fromInvitations.
+const invitationColumns = `i.id, i.list_id, l.name, i.inviter_id, u.username, i.email, i.role,
+	CASE
+		WHEN i.accepted_at IS NOT NULL THEN 'accepted'
+		WHEN i.declined_at IS NOT NULL THEN 'declined'
+		WHEN i.revoked_at IS NOT NULL THEN 'revoked'
+		WHEN i.expires_at <= NOW() THEN 'expired'
+		ELSE 'pending'
+	END,
+	i.created_at, i.expires_at`
+
+const fromInvitations = " JOIN task_lists l ON l.id = i.list_id JOIN users u ON u.id = i.inviter_id"
+
+func scanInvitation(row rowScanner) (*models.Invitation, error) {
+	var inv models.Invitation
+	var email sql.NullString
+	err := row.Scan(&inv.ID, &inv.ListID, &inv.ListName, &inv.InviterID, &inv.InviterName, &email, &inv.Role,
+		&inv.Status, &inv.CreatedAt, &inv.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	inv.Email = email.String
+	return &inv, nil
+}
+
+func (r *PostgresInvitationRepository) CreateInvitation(ctx context.Context, inv *models.Invitation, tokenID string) error {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.CreateInvitation")
+	defer span.End()
+
+	query := `WITH i AS (
+			INSERT INTO list_invitations (list_id, inviter_id, email, role, token_id, expires_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6)
+			RETURNING *
+		)
+		SELECT ` + invitationColumns + " FROM i" + fromInvitations
+	created, err := scanInvitation(r.db.QueryRowContext(ctx, query,
+		inv.ListID, inv.InviterID, inv.Email, inv.Role, tokenID, inv.ExpiresAt))
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrTaskListNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*inv = *created
+	return nil
+}
+
+func (r *PostgresInvitationRepository) GetInvitation(ctx context.Context, invitationID int, tokenID string) (*models.Invitation, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.GetInvitation")
+	defer span.End()
+
+	query := "SELECT " + invitationColumns + " FROM list_invitations i" + fromInvitations +
+		" WHERE i.id = $1 AND i.token_id = $2"
+	inv, err := scanInvitation(r.db.QueryRowContext(ctx, query, invitationID, tokenID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvitationNotFound
+	}
+	return inv, err
+}
+
+// ListPendingInvitations returns the pending invitations of a list, newest
+// first.
+func (r *PostgresInvitationRepository) ListPendingInvitations(ctx context.Context, listID int) ([]models.Invitation, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.ListPendingInvitations")
+	defer span.End()
+
+	query := "SELECT " + invitationColumns + " FROM list_invitations i" + fromInvitations +
+		" WHERE i.list_id = $1 AND " + pendingInvitation + " ORDER BY i.created_at DESC, i.id DESC"
+	rows, err := r.db.QueryContext(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+func (r *PostgresInvitationRepository) AcceptInvitation(ctx context.Context, invitationID int, tokenID string, userID int) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.AcceptInvitation")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Locking the row makes concurrent attempts to redeem the same token
+	// wait, so at most one of them sees the invitation as pending.
+	var listID int
+	var role models.ListRole
+	query := "SELECT i.list_id, i.role FROM list_invitations i WHERE i.id = $1 AND i.token_id = $2 AND " +
+		pendingInvitation + " FOR UPDATE"
+	err = tx.QueryRowContext(ctx, query, invitationID, tokenID).Scan(&listID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrInvitationNotPending
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	query = "INSERT INTO task_list_members (list_id, user_id, role) VALUES ($1, $2, $3)"
+	_, err = tx.ExecContext(ctx, query, listID, userID, role)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return 0, ErrAlreadyListMember
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := addWorkspaceMember(ctx, tx, listID, userID); err != nil {
+		return 0, err
+	}
+
+	query = "UPDATE list_invitations SET accepted_at = NOW(), accepted_by = $2 WHERE id = $1"
+	if _, err := tx.ExecContext(ctx, query, invitationID, userID); err != nil {
+		return 0, err
+	}
+
+	return listID, tx.Commit()
+}
+
+func (r *PostgresInvitationRepository) DeclineInvitation(ctx context.Context, invitationID int, tokenID string) error {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.DeclineInvitation")
+	defer span.End()
+
+	query := "UPDATE list_invitations i SET declined_at = NOW() WHERE i.id = $1 AND i.token_id = $2 AND " + pendingInvitation
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, invitationID, tokenID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvitationNotPending
+	}
+	return nil
+}
+
+func (r *PostgresInvitationRepository) RevokeInvitation(ctx context.Context, listID, invitationID int) error {
+	_, span := otel.Tracer("").Start(ctx, "InvitationRepository.RevokeInvitation")
+	defer span.End()
+
+	query := "UPDATE list_invitations i SET revoked_at = NOW() WHERE i.id = $1 AND i.list_id = $2 AND " + pendingInvitation
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, invitationID, listID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInvitationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+type LoginAttemptRepository interface {
+	ClaimLoginAttempt(ctx context.Context, keys []string, window time.Duration, admit func([]*models.LoginAttempt) error) ([]*models.LoginAttempt, error)
+	ReleaseLoginAttempt(ctx context.Context, key string) error
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginAttempts(ctx context.Context, key string) error
+}
+
+type PostgresLoginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresLoginAttemptRepository(db *sql.DB) *PostgresLoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db}
+}
+
+// ClaimLoginAttempt passes the failure records for keys to admit and, if
+// admit returns nil, counts a failure against each key. The records stay
+// locked until the failures are counted, so concurrent claims on a key are
+// decided one after the other and each sees the failures counted by the
+// ones before it. An error from admit is returned as is and nothing is
+// counted. Failures older than window are forgotten, so a counter whose
+// last failure is older restarts at zero. The records are returned in the
+// order of keys, as they are after counting.
+func (r *PostgresLoginAttemptRepository) ClaimLoginAttempt(ctx context.Context, keys []string, window time.Duration, admit func([]*models.LoginAttempt) error) ([]*models.LoginAttempt, error) {
+	_, span := otel.Tracer("").Start(ctx, "LoginAttemptRepository.ClaimLoginAttempt")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock in key order so that claims sharing keys cannot deadlock.
+	attempts := make([]*models.LoginAttempt, len(keys))
+	for _, key := range slices.Sorted(slices.Values(keys)) {
+		insert := "INSERT INTO login_attempts (key, failures, last_failure_at) VALUES ($1, 0, NOW()) ON CONFLICT (key) DO NOTHING"
+		if _, err := tx.ExecContext(ctx, insert, key); err != nil {
+			return nil, err
+		}
+
+		attempt := models.LoginAttempt{Key: key}
+		var lockedUntil sql.NullTime
+		query := `SELECT CASE WHEN last_failure_at < NOW() - $2 * INTERVAL '1 second' THEN 0 ELSE failures END, last_failure_at, locked_until
+			FROM login_attempts WHERE key = $1 FOR UPDATE`
+		if err := tx.QueryRowContext(ctx, query, key, window.Seconds()).Scan(&attempt.Failures, &attempt.LastFailureAt, &lockedUntil); err != nil {
+			return nil, err
+		}
+		attempt.LockedUntil = lockedUntil.Time
+		attempts[slices.Index(keys, key)] = &attempt
+	}
+
+	if err := admit(attempts); err != nil {
+		return nil, err
+	}
+
+	for _, attempt := range attempts {
+		var lockedUntil sql.NullTime
+		query := "UPDATE login_attempts SET failures = $2, last_failure_at = NOW() WHERE key = $1 RETURNING failures, last_failure_at, locked_until"
+		if err := tx.QueryRowContext(ctx, query, attempt.Key, attempt.Failures+1).Scan(&attempt.Failures, &attempt.LastFailureAt, &lockedUntil); err != nil {
+			return nil, err
+		}
+		attempt.LockedUntil = lockedUntil.Time
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// ReleaseLoginAttempt takes back one failure claimed by ClaimLoginAttempt
+// for an attempt that turned out not to fail.
+func (r *PostgresLoginAttemptRepository) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	_, span := otel.Tracer("").Start(ctx, "LoginAttemptRepository.ReleaseLoginAttempt")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE login_attempts SET failures = GREATEST(failures - 1, 0) WHERE key = $1", key)
+	return err
+}
+
+func (r *PostgresLoginAttemptRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, span := otel.Tracer("").Start(ctx, "LoginAttemptRepository.LockLogin")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE login_attempts SET locked_until = $1 WHERE key = $2", until, key)
+	return err
+}
+
+func (r *PostgresLoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	_, span := otel.Tracer("").Start(ctx, "LoginAttemptRepository.ResetLoginAttempts")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_attempts WHERE key = $1", key)
+	return err
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+)
+
+// loginAttemptStore answers the queries of ClaimLoginAttempt from failure
+// counts by key and appends the keys it is asked to lock to locked.
+func loginAttemptStore(failures map[string]int64, locked *[]string) func(string, []driver.NamedValue) ([][]driver.Value, error) {
+	last := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	return func(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+		switch {
+		case isQuery(query, "INSERT INTO login_attempts"):
+			return nil, nil
+		case isQuery(query, "SELECT") && strings.Contains(query, "FOR UPDATE"):
+			*locked = append(*locked, args[0].Value.(string))
+			return [][]driver.Value{{failures[args[0].Value.(string)], last, nil}}, nil
+		case isQuery(query, "UPDATE login_attempts"):
+			failures[args[0].Value.(string)] = args[1].Value.(int64)
+			return [][]driver.Value{{args[1].Value, last, nil}}, nil
+		}
+		return nil, errors.New("unexpected query: " + query)
+	}
+}
+
+func TestClaimLoginAttempt_CountsAdmittedAttempt(t *testing.T) {
+	failures := map[string]int64{"user:1": 2}
+	var locked []string
+	db, conn := newFakeDB(loginAttemptStore(failures, &locked))
+
+	var seen []int
+	attempts, err := NewPostgresLoginAttemptRepository(conn).ClaimLoginAttempt(context.Background(), []string{"user:1", "ip:10.0.0.1"}, time.Hour, func(attempts []*models.LoginAttempt) error {
+		for _, attempt := range attempts {
+			seen = append(seen, attempt.Failures)
+		}
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 0}, seen)
+	assert.Equal(t, "user:1", attempts[0].Key)
+	assert.Equal(t, 3, attempts[0].Failures)
+	assert.Equal(t, "ip:10.0.0.1", attempts[1].Key)
+	assert.Equal(t, 1, attempts[1].Failures)
+	assert.Equal(t, 1, db.commits)
+	// Records are locked in key order so that claims cannot deadlock.
+	assert.Equal(t, []string{"ip:10.0.0.1", "user:1"}, locked)
+}
+
+func TestClaimLoginAttempt_RejectedAttemptCountsNothing(t *testing.T) {
+	failures := map[string]int64{"user:1": 5}
+	var locked []string
+	db, conn := newFakeDB(loginAttemptStore(failures, &locked))
+	rejected := errors.New("too many attempts")
+
+	attempts, err := NewPostgresLoginAttemptRepository(conn).ClaimLoginAttempt(context.Background(), []string{"user:1", "ip:10.0.0.1"}, time.Hour, func([]*models.LoginAttempt) error {
+		return rejected
+	})
+
+	assert.ErrorIs(t, err, rejected)
+	assert.Nil(t, attempts)
+	assert.Equal(t, int64(5), failures["user:1"])
+	assert.Zero(t, db.commits)
+	for _, query := range db.queries {
+		assert.False(t, isQuery(query, "UPDATE"), query)
+	}
+}
@@ -0,0 +1,223 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository stores in-app notifications and the users'
+// notification preferences. Notifications about tasks are only shown while
+// the user is a member of the task's list, and when the context is scoped to
+// a workspace, only those about tasks in it are shown.
+type NotificationRepository interface {
+	// CreateNotifications stores notifications with a single statement.
+	CreateNotifications(ctx context.Context, notifications []models.Notification) error
+	// ListNotifications returns one page of the user's notifications, newest
+	// first.
+	ListNotifications(ctx context.Context, userID int, query models.NotificationQuery) ([]models.Notification, error)
+	// CountNotifications returns the number of notifications matching
+	// unreadOnly and the number of unread notifications.
+	CountNotifications(ctx context.Context, userID int, unreadOnly bool) (total, unread int, err error)
+	// MarkRead marks a notification of the user as read. Marking it again
+	// keeps the original time.
+	MarkRead(ctx context.Context, userID, notificationID int) error
+	// MarkAllRead marks every unread notification of the user as read and
+	// returns how many there were.
+	MarkAllRead(ctx context.Context, userID int) (int, error)
+	// GetPreferences returns the preferences the users have set. Types and
+	// channels they have not chosen for are missing.
+	GetPreferences(ctx context.Context, userIDs []int) ([]models.NotificationPreference, error)
+	// SetPreferences stores the user's preferences, replacing earlier
+	// choices for the same type and channel.
+	SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error
+}
+
+type PostgresNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresNotificationRepository(db *sql.DB) *PostgresNotificationRepository {
+	return &PostgresNotificationRepository{db: db}
+}
+
+const notificationColumns = "n.id, n.user_id, n.type, n.actor_id, a.username, n.task_id, nt.title, n.comment_id, n.created_at, n.read_at"
+
+const fromNotifications = ` FROM notifications n
+	LEFT JOIN users a ON a.id = n.actor_id
+	LEFT JOIN tasks nt ON nt.id = n.task_id`
+
+// notificationFilter selects the notifications of user $1 that are unread
+// if $2 is set and visible in workspace $3, where 0 means every workspace.
+const notificationFilter = ` WHERE n.user_id = $1 AND (NOT $2 OR n.read_at IS NULL)
+	AND (n.task_id IS NULL AND $3 = 0 OR EXISTS (
+		SELECT 1 FROM tasks t
+		JOIN task_lists l ON l.id = t.list_id
+		JOIN task_list_members m ON m.list_id = l.id AND m.user_id = n.user_id
+		WHERE t.id = n.task_id AND ($3 = 0 OR l.workspace_id = $3)))`
+
+func scanNotification(row rowScanner) (*models.Notification, error) {
+	var notification models.Notification
+	var actorID, taskID, commentID sql.NullInt64
+	var actorName, taskTitle sql.NullString
+	var readAt sql.NullTime
+	err := row.Scan(&notification.ID, &notification.UserID, &notification.Type, &actorID, &actorName,
+		&taskID, &taskTitle, &commentID, &notification.CreatedAt, &readAt)
+	if err != nil {
+		return nil, err
+	}
+	notification.ActorID = int(actorID.Int64)
+	notification.ActorName = actorName.String
+	notification.TaskID = int(taskID.Int64)
+	notification.TaskTitle = taskTitle.String
+	notification.CommentID = int(commentID.Int64)
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	return &notification, nil
+}
+
+func (r *PostgresNotificationRepository) CreateNotifications(ctx context.Context, notifications []models.Notification) error {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.CreateNotifications")
+	defer span.End()
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	n := len(notifications)
+	userIDs, actorIDs, taskIDs, commentIDs := make([]int64, n), make([]int64, n), make([]int64, n), make([]int64, n)
+	types := make([]string, n)
+	for i, notification := range notifications {
+		userIDs[i] = int64(notification.UserID)
+		types[i] = string(notification.Type)
+		actorIDs[i] = int64(notification.ActorID)
+		taskIDs[i] = int64(notification.TaskID)
+		commentIDs[i] = int64(notification.CommentID)
+	}
+
+	query := `INSERT INTO notifications (user_id, type, actor_id, task_id, comment_id)
+		SELECT n.user_id, n.type, NULLIF(n.actor_id, 0), NULLIF(n.task_id, 0), NULLIF(n.comment_id, 0)
+		FROM unnest($1::int[], $2::text[], $3::int[], $4::int[], $5::int[]) AS n(user_id, type, actor_id, task_id, comment_id)`
+	_, err := r.db.ExecContext(ctx, query, pq.Array(userIDs), pq.Array(types), pq.Array(actorIDs),
+		pq.Array(taskIDs), pq.Array(commentIDs))
+	return err
+}
+
+func (r *PostgresNotificationRepository) ListNotifications(ctx context.Context, userID int, query models.NotificationQuery) ([]models.Notification, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.ListNotifications")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+notificationColumns+fromNotifications+notificationFilter+
+		" ORDER BY n.created_at DESC, n.id DESC LIMIT $4 OFFSET $5",
+		userID, query.Unread, tenant.WorkspaceID(ctx), query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, *notification)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) CountNotifications(ctx context.Context, userID int, unreadOnly bool) (int, int, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.CountNotifications")
+	defer span.End()
+
+	var total, unread int
+	query := "SELECT COUNT(*), COUNT(*) FILTER (WHERE n.read_at IS NULL) FROM notifications n" + notificationFilter
+	err := r.db.QueryRowContext(ctx, query, userID, false, tenant.WorkspaceID(ctx)).Scan(&total, &unread)
+	if err != nil {
+		return 0, 0, err
+	}
+	if unreadOnly {
+		total = unread
+	}
+	return total, unread, nil
+}
+
+func (r *PostgresNotificationRepository) MarkRead(ctx context.Context, userID, notificationID int) error {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.MarkRead")
+	defer span.End()
+
+	query := "UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2"
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, notificationID, userID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) MarkAllRead(ctx context.Context, userID int) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.MarkAllRead")
+	defer span.End()
+
+	query := "UPDATE notifications n SET read_at = NOW()" + notificationFilter
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, userID, true, tenant.WorkspaceID(ctx)))
+	return int(n), err
+}
+
+func (r *PostgresNotificationRepository) GetPreferences(ctx context.Context, userIDs []int) ([]models.NotificationPreference, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.GetPreferences")
+	defer span.End()
+
+	ids := make([]int64, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = int64(id)
+	}
+	query := "SELECT user_id, type, channel, enabled FROM notification_preferences WHERE user_id = ANY($1)"
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []models.NotificationPreference
+	for rows.Next() {
+		var pref models.NotificationPreference
+		if err := rows.Scan(&pref.UserID, &pref.Type, &pref.Channel, &pref.Enabled); err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, rows.Err()
+}
+
+func (r *PostgresNotificationRepository) SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error {
+	_, span := otel.Tracer("").Start(ctx, "NotificationRepository.SetPreferences")
+	defer span.End()
+
+	if len(prefs) == 0 {
+		return nil
+	}
+	types, channels := make([]string, len(prefs)), make([]string, len(prefs))
+	enabled := make([]bool, len(prefs))
+	for i, pref := range prefs {
+		types[i] = string(pref.Type)
+		channels[i] = pref.Channel
+		enabled[i] = pref.Enabled
+	}
+
+	query := `INSERT INTO notification_preferences (user_id, type, channel, enabled)
+		SELECT $1, p.type, p.channel, p.enabled
+		FROM unnest($2::text[], $3::text[], $4::bool[]) AS p(type, channel, enabled)
+		ON CONFLICT (user_id, type, channel) DO UPDATE SET enabled = EXCLUDED.enabled`
+	_, err := r.db.ExecContext(ctx, query, userID, pq.Array(types), pq.Array(channels), pq.Array(enabled))
+	return err
+}
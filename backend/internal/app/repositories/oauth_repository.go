@@ -0,0 +1,305 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrOAuthClientNotFound      = errors.New("oauth client not found")
+	ErrConsentNotFound          = errors.New("consent not found")
+	ErrAuthorizationCodeInvalid = errors.New("authorization code is invalid or expired")
+	ErrAuthorizationCodeReused  = errors.New("authorization code was already used")
+	ErrAccessTokenNotFound      = errors.New("access token not found")
+)
+
+type OAuthRepository interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error)
+	ListClientsByOwner(ctx context.Context, ownerID int) ([]models.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerID int, clientID string) error
+	GetConsentScopes(ctx context.Context, userID int, clientID string) ([]string, error)
+	SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error
+	ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error)
+	DeleteConsent(ctx context.Context, userID int, clientID string) error
+	CreateAuthorizationCode(ctx context.Context, code *models.OAuthAuthorizationCode) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error)
+	CreateAccessToken(ctx context.Context, token *models.OAuthAccessToken) error
+	GetAccessToken(ctx context.Context, jti string) (*models.OAuthAccessToken, error)
+	RevokeAccessToken(ctx context.Context, jti string) error
+	RevokeAccessTokensByCode(ctx context.Context, codeHash string) error
+}
+
+type PostgresOAuthRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOAuthRepository(db *sql.DB) *PostgresOAuthRepository {
+	return &PostgresOAuthRepository{db: db}
+}
+
+func (r *PostgresOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.CreateClient")
+	defer span.End()
+
+	query := `INSERT INTO oauth_clients (id, secret_hash, name, redirect_uris, scopes, owner_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6) RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query, client.ID, client.SecretHash, client.Name,
+		pq.Array(client.RedirectURIs), pq.Array(client.Scopes), client.OwnerID).Scan(&client.CreatedAt)
+}
+
+const oauthClientColumns = "id, secret_hash, name, redirect_uris, scopes, owner_id, created_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOAuthClient(row rowScanner) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	var secretHash sql.NullString
+	err := row.Scan(&client.ID, &secretHash, &client.Name, pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes), &client.OwnerID, &client.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	client.SecretHash = secretHash.String
+	return &client, nil
+}
+
+func (r *PostgresOAuthRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.GetClient")
+	defer span.End()
+
+	query := "SELECT " + oauthClientColumns + " FROM oauth_clients WHERE id = $1"
+	client, err := scanOAuthClient(r.db.QueryRowContext(ctx, query, clientID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOAuthClientNotFound
+	}
+	return client, err
+}
+
+func (r *PostgresOAuthRepository) ListClientsByOwner(ctx context.Context, ownerID int) ([]models.OAuthClient, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.ListClientsByOwner")
+	defer span.End()
+
+	query := "SELECT " + oauthClientColumns + " FROM oauth_clients WHERE owner_id = $1 ORDER BY created_at"
+	rows, err := r.db.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.OAuthClient{}
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, *client)
+	}
+	return clients, rows.Err()
+}
+
+// DeleteClient deletes a client owned by ownerID. Its consents, codes and
+// tokens are removed with it.
+func (r *PostgresOAuthRepository) DeleteClient(ctx context.Context, ownerID int, clientID string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.DeleteClient")
+	defer span.End()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM oauth_clients WHERE id = $1 AND owner_id = $2", clientID, ownerID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrOAuthClientNotFound
+	}
+	return nil
+}
+
+// GetConsentScopes returns the scopes userID granted to clientID, or nil if
+// there is no consent.
+func (r *PostgresOAuthRepository) GetConsentScopes(ctx context.Context, userID int, clientID string) ([]string, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.GetConsentScopes")
+	defer span.End()
+
+	var scopes []string
+	query := "SELECT scopes FROM oauth_consents WHERE user_id = $1 AND client_id = $2"
+	err := r.db.QueryRowContext(ctx, query, userID, clientID).Scan(pq.Array(&scopes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return scopes, err
+}
+
+// SaveConsent records that userID granted scopes to clientID, adding to any
+// scopes granted earlier.
+func (r *PostgresOAuthRepository) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.SaveConsent")
+	defer span.End()
+
+	query := `INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, client_id) DO UPDATE SET
+			scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes) ORDER BY 1),
+			updated_at = NOW()`
+	_, err := r.db.ExecContext(ctx, query, userID, clientID, pq.Array(scopes))
+	return err
+}
+
+func (r *PostgresOAuthRepository) ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.ListConsents")
+	defer span.End()
+
+	query := `SELECT c.client_id, cl.name, c.scopes, c.updated_at
+		FROM oauth_consents c JOIN oauth_clients cl ON cl.id = c.client_id
+		WHERE c.user_id = $1 ORDER BY cl.name`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consents := []models.OAuthConsent{}
+	for rows.Next() {
+		var consent models.OAuthConsent
+		if err := rows.Scan(&consent.ClientID, &consent.ClientName, pq.Array(&consent.Scopes), &consent.UpdatedAt); err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+	return consents, rows.Err()
+}
+
+// DeleteConsent withdraws userID's consent for clientID and revokes every
+// access token the client holds for the user.
+func (r *PostgresOAuthRepository) DeleteConsent(ctx context.Context, userID int, clientID string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.DeleteConsent")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2", userID, clientID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrConsentNotFound
+	}
+
+	query := "UPDATE oauth_access_tokens SET revoked_at = NOW() WHERE user_id = $1 AND client_id = $2 AND revoked_at IS NULL"
+	if _, err := tx.ExecContext(ctx, query, userID, clientID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresOAuthRepository) CreateAuthorizationCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.CreateAuthorizationCode")
+	defer span.End()
+
+	query := `INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.ExecContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI,
+		pq.Array(code.Scopes), code.CodeChallenge, code.ExpiresAt)
+	return err
+}
+
+// ConsumeAuthorizationCode marks the code identified by codeHash as used and
+// returns it. A code that exists but was already used yields
+// ErrAuthorizationCodeReused so callers can revoke what it produced.
+func (r *PostgresOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.ConsumeAuthorizationCode")
+	defer span.End()
+
+	var code models.OAuthAuthorizationCode
+	query := `UPDATE oauth_authorization_codes SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at`
+	err := r.db.QueryRowContext(ctx, query, codeHash).Scan(&code.CodeHash, &code.ClientID, &code.UserID,
+		&code.RedirectURI, pq.Array(&code.Scopes), &code.CodeChallenge, &code.ExpiresAt)
+	if err == nil {
+		return &code, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	var used bool
+	err = r.db.QueryRowContext(ctx, "SELECT used_at IS NOT NULL FROM oauth_authorization_codes WHERE code_hash = $1", codeHash).Scan(&used)
+	if err == nil && used {
+		return nil, ErrAuthorizationCodeReused
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return nil, ErrAuthorizationCodeInvalid
+}
+
+func (r *PostgresOAuthRepository) CreateAccessToken(ctx context.Context, token *models.OAuthAccessToken) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.CreateAccessToken")
+	defer span.End()
+
+	query := `INSERT INTO oauth_access_tokens (jti, client_id, user_id, scopes, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6) RETURNING created_at`
+	return r.db.QueryRowContext(ctx, query, token.JTI, token.ClientID, token.UserID,
+		pq.Array(token.Scopes), token.CodeHash, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+func (r *PostgresOAuthRepository) GetAccessToken(ctx context.Context, jti string) (*models.OAuthAccessToken, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.GetAccessToken")
+	defer span.End()
+
+	var token models.OAuthAccessToken
+	var codeHash sql.NullString
+	var revokedAt sql.NullTime
+	query := `SELECT jti, client_id, user_id, scopes, code_hash, expires_at, revoked_at, created_at
+		FROM oauth_access_tokens WHERE jti = $1`
+	err := r.db.QueryRowContext(ctx, query, jti).Scan(&token.JTI, &token.ClientID, &token.UserID,
+		pq.Array(&token.Scopes), &codeHash, &token.ExpiresAt, &revokedAt, &token.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	token.CodeHash = codeHash.String
+	if revokedAt.Valid {
+		token.RevokedAt = &revokedAt.Time
+	}
+	return &token, nil
+}
+
+func (r *PostgresOAuthRepository) RevokeAccessToken(ctx context.Context, jti string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.RevokeAccessToken")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, "UPDATE oauth_access_tokens SET revoked_at = NOW() WHERE jti = $1 AND revoked_at IS NULL", jti)
+	return err
+}
+
+// RevokeAccessTokensByCode revokes the tokens issued for an authorization
+// code, used when the code is replayed (RFC 6749 section 4.1.2).
+func (r *PostgresOAuthRepository) RevokeAccessTokensByCode(ctx context.Context, codeHash string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthRepository.RevokeAccessTokensByCode")
+	defer span.End()
+
+	query := "UPDATE oauth_access_tokens SET revoked_at = NOW() WHERE code_hash = $1 AND revoked_at IS NULL"
+	_, err := r.db.ExecContext(ctx, query, codeHash)
+	return err
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrOutboxLeaseLost = errors.New("outbox event lease expired or was taken over")
+
+// OutboxRepository leases recorded domain events to the outbox relay.
+// Events are recorded by the repositories that make the changes, in the
+// same transaction, see recordEvents.
+type OutboxRepository interface {
+	// ClaimEvents leases up to limit unpublished events that are due to
+	// token for lease, oldest first. Events leased to someone else are
+	// skipped, not waited for.
+	ClaimEvents(ctx context.Context, token string, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	// MarkPublished retires an event claimed with token once every sink
+	// has it.
+	MarkPublished(ctx context.Context, seq int64, token string, sinks []string) error
+	// RecordFailure releases an event claimed with token that some sinks
+	// rejected. sinks are the ones that have it; the others are retried at
+	// nextAttempt.
+	RecordFailure(ctx context.Context, seq int64, token string, sinks []string, lastError string, nextAttempt time.Time) error
+	// PurgePublished deletes events published before before and returns
+	// how many there were.
+	PurgePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresOutboxRepository(db *sql.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// recordEvents writes events to the outbox with q, which should be the
+// transaction of the change they describe. Events without an ID or time get
+// a fresh ID and the current time.
+func recordEvents(ctx context.Context, q execer, events ...models.Event) error {
+	for _, event := range events {
+		if event.ID == "" {
+			id, err := utils.GenerateSecureToken()
+			if err != nil {
+				return err
+			}
+			event.ID = id
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now().UTC()
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		query := `INSERT INTO outbox_events (event_id, event_type, list_id, payload, occurred_at)
+			VALUES ($1, $2, NULLIF($3, 0), $4::jsonb, $5)`
+		if _, err := q.ExecContext(ctx, query, event.ID, string(event.Type), event.ListID, string(payload), event.OccurredAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresOutboxRepository) ClaimEvents(ctx context.Context, token string, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	_, span := otel.Tracer("").Start(ctx, "OutboxRepository.ClaimEvents")
+	defer span.End()
+
+	query := `WITH due AS (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= NOW()
+			AND (lease_until IS NULL OR lease_until < NOW())
+			ORDER BY id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox_events o
+		SET lease_token = $1, lease_until = NOW() + $3 * INTERVAL '1 millisecond'
+		FROM due
+		WHERE o.id = due.id
+		RETURNING o.id, o.payload, o.published_sinks, o.attempts`
+	rows, err := r.db.QueryContext(ctx, query, token, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var e models.OutboxEvent
+		var payload string
+		if err := rows.Scan(&e.Seq, &payload, pq.Array(&e.PublishedSinks), &e.Attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(payload), &e.Event); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// UPDATE ... RETURNING does not keep the order of the CTE.
+	slices.SortFunc(events, func(a, b models.OutboxEvent) int { return cmp.Compare(a.Seq, b.Seq) })
+	return events, nil
+}
+
+func (r *PostgresOutboxRepository) MarkPublished(ctx context.Context, seq int64, token string, sinks []string) error {
+	_, span := otel.Tracer("").Start(ctx, "OutboxRepository.MarkPublished")
+	defer span.End()
+
+	query := `UPDATE outbox_events
+		SET published_at = NOW(), published_sinks = $3, last_error = NULL, lease_token = NULL, lease_until = NULL
+		WHERE id = $1 AND lease_token = $2`
+	return r.releaseLease(r.db.ExecContext(ctx, query, seq, token, pq.Array(sinks)))
+}
+
+func (r *PostgresOutboxRepository) RecordFailure(ctx context.Context, seq int64, token string, sinks []string, lastError string, nextAttempt time.Time) error {
+	_, span := otel.Tracer("").Start(ctx, "OutboxRepository.RecordFailure")
+	defer span.End()
+
+	query := `UPDATE outbox_events
+		SET attempts = attempts + 1, published_sinks = $3, last_error = $4, next_attempt_at = $5,
+			lease_token = NULL, lease_until = NULL
+		WHERE id = $1 AND lease_token = $2`
+	return r.releaseLease(r.db.ExecContext(ctx, query, seq, token, pq.Array(sinks), lastError, nextAttempt))
+}
+
+func (r *PostgresOutboxRepository) releaseLease(result sql.Result, err error) error {
+	n, err := rowsAffected(result, err)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrOutboxLeaseLost
+	}
+	return nil
+}
+
+func (r *PostgresOutboxRepository) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	_, span := otel.Tracer("").Start(ctx, "OutboxRepository.PurgePublished")
+	defer span.End()
+
+	return rowsAffected(r.db.ExecContext(ctx, "DELETE FROM outbox_events WHERE published_at < $1", before))
+}
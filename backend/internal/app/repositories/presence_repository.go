@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel"
+)
+
+// presenceChannel is the Postgres notification channel that carries
+// presence changes between replicas.
+const presenceChannel = "presence"
+
+// PresenceRepository carries presence notices between the replicas of the
+// backend with Postgres LISTEN/NOTIFY. Presence is never stored.
+type PresenceRepository interface {
+	// Announce sends notice to every listening replica, including this one.
+	Announce(ctx context.Context, notice string) error
+	// Listen calls handle with every announced notice until ctx is done.
+	// It calls ready once it listens, and again after every reconnect.
+	Listen(ctx context.Context, ready func(), handle func(notice string)) error
+}
+
+type PostgresPresenceRepository struct {
+	db  *sql.DB
+	dsn string
+}
+
+// NewPostgresPresenceRepository returns a presence repository that listens
+// on a connection of its own to dsn, outside the pool of db.
+func NewPostgresPresenceRepository(db *sql.DB, dsn string) *PostgresPresenceRepository {
+	return &PostgresPresenceRepository{db: db, dsn: dsn}
+}
+
+func (r *PostgresPresenceRepository) Announce(ctx context.Context, notice string) error {
+	_, span := otel.Tracer("").Start(ctx, "PresenceRepository.Announce")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", presenceChannel, notice)
+	return err
+}
+
+func (r *PostgresPresenceRepository) Listen(ctx context.Context, ready func(), handle func(notice string)) error {
+	return listen(ctx, r.dsn, presenceChannel, ready, handle)
+}
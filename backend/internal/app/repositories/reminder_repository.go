@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrReminderNotFound  = errors.New("reminder not found")
+	ErrTooManyReminders  = errors.New("too many reminders on the task")
+	ErrReminderInPast    = errors.New("reminder would fire in the past")
+	ErrReminderLeaseLost = errors.New("reminder lease expired or was taken over")
+)
+
+// ReminderRepository stores task reminders and leases due ones to the
+// scheduler. Users set reminders for themselves on tasks they can see;
+// reminders on tasks they cannot see are reported as ErrTaskNotFound.
+type ReminderRepository interface {
+	// ListReminders returns the user's reminders on taskID, soonest first.
+	ListReminders(ctx context.Context, taskID, userID int) ([]models.Reminder, error)
+	// CreateReminder adds reminder for reminder.UserID and fills in FireAt
+	// and the generated fields. It fails with ErrTooManyReminders if the
+	// user already has max pending reminders on the task.
+	CreateReminder(ctx context.Context, reminder *models.Reminder, max int) error
+	DeleteReminder(ctx context.Context, taskID, reminderID, userID int) error
+	// ClaimDueReminders leases up to limit due reminders to token for lease.
+	// Reminders leased to someone else are skipped, not waited for.
+	ClaimDueReminders(ctx context.Context, token string, limit int, lease time.Duration) ([]models.DueReminder, error)
+	// RenewLease extends the lease of a reminder claimed with token to lease
+	// from now. It fails with ErrReminderLeaseLost if the reminder was
+	// claimed by someone else or retired in the meantime.
+	RenewLease(ctx context.Context, reminderID int, token string, lease time.Duration) error
+	// MarkFired retires a reminder claimed with token. lastError records
+	// why delivery failed, if it did.
+	MarkFired(ctx context.Context, reminderID int, token, lastError string) error
+}
+
+type PostgresReminderRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresReminderRepository(db *sql.DB) *PostgresReminderRepository {
+	return &PostgresReminderRepository{db: db}
+}
+
+const reminderColumns = "id, task_id, user_id, remind_at, offset_minutes, fire_at, fired_at, created_at"
+
+func scanReminder(row rowScanner) (*models.Reminder, error) {
+	var reminder models.Reminder
+	var remindAt, firedAt sql.NullTime
+	var offset sql.NullInt64
+	err := row.Scan(&reminder.ID, &reminder.TaskID, &reminder.UserID, &remindAt, &offset,
+		&reminder.FireAt, &firedAt, &reminder.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if remindAt.Valid {
+		reminder.RemindAt = &remindAt.Time
+	}
+	if offset.Valid {
+		minutes := int(offset.Int64)
+		reminder.OffsetMinutes = &minutes
+	}
+	if firedAt.Valid {
+		reminder.FiredAt = &firedAt.Time
+	}
+	return &reminder, nil
+}
+
+func (r *PostgresReminderRepository) ListReminders(ctx context.Context, taskID, userID int) ([]models.Reminder, error) {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.ListReminders")
+	defer span.End()
+
+	if _, err := taskRole(ctx, r.db, taskID, userID); err != nil {
+		return nil, err
+	}
+	query := "SELECT " + reminderColumns + " FROM task_reminders WHERE task_id = $1 AND user_id = $2 ORDER BY fire_at, id"
+	rows, err := r.db.QueryContext(ctx, query, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []models.Reminder{}
+	for rows.Next() {
+		reminder, err := scanReminder(rows)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, *reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (r *PostgresReminderRepository) CreateReminder(ctx context.Context, reminder *models.Reminder, max int) error {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.CreateReminder")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(ctx, tx, reminder.TaskID, reminder.UserID); err != nil {
+		return err
+	}
+	// Locking the task serializes concurrent requests so that the limit
+	// holds.
+	var pending int
+	query := `SELECT COUNT(r.id) FROM (SELECT id FROM tasks WHERE id = $1 FOR UPDATE) t
+		LEFT JOIN task_reminders r ON r.task_id = t.id AND r.user_id = $2 AND r.fired_at IS NULL`
+	if err := tx.QueryRowContext(ctx, query, reminder.TaskID, reminder.UserID).Scan(&pending); err != nil {
+		return err
+	}
+	if pending >= max {
+		return ErrTooManyReminders
+	}
+
+	query = `INSERT INTO task_reminders (task_id, user_id, remind_at, offset_minutes, fire_at)
+		SELECT t.id, $2, $3::timestamptz, $4::int, f.fire_at
+		FROM tasks t, LATERAL (SELECT COALESCE($3::timestamptz, t.created_at + $4::int * INTERVAL '1 minute') AS fire_at) f
+		WHERE t.id = $1 AND f.fire_at > NOW()
+		RETURNING ` + reminderColumns
+	created, err := scanReminder(tx.QueryRowContext(ctx, query, reminder.TaskID, reminder.UserID, reminder.RemindAt, reminder.OffsetMinutes))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrReminderInPast
+	}
+	if err != nil {
+		return err
+	}
+	*reminder = *created
+
+	return tx.Commit()
+}
+
+func (r *PostgresReminderRepository) DeleteReminder(ctx context.Context, taskID, reminderID, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.DeleteReminder")
+	defer span.End()
+
+	if _, err := taskRole(ctx, r.db, taskID, userID); err != nil {
+		return err
+	}
+	query := "DELETE FROM task_reminders WHERE id = $1 AND task_id = $2 AND user_id = $3"
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, reminderID, taskID, userID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReminderNotFound
+	}
+	return nil
+}
+
+func (r *PostgresReminderRepository) ClaimDueReminders(ctx context.Context, token string, limit int, lease time.Duration) ([]models.DueReminder, error) {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.ClaimDueReminders")
+	defer span.End()
+
+	query := `WITH due AS (
+			SELECT id FROM task_reminders
+			WHERE fired_at IS NULL AND fire_at <= NOW() AND (lease_until IS NULL OR lease_until < NOW())
+			ORDER BY fire_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE task_reminders r
+		SET lease_token = $1, lease_until = NOW() + $3 * INTERVAL '1 millisecond', attempts = r.attempts + 1
+		FROM due, tasks t
+		WHERE r.id = due.id AND t.id = r.task_id
+		RETURNING r.id, r.task_id, r.user_id, t.title, NOT t.completed AND EXISTS (
+			SELECT 1 FROM task_list_members m WHERE m.list_id = t.list_id AND m.user_id = r.user_id)`
+	rows, err := r.db.QueryContext(ctx, query, token, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []models.DueReminder
+	for rows.Next() {
+		var reminder models.DueReminder
+		if err := rows.Scan(&reminder.ID, &reminder.TaskID, &reminder.UserID, &reminder.TaskTitle, &reminder.Deliver); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+func (r *PostgresReminderRepository) RenewLease(ctx context.Context, reminderID int, token string, lease time.Duration) error {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.RenewLease")
+	defer span.End()
+
+	query := `UPDATE task_reminders
+		SET lease_until = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND lease_token = $2 AND fired_at IS NULL`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, reminderID, token, lease.Milliseconds()))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReminderLeaseLost
+	}
+	return nil
+}
+
+func (r *PostgresReminderRepository) MarkFired(ctx context.Context, reminderID int, token, lastError string) error {
+	_, span := otel.Tracer("").Start(ctx, "ReminderRepository.MarkFired")
+	defer span.End()
+
+	query := `UPDATE task_reminders
+		SET fired_at = NOW(), last_error = NULLIF($3, ''), lease_token = NULL, lease_until = NULL
+		WHERE id = $1 AND lease_token = $2 AND fired_at IS NULL`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, reminderID, token, lastError))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReminderLeaseLost
+	}
+	return nil
+}
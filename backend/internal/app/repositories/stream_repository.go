@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+// streamChannel is the Postgres notification channel that announces relayed
+// events to every replica.
+const streamChannel = "task_events"
+
+var ErrStreamEventNotFound = errors.New("stream event not found")
+
+// StreamRepository carries relayed events between the replicas of the
+// backend with Postgres LISTEN/NOTIFY. Notifications only carry the event
+// ID; the event itself is read back from the outbox.
+type StreamRepository interface {
+	// Notify numbers the outbox event eventID in the order of the stream
+	// and announces it to every listening replica. Numbers are assigned in
+	// the order the announcements commit, which is the order replicas
+	// receive them in. An event announced again keeps its number.
+	Notify(ctx context.Context, eventID string) error
+	// Listen calls handle with every announced event ID until ctx is done.
+	// It calls ready once it listens, and again after every reconnect, as
+	// notifications sent in between are lost.
+	Listen(ctx context.Context, ready func(), handle func(eventID string)) error
+	// GetEvent returns the announced outbox event eventID with its stream
+	// number, the workspace and the members of its list.
+	GetEvent(ctx context.Context, eventID string) (*models.StreamEvent, error)
+	// LatestSeq returns the stream number of the last announced event.
+	LatestSeq(ctx context.Context) (int64, error)
+}
+
+type PostgresStreamRepository struct {
+	db  *sql.DB
+	dsn string
+}
+
+// NewPostgresStreamRepository returns a stream repository that listens on
+// a connection of its own to dsn, outside the pool of db.
+func NewPostgresStreamRepository(db *sql.DB, dsn string) *PostgresStreamRepository {
+	return &PostgresStreamRepository{db: db, dsn: dsn}
+}
+
+func (r *PostgresStreamRepository) Notify(ctx context.Context, eventID string) error {
+	_, span := otel.Tracer("").Start(ctx, "StreamRepository.Notify")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The row lock on stream_position is held until the notification is
+	// sent with the commit, so numbers are assigned in commit order.
+	var seq int64
+	if err := tx.QueryRowContext(ctx, "UPDATE stream_position SET seq = seq + 1 RETURNING seq").Scan(&seq); err != nil {
+		return err
+	}
+	query := "UPDATE outbox_events SET stream_seq = $2 WHERE event_id = $1 AND stream_seq IS NULL"
+	if _, err := tx.ExecContext(ctx, query, eventID, seq); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", streamChannel, eventID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresStreamRepository) Listen(ctx context.Context, ready func(), handle func(eventID string)) error {
+	return listen(ctx, r.dsn, streamChannel, ready, handle)
+}
+
+// listen calls handle with the payload of every notification on channel
+// until ctx is done. It listens on a connection of its own to dsn and calls
+// ready once it listens, and again after every reconnect.
+func listen(ctx context.Context, dsn, channel string, ready func(), handle func(payload string)) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(_ pq.ListenerEventType, err error) {
+		if err != nil {
+			logging.ContextLogger(ctx).Warn("Listener lost its connection", "channel", channel, "error", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(channel); err != nil {
+		return err
+	}
+	ready()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-listener.Notify:
+			// pq sends nil once it has reconnected.
+			if n == nil {
+				ready()
+				continue
+			}
+			handle(n.Extra)
+		case <-time.After(90 * time.Second):
+			// Notice dead connections even when nothing is announced.
+			go listener.Ping()
+		}
+	}
+}
+
+func (r *PostgresStreamRepository) GetEvent(ctx context.Context, eventID string) (*models.StreamEvent, error) {
+	_, span := otel.Tracer("").Start(ctx, "StreamRepository.GetEvent")
+	defer span.End()
+
+	query := `SELECT o.stream_seq, o.payload, COALESCE(l.workspace_id, 0),
+			ARRAY(SELECT m.user_id FROM task_list_members m WHERE m.list_id = o.list_id ORDER BY m.user_id)
+		FROM outbox_events o LEFT JOIN task_lists l ON l.id = o.list_id
+		WHERE o.event_id = $1 AND o.stream_seq IS NOT NULL`
+	var event models.StreamEvent
+	var payload string
+	var userIDs []int64
+	err := r.db.QueryRowContext(ctx, query, eventID).Scan(&event.Seq, &payload, &event.WorkspaceID, pq.Array(&userIDs))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStreamEventNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(payload), &event.Event); err != nil {
+		return nil, err
+	}
+	for _, id := range userIDs {
+		event.UserIDs = append(event.UserIDs, int(id))
+	}
+	return &event, nil
+}
+
+func (r *PostgresStreamRepository) LatestSeq(ctx context.Context) (int64, error) {
+	_, span := otel.Tracer("").Start(ctx, "StreamRepository.LatestSeq")
+	defer span.End()
+
+	var seq int64
+	err := r.db.QueryRowContext(ctx, "SELECT seq FROM stream_position").Scan(&seq)
+	return seq, err
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamNotify_NumbersBeforeAnnouncing(t *testing.T) {
+	var numbered []driver.Value
+	db, conn := newFakeDB(func(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+		switch {
+		case isQuery(query, "UPDATE stream_position"):
+			return [][]driver.Value{{int64(12)}}, nil
+		case isQuery(query, "UPDATE outbox_events"):
+			numbered = []driver.Value{args[0].Value, args[1].Value}
+			return nil, nil
+		case isQuery(query, "SELECT pg_notify"):
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected query %q", query)
+	})
+	repo := NewPostgresStreamRepository(conn, "")
+
+	err := repo.Notify(context.Background(), "evt")
+
+	require.NoError(t, err)
+	assert.Equal(t, []driver.Value{"evt", int64(12)}, numbered)
+	// The position stays locked until the notification is committed.
+	require.Len(t, db.queries, 3)
+	assert.True(t, isQuery(db.queries[0], "UPDATE stream_position"))
+	assert.True(t, isQuery(db.queries[2], "SELECT pg_notify"))
+	assert.Equal(t, 1, db.commits)
+}
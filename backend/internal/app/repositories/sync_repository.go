@@ -0,0 +1,322 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrSyncConflict = errors.New("task changed since the sync token")
+
+// SyncRepository reads and applies the changes of delta sync. A sync token
+// is the text form of the database snapshot the client's last sync read,
+// see pg_current_snapshot; a change is new to the client if it is not
+// visible in that snapshot. An empty snapshot stands for a client that has
+// seen nothing. Access is checked like in TaskRepository, and changes
+// record their domain events in the outbox. Writes are stamped like in
+// TaskRepository.
+type SyncRepository interface {
+	// Changes returns the changes to the tasks userID can see since
+	// snapshot. Its Token is the snapshot they were read in. Nothing is
+	// reported deleted to a client that has seen nothing.
+	Changes(ctx context.Context, userID int, snapshot string) (*models.SyncChanges, error)
+	// CreateTask adds task to task.ListID on behalf of task.CreatedBy. If
+	// that user already created a task with clientID, task is set to that
+	// one instead and created is false.
+	CreateTask(ctx context.Context, task *models.Task, clientID string) (created bool, err error)
+	// UpdateTask sets the fields of change on change.TaskID on behalf of
+	// userID. A field with a timestamp in change.Clock is merged by it; one
+	// without is not set if it changed since snapshot. It returns the task
+	// and the names of the fields that kept their value.
+	UpdateTask(ctx context.Context, userID int, snapshot string, change models.SyncChange) (*models.Task, []string, error)
+	// DeleteTask deletes taskID on behalf of userID unless it changed since
+	// snapshot. Then it returns the task and ErrSyncConflict.
+	DeleteTask(ctx context.Context, userID, taskID int, snapshot string) (*models.Task, error)
+}
+
+type PostgresSyncRepository struct {
+	db    *sql.DB
+	clock *crdt.Clock
+}
+
+// NewPostgresSyncRepository returns a sync repository that stamps writes
+// with clock.
+func NewPostgresSyncRepository(db *sql.DB, clock *crdt.Clock) *PostgresSyncRepository {
+	return &PostgresSyncRepository{db: db, clock: clock}
+}
+
+// changedSince matches if the transaction id in column is not visible in
+// the snapshot in parameter snapshotArg, or the parameter is NULL. The
+// xmin bound lets the index on column narrow the search.
+func changedSince(column string, snapshotArg int) string {
+	return fmt.Sprintf(`($%[2]d::pg_snapshot IS NULL OR (%[1]s >= pg_snapshot_xmin($%[2]d::pg_snapshot)
+		AND NOT pg_visible_in_snapshot(%[1]s, $%[2]d::pg_snapshot)))`, column, snapshotArg)
+}
+
+func nullSnapshot(snapshot string) sql.NullString {
+	return sql.NullString{String: snapshot, Valid: snapshot != ""}
+}
+
+// taskScanner scans the task columns followed by extra.
+type taskScanner struct {
+	row   rowScanner
+	extra []any
+}
+
+func (s taskScanner) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+func (r *PostgresSyncRepository) Changes(ctx context.Context, userID int, snapshot string) (*models.SyncChanges, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncRepository.Changes")
+	defer span.End()
+
+	// Every query reads the snapshot that becomes the new token.
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	changes := &models.SyncChanges{Created: []models.Task{}, Updated: []models.Task{}, Deleted: []int{}}
+	if err := tx.QueryRowContext(ctx, "SELECT pg_current_snapshot()::text").Scan(&changes.Token); err != nil {
+		return nil, err
+	}
+
+	// Tasks of a list the user joined are new to the client, however old
+	// they are.
+	query := "SELECT " + taskColumns + ", " + changedSince("t.created_xid", 2) + " OR " + changedSince("m.added_xid", 2) + `
+		FROM tasks t
+		JOIN task_list_members m ON m.list_id = t.list_id AND m.user_id = $1
+		JOIN task_lists l ON l.id = t.list_id
+		WHERE ($3 = 0 OR l.workspace_id = $3)
+		AND (` + changedSince("t.change_xid", 2) + " OR " + changedSince("m.added_xid", 2) + `)
+		ORDER BY t.id`
+	rows, err := tx.QueryContext(ctx, query, userID, nullSnapshot(snapshot), tenant.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var created bool
+		task, err := scanTask(taskScanner{row: rows, extra: []any{&created}})
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			changes.Created = append(changes.Created, *task)
+		} else {
+			changes.Updated = append(changes.Updated, *task)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if snapshot == "" {
+		return changes, nil
+	}
+
+	// Deleted are the tasks deleted from the user's lists, including lists
+	// the user has left since, and the remaining tasks of those lists.
+	removed := `EXISTS (SELECT 1 FROM task_list_member_removals r
+		WHERE r.list_id = %s AND r.user_id = $1 AND ` + changedSince("r.removed_xid", 2) + ")"
+	query = `SELECT d.task_id FROM task_tombstones d
+		WHERE ` + changedSince("d.deleted_xid", 2) + ` AND (
+			EXISTS (SELECT 1 FROM task_list_members m JOIN task_lists l ON l.id = m.list_id
+				WHERE m.list_id = d.list_id AND m.user_id = $1 AND ($3 = 0 OR l.workspace_id = $3))
+			OR ` + fmt.Sprintf(removed, "d.list_id") + `)
+		UNION
+		SELECT t.id FROM tasks t
+		WHERE ` + fmt.Sprintf(removed, "t.list_id") + `
+		AND NOT EXISTS (SELECT 1 FROM task_list_members m WHERE m.list_id = t.list_id AND m.user_id = $1)
+		ORDER BY 1`
+	deleted, err := tx.QueryContext(ctx, query, userID, nullSnapshot(snapshot), tenant.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer deleted.Close()
+	for deleted.Next() {
+		var id int
+		if err := deleted.Scan(&id); err != nil {
+			return nil, err
+		}
+		changes.Deleted = append(changes.Deleted, id)
+	}
+	return changes, deleted.Err()
+}
+
+func (r *PostgresSyncRepository) CreateTask(ctx context.Context, task *models.Task, clientID string) (bool, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncRepository.CreateTask")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	stamp := r.clock.Now().String()
+	query := `INSERT INTO tasks AS t (list_id, created_by, title, completed, client_id, title_clock, completed_clock)
+		SELECT $1, $2, $3, $4, $7, $8, $8 WHERE ` + listAccess("$1", 2, 5, 6) + `
+		ON CONFLICT (created_by, client_id) WHERE client_id IS NOT NULL DO NOTHING
+		RETURNING ` + taskColumns
+	created, err := scanTask(tx.QueryRowContext(ctx, query, task.ListID, task.CreatedBy, task.Title, task.Completed,
+		pq.Array(models.ListRolesAtLeast(models.ListRoleEditor)), tenant.WorkspaceID(ctx), clientID, stamp))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either the batch is retried or the user may not add to the list.
+		query := "SELECT " + taskColumns + " FROM tasks t WHERE t.created_by = $1 AND t.client_id = $2 AND " + listAccess("t.list_id", 1, 3, 4)
+		existing, err := scanTask(tx.QueryRowContext(ctx, query, task.CreatedBy, clientID,
+			pq.Array(models.ListRolesAtLeast(models.ListRoleViewer)), tenant.WorkspaceID(ctx)))
+		if err == nil {
+			*task = *existing
+			return false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return false, err
+		}
+		if _, err := memberRole(ctx, tx, task.ListID, task.CreatedBy); err != nil {
+			return false, err
+		}
+		return false, ErrListRoleTooLow
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := recordEvents(ctx, tx, models.NewTaskEvent(models.EventTaskCreated, created, task.CreatedBy)); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	*task = *created
+	return true, nil
+}
+
+func (r *PostgresSyncRepository) UpdateTask(ctx context.Context, userID int, snapshot string, change models.SyncChange) (*models.Task, []string, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncRepository.UpdateTask")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the task so that no change slips in between the conflict check
+	// and the update.
+	var titleChanged, completedChanged bool
+	query := "SELECT " + taskColumns + ", " + changedSince("t.title_xid", 2) + ", " + changedSince("t.completed_xid", 2) + `
+		FROM tasks t WHERE t.id = $1 FOR UPDATE`
+	previous, err := scanTask(taskScanner{
+		row:   tx.QueryRowContext(ctx, query, change.TaskID, nullSnapshot(snapshot)),
+		extra: []any{&titleChanged, &completedChanged},
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := requireTaskEditor(ctx, tx, change.TaskID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	var kept []string
+	title, titleKept, err := mergeSyncField(r.clock, crdt.Register[string]{Value: previous.Title, Stamp: previous.Clock.Title},
+		change.Title, change.Clock.Title, titleChanged)
+	if err != nil {
+		return nil, nil, err
+	}
+	if titleKept {
+		kept = append(kept, models.SyncFieldTitle)
+	}
+	completed, completedKept, err := mergeSyncField(r.clock, crdt.Register[bool]{Value: previous.Completed, Stamp: previous.Clock.Completed},
+		change.Completed, change.Clock.Completed, completedChanged)
+	if err != nil {
+		return nil, nil, err
+	}
+	if completedKept {
+		kept = append(kept, models.SyncFieldCompleted)
+	}
+	if title.Stamp == previous.Clock.Title && completed.Stamp == previous.Clock.Completed {
+		return previous, kept, nil
+	}
+
+	query = `UPDATE tasks t SET title = $2, completed = $3, title_clock = $4, completed_clock = $5,
+		updated_by = $6, updated_at = NOW()
+		WHERE t.id = $1 RETURNING ` + taskColumns
+	updated, err := scanTask(tx.QueryRowContext(ctx, query, change.TaskID, title.Value, completed.Value,
+		title.Stamp.String(), completed.Stamp.String(), userID))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := recordEvents(ctx, tx, models.TaskUpdateEvents(previous, updated, userID)...); err != nil {
+		return nil, nil, err
+	}
+	return updated, kept, tx.Commit()
+}
+
+func (r *PostgresSyncRepository) DeleteTask(ctx context.Context, userID, taskID int, snapshot string) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncRepository.DeleteTask")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var changed bool
+	query := "SELECT " + taskColumns + ", " + changedSince("t.change_xid", 2) + " FROM tasks t WHERE t.id = $1 FOR UPDATE"
+	task, err := scanTask(taskScanner{row: tx.QueryRowContext(ctx, query, taskID, nullSnapshot(snapshot)), extra: []any{&changed}})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := requireTaskEditor(ctx, tx, taskID, userID); err != nil {
+		return nil, err
+	}
+	if changed {
+		return task, ErrSyncConflict
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tasks WHERE id = $1", taskID); err != nil {
+		return nil, err
+	}
+	if err := recordEvents(ctx, tx, models.NewTaskEvent(models.EventTaskDeleted, task, userID)); err != nil {
+		return nil, err
+	}
+	return task, tx.Commit()
+}
+
+// mergeSyncField merges a write of a field like mergeField, except that a
+// write without a stamp is not made if the field changed since the
+// client's sync token.
+func mergeSyncField[T any](clock *crdt.Clock, stored crdt.Register[T], value *T, stamp crdt.Timestamp, changed bool) (crdt.Register[T], bool, error) {
+	if value != nil && stamp.IsZero() && changed {
+		return stored, true, nil
+	}
+	return mergeField(clock, stored, value, stamp)
+}
+
+// requireTaskEditor returns ErrTaskNotFound if userID cannot see taskID,
+// and ErrListRoleTooLow if the user may not change it.
+func requireTaskEditor(ctx context.Context, q queryRower, taskID, userID int) error {
+	role, err := taskRole(ctx, q, taskID, userID)
+	if err != nil {
+		return err
+	}
+	if !role.AtLeast(models.ListRoleEditor) {
+		return ErrListRoleTooLow
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+)
+
+// taskWrite matches SQL that changes rows of the tasks table.
+var taskWrite = regexp.MustCompile(`(?i)\b(UPDATE|INSERT\s+INTO|DELETE\s+FROM)\s+tasks\b`)
+
+// cascadingDelete matches SQL that deletes rows whose deletion cascades to
+// tasks.
+var cascadingDelete = regexp.MustCompile(`(?i)\bDELETE\s+FROM\s+(task_lists|workspaces|users)\b`)
+
+// TestTaskWritesRecordEvents checks that every function of this package
+// that writes to the tasks table also records events in the outbox, which
+// is the only source of task events for streams, collaboration and
+// webhooks. Bulk updates should go through updateTasks. Functions that
+// delete lists, workspaces or users, and with them tasks, must record the
+// deletions with recordTaskDeletions.
+func TestTaskWritesRecordEvents(t *testing.T) {
+	fset := token.NewFileSet()
+	names, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	checked := map[string]int{}
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		require.NoError(t, err)
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			writes, deletes := false, false
+			calls := map[string]bool{}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.BasicLit:
+					if n.Kind == token.STRING {
+						writes = writes || taskWrite.MatchString(n.Value)
+						deletes = deletes || cascadingDelete.MatchString(n.Value)
+					}
+				case *ast.CallExpr:
+					if ident, ok := n.Fun.(*ast.Ident); ok {
+						calls[ident.Name] = true
+					}
+				}
+				return true
+			})
+			if writes {
+				checked["recordEvents"]++
+				assert.True(t, calls["recordEvents"], "%s writes to tasks without recording events (%s)", fn.Name.Name, fset.Position(fn.Pos()))
+			}
+			if deletes {
+				checked["recordTaskDeletions"]++
+				assert.True(t, calls["recordTaskDeletions"], "%s deletes tasks by cascade without recording events (%s)", fn.Name.Name, fset.Position(fn.Pos()))
+			}
+		}
+	}
+	assert.NotZero(t, checked["recordEvents"])
+	assert.Equal(t, 3, checked["recordTaskDeletions"], "DeleteList, DeleteWorkspace and DeleteUser")
+}
+
+func TestRemoveMember_RecordsUnassignments(t *testing.T) {
+	assigned := fakeTaskRow(5, nil)
+	assigned[8] = int64(7)
+	outbox := &outboxRecorder{locked: [][]driver.Value{assigned}, updated: [][]driver.Value{fakeTaskRow(5, nil)}}
+	var lockArgs []driver.NamedValue
+	db, conn := newFakeDB(func(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+		switch {
+		case isQuery(query, "DELETE FROM task_list_members"):
+			return [][]driver.Value{{}}, nil
+		case isQuery(query, "INSERT INTO task_assignments"):
+			return nil, nil
+		case strings.Contains(query, "FOR UPDATE"):
+			lockArgs = args
+		}
+		return outbox.handle(query, args)
+	})
+	repo := NewPostgresTaskListRepository(conn)
+
+	err := repo.RemoveMember(context.Background(), 3, 7)
+
+	require.NoError(t, err)
+	require.Len(t, lockArgs, 2)
+	assert.Equal(t, []driver.Value{int64(3), int64(7)}, []driver.Value{lockArgs[0].Value, lockArgs[1].Value})
+	require.Len(t, outbox.events, 1)
+	assert.Equal(t, map[string]models.FieldChange{"assignee_id": {From: float64(7), To: nil}}, outbox.events[0].Changes)
+	assert.Contains(t, db.queries, "INSERT INTO task_assignments (task_id) VALUES ($1)")
+	assert.Equal(t, 1, db.commits)
+}
+
+func TestCascadingDeletes_RecordTaskDeletions(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent string
+		delete func(conn *sql.DB) error
+	}{
+		{"list", "task_lists", func(conn *sql.DB) error {
+			return NewPostgresTaskListRepository(conn).DeleteList(context.Background(), 3, 2)
+		}},
+		{"workspace", "workspaces", func(conn *sql.DB) error {
+			return NewPostgresWorkspaceRepository(conn).DeleteWorkspace(context.Background(), 3, 2)
+		}},
+		{"user", "users", func(conn *sql.DB) error {
+			return NewPostgresAdminRepository(conn).DeleteUser(context.Background(), 3, 2)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &outboxRecorder{locked: [][]driver.Value{fakeTaskRow(5, nil), fakeTaskRow(6, nil)}}
+			var order []string
+			db, conn := newFakeDB(func(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+				switch {
+				case isQuery(query, "DELETE FROM "+tt.parent):
+					order = append(order, "delete")
+					return [][]driver.Value{{}}, nil
+				case isQuery(query, "INSERT INTO outbox_events"):
+					order = append(order, "event")
+				}
+				return outbox.handle(query, args)
+			})
+
+			err := tt.delete(conn)
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"event", "event", "delete"}, order)
+			require.Len(t, outbox.events, 2)
+			for i, event := range outbox.events {
+				assert.Equal(t, models.EventTaskDeleted, event.Type)
+				assert.Equal(t, 2, event.ActorID)
+				assert.Equal(t, 5+i, event.Task.ID)
+			}
+			assert.Equal(t, 1, db.commits)
+		})
+	}
+}
+
+func TestDeleteList_NotFoundRecordsNothing(t *testing.T) {
+	outbox := &outboxRecorder{}
+	db, conn := newFakeDB(func(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+		if isQuery(query, "DELETE FROM task_lists") {
+			return nil, nil
+		}
+		return outbox.handle(query, args)
+	})
+
+	err := NewPostgresTaskListRepository(conn).DeleteList(context.Background(), 3, 2)
+
+	assert.ErrorIs(t, err, ErrTaskListNotFound)
+	assert.Zero(t, db.commits)
+}
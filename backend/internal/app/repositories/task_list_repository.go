@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+const personalListName = "My tasks"
+
+// TaskListRepository stores task lists and their members. Lists outside the
+// workspace the context is scoped to, see tenant.WorkspaceID, are reported
+// as not found.
+type TaskListRepository interface {
+	// CreateList creates list in list.WorkspaceID, or in the owner's
+	// personal workspace if it is 0, with list.OwnerID as its owner.
+	CreateList(ctx context.Context, list *models.TaskList) error
+	// EnsurePersonalList returns the ID of the user's personal list and
+	// creates it if necessary. It returns ErrOutsideWorkspace if the context
+	// is scoped to a workspace other than the user's personal one.
+	EnsurePersonalList(ctx context.Context, userID int) (int, error)
+	ListLists(ctx context.Context, userID int) ([]models.TaskList, error)
+	GetList(ctx context.Context, listID, userID int) (*models.TaskList, error)
+	RenameList(ctx context.Context, listID int, name string) error
+	// DeleteList deletes a list with its tasks and memberships and records
+	// task.deleted events by actorID for the tasks.
+	DeleteList(ctx context.Context, listID, actorID int) error
+	GetMemberRole(ctx context.Context, listID, userID int) (models.ListRole, error)
+	ListMembers(ctx context.Context, listID int) ([]models.ListMember, error)
+	AddMember(ctx context.Context, listID, userID int, role models.ListRole) error
+	UpdateMemberRole(ctx context.Context, listID, userID int, role models.ListRole) error
+	RemoveMember(ctx context.Context, listID, userID int) error
+}
+
+type PostgresTaskListRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresTaskListRepository(db *sql.DB) *PostgresTaskListRepository {
+	return &PostgresTaskListRepository{db: db}
+}
+
+func (r *PostgresTaskListRepository) CreateList(ctx context.Context, list *models.TaskList) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.CreateList")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if list.WorkspaceID == 0 {
+		if list.WorkspaceID, err = ensurePersonalWorkspace(ctx, tx, list.OwnerID); err != nil {
+			return err
+		}
+	}
+	query := "INSERT INTO task_lists (name, owner_id, workspace_id) VALUES ($1, $2, $3) RETURNING id, created_at"
+	err = tx.QueryRowContext(ctx, query, list.Name, list.OwnerID, list.WorkspaceID).Scan(&list.ID, &list.CreatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return err
+	}
+	query = "INSERT INTO task_list_members (list_id, user_id, role) VALUES ($1, $2, $3)"
+	if _, err := tx.ExecContext(ctx, query, list.ID, list.OwnerID, models.ListRoleOwner); err != nil {
+		return err
+	}
+	list.Role = models.ListRoleOwner
+	list.MemberCount = 1
+
+	return tx.Commit()
+}
+
+func (r *PostgresTaskListRepository) EnsurePersonalList(ctx context.Context, userID int) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.EnsurePersonalList")
+	defer span.End()
+
+	workspaceID, err := ensurePersonalWorkspace(ctx, r.db, userID)
+	if err != nil {
+		return 0, err
+	}
+	if scope := tenant.WorkspaceID(ctx); scope != 0 && scope != workspaceID {
+		return 0, ErrOutsideWorkspace
+	}
+
+	// The final SELECT does not see a list created by the same statement, so
+	// exactly one branch of the UNION returns a row. A list created by a
+	// concurrent request may be invisible to both; the second attempt finds
+	// it.
+	query := `WITH created AS (
+			INSERT INTO task_lists (name, owner_id, workspace_id, personal) VALUES ($2, $1, $3, TRUE)
+			ON CONFLICT (owner_id) WHERE personal DO NOTHING
+			RETURNING id
+		), owner AS (
+			INSERT INTO task_list_members (list_id, user_id, role) SELECT id, $1, 'owner' FROM created
+		)
+		SELECT id FROM created
+		UNION ALL
+		SELECT id FROM task_lists WHERE owner_id = $1 AND personal`
+	var listID int
+	for range 2 {
+		err = r.db.QueryRowContext(ctx, query, userID, personalListName, workspaceID).Scan(&listID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+	}
+	return listID, err
+}
+
+const taskListColumns = `l.id, l.workspace_id, l.name, l.owner_id, l.personal, m.role,
+	(SELECT COUNT(*) FROM task_list_members c WHERE c.list_id = l.id), l.created_at`
+
+func scanTaskList(row rowScanner) (*models.TaskList, error) {
+	var list models.TaskList
+	err := row.Scan(&list.ID, &list.WorkspaceID, &list.Name, &list.OwnerID, &list.Personal, &list.Role, &list.MemberCount, &list.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ListLists returns the lists userID is a member of, the personal list first.
+func (r *PostgresTaskListRepository) ListLists(ctx context.Context, userID int) ([]models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.ListLists")
+	defer span.End()
+
+	query := "SELECT " + taskListColumns + ` FROM task_lists l
+		JOIN task_list_members m ON m.list_id = l.id AND m.user_id = $1
+		WHERE $2 = 0 OR l.workspace_id = $2
+		ORDER BY l.personal DESC, l.name, l.id`
+	rows, err := r.db.QueryContext(ctx, query, userID, tenant.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []models.TaskList{}
+	for rows.Next() {
+		list, err := scanTaskList(rows)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, *list)
+	}
+	return lists, rows.Err()
+}
+
+// GetList returns a list userID is a member of.
+func (r *PostgresTaskListRepository) GetList(ctx context.Context, listID, userID int) (*models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.GetList")
+	defer span.End()
+
+	query := "SELECT " + taskListColumns + ` FROM task_lists l
+		JOIN task_list_members m ON m.list_id = l.id AND m.user_id = $2
+		WHERE l.id = $1 AND ($3 = 0 OR l.workspace_id = $3)`
+	list, err := scanTaskList(r.db.QueryRowContext(ctx, query, listID, userID, tenant.WorkspaceID(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTaskListNotFound
+	}
+	return list, err
+}
+
+func (r *PostgresTaskListRepository) RenameList(ctx context.Context, listID int, name string) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.RenameList")
+	defer span.End()
+
+	n, err := rowsAffected(r.db.ExecContext(ctx, "UPDATE task_lists SET name = $2 WHERE id = $1", listID, name))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTaskListNotFound
+	}
+	return nil
+}
+
+func (r *PostgresTaskListRepository) DeleteList(ctx context.Context, listID, actorID int) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.DeleteList")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordTaskDeletions(ctx, tx, actorID, "t.list_id = $1", listID); err != nil {
+		return err
+	}
+	n, err := rowsAffected(tx.ExecContext(ctx, "DELETE FROM task_lists WHERE id = $1", listID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrTaskListNotFound
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTaskListRepository) GetMemberRole(ctx context.Context, listID, userID int) (models.ListRole, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.GetMemberRole")
+	defer span.End()
+
+	return memberRole(ctx, r.db, listID, userID)
+}
+
+// memberRole returns the role of userID in listID, or ErrTaskListNotFound if
+// the user is not a member or the list is outside the workspace of ctx.
+func memberRole(ctx context.Context, q queryRower, listID, userID int) (models.ListRole, error) {
+	var role models.ListRole
+	query := `SELECT m.role FROM task_list_members m JOIN task_lists l ON l.id = m.list_id
+		WHERE m.list_id = $1 AND m.user_id = $2 AND ($3 = 0 OR l.workspace_id = $3)`
+	err := q.QueryRowContext(ctx, query, listID, userID, tenant.WorkspaceID(ctx)).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTaskListNotFound
+	}
+	return role, err
+}
+
+func (r *PostgresTaskListRepository) ListMembers(ctx context.Context, listID int) ([]models.ListMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.ListMembers")
+	defer span.End()
+
+	query := `SELECT m.user_id, u.username, m.role, m.created_at
+		FROM task_list_members m JOIN users u ON u.id = m.user_id
+		WHERE m.list_id = $1 ORDER BY m.created_at, m.user_id`
+	rows, err := r.db.QueryContext(ctx, query, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.ListMember{}
+	for rows.Next() {
+		var member models.ListMember
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *PostgresTaskListRepository) AddMember(ctx context.Context, listID, userID int, role models.ListRole) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.AddMember")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO task_list_members (list_id, user_id, role) VALUES ($1, $2, $3)"
+	_, err = tx.ExecContext(ctx, query, listID, userID, role)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return ErrAlreadyListMember
+		case foreignKeyViolation:
+			return ErrTaskListNotFound
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err := addWorkspaceMember(ctx, tx, listID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateMemberRole changes the role of a member other than the owner.
+func (r *PostgresTaskListRepository) UpdateMemberRole(ctx context.Context, listID, userID int, role models.ListRole) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.UpdateMemberRole")
+	defer span.End()
+
+	query := "UPDATE task_list_members SET role = $3 WHERE list_id = $1 AND user_id = $2 AND role <> 'owner'"
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, listID, userID, role))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotListMember
+	}
+	return nil
+}
+
+// RemoveMember removes a member other than the owner. Tasks of the list
+// assigned to the member become unassigned, which records task.updated
+// events without an actor.
+func (r *PostgresTaskListRepository) RemoveMember(ctx context.Context, listID, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListRepository.RemoveMember")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM task_list_members WHERE list_id = $1 AND user_id = $2 AND role <> 'owner'"
+	n, err := rowsAffected(tx.ExecContext(ctx, query, listID, userID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotListMember
+	}
+
+	if err := unassignTasks(ctx, tx, userID, "t.list_id = $1", listID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rowsAffected returns the number of rows affected by an Exec call.
+func rowsAffected(result sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -4,62 +4,251 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"slices"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 	"go.opentelemetry.io/otel"
 )
 
-var ErrTaskNotFound = errors.New("task not found")
+var (
+	ErrTaskNotFound      = errors.New("task not found")
+	ErrListRoleTooLow    = errors.New("list role does not permit this action")
+	ErrTaskListNotFound  = errors.New("task list not found")
+	ErrAlreadyListMember = errors.New("user is already a member of the list")
+	ErrNotListMember     = errors.New("user is not a member of the list")
+	ErrAssigneeNotMember = errors.New("assignee is not a member of the list")
+)
 
+// TaskRepository stores tasks. Every method checks that the acting user is a
+// member of the task's list with a sufficient role: viewer for reading,
+// editor for changes. Tasks in lists the user is not a member of, or outside
+// the workspace the context is scoped to, are reported as not found.
+// CreateTask, UpdateTask, DeleteTask and AssignTask record their domain
+// events in the outbox in the same transaction as the change. Writes of
+// the title, completion and positions of tasks are merged by their
+// timestamps, see models.TaskClock; the repository stamps those without
+// one with the server's clock.
 type TaskRepository interface {
-	GetTasks(ctx context.Context, userID uint) ([]models.Task, error)
+	// GetTasks returns the tasks of all lists of userID that match filter.
+	GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error)
+	// EachTask calls fn with the tasks GetTasks returns, one at a time as
+	// they are read, and stops at the first error fn returns.
+	EachTask(ctx context.Context, userID uint, filter models.TaskFilter, fn func(*models.Task) error) error
+	// CreateTask adds task to task.ListID on behalf of task.CreatedBy.
 	CreateTask(ctx context.Context, task *models.Task) error
+	// UpdateTask changes the title and completion of task.ID on behalf of
+	// task.UpdatedBy. A field whose timestamp in task.Clock is not later
+	// than the stored one keeps its value. Timestamps too far ahead of the
+	// server's clock are rejected with crdt.ErrClockDrift.
 	UpdateTask(ctx context.Context, task *models.Task) error
 	DeleteTask(ctx context.Context, taskID uint, userID uint) error
+	// AssignTask sets the assignee of taskID on behalf of userID, or removes
+	// it if assigneeID is 0, and records the change. The assignee must be a
+	// member of the task's list. changed is false if the task already had
+	// that assignee; nothing is recorded then.
+	AssignTask(ctx context.Context, taskID, userID, assigneeID int) (task *models.Task, changed bool, err error)
+	// ListAssignments returns the assignment history of taskID, oldest
+	// first.
+	ListAssignments(ctx context.Context, taskID, userID int) ([]models.TaskAssignment, error)
+	// SnoozeTask hides taskID until until on behalf of userID, or wakes it
+	// if until is nil, and records the change.
+	SnoozeTask(ctx context.Context, taskID, userID int, until *time.Time) (*models.Task, error)
+	// WakeSnoozedTasks clears the snooze of every task whose snooze has
+	// passed, records the changes and returns those tasks. It is not
+	// scoped to a user.
+	WakeSnoozedTasks(ctx context.Context) ([]models.Task, error)
+	// SetPosition places taskID in the manual order of userID unless the
+	// stored position has a later timestamp. It requires the viewer role
+	// and returns the task with the position that won.
+	SetPosition(ctx context.Context, taskID, userID int, position crdt.Register[string]) (*models.Task, error)
 }
 
 type PostgresTaskRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	clock *crdt.Clock
+}
+
+// NewPostgresTaskRepository returns a task repository that stamps writes
+// with clock.
+func NewPostgresTaskRepository(db *sql.DB, clock *crdt.Clock) *PostgresTaskRepository {
+	return &PostgresTaskRepository{db: db, clock: clock}
 }
 
-func NewPostgresTaskRepository(db *sql.DB) *PostgresTaskRepository {
-	return &PostgresTaskRepository{db: db}
+// listAccess is the access check for task lists. It matches if the user in
+// parameter userArg is a member of the list in listColumn with one of the
+// roles in the array parameter rolesArg, see models.ListRolesAtLeast, and
+// the list belongs to the workspace in parameter workspaceArg. A workspace
+// of 0 matches every list, see tenant.WorkspaceID.
+func listAccess(listColumn string, userArg, rolesArg, workspaceArg int) string {
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM task_list_members m JOIN task_lists ml ON ml.id = m.list_id
+		WHERE m.list_id = %s AND m.user_id = $%d AND m.role = ANY($%d)
+		AND ($%d = 0 OR ml.workspace_id = $%d))`, listColumn, userArg, rolesArg, workspaceArg, workspaceArg)
 }
 
-func (r *PostgresTaskRepository) GetTasks(ctx context.Context, userID uint) ([]models.Task, error) {
-	_, span := otel.Tracer("").Start(ctx, "TaskRepository.GetTasks")
+const taskColumns = `t.id, t.list_id, t.title, t.completed, t.created_by, t.created_at, t.updated_by, t.updated_at,
+	t.assignee_id, t.assigned_by, t.assigned_at, t.snoozed_until, t.title_clock, t.completed_clock,
+	(SELECT COUNT(*) FROM task_comments c WHERE c.task_id = t.id)`
+
+func scanTask(row rowScanner) (*models.Task, error) {
+	var task models.Task
+	var createdBy, updatedBy, assigneeID, assignedBy sql.NullInt64
+	var updatedAt, assignedAt, snoozedUntil sql.NullTime
+	var titleClock, completedClock string
+	err := row.Scan(&task.ID, &task.ListID, &task.Title, &task.Completed, &createdBy, &task.CreatedAt, &updatedBy, &updatedAt,
+		&assigneeID, &assignedBy, &assignedAt, &snoozedUntil, &titleClock, &completedClock, &task.CommentCount)
+	if err != nil {
+		return nil, err
+	}
+	if task.Clock.Title, err = crdt.ParseTimestamp(titleClock); err != nil {
+		return nil, err
+	}
+	if task.Clock.Completed, err = crdt.ParseTimestamp(completedClock); err != nil {
+		return nil, err
+	}
+	task.CreatedBy = int(createdBy.Int64)
+	task.UpdatedBy = int(updatedBy.Int64)
+	task.AssigneeID = int(assigneeID.Int64)
+	task.AssignedBy = int(assignedBy.Int64)
+	if updatedAt.Valid {
+		task.UpdatedAt = &updatedAt.Time
+	}
+	if assignedAt.Valid {
+		task.AssignedAt = &assignedAt.Time
+	}
+	if snoozedUntil.Valid {
+		task.SnoozedUntil = &snoozedUntil.Time
+	}
+	return &task, nil
+}
+
+// positionJoin joins the positions of the user in parameter userArg as p,
+// whose positionColumns scanPositionedTask reads after the task columns.
+func positionJoin(userArg int) string {
+	return fmt.Sprintf("LEFT JOIN task_positions p ON p.task_id = t.id AND p.user_id = $%d", userArg)
+}
+
+const positionColumns = "p.position, p.clock"
+
+func scanPositionedTask(row rowScanner) (*models.Task, error) {
+	var position, clock sql.NullString
+	task, err := scanTask(taskScanner{row: row, extra: []any{&position, &clock}})
+	if err != nil {
+		return nil, err
+	}
+	task.Position = position.String
+	if task.Clock.Position, err = crdt.ParseTimestamp(clock.String); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// mergeField merges a write of value, with stamp, into stored and reports
+// whether stored won. A nil value writes nothing. A write without a stamp
+// is stamped by clock after it observed stored, so that it wins. Other
+// stamps are observed too, and rejected with crdt.ErrClockDrift if they
+// are too far ahead.
+func mergeField[T any](clock *crdt.Clock, stored crdt.Register[T], value *T, stamp crdt.Timestamp) (crdt.Register[T], bool, error) {
+	if value == nil {
+		return stored, false, nil
+	}
+	if stamp.IsZero() {
+		// Stored stamps passed the drift check when they were written. Only
+		// a server clock that went back fails it now, and then the write
+		// does lose.
+		_ = clock.Observe(stored.Stamp)
+		stamp = clock.Now()
+	} else if err := clock.Observe(stamp); err != nil {
+		return stored, false, err
+	}
+	merged := stored.Merge(crdt.Register[T]{Value: *value, Stamp: stamp})
+	return merged, merged.Stamp != stamp, nil
+}
+
+func (r *PostgresTaskRepository) GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TaskRepository.GetTasks")
 	defer span.End()
 
-	rows, err := r.db.QueryContext(ctx, "SELECT id, user_id, title, completed FROM tasks WHERE user_id = $1", userID)
+	tasks := []models.Task{}
+	err := r.EachTask(ctx, userID, filter, func(task *models.Task) error {
+		tasks = append(tasks, *task)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return tasks, nil
+}
+
+func (r *PostgresTaskRepository) EachTask(ctx context.Context, userID uint, filter models.TaskFilter, fn func(*models.Task) error) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.EachTask")
+	defer span.End()
+
+	query := "SELECT " + taskColumns + ", " + positionColumns + " FROM tasks t " + positionJoin(1) + `
+		WHERE ` + listAccess("t.list_id", 1, 2, 6) + `
+		AND ($3 = 0 OR t.list_id = $3)
+		AND ($4 = 0 OR t.assignee_id = $4)
+		AND (NOT $5 OR t.assignee_id IS NULL)
+		AND ($7 OR $8 OR t.snoozed_until IS NULL OR t.snoozed_until <= NOW())
+		AND (NOT $8 OR t.snoozed_until > NOW())
+		ORDER BY CASE WHEN $9 THEN p.position END, t.id`
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(models.ListRolesAtLeast(models.ListRoleViewer)),
+		filter.ListID, filter.AssigneeID, filter.Unassigned, tenant.WorkspaceID(ctx), filter.IncludeSnoozed, filter.SnoozedOnly,
+		filter.ManualOrder)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	tasks := []models.Task{}
 	for rows.Next() {
-		var task models.Task
-		if err := rows.Scan(&task.ID, &task.UserID, &task.Title, &task.Completed); err != nil {
-			return nil, err
+		task, err := scanPositionedTask(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(task); err != nil {
+			return err
 		}
-		tasks = append(tasks, task)
 	}
-
-	return tasks, rows.Err()
+	return rows.Err()
 }
 
 func (r *PostgresTaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
 	_, span := otel.Tracer("").Start(ctx, "TaskRepository.CreateTask")
 	defer span.End()
 
-	query := "INSERT INTO tasks (user_id, title, completed) VALUES ($1, $2, $3) RETURNING id"
-	var id int
-	err := r.db.QueryRowContext(ctx, query, task.UserID, task.Title, task.Completed).Scan(&id)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stamp := r.clock.Now().String()
+	query := `INSERT INTO tasks AS t (list_id, created_by, title, completed, title_clock, completed_clock)
+		SELECT $1, $2, $3, $4, $7, $7 WHERE ` + listAccess("$1", 2, 5, 6) + `
+		RETURNING ` + taskColumns
+	created, err := scanTask(tx.QueryRowContext(ctx, query, task.ListID, task.CreatedBy, task.Title, task.Completed,
+		pq.Array(models.ListRolesAtLeast(models.ListRoleEditor)), tenant.WorkspaceID(ctx), stamp))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := memberRole(ctx, r.db, task.ListID, task.CreatedBy); err != nil {
+			return err
+		}
+		return ErrListRoleTooLow
+	}
 	if err != nil {
 		return err
 	}
-	task.ID = id
+	if err := recordEvents(ctx, tx, models.NewTaskEvent(models.EventTaskCreated, created, task.CreatedBy)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*task = *created
 
 	return nil
 }
@@ -68,18 +257,55 @@ func (r *PostgresTaskRepository) UpdateTask(ctx context.Context, task *models.Ta
 	_, span := otel.Tracer("").Start(ctx, "TaskRepository.UpdateTask")
 	defer span.End()
 
-	query := "UPDATE tasks SET title = $1, completed = $2 WHERE id = $3 AND user_id = $4"
-	result, err := r.db.ExecContext(ctx, query, task.Title, task.Completed, task.ID, task.UserID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the task so that the recorded changes are relative to the state
+	// this update replaces, and the fields are merged with the latest
+	// writes.
+	query := "SELECT " + taskColumns + " FROM tasks t WHERE t.id = $1 FOR UPDATE"
+	previous, err := scanTask(tx.QueryRowContext(ctx, query, task.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTaskNotFound
+	}
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
+	if err := requireTaskEditor(ctx, tx, task.ID, task.UpdatedBy); err != nil {
+		return err
+	}
+
+	title, _, err := mergeField(r.clock, crdt.Register[string]{Value: previous.Title, Stamp: previous.Clock.Title}, &task.Title, task.Clock.Title)
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrTaskNotFound
+	completed, _, err := mergeField(r.clock, crdt.Register[bool]{Value: previous.Completed, Stamp: previous.Clock.Completed}, &task.Completed, task.Clock.Completed)
+	if err != nil {
+		return err
+	}
+	if title.Stamp == previous.Clock.Title && completed.Stamp == previous.Clock.Completed {
+		*task = *previous
+		return nil
 	}
+
+	query = `UPDATE tasks t SET title = $1, completed = $2, title_clock = $3, completed_clock = $4,
+		updated_by = $5, updated_at = NOW()
+		WHERE t.id = $6 RETURNING ` + taskColumns
+	updated, err := scanTask(tx.QueryRowContext(ctx, query, title.Value, completed.Value, title.Stamp.String(), completed.Stamp.String(),
+		task.UpdatedBy, task.ID))
+	if err != nil {
+		return err
+	}
+	if err := recordEvents(ctx, tx, models.TaskUpdateEvents(previous, updated, task.UpdatedBy)...); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*task = *updated
 	return nil
 }
 
@@ -88,17 +314,346 @@ func (r *PostgresTaskRepository) DeleteTask(ctx context.Context, taskID uint, us
 	defer span.End()
 
 	utils.RandomSleep()
-	query := "DELETE FROM tasks WHERE id = $1 AND user_id = $2"
-	result, err := r.db.ExecContext(ctx, query, taskID, userID)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	query := "DELETE FROM tasks t WHERE t.id = $1 AND " + listAccess("t.list_id", 2, 3, 4) + " RETURNING " + taskColumns
+	deleted, err := scanTask(tx.QueryRowContext(ctx, query, taskID, userID, pq.Array(models.ListRolesAtLeast(models.ListRoleEditor)),
+		tenant.WorkspaceID(ctx)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.taskAccessError(ctx, int(taskID), int(userID))
+	}
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return ErrTaskNotFound
+	if err := recordEvents(ctx, tx, models.NewTaskEvent(models.EventTaskDeleted, deleted, int(userID))); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgresTaskRepository) AssignTask(ctx context.Context, taskID, userID, assigneeID int) (*models.Task, bool, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.AssignTask")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// Lock the task so that concurrent assignments are recorded in the
+	// order they are applied.
+	query := "SELECT " + taskColumns + " FROM tasks t WHERE t.id = $1 FOR UPDATE"
+	task, err := scanTask(tx.QueryRowContext(ctx, query, taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	role, err := memberRole(ctx, tx, task.ListID, userID)
+	if errors.Is(err, ErrTaskListNotFound) {
+		return nil, false, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !role.AtLeast(models.ListRoleEditor) {
+		return nil, false, ErrListRoleTooLow
+	}
+
+	if assigneeID != 0 {
+		_, err := memberRole(ctx, tx, task.ListID, assigneeID)
+		if errors.Is(err, ErrTaskListNotFound) {
+			return nil, false, ErrAssigneeNotMember
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if task.AssigneeID == assigneeID {
+		return task, false, nil
+	}
+
+	previous := task
+	query = `UPDATE tasks t SET assignee_id = NULLIF($2, 0), assigned_by = $3, assigned_at = NOW()
+		WHERE t.id = $1 RETURNING ` + taskColumns
+	task, err = scanTask(tx.QueryRowContext(ctx, query, taskID, assigneeID, userID))
+	if err != nil {
+		return nil, false, err
+	}
+	if err := recordEvents(ctx, tx, models.TaskUpdateEvents(previous, task, userID)...); err != nil {
+		return nil, false, err
+	}
+	query = "INSERT INTO task_assignments (task_id, assignee_id, assigned_by) VALUES ($1, NULLIF($2, 0), $3)"
+	if _, err := tx.ExecContext(ctx, query, taskID, assigneeID, userID); err != nil {
+		return nil, false, err
+	}
+
+	return task, true, tx.Commit()
+}
+
+func (r *PostgresTaskRepository) ListAssignments(ctx context.Context, taskID, userID int) ([]models.TaskAssignment, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.ListAssignments")
+	defer span.End()
+
+	query := `SELECT a.id, a.task_id, a.assignee_id, a.assigned_by, a.created_at
+		FROM task_assignments a JOIN tasks t ON t.id = a.task_id
+		WHERE a.task_id = $1 AND ` + listAccess("t.list_id", 2, 3, 4) + `
+		ORDER BY a.created_at, a.id`
+	rows, err := r.db.QueryContext(ctx, query, taskID, userID, pq.Array(models.ListRolesAtLeast(models.ListRoleViewer)),
+		tenant.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []models.TaskAssignment{}
+	for rows.Next() {
+		var a models.TaskAssignment
+		var assigneeID, assignedBy sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.TaskID, &assigneeID, &assignedBy, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.AssigneeID = int(assigneeID.Int64)
+		a.AssignedBy = int(assignedBy.Int64)
+		assignments = append(assignments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// An empty history is ambiguous: the task may never have been assigned,
+	// or the user may not see it.
+	if len(assignments) == 0 {
+		if _, err := taskRole(ctx, r.db, taskID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return assignments, nil
+}
+
+func (r *PostgresTaskRepository) SnoozeTask(ctx context.Context, taskID, userID int, until *time.Time) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.SnoozeTask")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	where := "t.id = $1 AND " + listAccess("t.list_id", 2, 3, 4)
+	tasks, err := updateTasks(ctx, tx, userID, "snoozed_until = $2, updated_by = $3, updated_at = NOW()", []any{until, userID},
+		where, taskID, userID, pq.Array(models.ListRolesAtLeast(models.ListRoleEditor)), tenant.WorkspaceID(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, r.taskAccessError(ctx, taskID, userID)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &tasks[0], nil
+}
+
+// WakeSnoozedTasks clears the snooze of the tasks whose snooze has passed
+// and records a task.updated event for each, with no actor.
+func (r *PostgresTaskRepository) WakeSnoozedTasks(ctx context.Context) ([]models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.WakeSnoozedTasks")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tasks, err := updateTasks(ctx, tx, 0, "snoozed_until = NULL, updated_at = NOW()", nil, "t.snoozed_until <= NOW()")
+	if err != nil {
+		return nil, err
+	}
+	return tasks, tx.Commit()
+}
+
+// unassignTasks unassigns userID from the tasks matching where, like
+// updateTasks, and records the unassignments in the assignment history.
+func unassignTasks(ctx context.Context, tx *sql.Tx, userID int, where string, whereArgs ...any) error {
+	where = fmt.Sprintf("%s AND t.assignee_id = $%d", where, len(whereArgs)+1)
+	tasks, err := updateTasks(ctx, tx, 0, "assignee_id = NULL, assigned_by = NULL, assigned_at = NOW()", nil,
+		where, append(whereArgs, userID)...)
+	if err != nil {
+		return err
+	}
+	for _, task := range tasks {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO task_assignments (task_id) VALUES ($1)", task.ID); err != nil {
+			return err
+		}
 	}
 	return nil
 }
+
+// updateTasks locks the tasks matching where, a condition on tasks t with
+// whereArgs, and sets them with set, an assignment list whose arguments
+// start at $2. The events of the changes, caused by actorID, are recorded
+// in tx, so every bulk update of tasks should go through here. It returns
+// the updated tasks ordered by ID.
+func updateTasks(ctx context.Context, tx *sql.Tx, actorID int, set string, setArgs []any, where string, whereArgs ...any) ([]models.Task, error) {
+	locked, err := lockTasks(ctx, tx, where, whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	if len(locked) == 0 {
+		return []models.Task{}, nil
+	}
+	previous := make(map[int]*models.Task, len(locked))
+	ids := make([]int64, 0, len(locked))
+	for _, task := range locked {
+		previous[task.ID] = task
+		ids = append(ids, int64(task.ID))
+	}
+
+	query := "UPDATE tasks t SET " + set + " WHERE t.id = ANY($1) RETURNING " + taskColumns
+	rows, err := tx.QueryContext(ctx, query, append([]any{pq.Array(ids)}, setArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	tasks := make([]models.Task, 0, len(ids))
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(tasks, func(a, b models.Task) int { return a.ID - b.ID })
+
+	for i := range tasks {
+		if err := recordEvents(ctx, tx, models.TaskUpdateEvents(previous[tasks[i].ID], &tasks[i], actorID)...); err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+// recordTaskDeletions locks the tasks matching where, like updateTasks, and
+// records task.deleted events by actorID for them. Statements that delete
+// tasks through ON DELETE CASCADE, such as deleting their list, must call it
+// in the same transaction first, or consumers of the outbox never learn that
+// the tasks are gone.
+func recordTaskDeletions(ctx context.Context, tx *sql.Tx, actorID int, where string, whereArgs ...any) error {
+	tasks, err := lockTasks(ctx, tx, where, whereArgs...)
+	if err != nil {
+		return err
+	}
+	events := make([]models.Event, 0, len(tasks))
+	for _, task := range tasks {
+		events = append(events, models.NewTaskEvent(models.EventTaskDeleted, task, actorID))
+	}
+	return recordEvents(ctx, tx, events...)
+}
+
+// lockTasks locks the tasks matching where and returns them ordered by ID.
+func lockTasks(ctx context.Context, tx *sql.Tx, where string, whereArgs ...any) ([]*models.Task, error) {
+	// Locking in ID order keeps concurrent bulk updates from deadlocking.
+	query := "SELECT " + taskColumns + " FROM tasks t WHERE " + where + " ORDER BY t.id FOR UPDATE OF t"
+	rows, err := tx.QueryContext(ctx, query, whereArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []*models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+func (r *PostgresTaskRepository) SetPosition(ctx context.Context, taskID, userID int, position crdt.Register[string]) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskRepository.SetPosition")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := taskRole(ctx, tx, taskID, userID); err != nil {
+		return nil, err
+	}
+	if position.Stamp.IsZero() {
+		// The server's stamp must be later than the stored one.
+		var stored string
+		query := "SELECT clock FROM task_positions WHERE user_id = $1 AND task_id = $2 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, userID, taskID).Scan(&stored)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		stamp, err := crdt.ParseTimestamp(stored)
+		if err != nil {
+			return nil, err
+		}
+		_ = r.clock.Observe(stamp)
+		position.Stamp = r.clock.Now()
+	} else if err := r.clock.Observe(position.Stamp); err != nil {
+		return nil, err
+	}
+
+	query := `INSERT INTO task_positions AS p (user_id, task_id, position, clock) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, task_id) DO UPDATE SET position = EXCLUDED.position, clock = EXCLUDED.clock
+		WHERE p.clock < EXCLUDED.clock`
+	if _, err := tx.ExecContext(ctx, query, userID, taskID, position.Value, position.Stamp.String()); err != nil {
+		return nil, err
+	}
+	query = "SELECT " + taskColumns + ", " + positionColumns + " FROM tasks t " + positionJoin(2) + " WHERE t.id = $1"
+	task, err := scanPositionedTask(tx.QueryRowContext(ctx, query, taskID, userID))
+	if err != nil {
+		return nil, err
+	}
+	return task, tx.Commit()
+}
+
+// taskAccessError explains why a change of taskID by userID did not match:
+// the task does not exist or is in a list the user is not a member of, or
+// the user's role is too low.
+func (r *PostgresTaskRepository) taskAccessError(ctx context.Context, taskID, userID int) error {
+	if _, err := taskRole(ctx, r.db, taskID, userID); err != nil {
+		return err
+	}
+	return ErrListRoleTooLow
+}
+
+// taskRole returns the role of userID in the list of taskID, or
+// ErrTaskNotFound if the task does not exist, the user is not a member or
+// the list is outside the workspace of ctx.
+func taskRole(ctx context.Context, q queryRower, taskID, userID int) (models.ListRole, error) {
+	var role models.ListRole
+	query := `SELECT m.role FROM tasks t
+		JOIN task_list_members m ON m.list_id = t.list_id AND m.user_id = $2
+		JOIN task_lists l ON l.id = t.list_id
+		WHERE t.id = $1 AND ($3 = 0 OR l.workspace_id = $3)`
+	err := q.QueryRowContext(ctx, query, taskID, userID, tenant.WorkspaceID(ctx)).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrTaskNotFound
+	}
+	return role, err
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+)
+
+// outboxRecorder answers the queries of a bulk task update from a script
+// and collects the events written to the outbox.
+type outboxRecorder struct {
+	locked  [][]driver.Value
+	updated [][]driver.Value
+	events  []models.Event
+}
+
+func (o *outboxRecorder) handle(query string, args []driver.NamedValue) ([][]driver.Value, error) {
+	switch {
+	case isQuery(query, "SELECT") && strings.Contains(query, "FOR UPDATE"):
+		return o.locked, nil
+	case isQuery(query, "UPDATE tasks"):
+		return o.updated, nil
+	case isQuery(query, "INSERT INTO outbox_events"):
+		var event models.Event
+		if err := json.Unmarshal([]byte(args[3].Value.(string)), &event); err != nil {
+			return nil, err
+		}
+		o.events = append(o.events, event)
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unexpected query %q", query)
+}
+
+func TestSnoozeTask_RecordsEvents(t *testing.T) {
+	until := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	outbox := &outboxRecorder{locked: [][]driver.Value{fakeTaskRow(5, nil)}, updated: [][]driver.Value{fakeTaskRow(5, &until)}}
+	db, conn := newFakeDB(outbox.handle)
+	repo := NewPostgresTaskRepository(conn, nil)
+
+	task, err := repo.SnoozeTask(context.Background(), 5, 2, &until)
+
+	require.NoError(t, err)
+	assert.Equal(t, until, *task.SnoozedUntil)
+	require.Len(t, outbox.events, 1)
+	event := outbox.events[0]
+	assert.Equal(t, models.EventTaskUpdated, event.Type)
+	assert.Equal(t, 2, event.ActorID)
+	assert.Equal(t, 3, event.ListID)
+	assert.Equal(t, map[string]models.FieldChange{"snoozed_until": {From: nil, To: until.Format(time.RFC3339)}}, event.Changes)
+	assert.Equal(t, 1, db.commits)
+}
+
+func TestSnoozeTask_NoChangeRecordsNothing(t *testing.T) {
+	outbox := &outboxRecorder{locked: [][]driver.Value{fakeTaskRow(5, nil)}, updated: [][]driver.Value{fakeTaskRow(5, nil)}}
+	_, conn := newFakeDB(outbox.handle)
+	repo := NewPostgresTaskRepository(conn, nil)
+
+	_, err := repo.SnoozeTask(context.Background(), 5, 2, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, outbox.events)
+}
+
+func TestWakeSnoozedTasks_RecordsEvents(t *testing.T) {
+	until := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	outbox := &outboxRecorder{
+		locked: [][]driver.Value{fakeTaskRow(5, &until), fakeTaskRow(6, &until)},
+		// RETURNING does not keep the order of the lock.
+		updated: [][]driver.Value{fakeTaskRow(6, nil), fakeTaskRow(5, nil)},
+	}
+	db, conn := newFakeDB(outbox.handle)
+	repo := NewPostgresTaskRepository(conn, nil)
+
+	tasks, err := repo.WakeSnoozedTasks(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, 5, tasks[0].ID)
+	require.Len(t, outbox.events, 2)
+	for i, event := range outbox.events {
+		assert.Equal(t, models.EventTaskUpdated, event.Type)
+		assert.Equal(t, tasks[i].ID, event.Task.ID)
+		assert.Zero(t, event.ActorID, "the waker is not a user")
+		assert.Equal(t, map[string]models.FieldChange{"snoozed_until": {From: until.Format(time.RFC3339), To: nil}}, event.Changes)
+	}
+	assert.Equal(t, 1, db.commits)
+	// The tasks are locked before they are updated, so the events are
+	// relative to the state the update replaced.
+	require.Len(t, db.queries, 4)
+	assert.Contains(t, db.queries[0], "FOR UPDATE")
+	assert.True(t, isQuery(db.queries[1], "UPDATE tasks"))
+}
@@ -0,0 +1,335 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookDeliveryPending  = errors.New("webhook delivery is still pending")
+	ErrDeliveryLeaseLost       = errors.New("webhook delivery lease was lost")
+)
+
+// WebhookRepository stores webhooks and their delivery queue. Webhooks are
+// looked up within a workspace; callers check the user's role in it.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.Webhook) error
+	ListWebhooks(ctx context.Context, workspaceID int) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, workspaceID, webhookID int) (*models.Webhook, error)
+	// UpdateWebhook replaces the URL, events and active flag of webhook.
+	UpdateWebhook(ctx context.Context, webhook *models.Webhook) error
+	DeleteWebhook(ctx context.Context, workspaceID, webhookID int) error
+	// EnqueueDeliveries queues payload for every active webhook that
+	// subscribes to event.Type in the workspace of event.ListID and whose
+	// creator is a member of that list. Webhooks that already have a
+	// delivery of event are skipped. It returns the number of queued
+	// deliveries.
+	EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte) (int, error)
+	// CreateTestDelivery queues payload for webhookID and claims it right
+	// away with token for lease.
+	CreateTestDelivery(ctx context.Context, webhookID int, event models.Event, payload []byte, token string, lease time.Duration) (*models.PendingDelivery, error)
+	ListDeliveries(ctx context.Context, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error)
+	// Redeliver queues a succeeded or dead delivery again with a fresh set
+	// of attempts.
+	Redeliver(ctx context.Context, webhookID, deliveryID int) (*models.WebhookDelivery, error)
+	// ClaimDueDeliveries leases up to limit pending deliveries of active
+	// webhooks whose next attempt is due, using SELECT ... FOR UPDATE SKIP
+	// LOCKED so that concurrent dispatchers never claim the same delivery.
+	ClaimDueDeliveries(ctx context.Context, token string, limit int, lease time.Duration) ([]models.PendingDelivery, error)
+	// RecordAttempt stores the outcome of an attempt and releases the
+	// lease. It returns ErrDeliveryLeaseLost if token no longer holds it.
+	RecordAttempt(ctx context.Context, deliveryID int, token string, attempt models.DeliveryAttempt) (*models.WebhookDelivery, error)
+}
+
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+const webhookColumns = "w.id, w.workspace_id, w.url, w.events, w.active, w.created_by, w.created_at"
+
+func scanWebhook(row rowScanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events []string
+	var createdBy sql.NullInt64
+	err := row.Scan(&webhook.ID, &webhook.WorkspaceID, &webhook.URL, pq.Array(&events), &webhook.Active,
+		&createdBy, &webhook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		webhook.Events = append(webhook.Events, models.EventType(e))
+	}
+	webhook.CreatedBy = int(createdBy.Int64)
+	return &webhook, nil
+}
+
+func eventNames(events []models.EventType) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = string(e)
+	}
+	return names
+}
+
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.CreateWebhook")
+	defer span.End()
+
+	query := `INSERT INTO webhooks (workspace_id, url, secret, events, active, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`
+	return r.db.QueryRowContext(ctx, query, webhook.WorkspaceID, webhook.URL, webhook.Secret,
+		pq.Array(eventNames(webhook.Events)), webhook.Active, webhook.CreatedBy).Scan(&webhook.ID, &webhook.CreatedAt)
+}
+
+func (r *PostgresWebhookRepository) ListWebhooks(ctx context.Context, workspaceID int) ([]models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.ListWebhooks")
+	defer span.End()
+
+	query := "SELECT " + webhookColumns + " FROM webhooks w WHERE w.workspace_id = $1 ORDER BY w.id"
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) GetWebhook(ctx context.Context, workspaceID, webhookID int) (*models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.GetWebhook")
+	defer span.End()
+
+	query := "SELECT " + webhookColumns + " FROM webhooks w WHERE w.id = $1 AND w.workspace_id = $2"
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, webhookID, workspaceID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func (r *PostgresWebhookRepository) UpdateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.UpdateWebhook")
+	defer span.End()
+
+	query := `UPDATE webhooks w SET url = $3, events = $4, active = $5
+		WHERE w.id = $1 AND w.workspace_id = $2
+		RETURNING ` + webhookColumns
+	updated, err := scanWebhook(r.db.QueryRowContext(ctx, query, webhook.ID, webhook.WorkspaceID, webhook.URL,
+		pq.Array(eventNames(webhook.Events)), webhook.Active))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		return err
+	}
+	*webhook = *updated
+	return nil
+}
+
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, workspaceID, webhookID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.DeleteWebhook")
+	defer span.End()
+
+	n, err := rowsAffected(r.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = $1 AND workspace_id = $2", webhookID, workspaceID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.EnqueueDeliveries")
+	defer span.End()
+
+	query := `INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload)
+		SELECT w.id, $2, $3::text, $4::jsonb FROM webhooks w JOIN task_lists l ON l.workspace_id = w.workspace_id
+		WHERE l.id = $1 AND w.active AND $3::text = ANY(w.events)
+		AND EXISTS (SELECT 1 FROM task_list_members m WHERE m.list_id = l.id AND m.user_id = w.created_by)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, event.ListID, event.ID, string(event.Type), string(payload)))
+	return int(n), err
+}
+
+// pendingColumns selects a delivery together with its webhook's URL and
+// secret. It expects the delivery as d and the webhook as w.
+const pendingColumns = "d.id, d.webhook_id, w.url, w.secret, d.event_id, d.event_type, d.payload, d.attempts"
+
+func scanPendingDelivery(row rowScanner) (*models.PendingDelivery, error) {
+	var d models.PendingDelivery
+	var payload string
+	if err := row.Scan(&d.ID, &d.WebhookID, &d.URL, &d.Secret, &d.EventID, &d.EventType, &payload, &d.Attempts); err != nil {
+		return nil, err
+	}
+	d.Payload = []byte(payload)
+	return &d, nil
+}
+
+func (r *PostgresWebhookRepository) CreateTestDelivery(ctx context.Context, webhookID int, event models.Event, payload []byte, token string, lease time.Duration) (*models.PendingDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.CreateTestDelivery")
+	defer span.End()
+
+	query := `WITH d AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, lease_token, lease_until)
+			SELECT w.id, $2, $3, $4::jsonb, $5, NOW() + $6 * INTERVAL '1 millisecond' FROM webhooks w WHERE w.id = $1
+			RETURNING *
+		)
+		SELECT ` + pendingColumns + ` FROM d JOIN webhooks w ON w.id = d.webhook_id`
+	delivery, err := scanPendingDelivery(r.db.QueryRowContext(ctx, query, webhookID, event.ID, string(event.Type),
+		string(payload), token, lease.Milliseconds()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return delivery, err
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, status, attempts, next_attempt_at, last_attempt_at,
+	last_status_code, last_error, last_response, delivered_at, created_at`
+
+func scanDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var nextAttemptAt, lastAttemptAt, deliveredAt sql.NullTime
+	var lastStatusCode sql.NullInt64
+	var lastError, lastResponse sql.NullString
+	err := row.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &nextAttemptAt,
+		&lastAttemptAt, &lastStatusCode, &lastError, &lastResponse, &deliveredAt, &d.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if nextAttemptAt.Valid && d.Status == models.DeliveryPending {
+		d.NextAttemptAt = &nextAttemptAt.Time
+	}
+	if lastAttemptAt.Valid {
+		d.LastAttemptAt = &lastAttemptAt.Time
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	d.LastStatusCode = int(lastStatusCode.Int64)
+	d.LastError = lastError.String
+	d.LastResponse = lastResponse.String
+	return &d, nil
+}
+
+func (r *PostgresWebhookRepository) ListDeliveries(ctx context.Context, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.ListDeliveries")
+	defer span.End()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT "+deliveryColumns+` FROM webhook_deliveries
+		WHERE webhook_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC LIMIT $3 OFFSET $4`, webhookID, string(query.Status), query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.Redeliver")
+	defer span.End()
+
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL
+		WHERE id = $1 AND webhook_id = $2 AND status <> 'pending'
+		RETURNING ` + deliveryColumns
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, query, deliveryID, webhookID))
+	if !errors.Is(err, sql.ErrNoRows) {
+		return delivery, err
+	}
+
+	var status string
+	err = r.db.QueryRowContext(ctx, "SELECT status FROM webhook_deliveries WHERE id = $1 AND webhook_id = $2",
+		deliveryID, webhookID).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, ErrWebhookDeliveryPending
+}
+
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, token string, limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.ClaimDueDeliveries")
+	defer span.End()
+
+	query := `WITH due AS (
+			SELECT d.id FROM webhook_deliveries d JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND w.active
+			AND (d.lease_until IS NULL OR d.lease_until < NOW())
+			ORDER BY d.next_attempt_at
+			LIMIT $2
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET lease_token = $1, lease_until = NOW() + $3 * INTERVAL '1 millisecond'
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING ` + pendingColumns
+	rows, err := r.db.QueryContext(ctx, query, token, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.PendingDelivery
+	for rows.Next() {
+		d, err := scanPendingDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *PostgresWebhookRepository) RecordAttempt(ctx context.Context, deliveryID int, token string, attempt models.DeliveryAttempt) (*models.WebhookDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookRepository.RecordAttempt")
+	defer span.End()
+
+	query := `UPDATE webhook_deliveries
+		SET status = $3, attempts = attempts + 1, last_attempt_at = NOW(),
+			last_status_code = NULLIF($4, 0), last_error = NULLIF($5, ''), last_response = NULLIF($6, ''),
+			next_attempt_at = COALESCE($7, next_attempt_at),
+			delivered_at = CASE WHEN $3 = 'succeeded' THEN NOW() END,
+			lease_token = NULL, lease_until = NULL
+		WHERE id = $1 AND lease_token = $2 AND status = 'pending'
+		RETURNING ` + deliveryColumns
+	delivery, err := scanDelivery(r.db.QueryRowContext(ctx, query, deliveryID, token, string(attempt.Status),
+		attempt.StatusCode, attempt.Error, attempt.Response, attempt.NextAttemptAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryLeaseLost
+	}
+	return delivery, err
+}
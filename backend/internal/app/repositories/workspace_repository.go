@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+const personalWorkspaceName = "Personal"
+
+var (
+	ErrWorkspaceNotFound      = errors.New("workspace not found")
+	ErrAlreadyWorkspaceMember = errors.New("user is already a member of the workspace")
+	ErrNotWorkspaceMember     = errors.New("user is not a member of the workspace")
+	ErrOwnsWorkspaceLists     = errors.New("user owns lists in the workspace")
+	ErrOutsideWorkspace       = errors.New("personal list is outside the workspace")
+)
+
+type WorkspaceRepository interface {
+	// EnsurePersonalWorkspace returns the ID of the user's personal
+	// workspace and creates it if necessary.
+	EnsurePersonalWorkspace(ctx context.Context, userID int) (int, error)
+	// CreateWorkspace creates ws with ws.OwnerID as its owner.
+	CreateWorkspace(ctx context.Context, ws *models.Workspace) error
+	ListWorkspaces(ctx context.Context, userID int) ([]models.Workspace, error)
+	GetWorkspace(ctx context.Context, workspaceID, userID int) (*models.Workspace, error)
+	RenameWorkspace(ctx context.Context, workspaceID int, name string) error
+	// DeleteWorkspace deletes a workspace with its lists and tasks and
+	// records task.deleted events by actorID for the tasks.
+	DeleteWorkspace(ctx context.Context, workspaceID, actorID int) error
+	GetMemberRole(ctx context.Context, workspaceID, userID int) (models.WorkspaceRole, error)
+	ListMembers(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error)
+	AddMember(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error
+	UpdateMemberRole(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error
+	// RemoveMember removes a member other than the owner from the workspace
+	// and all of its lists. Members who own lists in the workspace cannot be
+	// removed.
+	RemoveMember(ctx context.Context, workspaceID, userID int) error
+}
+
+type PostgresWorkspaceRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresWorkspaceRepository(db *sql.DB) *PostgresWorkspaceRepository {
+	return &PostgresWorkspaceRepository{db: db}
+}
+
+func (r *PostgresWorkspaceRepository) EnsurePersonalWorkspace(ctx context.Context, userID int) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.EnsurePersonalWorkspace")
+	defer span.End()
+
+	return ensurePersonalWorkspace(ctx, r.db, userID)
+}
+
+// ensurePersonalWorkspace works like EnsurePersonalList, see there.
+func ensurePersonalWorkspace(ctx context.Context, q queryRower, userID int) (int, error) {
+	query := `WITH created AS (
+			INSERT INTO workspaces (name, owner_id, personal) VALUES ($2, $1, TRUE)
+			ON CONFLICT (owner_id) WHERE personal DO NOTHING
+			RETURNING id
+		), owner AS (
+			INSERT INTO workspace_members (workspace_id, user_id, role) SELECT id, $1, 'owner' FROM created
+		)
+		SELECT id FROM created
+		UNION ALL
+		SELECT id FROM workspaces WHERE owner_id = $1 AND personal`
+	var workspaceID int
+	var err error
+	for range 2 {
+		err = q.QueryRowContext(ctx, query, userID, personalWorkspaceName).Scan(&workspaceID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+	}
+	return workspaceID, err
+}
+
+// addWorkspaceMember makes userID a member of the workspace of listID unless
+// they already belong to it. Everyone with access to a list is a member of
+// its workspace.
+func addWorkspaceMember(ctx context.Context, tx *sql.Tx, listID, userID int) error {
+	query := `INSERT INTO workspace_members (workspace_id, user_id, role)
+		SELECT workspace_id, $2, 'member' FROM task_lists WHERE id = $1
+		ON CONFLICT (workspace_id, user_id) DO NOTHING`
+	_, err := tx.ExecContext(ctx, query, listID, userID)
+	return err
+}
+
+func (r *PostgresWorkspaceRepository) CreateWorkspace(ctx context.Context, ws *models.Workspace) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.CreateWorkspace")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO workspaces (name, owner_id) VALUES ($1, $2) RETURNING id, created_at"
+	if err := tx.QueryRowContext(ctx, query, ws.Name, ws.OwnerID).Scan(&ws.ID, &ws.CreatedAt); err != nil {
+		return err
+	}
+	query = "INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)"
+	if _, err := tx.ExecContext(ctx, query, ws.ID, ws.OwnerID, models.WorkspaceRoleOwner); err != nil {
+		return err
+	}
+	ws.Role = models.WorkspaceRoleOwner
+	ws.MemberCount = 1
+
+	return tx.Commit()
+}
+
+const workspaceColumns = `w.id, w.name, w.owner_id, w.personal, m.role,
+	(SELECT COUNT(*) FROM workspace_members c WHERE c.workspace_id = w.id), w.created_at`
+
+func scanWorkspace(row rowScanner) (*models.Workspace, error) {
+	var ws models.Workspace
+	err := row.Scan(&ws.ID, &ws.Name, &ws.OwnerID, &ws.Personal, &ws.Role, &ws.MemberCount, &ws.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// ListWorkspaces returns the workspaces userID is a member of, the personal
+// workspace first.
+func (r *PostgresWorkspaceRepository) ListWorkspaces(ctx context.Context, userID int) ([]models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.ListWorkspaces")
+	defer span.End()
+
+	query := "SELECT " + workspaceColumns + ` FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $1
+		ORDER BY w.personal AND w.owner_id = $1 DESC, w.name, w.id`
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []models.Workspace{}
+	for rows.Next() {
+		ws, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, *ws)
+	}
+	return workspaces, rows.Err()
+}
+
+// GetWorkspace returns a workspace userID is a member of.
+func (r *PostgresWorkspaceRepository) GetWorkspace(ctx context.Context, workspaceID, userID int) (*models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.GetWorkspace")
+	defer span.End()
+
+	query := "SELECT " + workspaceColumns + ` FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = $2
+		WHERE w.id = $1`
+	ws, err := scanWorkspace(r.db.QueryRowContext(ctx, query, workspaceID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWorkspaceNotFound
+	}
+	return ws, err
+}
+
+func (r *PostgresWorkspaceRepository) RenameWorkspace(ctx context.Context, workspaceID int, name string) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.RenameWorkspace")
+	defer span.End()
+
+	n, err := rowsAffected(r.db.ExecContext(ctx, "UPDATE workspaces SET name = $2 WHERE id = $1", workspaceID, name))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWorkspaceNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWorkspaceRepository) DeleteWorkspace(ctx context.Context, workspaceID, actorID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.DeleteWorkspace")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	where := "t.list_id IN (SELECT id FROM task_lists WHERE workspace_id = $1)"
+	if err := recordTaskDeletions(ctx, tx, actorID, where, workspaceID); err != nil {
+		return err
+	}
+	n, err := rowsAffected(tx.ExecContext(ctx, "DELETE FROM workspaces WHERE id = $1", workspaceID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrWorkspaceNotFound
+	}
+	return tx.Commit()
+}
+
+// GetMemberRole returns the role of userID in workspaceID, or
+// ErrWorkspaceNotFound if the user is not a member.
+func (r *PostgresWorkspaceRepository) GetMemberRole(ctx context.Context, workspaceID, userID int) (models.WorkspaceRole, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.GetMemberRole")
+	defer span.End()
+
+	var role models.WorkspaceRole
+	query := "SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2"
+	err := r.db.QueryRowContext(ctx, query, workspaceID, userID).Scan(&role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrWorkspaceNotFound
+	}
+	return role, err
+}
+
+func (r *PostgresWorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.ListMembers")
+	defer span.End()
+
+	query := `SELECT m.user_id, u.username, m.role, m.created_at
+		FROM workspace_members m JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = $1 ORDER BY m.created_at, m.user_id`
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.WorkspaceMember{}
+	for rows.Next() {
+		var member models.WorkspaceMember
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Role, &member.CreatedAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+func (r *PostgresWorkspaceRepository) AddMember(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.AddMember")
+	defer span.End()
+
+	query := "INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)"
+	_, err := r.db.ExecContext(ctx, query, workspaceID, userID, role)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case uniqueViolation:
+			return ErrAlreadyWorkspaceMember
+		case foreignKeyViolation:
+			return ErrWorkspaceNotFound
+		}
+	}
+	return err
+}
+
+// UpdateMemberRole changes the role of a member other than the owner.
+func (r *PostgresWorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.UpdateMemberRole")
+	defer span.End()
+
+	query := "UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2 AND role <> 'owner'"
+	n, err := rowsAffected(r.db.ExecContext(ctx, query, workspaceID, userID, role))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotWorkspaceMember
+	}
+	return nil
+}
+
+func (r *PostgresWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceRepository.RemoveMember")
+	defer span.End()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2 AND role <> 'owner'"
+	n, err := rowsAffected(tx.ExecContext(ctx, query, workspaceID, userID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotWorkspaceMember
+	}
+
+	var ownsLists bool
+	query = "SELECT EXISTS (SELECT 1 FROM task_lists WHERE workspace_id = $1 AND owner_id = $2)"
+	if err := tx.QueryRowContext(ctx, query, workspaceID, userID).Scan(&ownsLists); err != nil {
+		return err
+	}
+	if ownsLists {
+		return ErrOwnsWorkspaceLists
+	}
+
+	// Leave every list of the workspace, as RemoveMember of the task list
+	// repository does for a single list.
+	where := "t.list_id IN (SELECT id FROM task_lists WHERE workspace_id = $1)"
+	if err := unassignTasks(ctx, tx, userID, where, workspaceID); err != nil {
+		return err
+	}
+	query = `DELETE FROM task_list_members m USING task_lists l
+		WHERE l.id = m.list_id AND l.workspace_id = $1 AND m.user_id = $2`
+	if _, err := tx.ExecContext(ctx, query, workspaceID, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+var (
+	ErrUserNotFound     = errors.New("User not found")
+	ErrCannotModifySelf = errors.New("Administrators cannot disable, delete or change the role of their own account")
+	ErrInvalidRole      = errors.New("Invalid role")
+	ErrRoleNotFound     = errors.New("Role not found")
+	ErrRoleExists       = errors.New("A role with this name already exists")
+	ErrBuiltinRole      = errors.New("Built-in roles cannot be changed or deleted")
+	ErrRoleInUse        = errors.New("Role is still assigned to users")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+type AdminServiceInterface interface {
+	ListUsers(ctx context.Context, query models.UserListQuery) (*models.UserList, error)
+	GetUser(ctx context.Context, id int) (*models.AdminUser, error)
+	DisableUser(ctx context.Context, actorID uint, id int) error
+	EnableUser(ctx context.Context, id int) error
+	DeleteUser(ctx context.Context, actorID uint, id int) error
+	SetUserRole(ctx context.Context, actorID uint, id int, role string) error
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	CreateRole(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error)
+	UpdateRole(ctx context.Context, name string, req models.UpdateRoleRequest) (*models.Role, error)
+	DeleteRole(ctx context.Context, name string) error
+	UsageStats(ctx context.Context) (*models.UsageStats, error)
+}
+
+type AdminService struct {
+	repo repositories.AdminRepository
+}
+
+func NewAdminService(repo repositories.AdminRepository) AdminServiceInterface {
+	return &AdminService{repo: repo}
+}
+
+// ListUsers returns one page of users. The page size defaults to 50 and is
+// capped at 200.
+func (s *AdminService) ListUsers(ctx context.Context, query models.UserListQuery) (*models.UserList, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.ListUsers")
+	defer span.End()
+
+	if query.Limit <= 0 {
+		query.Limit = defaultUserPageSize
+	}
+	query.Limit = min(query.Limit, maxUserPageSize)
+	query.Offset = max(query.Offset, 0)
+	query.Search = strings.TrimSpace(query.Search)
+
+	users, total, err := s.repo.ListUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserList{Users: users, Total: total, Limit: query.Limit, Offset: query.Offset}, nil
+}
+
+func (s *AdminService) GetUser(ctx context.Context, id int) (*models.AdminUser, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.GetUser")
+	defer span.End()
+
+	user, err := s.repo.GetUser(ctx, id)
+	return user, mapAdminError(err)
+}
+
+// DisableUser disables a user. The auth middleware rejects the user's
+// tokens from the next request on.
+func (s *AdminService) DisableUser(ctx context.Context, actorID uint, id int) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.DisableUser")
+	defer span.End()
+
+	if int(actorID) == id {
+		return ErrCannotModifySelf
+	}
+	if err := s.repo.SetUserDisabled(ctx, id, true); err != nil {
+		return mapAdminError(err)
+	}
+	logging.ContextLogger(ctx).Info("User disabled", "event", "user_disabled", "userID", id, "actorID", actorID)
+	return nil
+}
+
+func (s *AdminService) EnableUser(ctx context.Context, id int) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.EnableUser")
+	defer span.End()
+
+	return mapAdminError(s.repo.SetUserDisabled(ctx, id, false))
+}
+
+func (s *AdminService) DeleteUser(ctx context.Context, actorID uint, id int) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.DeleteUser")
+	defer span.End()
+
+	if int(actorID) == id {
+		return ErrCannotModifySelf
+	}
+	if err := s.repo.DeleteUser(ctx, id, int(actorID)); err != nil {
+		return mapAdminError(err)
+	}
+	logging.ContextLogger(ctx).Info("User deleted", "event", "user_deleted", "userID", id, "actorID", actorID)
+	return nil
+}
+
+// SetUserRole assigns role to a user. Administrators cannot change their
+// own role so they cannot lock themselves out by accident.
+func (s *AdminService) SetUserRole(ctx context.Context, actorID uint, id int, role string) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.SetUserRole")
+	defer span.End()
+
+	if int(actorID) == id {
+		return ErrCannotModifySelf
+	}
+	if err := s.repo.SetUserRole(ctx, id, role); err != nil {
+		return mapAdminError(err)
+	}
+	logging.ContextLogger(ctx).Info("User role changed", "event", "user_role_changed", "userID", id, "role", role, "actorID", actorID)
+	return nil
+}
+
+func (s *AdminService) ListRoles(ctx context.Context) ([]models.Role, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.ListRoles")
+	defer span.End()
+
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *AdminService) CreateRole(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.CreateRole")
+	defer span.End()
+
+	if !roleNamePattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: name must be 1 to 64 lowercase letters, digits, '-' or '_'", ErrInvalidRole)
+	}
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: req.Name, Description: strings.TrimSpace(req.Description), Permissions: permissions}
+	if err := s.repo.CreateRole(ctx, role); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return role, nil
+}
+
+// UpdateRole replaces the description and permissions of a custom role.
+// Users holding the role get the new permissions on their next request.
+func (s *AdminService) UpdateRole(ctx context.Context, name string, req models.UpdateRoleRequest) (*models.Role, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.UpdateRole")
+	defer span.End()
+
+	permissions, err := normalizePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{Name: name, Description: strings.TrimSpace(req.Description), Permissions: permissions}
+	if err := s.repo.UpdateRole(ctx, role); err != nil {
+		return nil, mapAdminError(err)
+	}
+	return role, nil
+}
+
+func (s *AdminService) DeleteRole(ctx context.Context, name string) error {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.DeleteRole")
+	defer span.End()
+
+	return mapAdminError(s.repo.DeleteRole(ctx, name))
+}
+
+func (s *AdminService) UsageStats(ctx context.Context) (*models.UsageStats, error) {
+	_, span := otel.Tracer("").Start(ctx, "AdminService.UsageStats")
+	defer span.End()
+
+	return s.repo.GetUsageStats(ctx)
+}
+
+// normalizePermissions rejects unknown permissions and returns the others
+// sorted and without duplicates.
+func normalizePermissions(permissions []string) ([]string, error) {
+	result := []string{}
+	for _, p := range permissions {
+		if !rbac.IsSupported(p) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidRole, p)
+		}
+		result = append(result, p)
+	}
+	slices.Sort(result)
+	return slices.Compact(result), nil
+}
+
+// mapAdminError translates repository errors into service errors.
+func mapAdminError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrUserNotFound):
+		return ErrUserNotFound
+	case errors.Is(err, repositories.ErrRoleNotFound):
+		return ErrRoleNotFound
+	case errors.Is(err, repositories.ErrRoleExists):
+		return ErrRoleExists
+	case errors.Is(err, repositories.ErrRoleBuiltin):
+		return ErrBuiltinRole
+	case errors.Is(err, repositories.ErrRoleInUse):
+		return ErrRoleInUse
+	default:
+		return err
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+)
+
+// MockAdminRepository is a mock implementation of the AdminRepository interface
+type MockAdminRepository struct {
+	mock.Mock
+}
+
+func (m *MockAdminRepository) ListUsers(ctx context.Context, query models.UserListQuery) ([]models.AdminUser, int, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]models.AdminUser), args.Int(1), args.Error(2)
+}
+
+func (m *MockAdminRepository) GetUser(ctx context.Context, id int) (*models.AdminUser, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AdminUser), args.Error(1)
+}
+
+func (m *MockAdminRepository) SetUserDisabled(ctx context.Context, id int, disabled bool) error {
+	args := m.Called(ctx, id, disabled)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) SetUserRole(ctx context.Context, id int, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) DeleteUser(ctx context.Context, id, actorID int) error {
+	args := m.Called(ctx, id, actorID)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) ListRoles(ctx context.Context) ([]models.Role, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Role), args.Error(1)
+}
+
+func (m *MockAdminRepository) CreateRole(ctx context.Context, role *models.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) UpdateRole(ctx context.Context, role *models.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+func (m *MockAdminRepository) GetUsageStats(ctx context.Context) (*models.UsageStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsageStats), args.Error(1)
+}
+
+func TestAdminService_ListUsers_Paging(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("ListUsers", ctx, models.UserListQuery{Search: "bob", Limit: defaultUserPageSize}).Return([]models.AdminUser{{ID: 2}}, 1, nil)
+	mockRepo.On("ListUsers", ctx, models.UserListQuery{Limit: maxUserPageSize, Offset: 0}).Return([]models.AdminUser{}, 1, nil)
+
+	list, err := service.ListUsers(ctx, models.UserListQuery{Search: " bob "})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, defaultUserPageSize, list.Limit)
+
+	list, err = service.ListUsers(ctx, models.UserListQuery{Limit: 10000, Offset: -5})
+	assert.NoError(t, err)
+	assert.Equal(t, maxUserPageSize, list.Limit)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdminService_CannotModifySelf(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.DisableUser(ctx, 1, 1), ErrCannotModifySelf)
+	assert.ErrorIs(t, service.DeleteUser(ctx, 1, 1), ErrCannotModifySelf)
+	assert.ErrorIs(t, service.SetUserRole(ctx, 1, 1, rbac.RoleUser), ErrCannotModifySelf)
+	mockRepo.AssertNotCalled(t, "SetUserDisabled", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "DeleteUser", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "SetUserRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminService_DisableUser(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SetUserDisabled", ctx, 2, true).Return(nil)
+	mockRepo.On("SetUserDisabled", ctx, 3, true).Return(repositories.ErrUserNotFound)
+
+	assert.NoError(t, service.DisableUser(ctx, 1, 2))
+	assert.ErrorIs(t, service.DisableUser(ctx, 1, 3), ErrUserNotFound)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdminService_SetUserRole_UnknownRole(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("SetUserRole", ctx, 2, "nope").Return(repositories.ErrRoleNotFound)
+
+	assert.ErrorIs(t, service.SetUserRole(ctx, 1, 2, "nope"), ErrRoleNotFound)
+}
+
+func TestAdminService_CreateRole(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("CreateRole", ctx, &models.Role{Name: "support", Description: "Support staff", Permissions: []string{rbac.UsersManage, rbac.UsersRead}}).Return(nil)
+
+	role, err := service.CreateRole(ctx, models.CreateRoleRequest{
+		Name:        "support",
+		Description: " Support staff ",
+		Permissions: []string{rbac.UsersRead, rbac.UsersManage, rbac.UsersRead},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{rbac.UsersManage, rbac.UsersRead}, role.Permissions)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAdminService_CreateRole_Invalid(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  models.CreateRoleRequest
+	}{
+		{"uppercase name", models.CreateRoleRequest{Name: "Support"}},
+		{"empty name", models.CreateRoleRequest{Name: ""}},
+		{"unknown permission", models.CreateRoleRequest{Name: "support", Permissions: []string{"tasks:delete_all"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateRole(ctx, tt.req)
+			assert.ErrorIs(t, err, ErrInvalidRole)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+}
+
+func TestAdminService_RoleErrors(t *testing.T) {
+	mockRepo := new(MockAdminRepository)
+	service := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.On("DeleteRole", ctx, rbac.RoleAdmin).Return(repositories.ErrRoleBuiltin)
+	mockRepo.On("DeleteRole", ctx, "support").Return(repositories.ErrRoleInUse)
+	mockRepo.On("UpdateRole", ctx, mock.Anything).Return(repositories.ErrRoleNotFound)
+
+	assert.ErrorIs(t, service.DeleteRole(ctx, rbac.RoleAdmin), ErrBuiltinRole)
+	assert.ErrorIs(t, service.DeleteRole(ctx, "support"), ErrRoleInUse)
+	_, err := service.UpdateRole(ctx, "missing", models.UpdateRoleRequest{})
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+}
@@ -3,60 +3,390 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 	"go.opentelemetry.io/otel"
-	"golang.org/x/crypto/bcrypt"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const emailVerificationTokenTTL = 72 * time.Hour
+
+var (
+	ErrInvalidUsername          = errors.New("Username must not contain '@'")
+	ErrEmailNotVerified         = errors.New("Email address has not been verified")
+	ErrInvalidVerificationToken = errors.New("Verification token is invalid or expired")
+	ErrEmailAlreadyVerified     = errors.New("Email address is already verified")
+	ErrAccountDisabled          = errors.New("Account is disabled")
+)
+
+// UnverifiedLoginMode controls whether accounts with an unverified email
+// address may log in.
+type UnverifiedLoginMode string
+
+const (
+	// UnverifiedLoginAllow lets unverified accounts log in without restriction.
+	UnverifiedLoginAllow UnverifiedLoginMode = "allow"
+	// UnverifiedLoginGrace lets unverified accounts log in for a grace period
+	// after signup.
+	UnverifiedLoginGrace UnverifiedLoginMode = "grace"
+	// UnverifiedLoginDeny rejects unverified accounts.
+	UnverifiedLoginDeny UnverifiedLoginMode = "deny"
+)
+
+// UnverifiedPolicy describes how accounts with an unverified email address
+// are treated at login.
+type UnverifiedPolicy struct {
+	Mode        UnverifiedLoginMode
+	GracePeriod time.Duration
+}
+
+// Allows reports whether user may log in under the policy at time now.
+func (p UnverifiedPolicy) Allows(user *models.User, now time.Time) bool {
+	if user.EmailVerified || user.Email == "" {
+		return true
+	}
+	switch p.Mode {
+	case UnverifiedLoginDeny:
+		return false
+	case UnverifiedLoginGrace:
+		return now.Before(user.CreatedAt.Add(p.GracePeriod))
+	default:
+		return true
+	}
+}
+
 type AuthServiceInterface interface {
-	Login(ctx context.Context, username, password string) (string, error)
-	Signup(ctx context.Context, username, password string) error
+	Login(ctx context.Context, login, password, clientIP string) (string, error)
+	Signup(ctx context.Context, username, email, password, invitationToken string) (*models.TaskList, error)
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID uint) error
+	AccountStatus(ctx context.Context, userID int) (*rbac.Account, error)
+	// SetTimeZone stores the IANA time zone snooze presets are resolved in.
+	SetTimeZone(ctx context.Context, userID uint, timeZone string) error
 }
 
 type AuthService struct {
-	repo repositories.AuthRepository
+	repo             repositories.AuthRepository
+	keys             *jwtkeys.Manager
+	mailer           mailer.Mailer
+	verifyURL        string
+	unverifiedPolicy UnverifiedPolicy
+	guard            *LoginGuard
+	passwordPolicy   password.Policy
+	hasher           password.PasswordHasher
+	invitations      InvitationServiceInterface
+}
+
+// AuthOption configures optional AuthService behaviour.
+type AuthOption func(*AuthService)
+
+// WithMailer sets the mailer used for verification emails. verifyURL is the
+// frontend page that receives the token as its "token" query parameter.
+func WithMailer(m mailer.Mailer, verifyURL string) AuthOption {
+	return func(s *AuthService) {
+		s.mailer = m
+		s.verifyURL = verifyURL
+	}
+}
+
+// WithUnverifiedPolicy sets the login policy for unverified accounts.
+func WithUnverifiedPolicy(p UnverifiedPolicy) AuthOption {
+	return func(s *AuthService) {
+		s.unverifiedPolicy = p
+	}
+}
+
+// WithPasswordPolicy sets the policy new passwords must satisfy.
+func WithPasswordPolicy(p password.Policy) AuthOption {
+	return func(s *AuthService) {
+		s.passwordPolicy = p
+	}
+}
+
+// WithPasswordHasher sets the hasher for new passwords. Stored hashes the
+// hasher reports as outdated are replaced on the next successful login.
+func WithPasswordHasher(h password.PasswordHasher) AuthOption {
+	return func(s *AuthService) {
+		s.hasher = h
+	}
+}
+
+// WithInvitations lets new users redeem a list invitation at signup.
+func WithInvitations(inv InvitationServiceInterface) AuthOption {
+	return func(s *AuthService) {
+		s.invitations = inv
+	}
 }
 
-func NewAuthService(repo repositories.AuthRepository) AuthServiceInterface {
-	return &AuthService{repo: repo}
+// WithLoginGuard enables brute-force protection for Login.
+func WithLoginGuard(g *LoginGuard) AuthOption {
+	return func(s *AuthService) {
+		s.guard = g
+	}
+}
+
+// NewAuthService creates an AuthService that signs access tokens with keys.
+func NewAuthService(repo repositories.AuthRepository, keys *jwtkeys.Manager, opts ...AuthOption) AuthServiceInterface {
+	s := &AuthService{
+		repo:             repo,
+		keys:             keys,
+		mailer:           mailer.NewLogMailer(),
+		unverifiedPolicy: UnverifiedPolicy{Mode: UnverifiedLoginAllow},
+		passwordPolicy:   password.DefaultPolicy(),
+		hasher:           password.DefaultHasher(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *AuthService) Login(ctx context.Context, username, password string) (string, error) {
+// Login authenticates a user by username or email address. clientIP is used
+// for brute-force protection when a LoginGuard is configured.
+func (s *AuthService) Login(ctx context.Context, login, password, clientIP string) (string, error) {
 	_, span := otel.Tracer("").Start(ctx, "AuthService.Login")
 	defer span.End()
 
 	utils.RandomSleep()
-	user, err := s.repo.GetUserByUsername(ctx, username)
+	user, err := s.repo.GetUserByLogin(ctx, login)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		user = nil
+	} else if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to look up user for login", "error", err)
+		span.SetAttributes(attribute.String("login.result", "error"))
+		return "", errors.New("Failed to log in")
+	}
+	accountKey := accountAttemptKey(user, login)
+
+	var claim *LoginClaim
+	if s.guard != nil {
+		claim, err = s.guard.Check(ctx, accountKey, clientIP)
+		if err != nil {
+			span.SetAttributes(attribute.String("login.result", "throttled"))
+			return "", err
+		}
+	}
+
+	// Accounts created through an identity provider may have no password.
+	if user == nil || user.Password == "" {
+		span.SetAttributes(attribute.String("login.result", "failure"))
+		return "", s.loginFailed(ctx, claim)
+	}
+
+	ok, err := s.hasher.Verify(password, user.Password)
 	if err != nil {
-		return "", errors.New("Invalid credentials")
+		logging.ContextLogger(ctx).Error("Failed to verify password hash", "userID", user.ID, "error", err)
+	}
+	if !ok {
+		span.SetAttributes(attribute.String("login.result", "failure"))
+		return "", s.loginFailed(ctx, claim)
+	}
+
+	if s.guard != nil {
+		if err := s.guard.RecordSuccess(ctx, claim); err != nil {
+			logging.ContextLogger(ctx).Error("Failed to reset login attempts", "userID", user.ID, "error", err)
+		}
+	}
+
+	if user.Disabled {
+		span.SetAttributes(attribute.String("login.result", "disabled"))
+		return "", ErrAccountDisabled
+	}
+
+	if !s.unverifiedPolicy.Allows(user, time.Now()) {
+		span.SetAttributes(attribute.String("login.result", "unverified"))
+		return "", ErrEmailNotVerified
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", errors.New("Invalid credentials")
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehash(ctx, user.ID, password)
 	}
 
-	token, err := utils.GenerateToken(user.ID)
+	token, err := utils.GenerateToken(s.keys, user.ID, user.Role, user.Permissions)
 	if err != nil {
 		return "", errors.New("Failed to generate token")
 	}
 
+	span.SetAttributes(attribute.String("login.result", "success"))
 	return token, nil
 }
 
-func (s *AuthService) Signup(ctx context.Context, username, password string) error {
+// rehash replaces a stored hash that uses an outdated algorithm or outdated
+// parameters. Failures are logged only; the login itself has succeeded.
+func (s *AuthService) rehash(ctx context.Context, userID int, password string) {
+	hash, err := s.hasher.Hash(password)
+	if err == nil {
+		err = s.repo.UpdatePassword(ctx, userID, hash)
+	}
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to rehash password", "userID", userID, "error", err)
+		return
+	}
+	logging.ContextLogger(ctx).Info("Password rehashed", "event", "password_rehashed", "userID", userID)
+}
+
+func (s *AuthService) loginFailed(ctx context.Context, claim *LoginClaim) error {
+	if s.guard == nil {
+		return &LoginError{Err: ErrInvalidCredentials}
+	}
+	return s.guard.RecordFailure(ctx, claim)
+}
+
+// Signup creates an account. With an invitation token the new user also
+// joins the invited list, which is returned. An invitation that was mailed to
+// the signup address counts as proof of the address, so no verification mail
+// is sent in that case.
+func (s *AuthService) Signup(ctx context.Context, username, email, password, invitationToken string) (*models.TaskList, error) {
 	_, span := otel.Tracer("").Start(ctx, "AuthService.Signup")
 	defer span.End()
 
+	// Usernames and email addresses share the login field, so a username
+	// must never look like an email address.
+	if strings.Contains(username, "@") {
+		return nil, ErrInvalidUsername
+	}
+
+	if err := s.passwordPolicy.Validate(ctx, password, username); err != nil {
+		return nil, err
+	}
+
+	user := &models.User{Username: username, Email: strings.TrimSpace(email)}
+
+	// Check the invitation before creating the account so that a stale link
+	// does not leave the user with an account but without the list.
+	if invitationToken != "" {
+		if s.invitations == nil {
+			return nil, ErrInvalidInvitation
+		}
+		inv, err := s.invitations.PreviewInvitation(ctx, invitationToken)
+		if err != nil {
+			return nil, err
+		}
+		user.EmailVerified = inv.Email != "" && strings.EqualFold(inv.Email, user.Email)
+	}
+
 	utils.RandomSleep()
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return nil, errors.New("Failed to hash password")
+	}
+	user.Password = hashedPassword
+
+	if err := s.repo.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	if user.Email != "" && !user.EmailVerified {
+		if err := s.sendVerification(ctx, user); err != nil {
+			logging.ContextLogger(ctx).Error("Failed to send verification mail", "userID", user.ID, "error", err)
+		}
+	}
+
+	if invitationToken == "" {
+		return nil, nil
+	}
+	// The account exists at this point, so a failure (for example a
+	// concurrent revocation) must not fail the signup.
+	list, err := s.invitations.AcceptInvitation(ctx, uint(user.ID), invitationToken)
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to accept invitation at signup", "userID", user.ID, "error", err)
+		return nil, nil
+	}
+	return list, nil
+}
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthService.VerifyEmail")
+	defer span.End()
+
+	err := s.repo.VerifyEmail(ctx, utils.HashToken(token))
+	if errors.Is(err, repositories.ErrVerificationTokenInvalid) {
+		return ErrInvalidVerificationToken
+	}
+	return err
+}
+
+func (s *AuthService) ResendVerification(ctx context.Context, userID uint) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthService.ResendVerification")
+	defer span.End()
+
+	user, err := s.repo.GetUserByID(ctx, int(userID))
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified || user.Email == "" {
+		return ErrEmailAlreadyVerified
+	}
+
+	return s.sendVerification(ctx, user)
+}
+
+func (s *AuthService) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(emailVerificationTokenTTL)
+	if err := s.repo.CreateEmailVerificationToken(ctx, user.ID, utils.HashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Welcome, %s!\n\nPlease confirm your email address by opening the following link:\n%s\n",
+			user.Username, tokenLink(s.verifyURL, token)),
+	})
+}
+
+// tokenLink appends token as the "token" query parameter of base.
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
 	if err != nil {
-		return errors.New("Failed to hash password")
+		return base + "?token=" + url.QueryEscape(token)
 	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
 
-	user := &models.User{Username: username, Password: string(hashedPassword)}
+// AccountStatus returns the current role, permissions and disabled state of
+// a user. It is consulted by the auth middleware on every request so that
+// role changes and disabled accounts take effect immediately.
+func (s *AuthService) AccountStatus(ctx context.Context, userID int) (*rbac.Account, error) {
+	_, span := otel.Tracer("").Start(ctx, "AuthService.AccountStatus")
+	defer span.End()
 
-	return s.repo.CreateUser(ctx, user)
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, rbac.ErrAccountNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rbac.Account{Role: user.Role, Permissions: user.Permissions, Disabled: user.Disabled}, nil
+}
+
+func (s *AuthService) SetTimeZone(ctx context.Context, userID uint, timeZone string) error {
+	_, span := otel.Tracer("").Start(ctx, "AuthService.SetTimeZone")
+	defer span.End()
+
+	loc, err := LoadTimeZone(timeZone)
+	if err != nil {
+		return err
+	}
+	return s.repo.SetTimeZone(ctx, int(userID), loc.String())
 }
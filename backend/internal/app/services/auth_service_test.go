@@ -3,15 +3,21 @@ package services
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
-	
-	
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 )
 
 // MockAuthRepository is a mock implementation of the AuthRepository interface
@@ -27,14 +33,77 @@ func (m *MockAuthRepository) GetUserByUsername(ctx context.Context, username str
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockAuthRepository) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	args := m.Called(ctx, login)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) GetUserByID(ctx context.Context, id int) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockAuthRepository) CreateUser(ctx context.Context, user *models.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
+func (m *MockAuthRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) CreatePasswordResetToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockAuthRepository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) error {
+	args := m.Called(ctx, tokenHash, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) CreateEmailVerificationToken(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) VerifyEmail(ctx context.Context, tokenHash string) error {
+	args := m.Called(ctx, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepository) SetTimeZone(ctx context.Context, userID int, timeZone string) error {
+	args := m.Called(ctx, userID, timeZone)
+	return args.Error(0)
+}
+
+func newTestKeys() *jwtkeys.Manager {
+	keys, err := jwtkeys.NewEphemeralManager("test")
+	if err != nil {
+		panic(err)
+	}
+	return keys
+}
+
 func TestAuthService_Login(t *testing.T) {
 	mockRepo := new(MockAuthRepository)
-	authService := NewAuthService(mockRepo)
+	keys := newTestKeys()
+	authService := NewAuthService(mockRepo, keys)
 
 	ctx := context.Background()
 	username := "testuser"
@@ -42,18 +111,52 @@ func TestAuthService_Login(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 
 	user := &models.User{ID: 1, Username: username, Password: string(hashedPassword)}
-	mockRepo.On("GetUserByUsername", ctx, username).Return(user, nil)
+	mockRepo.On("GetUserByLogin", ctx, username).Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, 1, mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$argon2id$")
+	})).Return(nil)
 
-	token, err := authService.Login(ctx, username, password)
+	token, err := authService.Login(ctx, username, password, "127.0.0.1")
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
+	userID, err := utils.ValidateToken(keys, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, userID)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestAuthService_Login_NoPassword(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+
+	mockRepo.On("GetUserByLogin", ctx, "oidcuser").Return(&models.User{ID: 1, Username: "oidcuser"}, nil)
+
+	_, err := authService.Login(ctx, "oidcuser", "", "127.0.0.1")
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestAuthService_Login_CurrentHashNotRehashed(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	hasher := password.NewMultiHasher(password.NewArgon2idHasher(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}))
+	authService := NewAuthService(mockRepo, newTestKeys(), WithPasswordHasher(hasher))
+
+	ctx := context.Background()
+	hash, _ := hasher.Hash("password123")
+	user := &models.User{ID: 1, Username: "testuser", Password: hash}
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+
+	token, err := authService.Login(ctx, "testuser", "password123", "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestAuthService_Login_InvalidCredentials(t *testing.T) {
 	mockRepo := new(MockAuthRepository)
-	authService := NewAuthService(mockRepo)
+	authService := NewAuthService(mockRepo, newTestKeys())
 
 	ctx := context.Background()
 	username := "testuser"
@@ -61,26 +164,92 @@ func TestAuthService_Login_InvalidCredentials(t *testing.T) {
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
 
 	user := &models.User{ID: 1, Username: username, Password: string(hashedPassword)}
-	mockRepo.On("GetUserByUsername", ctx, username).Return(user, nil)
+	mockRepo.On("GetUserByLogin", ctx, username).Return(user, nil)
 
-	_, err := authService.Login(ctx, username, password)
+	_, err := authService.Login(ctx, username, password, "127.0.0.1")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "Invalid credentials")
 	mockRepo.AssertExpectations(t)
 }
 
+func TestAuthService_Login_CarriesRole(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	hasher := password.NewMultiHasher(password.NewArgon2idHasher(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}))
+	keys := newTestKeys()
+	authService := NewAuthService(mockRepo, keys, WithPasswordHasher(hasher))
+
+	ctx := context.Background()
+	hash, _ := hasher.Hash("password123")
+	user := &models.User{ID: 1, Username: "admin", Password: hash, Role: rbac.RoleAdmin, Permissions: []string{rbac.UsersRead}}
+	mockRepo.On("GetUserByLogin", ctx, "admin").Return(user, nil)
+
+	token, err := authService.Login(ctx, "admin", "password123", "127.0.0.1")
+
+	assert.NoError(t, err)
+	claims, err := utils.ParseToken(keys, token)
+	assert.NoError(t, err)
+	assert.Equal(t, rbac.RoleAdmin, claims.Role)
+	assert.Equal(t, []string{rbac.UsersRead}, claims.Permissions)
+}
+
+func TestAuthService_Login_Disabled(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	hasher := password.NewMultiHasher(password.NewArgon2idHasher(password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}))
+	authService := NewAuthService(mockRepo, newTestKeys(), WithPasswordHasher(hasher))
+
+	ctx := context.Background()
+	hash, _ := hasher.Hash("password123")
+	user := &models.User{ID: 1, Username: "testuser", Password: hash, Disabled: true}
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+
+	_, err := authService.Login(ctx, "testuser", "password123", "127.0.0.1")
+
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+}
+
+func TestAuthService_AccountStatus(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+
+	mockRepo.On("GetUserByID", ctx, 1).Return(&models.User{ID: 1, Role: rbac.RoleAdmin, Permissions: []string{rbac.StatsRead}, Disabled: true}, nil)
+	mockRepo.On("GetUserByID", ctx, 2).Return(nil, repositories.ErrUserNotFound)
+
+	account, err := authService.AccountStatus(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, &rbac.Account{Role: rbac.RoleAdmin, Permissions: []string{rbac.StatsRead}, Disabled: true}, account)
+
+	_, err = authService.AccountStatus(ctx, 2)
+	assert.ErrorIs(t, err, rbac.ErrAccountNotFound)
+}
+
+func TestAuthService_SetTimeZone(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+
+	mockRepo.On("SetTimeZone", ctx, 1, "Europe/Berlin").Return(nil)
+
+	assert.NoError(t, authService.SetTimeZone(ctx, 1, "Europe/Berlin"))
+	assert.ErrorIs(t, authService.SetTimeZone(ctx, 1, "Europe/Atlantis"), ErrInvalidTimeZone)
+	assert.ErrorIs(t, authService.SetTimeZone(ctx, 1, "Local"), ErrInvalidTimeZone)
+	mockRepo.AssertNumberOfCalls(t, "SetTimeZone", 1)
+}
+
 func TestAuthService_Signup(t *testing.T) {
 	mockRepo := new(MockAuthRepository)
-	authService := NewAuthService(mockRepo)
+	authService := NewAuthService(mockRepo, newTestKeys())
 
 	ctx := context.Background()
 	username := "newuser"
+	email := "newuser@example.com"
 	password := "newpassword123"
 
 	mockRepo.On("CreateUser", ctx, mock.AnythingOfType("*models.User")).Return(nil)
+	mockRepo.On("CreateEmailVerificationToken", ctx, mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
 
-	err := authService.Signup(ctx, username, password)
+	_, err := authService.Signup(ctx, username, email, password, "")
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -88,17 +257,129 @@ func TestAuthService_Signup(t *testing.T) {
 
 func TestAuthService_Signup_CreateUserError(t *testing.T) {
 	mockRepo := new(MockAuthRepository)
-	authService := NewAuthService(mockRepo)
+	authService := NewAuthService(mockRepo, newTestKeys())
 
 	ctx := context.Background()
 	username := "newuser"
+	email := "newuser@example.com"
 	password := "newpassword123"
 
 	mockRepo.On("CreateUser", ctx, mock.AnythingOfType("*models.User")).Return(errors.New("db error"))
 
-	err := authService.Signup(ctx, username, password)
+	_, err := authService.Signup(ctx, username, email, password, "")
 
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "db error")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestAuthService_Login_ByEmail(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+
+	user := &models.User{ID: 1, Username: "testuser", Email: "test@example.com", EmailVerified: true, Password: string(hashedPassword)}
+	mockRepo.On("GetUserByLogin", ctx, "TEST@example.com").Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, 1, mock.AnythingOfType("string")).Return(nil)
+
+	token, err := authService.Login(ctx, "TEST@example.com", "password123", "127.0.0.1")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Login_UnverifiedPolicy(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+
+	tests := []struct {
+		name      string
+		policy    UnverifiedPolicy
+		createdAt time.Time
+		wantErr   error
+	}{
+		{"allow", UnverifiedPolicy{Mode: UnverifiedLoginAllow}, time.Now().Add(-30 * 24 * time.Hour), nil},
+		{"deny", UnverifiedPolicy{Mode: UnverifiedLoginDeny}, time.Now(), ErrEmailNotVerified},
+		{"grace period running", UnverifiedPolicy{Mode: UnverifiedLoginGrace, GracePeriod: 24 * time.Hour}, time.Now().Add(-time.Hour), nil},
+		{"grace period over", UnverifiedPolicy{Mode: UnverifiedLoginGrace, GracePeriod: 24 * time.Hour}, time.Now().Add(-48 * time.Hour), ErrEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAuthRepository)
+			authService := NewAuthService(mockRepo, newTestKeys(), WithUnverifiedPolicy(tt.policy))
+
+			ctx := context.Background()
+			user := &models.User{ID: 1, Username: "testuser", Email: "test@example.com", Password: string(hashedPassword), CreatedAt: tt.createdAt}
+			mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+			mockRepo.On("UpdatePassword", ctx, 1, mock.AnythingOfType("string")).Return(nil).Maybe()
+
+			_, err := authService.Login(ctx, "testuser", "password123", "127.0.0.1")
+
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthService_Signup_SendsVerification(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockMailer := new(MockMailer)
+	authService := NewAuthService(mockRepo, newTestKeys(), WithMailer(mockMailer, "http://localhost/verify"))
+
+	ctx := context.Background()
+
+	mockRepo.On("CreateUser", ctx, mock.AnythingOfType("*models.User")).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 7 }).
+		Return(nil)
+	mockRepo.On("CreateEmailVerificationToken", ctx, 7, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(nil)
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "new@example.com" && strings.Contains(msg.Body, "http://localhost/verify?token=")
+	})).Return(nil)
+
+	_, err := authService.Signup(ctx, "newuser", "new@example.com", "newpassword123", "")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestAuthService_Signup_RejectsAtInUsername(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+
+	_, err := authService.Signup(context.Background(), "someone@example.com", "someone@example.com", "newpassword123", "")
+
+	assert.ErrorIs(t, err, ErrInvalidUsername)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_VerifyEmail_InvalidToken(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+
+	ctx := context.Background()
+	mockRepo.On("VerifyEmail", ctx, utils.HashToken("token")).Return(repositories.ErrVerificationTokenInvalid)
+
+	err := authService.VerifyEmail(ctx, "token")
+
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Signup_PasswordPolicy(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys())
+
+	_, err := authService.Signup(context.Background(), "newuser", "new@example.com", "a", "")
+
+	var vErr *password.ValidationError
+	assert.ErrorAs(t, err, &vErr)
+	assert.Equal(t, password.RuleMinLength, vErr.Violations[0].Rule)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+var ErrCalendarFeedNotFound = errors.New("Calendar feed not found")
+
+// CalendarServiceInterface manages the iCalendar feeds that calendar apps
+// subscribe to. A feed lists every task of the user's lists as a VTODO,
+// like an export in models.TransferICS, and is read with the secret token
+// in its URL instead of a login.
+type CalendarServiceInterface interface {
+	GetFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error)
+	// CreateFeed creates the user's feed, or gives it a new URL so that the
+	// old one stops working. The URL is only returned here.
+	CreateFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error)
+	DeleteFeed(ctx context.Context, userID uint) error
+	// WriteFeed writes the feed with token to w.
+	WriteFeed(ctx context.Context, token string, w io.Writer) error
+}
+
+type CalendarService struct {
+	repo      repositories.CalendarFeedRepository
+	transfers TransferServiceInterface
+	feedURL   string
+}
+
+// NewCalendarService returns a calendar service whose feeds are served at
+// feedURL followed by "/<token>.ics".
+func NewCalendarService(repo repositories.CalendarFeedRepository, transfers TransferServiceInterface, feedURL string) CalendarServiceInterface {
+	return &CalendarService{repo: repo, transfers: transfers, feedURL: strings.TrimSuffix(feedURL, "/")}
+}
+
+func mapCalendarFeedError(err error) error {
+	if errors.Is(err, repositories.ErrCalendarFeedNotFound) {
+		return ErrCalendarFeedNotFound
+	}
+	return err
+}
+
+func (s *CalendarService) GetFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error) {
+	_, span := otel.Tracer("").Start(ctx, "CalendarService.GetFeed")
+	defer span.End()
+
+	feed, err := s.repo.GetFeed(ctx, int(userID))
+	return feed, mapCalendarFeedError(err)
+}
+
+func (s *CalendarService) CreateFeed(ctx context.Context, userID uint) (*models.CalendarFeed, error) {
+	_, span := otel.Tracer("").Start(ctx, "CalendarService.CreateFeed")
+	defer span.End()
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	feed, err := s.repo.SetFeed(ctx, int(userID), utils.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	feed.URL = s.feedURL + "/" + token + ".ics"
+	return feed, nil
+}
+
+func (s *CalendarService) DeleteFeed(ctx context.Context, userID uint) error {
+	_, span := otel.Tracer("").Start(ctx, "CalendarService.DeleteFeed")
+	defer span.End()
+
+	return mapCalendarFeedError(s.repo.DeleteFeed(ctx, int(userID)))
+}
+
+func (s *CalendarService) WriteFeed(ctx context.Context, token string, w io.Writer) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CalendarService.WriteFeed")
+	defer span.End()
+
+	if token == "" {
+		return ErrCalendarFeedNotFound
+	}
+	userID, err := s.repo.FeedUser(ctx, utils.HashToken(token))
+	if err != nil {
+		return mapCalendarFeedError(err)
+	}
+	return s.transfers.Export(ctx, uint(userID), models.TransferICS, w)
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+type MockCalendarFeedRepository struct {
+	mock.Mock
+}
+
+var _ repositories.CalendarFeedRepository = (*MockCalendarFeedRepository)(nil)
+
+func (m *MockCalendarFeedRepository) SetFeed(ctx context.Context, userID int, tokenHash string) (*models.CalendarFeed, error) {
+	args := m.Called(ctx, userID, tokenHash)
+	feed, _ := args.Get(0).(*models.CalendarFeed)
+	return feed, args.Error(1)
+}
+
+func (m *MockCalendarFeedRepository) GetFeed(ctx context.Context, userID int) (*models.CalendarFeed, error) {
+	args := m.Called(ctx, userID)
+	feed, _ := args.Get(0).(*models.CalendarFeed)
+	return feed, args.Error(1)
+}
+
+func (m *MockCalendarFeedRepository) DeleteFeed(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockCalendarFeedRepository) FeedUser(ctx context.Context, tokenHash string) (int, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Int(0), args.Error(1)
+}
+
+func newTestCalendarService() (CalendarServiceInterface, *MockCalendarFeedRepository, *transferTestEnv) {
+	repo := new(MockCalendarFeedRepository)
+	env := newTransferTestEnv()
+	return NewCalendarService(repo, env.service, "https://todo.example.com/calendar/"), repo, env
+}
+
+func TestCalendarService_CreateFeed(t *testing.T) {
+	service, repo, _ := newTestCalendarService()
+	createdAt := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	var tokenHash string
+	repo.On("SetFeed", mock.Anything, 1, mock.Anything).Run(func(args mock.Arguments) {
+		tokenHash = args.String(2)
+	}).Return(&models.CalendarFeed{CreatedAt: createdAt}, nil)
+
+	feed, err := service.CreateFeed(context.Background(), 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, createdAt, feed.CreatedAt)
+	require.True(t, strings.HasPrefix(feed.URL, "https://todo.example.com/calendar/"), feed.URL)
+	require.True(t, strings.HasSuffix(feed.URL, ".ics"), feed.URL)
+	token := strings.TrimSuffix(strings.TrimPrefix(feed.URL, "https://todo.example.com/calendar/"), ".ics")
+	assert.Len(t, token, 43)
+	// Only the hash of the token is stored.
+	assert.Equal(t, utils.HashToken(token), tokenHash)
+}
+
+func TestCalendarService_WriteFeed(t *testing.T) {
+	service, repo, env := newTestCalendarService()
+	token := "secret-token"
+	repo.On("FeedUser", mock.Anything, utils.HashToken(token)).Return(2, nil)
+	env.tasks.On("EachTask", mock.Anything, uint(2), models.TaskFilter{IncludeSnoozed: true}).Return(exportTasks(), nil)
+
+	var out bytes.Buffer
+	err := service.WriteFeed(context.Background(), token, &out)
+
+	require.NoError(t, err)
+	feed := out.String()
+	assert.True(t, strings.HasPrefix(feed, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//todo-app//Tasks//EN\r\n"), feed)
+	assert.Contains(t, feed, "BEGIN:VTODO\r\n"+
+		"UID:task-1@todo-app\r\n"+
+		"DTSTAMP:20260302T093000Z\r\n"+
+		"CREATED:20260301T080000Z\r\n"+
+		"LAST-MODIFIED:20260302T093000Z\r\n"+
+		"SUMMARY:Milk\\, 2 litres\r\n"+
+		"STATUS:COMPLETED\r\n"+
+		"END:VTODO\r\n")
+	assert.Contains(t, feed, "UID:task-2@todo-app\r\nDTSTAMP:20260301T080500Z\r\n")
+	assert.Contains(t, feed, "STATUS:NEEDS-ACTION\r\n")
+	assert.True(t, strings.HasSuffix(feed, "END:VCALENDAR\r\n"))
+}
+
+func TestCalendarService_WriteFeed_NotFound(t *testing.T) {
+	service, repo, env := newTestCalendarService()
+	repo.On("FeedUser", mock.Anything, mock.Anything).Return(0, repositories.ErrCalendarFeedNotFound)
+
+	err := service.WriteFeed(context.Background(), "wrong", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrCalendarFeedNotFound)
+
+	err = service.WriteFeed(context.Background(), "", &bytes.Buffer{})
+	assert.ErrorIs(t, err, ErrCalendarFeedNotFound)
+	repo.AssertNumberOfCalls(t, "FeedUser", 1)
+	env.tasks.AssertNotCalled(t, "EachTask", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCalendarService_DeleteFeed_NotFound(t *testing.T) {
+	service, repo, _ := newTestCalendarService()
+	repo.On("DeleteFeed", mock.Anything, 1).Return(repositories.ErrCalendarFeedNotFound)
+
+	err := service.DeleteFeed(context.Background(), 1)
+
+	assert.ErrorIs(t, err, ErrCalendarFeedNotFound)
+}
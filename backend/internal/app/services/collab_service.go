@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+var (
+	ErrTooManyConnections   = errors.New("Too many open collaboration connections")
+	ErrInvalidCollabMessage = errors.New("type must be subscribe, unsubscribe or presence with a list_id; state must be viewing, or editing with a task_id")
+	ErrTooManyCollabLists   = errors.New("Too many lists subscribed on this connection")
+	ErrNotSubscribed        = errors.New("Subscribe to the list first")
+	ErrPresenceNotAllowed   = errors.New("Reporting presence requires the tasks:write scope")
+)
+
+// CollabAccess is what a collaboration session may do with presence, which
+// names the users in a list. Sessions of third-party apps get only what
+// their scopes grant.
+type CollabAccess struct {
+	// SeePresence lets the session receive the presence of others.
+	SeePresence bool
+	// SharePresence lets others see the session, and lets it report what
+	// it is doing. Without it the session is invisible.
+	SharePresence bool
+}
+
+// FullCollabAccess is the access of sessions opened with a login token.
+var FullCollabAccess = CollabAccess{SeePresence: true, SharePresence: true}
+
+// CollabConfig tunes live collaboration. A connection that falls Buffer
+// messages behind is closed. A user may keep MaxPerUser connections open in
+// one replica, each subscribed to up to MaxLists lists. Every replica
+// announces the presence of its connections every PresenceRefresh, and
+// forgets the presence of other replicas' connections that were not
+// announced for PresenceTTL.
+type CollabConfig struct {
+	Buffer          int
+	MaxPerUser      int
+	MaxLists        int
+	PresenceRefresh time.Duration
+	PresenceTTL     time.Duration
+}
+
+// DefaultCollabConfig lets connections fall 64 messages behind, allows 5
+// connections per user with 50 lists each, and refreshes presence every 30
+// seconds, expiring it after 90.
+func DefaultCollabConfig() CollabConfig {
+	return CollabConfig{
+		Buffer:          64,
+		MaxPerUser:      5,
+		MaxLists:        50,
+		PresenceRefresh: 30 * time.Second,
+		PresenceTTL:     90 * time.Second,
+	}
+}
+
+// CollabServiceInterface runs the collaboration sessions of WebSocket
+// clients.
+type CollabServiceInterface interface {
+	// Connect opens a session of userID in the workspace of ctx with
+	// access.
+	Connect(ctx context.Context, userID uint, access CollabAccess) (*CollabSession, error)
+	// Handle processes a message the client of session sent. An error is
+	// reported to the client; the session stays open.
+	Handle(ctx context.Context, session *CollabSession, msg models.CollabMessage) error
+	// Disconnect closes session and ends its presence in every list.
+	Disconnect(session *CollabSession)
+}
+
+// CollabSession is the server side of one collaboration connection.
+type CollabSession struct {
+	ID string
+	// Out delivers the messages for the client. It is closed when the
+	// client fell too far behind; the connection should then be closed.
+	Out <-chan models.CollabMessage
+
+	userID      int
+	username    string
+	workspaceID int
+	access      CollabAccess
+	out         chan models.CollabMessage
+	closed      bool
+	// lists holds the session's presence in each subscribed list.
+	lists map[int]models.Presence
+}
+
+// presenceNotice is a presence change announced to the other replicas.
+type presenceNotice struct {
+	Replica  string          `json:"replica"`
+	Presence models.Presence `json:"presence"`
+}
+
+// presenceEntry is a presence and when it was last announced.
+type presenceEntry struct {
+	presence models.Presence
+	seen     time.Time
+}
+
+// CollabService shares task changes and presence between the collaboration
+// sessions of every replica. Task changes come from the event stream, which
+// it observes; presence travels between replicas as notices, and each
+// replica keeps the presence of every session of every replica.
+type CollabService struct {
+	lists    repositories.TaskListRepository
+	users    repositories.AuthRepository
+	presence repositories.PresenceRepository
+	config   CollabConfig
+	replica  string
+	now      func() time.Time
+
+	mu       sync.Mutex
+	sessions map[*CollabSession]struct{}
+	// present holds the presence in each list by session ID, of this
+	// replica's sessions and of the others.
+	present map[int]map[string]presenceEntry
+}
+
+// NewCollabService returns the concrete service, which must be Run and
+// observe stream.
+func NewCollabService(lists repositories.TaskListRepository, users repositories.AuthRepository, presence repositories.PresenceRepository, stream *StreamService, config CollabConfig) (*CollabService, error) {
+	replica, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	s := &CollabService{
+		lists:    lists,
+		users:    users,
+		presence: presence,
+		config:   config,
+		replica:  replica,
+		now:      time.Now,
+		sessions: make(map[*CollabSession]struct{}),
+		present:  make(map[int]map[string]presenceEntry),
+	}
+	stream.Observe(s)
+	return s, nil
+}
+
+func (s *CollabService) Connect(ctx context.Context, userID uint, access CollabAccess) (*CollabSession, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "CollabService.Connect")
+	defer span.End()
+
+	user, err := s.users.GetUserByID(ctx, int(userID))
+	if err != nil {
+		return nil, err
+	}
+	id, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	open := 0
+	for session := range s.sessions {
+		if session.userID == int(userID) {
+			open++
+		}
+	}
+	if open >= s.config.MaxPerUser {
+		return nil, ErrTooManyConnections
+	}
+	out := make(chan models.CollabMessage, s.config.Buffer)
+	session := &CollabSession{
+		ID:          id,
+		Out:         out,
+		userID:      int(userID),
+		username:    user.Username,
+		workspaceID: tenant.WorkspaceID(ctx),
+		access:      access,
+		out:         out,
+		lists:       make(map[int]models.Presence),
+	}
+	s.sessions[session] = struct{}{}
+	return session, nil
+}
+
+func (s *CollabService) Handle(ctx context.Context, session *CollabSession, msg models.CollabMessage) error {
+	ctx, span := otel.Tracer("").Start(ctx, "CollabService.Handle")
+	defer span.End()
+
+	if msg.ListID <= 0 {
+		return ErrInvalidCollabMessage
+	}
+	switch msg.Type {
+	case models.CollabSubscribe:
+		return s.subscribe(ctx, session, msg.ListID)
+	case models.CollabUnsubscribe:
+		s.announce(ctx, s.leave(session, msg.ListID, models.CollabUnsubscribed)...)
+		return nil
+	case models.CollabPresence:
+		if (msg.State != models.PresenceViewing || msg.TaskID != 0) && (msg.State != models.PresenceEditing || msg.TaskID <= 0) {
+			return ErrInvalidCollabMessage
+		}
+		if !session.access.SharePresence {
+			return ErrPresenceNotAllowed
+		}
+		return s.setPresence(ctx, session, msg.ListID, msg.State, msg.TaskID)
+	default:
+		return ErrInvalidCollabMessage
+	}
+}
+
+// subscribe adds listID to the lists of session if its user is a member
+// and answers with everyone's presence in it, as far as session.access
+// allows.
+func (s *CollabService) subscribe(ctx context.Context, session *CollabSession, listID int) error {
+	if _, err := s.lists.GetMemberRole(ctx, listID, session.userID); err != nil {
+		return mapTaskListError(err)
+	}
+
+	s.mu.Lock()
+	if session.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	p, ok := session.lists[listID]
+	if !ok {
+		if len(session.lists) >= s.config.MaxLists {
+			s.mu.Unlock()
+			return ErrTooManyCollabLists
+		}
+		p = models.Presence{ListID: listID, UserID: session.userID, Username: session.username, SessionID: session.ID,
+			State: models.PresenceViewing, UpdatedAt: s.now().UTC()}
+		session.lists[listID] = p
+		if session.access.SharePresence {
+			s.apply(p, session)
+		}
+	}
+	var others []models.Presence
+	for id, entry := range s.present[listID] {
+		if id != session.ID && session.access.SeePresence {
+			others = append(others, entry.presence)
+		}
+	}
+	slices.SortFunc(others, func(a, b models.Presence) int { return a.UpdatedAt.Compare(b.UpdatedAt) })
+	s.send(session, models.CollabMessage{Type: models.CollabSubscribed, ListID: listID, Presence: others})
+	s.mu.Unlock()
+
+	if !ok && session.access.SharePresence {
+		s.announce(ctx, p)
+	}
+	return nil
+}
+
+func (s *CollabService) setPresence(ctx context.Context, session *CollabSession, listID int, state models.PresenceState, taskID int) error {
+	s.mu.Lock()
+	p, ok := session.lists[listID]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotSubscribed
+	}
+	if p.State == state && p.TaskID == taskID {
+		s.mu.Unlock()
+		return nil
+	}
+	p.State, p.TaskID, p.UpdatedAt = state, taskID, s.now().UTC()
+	session.lists[listID] = p
+	s.apply(p, session)
+	s.mu.Unlock()
+
+	s.announce(ctx, p)
+	return nil
+}
+
+// leave removes listID from the lists of session, tells the client with a
+// message of type reply unless it is empty, and returns the presence that
+// ended, if any.
+func (s *CollabService) leave(session *CollabSession, listID int, reply models.CollabMessageType) []models.Presence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := session.lists[listID]
+	if reply != "" {
+		s.send(session, models.CollabMessage{Type: reply, ListID: listID})
+	}
+	if !ok {
+		return nil
+	}
+	delete(session.lists, listID)
+	if !session.access.SharePresence {
+		return nil
+	}
+	p.State, p.TaskID, p.UpdatedAt = models.PresenceLeft, 0, s.now().UTC()
+	s.apply(p, session)
+	return []models.Presence{p}
+}
+
+func (s *CollabService) Disconnect(session *CollabSession) {
+	s.mu.Lock()
+	if _, ok := s.sessions[session]; !ok {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, session)
+	s.close(session)
+	listIDs := slices.Collect(maps.Keys(session.lists))
+	s.mu.Unlock()
+
+	var left []models.Presence
+	for _, listID := range listIDs {
+		left = append(left, s.leave(session, listID, "")...)
+	}
+	s.announce(context.Background(), left...)
+}
+
+// apply records p and sends it to the sessions subscribed to its list that
+// may see presence, except from, the session it came from if it is one of
+// this replica's. s.mu must be held.
+func (s *CollabService) apply(p models.Presence, from *CollabSession) {
+	if p.State == models.PresenceLeft {
+		delete(s.present[p.ListID], p.SessionID)
+		if len(s.present[p.ListID]) == 0 {
+			delete(s.present, p.ListID)
+		}
+	} else {
+		if s.present[p.ListID] == nil {
+			s.present[p.ListID] = make(map[string]presenceEntry)
+		}
+		s.present[p.ListID][p.SessionID] = presenceEntry{presence: p, seen: s.now()}
+	}
+
+	msg := models.CollabMessage{Type: models.CollabPresence, ListID: p.ListID, Presence: []models.Presence{p}}
+	for session := range s.sessions {
+		if _, ok := session.lists[p.ListID]; ok && session != from && session.access.SeePresence {
+			s.send(session, msg)
+		}
+	}
+}
+
+// send queues msg for the client of session and closes the session's
+// messages if the client is too far behind. s.mu must be held.
+func (s *CollabService) send(session *CollabSession, msg models.CollabMessage) {
+	if session.closed {
+		return
+	}
+	select {
+	case session.out <- msg:
+	default:
+		s.close(session)
+	}
+}
+
+// close ends the messages of session. s.mu must be held.
+func (s *CollabService) close(session *CollabSession) {
+	if !session.closed {
+		session.closed = true
+		close(session.out)
+	}
+}
+
+// announce tells the other replicas about presence changes.
+func (s *CollabService) announce(ctx context.Context, presence ...models.Presence) {
+	for _, p := range presence {
+		notice, err := json.Marshal(presenceNotice{Replica: s.replica, Presence: p})
+		if err != nil {
+			logging.ContextLogger(ctx).Error("Failed to encode presence notice", "error", err)
+			continue
+		}
+		if err := s.presence.Announce(ctx, string(notice)); err != nil {
+			logging.ContextLogger(ctx).Error("Failed to announce presence", "listID", p.ListID, "error", err)
+		}
+	}
+}
+
+// receive applies a presence notice of another replica.
+func (s *CollabService) receive(ctx context.Context, notice string) {
+	var n presenceNotice
+	if err := json.Unmarshal([]byte(notice), &n); err != nil {
+		logging.ContextLogger(ctx).Error("Failed to decode presence notice", "error", err)
+		return
+	}
+	if n.Replica == s.replica {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A refresh of a known presence is not news to the clients.
+	if entry, ok := s.present[n.Presence.ListID][n.Presence.SessionID]; ok && entry.presence == n.Presence {
+		s.present[n.Presence.ListID][n.Presence.SessionID] = presenceEntry{presence: n.Presence, seen: s.now()}
+		return
+	}
+	s.apply(n.Presence, nil)
+}
+
+// ObserveEvent sends a task change to the sessions subscribed to its list
+// whose user may see it.
+func (s *CollabService) ObserveEvent(event models.StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := models.CollabMessage{Type: models.CollabEvent, ListID: event.Event.ListID, Seq: event.Seq, Event: &event.Event}
+	for session := range s.sessions {
+		if _, ok := session.lists[event.Event.ListID]; !ok {
+			continue
+		}
+		if slices.Contains(event.UserIDs, session.userID) {
+			s.send(session, msg)
+		}
+	}
+}
+
+// ObserveReset tells every session with subscriptions to reload its lists.
+func (s *CollabService) ObserveReset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for session := range s.sessions {
+		if len(session.lists) > 0 {
+			s.send(session, models.CollabMessage{Type: models.CollabReset})
+		}
+	}
+}
+
+// Run listens for the presence notices of other replicas and refreshes
+// presence until ctx is done.
+func (s *CollabService) Run(ctx context.Context) {
+	go s.refreshLoop(ctx)
+	for {
+		err := s.presence.Listen(ctx, func() { s.announce(ctx, s.localPresence()...) }, func(notice string) { s.receive(ctx, notice) })
+		if ctx.Err() != nil {
+			return
+		}
+		logging.ContextLogger(ctx).Error("Presence stopped listening", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamListenRetry):
+		}
+	}
+}
+
+func (s *CollabService) refreshLoop(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PresenceRefresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// refresh drops the subscriptions of users who are no longer members of
+// the list, announces the presence of this replica's sessions again and
+// expires the presence that other replicas stopped announcing.
+func (s *CollabService) refresh(ctx context.Context) {
+	type subscription struct {
+		session *CollabSession
+		listID  int
+	}
+	s.mu.Lock()
+	var subscriptions []subscription
+	for session := range s.sessions {
+		for listID := range session.lists {
+			subscriptions = append(subscriptions, subscription{session, listID})
+		}
+	}
+	s.mu.Unlock()
+
+	var left []models.Presence
+	for _, sub := range subscriptions {
+		_, err := s.lists.GetMemberRole(tenant.WithWorkspace(ctx, sub.session.workspaceID), sub.listID, sub.session.userID)
+		if errors.Is(err, repositories.ErrTaskListNotFound) {
+			left = append(left, s.leave(sub.session, sub.listID, models.CollabUnsubscribed)...)
+		} else if err != nil {
+			logging.ContextLogger(ctx).Error("Failed to check list membership", "listID", sub.listID, "error", err)
+		}
+	}
+	s.announce(ctx, left...)
+	s.announce(ctx, s.localPresence()...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expired := s.now().Add(-s.config.PresenceTTL)
+	for _, entries := range s.present {
+		for _, entry := range entries {
+			if entry.seen.Before(expired) {
+				p := entry.presence
+				p.State, p.TaskID, p.UpdatedAt = models.PresenceLeft, 0, s.now().UTC()
+				s.apply(p, nil)
+			}
+		}
+	}
+}
+
+// localPresence returns the presence of this replica's sessions and marks
+// it as seen now.
+func (s *CollabService) localPresence() []models.Presence {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var presence []models.Presence
+	for session := range s.sessions {
+		if !session.access.SharePresence {
+			continue
+		}
+		for _, p := range session.lists {
+			presence = append(presence, p)
+			s.present[p.ListID][p.SessionID] = presenceEntry{presence: p, seen: s.now()}
+		}
+	}
+	return presence
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockPresenceRepository is a mock implementation of the PresenceRepository interface
+type MockPresenceRepository struct {
+	mock.Mock
+}
+
+var _ repositories.PresenceRepository = (*MockPresenceRepository)(nil)
+
+func (m *MockPresenceRepository) Announce(ctx context.Context, notice string) error {
+	args := m.Called(ctx, notice)
+	return args.Error(0)
+}
+
+func (m *MockPresenceRepository) Listen(ctx context.Context, ready func(), handle func(notice string)) error {
+	args := m.Called(ctx, ready, handle)
+	return args.Error(0)
+}
+
+type collabFixture struct {
+	service  *CollabService
+	stream   *StreamService
+	lists    *MockTaskListRepository
+	users    *MockAuthRepository
+	presence *MockPresenceRepository
+	now      time.Time
+}
+
+// newCollabFixture returns a collaboration service in which users 1 and 2
+// are members of list 3, and every presence announcement succeeds.
+func newCollabFixture(t *testing.T, config CollabConfig) *collabFixture {
+	f := &collabFixture{
+		lists:    new(MockTaskListRepository),
+		users:    new(MockAuthRepository),
+		presence: new(MockPresenceRepository),
+		now:      time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.stream, _ = newListeningStream(DefaultStreamConfig())
+	service, err := NewCollabService(f.lists, f.users, f.presence, f.stream, config)
+	require.NoError(t, err)
+	service.now = func() time.Time { return f.now }
+	f.service = service
+
+	f.users.On("GetUserByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "alice"}, nil)
+	f.users.On("GetUserByID", mock.Anything, 2).Return(&models.User{ID: 2, Username: "bob"}, nil)
+	f.lists.On("GetMemberRole", mock.Anything, 3, 1).Return(models.ListRoleOwner, nil)
+	f.lists.On("GetMemberRole", mock.Anything, 3, 2).Return(models.ListRoleEditor, nil)
+	f.presence.On("Announce", mock.Anything, mock.Anything).Return(nil)
+	return f
+}
+
+// join connects userID and subscribes it to list 3.
+func (f *collabFixture) join(t *testing.T, userID uint) *CollabSession {
+	session, err := f.service.Connect(context.Background(), userID, FullCollabAccess)
+	require.NoError(t, err)
+	require.NoError(t, f.service.Handle(context.Background(), session, models.CollabMessage{Type: models.CollabSubscribe, ListID: 3}))
+	return session
+}
+
+func messages(session *CollabSession) []models.CollabMessage {
+	var msgs []models.CollabMessage
+	for {
+		select {
+		case msg, ok := <-session.Out:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestCollabService_Subscribe(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+
+	alice := f.join(t, 1)
+	bob := f.join(t, 2)
+
+	aliceGot := messages(alice)
+	if assert.Len(t, aliceGot, 2) {
+		assert.Equal(t, models.CollabSubscribed, aliceGot[0].Type)
+		assert.Empty(t, aliceGot[0].Presence)
+		assert.Equal(t, models.CollabPresence, aliceGot[1].Type)
+		assert.Equal(t, "bob", aliceGot[1].Presence[0].Username)
+		assert.Equal(t, models.PresenceViewing, aliceGot[1].Presence[0].State)
+	}
+	bobGot := messages(bob)
+	if assert.Len(t, bobGot, 1) {
+		assert.Equal(t, models.CollabSubscribed, bobGot[0].Type)
+		if assert.Len(t, bobGot[0].Presence, 1) {
+			assert.Equal(t, "alice", bobGot[0].Presence[0].Username)
+		}
+	}
+	f.presence.AssertNumberOfCalls(t, "Announce", 2)
+}
+
+func TestCollabService_Subscribe_NotAMember(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	f.lists.On("GetMemberRole", mock.Anything, 4, 1).Return(models.ListRole(""), repositories.ErrTaskListNotFound)
+	session, err := f.service.Connect(context.Background(), 1, FullCollabAccess)
+	require.NoError(t, err)
+
+	err = f.service.Handle(context.Background(), session, models.CollabMessage{Type: models.CollabSubscribe, ListID: 4})
+
+	assert.ErrorIs(t, err, ErrTaskListNotFound)
+	assert.Empty(t, messages(session))
+}
+
+func TestCollabService_Presence(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	bob := f.join(t, 2)
+	messages(alice)
+	messages(bob)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		msg  models.CollabMessage
+		err  error
+	}{
+		{"editing without task", models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceEditing}, ErrInvalidCollabMessage},
+		{"viewing a task", models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceViewing, TaskID: 12}, ErrInvalidCollabMessage},
+		{"left", models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceLeft}, ErrInvalidCollabMessage},
+		{"other list", models.CollabMessage{Type: models.CollabPresence, ListID: 4, State: models.PresenceViewing}, ErrNotSubscribed},
+		{"no list", models.CollabMessage{Type: models.CollabPresence, State: models.PresenceViewing}, ErrInvalidCollabMessage},
+		{"unknown type", models.CollabMessage{Type: "shout", ListID: 3}, ErrInvalidCollabMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, f.service.Handle(ctx, bob, tt.msg), tt.err)
+		})
+	}
+
+	err := f.service.Handle(ctx, bob, models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceEditing, TaskID: 12})
+
+	assert.NoError(t, err)
+	got := messages(alice)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, models.PresenceEditing, got[0].Presence[0].State)
+		assert.Equal(t, 12, got[0].Presence[0].TaskID)
+	}
+	assert.Empty(t, messages(bob))
+}
+
+func TestCollabService_Access(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	ctx := context.Background()
+	alice := f.join(t, 1)
+	messages(alice)
+
+	// An app with tasks:read only neither sees nor shows presence.
+	app, err := f.service.Connect(ctx, 2, CollabAccess{})
+	require.NoError(t, err)
+	require.NoError(t, f.service.Handle(ctx, app, models.CollabMessage{Type: models.CollabSubscribe, ListID: 3}))
+
+	got := messages(app)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, models.CollabSubscribed, got[0].Type)
+		assert.Empty(t, got[0].Presence, "presence names alice")
+	}
+	assert.Empty(t, messages(alice), "the app is invisible")
+	err = f.service.Handle(ctx, app, models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceEditing, TaskID: 12})
+	assert.ErrorIs(t, err, ErrPresenceNotAllowed)
+	assert.Empty(t, messages(alice))
+
+	require.NoError(t, f.service.Handle(ctx, alice, models.CollabMessage{Type: models.CollabPresence, ListID: 3, State: models.PresenceEditing, TaskID: 12}))
+	assert.Empty(t, messages(app))
+	for _, p := range f.service.localPresence() {
+		assert.NotEqual(t, 2, p.UserID, "the app is not announced to other replicas")
+	}
+
+	f.service.Disconnect(app)
+	assert.Empty(t, messages(alice))
+	f.presence.AssertNumberOfCalls(t, "Announce", 2)
+}
+
+func TestCollabService_TaskEvents(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	bob := f.join(t, 2)
+	lurker, err := f.service.Connect(context.Background(), 2, FullCollabAccess)
+	require.NoError(t, err)
+	messages(alice)
+	messages(bob)
+
+	f.stream.dispatch(models.StreamEvent{Seq: 11, Event: models.Event{ID: "a", Type: models.EventTaskCreated, ListID: 3}, UserIDs: []int{1, 2}})
+	// Bob was removed from the list before this change.
+	f.stream.dispatch(models.StreamEvent{Seq: 12, Event: models.Event{ID: "b", Type: models.EventTaskUpdated, ListID: 3}, UserIDs: []int{1}})
+	f.stream.dispatch(models.StreamEvent{Seq: 13, Event: models.Event{ID: "c", Type: models.EventTaskCreated, ListID: 4}, UserIDs: []int{1, 2}})
+
+	var seqs []int64
+	for _, msg := range messages(alice) {
+		assert.Equal(t, models.CollabEvent, msg.Type)
+		seqs = append(seqs, msg.Seq)
+	}
+	assert.Equal(t, []int64{11, 12}, seqs)
+	assert.Len(t, messages(bob), 1)
+	assert.Empty(t, messages(lurker))
+}
+
+func TestCollabService_Disconnect(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	bob := f.join(t, 2)
+	messages(alice)
+
+	f.service.Disconnect(bob)
+	f.service.Disconnect(bob)
+
+	got := messages(alice)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, models.PresenceLeft, got[0].Presence[0].State)
+		assert.Equal(t, bob.ID, got[0].Presence[0].SessionID)
+	}
+	messages(bob)
+	_, ok := <-bob.Out
+	assert.False(t, ok)
+}
+
+func TestCollabService_Connect_TooMany(t *testing.T) {
+	config := DefaultCollabConfig()
+	config.MaxPerUser = 1
+	f := newCollabFixture(t, config)
+
+	session, err := f.service.Connect(context.Background(), 1, FullCollabAccess)
+	require.NoError(t, err)
+	_, err = f.service.Connect(context.Background(), 1, FullCollabAccess)
+	assert.ErrorIs(t, err, ErrTooManyConnections)
+
+	f.service.Disconnect(session)
+	_, err = f.service.Connect(context.Background(), 1, FullCollabAccess)
+	assert.NoError(t, err)
+}
+
+func TestCollabService_SlowClient(t *testing.T) {
+	config := DefaultCollabConfig()
+	config.Buffer = 2
+	f := newCollabFixture(t, config)
+	alice := f.join(t, 1)
+
+	for seq := int64(11); seq <= 13; seq++ {
+		f.stream.dispatch(models.StreamEvent{Seq: seq, Event: models.Event{ListID: 3}, UserIDs: []int{1}})
+	}
+
+	assert.Len(t, messages(alice), 2)
+	_, ok := <-alice.Out
+	assert.False(t, ok)
+}
+
+func TestCollabService_RemotePresence(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	messages(alice)
+	ctx := context.Background()
+
+	remote := models.Presence{ListID: 3, UserID: 2, Username: "bob", SessionID: "remote", State: models.PresenceViewing, UpdatedAt: f.now}
+	notice, err := json.Marshal(presenceNotice{Replica: "other", Presence: remote})
+	require.NoError(t, err)
+	own, err := json.Marshal(presenceNotice{Replica: f.service.replica, Presence: remote})
+	require.NoError(t, err)
+
+	f.service.receive(ctx, string(own))
+	assert.Empty(t, messages(alice))
+
+	f.service.receive(ctx, string(notice))
+	f.service.receive(ctx, string(notice))
+	got := messages(alice)
+	if assert.Len(t, got, 1, "a refresh is not sent again") {
+		assert.Equal(t, "remote", got[0].Presence[0].SessionID)
+	}
+
+	// The other replica stops announcing the presence.
+	f.now = f.now.Add(2 * time.Minute)
+	f.service.refresh(ctx)
+
+	got = messages(alice)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, models.PresenceLeft, got[0].Presence[0].State)
+	}
+	assert.Empty(t, f.service.present[3]["remote"])
+}
+
+func TestCollabService_Refresh_DropsFormerMembers(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	bob := f.join(t, 2)
+	messages(alice)
+	messages(bob)
+
+	f.lists.ExpectedCalls = nil
+	f.lists.On("GetMemberRole", mock.Anything, 3, 1).Return(models.ListRoleOwner, nil)
+	f.lists.On("GetMemberRole", mock.Anything, 3, 2).Return(models.ListRole(""), repositories.ErrTaskListNotFound)
+	f.service.refresh(context.Background())
+
+	bobGot := messages(bob)
+	if assert.Len(t, bobGot, 1) {
+		assert.Equal(t, models.CollabUnsubscribed, bobGot[0].Type)
+	}
+	aliceGot := messages(alice)
+	if assert.Len(t, aliceGot, 1) {
+		assert.Equal(t, models.PresenceLeft, aliceGot[0].Presence[0].State)
+	}
+}
+
+func TestCollabService_Reset(t *testing.T) {
+	f := newCollabFixture(t, DefaultCollabConfig())
+	alice := f.join(t, 1)
+	idle, err := f.service.Connect(context.Background(), 2, FullCollabAccess)
+	require.NoError(t, err)
+	messages(alice)
+
+	f.stream.resync(context.Background())
+
+	got := messages(alice)
+	if assert.Len(t, got, 1) {
+		assert.Equal(t, models.CollabReset, got[0].Type)
+	}
+	assert.Empty(t, messages(idle))
+}
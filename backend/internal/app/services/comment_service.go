@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mention"
+	"go.opentelemetry.io/otel"
+)
+
+const maxCommentLength = 10000
+
+var (
+	ErrInvalidComment          = errors.New("Comment must be between 1 and 10000 characters")
+	ErrCommentNotFound         = errors.New("Comment not found")
+	ErrCommentPermissionDenied = errors.New("Comment belongs to another user")
+)
+
+type CommentServiceInterface interface {
+	ListComments(ctx context.Context, userID uint, taskID int) ([]models.Comment, error)
+	// CreateComment adds a Markdown comment to a task and notifies the
+	// members of the task's list it mentions.
+	CreateComment(ctx context.Context, userID uint, taskID int, req models.CommentRequest) (*models.Comment, error)
+	// UpdateComment changes the author's comment. Only users who were not
+	// mentioned before are notified.
+	UpdateComment(ctx context.Context, userID uint, taskID, commentID int, req models.CommentRequest) (*models.Comment, error)
+	// DeleteComment removes a comment. Besides its author, list admins may
+	// delete it.
+	DeleteComment(ctx context.Context, userID uint, taskID, commentID int) error
+}
+
+type CommentService struct {
+	repo     repositories.CommentRepository
+	notifier Notifier
+}
+
+func NewCommentService(repo repositories.CommentRepository, notifier Notifier) CommentServiceInterface {
+	return &CommentService{repo: repo, notifier: notifier}
+}
+
+func (s *CommentService) ListComments(ctx context.Context, userID uint, taskID int) ([]models.Comment, error) {
+	_, span := otel.Tracer("").Start(ctx, "CommentService.ListComments")
+	defer span.End()
+
+	return s.repo.ListComments(ctx, taskID, int(userID))
+}
+
+func (s *CommentService) CreateComment(ctx context.Context, userID uint, taskID int, req models.CommentRequest) (*models.Comment, error) {
+	_, span := otel.Tracer("").Start(ctx, "CommentService.CreateComment")
+	defer span.End()
+
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	comment := &models.Comment{TaskID: taskID, AuthorID: int(userID), Body: body}
+	if err := s.repo.CreateComment(ctx, comment, mention.Parse(body)); err != nil {
+		return nil, mapCommentError(err)
+	}
+
+	mentioned := make([]int, len(comment.Mentions))
+	for i, m := range comment.Mentions {
+		mentioned[i] = m.UserID
+	}
+	s.notifyMentioned(ctx, comment, mentioned)
+
+	logging.ContextLogger(ctx).Info("Comment created", "event", "comment_created", "commentID", comment.ID, "taskID", taskID, "userID", userID)
+	return comment, nil
+}
+
+func (s *CommentService) UpdateComment(ctx context.Context, userID uint, taskID, commentID int, req models.CommentRequest) (*models.Comment, error) {
+	_, span := otel.Tracer("").Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+
+	body, err := commentBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	comment := &models.Comment{ID: commentID, TaskID: taskID, AuthorID: int(userID), Body: body}
+	added, err := s.repo.UpdateComment(ctx, comment, mention.Parse(body))
+	if err != nil {
+		return nil, mapCommentError(err)
+	}
+	s.notifyMentioned(ctx, comment, added)
+
+	logging.ContextLogger(ctx).Info("Comment updated", "event", "comment_updated", "commentID", commentID, "taskID", taskID, "userID", userID)
+	return comment, nil
+}
+
+func (s *CommentService) DeleteComment(ctx context.Context, userID uint, taskID, commentID int) error {
+	_, span := otel.Tracer("").Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+
+	if err := s.repo.DeleteComment(ctx, taskID, commentID, int(userID)); err != nil {
+		return mapCommentError(err)
+	}
+	logging.ContextLogger(ctx).Info("Comment deleted", "event", "comment_deleted", "commentID", commentID, "taskID", taskID, "userID", userID)
+	return nil
+}
+
+// notifyMentioned notifies userIDs except the author of a mention. The
+// comment has been saved already, so failures are only logged.
+func (s *CommentService) notifyMentioned(ctx context.Context, comment *models.Comment, userIDs []int) {
+	var notifications []models.Notification
+	for _, userID := range userIDs {
+		if userID == comment.AuthorID {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    userID,
+			Type:      models.NotificationMention,
+			ActorID:   comment.AuthorID,
+			TaskID:    comment.TaskID,
+			CommentID: comment.ID,
+		})
+	}
+	if len(notifications) == 0 {
+		return
+	}
+	if err := s.notifier.Notify(ctx, notifications...); err != nil {
+		logging.ContextLogger(ctx).Error("Failed to create mention notifications", "commentID", comment.ID, "error", err)
+	}
+}
+
+// commentBody trims the Markdown source of a comment and checks its length.
+func commentBody(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
+
+func mapCommentError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrCommentNotFound):
+		return ErrCommentNotFound
+	case errors.Is(err, repositories.ErrNotCommentAuthor):
+		return ErrCommentPermissionDenied
+	default:
+		return err
+	}
+}
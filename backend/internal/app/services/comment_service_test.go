@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockCommentRepository is a mock implementation of the CommentRepository interface
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) ListComments(ctx context.Context, taskID, userID int) ([]models.Comment, error) {
+	args := m.Called(ctx, taskID, userID)
+	return args.Get(0).([]models.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) CreateComment(ctx context.Context, comment *models.Comment, mentions []string) error {
+	args := m.Called(ctx, comment, mentions)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) UpdateComment(ctx context.Context, comment *models.Comment, mentions []string) ([]int, error) {
+	args := m.Called(ctx, comment, mentions)
+	added, _ := args.Get(0).([]int)
+	return added, args.Error(1)
+}
+
+func (m *MockCommentRepository) DeleteComment(ctx context.Context, taskID, commentID, userID int) error {
+	args := m.Called(ctx, taskID, commentID, userID)
+	return args.Error(0)
+}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	mockRepo := new(MockCommentRepository)
+	mockNotifier := new(MockNotifier)
+	commentService := NewCommentService(mockRepo, mockNotifier)
+	ctx := context.Background()
+
+	mockRepo.On("CreateComment", ctx, mock.MatchedBy(func(c *models.Comment) bool {
+		return c.TaskID == 5 && c.AuthorID == 1 && c.Body == "@bob @alice see `@carol`"
+	}), []string{"bob", "alice"}).Run(func(args mock.Arguments) {
+		c := args.Get(1).(*models.Comment)
+		c.ID = 7
+		// alice is the author; unknown users and non-members are dropped
+		// by the repository.
+		c.Mentions = []models.CommentMention{{UserID: 1, Username: "alice"}, {UserID: 2, Username: "bob"}}
+	}).Return(nil)
+	mockNotifier.On("Notify", ctx, []models.Notification{
+		{UserID: 2, Type: models.NotificationMention, ActorID: 1, TaskID: 5, CommentID: 7},
+	}).Return(nil)
+
+	comment, err := commentService.CreateComment(ctx, 1, 5, models.CommentRequest{Body: "  @bob @alice see `@carol`\n"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, comment.ID)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestCommentService_CreateComment_InvalidBody(t *testing.T) {
+	for name, body := range map[string]string{"blank": " \n ", "too long": strings.Repeat("a", maxCommentLength+1)} {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			commentService := NewCommentService(mockRepo, new(MockNotifier))
+
+			_, err := commentService.CreateComment(context.Background(), 1, 5, models.CommentRequest{Body: body})
+
+			assert.ErrorIs(t, err, ErrInvalidComment)
+			mockRepo.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestCommentService_UpdateComment_NotifiesNewMentionsOnly(t *testing.T) {
+	mockRepo := new(MockCommentRepository)
+	mockNotifier := new(MockNotifier)
+	commentService := NewCommentService(mockRepo, mockNotifier)
+	ctx := context.Background()
+
+	mockRepo.On("UpdateComment", ctx, mock.MatchedBy(func(c *models.Comment) bool {
+		return c.ID == 7 && c.TaskID == 5 && c.AuthorID == 1
+	}), []string{"bob", "dave"}).Return([]int{4}, nil)
+	mockNotifier.On("Notify", ctx, []models.Notification{
+		{UserID: 4, Type: models.NotificationMention, ActorID: 1, TaskID: 5, CommentID: 7},
+	}).Return(nil)
+
+	_, err := commentService.UpdateComment(ctx, 1, 5, 7, models.CommentRequest{Body: "@bob and now @dave"})
+
+	assert.NoError(t, err)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestCommentService_Errors(t *testing.T) {
+	mockRepo := new(MockCommentRepository)
+	commentService := NewCommentService(mockRepo, new(MockNotifier))
+	ctx := context.Background()
+
+	mockRepo.On("UpdateComment", ctx, mock.Anything, mock.Anything).Return(nil, repositories.ErrNotCommentAuthor)
+	mockRepo.On("DeleteComment", ctx, 5, 7, 1).Return(repositories.ErrCommentNotFound)
+	mockRepo.On("DeleteComment", ctx, 6, 7, 1).Return(repositories.ErrTaskNotFound)
+
+	_, err := commentService.UpdateComment(ctx, 1, 5, 7, models.CommentRequest{Body: "edited"})
+	assert.ErrorIs(t, err, ErrCommentPermissionDenied)
+	assert.ErrorIs(t, commentService.DeleteComment(ctx, 1, 5, 7), ErrCommentNotFound)
+	assert.ErrorIs(t, commentService.DeleteComment(ctx, 1, 6, 7), repositories.ErrTaskNotFound)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"go.opentelemetry.io/otel"
+)
+
+// EventBus is an in-process event sink. Subscribers are called one after
+// another for every relayed event, in the replica that relayed it.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers []func(ctx context.Context, event models.Event)
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+func (b *EventBus) Name() string { return "bus" }
+
+// Subscribe calls handle for every event published from now on. handle must
+// not block, as it holds up the relay.
+func (b *EventBus) Subscribe(handle func(ctx context.Context, event models.Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, handle)
+}
+
+func (b *EventBus) Publish(ctx context.Context, event models.Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, handle := range b.subscribers {
+		handle(ctx, event)
+	}
+	return nil
+}
+
+// BrokerMessage is a message for a NATS- or Kafka-like broker. Subject is
+// the NATS subject or Kafka topic. Key keeps the messages of one list in
+// order, as a Kafka partition key. Headers carry the event ID, which brokers
+// that deduplicate, such as NATS JetStream with Nats-Msg-Id, can use to drop
+// repeated messages.
+type BrokerMessage struct {
+	Subject string
+	Key     string
+	Headers map[string]string
+	Data    []byte
+}
+
+// MessageBroker publishes messages to a broker. Adapters for a NATS or Kafka
+// client implement it.
+type MessageBroker interface {
+	PublishMessage(ctx context.Context, msg BrokerMessage) error
+}
+
+// BrokerSink publishes events to a MessageBroker, each to the subject
+// "<prefix>.<event type>", such as todo.task.created.
+type BrokerSink struct {
+	name   string
+	broker MessageBroker
+	prefix string
+}
+
+// NewBrokerSink returns a sink named name, which is recorded in the outbox
+// for every event the broker has.
+func NewBrokerSink(name string, broker MessageBroker, prefix string) *BrokerSink {
+	return &BrokerSink{name: name, broker: broker, prefix: prefix}
+}
+
+func (s *BrokerSink) Name() string { return s.name }
+
+func (s *BrokerSink) Publish(ctx context.Context, event models.Event) error {
+	ctx, span := otel.Tracer("").Start(ctx, "BrokerSink.Publish")
+	defer span.End()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.broker.PublishMessage(ctx, BrokerMessage{
+		Subject: s.prefix + "." + string(event.Type),
+		Key:     strconv.Itoa(event.ListID),
+		Headers: map[string]string{"event-id": event.ID, "event-type": string(event.Type)},
+		Data:    data,
+	})
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+)
+
+type fakeBroker struct {
+	messages []BrokerMessage
+}
+
+func (b *fakeBroker) PublishMessage(ctx context.Context, msg BrokerMessage) error {
+	b.messages = append(b.messages, msg)
+	return nil
+}
+
+func TestEventBus_Publish(t *testing.T) {
+	bus := NewEventBus()
+	var first, second []string
+	bus.Subscribe(func(ctx context.Context, event models.Event) { first = append(first, event.ID) })
+	bus.Subscribe(func(ctx context.Context, event models.Event) { second = append(second, event.ID) })
+
+	assert.NoError(t, bus.Publish(context.Background(), models.Event{ID: "a"}))
+	assert.NoError(t, bus.Publish(context.Background(), models.Event{ID: "b"}))
+
+	assert.Equal(t, []string{"a", "b"}, first)
+	assert.Equal(t, []string{"a", "b"}, second)
+}
+
+func TestBrokerSink_Publish(t *testing.T) {
+	broker := &fakeBroker{}
+	sink := NewBrokerSink("nats", broker, "todo")
+	event := models.Event{ID: "evt", Type: models.EventTaskCompleted, ListID: 4, Task: &models.Task{ID: 9, Title: "Ship it"}}
+
+	assert.NoError(t, sink.Publish(context.Background(), event))
+
+	assert.Equal(t, "nats", sink.Name())
+	if assert.Len(t, broker.messages, 1) {
+		msg := broker.messages[0]
+		assert.Equal(t, "todo.task.completed", msg.Subject)
+		assert.Equal(t, "4", msg.Key)
+		assert.Equal(t, map[string]string{"event-id": "evt", "event-type": "task.completed"}, msg.Headers)
+		var decoded models.Event
+		assert.NoError(t, json.Unmarshal(msg.Data, &decoded))
+		assert.Equal(t, "Ship it", decoded.Task.Title)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+// ImportRunnerConfig tunes the import runner. Lease must be longer than a
+// job takes to work through the rows between two progress reports, or
+// another replica may take the job over.
+type ImportRunnerConfig struct {
+	Interval    time.Duration
+	Lease       time.Duration
+	MaxAttempts int
+}
+
+// DefaultImportRunnerConfig polls every 10 seconds, leases jobs for two
+// minutes and gives up on a job after three attempts.
+func DefaultImportRunnerConfig() ImportRunnerConfig {
+	return ImportRunnerConfig{
+		Interval:    10 * time.Second,
+		Lease:       2 * time.Minute,
+		MaxAttempts: 3,
+	}
+}
+
+// ImportRunner applies import jobs. Every replica of the backend runs one,
+// and jobs are leased so that only one replica applies a job at a time. A
+// job whose runner died is taken over once its lease runs out; it starts
+// from scratch, since nothing is created until a job completes.
+type ImportRunner struct {
+	repo   repositories.ImportRepository
+	config ImportRunnerConfig
+	wake   chan struct{}
+}
+
+func NewImportRunner(repo repositories.ImportRepository, config ImportRunnerConfig) *ImportRunner {
+	return &ImportRunner{repo: repo, config: config, wake: make(chan struct{}, 1)}
+}
+
+// Run applies pending jobs every interval, and when woken, until ctx is
+// done.
+func (r *ImportRunner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.RunPending(ctx); err != nil && ctx.Err() == nil {
+			logging.ContextLogger(ctx).Error("Failed to run import jobs", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// Wake makes Run look for pending jobs now rather than at the next tick.
+func (r *ImportRunner) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RunPending applies pending jobs until there are none and returns how many
+// it finished.
+func (r *ImportRunner) RunPending(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ImportRunner.RunPending")
+	defer span.End()
+
+	finished := 0
+	for {
+		job, err := r.RunJob(ctx, 0)
+		if err != nil || job == nil {
+			return finished, err
+		}
+		finished++
+	}
+}
+
+// RunJob claims and applies jobID, or the oldest pending job if it is 0.
+// It returns the finished job, or nil if there was nothing to claim.
+func (r *ImportRunner) RunJob(ctx context.Context, jobID int) (*models.ImportJob, error) {
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	job, err := r.repo.ClaimJob(ctx, token, r.config.Lease, jobID)
+	if err != nil || job == nil {
+		return nil, err
+	}
+	if job.Attempts > r.config.MaxAttempts {
+		return r.fail(ctx, job, token, fmt.Sprintf("The import was given up after %d attempts", r.config.MaxAttempts))
+	}
+
+	applied, err := r.repo.ApplyJob(ctx, job, token, r.config.Lease)
+	if err == nil {
+		logging.ContextLogger(ctx).Info("Import completed", "event", "import_completed", "jobID", job.ID, "userID", job.UserID,
+			"created", applied.Created, "skipped", applied.Skipped)
+		return applied, nil
+	}
+	// The user lost access to a list since the preview; trying again will
+	// not help.
+	if reason := mapTaskListError(err); errors.Is(reason, repositories.ErrTaskNotFound) || errors.Is(reason, ErrTaskListNotFound) ||
+		errors.Is(reason, ErrListPermissionDenied) {
+		return r.fail(ctx, job, token, reason.Error())
+	}
+	if errors.Is(err, repositories.ErrImportLeaseLost) || ctx.Err() != nil {
+		return nil, err
+	}
+	logging.ContextLogger(ctx).Error("Failed to apply import", "jobID", job.ID, "attempt", job.Attempts, "error", err)
+	if job.Attempts >= r.config.MaxAttempts {
+		return r.fail(ctx, job, token, "The import could not be applied")
+	}
+	// The job is tried again once its lease runs out.
+	return nil, err
+}
+
+func (r *ImportRunner) fail(ctx context.Context, job *models.ImportJob, token, message string) (*models.ImportJob, error) {
+	if err := r.repo.FailJob(ctx, job.ID, token, message); err != nil {
+		return nil, err
+	}
+	logging.ContextLogger(ctx).Warn("Import failed", "event", "import_failed", "jobID", job.ID, "userID", job.UserID, "reason", message)
+	now := time.Now()
+	job.Status, job.Error, job.FinishedAt = models.ImportFailed, message, &now
+	return job, nil
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+type MockImportRepository struct {
+	mock.Mock
+}
+
+var _ repositories.ImportRepository = (*MockImportRepository)(nil)
+
+func (m *MockImportRepository) ListTitles(ctx context.Context, listIDs []int) (map[int][]string, error) {
+	args := m.Called(ctx, listIDs)
+	titles, _ := args.Get(0).(map[int][]string)
+	return titles, args.Error(1)
+}
+
+func (m *MockImportRepository) CreateJob(ctx context.Context, job *models.ImportJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
+
+func (m *MockImportRepository) GetJob(ctx context.Context, jobID, userID int) (*models.ImportJob, error) {
+	args := m.Called(ctx, jobID, userID)
+	job, _ := args.Get(0).(*models.ImportJob)
+	return job, args.Error(1)
+}
+
+func (m *MockImportRepository) ClaimJob(ctx context.Context, token string, lease time.Duration, jobID int) (*models.ImportJob, error) {
+	args := m.Called(ctx, token, lease, jobID)
+	job, _ := args.Get(0).(*models.ImportJob)
+	return job, args.Error(1)
+}
+
+func (m *MockImportRepository) ApplyJob(ctx context.Context, job *models.ImportJob, token string, lease time.Duration) (*models.ImportJob, error) {
+	args := m.Called(ctx, job, token, lease)
+	applied, _ := args.Get(0).(*models.ImportJob)
+	return applied, args.Error(1)
+}
+
+func (m *MockImportRepository) FailJob(ctx context.Context, jobID int, token, message string) error {
+	args := m.Called(ctx, jobID, token, message)
+	return args.Error(0)
+}
+
+var testImportRunnerConfig = ImportRunnerConfig{Interval: time.Second, Lease: time.Minute, MaxAttempts: 2}
+
+func TestImportRunner_RunJob(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	job := &models.ImportJob{ID: 3, UserID: 1, Status: models.ImportRunning, Total: 2, Attempts: 1}
+	completed := &models.ImportJob{ID: 3, Status: models.ImportCompleted, Total: 2, Processed: 2, Created: 1, Skipped: 1}
+
+	var token string
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Run(func(args mock.Arguments) {
+		token = args.String(1)
+	}).Return(job, nil)
+	mockRepo.On("ApplyJob", mock.Anything, job, mock.Anything, time.Minute).Return(completed, nil)
+
+	got, err := runner.RunJob(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, completed, got)
+	assert.NotEmpty(t, token)
+	mockRepo.AssertCalled(t, "ApplyJob", mock.Anything, job, token, time.Minute)
+}
+
+func TestImportRunner_RunJob_NothingToClaim(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(nil, nil)
+
+	got, err := runner.RunJob(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	mockRepo.AssertNotCalled(t, "ApplyJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportRunner_RunJob_FailsWhenAccessIsLost(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	job := &models.ImportJob{ID: 3, Status: models.ImportRunning, Attempts: 1}
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(job, nil)
+	mockRepo.On("ApplyJob", mock.Anything, job, mock.Anything, time.Minute).Return(nil, repositories.ErrListRoleTooLow)
+	mockRepo.On("FailJob", mock.Anything, 3, mock.Anything, ErrListPermissionDenied.Error()).Return(nil)
+
+	got, err := runner.RunJob(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportFailed, got.Status)
+	assert.Equal(t, ErrListPermissionDenied.Error(), got.Error)
+	assert.NotNil(t, got.FinishedAt)
+}
+
+func TestImportRunner_RunJob_RetriesUntilMaxAttempts(t *testing.T) {
+	applyErr := errors.New("connection reset")
+
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	job := &models.ImportJob{ID: 3, Status: models.ImportRunning, Attempts: 1}
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(job, nil)
+	mockRepo.On("ApplyJob", mock.Anything, job, mock.Anything, time.Minute).Return(nil, applyErr)
+
+	got, err := runner.RunJob(context.Background(), 3)
+
+	assert.ErrorIs(t, err, applyErr)
+	assert.Nil(t, got)
+	mockRepo.AssertNotCalled(t, "FailJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// The last attempt fails the job rather than leaving it to be claimed
+	// again.
+	mockRepo = new(MockImportRepository)
+	runner = NewImportRunner(mockRepo, testImportRunnerConfig)
+	job = &models.ImportJob{ID: 3, Status: models.ImportRunning, Attempts: 2}
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(job, nil)
+	mockRepo.On("ApplyJob", mock.Anything, job, mock.Anything, time.Minute).Return(nil, applyErr)
+	mockRepo.On("FailJob", mock.Anything, 3, mock.Anything, "The import could not be applied").Return(nil)
+
+	got, err = runner.RunJob(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportFailed, got.Status)
+}
+
+func TestImportRunner_RunJob_GivesUpAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	job := &models.ImportJob{ID: 3, Status: models.ImportRunning, Attempts: 3}
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(job, nil)
+	mockRepo.On("FailJob", mock.Anything, 3, mock.Anything, "The import was given up after 2 attempts").Return(nil)
+
+	got, err := runner.RunJob(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ImportFailed, got.Status)
+	mockRepo.AssertNotCalled(t, "ApplyJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestImportRunner_RunPending(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	first := &models.ImportJob{ID: 1, Attempts: 1}
+	second := &models.ImportJob{ID: 2, Attempts: 1}
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 0).Return(first, nil).Once()
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 0).Return(second, nil).Once()
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 0).Return(nil, nil).Once()
+	mockRepo.On("ApplyJob", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(&models.ImportJob{Status: models.ImportCompleted}, nil)
+
+	finished, err := runner.RunPending(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, finished)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportRunner_Run_StopsWithContext(t *testing.T) {
+	mockRepo := new(MockImportRepository)
+	runner := NewImportRunner(mockRepo, testImportRunnerConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepo.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 0).Run(func(mock.Arguments) {
+		cancel()
+	}).Return(nil, nil)
+
+	done := make(chan struct{})
+	go func() {
+		runner.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after the context was cancelled")
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrInvalidInvitation  = errors.New("Invitation is invalid or expired")
+	ErrInvitationNotFound = errors.New("Invitation not found")
+)
+
+type InvitationServiceInterface interface {
+	CreateInvitation(ctx context.Context, userID uint, listID int, req models.CreateInvitationRequest) (*models.CreatedInvitation, error)
+	ListInvitations(ctx context.Context, userID uint, listID int) ([]models.Invitation, error)
+	RevokeInvitation(ctx context.Context, userID uint, listID, invitationID int) error
+	PreviewInvitation(ctx context.Context, token string) (*models.Invitation, error)
+	AcceptInvitation(ctx context.Context, userID uint, token string) (*models.TaskList, error)
+	DeclineInvitation(ctx context.Context, token string) error
+}
+
+type InvitationService struct {
+	repo      repositories.InvitationRepository
+	lists     repositories.TaskListRepository
+	keys      *jwtkeys.Manager
+	mailer    mailer.Mailer
+	acceptURL string
+}
+
+// NewInvitationService creates an InvitationService that signs invitation
+// tokens with keys. acceptURL is the frontend page that receives the token
+// as its "token" query parameter.
+func NewInvitationService(repo repositories.InvitationRepository, lists repositories.TaskListRepository, keys *jwtkeys.Manager, mailer mailer.Mailer, acceptURL string) InvitationServiceInterface {
+	return &InvitationService{repo: repo, lists: lists, keys: keys, mailer: mailer, acceptURL: acceptURL}
+}
+
+// CreateInvitation invites someone to a list. It requires the admin role.
+// The invitation is mailed if req has an email address; either way the
+// token and link are returned so the inviter can pass them on.
+func (s *InvitationService) CreateInvitation(ctx context.Context, userID uint, listID int, req models.CreateInvitationRequest) (*models.CreatedInvitation, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.CreateInvitation")
+	defer span.End()
+
+	if !assignableListRole(req.Role) {
+		return nil, ErrInvalidListRole
+	}
+	list, err := s.lists.GetList(ctx, listID, int(userID))
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	if !list.Role.AtLeast(models.ListRoleAdmin) {
+		return nil, ErrListPermissionDenied
+	}
+	if list.Personal {
+		return nil, ErrPersonalList
+	}
+
+	tokenID, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, errors.New("Failed to generate invitation token")
+	}
+	inv := &models.Invitation{
+		ListID:    listID,
+		InviterID: int(userID),
+		Email:     strings.TrimSpace(req.Email),
+		Role:      req.Role,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, inv, tokenID); err != nil {
+		return nil, mapTaskListError(err)
+	}
+
+	token, err := utils.GenerateInvitationToken(s.keys, inv.ID, tokenID, inv.ExpiresAt)
+	if err != nil {
+		// Nobody holds a token for the invitation, so it must not stay
+		// listed as pending.
+		if err := s.repo.RevokeInvitation(ctx, listID, inv.ID); err != nil {
+			logging.ContextLogger(ctx).Error("Failed to revoke unusable invitation", "invitationID", inv.ID, "error", err)
+		}
+		return nil, errors.New("Failed to generate invitation token")
+	}
+	link := tokenLink(s.acceptURL, token)
+
+	if inv.Email != "" {
+		msg := mailer.Message{
+			To:      inv.Email,
+			Subject: fmt.Sprintf("%s invited you to %q", inv.InviterName, inv.ListName),
+			Body: fmt.Sprintf("%s invited you to join the list %q as %s.\n\n"+
+				"Open the following link within %s to accept or decline the invitation. "+
+				"If you do not have an account yet, you can sign up there:\n%s\n",
+				inv.InviterName, inv.ListName, inv.Role, invitationTTL, link),
+		}
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			logging.ContextLogger(ctx).Error("Failed to send invitation mail", "invitationID", inv.ID, "error", err)
+		}
+	}
+
+	logging.ContextLogger(ctx).Info("Invitation created", "event", "invitation_created", "invitationID", inv.ID, "listID", listID, "role", inv.Role, "userID", userID)
+	return &models.CreatedInvitation{Invitation: *inv, Token: token, URL: link}, nil
+}
+
+// ListInvitations returns the pending invitations of a list. It requires the
+// admin role.
+func (s *InvitationService) ListInvitations(ctx context.Context, userID uint, listID int) ([]models.Invitation, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.ListInvitations")
+	defer span.End()
+
+	if err := requireListRole(ctx, s.lists, userID, listID, models.ListRoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPendingInvitations(ctx, listID)
+}
+
+// RevokeInvitation invalidates a pending invitation. It requires the admin
+// role.
+func (s *InvitationService) RevokeInvitation(ctx context.Context, userID uint, listID, invitationID int) error {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.RevokeInvitation")
+	defer span.End()
+
+	if err := requireListRole(ctx, s.lists, userID, listID, models.ListRoleAdmin); err != nil {
+		return err
+	}
+	err := s.repo.RevokeInvitation(ctx, listID, invitationID)
+	if errors.Is(err, repositories.ErrInvitationNotFound) {
+		return ErrInvitationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	logging.ContextLogger(ctx).Info("Invitation revoked", "event", "invitation_revoked", "invitationID", invitationID, "listID", listID, "userID", userID)
+	return nil
+}
+
+// PreviewInvitation returns the pending invitation a token belongs to, so
+// the invitee can see the list and the inviter before deciding.
+func (s *InvitationService) PreviewInvitation(ctx context.Context, token string) (*models.Invitation, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.PreviewInvitation")
+	defer span.End()
+
+	invitationID, tokenID, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.repo.GetInvitation(ctx, invitationID, tokenID)
+	if errors.Is(err, repositories.ErrInvitationNotFound) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != models.InvitationPending {
+		return nil, ErrInvalidInvitation
+	}
+	return inv, nil
+}
+
+// AcceptInvitation makes the user a member of the invited list and returns
+// the list. The token cannot be used again afterwards.
+func (s *InvitationService) AcceptInvitation(ctx context.Context, userID uint, token string) (*models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.AcceptInvitation")
+	defer span.End()
+
+	invitationID, tokenID, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+	listID, err := s.repo.AcceptInvitation(ctx, invitationID, tokenID, int(userID))
+	if errors.Is(err, repositories.ErrInvitationNotPending) {
+		return nil, ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	logging.ContextLogger(ctx).Info("Invitation accepted", "event", "invitation_accepted", "invitationID", invitationID, "listID", listID, "userID", userID)
+
+	// The list may belong to another workspace than the request is scoped
+	// to.
+	list, err := s.lists.GetList(tenant.WithWorkspace(ctx, 0), listID, int(userID))
+	return list, mapTaskListError(err)
+}
+
+// DeclineInvitation rejects an invitation. Holding the token is enough, so
+// invitees without an account can decline too.
+func (s *InvitationService) DeclineInvitation(ctx context.Context, token string) error {
+	_, span := otel.Tracer("").Start(ctx, "InvitationService.DeclineInvitation")
+	defer span.End()
+
+	invitationID, tokenID, err := s.parseToken(token)
+	if err != nil {
+		return err
+	}
+	err = s.repo.DeclineInvitation(ctx, invitationID, tokenID)
+	if errors.Is(err, repositories.ErrInvitationNotPending) {
+		return ErrInvalidInvitation
+	}
+	if err != nil {
+		return err
+	}
+	logging.ContextLogger(ctx).Info("Invitation declined", "event", "invitation_declined", "invitationID", invitationID)
+	return nil
+}
+
+func (s *InvitationService) parseToken(token string) (int, string, error) {
+	invitationID, tokenID, err := utils.ParseInvitationToken(s.keys, token)
+	if err != nil {
+		return 0, "", ErrInvalidInvitation
+	}
+	return invitationID, tokenID, nil
+}
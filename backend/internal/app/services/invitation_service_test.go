@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+// MockInvitationRepository is a mock implementation of the InvitationRepository interface
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepository) CreateInvitation(ctx context.Context, inv *models.Invitation, tokenID string) error {
+	args := m.Called(ctx, inv, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) GetInvitation(ctx context.Context, invitationID int, tokenID string) (*models.Invitation, error) {
+	args := m.Called(ctx, invitationID, tokenID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) ListPendingInvitations(ctx context.Context, listID int) ([]models.Invitation, error) {
+	args := m.Called(ctx, listID)
+	return args.Get(0).([]models.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) AcceptInvitation(ctx context.Context, invitationID int, tokenID string, userID int) (int, error) {
+	args := m.Called(ctx, invitationID, tokenID, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockInvitationRepository) DeclineInvitation(ctx context.Context, invitationID int, tokenID string) error {
+	args := m.Called(ctx, invitationID, tokenID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) RevokeInvitation(ctx context.Context, listID, invitationID int) error {
+	args := m.Called(ctx, listID, invitationID)
+	return args.Error(0)
+}
+
+type invitationTestEnv struct {
+	repo    *MockInvitationRepository
+	lists   *MockTaskListRepository
+	mailer  *MockMailer
+	service InvitationServiceInterface
+}
+
+func newInvitationTestEnv() *invitationTestEnv {
+	env := &invitationTestEnv{repo: new(MockInvitationRepository), lists: new(MockTaskListRepository), mailer: new(MockMailer)}
+	env.service = NewInvitationService(env.repo, env.lists, newTestKeys(), env.mailer, "http://localhost/invitation")
+	return env
+}
+
+func TestInvitationService_CreateInvitation(t *testing.T) {
+	env := newInvitationTestEnv()
+	ctx := context.Background()
+
+	var tokenID string
+	env.lists.On("GetList", ctx, 3, 1).Return(&models.TaskList{ID: 3, Name: "Groceries", Role: models.ListRoleAdmin}, nil)
+	env.repo.On("CreateInvitation", ctx, mock.MatchedBy(func(inv *models.Invitation) bool {
+		return inv.ListID == 3 && inv.InviterID == 1 && inv.Email == "bob@example.com" && inv.Role == models.ListRoleEditor
+	}), mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		inv := args.Get(1).(*models.Invitation)
+		inv.ID, inv.ListName, inv.InviterName = 9, "Groceries", "alice"
+		tokenID = args.String(2)
+	}).Return(nil)
+	env.mailer.On("Send", ctx, mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "bob@example.com" && strings.Contains(msg.Body, "http://localhost/invitation?token=")
+	})).Return(nil)
+
+	created, err := env.service.CreateInvitation(ctx, 1, 3, models.CreateInvitationRequest{Email: " bob@example.com ", Role: models.ListRoleEditor})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 9, created.ID)
+	assert.Contains(t, created.URL, created.Token)
+
+	invitationID, gotTokenID, err := utils.ParseInvitationToken(newTestKeys(), created.Token)
+	assert.Error(t, err, "tokens must not verify with other keys")
+	assert.Zero(t, invitationID)
+	assert.Empty(t, gotTokenID)
+
+	invitationID, gotTokenID, err = utils.ParseInvitationToken(env.service.(*InvitationService).keys, created.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, invitationID)
+	assert.Equal(t, tokenID, gotTokenID)
+	env.repo.AssertExpectations(t)
+	env.mailer.AssertExpectations(t)
+}
+
+func TestInvitationService_CreateInvitation_Rejections(t *testing.T) {
+	tests := []struct {
+		name string
+		list *models.TaskList
+		role models.ListRole
+		want error
+	}{
+		{"editor cannot invite", &models.TaskList{ID: 3, Role: models.ListRoleEditor}, models.ListRoleViewer, ErrListPermissionDenied},
+		{"owner role cannot be granted", &models.TaskList{ID: 3, Role: models.ListRoleOwner}, models.ListRoleOwner, ErrInvalidListRole},
+		{"personal list", &models.TaskList{ID: 3, Role: models.ListRoleOwner, Personal: true}, models.ListRoleViewer, ErrPersonalList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newInvitationTestEnv()
+			ctx := context.Background()
+			env.lists.On("GetList", ctx, 3, 1).Return(tt.list, nil)
+
+			_, err := env.service.CreateInvitation(ctx, 1, 3, models.CreateInvitationRequest{Role: tt.role})
+
+			assert.ErrorIs(t, err, tt.want)
+			env.repo.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestInvitationService_AcceptInvitation(t *testing.T) {
+	env := newInvitationTestEnv()
+	ctx := context.Background()
+	keys := env.service.(*InvitationService).keys
+	token, _ := utils.GenerateInvitationToken(keys, 9, "nonce", time.Now().Add(time.Hour))
+
+	env.repo.On("AcceptInvitation", ctx, 9, "nonce", 2).Return(3, nil).Once()
+	env.repo.On("AcceptInvitation", ctx, 9, "nonce", 2).Return(0, repositories.ErrInvitationNotPending)
+	env.lists.On("GetList", mock.Anything, 3, 2).Return(&models.TaskList{ID: 3, Role: models.ListRoleEditor}, nil)
+
+	list, err := env.service.AcceptInvitation(ctx, 2, token)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, list.ID)
+
+	_, err = env.service.AcceptInvitation(ctx, 2, token)
+	assert.ErrorIs(t, err, ErrInvalidInvitation, "tokens are single-use")
+}
+
+func TestInvitationService_AcceptInvitation_InvalidTokens(t *testing.T) {
+	env := newInvitationTestEnv()
+	ctx := context.Background()
+	keys := env.service.(*InvitationService).keys
+
+	expired, _ := utils.GenerateInvitationToken(keys, 9, "nonce", time.Now().Add(-time.Minute))
+	accessToken, _ := utils.GenerateToken(keys, 1, "user", nil)
+	for name, token := range map[string]string{"garbage": "not-a-token", "expired": expired, "access token": accessToken} {
+		t.Run(name, func(t *testing.T) {
+			_, err := env.service.AcceptInvitation(ctx, 2, token)
+			assert.ErrorIs(t, err, ErrInvalidInvitation)
+		})
+	}
+	env.repo.AssertNotCalled(t, "AcceptInvitation", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	invitation, _ := utils.GenerateInvitationToken(keys, 9, "nonce", time.Now().Add(time.Hour))
+	_, err := utils.ParseToken(keys, invitation)
+	assert.Error(t, err, "invitation tokens must not work as access tokens")
+}
+
+func TestInvitationService_PreviewInvitation_NotPending(t *testing.T) {
+	env := newInvitationTestEnv()
+	ctx := context.Background()
+	token, _ := utils.GenerateInvitationToken(env.service.(*InvitationService).keys, 9, "nonce", time.Now().Add(time.Hour))
+
+	env.repo.On("GetInvitation", ctx, 9, "nonce").Return(&models.Invitation{ID: 9, Status: models.InvitationRevoked}, nil)
+
+	_, err := env.service.PreviewInvitation(ctx, token)
+
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+}
+
+func TestInvitationService_RevokeInvitation(t *testing.T) {
+	env := newInvitationTestEnv()
+	ctx := context.Background()
+
+	env.lists.On("GetMemberRole", ctx, 3, 1).Return(models.ListRoleAdmin, nil)
+	env.repo.On("RevokeInvitation", ctx, 3, 9).Return(nil)
+	env.repo.On("RevokeInvitation", ctx, 3, 10).Return(repositories.ErrInvitationNotFound)
+
+	assert.NoError(t, env.service.RevokeInvitation(ctx, 1, 3, 9))
+	assert.ErrorIs(t, env.service.RevokeInvitation(ctx, 1, 3, 10), ErrInvitationNotFound)
+}
+
+func TestAuthService_Signup_WithInvitation(t *testing.T) {
+	env := newInvitationTestEnv()
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys(), WithMailer(env.mailer, "http://localhost/verify"), WithInvitations(env.service))
+	ctx := context.Background()
+	token, _ := utils.GenerateInvitationToken(env.service.(*InvitationService).keys, 9, "nonce", time.Now().Add(time.Hour))
+
+	env.repo.On("GetInvitation", ctx, 9, "nonce").
+		Return(&models.Invitation{ID: 9, ListID: 3, Email: "Bob@example.com", Status: models.InvitationPending}, nil)
+	mockRepo.On("CreateUser", ctx, mock.MatchedBy(func(u *models.User) bool { return u.EmailVerified })).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 7 }).Return(nil)
+	env.repo.On("AcceptInvitation", ctx, 9, "nonce", 7).Return(3, nil)
+	env.lists.On("GetList", mock.Anything, 3, 7).Return(&models.TaskList{ID: 3, Role: models.ListRoleViewer}, nil)
+
+	list, err := authService.Signup(ctx, "bob", "bob@example.com", "newpassword123", token)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, list.ID)
+	mockRepo.AssertNotCalled(t, "CreateEmailVerificationToken", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	env.mailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestAuthService_Signup_InvalidInvitation(t *testing.T) {
+	env := newInvitationTestEnv()
+	mockRepo := new(MockAuthRepository)
+	authService := NewAuthService(mockRepo, newTestKeys(), WithInvitations(env.service))
+
+	_, err := authService.Signup(context.Background(), "bob", "bob@example.com", "newpassword123", "stale")
+
+	assert.ErrorIs(t, err, ErrInvalidInvitation)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
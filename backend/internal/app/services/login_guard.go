@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("Invalid credentials")
+	ErrTooManyAttempts    = errors.New("Too many failed login attempts, try again later")
+	ErrAccountLocked      = errors.New("Account is temporarily locked")
+)
+
+// LoginError is returned by AuthService.Login when a login is rejected. Err
+// is one of ErrInvalidCredentials, ErrTooManyAttempts or ErrAccountLocked.
+type LoginError struct {
+	Err             error
+	RetryAfter      time.Duration
+	CaptchaRequired bool
+}
+
+func (e *LoginError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *LoginError) Unwrap() error {
+	return e.Err
+}
+
+// LoginGuardConfig configures brute-force protection for logins.
+type LoginGuardConfig struct {
+	// FreeAttempts is the number of failures allowed before backoff starts.
+	FreeAttempts int
+	// BaseDelay is the wait after the first failure beyond FreeAttempts. It
+	// doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// UserLockoutThreshold and IPLockoutThreshold are the failure counts at
+	// which an account or client IP is locked for LockoutDuration.
+	UserLockoutThreshold int
+	IPLockoutThreshold   int
+	LockoutDuration      time.Duration
+	// CaptchaThreshold is the failure count from which responses signal that
+	// a CAPTCHA should be shown. Zero disables the signal.
+	CaptchaThreshold int
+	// FailureWindow is how long a failure is remembered.
+	FailureWindow time.Duration
+}
+
+// DefaultLoginGuardConfig returns the configuration used unless overridden.
+func DefaultLoginGuardConfig() LoginGuardConfig {
+	return LoginGuardConfig{
+		FreeAttempts:         3,
+		BaseDelay:            time.Second,
+		MaxDelay:             5 * time.Minute,
+		UserLockoutThreshold: 10,
+		IPLockoutThreshold:   50,
+		LockoutDuration:      15 * time.Minute,
+		CaptchaThreshold:     3,
+		FailureWindow:        24 * time.Hour,
+	}
+}
+
+// LoginGuard tracks failed logins per account and per client IP and
+// decides whether a login attempt may proceed. Accounts are identified by
+// the key from accountAttemptKey.
+type LoginGuard struct {
+	repo repositories.LoginAttemptRepository
+	cfg  LoginGuardConfig
+	now  func() time.Time
+}
+
+func NewLoginGuard(repo repositories.LoginAttemptRepository, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{repo: repo, cfg: cfg, now: time.Now}
+}
+
+// accountAttemptKey returns the key under which failed logins against an
+// account are counted. For an existing user it is the user ID, so that
+// attempts with the username and with the email address share one counter.
+// A login that names no account is keyed by the normalized login instead,
+// which throttles it exactly like a real one and so does not reveal which
+// accounts exist.
+func accountAttemptKey(user *models.User, login string) string {
+	if user != nil {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "login:" + strings.ToLower(strings.TrimSpace(login))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// LoginClaim is a login attempt admitted by LoginGuard.Check. It already
+// counts as a failure; RecordFailure or RecordSuccess settles it once the
+// credentials have been checked.
+type LoginClaim struct {
+	accountKey string
+	clientIP   string
+	account    *models.LoginAttempt
+	ip         *models.LoginAttempt
+}
+
+// Check returns a *LoginError if the account or the client IP is locked or
+// still backing off from earlier failures. Otherwise it counts the attempt
+// as a failure before the credentials are checked, in the same step as the
+// check itself, so that parallel attempts cannot all pass the check before
+// any of them has failed.
+func (g *LoginGuard) Check(ctx context.Context, accountKey, clientIP string) (*LoginClaim, error) {
+	_, span := otel.Tracer("").Start(ctx, "LoginGuard.Check")
+	defer span.End()
+
+	now := g.now()
+	var worst *LoginError
+	keys := []string{accountKey, ipAttemptKey(clientIP)}
+	attempts, err := g.repo.ClaimLoginAttempt(ctx, keys, g.cfg.FailureWindow, func(attempts []*models.LoginAttempt) error {
+		for _, attempt := range attempts {
+			if loginErr := g.evaluate(attempt, now); loginErr != nil && (worst == nil || loginErr.RetryAfter > worst.RetryAfter) {
+				worst = loginErr
+			}
+		}
+		if worst != nil {
+			return worst
+		}
+		return nil
+	})
+
+	if worst != nil {
+		span.SetAttributes(
+			attribute.Bool("login.throttled", true),
+			attribute.Bool("login.locked", errors.Is(worst, ErrAccountLocked)),
+			attribute.Int64("login.retry_after_seconds", int64(worst.RetryAfter.Seconds())),
+		)
+		logging.ContextLogger(ctx).Warn("Login throttled",
+			"event", "login_throttled",
+			"account", accountKey,
+			"client_ip", clientIP,
+			"locked", errors.Is(worst, ErrAccountLocked),
+			"retry_after", worst.RetryAfter.String(),
+		)
+		return nil, worst
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &LoginClaim{accountKey: accountKey, clientIP: clientIP, account: attempts[0], ip: attempts[1]}, nil
+}
+
+// RecordFailure settles claim as a failed login, locks the account or the
+// client IP once enough failures have been counted, and returns a
+// *LoginError that describes the state the caller should report.
+func (g *LoginGuard) RecordFailure(ctx context.Context, claim *LoginClaim) error {
+	_, span := otel.Tracer("").Start(ctx, "LoginGuard.RecordFailure")
+	defer span.End()
+
+	userAttempt, ipAttempt := claim.account, claim.ip
+	span.SetAttributes(
+		attribute.Int("login.user_failures", userAttempt.Failures),
+		attribute.Int("login.ip_failures", ipAttempt.Failures),
+	)
+	logging.ContextLogger(ctx).Warn("Login failed",
+		"event", "login_failed",
+		"account", claim.accountKey,
+		"client_ip", claim.clientIP,
+		"user_failures", userAttempt.Failures,
+		"ip_failures", ipAttempt.Failures,
+	)
+
+	if err := g.lockIfNeeded(ctx, span, userAttempt, g.cfg.UserLockoutThreshold); err != nil {
+		return err
+	}
+	if err := g.lockIfNeeded(ctx, span, ipAttempt, g.cfg.IPLockoutThreshold); err != nil {
+		return err
+	}
+
+	failures := max(userAttempt.Failures, ipAttempt.Failures)
+	return &LoginError{
+		Err:             ErrInvalidCredentials,
+		CaptchaRequired: g.captchaRequired(failures),
+	}
+}
+
+// RecordSuccess settles claim as a login with valid credentials. It clears
+// the failure history of the account and takes back the failure counted
+// against the client IP. The rest of the client IP history is kept so a
+// valid account cannot be used to reset it.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, claim *LoginClaim) error {
+	_, span := otel.Tracer("").Start(ctx, "LoginGuard.RecordSuccess")
+	defer span.End()
+
+	if err := g.repo.ResetLoginAttempts(ctx, claim.accountKey); err != nil {
+		return err
+	}
+	return g.repo.ReleaseLoginAttempt(ctx, ipAttemptKey(claim.clientIP))
+}
+
+func (g *LoginGuard) lockIfNeeded(ctx context.Context, span trace.Span, attempt *models.LoginAttempt, threshold int) error {
+	if threshold <= 0 || attempt.Failures < threshold {
+		return nil
+	}
+
+	until := g.now().Add(g.cfg.LockoutDuration)
+	if err := g.repo.LockLogin(ctx, attempt.Key, until); err != nil {
+		return fmt.Errorf("error locking login: %w", err)
+	}
+
+	span.SetAttributes(attribute.Bool("login.locked", true), attribute.String("login.locked_key", attempt.Key))
+	logging.ContextLogger(ctx).Warn("Login locked",
+		"event", "login_locked",
+		"key", attempt.Key,
+		"failures", attempt.Failures,
+		"locked_until", until,
+	)
+	return nil
+}
+
+// evaluate returns the restriction currently in force for attempt, if any.
+func (g *LoginGuard) evaluate(attempt *models.LoginAttempt, now time.Time) *LoginError {
+	captcha := g.captchaRequired(attempt.Failures)
+	if now.Before(attempt.LockedUntil) {
+		return &LoginError{Err: ErrAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now), CaptchaRequired: captcha}
+	}
+
+	next := attempt.LastFailureAt.Add(g.backoff(attempt.Failures))
+	if now.Before(next) {
+		return &LoginError{Err: ErrTooManyAttempts, RetryAfter: next.Sub(now), CaptchaRequired: captcha}
+	}
+	return nil
+}
+
+// backoff returns how long to wait after the given number of failures.
+func (g *LoginGuard) backoff(failures int) time.Duration {
+	over := failures - g.cfg.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := g.cfg.BaseDelay
+	for i := 1; i < over; i++ {
+		delay *= 2
+		if delay >= g.cfg.MaxDelay {
+			return g.cfg.MaxDelay
+		}
+	}
+	return min(delay, g.cfg.MaxDelay)
+}
+
+func (g *LoginGuard) captchaRequired(failures int) bool {
+	return g.cfg.CaptchaThreshold > 0 && failures >= g.cfg.CaptchaThreshold
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockLoginAttemptRepository is a mock implementation of the LoginAttemptRepository interface
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+// ClaimLoginAttempt passes the configured records to admit and, if admit
+// accepts them, counts a failure against each of them in place.
+func (m *MockLoginAttemptRepository) ClaimLoginAttempt(ctx context.Context, keys []string, window time.Duration, admit func([]*models.LoginAttempt) error) ([]*models.LoginAttempt, error) {
+	args := m.Called(ctx, keys, window)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	attempts := args.Get(0).([]*models.LoginAttempt)
+	if err := admit(attempts); err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		attempt.Failures++
+	}
+	return attempts, args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) LockLogin(ctx context.Context, key string, until time.Time) error {
+	args := m.Called(ctx, key, until)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) ResetLoginAttempts(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func newTestLoginGuard(repo *MockLoginAttemptRepository, now time.Time) *LoginGuard {
+	guard := NewLoginGuard(repo, DefaultLoginGuardConfig())
+	guard.now = func() time.Time { return now }
+	return guard
+}
+
+func TestLoginGuard_Backoff(t *testing.T) {
+	guard := NewLoginGuard(nil, DefaultLoginGuardConfig())
+
+	assert.Equal(t, time.Duration(0), guard.backoff(0))
+	assert.Equal(t, time.Duration(0), guard.backoff(3))
+	assert.Equal(t, time.Second, guard.backoff(4))
+	assert.Equal(t, 2*time.Second, guard.backoff(5))
+	assert.Equal(t, 4*time.Second, guard.backoff(6))
+	assert.Equal(t, 5*time.Minute, guard.backoff(100))
+}
+
+func TestLoginGuard_Check_Backoff(t *testing.T) {
+	mockRepo := new(MockLoginAttemptRepository)
+	now := time.Now()
+	guard := newTestLoginGuard(mockRepo, now)
+
+	ctx := context.Background()
+	account := &models.LoginAttempt{Key: "user:testuser", Failures: 5, LastFailureAt: now.Add(-time.Second)}
+	mockRepo.On("ClaimLoginAttempt", ctx, []string{"user:testuser", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{account, {Key: "ip:10.0.0.1"}}, nil)
+
+	claim, err := guard.Check(ctx, "user:testuser", "10.0.0.1")
+
+	var loginErr *LoginError
+	assert.Nil(t, claim)
+	assert.ErrorAs(t, err, &loginErr)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Equal(t, time.Second, loginErr.RetryAfter)
+	assert.True(t, loginErr.CaptchaRequired)
+	assert.Equal(t, 5, account.Failures)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestLoginGuard_Check_Locked(t *testing.T) {
+	mockRepo := new(MockLoginAttemptRepository)
+	now := time.Now()
+	guard := newTestLoginGuard(mockRepo, now)
+
+	ctx := context.Background()
+	mockRepo.On("ClaimLoginAttempt", ctx, []string{"user:testuser", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{
+		{Key: "user:testuser"},
+		{Key: "ip:10.0.0.1", Failures: 50, LastFailureAt: now.Add(-time.Hour), LockedUntil: now.Add(10 * time.Minute)},
+	}, nil)
+
+	_, err := guard.Check(ctx, "user:testuser", "10.0.0.1")
+
+	var loginErr *LoginError
+	assert.ErrorAs(t, err, &loginErr)
+	assert.ErrorIs(t, err, ErrAccountLocked)
+	assert.Equal(t, 10*time.Minute, loginErr.RetryAfter)
+}
+
+func TestLoginGuard_Check_Allowed(t *testing.T) {
+	mockRepo := new(MockLoginAttemptRepository)
+	now := time.Now()
+	guard := newTestLoginGuard(mockRepo, now)
+
+	ctx := context.Background()
+	mockRepo.On("ClaimLoginAttempt", ctx, []string{"user:testuser", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{
+		{Key: "user:testuser", Failures: 2, LastFailureAt: now},
+		{Key: "ip:10.0.0.1", Failures: 12, LastFailureAt: now.Add(-time.Hour), LockedUntil: now.Add(-time.Minute)},
+	}, nil)
+
+	claim, err := guard.Check(ctx, "user:testuser", "10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, claim.account.Failures)
+	assert.Equal(t, 13, claim.ip.Failures)
+}
+
+func TestLoginGuard_RecordFailure_Locks(t *testing.T) {
+	mockRepo := new(MockLoginAttemptRepository)
+	now := time.Now()
+	guard := newTestLoginGuard(mockRepo, now)
+
+	ctx := context.Background()
+	claim := &LoginClaim{
+		accountKey: "user:testuser",
+		clientIP:   "10.0.0.1",
+		account:    &models.LoginAttempt{Key: "user:testuser", Failures: 10, LastFailureAt: now},
+		ip:         &models.LoginAttempt{Key: "ip:10.0.0.1", Failures: 10, LastFailureAt: now},
+	}
+	mockRepo.On("LockLogin", ctx, "user:testuser", now.Add(15*time.Minute)).Return(nil)
+
+	err := guard.RecordFailure(ctx, claim)
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "LockLogin", ctx, "ip:10.0.0.1", mock.Anything)
+}
+
+func TestAuthService_Login_WithGuard(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockAttempts := new(MockLoginAttemptRepository)
+	now := time.Now()
+	authService := NewAuthService(mockRepo, newTestKeys(), WithLoginGuard(newTestLoginGuard(mockAttempts, now)))
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &models.User{ID: 1, Username: "testuser", Password: string(hashedPassword)}
+
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, 1, mock.AnythingOfType("string")).Return(nil)
+	mockAttempts.On("ClaimLoginAttempt", ctx, []string{"user:1", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{{Key: "user:1"}, {Key: "ip:10.0.0.1"}}, nil).Twice()
+	mockAttempts.On("ResetLoginAttempts", ctx, "user:1").Return(nil).Once()
+	mockAttempts.On("ReleaseLoginAttempt", ctx, "ip:10.0.0.1").Return(nil).Once()
+
+	_, err := authService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	token, err := authService.Login(ctx, "testuser", "password123", "10.0.0.1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, token)
+
+	mockAttempts.AssertExpectations(t)
+}
+
+func TestAccountAttemptKey(t *testing.T) {
+	assert.Equal(t, "user:7", accountAttemptKey(&models.User{ID: 7, Username: "TestUser"}, "TestUser"))
+	assert.Equal(t, "login:nobody@example.com", accountAttemptKey(nil, " Nobody@Example.com "))
+}
+
+func TestAuthService_Login_WithGuard_CountsPerAccount(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockAttempts := new(MockLoginAttemptRepository)
+	now := time.Now()
+	authService := NewAuthService(mockRepo, newTestKeys(), WithLoginGuard(newTestLoginGuard(mockAttempts, now)))
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	user := &models.User{ID: 1, Username: "testuser", Email: "test@example.com", Password: string(hashedPassword)}
+
+	attempt := &models.LoginAttempt{Key: "user:1"}
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+	mockRepo.On("GetUserByLogin", ctx, "test@example.com").Return(user, nil)
+	mockAttempts.On("ClaimLoginAttempt", ctx, []string{"user:1", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{attempt, {Key: "ip:10.0.0.1"}}, nil)
+	mockAttempts.On("LockLogin", ctx, "user:1", now.Add(15*time.Minute)).Return(nil).Once()
+
+	// Alternating between the username and the email address must not
+	// spread the failures over two counters.
+	for i := 0; i < 10; i++ {
+		login := "testuser"
+		if i%2 == 1 {
+			login = "test@example.com"
+		}
+		_, err := authService.Login(ctx, login, "wrongpassword", "10.0.0.1")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	assert.Equal(t, 10, attempt.Failures)
+	mockAttempts.AssertExpectations(t)
+	mockAttempts.AssertNumberOfCalls(t, "ClaimLoginAttempt", 10)
+}
+
+func TestAuthService_Login_WithGuard_UnknownLogin(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockAttempts := new(MockLoginAttemptRepository)
+	now := time.Now()
+	authService := NewAuthService(mockRepo, newTestKeys(), WithLoginGuard(newTestLoginGuard(mockAttempts, now)))
+
+	ctx := context.Background()
+	account := &models.LoginAttempt{Key: "login:ghost"}
+	mockRepo.On("GetUserByLogin", ctx, "Ghost").Return(nil, repositories.ErrUserNotFound)
+	mockAttempts.On("ClaimLoginAttempt", ctx, []string{"login:ghost", "ip:10.0.0.1"}, 24*time.Hour).Return([]*models.LoginAttempt{account, {Key: "ip:10.0.0.1"}}, nil).Once()
+
+	_, err := authService.Login(ctx, "Ghost", "password123", "10.0.0.1")
+
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, 1, account.Failures)
+	mockAttempts.AssertExpectations(t)
+}
+
+func TestAuthService_Login_WithGuard_LookupError(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockAttempts := new(MockLoginAttemptRepository)
+	authService := NewAuthService(mockRepo, newTestKeys(), WithLoginGuard(newTestLoginGuard(mockAttempts, time.Now())))
+
+	ctx := context.Background()
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(nil, errors.New("connection refused"))
+
+	_, err := authService.Login(ctx, "testuser", "password123", "10.0.0.1")
+
+	var loginErr *LoginError
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &loginErr))
+	assert.NotContains(t, err.Error(), "connection refused")
+	mockAttempts.AssertNotCalled(t, "ClaimLoginAttempt", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// memoryLoginAttempts keeps login attempts in memory and, like the row locks
+// taken by the Postgres repository, decides one claim at a time.
+type memoryLoginAttempts struct {
+	mu       sync.Mutex
+	now      time.Time
+	attempts map[string]models.LoginAttempt
+}
+
+func (r *memoryLoginAttempts) ClaimLoginAttempt(ctx context.Context, keys []string, window time.Duration, admit func([]*models.LoginAttempt) error) ([]*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempts := make([]*models.LoginAttempt, len(keys))
+	for i, key := range keys {
+		attempt := r.attempts[key]
+		attempt.Key = key
+		attempts[i] = &attempt
+	}
+	if err := admit(attempts); err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		attempt.Failures++
+		attempt.LastFailureAt = r.now
+		r.attempts[attempt.Key] = *attempt
+	}
+	return attempts, nil
+}
+
+func (r *memoryLoginAttempts) ReleaseLoginAttempt(ctx context.Context, key string) error {
+	return nil
+}
+
+func (r *memoryLoginAttempts) LockLogin(ctx context.Context, key string, until time.Time) error {
+	return nil
+}
+
+func (r *memoryLoginAttempts) ResetLoginAttempts(ctx context.Context, key string) error {
+	return nil
+}
+
+func TestAuthService_Login_WithGuard_ConcurrentAttempts(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	now := time.Now()
+	attempts := &memoryLoginAttempts{now: now, attempts: map[string]models.LoginAttempt{}}
+	guard := NewLoginGuard(attempts, DefaultLoginGuardConfig())
+	guard.now = func() time.Time { return now }
+	authService := NewAuthService(mockRepo, newTestKeys(), WithLoginGuard(guard))
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &models.User{ID: 1, Username: "testuser", Password: string(hashedPassword)}
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+
+	// All attempts start together, before any of them has failed. Only the
+	// free attempts and the one after them may reach the password check;
+	// the rest must wait for the backoff.
+	const parallel = 20
+	errs := make(chan error, parallel)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := authService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	checked, throttled := 0, 0
+	for err := range errs {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			checked++
+		case errors.Is(err, ErrTooManyAttempts):
+			throttled++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	assert.Equal(t, 4, checked)
+	assert.Equal(t, parallel-4, throttled)
+	assert.Equal(t, 4, attempts.attempts["user:1"].Failures)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"go.opentelemetry.io/otel"
+)
+
+// InAppChannel stores notifications for the notification center. It is
+// enabled for every type by default.
+type InAppChannel struct {
+	repo repositories.NotificationRepository
+}
+
+func NewInAppChannel(repo repositories.NotificationRepository) *InAppChannel {
+	return &InAppChannel{repo: repo}
+}
+
+func (c *InAppChannel) Name() string { return "in_app" }
+
+func (c *InAppChannel) DefaultEnabled(models.NotificationType) bool { return true }
+
+func (c *InAppChannel) Deliver(ctx context.Context, notifications []models.Notification) error {
+	return c.repo.CreateNotifications(ctx, notifications)
+}
+
+// MailChannel sends notifications by email. Users without an email address
+// are skipped. Mentions are only mailed to users who ask for it.
+type MailChannel struct {
+	users  repositories.AuthRepository
+	mailer mailer.Mailer
+}
+
+func NewMailChannel(users repositories.AuthRepository, mailer mailer.Mailer) *MailChannel {
+	return &MailChannel{users: users, mailer: mailer}
+}
+
+func (c *MailChannel) Name() string { return "email" }
+
+func (c *MailChannel) DefaultEnabled(t models.NotificationType) bool {
+	return t != models.NotificationMention
+}
+
+func (c *MailChannel) Deliver(ctx context.Context, notifications []models.Notification) error {
+	_, span := otel.Tracer("").Start(ctx, "MailChannel.Deliver")
+	defer span.End()
+
+	var errs []error
+	for _, n := range notifications {
+		if err := c.send(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *MailChannel) send(ctx context.Context, n models.Notification) error {
+	user, err := c.users.GetUserByID(ctx, n.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		logging.ContextLogger(ctx).Info("User has no email address", "userID", user.ID)
+		return nil
+	}
+	if n.ActorName == "" && n.ActorID != 0 {
+		actor, err := c.users.GetUserByID(ctx, n.ActorID)
+		if err != nil {
+			return err
+		}
+		n.ActorName = actor.Username
+	}
+
+	subject, text := notificationMail(n)
+	return c.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("Hi %s,\n\n%s\n", user.Username, text),
+	})
+}
+
+// notificationMail returns the subject and the text of the email for n.
+func notificationMail(n models.Notification) (string, string) {
+	task := ""
+	if n.TaskTitle != "" {
+		task = fmt.Sprintf("\n\n%s", n.TaskTitle)
+	}
+	switch n.Type {
+	case models.NotificationAssigned:
+		return fmt.Sprintf("%s assigned a task to you", n.ActorName),
+			fmt.Sprintf("%s assigned the following task to you:%s", n.ActorName, task)
+	case models.NotificationMention:
+		return fmt.Sprintf("%s mentioned you in a comment", n.ActorName),
+			fmt.Sprintf("%s mentioned you in a comment on the following task:%s", n.ActorName, task)
+	case models.NotificationReminder:
+		return fmt.Sprintf("Reminder: %s", n.TaskTitle), fmt.Sprintf("This is the reminder you set for the following task:%s", task)
+	case models.NotificationDueSoon:
+		return fmt.Sprintf("Due soon: %s", n.TaskTitle), fmt.Sprintf("The following task is due soon:%s", task)
+	default:
+		return "You have a new notification", "You have a new notification."
+	}
+}
+
+// LogChannel writes notifications to the structured log. It is meant for
+// local development and is enabled for every type by default.
+type LogChannel struct{}
+
+func NewLogChannel() *LogChannel {
+	return &LogChannel{}
+}
+
+func (c *LogChannel) Name() string { return "log" }
+
+func (c *LogChannel) DefaultEnabled(models.NotificationType) bool { return true }
+
+func (c *LogChannel) Deliver(ctx context.Context, notifications []models.Notification) error {
+	for _, n := range notifications {
+		logging.ContextLogger(ctx).Info("Notification", "type", n.Type, "userID", n.UserID, "actorID", n.ActorID,
+			"taskID", n.TaskID, "taskTitle", n.TaskTitle, "commentID", n.CommentID)
+	}
+	return nil
+}
+
+// WebhookChannel posts each notification as JSON to a URL set by the
+// operator, for example a chat integration. Any status other than 2xx counts
+// as a failure. It is enabled for every type by default.
+type WebhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookChannel(url string) *WebhookChannel {
+	return &WebhookChannel{url: url, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+func (c *WebhookChannel) DefaultEnabled(models.NotificationType) bool { return true }
+
+func (c *WebhookChannel) Deliver(ctx context.Context, notifications []models.Notification) error {
+	_, span := otel.Tracer("").Start(ctx, "WebhookChannel.Deliver")
+	defer span.End()
+
+	var errs []error
+	for _, n := range notifications {
+		if err := c.post(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *WebhookChannel) post(ctx context.Context, n models.Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
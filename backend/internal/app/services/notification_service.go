@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultNotificationPageSize = 50
+	maxNotificationPageSize     = 200
+)
+
+var (
+	ErrNotificationNotFound          = errors.New("Notification not found")
+	ErrInvalidNotificationPreference = errors.New("Unknown notification type or channel")
+)
+
+type NotificationServiceInterface interface {
+	ListNotifications(ctx context.Context, userID uint, query models.NotificationQuery) (*models.NotificationList, error)
+	UnreadCount(ctx context.Context, userID uint) (int, error)
+	MarkRead(ctx context.Context, userID uint, notificationID int) error
+	// MarkAllRead marks all unread notifications as read and returns how
+	// many there were.
+	MarkAllRead(ctx context.Context, userID uint) (int, error)
+	// GetPreferences returns whether the user gets each notification type
+	// through each channel.
+	GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error)
+	// UpdatePreferences changes the given preferences and returns all of
+	// them.
+	UpdatePreferences(ctx context.Context, userID uint, prefs []models.NotificationPreference) ([]models.NotificationPreference, error)
+}
+
+type NotificationService struct {
+	repo     repositories.NotificationRepository
+	channels []NotificationChannel
+}
+
+// NewNotificationService returns the notification center. channels are the
+// channels the Notifier delivers through; users set their preferences for
+// them.
+func NewNotificationService(repo repositories.NotificationRepository, channels []NotificationChannel) NotificationServiceInterface {
+	return &NotificationService{repo: repo, channels: channels}
+}
+
+// ListNotifications returns one page of the user's notifications with the
+// unread count. The page size defaults to 50 and is capped at 200.
+func (s *NotificationService) ListNotifications(ctx context.Context, userID uint, query models.NotificationQuery) (*models.NotificationList, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.ListNotifications")
+	defer span.End()
+
+	if query.Limit <= 0 {
+		query.Limit = defaultNotificationPageSize
+	}
+	query.Limit = min(query.Limit, maxNotificationPageSize)
+	query.Offset = max(query.Offset, 0)
+
+	notifications, err := s.repo.ListNotifications(ctx, int(userID), query)
+	if err != nil {
+		return nil, err
+	}
+	total, unread, err := s.repo.CountNotifications(ctx, int(userID), query.Unread)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationList{
+		Notifications: notifications,
+		Total:         total,
+		UnreadCount:   unread,
+		Limit:         query.Limit,
+		Offset:        query.Offset,
+	}, nil
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID uint) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.UnreadCount")
+	defer span.End()
+
+	_, unread, err := s.repo.CountNotifications(ctx, int(userID), true)
+	return unread, err
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID uint, notificationID int) error {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.MarkRead")
+	defer span.End()
+
+	err := s.repo.MarkRead(ctx, int(userID), notificationID)
+	if errors.Is(err, repositories.ErrNotificationNotFound) {
+		return ErrNotificationNotFound
+	}
+	return err
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID uint) (int, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.MarkAllRead")
+	defer span.End()
+
+	n, err := s.repo.MarkAllRead(ctx, int(userID))
+	if err != nil {
+		return 0, err
+	}
+	logging.ContextLogger(ctx).Info("Notifications marked as read", "event", "notifications_read", "count", n, "userID", userID)
+	return n, nil
+}
+
+func (s *NotificationService) GetPreferences(ctx context.Context, userID uint) ([]models.NotificationPreference, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.GetPreferences")
+	defer span.End()
+
+	prefs, err := s.repo.GetPreferences(ctx, []int{int(userID)})
+	if err != nil {
+		return nil, err
+	}
+	chosen := preferenceIndex(prefs)
+
+	all := make([]models.NotificationPreference, 0, len(models.NotificationTypes)*len(s.channels))
+	for _, t := range models.NotificationTypes {
+		for _, channel := range s.channels {
+			all = append(all, models.NotificationPreference{
+				Type:    t,
+				Channel: channel.Name(),
+				Enabled: channelEnabled(chosen, channel, int(userID), t),
+			})
+		}
+	}
+	return all, nil
+}
+
+// UpdatePreferences rejects the whole request if any preference names an
+// unknown type or channel. If a type and channel are given more than once,
+// the last one wins.
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID uint, prefs []models.NotificationPreference) ([]models.NotificationPreference, error) {
+	_, span := otel.Tracer("").Start(ctx, "NotificationService.UpdatePreferences")
+	defer span.End()
+
+	index := make(map[preferenceKey]int, len(prefs))
+	var unique []models.NotificationPreference
+	for _, pref := range prefs {
+		if !pref.Type.Valid() || !s.hasChannel(pref.Channel) {
+			return nil, ErrInvalidNotificationPreference
+		}
+		key := preferenceKey{int(userID), pref.Type, pref.Channel}
+		if i, ok := index[key]; ok {
+			unique[i].Enabled = pref.Enabled
+			continue
+		}
+		index[key] = len(unique)
+		unique = append(unique, pref)
+	}
+
+	if err := s.repo.SetPreferences(ctx, int(userID), unique); err != nil {
+		return nil, err
+	}
+	logging.ContextLogger(ctx).Info("Notification preferences updated", "event", "notification_preferences_updated", "userID", userID)
+	return s.GetPreferences(ctx, userID)
+}
+
+func (s *NotificationService) hasChannel(name string) bool {
+	for _, channel := range s.channels {
+		if channel.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+)
+
+// MockNotificationRepository is a mock implementation of the NotificationRepository interface
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) CreateNotifications(ctx context.Context, notifications []models.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) ListNotifications(ctx context.Context, userID int, query models.NotificationQuery) ([]models.Notification, error) {
+	args := m.Called(ctx, userID, query)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) CountNotifications(ctx context.Context, userID int, unreadOnly bool) (int, int, error) {
+	args := m.Called(ctx, userID, unreadOnly)
+	return args.Int(0), args.Int(1), args.Error(2)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, userID, notificationID int) error {
+	args := m.Called(ctx, userID, notificationID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) MarkAllRead(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationRepository) GetPreferences(ctx context.Context, userIDs []int) ([]models.NotificationPreference, error) {
+	args := m.Called(ctx, userIDs)
+	return args.Get(0).([]models.NotificationPreference), args.Error(1)
+}
+
+func (m *MockNotificationRepository) SetPreferences(ctx context.Context, userID int, prefs []models.NotificationPreference) error {
+	args := m.Called(ctx, userID, prefs)
+	return args.Error(0)
+}
+
+// MockNotificationChannel is a mock implementation of the NotificationChannel interface
+type MockNotificationChannel struct {
+	mock.Mock
+	name     string
+	disabled []models.NotificationType
+}
+
+func (m *MockNotificationChannel) Name() string { return m.name }
+
+func (m *MockNotificationChannel) DefaultEnabled(t models.NotificationType) bool {
+	for _, d := range m.disabled {
+		if d == t {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MockNotificationChannel) Deliver(ctx context.Context, notifications []models.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
+func TestNotificationDispatcher_Notify(t *testing.T) {
+	mockRepo := new(MockNotificationRepository)
+	inApp := &MockNotificationChannel{name: "in_app"}
+	email := &MockNotificationChannel{name: "email", disabled: []models.NotificationType{models.NotificationMention}}
+	dispatcher := NewNotificationDispatcher(mockRepo, inApp, email)
+	ctx := context.Background()
+
+	bob := models.Notification{UserID: 2, Type: models.NotificationMention, ActorID: 1, TaskID: 5}
+	carol := models.Notification{UserID: 3, Type: models.NotificationMention, ActorID: 1, TaskID: 5}
+	mockRepo.On("GetPreferences", ctx, []int{2, 3}).Return([]models.NotificationPreference{
+		{UserID: 2, Type: models.NotificationMention, Channel: "in_app", Enabled: false},
+		{UserID: 3, Type: models.NotificationMention, Channel: "email", Enabled: true},
+	}, nil)
+	inApp.On("Deliver", ctx, []models.Notification{carol}).Return(nil)
+	email.On("Deliver", ctx, []models.Notification{carol}).Return(nil)
+
+	err := dispatcher.Notify(ctx, bob, carol)
+
+	assert.NoError(t, err)
+	inApp.AssertExpectations(t)
+	email.AssertExpectations(t)
+}
+
+func TestNotificationDispatcher_Notify_ChannelFailure(t *testing.T) {
+	mockRepo := new(MockNotificationRepository)
+	inApp := &MockNotificationChannel{name: "in_app"}
+	email := &MockNotificationChannel{name: "email"}
+	dispatcher := NewNotificationDispatcher(mockRepo, email, inApp)
+	ctx := context.Background()
+
+	n := models.Notification{UserID: 2, Type: models.NotificationAssigned, ActorID: 1, TaskID: 5}
+	mockRepo.On("GetPreferences", ctx, []int{2}).Return([]models.NotificationPreference(nil), nil)
+	email.On("Deliver", ctx, mock.Anything).Return(errors.New("smtp down"))
+	inApp.On("Deliver", ctx, []models.Notification{n}).Return(nil)
+
+	err := dispatcher.Notify(ctx, n)
+
+	assert.ErrorContains(t, err, "email: smtp down")
+	inApp.AssertExpectations(t)
+}
+
+func TestMailChannel_Deliver(t *testing.T) {
+	mockUsers := new(MockAuthRepository)
+	mockMailer := new(MockMailer)
+	channel := NewMailChannel(mockUsers, mockMailer)
+	ctx := context.Background()
+
+	mockUsers.On("GetUserByID", ctx, 5).Return(&models.User{ID: 5, Username: "bob", Email: "bob@example.com"}, nil)
+	mockUsers.On("GetUserByID", ctx, 6).Return(&models.User{ID: 6, Username: "carol"}, nil)
+	mockUsers.On("GetUserByID", ctx, 2).Return(&models.User{ID: 2, Username: "alice"}, nil)
+	mockMailer.On("Send", ctx, mock.MatchedBy(func(msg mailer.Message) bool {
+		return msg.To == "bob@example.com" && strings.Contains(msg.Subject, "alice") && strings.Contains(msg.Body, "Eggs")
+	})).Return(nil).Once()
+
+	err := channel.Deliver(ctx, []models.Notification{
+		{UserID: 5, Type: models.NotificationAssigned, ActorID: 2, TaskID: 4, TaskTitle: "Eggs"},
+		// carol has no email address.
+		{UserID: 6, Type: models.NotificationAssigned, ActorID: 2, TaskID: 4, TaskTitle: "Eggs"},
+	})
+
+	assert.NoError(t, err)
+	mockMailer.AssertExpectations(t)
+	assert.False(t, channel.DefaultEnabled(models.NotificationMention))
+}
+
+func TestWebhookChannel_Deliver(t *testing.T) {
+	var received []models.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n models.Notification
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		received = append(received, n)
+		if n.TaskID == 6 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+	channel := NewWebhookChannel(server.URL)
+
+	err := channel.Deliver(context.Background(), []models.Notification{
+		{UserID: 2, Type: models.NotificationReminder, TaskID: 5, TaskTitle: "Milk"},
+		{UserID: 2, Type: models.NotificationReminder, TaskID: 6, TaskTitle: "Eggs"},
+	})
+
+	assert.ErrorContains(t, err, "status 502")
+	assert.Len(t, received, 2)
+	assert.Equal(t, "Milk", received[0].TaskTitle)
+}
+
+func TestNotificationService_ListNotifications(t *testing.T) {
+	mockRepo := new(MockNotificationRepository)
+	notificationService := NewNotificationService(mockRepo, nil)
+	ctx := context.Background()
+
+	mockRepo.On("ListNotifications", ctx, 1, models.NotificationQuery{Unread: true, Limit: 200}).
+		Return([]models.Notification{{ID: 9, UserID: 1, Type: models.NotificationMention}}, nil)
+	mockRepo.On("CountNotifications", ctx, 1, true).Return(3, 3, nil)
+
+	list, err := notificationService.ListNotifications(ctx, 1, models.NotificationQuery{Unread: true, Limit: 1000, Offset: -5})
+
+	assert.NoError(t, err)
+	assert.Len(t, list.Notifications, 1)
+	assert.Equal(t, 3, list.UnreadCount)
+	assert.Equal(t, 200, list.Limit)
+	assert.Zero(t, list.Offset)
+}
+
+func TestNotificationService_MarkRead_NotFound(t *testing.T) {
+	mockRepo := new(MockNotificationRepository)
+	notificationService := NewNotificationService(mockRepo, nil)
+	ctx := context.Background()
+
+	mockRepo.On("MarkRead", ctx, 1, 9).Return(repositories.ErrNotificationNotFound)
+
+	err := notificationService.MarkRead(ctx, 1, 9)
+
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+}
+
+func TestNotificationService_UpdatePreferences(t *testing.T) {
+	mockRepo := new(MockNotificationRepository)
+	channels := []NotificationChannel{
+		&MockNotificationChannel{name: "in_app"},
+		&MockNotificationChannel{name: "email", disabled: []models.NotificationType{models.NotificationMention}},
+	}
+	notificationService := NewNotificationService(mockRepo, channels)
+	ctx := context.Background()
+
+	mockRepo.On("SetPreferences", ctx, 1, []models.NotificationPreference{
+		{Type: models.NotificationAssigned, Channel: "email", Enabled: true},
+	}).Return(nil)
+	mockRepo.On("GetPreferences", ctx, []int{1}).Return([]models.NotificationPreference{
+		{UserID: 1, Type: models.NotificationAssigned, Channel: "email", Enabled: true},
+	}, nil)
+
+	prefs, err := notificationService.UpdatePreferences(ctx, 1, []models.NotificationPreference{
+		{Type: models.NotificationAssigned, Channel: "email", Enabled: false},
+		{Type: models.NotificationAssigned, Channel: "email", Enabled: true},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, prefs, len(models.NotificationTypes)*len(channels))
+	assert.Contains(t, prefs, models.NotificationPreference{Type: models.NotificationMention, Channel: "email", Enabled: false})
+	assert.Contains(t, prefs, models.NotificationPreference{Type: models.NotificationMention, Channel: "in_app", Enabled: true})
+}
+
+func TestNotificationService_UpdatePreferences_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		pref models.NotificationPreference
+	}{
+		{"unknown type", models.NotificationPreference{Type: "digest", Channel: "email"}},
+		{"unknown channel", models.NotificationPreference{Type: models.NotificationMention, Channel: "sms"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockNotificationRepository)
+			notificationService := NewNotificationService(mockRepo, []NotificationChannel{&MockNotificationChannel{name: "email"}})
+
+			_, err := notificationService.UpdatePreferences(context.Background(), 1, []models.NotificationPreference{tt.pref})
+
+			assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+			mockRepo.AssertNotCalled(t, "SetPreferences", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"go.opentelemetry.io/otel"
+)
+
+// Notifier tells users about events that concern them, such as mentions,
+// assignments, reminders and tasks that are due soon. Services produce
+// notifications through it without knowing how they are delivered.
+type Notifier interface {
+	Notify(ctx context.Context, notifications ...models.Notification) error
+}
+
+// NotificationChannel delivers notifications one way, for example in the
+// app or by email.
+type NotificationChannel interface {
+	// Name identifies the channel in notification preferences.
+	Name() string
+	// DefaultEnabled reports whether users who have not chosen otherwise get
+	// notifications of type t through the channel.
+	DefaultEnabled(t models.NotificationType) bool
+	Deliver(ctx context.Context, notifications []models.Notification) error
+}
+
+// NotificationDispatcher is the Notifier. It hands each notification to
+// every channel the recipient has enabled for its type.
+type NotificationDispatcher struct {
+	prefs    repositories.NotificationRepository
+	channels []NotificationChannel
+}
+
+func NewNotificationDispatcher(prefs repositories.NotificationRepository, channels ...NotificationChannel) *NotificationDispatcher {
+	return &NotificationDispatcher{prefs: prefs, channels: channels}
+}
+
+// Notify delivers notifications through every enabled channel. A channel
+// that fails does not keep the others from delivering; their errors are
+// returned together.
+func (d *NotificationDispatcher) Notify(ctx context.Context, notifications ...models.Notification) error {
+	_, span := otel.Tracer("").Start(ctx, "NotificationDispatcher.Notify")
+	defer span.End()
+
+	if len(notifications) == 0 {
+		return nil
+	}
+	userIDs := make([]int, 0, len(notifications))
+	for _, n := range notifications {
+		userIDs = append(userIDs, n.UserID)
+	}
+	prefs, err := d.prefs.GetPreferences(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	chosen := preferenceIndex(prefs)
+
+	var errs []error
+	for _, channel := range d.channels {
+		var batch []models.Notification
+		for _, n := range notifications {
+			if channelEnabled(chosen, channel, n.UserID, n.Type) {
+				batch = append(batch, n)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		if err := channel.Deliver(ctx, batch); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", channel.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type preferenceKey struct {
+	userID  int
+	typ     models.NotificationType
+	channel string
+}
+
+func preferenceIndex(prefs []models.NotificationPreference) map[preferenceKey]bool {
+	index := make(map[preferenceKey]bool, len(prefs))
+	for _, pref := range prefs {
+		index[preferenceKey{pref.UserID, pref.Type, pref.Channel}] = pref.Enabled
+	}
+	return index
+}
+
+// channelEnabled applies the user's choice for the type and channel, or the
+// channel's default if there is none.
+func channelEnabled(chosen map[preferenceKey]bool, channel NotificationChannel, userID int, t models.NotificationType) bool {
+	if enabled, ok := chosen[preferenceKey{userID, t, channel.Name()}]; ok {
+		return enabled
+	}
+	return channel.DefaultEnabled(t)
+}
@@ -0,0 +1,514 @@
+package services
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	authorizationCodeTTL = 5 * time.Minute
+	delegatedTokenTTL    = time.Hour
+)
+
+var (
+	ErrInvalidClientMetadata = errors.New("Invalid client registration")
+	ErrOAuthClientNotFound   = errors.New("OAuth client not found")
+	ErrOAuthConsentNotFound  = errors.New("No consent found for this client")
+)
+
+// OAuthError is an OAuth 2.0 error response (RFC 6749 sections 4.1.2.1
+// and 5.2). Code is one of the registered error codes.
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// ClientCredentials identify the client calling the token, introspection or
+// revocation endpoint. Secret is empty for public clients.
+type ClientCredentials struct {
+	ID     string
+	Secret string
+}
+
+// TokenRequest holds the parameters of an authorization code token request.
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+type OAuthServiceInterface interface {
+	RegisterClient(ctx context.Context, ownerID uint, req models.CreateOAuthClientRequest) (*models.OAuthClient, string, error)
+	ListClients(ctx context.Context, ownerID uint) ([]models.OAuthClient, error)
+	DeleteClient(ctx context.Context, ownerID uint, clientID string) error
+	PrepareConsent(ctx context.Context, userID uint, req models.AuthorizationRequest) (*models.ConsentPrompt, error)
+	Authorize(ctx context.Context, userID uint, decision models.ConsentDecision) (string, error)
+	ExchangeCode(ctx context.Context, creds ClientCredentials, req TokenRequest) (*models.TokenResponse, error)
+	Introspect(ctx context.Context, creds ClientCredentials, token string) (*models.IntrospectionResponse, error)
+	Revoke(ctx context.Context, creds ClientCredentials, token string) error
+	ListConsents(ctx context.Context, userID uint) ([]models.OAuthConsent, error)
+	RevokeConsent(ctx context.Context, userID uint, clientID string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+type OAuthService struct {
+	repo repositories.OAuthRepository
+	keys *jwtkeys.Manager
+}
+
+// NewOAuthService creates an OAuthService that signs access tokens for
+// third-party clients with keys.
+func NewOAuthService(repo repositories.OAuthRepository, keys *jwtkeys.Manager) OAuthServiceInterface {
+	return &OAuthService{repo: repo, keys: keys}
+}
+
+// RegisterClient registers a client owned by ownerID. For confidential
+// clients the generated secret is returned; it is not retrievable later.
+func (s *OAuthService) RegisterClient(ctx context.Context, ownerID uint, req models.CreateOAuthClientRequest) (*models.OAuthClient, string, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.RegisterClient")
+	defer span.End()
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name must not be empty", ErrInvalidClientMetadata)
+	}
+	for _, uri := range req.RedirectURIs {
+		if err := validateRedirectURI(uri); err != nil {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidClientMetadata, err)
+		}
+	}
+	scopes := scope.Parse(scope.Format(req.Scopes))
+	for _, sc := range scopes {
+		if !scope.IsSupported(sc) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidClientMetadata, sc)
+		}
+	}
+
+	clientID, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, "", err
+	}
+	client := &models.OAuthClient{ID: clientID, Name: name, RedirectURIs: req.RedirectURIs, Scopes: scopes, OwnerID: int(ownerID)}
+
+	var secret string
+	if req.Confidential {
+		if secret, err = utils.GenerateSecureToken(); err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = utils.HashToken(secret)
+	}
+
+	if err := s.repo.CreateClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+	return client, secret, nil
+}
+
+// validateRedirectURI accepts HTTPS URLs, HTTP loopback URLs and
+// private-use URI schemes for native apps (RFC 8252 section 7).
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() {
+		return fmt.Errorf("redirect URI %q must be an absolute URI", raw)
+	}
+	if u.Fragment != "" {
+		return fmt.Errorf("redirect URI %q must not contain a fragment", raw)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		switch u.Hostname() {
+		case "localhost", "127.0.0.1", "::1":
+			return nil
+		}
+		return fmt.Errorf("redirect URI %q must use https", raw)
+	default:
+		if !strings.Contains(u.Scheme, ".") {
+			return fmt.Errorf("redirect URI %q must use https or a reverse-domain private-use scheme", raw)
+		}
+		return nil
+	}
+}
+
+func (s *OAuthService) ListClients(ctx context.Context, ownerID uint) ([]models.OAuthClient, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.ListClients")
+	defer span.End()
+
+	return s.repo.ListClientsByOwner(ctx, int(ownerID))
+}
+
+func (s *OAuthService) DeleteClient(ctx context.Context, ownerID uint, clientID string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.DeleteClient")
+	defer span.End()
+
+	err := s.repo.DeleteClient(ctx, int(ownerID), clientID)
+	if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+		return ErrOAuthClientNotFound
+	}
+	return err
+}
+
+// PrepareConsent validates an authorization request and returns what the
+// consent screen should show.
+func (s *OAuthService) PrepareConsent(ctx context.Context, userID uint, req models.AuthorizationRequest) (*models.ConsentPrompt, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.PrepareConsent")
+	defer span.End()
+
+	client, redirectURI, scopes, err := s.validateAuthorizationRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	granted, err := s.repo.GetConsentScopes(ctx, int(userID), client.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := &models.ConsentPrompt{
+		ClientID:       client.ID,
+		ClientName:     client.Name,
+		RedirectURI:    redirectURI,
+		AlreadyGranted: granted != nil && scope.Subset(scopes, granted),
+	}
+	for _, sc := range scopes {
+		prompt.Scopes = append(prompt.Scopes, models.ScopeDescription{Scope: sc, Description: scope.Describe(sc)})
+	}
+	return prompt, nil
+}
+
+// Authorize records the user's consent decision and returns the URL to
+// redirect the user agent to, carrying either an authorization code or an
+// access_denied error.
+func (s *OAuthService) Authorize(ctx context.Context, userID uint, decision models.ConsentDecision) (string, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.Authorize")
+	defer span.End()
+
+	client, redirectURI, scopes, err := s.validateAuthorizationRequest(ctx, decision.AuthorizationRequest)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{"iss": {s.keys.Issuer()}}
+	if decision.State != "" {
+		params.Set("state", decision.State)
+	}
+
+	if !decision.Approve {
+		params.Set("error", "access_denied")
+		return appendQuery(redirectURI, params), nil
+	}
+
+	if err := s.repo.SaveConsent(ctx, int(userID), client.ID, scopes); err != nil {
+		return "", err
+	}
+
+	code, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.repo.CreateAuthorizationCode(ctx, &models.OAuthAuthorizationCode{
+		CodeHash:      utils.HashToken(code),
+		ClientID:      client.ID,
+		UserID:        int(userID),
+		RedirectURI:   redirectURI,
+		Scopes:        scopes,
+		CodeChallenge: decision.CodeChallenge,
+		ExpiresAt:     time.Now().Add(authorizationCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logging.ContextLogger(ctx).Info("OAuth authorization granted", "event", "oauth_authorized", "userID", userID, "clientID", client.ID, "scope", scope.Format(scopes))
+	params.Set("code", code)
+	return appendQuery(redirectURI, params), nil
+}
+
+// validateAuthorizationRequest checks an authorization request and returns
+// the client, the effective redirect URI and the requested scopes. PKCE with
+// S256 is mandatory for every client.
+func (s *OAuthService) validateAuthorizationRequest(ctx context.Context, req models.AuthorizationRequest) (*models.OAuthClient, string, []string, error) {
+	client, err := s.repo.GetClient(ctx, req.ClientID)
+	if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+		return nil, "", nil, oauthError("invalid_request", "Unknown client")
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, "", nil, oauthError("invalid_request", "redirect_uri is not registered for this client")
+	}
+
+	if req.ResponseType != "code" {
+		return nil, "", nil, oauthError("unsupported_response_type", "Only the code response type is supported")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, "", nil, oauthError("invalid_request", "PKCE with code_challenge_method S256 is required")
+	}
+
+	scopes := scope.Parse(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !scope.Subset(scopes, client.Scopes) {
+		return nil, "", nil, oauthError("invalid_scope", "The client is not allowed to request this scope")
+	}
+
+	return client, redirectURI, scopes, nil
+}
+
+// ExchangeCode redeems an authorization code for an access token
+// (RFC 6749 section 4.1.3).
+func (s *OAuthService) ExchangeCode(ctx context.Context, creds ClientCredentials, req TokenRequest) (*models.TokenResponse, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.ExchangeCode")
+	defer span.End()
+
+	if req.GrantType != "authorization_code" {
+		return nil, oauthError("unsupported_grant_type", "Only the authorization_code grant is supported")
+	}
+
+	client, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	codeHash := utils.HashToken(req.Code)
+	code, err := s.repo.ConsumeAuthorizationCode(ctx, codeHash)
+	if errors.Is(err, repositories.ErrAuthorizationCodeReused) {
+		// A replayed code may have been stolen; revoke what it produced.
+		logging.ContextLogger(ctx).Warn("Authorization code reused", "event", "oauth_code_reused", "clientID", client.ID)
+		if err := s.repo.RevokeAccessTokensByCode(ctx, codeHash); err != nil {
+			return nil, err
+		}
+		return nil, oauthError("invalid_grant", "Authorization code is invalid or expired")
+	}
+	if errors.Is(err, repositories.ErrAuthorizationCodeInvalid) {
+		return nil, oauthError("invalid_grant", "Authorization code is invalid or expired")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if code.ClientID != client.ID || code.RedirectURI != req.RedirectURI {
+		return nil, oauthError("invalid_grant", "Authorization code was issued to another client or redirect_uri")
+	}
+	if subtle.ConstantTimeCompare([]byte(oidc.CodeChallengeS256(req.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, oauthError("invalid_grant", "code_verifier does not match the code challenge")
+	}
+
+	return s.issueToken(ctx, client.ID, code)
+}
+
+func (s *OAuthService) issueToken(ctx context.Context, clientID string, code *models.OAuthAuthorizationCode) (*models.TokenResponse, error) {
+	jti, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(delegatedTokenTTL)
+	signed, err := s.keys.Sign(utils.Claims{
+		UserID:   code.UserID,
+		ClientID: clientID,
+		Scope:    scope.Format(code.Scopes),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.keys.Issuer(),
+			Subject:   strconv.Itoa(code.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	if err != nil {
+		return nil, errors.New("Failed to generate token")
+	}
+
+	err = s.repo.CreateAccessToken(ctx, &models.OAuthAccessToken{
+		JTI:       jti,
+		ClientID:  clientID,
+		UserID:    code.UserID,
+		Scopes:    code.Scopes,
+		CodeHash:  code.CodeHash,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenResponse{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(delegatedTokenTTL.Seconds()),
+		Scope:       scope.Format(code.Scopes),
+	}, nil
+}
+
+// authenticateClient verifies the client's credentials. Confidential clients
+// must present their secret; public clients must not present one.
+func (s *OAuthService) authenticateClient(ctx context.Context, creds ClientCredentials) (*models.OAuthClient, error) {
+	client, err := s.repo.GetClient(ctx, creds.ID)
+	if errors.Is(err, repositories.ErrOAuthClientNotFound) {
+		return nil, oauthError("invalid_client", "Client authentication failed")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Confidential() {
+		if creds.Secret == "" || subtle.ConstantTimeCompare([]byte(utils.HashToken(creds.Secret)), []byte(client.SecretHash)) != 1 {
+			return nil, oauthError("invalid_client", "Client authentication failed")
+		}
+	} else if creds.Secret != "" {
+		return nil, oauthError("invalid_client", "Client authentication failed")
+	}
+	return client, nil
+}
+
+// delegatedToken returns the claims and record of an access token issued to
+// client, or nil if token is not a live token of that client.
+func (s *OAuthService) delegatedToken(ctx context.Context, client *models.OAuthClient, token string) (*utils.Claims, *models.OAuthAccessToken, error) {
+	claims, err := utils.ParseToken(s.keys, token)
+	if err != nil || !claims.Delegated() || claims.ClientID != client.ID {
+		return nil, nil, nil
+	}
+
+	record, err := s.repo.GetAccessToken(ctx, claims.ID)
+	if errors.Is(err, repositories.ErrAccessTokenNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, record, nil
+}
+
+// Introspect reports whether token is active (RFC 7662). Clients can only
+// introspect their own tokens; anything else is reported as inactive.
+func (s *OAuthService) Introspect(ctx context.Context, creds ClientCredentials, token string) (*models.IntrospectionResponse, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.Introspect")
+	defer span.End()
+
+	client, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, record, err := s.delegatedToken(ctx, client, token)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil || record.RevokedAt != nil {
+		return &models.IntrospectionResponse{Active: false}, nil
+	}
+
+	return &models.IntrospectionResponse{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		Exp:       claims.ExpiresAt.Unix(),
+		Iat:       claims.IssuedAt.Unix(),
+		Sub:       claims.Subject,
+		Iss:       claims.Issuer,
+	}, nil
+}
+
+// Revoke revokes token (RFC 7009). Unknown, expired and foreign tokens are
+// ignored, as the RFC requires the same response for them.
+func (s *OAuthService) Revoke(ctx context.Context, creds ClientCredentials, token string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.Revoke")
+	defer span.End()
+
+	client, err := s.authenticateClient(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, record, err := s.delegatedToken(ctx, client, token)
+	if err != nil || record == nil {
+		return err
+	}
+	return s.repo.RevokeAccessToken(ctx, record.JTI)
+}
+
+func (s *OAuthService) ListConsents(ctx context.Context, userID uint) ([]models.OAuthConsent, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.ListConsents")
+	defer span.End()
+
+	return s.repo.ListConsents(ctx, int(userID))
+}
+
+// RevokeConsent withdraws the user's consent for a client and revokes the
+// client's access tokens for the user.
+func (s *OAuthService) RevokeConsent(ctx context.Context, userID uint, clientID string) error {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.RevokeConsent")
+	defer span.End()
+
+	err := s.repo.DeleteConsent(ctx, int(userID), clientID)
+	if errors.Is(err, repositories.ErrConsentNotFound) {
+		return ErrOAuthConsentNotFound
+	}
+	return err
+}
+
+// IsTokenRevoked implements middleware.RevocationChecker. Tokens without a
+// server-side record are treated as revoked.
+func (s *OAuthService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	_, span := otel.Tracer("").Start(ctx, "OAuthService.IsTokenRevoked")
+	defer span.End()
+
+	record, err := s.repo.GetAccessToken(ctx, jti)
+	if errors.Is(err, repositories.ErrAccessTokenNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return record.RevokedAt != nil, nil
+}
+
+// appendQuery adds params to the query of rawURL, keeping existing ones.
+func appendQuery(rawURL string, params url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+// MockOAuthRepository is a mock implementation of the OAuthRepository interface
+type MockOAuthRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	args := m.Called(ctx, client)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) GetClient(ctx context.Context, clientID string) (*models.OAuthClient, error) {
+	args := m.Called(ctx, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthRepository) ListClientsByOwner(ctx context.Context, ownerID int) ([]models.OAuthClient, error) {
+	args := m.Called(ctx, ownerID)
+	return args.Get(0).([]models.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthRepository) DeleteClient(ctx context.Context, ownerID int, clientID string) error {
+	args := m.Called(ctx, ownerID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) GetConsentScopes(ctx context.Context, userID int, clientID string) ([]string, error) {
+	args := m.Called(ctx, userID, clientID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockOAuthRepository) SaveConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	args := m.Called(ctx, userID, clientID, scopes)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) ListConsents(ctx context.Context, userID int) ([]models.OAuthConsent, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.OAuthConsent), args.Error(1)
+}
+
+func (m *MockOAuthRepository) DeleteConsent(ctx context.Context, userID int, clientID string) error {
+	args := m.Called(ctx, userID, clientID)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) CreateAuthorizationCode(ctx context.Context, code *models.OAuthAuthorizationCode) error {
+	args := m.Called(ctx, code)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) (*models.OAuthAuthorizationCode, error) {
+	args := m.Called(ctx, codeHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthAuthorizationCode), args.Error(1)
+}
+
+func (m *MockOAuthRepository) CreateAccessToken(ctx context.Context, token *models.OAuthAccessToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) GetAccessToken(ctx context.Context, jti string) (*models.OAuthAccessToken, error) {
+	args := m.Called(ctx, jti)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OAuthAccessToken), args.Error(1)
+}
+
+func (m *MockOAuthRepository) RevokeAccessToken(ctx context.Context, jti string) error {
+	args := m.Called(ctx, jti)
+	return args.Error(0)
+}
+
+func (m *MockOAuthRepository) RevokeAccessTokensByCode(ctx context.Context, codeHash string) error {
+	args := m.Called(ctx, codeHash)
+	return args.Error(0)
+}
+
+const testRedirectURI = "https://client.example/callback"
+
+func testPublicClient() *models.OAuthClient {
+	return &models.OAuthClient{ID: "client-1", Name: "Planner", RedirectURIs: []string{testRedirectURI}, Scopes: scope.Supported()}
+}
+
+func testAuthorizationRequest(verifier string) models.AuthorizationRequest {
+	return models.AuthorizationRequest{
+		ResponseType:        "code",
+		ClientID:            "client-1",
+		RedirectURI:         testRedirectURI,
+		Scope:               scope.TasksRead,
+		State:               "xyz",
+		CodeChallenge:       oidc.CodeChallengeS256(verifier),
+		CodeChallengeMethod: "S256",
+	}
+}
+
+func TestOAuthService_RegisterClient(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	oauthService := NewOAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+
+	mockRepo.On("CreateClient", ctx, mock.AnythingOfType("*models.OAuthClient")).Return(nil)
+
+	client, secret, err := oauthService.RegisterClient(ctx, 1, models.CreateOAuthClientRequest{
+		Name:         "Planner",
+		RedirectURIs: []string{testRedirectURI, "http://127.0.0.1:8000/cb", "com.example.app:/cb"},
+		Scopes:       []string{scope.TasksRead},
+		Confidential: true,
+	})
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, client.ID)
+	assert.NotEmpty(t, secret)
+	assert.Equal(t, utils.HashToken(secret), client.SecretHash, "only the secret hash is stored")
+	assert.Equal(t, 1, client.OwnerID)
+}
+
+func TestOAuthService_RegisterClient_InvalidMetadata(t *testing.T) {
+	oauthService := NewOAuthService(new(MockOAuthRepository), newTestKeys())
+
+	for name, req := range map[string]models.CreateOAuthClientRequest{
+		"plain http":     {Name: "x", RedirectURIs: []string{"http://client.example/cb"}, Scopes: []string{scope.TasksRead}},
+		"fragment":       {Name: "x", RedirectURIs: []string{"https://client.example/cb#frag"}, Scopes: []string{scope.TasksRead}},
+		"relative":       {Name: "x", RedirectURIs: []string{"/cb"}, Scopes: []string{scope.TasksRead}},
+		"unknown scope":  {Name: "x", RedirectURIs: []string{testRedirectURI}, Scopes: []string{"admin"}},
+		"blank name":     {Name: " ", RedirectURIs: []string{testRedirectURI}, Scopes: []string{scope.TasksRead}},
+		"non-dns scheme": {Name: "x", RedirectURIs: []string{"myapp:/cb"}, Scopes: []string{scope.TasksRead}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := oauthService.RegisterClient(context.Background(), 1, req)
+			assert.ErrorIs(t, err, ErrInvalidClientMetadata)
+		})
+	}
+}
+
+func TestOAuthService_PrepareConsent(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	oauthService := NewOAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+
+	mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+	mockRepo.On("GetConsentScopes", ctx, 1, "client-1").Return([]string{scope.TasksRead, scope.TasksWrite}, nil)
+
+	prompt, err := oauthService.PrepareConsent(ctx, 1, testAuthorizationRequest("verifier"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Planner", prompt.ClientName)
+	assert.True(t, prompt.AlreadyGranted)
+	require.Len(t, prompt.Scopes, 1)
+	assert.Equal(t, scope.TasksRead, prompt.Scopes[0].Scope)
+	assert.NotEmpty(t, prompt.Scopes[0].Description)
+}
+
+func TestOAuthService_PrepareConsent_InvalidRequests(t *testing.T) {
+	tests := map[string]struct {
+		mutate func(*models.AuthorizationRequest)
+		code   string
+	}{
+		"unknown redirect": {func(r *models.AuthorizationRequest) { r.RedirectURI = "https://evil.example/cb" }, "invalid_request"},
+		"no PKCE":          {func(r *models.AuthorizationRequest) { r.CodeChallenge = "" }, "invalid_request"},
+		"plain PKCE":       {func(r *models.AuthorizationRequest) { r.CodeChallengeMethod = "plain" }, "invalid_request"},
+		"token response":   {func(r *models.AuthorizationRequest) { r.ResponseType = "token" }, "unsupported_response_type"},
+		"unknown scope":    {func(r *models.AuthorizationRequest) { r.Scope = "admin" }, "invalid_scope"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockOAuthRepository)
+			oauthService := NewOAuthService(mockRepo, newTestKeys())
+			ctx := context.Background()
+			mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+
+			req := testAuthorizationRequest("verifier")
+			tt.mutate(&req)
+			_, err := oauthService.PrepareConsent(ctx, 1, req)
+
+			var oauthErr *OAuthError
+			require.ErrorAs(t, err, &oauthErr)
+			assert.Equal(t, tt.code, oauthErr.Code)
+		})
+	}
+}
+
+func TestOAuthService_Authorize_Denied(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	oauthService := NewOAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+	mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+
+	redirectTo, err := oauthService.Authorize(ctx, 1, models.ConsentDecision{AuthorizationRequest: testAuthorizationRequest("verifier")})
+
+	require.NoError(t, err)
+	u, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "access_denied", u.Query().Get("error"))
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	mockRepo.AssertNotCalled(t, "CreateAuthorizationCode", mock.Anything, mock.Anything)
+}
+
+func TestOAuthService_AuthorizationCodeFlow(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	keys := newTestKeys()
+	oauthService := NewOAuthService(mockRepo, keys)
+	ctx := context.Background()
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+
+	var stored *models.OAuthAuthorizationCode
+	mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+	mockRepo.On("SaveConsent", ctx, 1, "client-1", []string{scope.TasksRead}).Return(nil)
+	mockRepo.On("CreateAuthorizationCode", ctx, mock.AnythingOfType("*models.OAuthAuthorizationCode")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.OAuthAuthorizationCode) }).
+		Return(nil)
+
+	redirectTo, err := oauthService.Authorize(ctx, 1, models.ConsentDecision{AuthorizationRequest: testAuthorizationRequest(verifier), Approve: true})
+	require.NoError(t, err)
+	u, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	code := u.Query().Get("code")
+	assert.Equal(t, "xyz", u.Query().Get("state"))
+	assert.Equal(t, "test", u.Query().Get("iss"))
+	assert.Equal(t, utils.HashToken(code), stored.CodeHash)
+
+	var record *models.OAuthAccessToken
+	mockRepo.On("ConsumeAuthorizationCode", ctx, stored.CodeHash).Return(stored, nil)
+	mockRepo.On("CreateAccessToken", ctx, mock.AnythingOfType("*models.OAuthAccessToken")).
+		Run(func(args mock.Arguments) { record = args.Get(1).(*models.OAuthAccessToken) }).
+		Return(nil)
+
+	token, err := oauthService.ExchangeCode(ctx, ClientCredentials{ID: "client-1"}, TokenRequest{
+		GrantType: "authorization_code", Code: code, RedirectURI: testRedirectURI, CodeVerifier: verifier,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, scope.TasksRead, token.Scope)
+
+	claims, err := utils.ParseToken(keys, token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, claims.UserID)
+	assert.Equal(t, "client-1", claims.ClientID)
+	assert.Equal(t, scope.TasksRead, claims.Scope)
+	assert.Equal(t, record.JTI, claims.ID)
+	assert.Equal(t, stored.CodeHash, record.CodeHash)
+}
+
+func TestOAuthService_ExchangeCode_Rejections(t *testing.T) {
+	ctx := context.Background()
+	code := &models.OAuthAuthorizationCode{
+		CodeHash: utils.HashToken("code"), ClientID: "client-1", UserID: 1, RedirectURI: testRedirectURI,
+		Scopes: []string{scope.TasksRead}, CodeChallenge: oidc.CodeChallengeS256("verifier"), ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		mockRepo := new(MockOAuthRepository)
+		oauthService := NewOAuthService(mockRepo, newTestKeys())
+		mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+		mockRepo.On("ConsumeAuthorizationCode", ctx, code.CodeHash).Return(code, nil)
+
+		_, err := oauthService.ExchangeCode(ctx, ClientCredentials{ID: "client-1"}, TokenRequest{
+			GrantType: "authorization_code", Code: "code", RedirectURI: testRedirectURI, CodeVerifier: "other",
+		})
+
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_grant", oauthErr.Code)
+		mockRepo.AssertNotCalled(t, "CreateAccessToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("replayed code revokes issued tokens", func(t *testing.T) {
+		mockRepo := new(MockOAuthRepository)
+		oauthService := NewOAuthService(mockRepo, newTestKeys())
+		mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+		mockRepo.On("ConsumeAuthorizationCode", ctx, code.CodeHash).Return(nil, repositories.ErrAuthorizationCodeReused)
+		mockRepo.On("RevokeAccessTokensByCode", ctx, code.CodeHash).Return(nil)
+
+		_, err := oauthService.ExchangeCode(ctx, ClientCredentials{ID: "client-1"}, TokenRequest{
+			GrantType: "authorization_code", Code: "code", RedirectURI: testRedirectURI, CodeVerifier: "verifier",
+		})
+
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_grant", oauthErr.Code)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("confidential client without secret", func(t *testing.T) {
+		mockRepo := new(MockOAuthRepository)
+		oauthService := NewOAuthService(mockRepo, newTestKeys())
+		client := testPublicClient()
+		client.SecretHash = utils.HashToken("secret")
+		mockRepo.On("GetClient", ctx, "client-1").Return(client, nil)
+
+		_, err := oauthService.ExchangeCode(ctx, ClientCredentials{ID: "client-1", Secret: "wrong"}, TokenRequest{
+			GrantType: "authorization_code", Code: "code", RedirectURI: testRedirectURI, CodeVerifier: "verifier",
+		})
+
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "invalid_client", oauthErr.Code)
+	})
+
+	t.Run("unsupported grant", func(t *testing.T) {
+		oauthService := NewOAuthService(new(MockOAuthRepository), newTestKeys())
+
+		_, err := oauthService.ExchangeCode(ctx, ClientCredentials{ID: "client-1"}, TokenRequest{GrantType: "password"})
+
+		var oauthErr *OAuthError
+		require.ErrorAs(t, err, &oauthErr)
+		assert.Equal(t, "unsupported_grant_type", oauthErr.Code)
+	})
+}
+
+// issueTestToken issues a delegated token for client-1 directly.
+func issueTestToken(t *testing.T, service OAuthServiceInterface, mockRepo *MockOAuthRepository) (string, string) {
+	t.Helper()
+	var jti string
+	mockRepo.On("CreateAccessToken", mock.Anything, mock.AnythingOfType("*models.OAuthAccessToken")).
+		Run(func(args mock.Arguments) { jti = args.Get(1).(*models.OAuthAccessToken).JTI }).
+		Return(nil).Once()
+	token, err := service.(*OAuthService).issueToken(context.Background(), "client-1",
+		&models.OAuthAuthorizationCode{UserID: 1, Scopes: []string{scope.TasksRead}})
+	require.NoError(t, err)
+	return token.AccessToken, jti
+}
+
+func TestOAuthService_IntrospectAndRevoke(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	oauthService := NewOAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+	mockRepo.On("GetClient", ctx, "client-1").Return(testPublicClient(), nil)
+	other := testPublicClient()
+	other.ID = "client-2"
+	mockRepo.On("GetClient", ctx, "client-2").Return(other, nil)
+
+	token, jti := issueTestToken(t, oauthService, mockRepo)
+	record := &models.OAuthAccessToken{JTI: jti, ClientID: "client-1", UserID: 1}
+	mockRepo.On("GetAccessToken", ctx, jti).Return(record, nil)
+
+	result, err := oauthService.Introspect(ctx, ClientCredentials{ID: "client-1"}, token)
+	require.NoError(t, err)
+	assert.True(t, result.Active)
+	assert.Equal(t, scope.TasksRead, result.Scope)
+	assert.Equal(t, "1", result.Sub)
+
+	result, err = oauthService.Introspect(ctx, ClientCredentials{ID: "client-2"}, token)
+	require.NoError(t, err)
+	assert.False(t, result.Active, "clients cannot introspect other clients' tokens")
+
+	result, err = oauthService.Introspect(ctx, ClientCredentials{ID: "client-1"}, "garbage")
+	require.NoError(t, err)
+	assert.False(t, result.Active)
+
+	mockRepo.On("RevokeAccessToken", ctx, jti).Return(nil)
+	require.NoError(t, oauthService.Revoke(ctx, ClientCredentials{ID: "client-1"}, token))
+	require.NoError(t, oauthService.Revoke(ctx, ClientCredentials{ID: "client-1"}, "garbage"))
+	mockRepo.AssertNumberOfCalls(t, "RevokeAccessToken", 1)
+
+	now := time.Now()
+	record.RevokedAt = &now
+	result, err = oauthService.Introspect(ctx, ClientCredentials{ID: "client-1"}, token)
+	require.NoError(t, err)
+	assert.False(t, result.Active)
+}
+
+func TestOAuthService_IsTokenRevoked(t *testing.T) {
+	mockRepo := new(MockOAuthRepository)
+	oauthService := NewOAuthService(mockRepo, newTestKeys())
+	ctx := context.Background()
+	now := time.Now()
+
+	mockRepo.On("GetAccessToken", ctx, "live").Return(&models.OAuthAccessToken{JTI: "live"}, nil)
+	mockRepo.On("GetAccessToken", ctx, "revoked").Return(&models.OAuthAccessToken{JTI: "revoked", RevokedAt: &now}, nil)
+	mockRepo.On("GetAccessToken", ctx, "unknown").Return(nil, repositories.ErrAccessTokenNotFound)
+
+	for jti, want := range map[string]bool{"live": false, "revoked": true, "unknown": true} {
+		revoked, err := oauthService.IsTokenRevoked(ctx, jti)
+		require.NoError(t, err)
+		assert.Equal(t, want, revoked, jti)
+	}
+}
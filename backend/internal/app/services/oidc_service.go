@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// OIDCLoginStateTTL is how long a login started by StartLogin can be
+	// completed.
+	OIDCLoginStateTTL = 10 * time.Minute
+	// maxUsernameAttempts bounds how many suffixed usernames are tried when
+	// the preferred one is taken during just-in-time signup.
+	maxUsernameAttempts = 5
+	maxUsernameLength   = 50
+)
+
+var (
+	ErrUnknownProvider          = errors.New("Unknown identity provider")
+	ErrInvalidOIDCState         = errors.New("Login request is invalid or expired")
+	ErrOIDCAuthenticationFailed = errors.New("Authentication with the identity provider failed")
+	ErrIdentityInUse            = errors.New("This identity is already linked to another account")
+	ErrProviderAlreadyLinked    = errors.New("An identity from this provider is already linked to your account")
+	ErrAccountExists            = errors.New("An account with this email address already exists; log in and link the provider from your account settings")
+	ErrIdentityNotFound         = errors.New("No identity from this provider is linked to your account")
+	ErrLastLoginMethod          = errors.New("Cannot remove the last way to log in to this account")
+)
+
+// OIDCResult describes a completed callback. Token is set for logins;
+// Linked is set when an identity was attached to an existing session's user.
+type OIDCResult struct {
+	Token   string
+	Linked  bool
+	Created bool
+}
+
+type OIDCServiceInterface interface {
+	Providers() []string
+	// StartLogin returns the provider's authorization URL and the state in
+	// it, which the caller binds to the browser.
+	StartLogin(ctx context.Context, provider string, linkUserID uint) (authURL, state string, err error)
+	Callback(ctx context.Context, provider, code, state string) (*OIDCResult, error)
+	ListIdentities(ctx context.Context, userID uint) ([]models.UserIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID uint, provider string) error
+	DisablePasswordLogin(ctx context.Context, userID uint) error
+}
+
+type OIDCService struct {
+	repo      repositories.IdentityRepository
+	authRepo  repositories.AuthRepository
+	keys      *jwtkeys.Manager
+	providers map[string]*oidc.Provider
+}
+
+// NewOIDCService creates an OIDCService for the given providers. Access
+// tokens for logins are signed with keys.
+func NewOIDCService(repo repositories.IdentityRepository, authRepo repositories.AuthRepository, keys *jwtkeys.Manager, providers []*oidc.Provider) OIDCServiceInterface {
+	s := &OIDCService{repo: repo, authRepo: authRepo, keys: keys, providers: make(map[string]*oidc.Provider)}
+	for _, p := range providers {
+		s.providers[p.Name()] = p
+	}
+	return s
+}
+
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartLogin creates a login state and returns the provider's authorization
+// URL. A non-zero linkUserID links the resulting identity to that user
+// instead of logging in.
+func (s *OIDCService) StartLogin(ctx context.Context, provider string, linkUserID uint) (string, string, error) {
+	_, span := otel.Tracer("").Start(ctx, "OIDCService.StartLogin")
+	defer span.End()
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrUnknownProvider
+	}
+
+	state, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.GenerateSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = s.repo.CreateLoginState(ctx, &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   int(linkUserID),
+		ExpiresAt:    time.Now().Add(OIDCLoginStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// Callback completes the authorization code flow. The identity is looked up
+// by (provider, subject); unknown identities are linked to an existing
+// account with the same verified email address or get a new account.
+func (s *OIDCService) Callback(ctx context.Context, provider, code, state string) (*OIDCResult, error) {
+	_, span := otel.Tracer("").Start(ctx, "OIDCService.Callback")
+	defer span.End()
+
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	loginState, err := s.repo.ConsumeLoginState(ctx, utils.HashToken(state))
+	if errors.Is(err, repositories.ErrLoginStateInvalid) {
+		return nil, ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if loginState.Provider != provider {
+		return nil, ErrInvalidOIDCState
+	}
+
+	token, err := p.Exchange(ctx, code, loginState.CodeVerifier)
+	if err != nil {
+		logging.ContextLogger(ctx).Warn("OIDC code exchange failed", "provider", provider, "error", err)
+		return nil, ErrOIDCAuthenticationFailed
+	}
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, loginState.Nonce)
+	if err != nil {
+		logging.ContextLogger(ctx).Warn("OIDC ID token rejected", "provider", provider, "error", err)
+		return nil, ErrOIDCAuthenticationFailed
+	}
+
+	identity := &models.UserIdentity{Provider: provider, Subject: claims.Subject}
+	if claims.EmailVerified {
+		identity.Email = claims.Email
+	}
+
+	if loginState.LinkUserID != 0 {
+		span.SetAttributes(attribute.String("oidc.result", "linked"))
+		identity.UserID = loginState.LinkUserID
+		if err := mapIdentityError(s.repo.CreateIdentity(ctx, identity)); err != nil {
+			return nil, err
+		}
+		logging.ContextLogger(ctx).Info("Identity linked", "event", "identity_linked", "userID", identity.UserID, "provider", provider)
+		return &OIDCResult{Linked: true}, nil
+	}
+
+	user, created, err := s.resolveUser(ctx, identity, claims)
+	if err != nil {
+		span.SetAttributes(attribute.String("oidc.result", "failure"))
+		return nil, err
+	}
+	if user.Disabled {
+		span.SetAttributes(attribute.String("oidc.result", "disabled"))
+		return nil, ErrAccountDisabled
+	}
+
+	signed, err := utils.GenerateToken(s.keys, user.ID, user.Role, user.Permissions)
+	if err != nil {
+		return nil, errors.New("Failed to generate token")
+	}
+
+	span.SetAttributes(attribute.String("oidc.result", "success"))
+	return &OIDCResult{Token: signed, Created: created}, nil
+}
+
+// resolveUser finds or creates the user for identity.
+func (s *OIDCService) resolveUser(ctx context.Context, identity *models.UserIdentity, claims *oidc.IDTokenClaims) (*models.User, bool, error) {
+	user, err := s.repo.GetUserByIdentity(ctx, identity.Provider, identity.Subject)
+	if err == nil {
+		return user, false, nil
+	}
+	if !errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, false, err
+	}
+
+	// Only link by email when both sides have verified the address;
+	// otherwise anyone could pre-register a victim's address and capture
+	// their later provider login, or the other way round.
+	if identity.Email != "" {
+		existing, err := s.authRepo.GetUserByLogin(ctx, identity.Email)
+		if err == nil {
+			if !existing.EmailVerified {
+				return nil, false, ErrAccountExists
+			}
+			identity.UserID = existing.ID
+			if err := mapIdentityError(s.repo.CreateIdentity(ctx, identity)); err != nil {
+				return nil, false, err
+			}
+			logging.ContextLogger(ctx).Info("Identity linked by email", "event", "identity_linked", "userID", existing.ID, "provider", identity.Provider)
+			return existing, false, nil
+		}
+	}
+
+	base := usernameFromClaims(claims)
+	for attempt := 0; attempt < maxUsernameAttempts; attempt++ {
+		username := base
+		if attempt > 0 {
+			suffix, err := utils.GenerateSecureToken()
+			if err != nil {
+				return nil, false, err
+			}
+			username = fmt.Sprintf("%s-%s", base, strings.ToLower(suffix[:6]))
+		}
+
+		user := &models.User{Username: username, Email: identity.Email, EmailVerified: identity.Email != ""}
+		err := s.repo.CreateUserWithIdentity(ctx, user, identity)
+		if errors.Is(err, repositories.ErrUsernameTaken) {
+			continue
+		}
+		if errors.Is(err, repositories.ErrEmailTaken) {
+			return nil, false, ErrAccountExists
+		}
+		if err != nil {
+			return nil, false, mapIdentityError(err)
+		}
+
+		logging.ContextLogger(ctx).Info("User created from identity provider", "event", "user_provisioned", "userID", user.ID, "provider", identity.Provider)
+		return user, true, nil
+	}
+	return nil, false, errors.New("Failed to choose a unique username")
+}
+
+// usernameFromClaims derives a username from the provider's claims. The
+// result never contains '@', which is reserved for email logins.
+func usernameFromClaims(claims *oidc.IDTokenClaims) string {
+	candidate := claims.PreferredUsername
+	if candidate == "" && claims.Email != "" {
+		candidate, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	var b strings.Builder
+	for _, r := range candidate {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	username := b.String()
+	if runes := []rune(username); len(runes) > maxUsernameLength {
+		username = string(runes[:maxUsernameLength])
+	}
+	if username == "" {
+		username = "user"
+	}
+	return username
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uint) ([]models.UserIdentity, error) {
+	_, span := otel.Tracer("").Start(ctx, "OIDCService.ListIdentities")
+	defer span.End()
+
+	return s.repo.ListIdentities(ctx, int(userID))
+}
+
+func (s *OIDCService) UnlinkIdentity(ctx context.Context, userID uint, provider string) error {
+	_, span := otel.Tracer("").Start(ctx, "OIDCService.UnlinkIdentity")
+	defer span.End()
+
+	return mapIdentityError(s.repo.DeleteIdentity(ctx, int(userID), provider))
+}
+
+// DisablePasswordLogin removes the user's password so only linked identities
+// can be used to log in.
+func (s *OIDCService) DisablePasswordLogin(ctx context.Context, userID uint) error {
+	_, span := otel.Tracer("").Start(ctx, "OIDCService.DisablePasswordLogin")
+	defer span.End()
+
+	return mapIdentityError(s.repo.DisablePasswordLogin(ctx, int(userID)))
+}
+
+func mapIdentityError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrIdentityInUse):
+		return ErrIdentityInUse
+	case errors.Is(err, repositories.ErrProviderAlreadyLinked):
+		return ErrProviderAlreadyLinked
+	case errors.Is(err, repositories.ErrIdentityNotFound):
+		return ErrIdentityNotFound
+	case errors.Is(err, repositories.ErrLastLoginMethod):
+		return ErrLastLoginMethod
+	default:
+		return err
+	}
+}
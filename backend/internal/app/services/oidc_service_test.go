@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc/oidctest"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+// MockIdentityRepository is a mock implementation of the IdentityRepository interface
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) CreateLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	args := m.Called(ctx, state)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockIdentityRepository) GetUserByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	args := m.Called(ctx, provider, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockIdentityRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	args := m.Called(ctx, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	args := m.Called(ctx, user, identity)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.UserIdentity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) DeleteIdentity(ctx context.Context, userID int, provider string) error {
+	args := m.Called(ctx, userID, provider)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) DisablePasswordLogin(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type oidcTestEnv struct {
+	fake     *oidctest.Provider
+	repo     *MockIdentityRepository
+	authRepo *MockAuthRepository
+	keys     *jwtkeys.Manager
+	service  OIDCServiceInterface
+}
+
+func newOIDCTestEnv(t *testing.T) *oidcTestEnv {
+	t.Helper()
+	fake, err := oidctest.NewProvider("todo-client", "s3cret")
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	provider := oidc.NewProvider(fake.Config("fake", "http://app.test/auth/oidc/fake/callback"), nil)
+	env := &oidcTestEnv{fake: fake, repo: new(MockIdentityRepository), authRepo: new(MockAuthRepository), keys: newTestKeys()}
+	env.service = NewOIDCService(env.repo, env.authRepo, env.keys, []*oidc.Provider{provider})
+	return env
+}
+
+// login runs the browser part of the flow and returns the callback's code
+// and state. The login state is stored in and served from the mock.
+func (env *oidcTestEnv) login(t *testing.T, ctx context.Context, linkUserID uint) (string, string) {
+	t.Helper()
+	var stored *models.OIDCLoginState
+	env.repo.On("CreateLoginState", ctx, mock.AnythingOfType("*models.OIDCLoginState")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*models.OIDCLoginState) }).
+		Return(nil).Once()
+
+	authURL, state, err := env.service.StartLogin(ctx, "fake", linkUserID)
+	require.NoError(t, err)
+	callback, err := env.fake.Authorize(authURL)
+	require.NoError(t, err)
+
+	require.Equal(t, state, callback.Query().Get("state"))
+	require.Equal(t, stored.StateHash, utils.HashToken(state), "only the state hash is persisted")
+	env.repo.On("ConsumeLoginState", ctx, stored.StateHash).Return(stored, nil).Once()
+	return callback.Query().Get("code"), state
+}
+
+func TestOIDCService_Callback_ExistingIdentity(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-1"})
+
+	code, state := env.login(t, ctx, 0)
+	env.repo.On("GetUserByIdentity", ctx, "fake", "sub-1").Return(&models.User{ID: 7, Username: "alice"}, nil)
+
+	result, err := env.service.Callback(ctx, "fake", code, state)
+
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	userID, err := utils.ValidateToken(env.keys, result.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 7, userID)
+	env.repo.AssertExpectations(t)
+}
+
+func TestOIDCService_Callback_DisabledUser(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-1"})
+
+	code, state := env.login(t, ctx, 0)
+	env.repo.On("GetUserByIdentity", ctx, "fake", "sub-1").Return(&models.User{ID: 7, Username: "alice", Disabled: true}, nil)
+
+	_, err := env.service.Callback(ctx, "fake", code, state)
+
+	assert.ErrorIs(t, err, ErrAccountDisabled)
+}
+
+func TestOIDCService_Callback_JustInTimeSignup(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-2", Email: "bob@example.com", EmailVerified: true, PreferredUsername: "bob"})
+
+	code, state := env.login(t, ctx, 0)
+	env.repo.On("GetUserByIdentity", ctx, "fake", "sub-2").Return(nil, repositories.ErrUserNotFound)
+	env.authRepo.On("GetUserByLogin", ctx, "bob@example.com").Return(nil, repositories.ErrUserNotFound)
+	env.repo.On("CreateUserWithIdentity", ctx, mock.MatchedBy(func(u *models.User) bool { return u.Username == "bob" }), mock.Anything).
+		Return(repositories.ErrUsernameTaken).Once()
+	env.repo.On("CreateUserWithIdentity", ctx, mock.MatchedBy(func(u *models.User) bool { return strings.HasPrefix(u.Username, "bob-") }), mock.Anything).
+		Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 9 }).
+		Return(nil).Once()
+
+	result, err := env.service.Callback(ctx, "fake", code, state)
+
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.NotEmpty(t, result.Token)
+	created := env.repo.Calls[len(env.repo.Calls)-1].Arguments
+	user := created.Get(1).(*models.User)
+	identity := created.Get(2).(*models.UserIdentity)
+	assert.Empty(t, user.Password, "provider accounts have no password")
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, "bob@example.com", user.Email)
+	assert.Equal(t, "sub-2", identity.Subject)
+	env.repo.AssertExpectations(t)
+}
+
+func TestOIDCService_Callback_LinksVerifiedEmail(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-3", Email: "carol@example.com", EmailVerified: true})
+
+	code, state := env.login(t, ctx, 0)
+	env.repo.On("GetUserByIdentity", ctx, "fake", "sub-3").Return(nil, repositories.ErrUserNotFound)
+	env.authRepo.On("GetUserByLogin", ctx, "carol@example.com").
+		Return(&models.User{ID: 3, Username: "carol", Email: "carol@example.com", EmailVerified: true}, nil)
+	env.repo.On("CreateIdentity", ctx, mock.MatchedBy(func(i *models.UserIdentity) bool {
+		return i.UserID == 3 && i.Subject == "sub-3"
+	})).Return(nil)
+
+	result, err := env.service.Callback(ctx, "fake", code, state)
+
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	env.repo.AssertExpectations(t)
+}
+
+func TestOIDCService_Callback_RefusesUnverifiedLocalEmail(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-4", Email: "dave@example.com", EmailVerified: true})
+
+	code, state := env.login(t, ctx, 0)
+	env.repo.On("GetUserByIdentity", ctx, "fake", "sub-4").Return(nil, repositories.ErrUserNotFound)
+	env.authRepo.On("GetUserByLogin", ctx, "dave@example.com").
+		Return(&models.User{ID: 4, Username: "dave", Email: "dave@example.com"}, nil)
+
+	_, err := env.service.Callback(ctx, "fake", code, state)
+
+	assert.ErrorIs(t, err, ErrAccountExists)
+	env.repo.AssertNotCalled(t, "CreateIdentity", mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_Callback_LinkToSession(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.fake.SetUser(oidctest.User{Subject: "sub-5"})
+
+	code, state := env.login(t, ctx, 5)
+	env.repo.On("CreateIdentity", ctx, mock.MatchedBy(func(i *models.UserIdentity) bool {
+		return i.UserID == 5 && i.Provider == "fake" && i.Subject == "sub-5"
+	})).Return(repositories.ErrIdentityInUse)
+
+	_, err := env.service.Callback(ctx, "fake", code, state)
+
+	assert.ErrorIs(t, err, ErrIdentityInUse)
+}
+
+func TestOIDCService_Callback_InvalidState(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.repo.On("ConsumeLoginState", ctx, utils.HashToken("forged")).Return(nil, repositories.ErrLoginStateInvalid)
+
+	_, err := env.service.Callback(ctx, "fake", "code", "forged")
+
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCService_Callback_WrongProviderForState(t *testing.T) {
+	env := newOIDCTestEnv(t)
+	ctx := context.Background()
+	env.repo.On("ConsumeLoginState", ctx, utils.HashToken("state")).
+		Return(&models.OIDCLoginState{Provider: "other"}, nil)
+
+	_, err := env.service.Callback(ctx, "fake", "code", "state")
+
+	assert.ErrorIs(t, err, ErrInvalidOIDCState)
+}
+
+func TestOIDCService_StartLogin_UnknownProvider(t *testing.T) {
+	env := newOIDCTestEnv(t)
+
+	_, _, err := env.service.StartLogin(context.Background(), "nope", 0)
+
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestUsernameFromClaims(t *testing.T) {
+	assert.Equal(t, "alice", usernameFromClaims(&oidc.IDTokenClaims{PreferredUsername: "alice"}))
+	assert.Equal(t, "bob.smith", usernameFromClaims(&oidc.IDTokenClaims{Email: "bob.smith@example.com"}))
+	assert.Equal(t, "evilexample.com", usernameFromClaims(&oidc.IDTokenClaims{PreferredUsername: "evil@example.com"}))
+	assert.Equal(t, "user", usernameFromClaims(&oidc.IDTokenClaims{}))
+}
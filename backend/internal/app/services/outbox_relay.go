@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+// outboxPurgeInterval is how often the relay deletes published events that
+// are older than the retention.
+const outboxPurgeInterval = time.Hour
+
+// EventSink receives the domain events relayed from the outbox. The outbox
+// records which sinks have an event by Name, so the name must not change
+// between releases. Publish may be called more than once for an event, for
+// example after a crash, and should be idempotent on the event ID.
+type EventSink interface {
+	Name() string
+	Publish(ctx context.Context, event models.Event) error
+}
+
+// OutboxRelayConfig tunes the outbox relay. A failed event is retried after
+// BaseBackoff, doubled for every further failure up to MaxBackoff, and is
+// never given up on. Published events are purged after Retention.
+type OutboxRelayConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Retention   time.Duration
+}
+
+// DefaultOutboxRelayConfig polls every second, leases batches of 100 events
+// for a minute, retries failures for up to five minutes apart and keeps
+// published events for three days.
+func DefaultOutboxRelayConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{
+		Interval:    time.Second,
+		BatchSize:   100,
+		Lease:       time.Minute,
+		BaseBackoff: time.Second,
+		MaxBackoff:  5 * time.Minute,
+		Retention:   72 * time.Hour,
+	}
+}
+
+// OutboxRelay publishes the events recorded in the outbox to its sinks.
+// Every replica of the backend runs one; events are leased with SELECT ...
+// FOR UPDATE SKIP LOCKED, so replicas never relay the same event at once.
+// Since events are only recorded by committed transactions, sinks never see
+// events of changes that were rolled back, and since an event stays in the
+// outbox until every sink has it, they never miss one.
+type OutboxRelay struct {
+	repo   repositories.OutboxRepository
+	sinks  []EventSink
+	config OutboxRelayConfig
+	now    func() time.Time
+}
+
+func NewOutboxRelay(repo repositories.OutboxRepository, config OutboxRelayConfig, sinks ...EventSink) *OutboxRelay {
+	return &OutboxRelay{repo: repo, sinks: sinks, config: config, now: time.Now}
+}
+
+// Run relays events every interval, and purges old ones every
+// outboxPurgeInterval, until ctx is done.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	var purged time.Time
+	for {
+		if _, err := r.RelayDue(ctx); err != nil && ctx.Err() == nil {
+			logging.ContextLogger(ctx).Error("Failed to relay outbox events", "error", err)
+		}
+		if now := r.now(); now.Sub(purged) >= outboxPurgeInterval {
+			purged = now
+			if _, err := r.repo.PurgePublished(ctx, now.Add(-r.config.Retention)); err != nil && ctx.Err() == nil {
+				logging.ContextLogger(ctx).Error("Failed to purge published outbox events", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayDue relays every due event, batch by batch, and returns how many
+// events every sink now has.
+func (r *OutboxRelay) RelayDue(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "OutboxRelay.RelayDue")
+	defer span.End()
+
+	published := 0
+	for {
+		token, err := utils.GenerateSecureToken()
+		if err != nil {
+			return published, err
+		}
+		batch, err := r.repo.ClaimEvents(ctx, token, r.config.BatchSize, r.config.Lease)
+		if err != nil {
+			return published, err
+		}
+		for _, event := range batch {
+			ok, err := r.relay(ctx, event, token)
+			if err != nil {
+				logging.ContextLogger(ctx).Error("Failed to record the outcome of an outbox event", "seq", event.Seq, "error", err)
+			}
+			if ok {
+				published++
+			}
+		}
+		if len(batch) < r.config.BatchSize {
+			return published, nil
+		}
+	}
+}
+
+// relay hands event to every sink that does not have it yet and records
+// the outcome. It reports whether every sink has the event.
+func (r *OutboxRelay) relay(ctx context.Context, event models.OutboxEvent, token string) (bool, error) {
+	done := slices.Clone(event.PublishedSinks)
+	var errs []error
+	for _, sink := range r.sinks {
+		if slices.Contains(done, sink.Name()) {
+			continue
+		}
+		if err := sink.Publish(ctx, event.Event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		done = append(done, sink.Name())
+	}
+
+	if len(errs) == 0 {
+		if err := r.repo.MarkPublished(ctx, event.Seq, token, done); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	err := errors.Join(errs...)
+	next := r.now().Add(retryBackoff(event.Attempts+1, r.config.BaseBackoff, r.config.MaxBackoff))
+	logging.ContextLogger(ctx).Warn("Outbox event not relayed to every sink", "seq", event.Seq, "eventID", event.Event.ID,
+		"attempts", event.Attempts+1, "nextAttemptAt", next, "error", err)
+	return false, r.repo.RecordFailure(ctx, event.Seq, token, done, err.Error(), next)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockOutboxRepository is a mock implementation of the OutboxRepository interface
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+var _ repositories.OutboxRepository = (*MockOutboxRepository)(nil)
+
+func (m *MockOutboxRepository) ClaimEvents(ctx context.Context, token string, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, token, limit, lease)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkPublished(ctx context.Context, seq int64, token string, sinks []string) error {
+	args := m.Called(ctx, seq, token, sinks)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) RecordFailure(ctx context.Context, seq int64, token string, sinks []string, lastError string, nextAttempt time.Time) error {
+	args := m.Called(ctx, seq, token, sinks, lastError, nextAttempt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) PurgePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// fakeSink records the events it is given and fails with err.
+type fakeSink struct {
+	name   string
+	err    error
+	events []models.Event
+}
+
+func (s *fakeSink) Name() string { return s.name }
+
+func (s *fakeSink) Publish(ctx context.Context, event models.Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func newTestRelay(repo *MockOutboxRepository, batchSize int, sinks ...EventSink) *OutboxRelay {
+	config := DefaultOutboxRelayConfig()
+	config.BatchSize = batchSize
+	relay := NewOutboxRelay(repo, config, sinks...)
+	relay.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+	return relay
+}
+
+func TestOutboxRelay_RelayDue_AllSinks(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	bus, hooks := &fakeSink{name: "bus"}, &fakeSink{name: "webhooks"}
+	relay := newTestRelay(mockRepo, 10, bus, hooks)
+	ctx := context.Background()
+
+	event := models.OutboxEvent{Seq: 7, Event: models.Event{ID: "evt", Type: models.EventTaskCreated, ListID: 4}}
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 10, time.Minute).Return([]models.OutboxEvent{event}, nil).Once()
+	mockRepo.On("MarkPublished", mock.Anything, int64(7), mock.Anything, []string{"bus", "webhooks"}).Return(nil)
+
+	published, err := relay.RelayDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []models.Event{event.Event}, bus.events)
+	assert.Equal(t, []models.Event{event.Event}, hooks.events)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOutboxRelay_RelayDue_SinkFails(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	bus, hooks := &fakeSink{name: "bus"}, &fakeSink{name: "webhooks", err: errors.New("database is down")}
+	relay := newTestRelay(mockRepo, 10, bus, hooks)
+	ctx := context.Background()
+
+	event := models.OutboxEvent{Seq: 7, Event: models.Event{ID: "evt"}, Attempts: 2}
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 10, time.Minute).Return([]models.OutboxEvent{event}, nil).Once()
+	// The third failure waits four times the base backoff.
+	next := relay.now().Add(4 * time.Second)
+	mockRepo.On("RecordFailure", mock.Anything, int64(7), mock.Anything, []string{"bus"}, "webhooks: database is down", next).Return(nil)
+
+	published, err := relay.RelayDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkPublished", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOutboxRelay_RelayDue_SkipsSinksThatHaveTheEvent(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	bus, hooks := &fakeSink{name: "bus"}, &fakeSink{name: "webhooks"}
+	relay := newTestRelay(mockRepo, 10, bus, hooks)
+	ctx := context.Background()
+
+	event := models.OutboxEvent{Seq: 7, Event: models.Event{ID: "evt"}, PublishedSinks: []string{"bus"}, Attempts: 1}
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 10, time.Minute).Return([]models.OutboxEvent{event}, nil).Once()
+	mockRepo.On("MarkPublished", mock.Anything, int64(7), mock.Anything, []string{"bus", "webhooks"}).Return(nil)
+
+	_, err := relay.RelayDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, bus.events)
+	assert.Len(t, hooks.events, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOutboxRelay_RelayDue_ClaimsUntilBatchIsShort(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	bus := &fakeSink{name: "bus"}
+	relay := newTestRelay(mockRepo, 2, bus)
+	ctx := context.Background()
+
+	full := []models.OutboxEvent{{Seq: 1}, {Seq: 2}}
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 2, time.Minute).Return(full, nil).Once()
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 2, time.Minute).Return([]models.OutboxEvent{{Seq: 3}}, nil).Once()
+	mockRepo.On("MarkPublished", mock.Anything, mock.Anything, mock.Anything, []string{"bus"}).Return(nil)
+
+	published, err := relay.RelayDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, published)
+	mockRepo.AssertNumberOfCalls(t, "ClaimEvents", 2)
+}
+
+func TestOutboxRelay_RelayDue_LeaseLost(t *testing.T) {
+	mockRepo := new(MockOutboxRepository)
+	relay := newTestRelay(mockRepo, 10, &fakeSink{name: "bus"})
+	ctx := context.Background()
+
+	mockRepo.On("ClaimEvents", mock.Anything, mock.Anything, 10, time.Minute).Return([]models.OutboxEvent{{Seq: 1}}, nil).Once()
+	mockRepo.On("MarkPublished", mock.Anything, int64(1), mock.Anything, []string{"bus"}).Return(repositories.ErrOutboxLeaseLost)
+
+	published, err := relay.RelayDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+const passwordResetTokenTTL = time.Hour
+
+var (
+	ErrInvalidCurrentPassword = errors.New("Current password is incorrect")
+	ErrInvalidResetToken      = errors.New("Reset token is invalid or expired")
+)
+
+type PasswordServiceInterface interface {
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error
+	RequestPasswordReset(ctx context.Context, login string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+type PasswordService struct {
+	repo     repositories.AuthRepository
+	mailer   mailer.Mailer
+	policy   password.Policy
+	hasher   password.PasswordHasher
+	resetURL string
+}
+
+// NewPasswordService creates a PasswordService. resetURL is the frontend page
+// that receives the reset token as its "token" query parameter.
+func NewPasswordService(repo repositories.AuthRepository, mailer mailer.Mailer, policy password.Policy, hasher password.PasswordHasher, resetURL string) PasswordServiceInterface {
+	return &PasswordService{repo: repo, mailer: mailer, policy: policy, hasher: hasher, resetURL: resetURL}
+}
+
+func (s *PasswordService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	_, span := otel.Tracer("").Start(ctx, "PasswordService.ChangePassword")
+	defer span.End()
+
+	user, err := s.repo.GetUserByID(ctx, int(userID))
+	if err != nil {
+		return err
+	}
+
+	// Accounts without a password (identity provider only) may set one
+	// without knowing a current password.
+	if user.Password != "" {
+		if ok, _ := s.hasher.Verify(currentPassword, user.Password); !ok {
+			return ErrInvalidCurrentPassword
+		}
+	}
+
+	if err := s.policy.Validate(ctx, newPassword, user.Username); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return errors.New("Failed to hash password")
+	}
+
+	return s.repo.UpdatePassword(ctx, user.ID, hashedPassword)
+}
+
+// RequestPasswordReset issues a reset token and mails a reset link to the
+// user identified by username or email. It returns nil for unknown users so
+// callers cannot probe which accounts exist.
+func (s *PasswordService) RequestPasswordReset(ctx context.Context, login string) error {
+	_, span := otel.Tracer("").Start(ctx, "PasswordService.RequestPasswordReset")
+	defer span.End()
+
+	user, err := s.repo.GetUserByLogin(ctx, login)
+	if err != nil {
+		logging.ContextLogger(ctx).Info("Password reset requested for unknown user")
+		return nil
+	}
+	if user.Email == "" {
+		logging.ContextLogger(ctx).Info("Password reset requested for user without email", "userID", user.ID)
+		return nil
+	}
+
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return errors.New("Failed to generate reset token")
+	}
+
+	expiresAt := time.Now().Add(passwordResetTokenTTL)
+	if err := s.repo.CreatePasswordResetToken(ctx, user.ID, utils.HashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("A password reset was requested for your account.\n\n"+
+			"Open the following link within %s to choose a new password:\n%s\n\n"+
+			"If you did not request this, you can ignore this email.",
+			passwordResetTokenTTL, tokenLink(s.resetURL, token)),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		logging.ContextLogger(ctx).Error("Failed to send password reset mail", "userID", user.ID, "error", err)
+	}
+
+	return nil
+}
+
+func (s *PasswordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	_, span := otel.Tracer("").Start(ctx, "PasswordService.ResetPassword")
+	defer span.End()
+
+	tokenHash := utils.HashToken(token)
+	user, err := s.repo.GetUserByResetToken(ctx, tokenHash)
+	if errors.Is(err, repositories.ErrResetTokenInvalid) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.policy.Validate(ctx, newPassword, user.Username); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return errors.New("Failed to hash password")
+	}
+
+	err = s.repo.ResetPassword(ctx, tokenHash, hashedPassword)
+	if errors.Is(err, repositories.ErrResetTokenInvalid) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/mailer"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/password"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+// MockMailer is a mock implementation of the mailer.Mailer interface
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, msg mailer.Message) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("oldpassword"), bcrypt.DefaultCost)
+	user := &models.User{ID: 1, Username: "testuser", Password: string(hashedPassword)}
+
+	mockRepo.On("GetUserByID", ctx, 1).Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, 1, mock.MatchedBy(func(hash string) bool {
+		ok, _ := password.DefaultHasher().Verify("newpassword", hash)
+		return ok
+	})).Return(nil)
+
+	err := passwordService.ChangePassword(ctx, 1, "oldpassword", "newpassword")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPasswordService_ChangePassword_WrongCurrentPassword(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("oldpassword"), bcrypt.DefaultCost)
+	user := &models.User{ID: 1, Username: "testuser", Password: string(hashedPassword)}
+
+	mockRepo.On("GetUserByID", ctx, 1).Return(user, nil)
+
+	err := passwordService.ChangePassword(ctx, 1, "wrongpassword", "newpassword")
+
+	assert.ErrorIs(t, err, ErrInvalidCurrentPassword)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_ChangePassword_NoPasswordYet(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	user := &models.User{ID: 1, Username: "testuser"}
+
+	mockRepo.On("GetUserByID", ctx, 1).Return(user, nil)
+	mockRepo.On("UpdatePassword", ctx, 1, mock.AnythingOfType("string")).Return(nil)
+
+	err := passwordService.ChangePassword(ctx, 1, "", "newpassword")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPasswordService_RequestPasswordReset(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockMailer := new(MockMailer)
+	passwordService := NewPasswordService(mockRepo, mockMailer, password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	user := &models.User{ID: 1, Username: "testuser", Email: "test@example.com"}
+
+	var storedHash string
+	mockRepo.On("GetUserByLogin", ctx, "testuser").Return(user, nil)
+	mockRepo.On("CreatePasswordResetToken", ctx, 1, mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+		Return(nil)
+
+	var sent mailer.Message
+	mockMailer.On("Send", ctx, mock.AnythingOfType("mailer.Message")).
+		Run(func(args mock.Arguments) { sent = args.Get(1).(mailer.Message) }).
+		Return(nil)
+
+	err := passwordService.RequestPasswordReset(ctx, "testuser")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", sent.To)
+
+	// The mailed link carries the raw token, only its hash is stored.
+	idx := strings.Index(sent.Body, "token=")
+	assert.NotEqual(t, -1, idx)
+	token := strings.Fields(sent.Body[idx+len("token="):])[0]
+	assert.Equal(t, utils.HashToken(token), storedHash)
+	assert.NotEqual(t, token, storedHash)
+
+	mockRepo.AssertExpectations(t)
+	mockMailer.AssertExpectations(t)
+}
+
+func TestPasswordService_RequestPasswordReset_UnknownUser(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	mockMailer := new(MockMailer)
+	passwordService := NewPasswordService(mockRepo, mockMailer, password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	mockRepo.On("GetUserByLogin", ctx, "nobody").Return(nil, repositories.ErrUserNotFound)
+
+	err := passwordService.RequestPasswordReset(ctx, "nobody")
+
+	assert.NoError(t, err)
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	mockRepo.On("GetUserByResetToken", ctx, utils.HashToken("token")).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+	mockRepo.On("ResetPassword", ctx, utils.HashToken("token"), mock.AnythingOfType("string")).Return(nil)
+
+	err := passwordService.ResetPassword(ctx, "token", "newpassword")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPasswordService_ResetPassword_InvalidToken(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	mockRepo.On("GetUserByResetToken", ctx, utils.HashToken("token")).Return(nil, repositories.ErrResetTokenInvalid)
+
+	err := passwordService.ResetPassword(ctx, "token", "newpassword")
+
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+	mockRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_ResetPassword_PolicyViolation(t *testing.T) {
+	mockRepo := new(MockAuthRepository)
+	passwordService := NewPasswordService(mockRepo, new(MockMailer), password.DefaultPolicy(), password.DefaultHasher(), "http://localhost/reset")
+
+	ctx := context.Background()
+	mockRepo.On("GetUserByResetToken", ctx, utils.HashToken("token")).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+
+	err := passwordService.ResetPassword(ctx, "token", "testuser-2024")
+
+	var vErr *password.ValidationError
+	assert.ErrorAs(t, err, &vErr)
+	assert.Equal(t, password.RuleContainsUsername, vErr.Violations[0].Rule)
+	mockRepo.AssertNotCalled(t, "ResetPassword", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+// ReminderSchedulerConfig tunes the reminder scheduler. The lease of each
+// reminder is renewed right before it is delivered, and delivery is given at
+// most half of Lease, so Lease only has to outlast delivering one reminder.
+type ReminderSchedulerConfig struct {
+	Interval  time.Duration
+	BatchSize int
+	Lease     time.Duration
+}
+
+// DefaultReminderSchedulerConfig polls every 30 seconds and claims batches
+// of 100 reminders, each leased for five minutes from its renewal.
+func DefaultReminderSchedulerConfig() ReminderSchedulerConfig {
+	return ReminderSchedulerConfig{
+		Interval:  30 * time.Second,
+		BatchSize: 100,
+		Lease:     5 * time.Minute,
+	}
+}
+
+// ReminderScheduler fires due reminders through the Notifier. Every replica
+// of the backend runs one. Due reminders are leased with SELECT ... FOR
+// UPDATE SKIP LOCKED, so replicas do not fire the same reminder while its
+// lease holds, and a reminder is retired in the database once its
+// notification has been handed to the channels. Reminders that came due
+// while no scheduler was running fire on the next poll. Delivery is at least
+// once: if a replica dies or stalls between delivering and retiring a
+// reminder, the reminder fires again once its lease runs out.
+type ReminderScheduler struct {
+	repo     repositories.ReminderRepository
+	notifier Notifier
+	config   ReminderSchedulerConfig
+}
+
+func NewReminderScheduler(repo repositories.ReminderRepository, notifier Notifier, config ReminderSchedulerConfig) *ReminderScheduler {
+	return &ReminderScheduler{repo: repo, notifier: notifier, config: config}
+}
+
+// Run fires due reminders every interval until ctx is done.
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.FireDue(ctx); err != nil && ctx.Err() == nil {
+			logging.ContextLogger(ctx).Error("Failed to fire reminders", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FireDue fires every due reminder, batch by batch, and returns how many
+// were retired.
+func (s *ReminderScheduler) FireDue(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "ReminderScheduler.FireDue")
+	defer span.End()
+
+	fired := 0
+	for {
+		token, err := utils.GenerateSecureToken()
+		if err != nil {
+			return fired, err
+		}
+		batch, err := s.repo.ClaimDueReminders(ctx, token, s.config.BatchSize, s.config.Lease)
+		if err != nil {
+			return fired, err
+		}
+		for _, reminder := range batch {
+			if s.fire(ctx, reminder, token) {
+				fired++
+			}
+		}
+		if len(batch) < s.config.BatchSize {
+			return fired, nil
+		}
+	}
+}
+
+// fire renews the lease of a claimed reminder, delivers it and retires it.
+// A reminder whose lease was lost while earlier ones of the batch were being
+// delivered is left to whoever holds it now. Delivery failures are recorded
+// on the reminder rather than retried, because the channels that succeeded
+// would deliver it again.
+func (s *ReminderScheduler) fire(ctx context.Context, reminder models.DueReminder, token string) bool {
+	err := s.repo.RenewLease(ctx, reminder.ID, token, s.config.Lease)
+	if errors.Is(err, repositories.ErrReminderLeaseLost) {
+		logging.ContextLogger(ctx).Warn("Reminder lease lost before it was delivered", "reminderID", reminder.ID)
+		return false
+	}
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to renew reminder lease", "reminderID", reminder.ID, "error", err)
+		return false
+	}
+
+	var lastError string
+	if reminder.Deliver {
+		notifyCtx, cancel := context.WithTimeout(ctx, s.config.Lease/2)
+		err := s.notifier.Notify(notifyCtx, models.Notification{
+			UserID:    reminder.UserID,
+			Type:      models.NotificationReminder,
+			TaskID:    reminder.TaskID,
+			TaskTitle: reminder.TaskTitle,
+		})
+		cancel()
+		if err != nil {
+			lastError = err.Error()
+			logging.ContextLogger(ctx).Error("Failed to deliver reminder", "reminderID", reminder.ID, "error", err)
+		}
+	}
+
+	err = s.repo.MarkFired(ctx, reminder.ID, token, lastError)
+	if errors.Is(err, repositories.ErrReminderLeaseLost) {
+		logging.ContextLogger(ctx).Warn("Reminder lease lost before it was retired", "reminderID", reminder.ID)
+		return false
+	}
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to retire reminder", "reminderID", reminder.ID, "error", err)
+		return false
+	}
+	logging.ContextLogger(ctx).Info("Reminder fired", "event", "reminder_fired", "reminderID", reminder.ID, "taskID", reminder.TaskID, "delivered", reminder.Deliver)
+	return true
+}
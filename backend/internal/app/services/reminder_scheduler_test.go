@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+var testSchedulerConfig = ReminderSchedulerConfig{Interval: time.Second, BatchSize: 2, Lease: time.Minute}
+
+func TestReminderScheduler_FireDue(t *testing.T) {
+	mockRepo := new(MockReminderRepository)
+	mockNotifier := new(MockNotifier)
+	scheduler := NewReminderScheduler(mockRepo, mockNotifier, testSchedulerConfig)
+	ctx := context.Background()
+
+	// A full batch makes the scheduler claim again until a short batch
+	// comes back.
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Return([]models.DueReminder{
+		{ID: 1, TaskID: 5, UserID: 2, TaskTitle: "Milk", Deliver: true},
+		{ID: 2, TaskID: 6, UserID: 2, TaskTitle: "Done already", Deliver: false},
+	}, nil).Once()
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Return([]models.DueReminder{
+		{ID: 3, TaskID: 7, UserID: 4, TaskTitle: "Eggs", Deliver: true},
+	}, nil).Once()
+	mockNotifier.On("Notify", mock.Anything, []models.Notification{
+		{UserID: 2, Type: models.NotificationReminder, TaskID: 5, TaskTitle: "Milk"},
+	}).Return(nil)
+	mockNotifier.On("Notify", mock.Anything, []models.Notification{
+		{UserID: 4, Type: models.NotificationReminder, TaskID: 7, TaskTitle: "Eggs"},
+	}).Return(errors.New("email: smtp down"))
+	mockRepo.On("RenewLease", mock.Anything, mock.Anything, mock.Anything, time.Minute).Return(nil)
+	mockRepo.On("MarkFired", mock.Anything, 1, mock.Anything, "").Return(nil)
+	mockRepo.On("MarkFired", mock.Anything, 2, mock.Anything, "").Return(nil)
+	mockRepo.On("MarkFired", mock.Anything, 3, mock.Anything, "email: smtp down").Return(nil)
+
+	fired, err := scheduler.FireDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fired)
+	mockRepo.AssertExpectations(t)
+	mockNotifier.AssertNumberOfCalls(t, "Notify", 2)
+}
+
+func TestReminderScheduler_FireDue_UsesClaimToken(t *testing.T) {
+	mockRepo := new(MockReminderRepository)
+	mockNotifier := new(MockNotifier)
+	scheduler := NewReminderScheduler(mockRepo, mockNotifier, testSchedulerConfig)
+	ctx := context.Background()
+
+	var token string
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Run(func(args mock.Arguments) {
+		token = args.String(1)
+	}).Return([]models.DueReminder{{ID: 1, TaskID: 5, UserID: 2, Deliver: true}}, nil)
+	mockRepo.On("RenewLease", mock.Anything, 1, mock.Anything, time.Minute).Return(nil)
+	mockNotifier.On("Notify", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("MarkFired", mock.Anything, 1, mock.Anything, "").Return(repositories.ErrReminderLeaseLost)
+
+	fired, err := scheduler.FireDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Zero(t, fired)
+	assert.NotEmpty(t, token)
+	mockRepo.AssertCalled(t, "RenewLease", mock.Anything, 1, token, time.Minute)
+	mockRepo.AssertCalled(t, "MarkFired", mock.Anything, 1, token, "")
+}
+
+func TestReminderScheduler_FireDue_SkipsLostLease(t *testing.T) {
+	mockRepo := new(MockReminderRepository)
+	mockNotifier := new(MockNotifier)
+	scheduler := NewReminderScheduler(mockRepo, mockNotifier, testSchedulerConfig)
+	ctx := context.Background()
+
+	// Reminder 1 was taken over by another replica while the batch was
+	// being delivered.
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Return([]models.DueReminder{
+		{ID: 1, TaskID: 5, UserID: 2, Deliver: true},
+		{ID: 2, TaskID: 6, UserID: 2, Deliver: true},
+	}, nil).Once()
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Return([]models.DueReminder(nil), nil).Once()
+	mockRepo.On("RenewLease", mock.Anything, 1, mock.Anything, time.Minute).Return(repositories.ErrReminderLeaseLost)
+	mockRepo.On("RenewLease", mock.Anything, 2, mock.Anything, time.Minute).Return(nil)
+	var deadline time.Time
+	mockNotifier.On("Notify", mock.Anything, []models.Notification{
+		{UserID: 2, Type: models.NotificationReminder, TaskID: 6},
+	}).Run(func(args mock.Arguments) {
+		deadline, _ = args.Get(0).(context.Context).Deadline()
+	}).Return(nil).Once()
+	mockRepo.On("MarkFired", mock.Anything, 2, mock.Anything, "").Return(nil)
+
+	fired, err := scheduler.FireDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	mockRepo.AssertExpectations(t)
+	mockNotifier.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkFired", mock.Anything, 1, mock.Anything, mock.Anything)
+	assert.WithinDuration(t, time.Now().Add(30*time.Second), deadline, 5*time.Second, "delivery must end well within the lease")
+}
+
+func TestReminderScheduler_Run_StopsWithContext(t *testing.T) {
+	mockRepo := new(MockReminderRepository)
+	scheduler := NewReminderScheduler(mockRepo, new(MockNotifier), testSchedulerConfig)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mockRepo.On("ClaimDueReminders", mock.Anything, mock.Anything, 2, time.Minute).Run(func(mock.Arguments) {
+		cancel()
+	}).Return([]models.DueReminder(nil), nil)
+
+	done := make(chan struct{})
+	go func() {
+		scheduler.Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was canceled")
+	}
+}
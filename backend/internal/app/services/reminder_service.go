@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+// maxRemindersPerTask limits the pending reminders a user can set on one
+// task.
+const maxRemindersPerTask = 10
+
+var (
+	ErrInvalidReminder  = errors.New("Set either remind_at or a non-negative offset_minutes")
+	ErrReminderNotFound = errors.New("Reminder not found")
+	ErrReminderInPast   = errors.New("Reminder would fire in the past")
+	ErrTooManyReminders = errors.New("A task can have at most 10 pending reminders per user")
+)
+
+type ReminderServiceInterface interface {
+	// ListReminders returns the user's reminders on a task, soonest first.
+	ListReminders(ctx context.Context, userID uint, taskID int) ([]models.Reminder, error)
+	// CreateReminder sets a reminder for the user, either at an absolute
+	// time or as an offset from the task's creation.
+	CreateReminder(ctx context.Context, userID uint, taskID int, req models.ReminderRequest) (*models.Reminder, error)
+	DeleteReminder(ctx context.Context, userID uint, taskID, reminderID int) error
+}
+
+type ReminderService struct {
+	repo repositories.ReminderRepository
+}
+
+func NewReminderService(repo repositories.ReminderRepository) ReminderServiceInterface {
+	return &ReminderService{repo: repo}
+}
+
+func (s *ReminderService) ListReminders(ctx context.Context, userID uint, taskID int) ([]models.Reminder, error) {
+	_, span := otel.Tracer("").Start(ctx, "ReminderService.ListReminders")
+	defer span.End()
+
+	return s.repo.ListReminders(ctx, taskID, int(userID))
+}
+
+func (s *ReminderService) CreateReminder(ctx context.Context, userID uint, taskID int, req models.ReminderRequest) (*models.Reminder, error) {
+	_, span := otel.Tracer("").Start(ctx, "ReminderService.CreateReminder")
+	defer span.End()
+
+	if (req.RemindAt == nil) == (req.OffsetMinutes == nil) || (req.OffsetMinutes != nil && *req.OffsetMinutes < 0) {
+		return nil, ErrInvalidReminder
+	}
+	reminder := &models.Reminder{TaskID: taskID, UserID: int(userID), RemindAt: req.RemindAt, OffsetMinutes: req.OffsetMinutes}
+	if err := s.repo.CreateReminder(ctx, reminder, maxRemindersPerTask); err != nil {
+		return nil, mapReminderError(err)
+	}
+	logging.ContextLogger(ctx).Info("Reminder created", "event", "reminder_created", "reminderID", reminder.ID, "taskID", taskID, "fireAt", reminder.FireAt, "userID", userID)
+	return reminder, nil
+}
+
+func (s *ReminderService) DeleteReminder(ctx context.Context, userID uint, taskID, reminderID int) error {
+	_, span := otel.Tracer("").Start(ctx, "ReminderService.DeleteReminder")
+	defer span.End()
+
+	if err := s.repo.DeleteReminder(ctx, taskID, reminderID, int(userID)); err != nil {
+		return mapReminderError(err)
+	}
+	logging.ContextLogger(ctx).Info("Reminder deleted", "event", "reminder_deleted", "reminderID", reminderID, "taskID", taskID, "userID", userID)
+	return nil
+}
+
+func mapReminderError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrReminderNotFound):
+		return ErrReminderNotFound
+	case errors.Is(err, repositories.ErrReminderInPast):
+		return ErrReminderInPast
+	case errors.Is(err, repositories.ErrTooManyReminders):
+		return ErrTooManyReminders
+	default:
+		return err
+	}
+}
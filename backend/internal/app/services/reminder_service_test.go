@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockReminderRepository is a mock implementation of the ReminderRepository interface
+type MockReminderRepository struct {
+	mock.Mock
+}
+
+func (m *MockReminderRepository) ListReminders(ctx context.Context, taskID, userID int) ([]models.Reminder, error) {
+	args := m.Called(ctx, taskID, userID)
+	return args.Get(0).([]models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) CreateReminder(ctx context.Context, reminder *models.Reminder, max int) error {
+	args := m.Called(ctx, reminder, max)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) DeleteReminder(ctx context.Context, taskID, reminderID, userID int) error {
+	args := m.Called(ctx, taskID, reminderID, userID)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) ClaimDueReminders(ctx context.Context, token string, limit int, lease time.Duration) ([]models.DueReminder, error) {
+	args := m.Called(ctx, token, limit, lease)
+	return args.Get(0).([]models.DueReminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) RenewLease(ctx context.Context, reminderID int, token string, lease time.Duration) error {
+	args := m.Called(ctx, reminderID, token, lease)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) MarkFired(ctx context.Context, reminderID int, token, lastError string) error {
+	args := m.Called(ctx, reminderID, token, lastError)
+	return args.Error(0)
+}
+
+func TestReminderService_CreateReminder(t *testing.T) {
+	mockRepo := new(MockReminderRepository)
+	reminderService := NewReminderService(mockRepo)
+	ctx := context.Background()
+	offset := 90
+
+	mockRepo.On("CreateReminder", ctx, mock.MatchedBy(func(r *models.Reminder) bool {
+		return r.TaskID == 5 && r.UserID == 1 && r.RemindAt == nil && *r.OffsetMinutes == 90
+	}), maxRemindersPerTask).Run(func(args mock.Arguments) {
+		r := args.Get(1).(*models.Reminder)
+		r.ID = 3
+	}).Return(nil)
+
+	reminder, err := reminderService.CreateReminder(ctx, 1, 5, models.ReminderRequest{OffsetMinutes: &offset})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reminder.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestReminderService_CreateReminder_Invalid(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	negative := -5
+	zero := 0
+	tests := map[string]models.ReminderRequest{
+		"neither":         {},
+		"both":            {RemindAt: &at, OffsetMinutes: &zero},
+		"negative offset": {OffsetMinutes: &negative},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockReminderRepository)
+			reminderService := NewReminderService(mockRepo)
+
+			_, err := reminderService.CreateReminder(context.Background(), 1, 5, req)
+
+			assert.ErrorIs(t, err, ErrInvalidReminder)
+			mockRepo.AssertNotCalled(t, "CreateReminder", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestReminderService_CreateReminder_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		want    error
+	}{
+		{"in the past", repositories.ErrReminderInPast, ErrReminderInPast},
+		{"limit reached", repositories.ErrTooManyReminders, ErrTooManyReminders},
+		{"task not visible", repositories.ErrTaskNotFound, repositories.ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockReminderRepository)
+			reminderService := NewReminderService(mockRepo)
+			at := time.Now().Add(time.Hour)
+			mockRepo.On("CreateReminder", mock.Anything, mock.Anything, maxRemindersPerTask).Return(tt.repoErr)
+
+			_, err := reminderService.CreateReminder(context.Background(), 1, 5, models.ReminderRequest{RemindAt: &at})
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
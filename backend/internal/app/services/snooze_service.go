@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+// Snooze presets resolve to these local times. Tasks cannot be snoozed for
+// longer than maxSnoozeDuration.
+const (
+	snoozeLaterToday  = 3 * time.Hour
+	snoozeMorningHour = 9
+	snoozeEveningHour = 18
+	maxSnoozeDuration = 366 * 24 * time.Hour
+)
+
+// SnoozePresets are the presets accepted by SnoozeTask, see
+// resolveSnoozePreset.
+var SnoozePresets = []string{"later_today", "this_evening", "tomorrow_morning", "this_weekend", "next_week"}
+
+var (
+	ErrInvalidSnooze       = errors.New("Set either until or a preset")
+	ErrUnknownSnoozePreset = fmt.Errorf("preset must be one of %s", strings.Join(SnoozePresets, ", "))
+	ErrSnoozeInPast        = errors.New("Snooze time must be in the future")
+	ErrSnoozeTooLong       = errors.New("Tasks can be snoozed for at most a year")
+	ErrInvalidTimeZone     = errors.New("Unknown time zone")
+)
+
+type SnoozeServiceInterface interface {
+	// SnoozeTask hides a task from the default task list until the time of
+	// req. It requires the editor role.
+	SnoozeTask(ctx context.Context, userID uint, taskID int, req models.SnoozeTaskRequest) (*models.Task, error)
+	// UnsnoozeTask shows a snoozed task again right away.
+	UnsnoozeTask(ctx context.Context, userID uint, taskID int) (*models.Task, error)
+}
+
+type SnoozeService struct {
+	repo  repositories.TaskRepository
+	users repositories.AuthRepository
+	now   func() time.Time
+}
+
+func NewSnoozeService(repo repositories.TaskRepository, users repositories.AuthRepository) SnoozeServiceInterface {
+	return &SnoozeService{repo: repo, users: users, now: time.Now}
+}
+
+func (s *SnoozeService) SnoozeTask(ctx context.Context, userID uint, taskID int, req models.SnoozeTaskRequest) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "SnoozeService.SnoozeTask")
+	defer span.End()
+
+	if (req.Until == nil) == (req.Preset == "") {
+		return nil, ErrInvalidSnooze
+	}
+	now := s.now()
+	until := req.Until
+	if req.Preset != "" {
+		loc, err := s.location(ctx, userID, req.TimeZone)
+		if err != nil {
+			return nil, err
+		}
+		t, err := resolveSnoozePreset(req.Preset, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		until = &t
+	}
+	if !until.After(now) {
+		return nil, ErrSnoozeInPast
+	}
+	if until.Sub(now) > maxSnoozeDuration {
+		return nil, ErrSnoozeTooLong
+	}
+
+	task, err := s.repo.SnoozeTask(ctx, taskID, int(userID), until)
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	logging.ContextLogger(ctx).Info("Task snoozed", "event", "task_snoozed", "taskID", taskID, "until", until, "userID", userID)
+	return task, nil
+}
+
+func (s *SnoozeService) UnsnoozeTask(ctx context.Context, userID uint, taskID int) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "SnoozeService.UnsnoozeTask")
+	defer span.End()
+
+	task, err := s.repo.SnoozeTask(ctx, taskID, int(userID), nil)
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	logging.ContextLogger(ctx).Info("Task unsnoozed", "event", "task_unsnoozed", "taskID", taskID, "userID", userID)
+	return task, nil
+}
+
+// location returns the time zone named by timeZone, or the user's time zone
+// if it is empty. Users who never set one get UTC.
+func (s *SnoozeService) location(ctx context.Context, userID uint, timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		user, err := s.users.GetUserByID(ctx, int(userID))
+		if err != nil {
+			return nil, err
+		}
+		timeZone = user.TimeZone
+	}
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	return LoadTimeZone(timeZone)
+}
+
+// LoadTimeZone loads an IANA time zone such as "Europe/Berlin". It rejects
+// "Local", which would depend on the server's configuration.
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" || len(name) > 64 {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
+
+// resolveSnoozePreset returns the time preset names, seen from now in loc:
+//
+//   - later_today: three hours from now
+//   - this_evening: 18:00 today
+//   - tomorrow_morning: 09:00 tomorrow
+//   - this_weekend: 09:00 on the coming Saturday
+//   - next_week: 09:00 on the coming Monday
+//
+// The result may lie in the past, e.g. this_evening at night.
+func resolveSnoozePreset(preset string, now time.Time, loc *time.Location) (time.Time, error) {
+	local := now.In(loc)
+	at := func(days, hour int) time.Time {
+		return time.Date(local.Year(), local.Month(), local.Day()+days, hour, 0, 0, 0, loc)
+	}
+	switch preset {
+	case "later_today":
+		return now.Add(snoozeLaterToday), nil
+	case "this_evening":
+		return at(0, snoozeEveningHour), nil
+	case "tomorrow_morning":
+		return at(1, snoozeMorningHour), nil
+	case "this_weekend":
+		days := daysUntil(local.Weekday(), time.Saturday)
+		if t := at(days, snoozeMorningHour); t.After(now) {
+			return t, nil
+		}
+		return at(days+7, snoozeMorningHour), nil
+	case "next_week":
+		days := daysUntil(local.Weekday(), time.Monday)
+		if days == 0 {
+			days = 7
+		}
+		return at(days, snoozeMorningHour), nil
+	default:
+		return time.Time{}, ErrUnknownSnoozePreset
+	}
+}
+
+// daysUntil returns the number of days from the weekday from to the next
+// day, 0 if both are the same weekday.
+func daysUntil(from, day time.Weekday) int {
+	return (int(day) - int(from) + 7) % 7
+}
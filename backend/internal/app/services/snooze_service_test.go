@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+func newTestSnoozeService(repo *MockTaskRepository, users *MockAuthRepository, now time.Time) *SnoozeService {
+	service := NewSnoozeService(repo, users).(*SnoozeService)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestResolveSnoozePreset(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	// Wednesday, 22:30 in Berlin and already Thursday in UTC+9.
+	now := time.Date(2026, 3, 25, 22, 30, 0, 0, berlin)
+
+	tests := []struct {
+		preset string
+		loc    *time.Location
+		want   time.Time
+	}{
+		{"later_today", berlin, now.Add(3 * time.Hour)},
+		{"this_evening", berlin, time.Date(2026, 3, 25, 18, 0, 0, 0, berlin)},
+		{"tomorrow_morning", berlin, time.Date(2026, 3, 26, 9, 0, 0, 0, berlin)},
+		{"this_weekend", berlin, time.Date(2026, 3, 28, 9, 0, 0, 0, berlin)},
+		// Summer time starts on Sunday, 29 March.
+		{"next_week", berlin, time.Date(2026, 3, 30, 9, 0, 0, 0, berlin)},
+		{"tomorrow_morning", time.FixedZone("UTC+9", 9*3600), time.Date(2026, 3, 27, 9, 0, 0, 0, time.FixedZone("UTC+9", 9*3600))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			got, err := resolveSnoozePreset(tt.preset, now, tt.loc)
+
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+
+	_, err = resolveSnoozePreset("someday", now, berlin)
+	assert.ErrorIs(t, err, ErrUnknownSnoozePreset)
+}
+
+func TestResolveSnoozePreset_OnWeekend(t *testing.T) {
+	saturdayNoon := time.Date(2026, 3, 28, 12, 0, 0, 0, time.UTC)
+	mondayEarly := time.Date(2026, 3, 30, 6, 0, 0, 0, time.UTC)
+
+	weekend, _ := resolveSnoozePreset("this_weekend", saturdayNoon, time.UTC)
+	nextWeek, _ := resolveSnoozePreset("next_week", mondayEarly, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 4, 4, 9, 0, 0, 0, time.UTC), weekend)
+	assert.Equal(t, time.Date(2026, 4, 6, 9, 0, 0, 0, time.UTC), nextWeek)
+}
+
+func TestSnoozeService_SnoozeTask_PresetInUserTimeZone(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockUsers := new(MockAuthRepository)
+	now := time.Date(2026, 3, 25, 20, 0, 0, 0, time.UTC)
+	snoozeService := newTestSnoozeService(mockRepo, mockUsers, now)
+	ctx := context.Background()
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	want := time.Date(2026, 3, 27, 9, 0, 0, 0, tokyo)
+	mockUsers.On("GetUserByID", ctx, 1).Return(&models.User{ID: 1, TimeZone: "Asia/Tokyo"}, nil)
+	mockRepo.On("SnoozeTask", ctx, 5, 1, mock.MatchedBy(func(until *time.Time) bool {
+		return until.Equal(want)
+	})).Return(&models.Task{ID: 5, SnoozedUntil: &want}, nil)
+
+	task, err := snoozeService.SnoozeTask(ctx, 1, 5, models.SnoozeTaskRequest{Preset: "tomorrow_morning"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &want, task.SnoozedUntil)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSnoozeService_SnoozeTask_RequestTimeZone(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockUsers := new(MockAuthRepository)
+	now := time.Date(2026, 3, 25, 20, 0, 0, 0, time.UTC)
+	snoozeService := newTestSnoozeService(mockRepo, mockUsers, now)
+	ctx := context.Background()
+
+	// 09:00 PDT.
+	want := time.Date(2026, 3, 26, 16, 0, 0, 0, time.UTC)
+	mockRepo.On("SnoozeTask", ctx, 5, 1, mock.MatchedBy(func(until *time.Time) bool {
+		return until.Equal(want)
+	})).Return(&models.Task{ID: 5}, nil)
+
+	_, err := snoozeService.SnoozeTask(ctx, 1, 5, models.SnoozeTaskRequest{Preset: "tomorrow_morning", TimeZone: "America/Los_Angeles"})
+
+	assert.NoError(t, err)
+	mockUsers.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
+
+func TestSnoozeService_SnoozeTask_Invalid(t *testing.T) {
+	now := time.Date(2026, 3, 25, 20, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	tooLate := now.AddDate(2, 0, 0)
+
+	tests := []struct {
+		name string
+		req  models.SnoozeTaskRequest
+		want error
+	}{
+		{"neither", models.SnoozeTaskRequest{}, ErrInvalidSnooze},
+		{"both", models.SnoozeTaskRequest{Until: &tooLate, Preset: "next_week"}, ErrInvalidSnooze},
+		{"past", models.SnoozeTaskRequest{Until: &past}, ErrSnoozeInPast},
+		{"too long", models.SnoozeTaskRequest{Until: &tooLate}, ErrSnoozeTooLong},
+		{"evening has passed", models.SnoozeTaskRequest{Preset: "this_evening", TimeZone: "UTC"}, ErrSnoozeInPast},
+		{"unknown preset", models.SnoozeTaskRequest{Preset: "someday", TimeZone: "UTC"}, ErrUnknownSnoozePreset},
+		{"unknown time zone", models.SnoozeTaskRequest{Preset: "next_week", TimeZone: "Mars/Olympus"}, ErrInvalidTimeZone},
+		{"local time zone", models.SnoozeTaskRequest{Preset: "next_week", TimeZone: "Local"}, ErrInvalidTimeZone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			snoozeService := newTestSnoozeService(mockRepo, new(MockAuthRepository), now)
+
+			_, err := snoozeService.SnoozeTask(context.Background(), 1, 5, tt.req)
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "SnoozeTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestSnoozeService_UnsnoozeTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	snoozeService := NewSnoozeService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("SnoozeTask", ctx, 5, 2, (*time.Time)(nil)).Return(nil, repositories.ErrListRoleTooLow)
+
+	_, err := snoozeService.UnsnoozeTask(ctx, 2, 5)
+
+	assert.ErrorIs(t, err, ErrListPermissionDenied)
+}
+
+func TestSnoozeWaker_WakeDue(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	waker := NewSnoozeWaker(mockRepo, time.Minute)
+
+	mockRepo.On("WakeSnoozedTasks", mock.Anything).Return([]models.Task{{ID: 5}, {ID: 6}}, nil)
+
+	woken, err := waker.WakeDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, woken)
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+// DefaultSnoozeWakeInterval is how often the snooze waker looks for tasks
+// whose snooze has passed.
+const DefaultSnoozeWakeInterval = time.Minute
+
+// SnoozeWaker clears the snooze of tasks once it has passed. Snoozed tasks
+// show up in the task list as soon as their time comes even without it;
+// waking them records a task.updated event without an actor, so that
+// clients listening for changes and webhooks see them return. Every replica
+// of the backend runs one, and a task is only woken once.
+type SnoozeWaker struct {
+	repo     repositories.TaskRepository
+	interval time.Duration
+}
+
+func NewSnoozeWaker(repo repositories.TaskRepository, interval time.Duration) *SnoozeWaker {
+	return &SnoozeWaker{repo: repo, interval: interval}
+}
+
+// Run wakes snoozed tasks every interval until ctx is done.
+func (w *SnoozeWaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.WakeDue(ctx); err != nil && ctx.Err() == nil {
+			logging.ContextLogger(ctx).Error("Failed to wake snoozed tasks", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// WakeDue wakes every task whose snooze has passed and returns how many
+// were woken.
+func (w *SnoozeWaker) WakeDue(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "SnoozeWaker.WakeDue")
+	defer span.End()
+
+	tasks, err := w.repo.WakeSnoozedTasks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, task := range tasks {
+		logging.ContextLogger(ctx).Info("Snoozed task woke up", "event", "task_woken", "taskID", task.ID, "listID", task.ListID)
+	}
+	return len(tasks), nil
+}
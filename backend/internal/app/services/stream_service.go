@@ -0,0 +1,269 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+// streamListenRetry is how long the stream waits before listening again
+// after its connection failed.
+const streamListenRetry = 5 * time.Second
+
+var ErrTooManyStreams = errors.New("Too many open event streams")
+
+// StreamConfig tunes the event stream. Every replica keeps the last
+// ReplaySize events for clients that resume with Last-Event-ID. A client
+// that falls Buffer events behind is disconnected, and a user may keep
+// MaxPerUser streams open in one replica. Idle streams get a heartbeat
+// every Heartbeat.
+type StreamConfig struct {
+	ReplaySize int
+	Buffer     int
+	MaxPerUser int
+	Heartbeat  time.Duration
+}
+
+// DefaultStreamConfig replays up to 1000 events, lets clients fall 64
+// events behind, allows 10 streams per user and sends heartbeats every 15
+// seconds.
+func DefaultStreamConfig() StreamConfig {
+	return StreamConfig{
+		ReplaySize: 1000,
+		Buffer:     64,
+		MaxPerUser: 10,
+		Heartbeat:  15 * time.Second,
+	}
+}
+
+// StreamServiceInterface feeds the event streams of clients.
+type StreamServiceInterface interface {
+	// Subscribe opens a stream of the events of the lists userID is a
+	// member of, limited to the workspace of ctx if it has one. A
+	// lastEventID other than 0 replays the buffered events after it.
+	Subscribe(ctx context.Context, userID uint, lastEventID int64) (*StreamSubscription, error)
+	// Unsubscribe closes a stream opened by Subscribe.
+	Unsubscribe(sub *StreamSubscription)
+}
+
+// StreamSubscription is an open event stream.
+type StreamSubscription struct {
+	// Reset is set when the replay buffer no longer reaches back to the
+	// Last-Event-ID, so the client may have missed events and should
+	// reload its tasks.
+	Reset bool
+	// Replay holds the buffered events after the Last-Event-ID.
+	Replay []models.StreamEvent
+	// Events delivers new events. It is closed when the client falls too
+	// far behind or events may have been lost; the client should then
+	// reconnect with its Last-Event-ID.
+	Events <-chan models.StreamEvent
+
+	userID      int
+	workspaceID int
+	events      chan models.StreamEvent
+}
+
+// visible reports whether the subscriber may see event.
+func (sub *StreamSubscription) visible(event models.StreamEvent) bool {
+	return slices.Contains(event.UserIDs, sub.userID) && (sub.workspaceID == 0 || sub.workspaceID == event.WorkspaceID)
+}
+
+// StreamObserver sees every event the stream dispatches, whoever it is
+// visible to. Its methods are called with the stream locked and must not
+// block or call back into the stream.
+type StreamObserver interface {
+	ObserveEvent(event models.StreamEvent)
+	// ObserveReset is called when events may have been missed.
+	ObserveReset()
+}
+
+// StreamService fans relayed events out to the event streams of every
+// replica. As an EventSink it numbers and announces each event with
+// Postgres NOTIFY; Run listens for the announcements, reads the events
+// back from the outbox and hands them to the subscribers they are visible
+// to. Events are numbered in the order they are announced rather than
+// written, so a client that resumes after an event gets every event
+// announced after it, even one whose transaction started earlier.
+type StreamService struct {
+	repo   repositories.StreamRepository
+	config StreamConfig
+
+	mu        sync.Mutex
+	subs      map[*StreamSubscription]struct{}
+	observers []StreamObserver
+	// replay holds the last events, ordered by Seq.
+	replay []models.StreamEvent
+	// floor is the Seq up to which events may be missing from replay.
+	floor int64
+}
+
+// NewStreamService returns the concrete service, which is also the sink
+// that feeds it and must be Run.
+func NewStreamService(repo repositories.StreamRepository, config StreamConfig) *StreamService {
+	return &StreamService{
+		repo:   repo,
+		config: config,
+		subs:   make(map[*StreamSubscription]struct{}),
+		// Nothing can be replayed until the service listens.
+		floor: math.MaxInt64,
+	}
+}
+
+func (s *StreamService) Name() string { return "stream" }
+
+// Publish announces event to the streams of every replica.
+func (s *StreamService) Publish(ctx context.Context, event models.Event) error {
+	return s.repo.Notify(ctx, event.ID)
+}
+
+// Run listens for announced events until ctx is done.
+func (s *StreamService) Run(ctx context.Context) {
+	for {
+		err := s.repo.Listen(ctx, func() { s.resync(ctx) }, func(eventID string) { s.receive(ctx, eventID) })
+		if ctx.Err() != nil {
+			return
+		}
+		logging.ContextLogger(ctx).Error("Event stream stopped listening", "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamListenRetry):
+		}
+	}
+}
+
+// resync starts over after events may have been missed: replay is cut
+// back to the events after the last announced one, and every open
+// stream is closed so its client resumes and learns whether it missed
+// something.
+func (s *StreamService) resync(ctx context.Context) {
+	floor, err := s.repo.LatestSeq(ctx)
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to read the latest stream position", "error", err)
+		floor = math.MaxInt64
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.floor = floor
+	s.replay = slices.DeleteFunc(s.replay, func(e models.StreamEvent) bool { return e.Seq <= floor })
+	for sub := range s.subs {
+		s.drop(sub)
+	}
+	for _, o := range s.observers {
+		o.ObserveReset()
+	}
+}
+
+// Observe has o see every event dispatched from now on.
+func (s *StreamService) Observe(o StreamObserver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observers = append(s.observers, o)
+}
+
+func (s *StreamService) receive(ctx context.Context, eventID string) {
+	ctx, span := otel.Tracer("").Start(ctx, "StreamService.receive")
+	defer span.End()
+
+	event, err := s.repo.GetEvent(ctx, eventID)
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to read announced event", "eventID", eventID, "error", err)
+		return
+	}
+	s.dispatch(*event)
+}
+
+// dispatch buffers event for replay and sends it to the subscribers it is
+// visible to. Subscribers that are too far behind are dropped.
+func (s *StreamService) dispatch(event models.StreamEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event.Seq > s.floor {
+		i, found := slices.BinarySearchFunc(s.replay, event.Seq, func(e models.StreamEvent, seq int64) int { return cmp.Compare(e.Seq, seq) })
+		if found {
+			// The relay published the event again.
+			return
+		}
+		s.replay = slices.Insert(s.replay, i, event)
+		if len(s.replay) > s.config.ReplaySize {
+			s.floor = s.replay[0].Seq
+			s.replay = slices.Delete(s.replay, 0, 1)
+		}
+	}
+
+	for _, o := range s.observers {
+		o.ObserveEvent(event)
+	}
+	for sub := range s.subs {
+		if !sub.visible(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			s.drop(sub)
+		}
+	}
+}
+
+// drop closes sub. s.mu must be held.
+func (s *StreamService) drop(sub *StreamSubscription) {
+	delete(s.subs, sub)
+	close(sub.events)
+}
+
+func (s *StreamService) Subscribe(ctx context.Context, userID uint, lastEventID int64) (*StreamSubscription, error) {
+	_, span := otel.Tracer("").Start(ctx, "StreamService.Subscribe")
+	defer span.End()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open := 0
+	for sub := range s.subs {
+		if sub.userID == int(userID) {
+			open++
+		}
+	}
+	if open >= s.config.MaxPerUser {
+		return nil, ErrTooManyStreams
+	}
+
+	events := make(chan models.StreamEvent, s.config.Buffer)
+	sub := &StreamSubscription{Events: events, userID: int(userID), workspaceID: tenant.WorkspaceID(ctx), events: events}
+	if lastEventID > 0 {
+		if lastEventID < s.floor {
+			sub.Reset = true
+		} else {
+			for _, event := range s.replay {
+				if event.Seq > lastEventID && sub.visible(event) {
+					sub.Replay = append(sub.Replay, event)
+				}
+			}
+		}
+	}
+	s.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (s *StreamService) Unsubscribe(sub *StreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[sub]; ok {
+		s.drop(sub)
+	}
+}
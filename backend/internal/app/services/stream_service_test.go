@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+)
+
+// MockStreamRepository is a mock implementation of the StreamRepository interface
+type MockStreamRepository struct {
+	mock.Mock
+}
+
+var _ repositories.StreamRepository = (*MockStreamRepository)(nil)
+
+func (m *MockStreamRepository) Notify(ctx context.Context, eventID string) error {
+	args := m.Called(ctx, eventID)
+	return args.Error(0)
+}
+
+func (m *MockStreamRepository) Listen(ctx context.Context, ready func(), handle func(eventID string)) error {
+	args := m.Called(ctx, ready, handle)
+	return args.Error(0)
+}
+
+func (m *MockStreamRepository) GetEvent(ctx context.Context, eventID string) (*models.StreamEvent, error) {
+	args := m.Called(ctx, eventID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.StreamEvent), args.Error(1)
+}
+
+func (m *MockStreamRepository) LatestSeq(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// newListeningStream returns a stream service that listens and has seen
+// every event up to seq 10.
+func newListeningStream(config StreamConfig) (*StreamService, *MockStreamRepository) {
+	mockRepo := new(MockStreamRepository)
+	mockRepo.On("LatestSeq", mock.Anything).Return(int64(10), nil)
+	service := NewStreamService(mockRepo, config)
+	service.resync(context.Background())
+	return service, mockRepo
+}
+
+func streamEvent(seq int64, workspaceID int, userIDs ...int) models.StreamEvent {
+	return models.StreamEvent{Seq: seq, Event: models.Event{Type: models.EventTaskUpdated}, WorkspaceID: workspaceID, UserIDs: userIDs}
+}
+
+func received(sub *StreamSubscription) []int64 {
+	var seqs []int64
+	for {
+		select {
+		case event, ok := <-sub.Events:
+			if !ok {
+				return seqs
+			}
+			seqs = append(seqs, event.Seq)
+		default:
+			return seqs
+		}
+	}
+}
+
+func TestStreamService_Dispatch_OnlyToMembers(t *testing.T) {
+	service, _ := newListeningStream(DefaultStreamConfig())
+	ctx := context.Background()
+
+	all, err := service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+	scoped, err := service.Subscribe(tenant.WithWorkspace(ctx, 3), 1, 0)
+	assert.NoError(t, err)
+
+	service.dispatch(streamEvent(11, 3, 1, 2))
+	service.dispatch(streamEvent(12, 4, 1))
+	service.dispatch(streamEvent(13, 3, 2))
+
+	assert.Equal(t, []int64{11, 12}, received(all))
+	assert.Equal(t, []int64{11}, received(scoped))
+}
+
+func TestStreamService_Subscribe_Resume(t *testing.T) {
+	service, _ := newListeningStream(DefaultStreamConfig())
+	ctx := context.Background()
+
+	service.dispatch(streamEvent(13, 3, 1))
+	service.dispatch(streamEvent(11, 3, 1))
+	service.dispatch(streamEvent(12, 3, 2))
+	service.dispatch(streamEvent(11, 3, 1))
+
+	sub, err := service.Subscribe(ctx, 1, 10)
+	assert.NoError(t, err)
+	assert.False(t, sub.Reset)
+	if assert.Len(t, sub.Replay, 2) {
+		assert.Equal(t, int64(11), sub.Replay[0].Seq)
+		assert.Equal(t, int64(13), sub.Replay[1].Seq)
+	}
+
+	sub, err = service.Subscribe(ctx, 1, 11)
+	assert.NoError(t, err)
+	assert.Len(t, sub.Replay, 1)
+
+	// Events before the service listened may have been missed.
+	sub, err = service.Subscribe(ctx, 1, 9)
+	assert.NoError(t, err)
+	assert.True(t, sub.Reset)
+	assert.Empty(t, sub.Replay)
+}
+
+func TestStreamService_Subscribe_ResumeAfterLateCommit(t *testing.T) {
+	service, _ := newListeningStream(DefaultStreamConfig())
+	ctx := context.Background()
+
+	// Outbox event 10 committed after event 11 and was announced second,
+	// so it has the higher stream number.
+	first := streamEvent(11, 3, 1)
+	first.Event.ID = "outbox-11"
+	late := streamEvent(12, 3, 1)
+	late.Event.ID = "outbox-10"
+	live, err := service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+	service.dispatch(first)
+	assert.Equal(t, []int64{11}, received(live))
+	service.Unsubscribe(live)
+	service.dispatch(late)
+
+	sub, err := service.Subscribe(ctx, 1, 11)
+
+	assert.NoError(t, err)
+	assert.False(t, sub.Reset)
+	if assert.Len(t, sub.Replay, 1) {
+		assert.Equal(t, "outbox-10", sub.Replay[0].Event.ID)
+	}
+}
+
+func TestStreamService_Subscribe_ResumeBeyondBuffer(t *testing.T) {
+	config := DefaultStreamConfig()
+	config.ReplaySize = 2
+	service, _ := newListeningStream(config)
+	ctx := context.Background()
+
+	for seq := int64(11); seq <= 14; seq++ {
+		service.dispatch(streamEvent(seq, 3, 1))
+	}
+
+	sub, err := service.Subscribe(ctx, 1, 11)
+	assert.NoError(t, err)
+	assert.True(t, sub.Reset)
+
+	sub, err = service.Subscribe(ctx, 1, 12)
+	assert.NoError(t, err)
+	assert.False(t, sub.Reset)
+	assert.Len(t, sub.Replay, 2)
+}
+
+func TestStreamService_Subscribe_BeforeListening(t *testing.T) {
+	service := NewStreamService(new(MockStreamRepository), DefaultStreamConfig())
+
+	sub, err := service.Subscribe(context.Background(), 1, 42)
+
+	assert.NoError(t, err)
+	assert.True(t, sub.Reset)
+}
+
+func TestStreamService_Dispatch_DropsSlowSubscribers(t *testing.T) {
+	config := DefaultStreamConfig()
+	config.Buffer = 1
+	service, _ := newListeningStream(config)
+
+	sub, err := service.Subscribe(context.Background(), 1, 0)
+	assert.NoError(t, err)
+
+	service.dispatch(streamEvent(11, 3, 1))
+	service.dispatch(streamEvent(12, 3, 1))
+
+	assert.Equal(t, []int64{11}, received(sub))
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	// Unsubscribing a dropped subscriber is harmless.
+	service.Unsubscribe(sub)
+}
+
+func TestStreamService_Subscribe_TooMany(t *testing.T) {
+	config := DefaultStreamConfig()
+	config.MaxPerUser = 1
+	service, _ := newListeningStream(config)
+	ctx := context.Background()
+
+	sub, err := service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+	_, err = service.Subscribe(ctx, 1, 0)
+	assert.ErrorIs(t, err, ErrTooManyStreams)
+	_, err = service.Subscribe(ctx, 2, 0)
+	assert.NoError(t, err)
+
+	service.Unsubscribe(sub)
+	_, err = service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+}
+
+func TestStreamService_Resync_ClosesStreams(t *testing.T) {
+	service, mockRepo := newListeningStream(DefaultStreamConfig())
+	ctx := context.Background()
+
+	service.dispatch(streamEvent(11, 3, 1))
+	sub, err := service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+
+	mockRepo.ExpectedCalls = nil
+	mockRepo.On("LatestSeq", mock.Anything).Return(int64(20), nil)
+	service.resync(ctx)
+
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	sub, err = service.Subscribe(ctx, 1, 11)
+	assert.NoError(t, err)
+	assert.True(t, sub.Reset)
+}
+
+func TestStreamService_Receive(t *testing.T) {
+	service, mockRepo := newListeningStream(DefaultStreamConfig())
+	ctx := context.Background()
+
+	sub, err := service.Subscribe(ctx, 1, 0)
+	assert.NoError(t, err)
+	event := streamEvent(11, 3, 1)
+	mockRepo.On("GetEvent", mock.Anything, "evt").Return(&event, nil)
+	mockRepo.On("GetEvent", mock.Anything, "gone").Return(nil, repositories.ErrStreamEventNotFound)
+
+	service.receive(ctx, "evt")
+	service.receive(ctx, "gone")
+
+	assert.Equal(t, []int64{11}, received(sub))
+}
+
+func TestStreamService_Publish(t *testing.T) {
+	mockRepo := new(MockStreamRepository)
+	service := NewStreamService(mockRepo, DefaultStreamConfig())
+	mockRepo.On("Notify", mock.Anything, "evt").Return(nil)
+
+	err := service.Publish(context.Background(), models.Event{ID: "evt"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "stream", service.Name())
+	mockRepo.AssertExpectations(t)
+}
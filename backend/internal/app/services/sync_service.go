@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"go.opentelemetry.io/otel"
+)
+
+// maxSyncChanges is how many changes a client may push at once.
+const maxSyncChanges = 100
+
+var (
+	ErrInvalidSyncToken   = errors.New("Invalid sync token")
+	ErrTooManySyncChanges = fmt.Errorf("At most %d changes can be synced at once", maxSyncChanges)
+	ErrInvalidSyncChange  = errors.New("A change needs a client_id of at most 64 characters, an op of create, update or delete, a task_id unless it creates a task, and a title unless it updates one")
+	ErrSyncConflict       = errors.New("Task changed on the server since the last sync")
+)
+
+// syncSnapshotPattern matches the text form of a database snapshot, which
+// sync tokens encode: xmin:xmax:xip,xip,...
+var syncSnapshotPattern = regexp.MustCompile(`^[0-9]{1,20}:[0-9]{1,20}:([0-9]{1,20}(,[0-9]{1,20})*)?$`)
+
+// SyncServiceInterface syncs the tasks of offline clients. A client pulls
+// the changes since its last sync token and pushes the changes it made
+// offline along with that token. Fields with a timestamp are merged by it,
+// see models.TaskClock. Fields without one that the server changed since
+// the token keep the server's value.
+type SyncServiceInterface interface {
+	// Changes returns the changes to the user's tasks since the sync token
+	// since, or every task if it is empty.
+	Changes(ctx context.Context, userID uint, since string) (*models.SyncChanges, error)
+	// Apply applies the changes of req in order and reports the outcome of
+	// each. A change the user may not make is rejected; the others are
+	// still applied.
+	Apply(ctx context.Context, userID uint, req models.SyncRequest) (*models.SyncResponse, error)
+}
+
+type SyncService struct {
+	repo  repositories.SyncRepository
+	lists repositories.TaskListRepository
+}
+
+func NewSyncService(repo repositories.SyncRepository, lists repositories.TaskListRepository) SyncServiceInterface {
+	return &SyncService{repo: repo, lists: lists}
+}
+
+// decodeSyncToken returns the snapshot of token, or "" if it is empty.
+func decodeSyncToken(token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	snapshot, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !syncSnapshotPattern.Match(snapshot) {
+		return "", ErrInvalidSyncToken
+	}
+	return string(snapshot), nil
+}
+
+func (s *SyncService) Changes(ctx context.Context, userID uint, since string) (*models.SyncChanges, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncService.Changes")
+	defer span.End()
+
+	snapshot, err := decodeSyncToken(since)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := s.repo.Changes(ctx, int(userID), snapshot)
+	if err != nil {
+		return nil, err
+	}
+	changes.Token = base64.RawURLEncoding.EncodeToString([]byte(changes.Token))
+	return changes, nil
+}
+
+func (s *SyncService) Apply(ctx context.Context, userID uint, req models.SyncRequest) (*models.SyncResponse, error) {
+	_, span := otel.Tracer("").Start(ctx, "SyncService.Apply")
+	defer span.End()
+
+	snapshot, err := decodeSyncToken(req.Since)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Changes) > maxSyncChanges {
+		return nil, ErrTooManySyncChanges
+	}
+
+	resp := &models.SyncResponse{Results: make([]models.SyncResult, 0, len(req.Changes))}
+	for _, change := range req.Changes {
+		result, err := s.apply(ctx, int(userID), snapshot, change)
+		if err != nil {
+			return nil, err
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// apply applies change and returns its result. Errors that reject the
+// change become part of the result; others are returned.
+func (s *SyncService) apply(ctx context.Context, userID int, snapshot string, change models.SyncChange) (models.SyncResult, error) {
+	result := models.SyncResult{ClientID: change.ClientID, Status: models.SyncApplied}
+	if !validSyncChange(change) {
+		return syncRejection(result, ErrInvalidSyncChange)
+	}
+
+	switch change.Op {
+	case models.SyncCreate:
+		task := &models.Task{ListID: change.ListID, Title: *change.Title, CreatedBy: userID}
+		if change.Completed != nil {
+			task.Completed = *change.Completed
+		}
+		if task.ListID == 0 {
+			listID, err := s.lists.EnsurePersonalList(ctx, userID)
+			if errors.Is(err, repositories.ErrOutsideWorkspace) {
+				return syncRejection(result, ErrListRequired)
+			}
+			if err != nil {
+				return result, err
+			}
+			task.ListID = listID
+		}
+		if _, err := s.repo.CreateTask(ctx, task, change.ClientID); err != nil {
+			return syncRejection(result, err)
+		}
+		result.Task = task
+
+	case models.SyncUpdate:
+		task, kept, err := s.repo.UpdateTask(ctx, userID, snapshot, change)
+		if err != nil {
+			return syncRejection(result, err)
+		}
+		result.Task = task
+		if len(kept) > 0 {
+			result.Status = models.SyncPartial
+			if len(kept) == syncFieldCount(change) {
+				result.Status = models.SyncRejected
+			}
+			result.Reason = models.SyncConflict
+			result.Error = ErrSyncConflict.Error()
+			result.RejectedFields = kept
+		}
+
+	case models.SyncDelete:
+		task, err := s.repo.DeleteTask(ctx, userID, change.TaskID, snapshot)
+		if errors.Is(err, repositories.ErrTaskNotFound) {
+			// The task is gone already, possibly deleted by an earlier try
+			// of this batch.
+			return result, nil
+		}
+		if errors.Is(err, repositories.ErrSyncConflict) {
+			result.Task = task
+		}
+		if err != nil {
+			return syncRejection(result, err)
+		}
+	}
+	return result, nil
+}
+
+func validSyncChange(change models.SyncChange) bool {
+	if change.ClientID == "" || len(change.ClientID) > 64 {
+		return false
+	}
+	switch change.Op {
+	case models.SyncCreate:
+		return change.TaskID == 0 && change.Title != nil && *change.Title != ""
+	case models.SyncUpdate:
+		return change.TaskID > 0 && syncFieldCount(change) > 0 && (change.Title == nil || *change.Title != "")
+	case models.SyncDelete:
+		return change.TaskID > 0
+	default:
+		return false
+	}
+}
+
+// syncFieldCount returns how many fields change sets.
+func syncFieldCount(change models.SyncChange) int {
+	n := 0
+	if change.Title != nil {
+		n++
+	}
+	if change.Completed != nil {
+		n++
+	}
+	return n
+}
+
+// syncRejection rejects result because of err, or returns err if it is
+// not the client's fault.
+func syncRejection(result models.SyncResult, err error) (models.SyncResult, error) {
+	err = mapClockError(mapTaskListError(err))
+	switch {
+	case errors.Is(err, repositories.ErrSyncConflict):
+		result.Reason, err = models.SyncConflict, ErrSyncConflict
+	case errors.Is(err, repositories.ErrTaskNotFound), errors.Is(err, ErrTaskListNotFound):
+		result.Reason = models.SyncNotFound
+	case errors.Is(err, ErrListPermissionDenied):
+		result.Reason = models.SyncForbidden
+	case errors.Is(err, ErrInvalidSyncChange), errors.Is(err, ErrListRequired), errors.Is(err, ErrInvalidClock):
+		result.Reason = models.SyncInvalid
+	default:
+		return result, err
+	}
+	result.Status = models.SyncRejected
+	result.Error = err.Error()
+	return result, nil
+}
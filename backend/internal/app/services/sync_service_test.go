@@ -0,0 +1,259 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+)
+
+// MockSyncRepository is a mock implementation of the SyncRepository interface
+type MockSyncRepository struct {
+	mock.Mock
+}
+
+var _ repositories.SyncRepository = (*MockSyncRepository)(nil)
+
+func (m *MockSyncRepository) Changes(ctx context.Context, userID int, snapshot string) (*models.SyncChanges, error) {
+	args := m.Called(ctx, userID, snapshot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SyncChanges), args.Error(1)
+}
+
+func (m *MockSyncRepository) CreateTask(ctx context.Context, task *models.Task, clientID string) (bool, error) {
+	args := m.Called(ctx, task, clientID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSyncRepository) UpdateTask(ctx context.Context, userID int, snapshot string, change models.SyncChange) (*models.Task, []string, error) {
+	args := m.Called(ctx, userID, snapshot, change)
+	var task *models.Task
+	if args.Get(0) != nil {
+		task = args.Get(0).(*models.Task)
+	}
+	var kept []string
+	if args.Get(1) != nil {
+		kept = args.Get(1).([]string)
+	}
+	return task, kept, args.Error(2)
+}
+
+func (m *MockSyncRepository) DeleteTask(ctx context.Context, userID, taskID int, snapshot string) (*models.Task, error) {
+	args := m.Called(ctx, userID, taskID, snapshot)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+const testSnapshot = "100:104:101,103"
+
+var testSyncToken = base64.RawURLEncoding.EncodeToString([]byte(testSnapshot))
+
+func TestSyncService_Changes(t *testing.T) {
+	repo := new(MockSyncRepository)
+	service := NewSyncService(repo, new(MockTaskListRepository))
+
+	repo.On("Changes", mock.Anything, 1, testSnapshot).Return(&models.SyncChanges{Token: "110:110:", Deleted: []int{3}}, nil)
+
+	changes, err := service.Changes(context.Background(), 1, testSyncToken)
+
+	require.NoError(t, err)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString([]byte("110:110:")), changes.Token)
+	assert.Equal(t, []int{3}, changes.Deleted)
+}
+
+func TestSyncService_Changes_Full(t *testing.T) {
+	repo := new(MockSyncRepository)
+	service := NewSyncService(repo, new(MockTaskListRepository))
+
+	repo.On("Changes", mock.Anything, 1, "").Return(&models.SyncChanges{Token: "110:110:"}, nil)
+
+	_, err := service.Changes(context.Background(), 1, "")
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestSyncService_InvalidToken(t *testing.T) {
+	tokens := []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("100:104")),
+		base64.RawURLEncoding.EncodeToString([]byte("100:104:101,'x'")),
+	}
+	for _, token := range tokens {
+		service := NewSyncService(new(MockSyncRepository), new(MockTaskListRepository))
+
+		_, err := service.Changes(context.Background(), 1, token)
+		assert.ErrorIs(t, err, ErrInvalidSyncToken, token)
+		_, err = service.Apply(context.Background(), 1, models.SyncRequest{Since: token})
+		assert.ErrorIs(t, err, ErrInvalidSyncToken, token)
+	}
+}
+
+func TestSyncService_Apply(t *testing.T) {
+	repo := new(MockSyncRepository)
+	lists := new(MockTaskListRepository)
+	service := NewSyncService(repo, lists)
+	title, done := "Buy milk", true
+
+	lists.On("EnsurePersonalList", mock.Anything, 1).Return(7, nil)
+	repo.On("CreateTask", mock.Anything, &models.Task{ListID: 7, Title: title, CreatedBy: 1}, "c1").
+		Run(func(args mock.Arguments) { args.Get(1).(*models.Task).ID = 20 }).Return(true, nil)
+	update := models.SyncChange{ClientID: "c2", Op: models.SyncUpdate, TaskID: 4, Title: &title, Completed: &done}
+	repo.On("UpdateTask", mock.Anything, 1, testSnapshot, update).
+		Return(&models.Task{ID: 4, Title: "Theirs", Completed: true}, []string{models.SyncFieldTitle}, nil)
+	repo.On("DeleteTask", mock.Anything, 1, 5, testSnapshot).Return(nil, repositories.ErrTaskNotFound)
+	repo.On("DeleteTask", mock.Anything, 1, 6, testSnapshot).Return(&models.Task{ID: 6}, repositories.ErrSyncConflict)
+
+	resp, err := service.Apply(context.Background(), 1, models.SyncRequest{Since: testSyncToken, Changes: []models.SyncChange{
+		{ClientID: "c1", Op: models.SyncCreate, Title: &title},
+		update,
+		{ClientID: "c3", Op: models.SyncDelete, TaskID: 5},
+		{ClientID: "c4", Op: models.SyncDelete, TaskID: 6},
+		{ClientID: "c5", Op: "rename", TaskID: 6},
+	}})
+
+	require.NoError(t, err)
+	require.Len(t, resp.Results, 5)
+	assert.Equal(t, models.SyncResult{ClientID: "c1", Status: models.SyncApplied, Task: &models.Task{ID: 20, ListID: 7, Title: title, CreatedBy: 1}}, resp.Results[0])
+	assert.Equal(t, models.SyncResult{
+		ClientID:       "c2",
+		Status:         models.SyncPartial,
+		Reason:         models.SyncConflict,
+		Error:          ErrSyncConflict.Error(),
+		RejectedFields: []string{models.SyncFieldTitle},
+		Task:           &models.Task{ID: 4, Title: "Theirs", Completed: true},
+	}, resp.Results[1])
+	assert.Equal(t, models.SyncResult{ClientID: "c3", Status: models.SyncApplied}, resp.Results[2], "the task is gone already")
+	assert.Equal(t, models.SyncResult{
+		ClientID: "c4",
+		Status:   models.SyncRejected,
+		Reason:   models.SyncConflict,
+		Error:    ErrSyncConflict.Error(),
+		Task:     &models.Task{ID: 6},
+	}, resp.Results[3])
+	assert.Equal(t, models.SyncRejected, resp.Results[4].Status)
+	assert.Equal(t, models.SyncInvalid, resp.Results[4].Reason)
+}
+
+func TestSyncService_Apply_Rejections(t *testing.T) {
+	title := "Buy milk"
+	tests := []struct {
+		name   string
+		change models.SyncChange
+		setup  func(repo *MockSyncRepository, lists *MockTaskListRepository)
+		status models.SyncStatus
+		reason models.SyncReason
+	}{
+		{
+			name:   "every field conflicts",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("UpdateTask", mock.Anything, 1, testSnapshot, mock.Anything).Return(&models.Task{ID: 4}, []string{models.SyncFieldTitle}, nil)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncConflict,
+		},
+		{
+			name:   "no conflict",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("UpdateTask", mock.Anything, 1, testSnapshot, mock.Anything).Return(&models.Task{ID: 4, Title: title}, nil, nil)
+			},
+			status: models.SyncApplied,
+		},
+		{
+			name:   "viewer",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("UpdateTask", mock.Anything, 1, testSnapshot, mock.Anything).Return(nil, nil, repositories.ErrListRoleTooLow)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncForbidden,
+		},
+		{
+			name:   "deleted on the server",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("UpdateTask", mock.Anything, 1, testSnapshot, mock.Anything).Return(nil, nil, repositories.ErrTaskNotFound)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncNotFound,
+		},
+		{
+			name:   "clock far ahead",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("UpdateTask", mock.Anything, 1, testSnapshot, mock.Anything).Return(nil, nil, crdt.ErrClockDrift)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncInvalid,
+		},
+		{
+			name:   "list of someone else",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncCreate, ListID: 9, Title: &title},
+			setup: func(repo *MockSyncRepository, _ *MockTaskListRepository) {
+				repo.On("CreateTask", mock.Anything, mock.Anything, "c").Return(false, repositories.ErrTaskListNotFound)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncNotFound,
+		},
+		{
+			name:   "no list outside the personal workspace",
+			change: models.SyncChange{ClientID: "c", Op: models.SyncCreate, Title: &title},
+			setup: func(_ *MockSyncRepository, lists *MockTaskListRepository) {
+				lists.On("EnsurePersonalList", mock.Anything, 1).Return(0, repositories.ErrOutsideWorkspace)
+			},
+			status: models.SyncRejected,
+			reason: models.SyncInvalid,
+		},
+		{name: "no client id", change: models.SyncChange{Op: models.SyncDelete, TaskID: 4}, status: models.SyncRejected, reason: models.SyncInvalid},
+		{name: "create without title", change: models.SyncChange{ClientID: "c", Op: models.SyncCreate}, status: models.SyncRejected, reason: models.SyncInvalid},
+		{name: "update without fields", change: models.SyncChange{ClientID: "c", Op: models.SyncUpdate, TaskID: 4}, status: models.SyncRejected, reason: models.SyncInvalid},
+		{name: "delete without task", change: models.SyncChange{ClientID: "c", Op: models.SyncDelete}, status: models.SyncRejected, reason: models.SyncInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockSyncRepository)
+			lists := new(MockTaskListRepository)
+			if tt.setup != nil {
+				tt.setup(repo, lists)
+			}
+			service := NewSyncService(repo, lists)
+
+			resp, err := service.Apply(context.Background(), 1, models.SyncRequest{Since: testSyncToken, Changes: []models.SyncChange{tt.change}})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.Results[0].Status)
+			assert.Equal(t, tt.reason, resp.Results[0].Reason)
+		})
+	}
+}
+
+func TestSyncService_Apply_TooMany(t *testing.T) {
+	service := NewSyncService(new(MockSyncRepository), new(MockTaskListRepository))
+
+	_, err := service.Apply(context.Background(), 1, models.SyncRequest{Changes: make([]models.SyncChange, maxSyncChanges+1)})
+
+	assert.ErrorIs(t, err, ErrTooManySyncChanges)
+}
+
+func TestSyncService_Apply_Error(t *testing.T) {
+	repo := new(MockSyncRepository)
+	service := NewSyncService(repo, new(MockTaskListRepository))
+	repo.On("DeleteTask", mock.Anything, 1, 4, "").Return(nil, errors.New("db down"))
+
+	_, err := service.Apply(context.Background(), 1, models.SyncRequest{Changes: []models.SyncChange{{ClientID: "c", Op: models.SyncDelete, TaskID: 4}}})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+const maxListNameLength = 255
+
+var (
+	ErrTaskListNotFound     = errors.New("Task list not found")
+	ErrListPermissionDenied = errors.New("Your role in this list does not allow this action")
+	ErrInvalidListName      = errors.New("List name must be between 1 and 255 characters")
+	ErrInvalidListRole      = errors.New("Role must be viewer, editor or admin")
+	ErrListMemberNotFound   = errors.New("User is not a member of this list")
+	ErrAlreadyListMember    = errors.New("User is already a member of this list")
+	ErrListOwner            = errors.New("The owner's membership cannot be changed")
+	ErrPersonalList         = errors.New("Personal lists cannot be shared or deleted")
+)
+
+type TaskListServiceInterface interface {
+	ListLists(ctx context.Context, userID uint) ([]models.TaskList, error)
+	CreateList(ctx context.Context, userID uint, name string) (*models.TaskList, error)
+	GetList(ctx context.Context, userID uint, listID int) (*models.TaskList, error)
+	RenameList(ctx context.Context, userID uint, listID int, name string) (*models.TaskList, error)
+	DeleteList(ctx context.Context, userID uint, listID int) error
+	ListMembers(ctx context.Context, userID uint, listID int) ([]models.ListMember, error)
+	AddMember(ctx context.Context, userID uint, listID int, req models.AddMemberRequest) (*models.ListMember, error)
+	UpdateMember(ctx context.Context, userID uint, listID, memberID int, role models.ListRole) error
+	RemoveMember(ctx context.Context, userID uint, listID, memberID int) error
+}
+
+type TaskListService struct {
+	repo     repositories.TaskListRepository
+	authRepo repositories.AuthRepository
+}
+
+func NewTaskListService(repo repositories.TaskListRepository, authRepo repositories.AuthRepository) TaskListServiceInterface {
+	return &TaskListService{repo: repo, authRepo: authRepo}
+}
+
+func (s *TaskListService) requireRole(ctx context.Context, userID uint, listID int, min models.ListRole) error {
+	return requireListRole(ctx, s.repo, userID, listID, min)
+}
+
+// requireListRole returns ErrTaskListNotFound if userID is not a member of
+// listID and ErrListPermissionDenied if the member's role is below min.
+func requireListRole(ctx context.Context, repo repositories.TaskListRepository, userID uint, listID int, min models.ListRole) error {
+	role, err := repo.GetMemberRole(ctx, listID, int(userID))
+	if err != nil {
+		return mapTaskListError(err)
+	}
+	if !role.AtLeast(min) {
+		return ErrListPermissionDenied
+	}
+	return nil
+}
+
+// ListLists returns the user's lists in the workspace the request is scoped
+// to, or in all workspaces. The personal list is created on first use so
+// that it is always listed with the personal workspace.
+func (s *TaskListService) ListLists(ctx context.Context, userID uint) ([]models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.ListLists")
+	defer span.End()
+
+	_, err := s.repo.EnsurePersonalList(ctx, int(userID))
+	if err != nil && !errors.Is(err, repositories.ErrOutsideWorkspace) {
+		return nil, err
+	}
+	return s.repo.ListLists(ctx, int(userID))
+}
+
+// CreateList creates a list in the workspace the request is scoped to, or in
+// the user's personal workspace.
+func (s *TaskListService) CreateList(ctx context.Context, userID uint, name string) (*models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.CreateList")
+	defer span.End()
+
+	name, err := validListName(name)
+	if err != nil {
+		return nil, err
+	}
+	list := &models.TaskList{Name: name, OwnerID: int(userID), WorkspaceID: tenant.WorkspaceID(ctx)}
+	if err := s.repo.CreateList(ctx, list); err != nil {
+		return nil, mapTaskListError(err)
+	}
+	return list, nil
+}
+
+func (s *TaskListService) GetList(ctx context.Context, userID uint, listID int) (*models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.GetList")
+	defer span.End()
+
+	list, err := s.repo.GetList(ctx, listID, int(userID))
+	return list, mapTaskListError(err)
+}
+
+// RenameList renames a list. It requires the admin role.
+func (s *TaskListService) RenameList(ctx context.Context, userID uint, listID int, name string) (*models.TaskList, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.RenameList")
+	defer span.End()
+
+	name, err := validListName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRole(ctx, userID, listID, models.ListRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameList(ctx, listID, name); err != nil {
+		return nil, mapTaskListError(err)
+	}
+	list, err := s.repo.GetList(ctx, listID, int(userID))
+	return list, mapTaskListError(err)
+}
+
+// DeleteList deletes a list and its tasks. Only the owner may delete a list,
+// and personal lists cannot be deleted.
+func (s *TaskListService) DeleteList(ctx context.Context, userID uint, listID int) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.DeleteList")
+	defer span.End()
+
+	list, err := s.repo.GetList(ctx, listID, int(userID))
+	if err != nil {
+		return mapTaskListError(err)
+	}
+	if list.Role != models.ListRoleOwner {
+		return ErrListPermissionDenied
+	}
+	if list.Personal {
+		return ErrPersonalList
+	}
+	if err := s.repo.DeleteList(ctx, listID, int(userID)); err != nil {
+		return mapTaskListError(err)
+	}
+	logging.ContextLogger(ctx).Info("Task list deleted", "event", "task_list_deleted", "listID", listID, "userID", userID)
+	return nil
+}
+
+func (s *TaskListService) ListMembers(ctx context.Context, userID uint, listID int) ([]models.ListMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.ListMembers")
+	defer span.End()
+
+	if err := s.requireRole(ctx, userID, listID, models.ListRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, listID)
+}
+
+// AddMember adds the user identified by username or email to a list. It
+// requires the admin role; the owner role cannot be granted.
+func (s *TaskListService) AddMember(ctx context.Context, userID uint, listID int, req models.AddMemberRequest) (*models.ListMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.AddMember")
+	defer span.End()
+
+	if !assignableListRole(req.Role) {
+		return nil, ErrInvalidListRole
+	}
+	list, err := s.repo.GetList(ctx, listID, int(userID))
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	if !list.Role.AtLeast(models.ListRoleAdmin) {
+		return nil, ErrListPermissionDenied
+	}
+	if list.Personal {
+		return nil, ErrPersonalList
+	}
+
+	user, err := s.authRepo.GetUserByLogin(ctx, strings.TrimSpace(req.Login))
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.repo.AddMember(ctx, listID, user.ID, req.Role); err != nil {
+		return nil, mapTaskListError(err)
+	}
+	logging.ContextLogger(ctx).Info("List member added", "event", "list_member_added", "listID", listID, "memberID", user.ID, "role", req.Role, "userID", userID)
+	return &models.ListMember{UserID: user.ID, Username: user.Username, Role: req.Role}, nil
+}
+
+// UpdateMember changes a member's role. It requires the admin role.
+func (s *TaskListService) UpdateMember(ctx context.Context, userID uint, listID, memberID int, role models.ListRole) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.UpdateMember")
+	defer span.End()
+
+	if !assignableListRole(role) {
+		return ErrInvalidListRole
+	}
+	if err := s.requireRole(ctx, userID, listID, models.ListRoleAdmin); err != nil {
+		return err
+	}
+	if err := s.checkNotOwner(ctx, listID, memberID); err != nil {
+		return err
+	}
+	return mapTaskListError(s.repo.UpdateMemberRole(ctx, listID, memberID, role))
+}
+
+// RemoveMember removes a member from a list. Admins may remove anyone but
+// the owner; every other member may only leave.
+func (s *TaskListService) RemoveMember(ctx context.Context, userID uint, listID, memberID int) error {
+	_, span := otel.Tracer("").Start(ctx, "TaskListService.RemoveMember")
+	defer span.End()
+
+	if memberID != int(userID) {
+		if err := s.requireRole(ctx, userID, listID, models.ListRoleAdmin); err != nil {
+			return err
+		}
+	}
+	if err := s.checkNotOwner(ctx, listID, memberID); err != nil {
+		return err
+	}
+	return mapTaskListError(s.repo.RemoveMember(ctx, listID, memberID))
+}
+
+func (s *TaskListService) checkNotOwner(ctx context.Context, listID, memberID int) error {
+	role, err := s.repo.GetMemberRole(ctx, listID, memberID)
+	if errors.Is(err, repositories.ErrTaskListNotFound) {
+		return ErrListMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role == models.ListRoleOwner {
+		return ErrListOwner
+	}
+	return nil
+}
+
+func validListName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxListNameLength {
+		return "", ErrInvalidListName
+	}
+	return name, nil
+}
+
+// assignableListRole reports whether role may be granted to a member. There
+// is exactly one owner per list.
+func assignableListRole(role models.ListRole) bool {
+	return role.Valid() && role != models.ListRoleOwner
+}
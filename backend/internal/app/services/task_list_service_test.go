@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockTaskListRepository is a mock implementation of the TaskListRepository interface
+type MockTaskListRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaskListRepository) CreateList(ctx context.Context, list *models.TaskList) error {
+	args := m.Called(ctx, list)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepository) EnsurePersonalList(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTaskListRepository) ListLists(ctx context.Context, userID int) ([]models.TaskList, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListRepository) GetList(ctx context.Context, listID, userID int) (*models.TaskList, error) {
+	args := m.Called(ctx, listID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TaskList), args.Error(1)
+}
+
+func (m *MockTaskListRepository) RenameList(ctx context.Context, listID int, name string) error {
+	args := m.Called(ctx, listID, name)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepository) DeleteList(ctx context.Context, listID, actorID int) error {
+	args := m.Called(ctx, listID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepository) GetMemberRole(ctx context.Context, listID, userID int) (models.ListRole, error) {
+	args := m.Called(ctx, listID, userID)
+	return args.Get(0).(models.ListRole), args.Error(1)
+}
+
+func (m *MockTaskListRepository) ListMembers(ctx context.Context, listID int) ([]models.ListMember, error) {
+	args := m.Called(ctx, listID)
+	return args.Get(0).([]models.ListMember), args.Error(1)
+}
+
+func (m *MockTaskListRepository) AddMember(ctx context.Context, listID, userID int, role models.ListRole) error {
+	args := m.Called(ctx, listID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepository) UpdateMemberRole(ctx context.Context, listID, userID int, role models.ListRole) error {
+	args := m.Called(ctx, listID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockTaskListRepository) RemoveMember(ctx context.Context, listID, userID int) error {
+	args := m.Called(ctx, listID, userID)
+	return args.Error(0)
+}
+
+func TestListRole_AtLeast(t *testing.T) {
+	assert.True(t, models.ListRoleOwner.AtLeast(models.ListRoleAdmin))
+	assert.True(t, models.ListRoleEditor.AtLeast(models.ListRoleEditor))
+	assert.False(t, models.ListRoleViewer.AtLeast(models.ListRoleEditor))
+	assert.False(t, models.ListRole("guest").AtLeast(models.ListRoleViewer))
+	assert.Equal(t, []string{"editor", "admin", "owner"}, models.ListRolesAtLeast(models.ListRoleEditor))
+}
+
+func TestTaskListService_CreateList(t *testing.T) {
+	mockRepo := new(MockTaskListRepository)
+	service := NewTaskListService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("CreateList", ctx, &models.TaskList{Name: "Groceries", OwnerID: 1}).Return(nil)
+
+	list, err := service.CreateList(ctx, 1, "  Groceries ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Groceries", list.Name)
+
+	_, err = service.CreateList(ctx, 1, "   ")
+	assert.ErrorIs(t, err, ErrInvalidListName)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskListService_AddMember(t *testing.T) {
+	mockRepo := new(MockTaskListRepository)
+	mockAuth := new(MockAuthRepository)
+	service := NewTaskListService(mockRepo, mockAuth)
+	ctx := context.Background()
+
+	mockRepo.On("GetList", ctx, 3, 1).Return(&models.TaskList{ID: 3, Role: models.ListRoleOwner}, nil)
+	mockAuth.On("GetUserByLogin", ctx, "partner@example.com").Return(&models.User{ID: 2, Username: "partner"}, nil)
+	mockRepo.On("AddMember", ctx, 3, 2, models.ListRoleEditor).Return(nil)
+
+	member, err := service.AddMember(ctx, 1, 3, models.AddMemberRequest{Login: "partner@example.com", Role: models.ListRoleEditor})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "partner", member.Username)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskListService_AddMember_Rejections(t *testing.T) {
+	tests := []struct {
+		name string
+		list *models.TaskList
+		role models.ListRole
+		want error
+	}{
+		{"editor cannot share", &models.TaskList{ID: 3, Role: models.ListRoleEditor}, models.ListRoleViewer, ErrListPermissionDenied},
+		{"owner role cannot be granted", &models.TaskList{ID: 3, Role: models.ListRoleOwner}, models.ListRoleOwner, ErrInvalidListRole},
+		{"unknown role", &models.TaskList{ID: 3, Role: models.ListRoleOwner}, "guest", ErrInvalidListRole},
+		{"personal list", &models.TaskList{ID: 3, Role: models.ListRoleOwner, Personal: true}, models.ListRoleViewer, ErrPersonalList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskListRepository)
+			service := NewTaskListService(mockRepo, new(MockAuthRepository))
+			ctx := context.Background()
+			mockRepo.On("GetList", ctx, 3, 1).Return(tt.list, nil)
+
+			_, err := service.AddMember(ctx, 1, 3, models.AddMemberRequest{Login: "partner", Role: tt.role})
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTaskListService_UpdateMember_Owner(t *testing.T) {
+	mockRepo := new(MockTaskListRepository)
+	service := NewTaskListService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.ListRoleAdmin, nil)
+	mockRepo.On("GetMemberRole", ctx, 3, 1).Return(models.ListRoleOwner, nil)
+
+	err := service.UpdateMember(ctx, 2, 3, 1, models.ListRoleViewer)
+
+	assert.ErrorIs(t, err, ErrListOwner)
+	mockRepo.AssertNotCalled(t, "UpdateMemberRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTaskListService_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("member leaves", func(t *testing.T) {
+		mockRepo := new(MockTaskListRepository)
+		service := NewTaskListService(mockRepo, new(MockAuthRepository))
+		mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.ListRoleViewer, nil)
+		mockRepo.On("RemoveMember", ctx, 3, 2).Return(nil)
+
+		assert.NoError(t, service.RemoveMember(ctx, 2, 3, 2))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("editor cannot remove others", func(t *testing.T) {
+		mockRepo := new(MockTaskListRepository)
+		service := NewTaskListService(mockRepo, new(MockAuthRepository))
+		mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.ListRoleEditor, nil)
+
+		assert.ErrorIs(t, service.RemoveMember(ctx, 2, 3, 4), ErrListPermissionDenied)
+	})
+
+	t.Run("not a member", func(t *testing.T) {
+		mockRepo := new(MockTaskListRepository)
+		service := NewTaskListService(mockRepo, new(MockAuthRepository))
+		mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.ListRole(""), repositories.ErrTaskListNotFound)
+
+		assert.ErrorIs(t, service.RemoveMember(ctx, 2, 3, 4), ErrTaskListNotFound)
+	})
+}
+
+func TestTaskListService_DeleteList(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		list *models.TaskList
+		want error
+	}{
+		{"admin cannot delete", &models.TaskList{ID: 3, Role: models.ListRoleAdmin}, ErrListPermissionDenied},
+		{"personal list", &models.TaskList{ID: 3, Role: models.ListRoleOwner, Personal: true}, ErrPersonalList},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskListRepository)
+			service := NewTaskListService(mockRepo, new(MockAuthRepository))
+			mockRepo.On("GetList", ctx, 3, 1).Return(tt.list, nil)
+
+			assert.ErrorIs(t, service.DeleteList(ctx, 1, 3), tt.want)
+			mockRepo.AssertNotCalled(t, "DeleteList", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -6,31 +6,62 @@ import (
 
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 	"go.opentelemetry.io/otel"
 )
 
+var (
+	ErrInvalidAssignee = errors.New("Assignee must be a member of the task's list")
+	ErrListRequired    = errors.New("list_id is required outside your personal workspace")
+	ErrInvalidClock    = errors.New("Clock timestamps must not be more than a minute ahead of the server's clock")
+	ErrInvalidPosition = errors.New("position must be lowercase letters or digits and must not end in 0")
+)
+
+// TaskServiceInterface manages tasks. The repository records the domain
+// events of every change in the outbox, see repositories.TaskRepository.
 type TaskServiceInterface interface {
-	GetTasks(ctx context.Context, userID uint) ([]models.Task, error)
+	// GetTasks returns the tasks of all lists of the user that match filter.
+	GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error)
+	// CreateTask adds task to task.ListID, or to the user's personal list if
+	// it is 0 and the request is not scoped to another workspace.
 	CreateTask(ctx context.Context, task *models.Task, userID uint) (*models.Task, error)
+	// UpdateTask merges the title and completion of task into the task,
+	// see models.TaskClock.
 	UpdateTask(ctx context.Context, task *models.Task, taskID uint, userID uint) error
 	DeleteTask(ctx context.Context, taskID uint, userID uint) error
+	// AssignTask assigns a task to a member of its list and notifies the
+	// assignee. It requires the editor role.
+	AssignTask(ctx context.Context, taskID uint, userID uint, assigneeID int) (*models.Task, error)
+	UnassignTask(ctx context.Context, taskID uint, userID uint) (*models.Task, error)
+	ListAssignments(ctx context.Context, taskID uint, userID uint) ([]models.TaskAssignment, error)
+	// MoveTask places a task in the user's manual order, unless it was
+	// moved later. It requires the viewer role.
+	MoveTask(ctx context.Context, taskID uint, userID uint, req models.MoveTaskRequest) (*models.Task, error)
 }
 
 type TaskService struct {
-	repo repositories.TaskRepository
+	repo     repositories.TaskRepository
+	lists    repositories.TaskListRepository
+	notifier Notifier
 }
 
-func NewTaskService(repo repositories.TaskRepository) TaskServiceInterface {
-	return &TaskService{repo: repo}
+func NewTaskService(repo repositories.TaskRepository, lists repositories.TaskListRepository, notifier Notifier) TaskServiceInterface {
+	return &TaskService{repo: repo, lists: lists, notifier: notifier}
 }
 
-func (s *TaskService) GetTasks(ctx context.Context, userID uint) ([]models.Task, error) {
+func (s *TaskService) GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error) {
 	_, span := otel.Tracer("").Start(ctx, "TaskService.GetTasks")
 	defer span.End()
 
 	utils.RandomSleep()
-	return s.repo.GetTasks(ctx, userID)
+	if filter.ListID != 0 {
+		if _, err := s.lists.GetMemberRole(ctx, filter.ListID, int(userID)); err != nil {
+			return nil, mapTaskListError(err)
+		}
+	}
+	return s.repo.GetTasks(ctx, userID, filter)
 }
 
 func (s *TaskService) CreateTask(ctx context.Context, task *models.Task, userID uint) (*models.Task, error) {
@@ -38,11 +69,21 @@ func (s *TaskService) CreateTask(ctx context.Context, task *models.Task, userID
 	defer span.End()
 
 	utils.RandomSleep()
-	task.UserID = int(userID)
+	if task.ListID == 0 {
+		listID, err := s.lists.EnsurePersonalList(ctx, int(userID))
+		if errors.Is(err, repositories.ErrOutsideWorkspace) {
+			return nil, ErrListRequired
+		}
+		if err != nil {
+			return nil, err
+		}
+		task.ListID = listID
+	}
+	task.CreatedBy = int(userID)
 	task.Completed = false
 
 	if err := s.repo.CreateTask(ctx, task); err != nil {
-		return nil, err
+		return nil, mapTaskListError(err)
 	}
 
 	return task, nil
@@ -54,27 +95,110 @@ func (s *TaskService) UpdateTask(ctx context.Context, task *models.Task, taskID
 
 	utils.RandomSleep()
 	task.ID = int(taskID)
-	task.UserID = int(userID)
-
-	// You might want to add logic here to check if the user is authorized to update the task
+	task.UpdatedBy = int(userID)
 
-	err := s.repo.UpdateTask(ctx, task)
-	if errors.Is(err, repositories.ErrTaskNotFound) {
-		return err
-	}
-	return nil
+	return mapClockError(mapTaskListError(s.repo.UpdateTask(ctx, task)))
 }
 
 func (s *TaskService) DeleteTask(ctx context.Context, taskID uint, userID uint) error {
 	_, span := otel.Tracer("").Start(ctx, "TaskService.DeleteTask")
 	defer span.End()
 
-	// You might want to add logic here to check if the user is authorized to delete the task
-
 	utils.RandomSleep()
-	err := s.repo.DeleteTask(ctx, taskID, userID)
-	if errors.Is(err, repositories.ErrTaskNotFound) {
+	return mapTaskListError(s.repo.DeleteTask(ctx, taskID, userID))
+}
+
+func (s *TaskService) AssignTask(ctx context.Context, taskID uint, userID uint, assigneeID int) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskService.AssignTask")
+	defer span.End()
+
+	if assigneeID <= 0 {
+		return nil, ErrInvalidAssignee
+	}
+	return s.assign(ctx, taskID, userID, assigneeID)
+}
+
+func (s *TaskService) UnassignTask(ctx context.Context, taskID uint, userID uint) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskService.UnassignTask")
+	defer span.End()
+
+	return s.assign(ctx, taskID, userID, 0)
+}
+
+func (s *TaskService) assign(ctx context.Context, taskID uint, userID uint, assigneeID int) (*models.Task, error) {
+	task, changed, err := s.repo.AssignTask(ctx, int(taskID), int(userID), assigneeID)
+	if errors.Is(err, repositories.ErrAssigneeNotMember) {
+		return nil, ErrInvalidAssignee
+	}
+	if err != nil {
+		return nil, mapTaskListError(err)
+	}
+	if !changed {
+		return task, nil
+	}
+
+	logging.ContextLogger(ctx).Info("Task assignee changed", "event", "task_assigned", "taskID", task.ID, "assigneeID", task.AssigneeID, "userID", userID)
+	// Users who assign a task to themselves need no notification.
+	if task.AssigneeID != 0 && task.AssigneeID != int(userID) {
+		err := s.notifier.Notify(ctx, models.Notification{
+			UserID:    task.AssigneeID,
+			Type:      models.NotificationAssigned,
+			ActorID:   int(userID),
+			TaskID:    task.ID,
+			TaskTitle: task.Title,
+		})
+		if err != nil {
+			logging.ContextLogger(ctx).Error("Failed to notify assignee", "taskID", task.ID, "assigneeID", task.AssigneeID, "error", err)
+		}
+	}
+	return task, nil
+}
+
+func (s *TaskService) ListAssignments(ctx context.Context, taskID uint, userID uint) ([]models.TaskAssignment, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskService.ListAssignments")
+	defer span.End()
+
+	assignments, err := s.repo.ListAssignments(ctx, int(taskID), int(userID))
+	return assignments, mapTaskListError(err)
+}
+
+func (s *TaskService) MoveTask(ctx context.Context, taskID uint, userID uint, req models.MoveTaskRequest) (*models.Task, error) {
+	_, span := otel.Tracer("").Start(ctx, "TaskService.MoveTask")
+	defer span.End()
+
+	if !crdt.ValidKey(req.Position) {
+		return nil, ErrInvalidPosition
+	}
+	task, err := s.repo.SetPosition(ctx, int(taskID), int(userID), crdt.Register[string]{Value: req.Position, Stamp: req.Clock})
+	if err != nil {
+		return nil, mapClockError(mapTaskListError(err))
+	}
+	return task, nil
+}
+
+// mapClockError translates crdt.ErrClockDrift into ErrInvalidClock.
+func mapClockError(err error) error {
+	if errors.Is(err, crdt.ErrClockDrift) {
+		return ErrInvalidClock
+	}
+	return err
+}
+
+// mapTaskListError translates repository errors about list access into
+// service errors. repositories.ErrTaskNotFound is passed through.
+func mapTaskListError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrTaskListNotFound):
+		return ErrTaskListNotFound
+	case errors.Is(err, repositories.ErrListRoleTooLow):
+		return ErrListPermissionDenied
+	case errors.Is(err, repositories.ErrNotListMember):
+		return ErrListMemberNotFound
+	case errors.Is(err, repositories.ErrAlreadyListMember):
+		return ErrAlreadyListMember
+	case errors.Is(err, repositories.ErrWorkspaceNotFound):
+		return ErrWorkspaceNotFound
+	default:
 		return err
 	}
-	return nil
 }
@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
 	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
 )
 
 // MockTaskRepository is a mock implementation of the TaskRepository interface
@@ -17,11 +19,23 @@ type MockTaskRepository struct {
 	mock.Mock
 }
 
-func (m *MockTaskRepository) GetTasks(ctx context.Context, userID uint) ([]models.Task, error) {
-	args := m.Called(ctx, userID)
+func (m *MockTaskRepository) GetTasks(ctx context.Context, userID uint, filter models.TaskFilter) ([]models.Task, error) {
+	args := m.Called(ctx, userID, filter)
 	return args.Get(0).([]models.Task), args.Error(1)
 }
 
+// EachTask passes the tasks of the first return value to fn.
+func (m *MockTaskRepository) EachTask(ctx context.Context, userID uint, filter models.TaskFilter, fn func(*models.Task) error) error {
+	args := m.Called(ctx, userID, filter)
+	tasks, _ := args.Get(0).([]models.Task)
+	for i := range tasks {
+		if err := fn(&tasks[i]); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
 func (m *MockTaskRepository) CreateTask(ctx context.Context, task *models.Task) error {
 	args := m.Called(ctx, task)
 	return args.Error(0)
@@ -37,17 +51,61 @@ func (m *MockTaskRepository) DeleteTask(ctx context.Context, taskID uint, userID
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) AssignTask(ctx context.Context, taskID, userID, assigneeID int) (*models.Task, bool, error) {
+	args := m.Called(ctx, taskID, userID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, false, args.Error(2)
+	}
+	return args.Get(0).(*models.Task), args.Bool(1), args.Error(2)
+}
+
+func (m *MockTaskRepository) ListAssignments(ctx context.Context, taskID, userID int) ([]models.TaskAssignment, error) {
+	args := m.Called(ctx, taskID, userID)
+	return args.Get(0).([]models.TaskAssignment), args.Error(1)
+}
+
+func (m *MockTaskRepository) SnoozeTask(ctx context.Context, taskID, userID int, until *time.Time) (*models.Task, error) {
+	args := m.Called(ctx, taskID, userID, until)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) WakeSnoozedTasks(ctx context.Context) ([]models.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) SetPosition(ctx context.Context, taskID, userID int, position crdt.Register[string]) (*models.Task, error) {
+	args := m.Called(ctx, taskID, userID, position)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Task), args.Error(1)
+}
+
+// MockNotifier is a mock implementation of the Notifier interface
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, notifications ...models.Notification) error {
+	args := m.Called(ctx, notifications)
+	return args.Error(0)
+}
+
 func TestTaskService_GetTasks(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
 
-	tasks := []models.Task{{ID: 1, UserID: int(userID), Title: "Test Task"}}
-	mockRepo.On("GetTasks", ctx, userID).Return(tasks, nil)
+	tasks := []models.Task{{ID: 1, ListID: 1, CreatedBy: int(userID), Title: "Test Task"}}
+	mockRepo.On("GetTasks", ctx, userID, models.TaskFilter{}).Return(tasks, nil)
 
-	result, err := taskService.GetTasks(ctx, userID)
+	result, err := taskService.GetTasks(ctx, userID, models.TaskFilter{})
 
 	assert.NoError(t, err)
 	assert.Equal(t, tasks, result)
@@ -56,26 +114,29 @@ func TestTaskService_GetTasks(t *testing.T) {
 
 func TestTaskService_CreateTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	mockLists := new(MockTaskListRepository)
+	taskService := NewTaskService(mockRepo, mockLists, new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
 	task := &models.Task{Title: "New Task"}
 
+	mockLists.On("EnsurePersonalList", ctx, 1).Return(7, nil)
 	mockRepo.On("CreateTask", ctx, mock.Anything).Return(nil)
 
 	createdTask, err := taskService.CreateTask(ctx, task, userID)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, createdTask)
-	assert.Equal(t, int(userID), createdTask.UserID)
+	assert.Equal(t, int(userID), createdTask.CreatedBy)
+	assert.Equal(t, 7, createdTask.ListID)
 	assert.Equal(t, "New Task", createdTask.Title)
 	mockRepo.AssertExpectations(t)
 }
 
 func TestTaskService_UpdateTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
@@ -92,7 +153,7 @@ func TestTaskService_UpdateTask(t *testing.T) {
 
 func TestTaskService_UpdateTask_NotFound(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
@@ -110,7 +171,7 @@ func TestTaskService_UpdateTask_NotFound(t *testing.T) {
 
 func TestTaskService_DeleteTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
@@ -126,7 +187,7 @@ func TestTaskService_DeleteTask(t *testing.T) {
 
 func TestTaskService_DeleteTask_NotFound(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
@@ -143,15 +204,210 @@ func TestTaskService_DeleteTask_NotFound(t *testing.T) {
 
 func TestTaskService_GetTasks_Error(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
 
 	ctx := context.Background()
 	userID := uint(1)
 
-	mockRepo.On("GetTasks", ctx, userID).Return([]models.Task{}, errors.New("some error"))
+	mockRepo.On("GetTasks", ctx, userID, models.TaskFilter{}).Return([]models.Task{}, errors.New("some error"))
 
-	_, err := taskService.GetTasks(ctx, userID)
+	_, err := taskService.GetTasks(ctx, userID, models.TaskFilter{})
 
 	assert.Error(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestTaskService_CreateTask_InSharedList(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockLists := new(MockTaskListRepository)
+	taskService := NewTaskService(mockRepo, mockLists, new(MockNotifier))
+	ctx := context.Background()
+
+	mockRepo.On("CreateTask", ctx, mock.Anything).Return(repositories.ErrListRoleTooLow)
+
+	_, err := taskService.CreateTask(ctx, &models.Task{ListID: 3, Title: "Milk"}, 2)
+
+	assert.ErrorIs(t, err, ErrListPermissionDenied)
+	mockLists.AssertNotCalled(t, "EnsurePersonalList", mock.Anything, mock.Anything)
+}
+
+func TestTaskService_UpdateTask_SetsModifier(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+	ctx := context.Background()
+
+	mockRepo.On("UpdateTask", ctx, &models.Task{ID: 4, Title: "Eggs", Completed: true, UpdatedBy: 2}).Return(nil)
+
+	err := taskService.UpdateTask(ctx, &models.Task{Title: "Eggs", Completed: true}, 4, 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskService_UpdateTask_Error(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+	ctx := context.Background()
+
+	mockRepo.On("UpdateTask", ctx, mock.Anything).Return(errors.New("connection reset"))
+
+	err := taskService.UpdateTask(ctx, &models.Task{Title: "Eggs"}, 4, 2)
+
+	assert.Error(t, err)
+}
+
+func TestTaskService_GetTasks_NotAMember(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockLists := new(MockTaskListRepository)
+	taskService := NewTaskService(mockRepo, mockLists, new(MockNotifier))
+	ctx := context.Background()
+
+	mockLists.On("GetMemberRole", ctx, 3, 2).Return(models.ListRole(""), repositories.ErrTaskListNotFound)
+
+	_, err := taskService.GetTasks(ctx, 2, models.TaskFilter{ListID: 3})
+
+	assert.ErrorIs(t, err, ErrTaskListNotFound)
+	mockRepo.AssertNotCalled(t, "GetTasks", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTaskService_AssignTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockNotifier := new(MockNotifier)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), mockNotifier)
+	ctx := context.Background()
+
+	task := &models.Task{ID: 4, ListID: 3, Title: "Eggs", AssigneeID: 5, AssignedBy: 2}
+	mockRepo.On("AssignTask", ctx, 4, 2, 5).Return(task, true, nil)
+	mockNotifier.On("Notify", ctx, []models.Notification{
+		{UserID: 5, Type: models.NotificationAssigned, ActorID: 2, TaskID: 4, TaskTitle: "Eggs"},
+	}).Return(nil)
+
+	result, err := taskService.AssignTask(ctx, 4, 2, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.AssigneeID)
+	mockNotifier.AssertExpectations(t)
+}
+
+func TestTaskService_AssignTask_NoNotification(t *testing.T) {
+	tests := []struct {
+		name     string
+		assignee int
+		changed  bool
+	}{
+		{"unchanged", 5, false},
+		{"self-assignment", 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			mockNotifier := new(MockNotifier)
+			taskService := NewTaskService(mockRepo, new(MockTaskListRepository), mockNotifier)
+			ctx := context.Background()
+
+			mockRepo.On("AssignTask", ctx, 4, 2, tt.assignee).Return(&models.Task{ID: 4, AssigneeID: tt.assignee}, tt.changed, nil)
+
+			_, err := taskService.AssignTask(ctx, 4, 2, tt.assignee)
+
+			assert.NoError(t, err)
+			mockNotifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTaskService_AssignTask_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		want    error
+	}{
+		{"assignee without access", repositories.ErrAssigneeNotMember, ErrInvalidAssignee},
+		{"viewer", repositories.ErrListRoleTooLow, ErrListPermissionDenied},
+		{"not a member", repositories.ErrTaskNotFound, repositories.ErrTaskNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+			ctx := context.Background()
+
+			mockRepo.On("AssignTask", ctx, 4, 2, 5).Return(nil, false, tt.repoErr)
+
+			_, err := taskService.AssignTask(ctx, 4, 2, 5)
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestTaskService_UnassignTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockNotifier := new(MockNotifier)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), mockNotifier)
+	ctx := context.Background()
+
+	mockRepo.On("AssignTask", ctx, 4, 2, 0).Return(&models.Task{ID: 4}, true, nil)
+
+	result, err := taskService.UnassignTask(ctx, 4, 2)
+
+	assert.NoError(t, err)
+	assert.Zero(t, result.AssigneeID)
+	mockNotifier.AssertNotCalled(t, "Notify", mock.Anything, mock.Anything)
+}
+
+func TestTaskService_CreateTask_OutsidePersonalWorkspace(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	mockLists := new(MockTaskListRepository)
+	taskService := NewTaskService(mockRepo, mockLists, new(MockNotifier))
+	ctx := context.Background()
+
+	mockLists.On("EnsurePersonalList", ctx, 1).Return(0, repositories.ErrOutsideWorkspace)
+
+	_, err := taskService.CreateTask(ctx, &models.Task{Title: "Milk"}, 1)
+
+	assert.ErrorIs(t, err, ErrListRequired)
+	mockRepo.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskService_UpdateTask_ClockDrift(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+	task := &models.Task{Title: "Offline", Clock: models.TaskClock{Title: crdt.Timestamp{Wall: time.Now().Add(time.Hour).UnixMilli(), Node: "phone"}}}
+
+	mockRepo.On("UpdateTask", mock.Anything, task).Return(crdt.ErrClockDrift)
+
+	err := taskService.UpdateTask(context.Background(), task, 3, 1)
+
+	assert.ErrorIs(t, err, ErrInvalidClock)
+}
+
+func TestTaskService_MoveTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+	stamp := crdt.Timestamp{Wall: 1_700_000_000_000, Node: "phone"}
+	position := crdt.Register[string]{Value: "k2phonei", Stamp: stamp}
+
+	mockRepo.On("SetPosition", mock.Anything, 3, 1, position).Return(&models.Task{ID: 3, Position: position.Value}, nil)
+
+	task, err := taskService.MoveTask(context.Background(), 3, 1, models.MoveTaskRequest{Position: "k2phonei", Clock: stamp})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "k2phonei", task.Position)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskService_MoveTask_Errors(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockTaskListRepository), new(MockNotifier))
+
+	_, err := taskService.MoveTask(context.Background(), 3, 1, models.MoveTaskRequest{Position: "k20"})
+	assert.ErrorIs(t, err, ErrInvalidPosition)
+
+	mockRepo.On("SetPosition", mock.Anything, 4, 1, mock.Anything).Return(nil, repositories.ErrTaskNotFound)
+	_, err = taskService.MoveTask(context.Background(), 4, 1, models.MoveTaskRequest{Position: "k2"})
+	assert.ErrorIs(t, err, repositories.ErrTaskNotFound)
+
+	mockRepo.On("SetPosition", mock.Anything, 5, 1, mock.Anything).Return(nil, crdt.ErrClockDrift)
+	_, err = taskService.MoveTask(context.Background(), 5, 1, models.MoveTaskRequest{Position: "k2"})
+	assert.ErrorIs(t, err, ErrInvalidClock)
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/ical"
+)
+
+// taskCSVHeader names the columns of exported CSV files. They match the
+// JSON fields of models.Task, with the clock flattened.
+var taskCSVHeader = []string{"id", "list_id", "title", "completed", "created_by", "created_at", "updated_by", "updated_at",
+	"assignee_id", "assigned_by", "assigned_at", "snoozed_until", "comment_count", "position",
+	"clock_title", "clock_completed", "clock_position"}
+
+// taskEncoder writes tasks one at a time. Close finishes the output and
+// must be called even if no task was written.
+type taskEncoder interface {
+	Encode(task *models.Task) error
+	Close() error
+}
+
+func newTaskEncoder(format string, w io.Writer) (taskEncoder, error) {
+	switch format {
+	case models.TransferJSON:
+		return &jsonTaskEncoder{w: w}, nil
+	case models.TransferCSV:
+		return &csvTaskEncoder{w: csv.NewWriter(w)}, nil
+	case models.TransferICS:
+		return icsTaskEncoder{ical.NewEncoder(w, calendarProdID, "Tasks")}, nil
+	default:
+		return nil, ErrInvalidTransferFormat
+	}
+}
+
+// jsonTaskEncoder writes a JSON array of tasks.
+type jsonTaskEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonTaskEncoder) Encode(task *models.Task) error {
+	sep := ",\n"
+	if !e.started {
+		sep, e.started = "[\n", true
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(e.w, sep+string(data))
+	return err
+}
+
+func (e *jsonTaskEncoder) Close() error {
+	end := "\n]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// csvTaskEncoder writes a CSV file with a header. Empty cells stand for
+// unset fields.
+type csvTaskEncoder struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (e *csvTaskEncoder) Encode(task *models.Task) error {
+	if !e.started {
+		e.started = true
+		if err := e.w.Write(taskCSVHeader); err != nil {
+			return err
+		}
+	}
+	return e.w.Write([]string{
+		strconv.Itoa(task.ID), strconv.Itoa(task.ListID), escapeCSVCell(task.Title), strconv.FormatBool(task.Completed),
+		csvInt(task.CreatedBy), csvTime(&task.CreatedAt), csvInt(task.UpdatedBy), csvTime(task.UpdatedAt),
+		csvInt(task.AssigneeID), csvInt(task.AssignedBy), csvTime(task.AssignedAt), csvTime(task.SnoozedUntil),
+		strconv.Itoa(task.CommentCount), task.Position,
+		task.Clock.Title.String(), task.Clock.Completed.String(), task.Clock.Position.String(),
+	})
+}
+
+func (e *csvTaskEncoder) Close() error {
+	if !e.started {
+		if err := e.w.Write(taskCSVHeader); err != nil {
+			return err
+		}
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func csvInt(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// calendarProdID identifies the app as the producer of iCalendar files.
+const calendarProdID = "-//todo-app//Tasks//EN"
+
+// icsTaskEncoder writes an iCalendar file with a VTODO per task.
+type icsTaskEncoder struct {
+	*ical.Encoder
+}
+
+func (e icsTaskEncoder) Encode(task *models.Task) error {
+	return e.Encoder.Encode(taskTodo(task))
+}
+
+// taskTodo maps task to a VTODO. Its UID stays the same as long as the
+// task exists. A snoozed task starts when it wakes up. Tasks record when
+// they were last changed rather than when they were completed, so
+// COMPLETED is left out.
+func taskTodo(task *models.Task) ical.Todo {
+	todo := ical.Todo{
+		UID:          fmt.Sprintf("task-%d@todo-app", task.ID),
+		Summary:      task.Title,
+		Status:       ical.StatusNeedsAction,
+		Created:      task.CreatedAt,
+		LastModified: task.CreatedAt,
+	}
+	if task.Completed {
+		todo.Status = ical.StatusCompleted
+	}
+	if task.UpdatedAt != nil {
+		todo.LastModified = *task.UpdatedAt
+	}
+	todo.Stamp = todo.LastModified
+	if task.SnoozedUntil != nil {
+		todo.Start = *task.SnoozedUntil
+	}
+	return todo
+}
+
+// escapeCSVCell prefixes text that spreadsheets would run as a formula with
+// a single quote. unescapeCSVCell undoes it.
+func escapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func unescapeCSVCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// importRecord is a row of an import file. Problem explains why the row
+// could not be read; the row is invalid then.
+type importRecord struct {
+	row     models.ImportRow
+	problem string
+}
+
+// decodeImport reads the rows of an import file in format. Only list_id,
+// title and completed are read; other fields, like those of an export, are
+// ignored. iCalendar files have no list_id. A file that cannot be read at
+// all is an ErrInvalidImportFile.
+func decodeImport(format string, r io.Reader) ([]importRecord, error) {
+	var records []importRecord
+	var err error
+	switch format {
+	case models.TransferJSON:
+		records, err = decodeJSONImport(r)
+	case models.TransferCSV:
+		records, err = decodeCSVImport(r)
+	case models.TransferICS:
+		records, err = decodeICSImport(r)
+	default:
+		return nil, ErrInvalidTransferFormat
+	}
+	if errors.Is(err, ErrImportTooLarge) {
+		return nil, ErrImportTooLarge
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+	return records, nil
+}
+
+func decodeJSONImport(r io.Reader) ([]importRecord, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, errors.New("expected an array of tasks")
+	}
+
+	var records []importRecord
+	for dec.More() {
+		if len(records) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		var fields struct {
+			ListID    int    `json:"list_id"`
+			Title     string `json:"title"`
+			Completed bool   `json:"completed"`
+		}
+		record := importRecord{row: models.ImportRow{Row: len(records) + 1}}
+		var typeErr *json.UnmarshalTypeError
+		err := json.Unmarshal(raw, &fields)
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			record.problem = fmt.Sprintf("%s has the wrong type", typeErr.Field)
+		case err != nil:
+			record.problem = "Each task must be an object"
+		case fields.ListID < 0:
+			record.problem = "list_id must be a positive integer"
+		default:
+			record.row.ListID, record.row.Title, record.row.Completed = fields.ListID, fields.Title, fields.Completed
+		}
+		records = append(records, record)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func decodeCSVImport(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("missing title column")
+	}
+	cell := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var records []importRecord
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+
+		record := importRecord{row: models.ImportRow{Row: len(records) + 1, Title: unescapeCSVCell(cell(fields, "title"))}}
+		if raw := cell(fields, "list_id"); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 31)
+			if err != nil {
+				record.problem = "list_id must be a positive integer"
+			}
+			record.row.ListID = int(id)
+		}
+		if raw := cell(fields, "completed"); raw != "" {
+			completed, err := strconv.ParseBool(raw)
+			if err != nil {
+				record.problem = "completed must be true or false"
+			}
+			record.row.Completed = completed
+		}
+		records = append(records, record)
+	}
+}
+
+// decodeICSImport reads the VTODOs of an iCalendar file. SUMMARY is the
+// title, and a to-do is completed if its STATUS is COMPLETED or it has a
+// COMPLETED time. Other components, such as events, are skipped.
+func decodeICSImport(r io.Reader) ([]importRecord, error) {
+	dec := ical.NewDecoder(r)
+	var records []importRecord
+	for {
+		todo, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		var valueErr *ical.ValueError
+		if err != nil && !errors.As(err, &valueErr) {
+			return nil, err
+		}
+		if len(records) == maxImportRows {
+			return nil, ErrImportTooLarge
+		}
+		record := importRecord{row: models.ImportRow{Row: len(records) + 1, Title: todo.Summary, Completed: todo.Done()}}
+		if valueErr != nil {
+			record.problem = fmt.Sprintf("%s has an invalid value", valueErr.Property)
+		}
+		records = append(records, record)
+	}
+}
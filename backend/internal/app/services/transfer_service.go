@@ -0,0 +1,263 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	// maxImportBytes and maxImportRows limit the size of an import file.
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10000
+	// maxImportIssues is how many rows an import preview lists.
+	maxImportIssues = 100
+	// importInlineRows is how many tasks an import creates before it
+	// responds; larger imports run in the background.
+	importInlineRows = 200
+)
+
+var (
+	ErrInvalidTransferFormat = errors.New("format must be json, csv or ics")
+	ErrInvalidImportFile     = errors.New("Invalid import file")
+	ErrImportTooLarge        = fmt.Errorf("Imports are limited to %d tasks and %d MB", maxImportRows, maxImportBytes>>20)
+	ErrInvalidImport         = errors.New("Some rows cannot be imported; nothing was imported")
+	ErrImportJobNotFound     = errors.New("Import job not found")
+)
+
+// TransferServiceInterface exports a user's tasks and imports tasks from
+// files in the same formats, see models.TransferJSON, TransferCSV and
+// TransferICS.
+type TransferServiceInterface interface {
+	// Export writes every task of the user's lists to w in format.
+	Export(ctx context.Context, userID uint, format string, w io.Writer) error
+	// Import reads tasks from r in format and previews the import. Rows
+	// without a list_id go to the user's personal list, and rows with the
+	// title of a task in their list, or of an earlier row, are skipped as
+	// duplicates. Unless dryRun is set the tasks are created in one
+	// transaction: small imports before Import returns, larger ones by a
+	// job that runs in the background. If a row is invalid, nothing is
+	// imported and the preview is returned with ErrInvalidImport.
+	Import(ctx context.Context, userID uint, format string, r io.Reader, dryRun bool) (*models.ImportResponse, error)
+	// GetImportJob reports the progress of an import the user started.
+	GetImportJob(ctx context.Context, userID uint, jobID int) (*models.ImportJob, error)
+}
+
+type TransferService struct {
+	tasks  repositories.TaskRepository
+	repo   repositories.ImportRepository
+	lists  repositories.TaskListRepository
+	runner *ImportRunner
+}
+
+func NewTransferService(tasks repositories.TaskRepository, repo repositories.ImportRepository, lists repositories.TaskListRepository,
+	runner *ImportRunner) TransferServiceInterface {
+	return &TransferService{tasks: tasks, repo: repo, lists: lists, runner: runner}
+}
+
+func (s *TransferService) Export(ctx context.Context, userID uint, format string, w io.Writer) error {
+	ctx, span := otel.Tracer("").Start(ctx, "TransferService.Export")
+	defer span.End()
+
+	enc, err := newTaskEncoder(format, w)
+	if err != nil {
+		return err
+	}
+	filter := models.TaskFilter{IncludeSnoozed: true}
+	if err := s.tasks.EachTask(ctx, userID, filter, enc.Encode); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// importLimitReader fails with ErrImportTooLarge once more than n bytes
+// were read.
+type importLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *importLimitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrImportTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrImportTooLarge
+	}
+	return n, err
+}
+
+func (s *TransferService) Import(ctx context.Context, userID uint, format string, r io.Reader, dryRun bool) (*models.ImportResponse, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "TransferService.Import")
+	defer span.End()
+
+	records, err := decodeImport(format, &importLimitReader{r: r, n: maxImportBytes})
+	if err != nil {
+		return nil, err
+	}
+	rows, preview, err := s.preview(ctx, userID, records)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.ImportResponse{Preview: *preview}
+	if preview.Invalid > 0 {
+		return resp, ErrInvalidImport
+	}
+	if dryRun || len(rows) == 0 {
+		return resp, nil
+	}
+
+	job := &models.ImportJob{UserID: int(userID), WorkspaceID: tenant.WorkspaceID(ctx), Rows: rows}
+	if err := s.repo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	resp.Job = job
+	if len(rows) > importInlineRows {
+		s.runner.Wake()
+		return resp, nil
+	}
+	finished, err := s.runner.RunJob(ctx, job.ID)
+	if err != nil || finished == nil {
+		// The job stays pending or running and the runner retries it.
+		logging.ContextLogger(ctx).Warn("Import did not finish inline", "jobID", job.ID, "error", err)
+		s.runner.Wake()
+		return resp, nil
+	}
+	resp.Job = finished
+	return resp, nil
+}
+
+// preview validates records, resolves their lists and finds duplicates. It
+// returns the rows to create along with the preview.
+func (s *TransferService) preview(ctx context.Context, userID uint, records []importRecord) ([]models.ImportRow, *models.ImportPreview, error) {
+	preview := &models.ImportPreview{Total: len(records), Issues: []models.ImportIssue{}}
+	issue := func(row int, kind models.ImportIssueKind, message string) {
+		if kind == models.ImportInvalid {
+			preview.Invalid++
+		} else {
+			preview.Duplicates++
+		}
+		if len(preview.Issues) < maxImportIssues {
+			preview.Issues = append(preview.Issues, models.ImportIssue{Row: row, Kind: kind, Error: message})
+		}
+	}
+
+	// listProblems caches why a list cannot be imported into, or "".
+	listProblems := make(map[int]string)
+	personalList := 0
+	var valid []models.ImportRow
+	var listIDs []int
+	for _, record := range records {
+		row := record.row
+		row.Title = strings.TrimSpace(row.Title)
+		if record.problem == "" && row.Title == "" {
+			record.problem = "title is required"
+		}
+		if record.problem == "" && row.ListID == 0 {
+			if personalList == 0 {
+				listID, err := s.lists.EnsurePersonalList(ctx, int(userID))
+				if errors.Is(err, repositories.ErrOutsideWorkspace) {
+					listID = -1
+				} else if err != nil {
+					return nil, nil, err
+				}
+				personalList = listID
+			}
+			if personalList < 0 {
+				record.problem = ErrListRequired.Error()
+			}
+			row.ListID = personalList
+		}
+		if record.problem == "" {
+			problem, ok := listProblems[row.ListID]
+			if !ok {
+				var err error
+				if problem, err = s.listProblem(ctx, row.ListID, userID); err != nil {
+					return nil, nil, err
+				}
+				listProblems[row.ListID] = problem
+				if problem == "" {
+					listIDs = append(listIDs, row.ListID)
+				}
+			}
+			record.problem = problem
+		}
+		if record.problem != "" {
+			issue(row.Row, models.ImportInvalid, record.problem)
+			continue
+		}
+		valid = append(valid, row)
+	}
+
+	titles := map[int][]string{}
+	if len(listIDs) > 0 {
+		var err error
+		if titles, err = s.repo.ListTitles(ctx, listIDs); err != nil {
+			return nil, nil, err
+		}
+	}
+	// firstRows maps the key of every title to the row it first appears
+	// in, or 0 for an existing task.
+	firstRows := make(map[string]int)
+	for listID, list := range titles {
+		for _, title := range list {
+			firstRows[models.ImportRow{ListID: listID, Title: title}.DuplicateKey()] = 0
+		}
+	}
+	var rows []models.ImportRow
+	for _, row := range valid {
+		key := row.DuplicateKey()
+		first, ok := firstRows[key]
+		switch {
+		case ok && first == 0:
+			issue(row.Row, models.ImportDuplicate, "A task with this title already exists in the list")
+		case ok:
+			issue(row.Row, models.ImportDuplicate, fmt.Sprintf("Duplicate of row %d", first))
+		default:
+			firstRows[key] = row.Row
+			rows = append(rows, row)
+		}
+	}
+	preview.Create = len(rows)
+	return rows, preview, nil
+}
+
+// listProblem explains why the user cannot import into listID, or returns
+// "" if they can.
+func (s *TransferService) listProblem(ctx context.Context, listID int, userID uint) (string, error) {
+	role, err := s.lists.GetMemberRole(ctx, listID, int(userID))
+	if errors.Is(err, repositories.ErrTaskListNotFound) {
+		return ErrTaskListNotFound.Error(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !role.AtLeast(models.ListRoleEditor) {
+		return ErrListPermissionDenied.Error(), nil
+	}
+	return "", nil
+}
+
+func (s *TransferService) GetImportJob(ctx context.Context, userID uint, jobID int) (*models.ImportJob, error) {
+	_, span := otel.Tracer("").Start(ctx, "TransferService.GetImportJob")
+	defer span.End()
+
+	job, err := s.repo.GetJob(ctx, jobID, int(userID))
+	if errors.Is(err, repositories.ErrImportJobNotFound) {
+		return nil, ErrImportJobNotFound
+	}
+	return job, err
+}
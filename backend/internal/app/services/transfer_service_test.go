@@ -0,0 +1,285 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/crdt"
+)
+
+type transferTestEnv struct {
+	tasks   *MockTaskRepository
+	imports *MockImportRepository
+	lists   *MockTaskListRepository
+	service TransferServiceInterface
+}
+
+func newTransferTestEnv() *transferTestEnv {
+	env := &transferTestEnv{tasks: new(MockTaskRepository), imports: new(MockImportRepository), lists: new(MockTaskListRepository)}
+	runner := NewImportRunner(env.imports, testImportRunnerConfig)
+	env.service = NewTransferService(env.tasks, env.imports, env.lists, runner)
+	return env
+}
+
+func exportTasks() []models.Task {
+	updatedAt := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	return []models.Task{
+		{ID: 1, ListID: 4, Title: "Milk, 2 litres", Completed: true, CreatedBy: 1,
+			CreatedAt: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC), UpdatedBy: 2, UpdatedAt: &updatedAt,
+			Clock: models.TaskClock{Title: crdt.Timestamp{Wall: 1, Node: "a"}}},
+		{ID: 2, ListID: 4, Title: "=HYPERLINK(\"x\")", CreatedBy: 1, CreatedAt: time.Date(2026, 3, 1, 8, 5, 0, 0, time.UTC)},
+	}
+}
+
+func TestTransferService_Export_JSON(t *testing.T) {
+	env := newTransferTestEnv()
+	env.tasks.On("EachTask", mock.Anything, uint(1), models.TaskFilter{IncludeSnoozed: true}).Return(exportTasks(), nil)
+
+	var out bytes.Buffer
+	err := env.service.Export(context.Background(), 1, models.TransferJSON, &out)
+
+	require.NoError(t, err)
+	records, err := decodeImport(models.TransferJSON, &out)
+	require.NoError(t, err)
+	assert.Equal(t, []importRecord{
+		{row: models.ImportRow{Row: 1, ListID: 4, Title: "Milk, 2 litres", Completed: true}},
+		{row: models.ImportRow{Row: 2, ListID: 4, Title: "=HYPERLINK(\"x\")"}},
+	}, records)
+}
+
+func TestTransferService_Export_Empty(t *testing.T) {
+	for format, want := range map[string]string{
+		models.TransferJSON: "[]\n",
+		models.TransferCSV:  strings.Join(taskCSVHeader, ",") + "\n",
+	} {
+		env := newTransferTestEnv()
+		env.tasks.On("EachTask", mock.Anything, uint(1), mock.Anything).Return([]models.Task(nil), nil)
+
+		var out bytes.Buffer
+		require.NoError(t, env.service.Export(context.Background(), 1, format, &out))
+		assert.Equal(t, want, out.String(), format)
+	}
+}
+
+func TestTransferService_Export_CSV(t *testing.T) {
+	env := newTransferTestEnv()
+	env.tasks.On("EachTask", mock.Anything, uint(1), mock.Anything).Return(exportTasks(), nil)
+
+	var out bytes.Buffer
+	err := env.service.Export(context.Background(), 1, models.TransferCSV, &out)
+
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, `1,4,"Milk, 2 litres",true,1,2026-03-01T08:00:00Z,2,2026-03-02T09:30:00Z,,,,,0,,`+
+		crdt.Timestamp{Wall: 1, Node: "a"}.String()+",,", lines[1])
+	// Formulas are quoted so that spreadsheets show them as text.
+	assert.Contains(t, lines[2], `"'=HYPERLINK(""x"")"`)
+
+	records, err := decodeImport(models.TransferCSV, &out)
+	require.NoError(t, err)
+	assert.Equal(t, "=HYPERLINK(\"x\")", records[1].row.Title)
+}
+
+func TestTransferService_Export_InvalidFormat(t *testing.T) {
+	env := newTransferTestEnv()
+
+	err := env.service.Export(context.Background(), 1, "xml", &bytes.Buffer{})
+
+	assert.ErrorIs(t, err, ErrInvalidTransferFormat)
+	env.tasks.AssertNotCalled(t, "EachTask", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestDecodeImport_CSV(t *testing.T) {
+	file := "\ufeffTitle,Completed,List_ID\n Milk ,TRUE,4\nEggs,,\nBread,maybe,4\nJam,false,-1\n"
+
+	records, err := decodeImport(models.TransferCSV, strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, []importRecord{
+		{row: models.ImportRow{Row: 1, ListID: 4, Title: "Milk", Completed: true}},
+		{row: models.ImportRow{Row: 2, Title: "Eggs"}},
+		{row: models.ImportRow{Row: 3, ListID: 4, Title: "Bread"}, problem: "completed must be true or false"},
+		{row: models.ImportRow{Row: 4, Title: "Jam"}, problem: "list_id must be a positive integer"},
+	}, records)
+}
+
+func TestDecodeImport_ICS(t *testing.T) {
+	file := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nSUMMARY:Meeting\r\nEND:VEVENT\r\n" +
+		"BEGIN:VTODO\r\nUID:a\r\nSUMMARY:Milk\\, eggs\r\nSTATUS:COMPLETED\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:b\r\nSUMMARY:Call Bob\r\nDTSTART:soon\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nUID:c\r\nSUMMARY:Jam\r\nCOMPLETED:20260301T080000Z\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+
+	records, err := decodeImport(models.TransferICS, strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, []importRecord{
+		{row: models.ImportRow{Row: 1, Title: "Milk, eggs", Completed: true}},
+		{row: models.ImportRow{Row: 2, Title: "Call Bob"}, problem: "DTSTART has an invalid value"},
+		{row: models.ImportRow{Row: 3, Title: "Jam", Completed: true}},
+	}, records)
+
+	_, err = decodeImport(models.TransferICS, strings.NewReader("BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\n"))
+	assert.ErrorIs(t, err, ErrInvalidImportFile)
+}
+
+func TestDecodeImport_JSONRowErrors(t *testing.T) {
+	file := `[{"title": "Milk"}, {"title": 5}, "Eggs", {"title": "Jam", "list_id": -2}]`
+
+	records, err := decodeImport(models.TransferJSON, strings.NewReader(file))
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "title has the wrong type", "Each task must be an object", "list_id must be a positive integer"},
+		[]string{records[0].problem, records[1].problem, records[2].problem, records[3].problem})
+}
+
+func TestDecodeImport_InvalidFiles(t *testing.T) {
+	tests := []struct{ format, file string }{
+		{models.TransferJSON, `{"title": "Milk"}`},
+		{models.TransferJSON, `[{"title": "Milk"}`},
+		{models.TransferCSV, ""},
+		{models.TransferCSV, "name,done\nMilk,true\n"},
+		{models.TransferCSV, "title\n\"Milk\n"},
+		{models.TransferICS, "title\nMilk\n"},
+	}
+	for _, tt := range tests {
+		_, err := decodeImport(tt.format, strings.NewReader(tt.file))
+		assert.ErrorIs(t, err, ErrInvalidImportFile, tt.file)
+	}
+}
+
+func TestDecodeImport_TooManyRows(t *testing.T) {
+	file := "title\n" + strings.Repeat("Milk\n", maxImportRows+1)
+
+	_, err := decodeImport(models.TransferCSV, strings.NewReader(file))
+
+	assert.ErrorIs(t, err, ErrImportTooLarge)
+}
+
+func TestTransferService_Import_TooLarge(t *testing.T) {
+	env := newTransferTestEnv()
+	file := "title\n" + strings.Repeat("x", maxImportBytes) + "\n"
+
+	_, err := env.service.Import(context.Background(), 1, models.TransferCSV, strings.NewReader(file), true)
+
+	assert.ErrorIs(t, err, ErrImportTooLarge)
+}
+
+func TestTransferService_Import_DryRun(t *testing.T) {
+	env := newTransferTestEnv()
+	env.lists.On("EnsurePersonalList", mock.Anything, 1).Return(7, nil)
+	env.lists.On("GetMemberRole", mock.Anything, 7, 1).Return(models.ListRoleOwner, nil)
+	env.lists.On("GetMemberRole", mock.Anything, 4, 1).Return(models.ListRoleEditor, nil)
+	env.imports.On("ListTitles", mock.Anything, []int{7, 4}).Return(map[int][]string{7: {"Milk"}}, nil)
+	file := `[{"title": " milk "}, {"title": "Eggs"}, {"title": "Eggs", "list_id": 4}, {"title": "EGGS"}]`
+
+	resp, err := env.service.Import(context.Background(), 1, models.TransferJSON, strings.NewReader(file), true)
+
+	require.NoError(t, err)
+	assert.Nil(t, resp.Job)
+	assert.Equal(t, models.ImportPreview{Total: 4, Create: 2, Duplicates: 2, Issues: []models.ImportIssue{
+		{Row: 1, Kind: models.ImportDuplicate, Error: "A task with this title already exists in the list"},
+		{Row: 4, Kind: models.ImportDuplicate, Error: "Duplicate of row 2"},
+	}}, resp.Preview)
+	env.imports.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+}
+
+func TestTransferService_Import_InvalidRows(t *testing.T) {
+	env := newTransferTestEnv()
+	env.lists.On("GetMemberRole", mock.Anything, 4, 1).Return(models.ListRoleViewer, nil)
+	env.lists.On("GetMemberRole", mock.Anything, 5, 1).Return(models.ListRole(""), repositories.ErrTaskListNotFound)
+	env.lists.On("GetMemberRole", mock.Anything, 6, 1).Return(models.ListRoleEditor, nil)
+	env.imports.On("ListTitles", mock.Anything, []int{6}).Return(map[int][]string{}, nil)
+	file := "title,list_id\nMilk,4\nEggs,5\n  ,6\nJam,6\nTea,4\n"
+
+	resp, err := env.service.Import(context.Background(), 1, models.TransferCSV, strings.NewReader(file), false)
+
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.Equal(t, models.ImportPreview{Total: 5, Create: 1, Invalid: 4, Issues: []models.ImportIssue{
+		{Row: 1, Kind: models.ImportInvalid, Error: ErrListPermissionDenied.Error()},
+		{Row: 2, Kind: models.ImportInvalid, Error: ErrTaskListNotFound.Error()},
+		{Row: 3, Kind: models.ImportInvalid, Error: "title is required"},
+		{Row: 5, Kind: models.ImportInvalid, Error: ErrListPermissionDenied.Error()},
+	}}, resp.Preview)
+	env.lists.AssertNumberOfCalls(t, "GetMemberRole", 3)
+	env.imports.AssertNotCalled(t, "CreateJob", mock.Anything, mock.Anything)
+}
+
+func TestTransferService_Import_OutsidePersonalWorkspace(t *testing.T) {
+	env := newTransferTestEnv()
+	env.lists.On("EnsurePersonalList", mock.Anything, 1).Return(0, repositories.ErrOutsideWorkspace)
+
+	resp, err := env.service.Import(context.Background(), 1, models.TransferCSV, strings.NewReader("title\nMilk\nEggs\n"), true)
+
+	assert.ErrorIs(t, err, ErrInvalidImport)
+	assert.Equal(t, 2, resp.Preview.Invalid)
+	assert.Equal(t, ErrListRequired.Error(), resp.Preview.Issues[0].Error)
+	env.lists.AssertNumberOfCalls(t, "EnsurePersonalList", 1)
+}
+
+func TestTransferService_Import_RunsSmallImportsInline(t *testing.T) {
+	env := newTransferTestEnv()
+	env.lists.On("EnsurePersonalList", mock.Anything, 1).Return(7, nil)
+	env.lists.On("GetMemberRole", mock.Anything, 7, 1).Return(models.ListRoleOwner, nil)
+	env.imports.On("ListTitles", mock.Anything, []int{7}).Return(map[int][]string{}, nil)
+	env.imports.On("CreateJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		job := args.Get(1).(*models.ImportJob)
+		job.ID, job.Status = 3, models.ImportPending
+	}).Return(nil)
+	claimed := &models.ImportJob{ID: 3, Status: models.ImportRunning, Attempts: 1}
+	completed := &models.ImportJob{ID: 3, Status: models.ImportCompleted, Total: 1, Processed: 1, Created: 1}
+	env.imports.On("ClaimJob", mock.Anything, mock.Anything, time.Minute, 3).Return(claimed, nil)
+	env.imports.On("ApplyJob", mock.Anything, claimed, mock.Anything, time.Minute).Return(completed, nil)
+
+	resp, err := env.service.Import(context.Background(), 1, models.TransferCSV, strings.NewReader("title,completed\nMilk,true\n"), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, completed, resp.Job)
+	env.imports.AssertCalled(t, "CreateJob", mock.Anything, mock.MatchedBy(func(job *models.ImportJob) bool {
+		return job.UserID == 1 && assert.ObjectsAreEqual([]models.ImportRow{{Row: 1, ListID: 7, Title: "Milk", Completed: true}}, job.Rows)
+	}))
+}
+
+func TestTransferService_Import_RunsLargeImportsInBackground(t *testing.T) {
+	env := newTransferTestEnv()
+	env.lists.On("EnsurePersonalList", mock.Anything, 1).Return(7, nil)
+	env.lists.On("GetMemberRole", mock.Anything, 7, 1).Return(models.ListRoleOwner, nil)
+	env.imports.On("ListTitles", mock.Anything, []int{7}).Return(map[int][]string{}, nil)
+	env.imports.On("CreateJob", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		job := args.Get(1).(*models.ImportJob)
+		job.ID, job.Status = 3, models.ImportPending
+	}).Return(nil)
+	var file strings.Builder
+	file.WriteString("title\n")
+	for i := range importInlineRows + 1 {
+		fmt.Fprintf(&file, "Task %d\n", i)
+	}
+
+	resp, err := env.service.Import(context.Background(), 1, models.TransferCSV, strings.NewReader(file.String()), false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, resp.Job.ID)
+	assert.Equal(t, models.ImportPending, resp.Job.Status)
+	assert.Equal(t, importInlineRows+1, resp.Preview.Create)
+	env.imports.AssertNotCalled(t, "ClaimJob", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferService_GetImportJob_NotFound(t *testing.T) {
+	env := newTransferTestEnv()
+	env.imports.On("GetJob", mock.Anything, 3, 1).Return(nil, repositories.ErrImportJobNotFound)
+
+	_, err := env.service.GetImportJob(context.Background(), 1, 3)
+
+	assert.ErrorIs(t, err, ErrImportJobNotFound)
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/webhook"
+	"go.opentelemetry.io/otel"
+)
+
+// maxWebhookResponseLength limits how much of a response body is kept in
+// the delivery log. Only responses of allowlisted hosts are kept, since
+// anyone who can add a webhook can read the log.
+const maxWebhookResponseLength = 1024
+
+// WebhookDispatcherConfig tunes the webhook dispatcher. Deliveries of a batch
+// are sent one after another, so Lease must be longer than BatchSize times
+// Timeout, or another replica may send the same deliveries again. The n-th
+// retry waits BaseBackoff * 2^(n-1), at most MaxBackoff; after MaxAttempts
+// failed attempts a delivery is dead. Webhooks cannot reach loopback,
+// private or other internal addresses unless their host is in AllowedHosts.
+type WebhookDispatcherConfig struct {
+	Interval     time.Duration
+	BatchSize    int
+	Lease        time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	AllowedHosts []string
+}
+
+// DefaultWebhookDispatcherConfig polls every five seconds, leases batches of
+// 20 deliveries for five minutes, and gives up after ten attempts, about
+// four and a quarter hours after the first.
+func DefaultWebhookDispatcherConfig() WebhookDispatcherConfig {
+	return WebhookDispatcherConfig{
+		Interval:    5 * time.Second,
+		BatchSize:   20,
+		Lease:       5 * time.Minute,
+		Timeout:     10 * time.Second,
+		MaxAttempts: 10,
+		BaseBackoff: 30 * time.Second,
+		MaxBackoff:  4 * time.Hour,
+	}
+}
+
+// WebhookDispatcher sends queued webhook deliveries. Every replica of the
+// backend runs one. Deliveries are leased with SELECT ... FOR UPDATE SKIP
+// LOCKED, so replicas never send the same delivery concurrently. Delivery is
+// at least once: if a replica dies while sending, the delivery is sent again
+// once its lease runs out, and receivers should ignore event IDs they have
+// already seen.
+type WebhookDispatcher struct {
+	repo    repositories.WebhookRepository
+	client  *http.Client
+	allowed webhook.Allowlist
+	config  WebhookDispatcherConfig
+	now     func() time.Time
+}
+
+func NewWebhookDispatcher(repo repositories.WebhookRepository, config WebhookDispatcherConfig) *WebhookDispatcher {
+	allowed := webhook.NewAllowlist(config.AllowedHosts)
+	client := &http.Client{
+		Transport: webhook.NewTransport(allowed),
+		Timeout:   config.Timeout,
+		// Redirects count as failures rather than sending the payload to
+		// another URL.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return &WebhookDispatcher{repo: repo, client: client, allowed: allowed, config: config, now: time.Now}
+}
+
+// Run sends due deliveries every interval until ctx is done.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DeliverDue(ctx); err != nil && ctx.Err() == nil {
+			logging.ContextLogger(ctx).Error("Failed to deliver webhooks", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue attempts every due delivery, batch by batch, and returns how
+// many attempts were recorded.
+func (d *WebhookDispatcher) DeliverDue(ctx context.Context) (int, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "WebhookDispatcher.DeliverDue")
+	defer span.End()
+
+	attempted := 0
+	for {
+		token, err := utils.GenerateSecureToken()
+		if err != nil {
+			return attempted, err
+		}
+		batch, err := d.repo.ClaimDueDeliveries(ctx, token, d.config.BatchSize, d.config.Lease)
+		if err != nil {
+			return attempted, err
+		}
+		for _, pending := range batch {
+			if _, err := d.attempt(ctx, pending, token); err == nil {
+				attempted++
+			}
+		}
+		if len(batch) < d.config.BatchSize {
+			return attempted, nil
+		}
+	}
+}
+
+// attempt sends a claimed delivery once and records the outcome.
+func (d *WebhookDispatcher) attempt(ctx context.Context, pending models.PendingDelivery, token string) (*models.WebhookDelivery, error) {
+	result := models.DeliveryAttempt{Status: models.DeliverySucceeded}
+	statusCode, response, err := d.send(ctx, pending)
+	result.StatusCode = statusCode
+	result.Response = response
+	if err != nil {
+		result.Error = err.Error()
+		attempts := pending.Attempts + 1
+		if attempts >= d.config.MaxAttempts {
+			result.Status = models.DeliveryDead
+		} else {
+			result.Status = models.DeliveryPending
+			next := d.now().Add(retryBackoff(attempts, d.config.BaseBackoff, d.config.MaxBackoff))
+			result.NextAttemptAt = &next
+		}
+	}
+
+	delivery, err := d.repo.RecordAttempt(ctx, pending.ID, token, result)
+	if errors.Is(err, repositories.ErrDeliveryLeaseLost) {
+		logging.ContextLogger(ctx).Warn("Webhook delivery lease lost before the attempt was recorded", "deliveryID", pending.ID)
+		return nil, err
+	}
+	if err != nil {
+		logging.ContextLogger(ctx).Error("Failed to record webhook delivery attempt", "deliveryID", pending.ID, "error", err)
+		return nil, err
+	}
+	logging.ContextLogger(ctx).Info("Webhook delivery attempted", "event", "webhook_attempted", "deliveryID", pending.ID,
+		"webhookID", pending.WebhookID, "eventType", pending.EventType, "status", delivery.Status, "statusCode", statusCode)
+	return delivery, nil
+}
+
+// send posts the payload of pending, signed with the webhook's secret. It
+// returns the response status and, for allowlisted hosts, the start of the
+// response body. Any status other than 2xx is an error.
+func (d *WebhookDispatcher) send(ctx context.Context, pending models.PendingDelivery) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pending.URL, bytes.NewReader(pending.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := d.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-webhooks/1.0")
+	req.Header.Set(webhook.EventHeader, string(pending.EventType))
+	req.Header.Set(webhook.DeliveryHeader, strconv.Itoa(pending.ID))
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(pending.Secret, timestamp, pending.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	var response string
+	if d.allowed.Contains(req.URL.Hostname()) {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseLength))
+		if response = string(body); !utf8.ValidString(response) {
+			response = ""
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, response, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, response, nil
+}
+
+// retryBackoff returns how long to wait before the retry that follows the
+// given number of failed attempts.
+func retryBackoff(attempts int, base, max time.Duration) time.Duration {
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return min(backoff, max)
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/webhook"
+)
+
+// MockWebhookRepository is a mock implementation of the WebhookRepository interface
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) CreateWebhook(ctx context.Context, w *models.Webhook) error {
+	args := m.Called(ctx, w)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) ListWebhooks(ctx context.Context, workspaceID int) ([]models.Webhook, error) {
+	args := m.Called(ctx, workspaceID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetWebhook(ctx context.Context, workspaceID, webhookID int) (*models.Webhook, error) {
+	args := m.Called(ctx, workspaceID, webhookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateWebhook(ctx context.Context, w *models.Webhook) error {
+	args := m.Called(ctx, w)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) DeleteWebhook(ctx context.Context, workspaceID, webhookID int) error {
+	args := m.Called(ctx, workspaceID, webhookID)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte) (int, error) {
+	args := m.Called(ctx, event, payload)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWebhookRepository) CreateTestDelivery(ctx context.Context, webhookID int, event models.Event, payload []byte, token string, lease time.Duration) (*models.PendingDelivery, error) {
+	args := m.Called(ctx, webhookID, event, payload, token, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PendingDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ListDeliveries(ctx context.Context, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, query)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Redeliver(ctx context.Context, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID, deliveryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) ClaimDueDeliveries(ctx context.Context, token string, limit int, lease time.Duration) ([]models.PendingDelivery, error) {
+	args := m.Called(ctx, token, limit, lease)
+	return args.Get(0).([]models.PendingDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) RecordAttempt(ctx context.Context, deliveryID int, token string, attempt models.DeliveryAttempt) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, deliveryID, token, attempt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookDelivery), args.Error(1)
+}
+
+// recordAttempt expects RecordAttempt with an attempt that matches.
+func recordAttempt(repo *MockWebhookRepository, match func(models.DeliveryAttempt) bool) *mock.Call {
+	return repo.On("RecordAttempt", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(match)).
+		Return(&models.WebhookDelivery{}, nil)
+}
+
+func newTestDispatcher(repo *MockWebhookRepository, now time.Time) *WebhookDispatcher {
+	config := DefaultWebhookDispatcherConfig()
+	config.BatchSize = 2
+	config.MaxAttempts = 3
+	// httptest receivers listen on loopback.
+	config.AllowedHosts = []string{"127.0.0.1"}
+	dispatcher := NewWebhookDispatcher(repo, config)
+	dispatcher.now = func() time.Time { return now }
+	return dispatcher
+}
+
+func TestWebhookDispatcher_DeliverDue_SignsPayload(t *testing.T) {
+	now := time.Now()
+	payload := []byte(`{"id":"ev1","type":"task.completed"}`)
+	received := make(chan *http.Request, 1)
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.Write([]byte("ok"))
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, now)
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, dispatcher.config.Lease).Return([]models.PendingDelivery{{
+		ID: 9, WebhookID: 3, URL: receiver.URL, Secret: "s3cret", EventID: "ev1", EventType: models.EventTaskCompleted, Payload: payload,
+	}}, nil)
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliverySucceeded && a.StatusCode == http.StatusOK && a.Response == "ok" && a.NextAttemptAt == nil
+	})
+
+	attempted, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	r := <-received
+	assert.Equal(t, payload, body)
+	assert.Equal(t, "task.completed", r.Header.Get(webhook.EventHeader))
+	assert.Equal(t, "9", r.Header.Get(webhook.DeliveryHeader))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), r.Header.Get(webhook.TimestampHeader))
+	assert.NoError(t, webhook.Verify("s3cret", r.Header.Get(webhook.SignatureHeader), r.Header.Get(webhook.TimestampHeader),
+		body, now, 5*time.Minute))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue_RetriesWithBackoff(t *testing.T) {
+	now := time.Date(2026, 3, 25, 12, 0, 0, 0, time.UTC)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, now)
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 9, URL: receiver.URL, Attempts: 1},
+	}, nil)
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliveryPending && a.StatusCode == http.StatusServiceUnavailable &&
+			a.Error != "" && a.NextAttemptAt != nil && a.NextAttemptAt.Equal(now.Add(time.Minute))
+	})
+
+	_, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue_DeadAfterMaxAttempts(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, time.Now())
+	// Nothing listens on a closed server.
+	receiver := httptest.NewServer(http.NotFoundHandler())
+	receiver.Close()
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 9, URL: receiver.URL, Attempts: 2},
+	}, nil)
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliveryDead && a.StatusCode == 0 && a.Error != "" && a.NextAttemptAt == nil
+	})
+
+	_, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue_RedirectIsFailure(t *testing.T) {
+	receiver := httptest.NewServer(http.RedirectHandler("http://example.com/elsewhere", http.StatusFound))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, time.Now())
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 9, URL: receiver.URL},
+	}, nil)
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliveryPending && a.StatusCode == http.StatusFound
+	})
+
+	_, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue_FullBatchClaimsAgain(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, time.Now())
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 1, URL: receiver.URL}, {ID: 2, URL: receiver.URL},
+	}, nil).Once()
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 3, URL: receiver.URL},
+	}, nil).Once()
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool { return a.Status == models.DeliverySucceeded })
+
+	attempted, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempted)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookDispatcher_DeliverDue_LeaseLost(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	dispatcher := newTestDispatcher(mockRepo, time.Now())
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, 2, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 1, URL: receiver.URL},
+	}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, 1, mock.Anything, mock.Anything).Return(nil, repositories.ErrDeliveryLeaseLost)
+
+	attempted, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Zero(t, attempted)
+}
+
+func TestWebhookDispatcher_DeliverDue_RefusesInternalAddress(t *testing.T) {
+	received := false
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = true
+		w.Write([]byte("secret"))
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	config := DefaultWebhookDispatcherConfig()
+	dispatcher := NewWebhookDispatcher(mockRepo, config)
+	mockRepo.On("ClaimDueDeliveries", mock.Anything, mock.Anything, config.BatchSize, mock.Anything).Return([]models.PendingDelivery{
+		{ID: 9, URL: receiver.URL},
+	}, nil)
+	recordAttempt(mockRepo, func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliveryPending && a.StatusCode == 0 && a.Response == "" &&
+			strings.Contains(a.Error, webhook.ErrForbiddenAddress.Error())
+	})
+
+	_, err := dispatcher.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, received)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	base, max := 30*time.Second, 4*time.Hour
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{9, 128 * time.Minute},
+		{10, 4 * time.Hour},
+		{100, 4 * time.Hour},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, retryBackoff(tt.attempts, base, max), "attempts %d", tt.attempts)
+	}
+}
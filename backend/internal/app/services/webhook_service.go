@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	maxWebhookURLLength      = 2048
+	defaultDeliveryPageLimit = 50
+	maxDeliveryPageLimit     = 200
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("url must be an absolute http or https URL of at most 2048 characters")
+	ErrInvalidWebhookEvents    = errors.New("events must list at least one of task.created, task.updated, task.completed, task.deleted")
+	ErrInvalidDeliveryQuery    = errors.New("status must be pending, succeeded or dead; limit between 1 and 200; offset not negative")
+	ErrWebhookNotFound         = errors.New("Webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("Webhook delivery not found")
+	ErrWebhookDeliveryPending  = errors.New("The delivery is still pending")
+)
+
+// WebhookServiceInterface manages the webhooks of the workspace a request is
+// scoped to, or of the user's personal workspace. Every method requires the
+// admin role in that workspace.
+type WebhookServiceInterface interface {
+	ListWebhooks(ctx context.Context, userID uint) ([]models.Webhook, error)
+	// CreateWebhook registers a webhook and returns it with its signing
+	// secret. The secret is not returned again.
+	CreateWebhook(ctx context.Context, userID uint, req models.WebhookRequest) (*models.Webhook, error)
+	GetWebhook(ctx context.Context, userID uint, webhookID int) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, userID uint, webhookID int, req models.WebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID uint, webhookID int) error
+	// ListDeliveries returns the delivery log of a webhook, newest first.
+	ListDeliveries(ctx context.Context, userID uint, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error)
+	// Redeliver queues a succeeded or dead delivery again.
+	Redeliver(ctx context.Context, userID uint, webhookID, deliveryID int) (*models.WebhookDelivery, error)
+	// SendTestEvent sends a webhook.test event right away and returns the
+	// logged delivery. Failed test deliveries are retried like any other.
+	SendTestEvent(ctx context.Context, userID uint, webhookID int) (*models.WebhookDelivery, error)
+}
+
+type WebhookService struct {
+	repo       repositories.WebhookRepository
+	workspaces repositories.WorkspaceRepository
+	dispatcher *WebhookDispatcher
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, workspaces repositories.WorkspaceRepository, dispatcher *WebhookDispatcher) WebhookServiceInterface {
+	return &WebhookService{repo: repo, workspaces: workspaces, dispatcher: dispatcher}
+}
+
+// workspace returns the workspace whose webhooks the request manages and
+// checks that userID is an admin of it.
+func (s *WebhookService) workspace(ctx context.Context, userID uint) (int, error) {
+	workspaceID := tenant.WorkspaceID(ctx)
+	if workspaceID == 0 {
+		id, err := s.workspaces.EnsurePersonalWorkspace(ctx, int(userID))
+		if err != nil {
+			return 0, err
+		}
+		workspaceID = id
+	}
+	role, err := s.workspaces.GetMemberRole(ctx, workspaceID, int(userID))
+	if err != nil {
+		return 0, mapWorkspaceError(err)
+	}
+	if !role.AtLeast(models.WorkspaceRoleAdmin) {
+		return 0, ErrWorkspacePermissionDenied
+	}
+	return workspaceID, nil
+}
+
+// webhook returns webhookID if it belongs to the workspace of the request
+// and userID is an admin of it.
+func (s *WebhookService) webhook(ctx context.Context, userID uint, webhookID int) (*models.Webhook, error) {
+	workspaceID, err := s.workspace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := s.repo.GetWebhook(ctx, workspaceID, webhookID)
+	return webhook, mapWebhookError(err)
+}
+
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID uint) ([]models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.ListWebhooks")
+	defer span.End()
+
+	workspaceID, err := s.workspace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListWebhooks(ctx, workspaceID)
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID uint, req models.WebhookRequest) (*models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.CreateWebhook")
+	defer span.End()
+
+	webhook, err := newWebhook(req)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, err := s.workspace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	webhook.WorkspaceID = workspaceID
+	webhook.Secret = secret
+	webhook.CreatedBy = int(userID)
+	if err := s.repo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	logging.ContextLogger(ctx).Info("Webhook created", "event", "webhook_created", "webhookID", webhook.ID,
+		"workspaceID", workspaceID, "userID", userID)
+	return webhook, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, userID uint, webhookID int) (*models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.GetWebhook")
+	defer span.End()
+
+	return s.webhook(ctx, userID, webhookID)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, userID uint, webhookID int, req models.WebhookRequest) (*models.Webhook, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.UpdateWebhook")
+	defer span.End()
+
+	webhook, err := newWebhook(req)
+	if err != nil {
+		return nil, err
+	}
+	workspaceID, err := s.workspace(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = webhookID
+	webhook.WorkspaceID = workspaceID
+	if err := s.repo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, mapWebhookError(err)
+	}
+	return webhook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID uint, webhookID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.DeleteWebhook")
+	defer span.End()
+
+	workspaceID, err := s.workspace(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteWebhook(ctx, workspaceID, webhookID); err != nil {
+		return mapWebhookError(err)
+	}
+
+	logging.ContextLogger(ctx).Info("Webhook deleted", "event", "webhook_deleted", "webhookID", webhookID,
+		"workspaceID", workspaceID, "userID", userID)
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID uint, webhookID int, query models.WebhookDeliveryQuery) ([]models.WebhookDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.ListDeliveries")
+	defer span.End()
+
+	if query.Limit == 0 {
+		query.Limit = defaultDeliveryPageLimit
+	}
+	switch query.Status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryDead:
+	default:
+		return nil, ErrInvalidDeliveryQuery
+	}
+	if query.Limit < 0 || query.Limit > maxDeliveryPageLimit || query.Offset < 0 {
+		return nil, ErrInvalidDeliveryQuery
+	}
+	if _, err := s.webhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, webhookID, query)
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, userID uint, webhookID, deliveryID int) (*models.WebhookDelivery, error) {
+	_, span := otel.Tracer("").Start(ctx, "WebhookService.Redeliver")
+	defer span.End()
+
+	if _, err := s.webhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.repo.Redeliver(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, mapWebhookError(err)
+	}
+
+	logging.ContextLogger(ctx).Info("Webhook delivery queued again", "event", "webhook_redelivered", "webhookID", webhookID,
+		"deliveryID", deliveryID, "userID", userID)
+	return delivery, nil
+}
+
+func (s *WebhookService) SendTestEvent(ctx context.Context, userID uint, webhookID int) (*models.WebhookDelivery, error) {
+	ctx, span := otel.Tracer("").Start(ctx, "WebhookService.SendTestEvent")
+	defer span.End()
+
+	if _, err := s.webhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	id, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	event := models.Event{ID: id, Type: models.EventWebhookTest, OccurredAt: time.Now().UTC(), ActorID: int(userID)}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	token, err := utils.GenerateSecureToken()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.repo.CreateTestDelivery(ctx, webhookID, event, payload, token, s.dispatcher.config.Lease)
+	if err != nil {
+		return nil, mapWebhookError(err)
+	}
+	return s.dispatcher.attempt(ctx, *pending, token)
+}
+
+// newWebhook validates req and returns the webhook it describes.
+func newWebhook(req models.WebhookRequest) (*models.Webhook, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || len(req.URL) > maxWebhookURLLength || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+	if len(req.Events) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+	var events []models.EventType
+	for _, e := range req.Events {
+		if !e.Valid() {
+			return nil, ErrInvalidWebhookEvents
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+	active := req.Active == nil || *req.Active
+	return &models.Webhook{URL: req.URL, Events: events, Active: active}, nil
+}
+
+func mapWebhookError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrWebhookNotFound):
+		return ErrWebhookNotFound
+	case errors.Is(err, repositories.ErrWebhookDeliveryNotFound):
+		return ErrWebhookDeliveryNotFound
+	case errors.Is(err, repositories.ErrWebhookDeliveryPending):
+		return ErrWebhookDeliveryPending
+	default:
+		return err
+	}
+}
+
+// WebhookSink queues a delivery of every event for the webhooks that
+// subscribe to it. It is an EventSink; each webhook gets one delivery per
+// event even if the event is published again.
+type WebhookSink struct {
+	repo repositories.WebhookRepository
+}
+
+func NewWebhookSink(repo repositories.WebhookRepository) *WebhookSink {
+	return &WebhookSink{repo: repo}
+}
+
+func (s *WebhookSink) Name() string { return "webhooks" }
+
+func (s *WebhookSink) Publish(ctx context.Context, event models.Event) error {
+	ctx, span := otel.Tracer("").Start(ctx, "WebhookSink.Publish")
+	defer span.End()
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.EnqueueDeliveries(ctx, event, payload)
+	return err
+}
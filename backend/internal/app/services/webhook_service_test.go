@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/webhook"
+)
+
+func newTestWebhookService(repo *MockWebhookRepository, workspaces *MockWorkspaceRepository) WebhookServiceInterface {
+	config := DefaultWebhookDispatcherConfig()
+	config.AllowedHosts = []string{"127.0.0.1"}
+	return NewWebhookService(repo, workspaces, NewWebhookDispatcher(repo, config))
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := context.Background()
+
+	mockWorkspaces.On("EnsurePersonalWorkspace", ctx, 1).Return(4, nil)
+	mockWorkspaces.On("GetMemberRole", ctx, 4, 1).Return(models.WorkspaceRoleOwner, nil)
+	mockRepo.On("CreateWebhook", ctx, mock.MatchedBy(func(w *models.Webhook) bool {
+		return w.WorkspaceID == 4 && w.CreatedBy == 1 && w.Active && w.Secret != "" &&
+			assert.ObjectsAreEqual([]models.EventType{models.EventTaskCreated, models.EventTaskDeleted}, w.Events)
+	})).Return(nil)
+
+	created, err := webhookService.CreateWebhook(ctx, 1, models.WebhookRequest{
+		URL:    "https://hooks.example.com/todo",
+		Events: []models.EventType{models.EventTaskCreated, models.EventTaskDeleted, models.EventTaskCreated},
+	})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_CreateWebhook_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		req  models.WebhookRequest
+		want error
+	}{
+		{"relative url", models.WebhookRequest{URL: "/hooks", Events: []models.EventType{models.EventTaskCreated}}, ErrInvalidWebhookURL},
+		{"ftp url", models.WebhookRequest{URL: "ftp://example.com", Events: []models.EventType{models.EventTaskCreated}}, ErrInvalidWebhookURL},
+		{"no events", models.WebhookRequest{URL: "https://example.com", Events: []models.EventType{}}, ErrInvalidWebhookEvents},
+		{"unknown event", models.WebhookRequest{URL: "https://example.com", Events: []models.EventType{"task.archived"}}, ErrInvalidWebhookEvents},
+		{"test event", models.WebhookRequest{URL: "https://example.com", Events: []models.EventType{models.EventWebhookTest}}, ErrInvalidWebhookEvents},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWebhookRepository)
+			webhookService := newTestWebhookService(mockRepo, new(MockWorkspaceRepository))
+
+			_, err := webhookService.CreateWebhook(context.Background(), 1, tt.req)
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWebhookService_RequiresWorkspaceAdmin(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := tenant.WithWorkspace(context.Background(), 6)
+
+	mockWorkspaces.On("GetMemberRole", ctx, 6, 2).Return(models.WorkspaceRoleMember, nil)
+
+	_, err := webhookService.ListWebhooks(ctx, 2)
+
+	assert.ErrorIs(t, err, ErrWorkspacePermissionDenied)
+	mockWorkspaces.AssertNotCalled(t, "EnsurePersonalWorkspace", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "ListWebhooks", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_ListDeliveries(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := tenant.WithWorkspace(context.Background(), 6)
+
+	mockWorkspaces.On("GetMemberRole", ctx, 6, 1).Return(models.WorkspaceRoleAdmin, nil)
+	mockRepo.On("GetWebhook", ctx, 6, 3).Return(&models.Webhook{ID: 3, WorkspaceID: 6}, nil)
+	mockRepo.On("ListDeliveries", ctx, 3, models.WebhookDeliveryQuery{Status: models.DeliveryDead, Limit: 50}).
+		Return([]models.WebhookDelivery{{ID: 8, Status: models.DeliveryDead}}, nil)
+
+	deliveries, err := webhookService.ListDeliveries(ctx, 1, 3, models.WebhookDeliveryQuery{Status: models.DeliveryDead})
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+}
+
+func TestWebhookService_ListDeliveries_InvalidQuery(t *testing.T) {
+	for _, query := range []models.WebhookDeliveryQuery{{Status: "failed"}, {Limit: 201}, {Limit: -1}, {Offset: -1}} {
+		webhookService := newTestWebhookService(new(MockWebhookRepository), new(MockWorkspaceRepository))
+
+		_, err := webhookService.ListDeliveries(context.Background(), 1, 3, query)
+
+		assert.ErrorIs(t, err, ErrInvalidDeliveryQuery, "%+v", query)
+	}
+}
+
+func TestWebhookService_Redeliver_Pending(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := tenant.WithWorkspace(context.Background(), 6)
+
+	mockWorkspaces.On("GetMemberRole", ctx, 6, 1).Return(models.WorkspaceRoleAdmin, nil)
+	mockRepo.On("GetWebhook", ctx, 6, 3).Return(&models.Webhook{ID: 3, WorkspaceID: 6}, nil)
+	mockRepo.On("Redeliver", ctx, 3, 8).Return(nil, repositories.ErrWebhookDeliveryPending)
+
+	_, err := webhookService.Redeliver(ctx, 1, 3, 8)
+
+	assert.ErrorIs(t, err, ErrWebhookDeliveryPending)
+}
+
+func TestWebhookService_GetWebhook_OtherWorkspace(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := tenant.WithWorkspace(context.Background(), 6)
+
+	mockWorkspaces.On("GetMemberRole", ctx, 6, 1).Return(models.WorkspaceRoleAdmin, nil)
+	mockRepo.On("GetWebhook", ctx, 6, 3).Return(nil, repositories.ErrWebhookNotFound)
+
+	_, err := webhookService.GetWebhook(ctx, 1, 3)
+
+	assert.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+func TestWebhookService_SendTestEvent(t *testing.T) {
+	var event models.Event
+	var signatureErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &event)
+		signatureErr = webhook.Verify("s3cret", r.Header.Get(webhook.SignatureHeader), r.Header.Get(webhook.TimestampHeader),
+			body, time.Now(), time.Minute)
+	}))
+	defer receiver.Close()
+
+	mockRepo := new(MockWebhookRepository)
+	mockWorkspaces := new(MockWorkspaceRepository)
+	webhookService := newTestWebhookService(mockRepo, mockWorkspaces)
+	ctx := tenant.WithWorkspace(context.Background(), 6)
+
+	mockWorkspaces.On("GetMemberRole", mock.Anything, 6, 1).Return(models.WorkspaceRoleAdmin, nil)
+	mockRepo.On("GetWebhook", mock.Anything, 6, 3).Return(&models.Webhook{ID: 3, WorkspaceID: 6}, nil)
+	mockRepo.On("CreateTestDelivery", mock.Anything, 3, mock.MatchedBy(func(e models.Event) bool {
+		return e.Type == models.EventWebhookTest && e.ActorID == 1
+	}), mock.Anything, mock.Anything, mock.Anything).Return(&models.PendingDelivery{
+		ID: 11, WebhookID: 3, URL: receiver.URL, Secret: "s3cret", EventType: models.EventWebhookTest,
+		Payload: []byte(`{"id":"test","type":"webhook.test"}`),
+	}, nil)
+	mockRepo.On("RecordAttempt", mock.Anything, 11, mock.Anything, mock.MatchedBy(func(a models.DeliveryAttempt) bool {
+		return a.Status == models.DeliverySucceeded
+	})).Return(&models.WebhookDelivery{ID: 11, Status: models.DeliverySucceeded}, nil)
+
+	delivery, err := webhookService.SendTestEvent(ctx, 1, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.NoError(t, signatureErr)
+	assert.Equal(t, models.EventWebhookTest, event.Type)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookSink_Publish(t *testing.T) {
+	mockRepo := new(MockWebhookRepository)
+	sink := NewWebhookSink(mockRepo)
+	ctx := context.Background()
+	event := models.Event{ID: "ev1", Type: models.EventTaskCompleted, ListID: 3, Task: &models.Task{ID: 4, ListID: 3}}
+
+	mockRepo.On("EnqueueDeliveries", mock.Anything, event, mock.MatchedBy(func(payload []byte) bool {
+		var decoded models.Event
+		return json.Unmarshal(payload, &decoded) == nil && decoded.ID == "ev1" && decoded.Task.ID == 4
+	})).Return(2, nil)
+
+	err := sink.Publish(ctx, event)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
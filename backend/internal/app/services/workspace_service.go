@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+	"go.opentelemetry.io/otel"
+)
+
+const maxWorkspaceNameLength = 255
+
+var (
+	ErrWorkspaceNotFound         = errors.New("Workspace not found")
+	ErrWorkspacePermissionDenied = errors.New("Your role in this workspace does not allow this action")
+	ErrInvalidWorkspaceName      = errors.New("Workspace name must be between 1 and 255 characters")
+	ErrInvalidWorkspaceRole      = errors.New("Role must be member or admin")
+	ErrWorkspaceMemberNotFound   = errors.New("User is not a member of this workspace")
+	ErrAlreadyWorkspaceMember    = errors.New("User is already a member of this workspace")
+	ErrWorkspaceOwner            = errors.New("The owner's membership cannot be changed")
+	ErrPersonalWorkspace         = errors.New("Personal workspaces cannot be deleted")
+	ErrOwnsWorkspaceLists        = errors.New("The user owns lists in this workspace; delete them first")
+)
+
+type WorkspaceServiceInterface interface {
+	ListWorkspaces(ctx context.Context, userID uint) ([]models.Workspace, error)
+	CreateWorkspace(ctx context.Context, userID uint, name string) (*models.Workspace, error)
+	GetWorkspace(ctx context.Context, userID uint, workspaceID int) (*models.Workspace, error)
+	RenameWorkspace(ctx context.Context, userID uint, workspaceID int, name string) (*models.Workspace, error)
+	DeleteWorkspace(ctx context.Context, userID uint, workspaceID int) error
+	ListMembers(ctx context.Context, userID uint, workspaceID int) ([]models.WorkspaceMember, error)
+	AddMember(ctx context.Context, userID uint, workspaceID int, req models.AddWorkspaceMemberRequest) (*models.WorkspaceMember, error)
+	UpdateMember(ctx context.Context, userID uint, workspaceID, memberID int, role models.WorkspaceRole) error
+	RemoveMember(ctx context.Context, userID uint, workspaceID, memberID int) error
+	// IsWorkspaceMember reports whether userID belongs to workspaceID. It
+	// implements middleware.WorkspaceChecker.
+	IsWorkspaceMember(ctx context.Context, workspaceID, userID int) (bool, error)
+}
+
+type WorkspaceService struct {
+	repo     repositories.WorkspaceRepository
+	authRepo repositories.AuthRepository
+}
+
+func NewWorkspaceService(repo repositories.WorkspaceRepository, authRepo repositories.AuthRepository) WorkspaceServiceInterface {
+	return &WorkspaceService{repo: repo, authRepo: authRepo}
+}
+
+// requireRole returns ErrWorkspaceNotFound if userID is not a member of
+// workspaceID and ErrWorkspacePermissionDenied if the member's role is below
+// min.
+func (s *WorkspaceService) requireRole(ctx context.Context, userID uint, workspaceID int, min models.WorkspaceRole) error {
+	role, err := s.repo.GetMemberRole(ctx, workspaceID, int(userID))
+	if err != nil {
+		return mapWorkspaceError(err)
+	}
+	if !role.AtLeast(min) {
+		return ErrWorkspacePermissionDenied
+	}
+	return nil
+}
+
+// ListWorkspaces returns the user's workspaces. The personal workspace is
+// created on first use so that it is always listed.
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context, userID uint) ([]models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.ListWorkspaces")
+	defer span.End()
+
+	if _, err := s.repo.EnsurePersonalWorkspace(ctx, int(userID)); err != nil {
+		return nil, err
+	}
+	return s.repo.ListWorkspaces(ctx, int(userID))
+}
+
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, userID uint, name string) (*models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.CreateWorkspace")
+	defer span.End()
+
+	name, err := validWorkspaceName(name)
+	if err != nil {
+		return nil, err
+	}
+	ws := &models.Workspace{Name: name, OwnerID: int(userID)}
+	if err := s.repo.CreateWorkspace(ctx, ws); err != nil {
+		return nil, err
+	}
+	logging.ContextLogger(ctx).Info("Workspace created", "event", "workspace_created", "workspaceID", ws.ID, "userID", userID)
+	return ws, nil
+}
+
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, userID uint, workspaceID int) (*models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.GetWorkspace")
+	defer span.End()
+
+	ws, err := s.repo.GetWorkspace(ctx, workspaceID, int(userID))
+	return ws, mapWorkspaceError(err)
+}
+
+// RenameWorkspace renames a workspace. It requires the admin role.
+func (s *WorkspaceService) RenameWorkspace(ctx context.Context, userID uint, workspaceID int, name string) (*models.Workspace, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.RenameWorkspace")
+	defer span.End()
+
+	name, err := validWorkspaceName(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.requireRole(ctx, userID, workspaceID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RenameWorkspace(ctx, workspaceID, name); err != nil {
+		return nil, mapWorkspaceError(err)
+	}
+	ws, err := s.repo.GetWorkspace(ctx, workspaceID, int(userID))
+	return ws, mapWorkspaceError(err)
+}
+
+// DeleteWorkspace deletes a workspace with all of its lists and tasks. Only
+// the owner may delete a workspace, and personal workspaces cannot be
+// deleted.
+func (s *WorkspaceService) DeleteWorkspace(ctx context.Context, userID uint, workspaceID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.DeleteWorkspace")
+	defer span.End()
+
+	ws, err := s.repo.GetWorkspace(ctx, workspaceID, int(userID))
+	if err != nil {
+		return mapWorkspaceError(err)
+	}
+	if ws.Role != models.WorkspaceRoleOwner {
+		return ErrWorkspacePermissionDenied
+	}
+	if ws.Personal {
+		return ErrPersonalWorkspace
+	}
+	if err := s.repo.DeleteWorkspace(ctx, workspaceID, int(userID)); err != nil {
+		return mapWorkspaceError(err)
+	}
+	logging.ContextLogger(ctx).Info("Workspace deleted", "event", "workspace_deleted", "workspaceID", workspaceID, "userID", userID)
+	return nil
+}
+
+func (s *WorkspaceService) ListMembers(ctx context.Context, userID uint, workspaceID int) ([]models.WorkspaceMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.ListMembers")
+	defer span.End()
+
+	if err := s.requireRole(ctx, userID, workspaceID, models.WorkspaceRoleMember); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, workspaceID)
+}
+
+// AddMember adds the user identified by username or email to a workspace.
+// It requires the admin role; the owner role cannot be granted.
+func (s *WorkspaceService) AddMember(ctx context.Context, userID uint, workspaceID int, req models.AddWorkspaceMemberRequest) (*models.WorkspaceMember, error) {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.AddMember")
+	defer span.End()
+
+	if !assignableWorkspaceRole(req.Role) {
+		return nil, ErrInvalidWorkspaceRole
+	}
+	if err := s.requireRole(ctx, userID, workspaceID, models.WorkspaceRoleAdmin); err != nil {
+		return nil, err
+	}
+
+	user, err := s.authRepo.GetUserByLogin(ctx, strings.TrimSpace(req.Login))
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if err := s.repo.AddMember(ctx, workspaceID, user.ID, req.Role); err != nil {
+		return nil, mapWorkspaceError(err)
+	}
+	logging.ContextLogger(ctx).Info("Workspace member added", "event", "workspace_member_added", "workspaceID", workspaceID, "memberID", user.ID, "role", req.Role, "userID", userID)
+	return &models.WorkspaceMember{UserID: user.ID, Username: user.Username, Role: req.Role}, nil
+}
+
+// UpdateMember changes a member's role. It requires the admin role.
+func (s *WorkspaceService) UpdateMember(ctx context.Context, userID uint, workspaceID, memberID int, role models.WorkspaceRole) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.UpdateMember")
+	defer span.End()
+
+	if !assignableWorkspaceRole(role) {
+		return ErrInvalidWorkspaceRole
+	}
+	if err := s.requireRole(ctx, userID, workspaceID, models.WorkspaceRoleAdmin); err != nil {
+		return err
+	}
+	if err := s.checkNotOwner(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	return mapWorkspaceError(s.repo.UpdateMemberRole(ctx, workspaceID, memberID, role))
+}
+
+// RemoveMember removes a member from a workspace and all of its lists.
+// Admins may remove anyone but the owner; every other member may only
+// leave.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID uint, workspaceID, memberID int) error {
+	_, span := otel.Tracer("").Start(ctx, "WorkspaceService.RemoveMember")
+	defer span.End()
+
+	if memberID != int(userID) {
+		if err := s.requireRole(ctx, userID, workspaceID, models.WorkspaceRoleAdmin); err != nil {
+			return err
+		}
+	}
+	if err := s.checkNotOwner(ctx, workspaceID, memberID); err != nil {
+		return err
+	}
+	if err := s.repo.RemoveMember(ctx, workspaceID, memberID); err != nil {
+		return mapWorkspaceError(err)
+	}
+	logging.ContextLogger(ctx).Info("Workspace member removed", "event", "workspace_member_removed", "workspaceID", workspaceID, "memberID", memberID, "userID", userID)
+	return nil
+}
+
+func (s *WorkspaceService) IsWorkspaceMember(ctx context.Context, workspaceID, userID int) (bool, error) {
+	_, err := s.repo.GetMemberRole(ctx, workspaceID, userID)
+	if errors.Is(err, repositories.ErrWorkspaceNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *WorkspaceService) checkNotOwner(ctx context.Context, workspaceID, memberID int) error {
+	role, err := s.repo.GetMemberRole(ctx, workspaceID, memberID)
+	if errors.Is(err, repositories.ErrWorkspaceNotFound) {
+		return ErrWorkspaceMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role == models.WorkspaceRoleOwner {
+		return ErrWorkspaceOwner
+	}
+	return nil
+}
+
+func validWorkspaceName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxWorkspaceNameLength {
+		return "", ErrInvalidWorkspaceName
+	}
+	return name, nil
+}
+
+// assignableWorkspaceRole reports whether role may be granted to a member.
+// There is exactly one owner per workspace.
+func assignableWorkspaceRole(role models.WorkspaceRole) bool {
+	return role.Valid() && role != models.WorkspaceRoleOwner
+}
+
+func mapWorkspaceError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrWorkspaceNotFound):
+		return ErrWorkspaceNotFound
+	case errors.Is(err, repositories.ErrNotWorkspaceMember):
+		return ErrWorkspaceMemberNotFound
+	case errors.Is(err, repositories.ErrAlreadyWorkspaceMember):
+		return ErrAlreadyWorkspaceMember
+	case errors.Is(err, repositories.ErrOwnsWorkspaceLists):
+		return ErrOwnsWorkspaceLists
+	default:
+		return err
+	}
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/models"
+	"github.com/tamago/todo-with-gemini/backend/internal/app/repositories"
+)
+
+// MockWorkspaceRepository is a mock implementation of the WorkspaceRepository interface
+type MockWorkspaceRepository struct {
+	mock.Mock
+}
+
+func (m *MockWorkspaceRepository) EnsurePersonalWorkspace(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) CreateWorkspace(ctx context.Context, ws *models.Workspace) error {
+	args := m.Called(ctx, ws)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) ListWorkspaces(ctx context.Context, userID int) ([]models.Workspace, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) GetWorkspace(ctx context.Context, workspaceID, userID int) (*models.Workspace, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Workspace), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) RenameWorkspace(ctx context.Context, workspaceID int, name string) error {
+	args := m.Called(ctx, workspaceID, name)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) DeleteWorkspace(ctx context.Context, workspaceID, actorID int) error {
+	args := m.Called(ctx, workspaceID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) GetMemberRole(ctx context.Context, workspaceID, userID int) (models.WorkspaceRole, error) {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Get(0).(models.WorkspaceRole), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) ListMembers(ctx context.Context, workspaceID int) ([]models.WorkspaceMember, error) {
+	args := m.Called(ctx, workspaceID)
+	return args.Get(0).([]models.WorkspaceMember), args.Error(1)
+}
+
+func (m *MockWorkspaceRepository) AddMember(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error {
+	args := m.Called(ctx, workspaceID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID, userID int, role models.WorkspaceRole) error {
+	args := m.Called(ctx, workspaceID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID, userID int) error {
+	args := m.Called(ctx, workspaceID, userID)
+	return args.Error(0)
+}
+
+func TestWorkspaceService_CreateWorkspace(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("CreateWorkspace", ctx, mock.MatchedBy(func(ws *models.Workspace) bool {
+		return ws.Name == "Team" && ws.OwnerID == 1
+	})).Return(nil)
+
+	ws, err := workspaceService.CreateWorkspace(ctx, 1, "  Team ")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Team", ws.Name)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkspaceService_CreateWorkspace_InvalidName(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+
+	_, err := workspaceService.CreateWorkspace(context.Background(), 1, "   ")
+
+	assert.ErrorIs(t, err, ErrInvalidWorkspaceName)
+	mockRepo.AssertNotCalled(t, "CreateWorkspace", mock.Anything, mock.Anything)
+}
+
+func TestWorkspaceService_AddMember(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	mockAuth := new(MockAuthRepository)
+	workspaceService := NewWorkspaceService(mockRepo, mockAuth)
+	ctx := context.Background()
+
+	mockRepo.On("GetMemberRole", ctx, 3, 1).Return(models.WorkspaceRoleAdmin, nil)
+	mockAuth.On("GetUserByLogin", ctx, "bob").Return(&models.User{ID: 2, Username: "bob"}, nil)
+	mockRepo.On("AddMember", ctx, 3, 2, models.WorkspaceRoleMember).Return(nil)
+
+	member, err := workspaceService.AddMember(ctx, 1, 3, models.AddWorkspaceMemberRequest{Login: " bob ", Role: models.WorkspaceRoleMember})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, member.UserID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkspaceService_AddMember_Denied(t *testing.T) {
+	tests := []struct {
+		name string
+		role models.WorkspaceRole
+		want error
+	}{
+		{"member cannot invite", models.WorkspaceRoleMember, ErrWorkspacePermissionDenied},
+		{"owner role cannot be granted", models.WorkspaceRoleOwner, ErrInvalidWorkspaceRole},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWorkspaceRepository)
+			mockAuth := new(MockAuthRepository)
+			workspaceService := NewWorkspaceService(mockRepo, mockAuth)
+			ctx := context.Background()
+			mockRepo.On("GetMemberRole", ctx, 3, 1).Return(tt.role, nil)
+
+			_, err := workspaceService.AddMember(ctx, 1, 3, models.AddWorkspaceMemberRequest{Login: "bob", Role: tt.role})
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "AddMember", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWorkspaceService_DeleteWorkspace(t *testing.T) {
+	tests := []struct {
+		name string
+		ws   *models.Workspace
+		want error
+	}{
+		{"personal workspace", &models.Workspace{ID: 3, Role: models.WorkspaceRoleOwner, Personal: true}, ErrPersonalWorkspace},
+		{"admin is not the owner", &models.Workspace{ID: 3, Role: models.WorkspaceRoleAdmin}, ErrWorkspacePermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWorkspaceRepository)
+			workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+			ctx := context.Background()
+			mockRepo.On("GetWorkspace", ctx, 3, 1).Return(tt.ws, nil)
+
+			err := workspaceService.DeleteWorkspace(ctx, 1, 3)
+
+			assert.ErrorIs(t, err, tt.want)
+			mockRepo.AssertNotCalled(t, "DeleteWorkspace", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestWorkspaceService_RemoveMember_Leave(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.WorkspaceRoleMember, nil)
+	mockRepo.On("RemoveMember", ctx, 3, 2).Return(nil)
+
+	err := workspaceService.RemoveMember(ctx, 2, 3, 2)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWorkspaceService_RemoveMember_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		role      models.WorkspaceRole
+		removeErr error
+		want      error
+	}{
+		{"owner cannot be removed", models.WorkspaceRoleOwner, nil, ErrWorkspaceOwner},
+		{"member owns lists", models.WorkspaceRoleMember, repositories.ErrOwnsWorkspaceLists, ErrOwnsWorkspaceLists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockWorkspaceRepository)
+			workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+			ctx := context.Background()
+			mockRepo.On("GetMemberRole", ctx, 3, 1).Return(models.WorkspaceRoleAdmin, nil)
+			mockRepo.On("GetMemberRole", ctx, 3, 2).Return(tt.role, nil)
+			mockRepo.On("RemoveMember", ctx, 3, 2).Return(tt.removeErr)
+
+			err := workspaceService.RemoveMember(ctx, 1, 3, 2)
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestWorkspaceService_IsWorkspaceMember(t *testing.T) {
+	mockRepo := new(MockWorkspaceRepository)
+	workspaceService := NewWorkspaceService(mockRepo, new(MockAuthRepository))
+	ctx := context.Background()
+
+	mockRepo.On("GetMemberRole", ctx, 3, 1).Return(models.WorkspaceRoleMember, nil)
+	mockRepo.On("GetMemberRole", ctx, 3, 2).Return(models.WorkspaceRole(""), repositories.ErrWorkspaceNotFound)
+
+	ok, err := workspaceService.IsWorkspaceMember(ctx, 3, 1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = workspaceService.IsWorkspaceMember(ctx, 3, 2)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,169 @@
+package crdt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MaxDrift is how far ahead of the local clock a remote timestamp may be.
+// Later ones are rejected, so that a client with a wrong clock cannot win
+// every future write.
+const MaxDrift = time.Minute
+
+var (
+	ErrInvalidTimestamp = errors.New("invalid hybrid logical clock timestamp")
+	ErrClockDrift       = errors.New("timestamp is too far ahead of the clock")
+	ErrInvalidNode      = errors.New("node IDs must be 1 to 16 lowercase letters or digits")
+)
+
+var (
+	timestampPattern = regexp.MustCompile(`^([0-9a-f]{12})-([0-9a-f]{8})-([0-9a-z]{1,16})$`)
+	nodePattern      = regexp.MustCompile(`^[0-9a-z]{1,16}$`)
+)
+
+// Timestamp is a hybrid logical clock timestamp: the physical time in
+// milliseconds, a counter that orders events within the same millisecond
+// or while the physical clock lags behind, and the node that took it.
+// Every node must have its own ID, which makes timestamps unique.
+//
+// Its text form is "<wall>-<counter>-<node>", with the wall time as 12 and
+// the counter as 8 lowercase hex digits. Comparing the text forms byte by
+// byte orders them like Compare. The zero Timestamp is "" and comes before
+// every other.
+type Timestamp struct {
+	Wall    int64
+	Counter uint32
+	Node    string
+}
+
+// ParseTimestamp parses the text form of a timestamp. "" is the zero
+// Timestamp.
+func ParseTimestamp(s string) (Timestamp, error) {
+	if s == "" {
+		return Timestamp{}, nil
+	}
+	m := timestampPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Timestamp{}, ErrInvalidTimestamp
+	}
+	wall, _ := strconv.ParseInt(m[1], 16, 64)
+	counter, _ := strconv.ParseUint(m[2], 16, 32)
+	return Timestamp{Wall: wall, Counter: uint32(counter), Node: m[3]}, nil
+}
+
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%012x-%08x-%s", t.Wall, t.Counter, t.Node)
+}
+
+func (t Timestamp) IsZero() bool {
+	return t == Timestamp{}
+}
+
+// Compare returns -1, 0 or +1 as t is before, equal to or after u.
+func (t Timestamp) Compare(u Timestamp) int {
+	switch {
+	case t.Wall != u.Wall:
+		return cmpInt(t.Wall, u.Wall)
+	case t.Counter != u.Counter:
+		return cmpInt(t.Counter, u.Counter)
+	case t.Node < u.Node:
+		return -1
+	case t.Node > u.Node:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func cmpInt[T int64 | uint32](a, b T) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *Timestamp) UnmarshalText(text []byte) error {
+	parsed, err := ParseTimestamp(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Clock is the hybrid logical clock of a node. Its timestamps are later
+// than every timestamp it took or observed before. It is safe for
+// concurrent use.
+type Clock struct {
+	node string
+	now  func() time.Time
+
+	mu   sync.Mutex
+	last Timestamp
+}
+
+// NewClock returns the clock of node, which must match [0-9a-z]{1,16}.
+func NewClock(node string) (*Clock, error) {
+	if !nodePattern.MatchString(node) {
+		return nil, ErrInvalidNode
+	}
+	return &Clock{node: node, now: time.Now}, nil
+}
+
+// RandomNode returns a random node ID, for nodes that have no stable one.
+func RandomNode() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Node returns the ID of the clock's node.
+func (c *Clock) Node() string {
+	return c.node
+}
+
+// Now returns a new timestamp.
+func (c *Clock) Now() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	wall := c.now().UnixMilli()
+	if wall > c.last.Wall {
+		c.last = Timestamp{Wall: wall, Node: c.node}
+	} else {
+		c.last = Timestamp{Wall: c.last.Wall, Counter: c.last.Counter + 1, Node: c.node}
+	}
+	return c.last
+}
+
+// Observe advances the clock past t, a timestamp received from another
+// node, so that the timestamps it takes later are after t. Timestamps more
+// than MaxDrift ahead of the physical clock are rejected with
+// ErrClockDrift.
+func (c *Clock) Observe(t Timestamp) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.UnixMilli(t.Wall).After(c.now().Add(MaxDrift)) {
+		return ErrClockDrift
+	}
+	if t.Wall > c.last.Wall || (t.Wall == c.last.Wall && t.Counter > c.last.Counter) {
+		c.last = Timestamp{Wall: t.Wall, Counter: t.Counter, Node: c.node}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package crdt
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Generate returns timestamps that often share their wall time or counter,
+// so that every part of the order is exercised.
+func (Timestamp) Generate(r *rand.Rand, _ int) reflect.Value {
+	nodes := []string{"a", "b", "a0", "z9", "node12"}
+	return reflect.ValueOf(Timestamp{
+		Wall:    1_700_000_000_000 + r.Int63n(3),
+		Counter: uint32(r.Intn(3)),
+		Node:    nodes[r.Intn(len(nodes))],
+	})
+}
+
+func TestTimestamp_TextOrder(t *testing.T) {
+	property := func(a, b Timestamp) bool {
+		parsed, err := ParseTimestamp(a.String())
+		return err == nil && parsed == a && strings.Compare(a.String(), b.String()) == a.Compare(b)
+	}
+	assert.NoError(t, quick.Check(property, nil))
+	assert.Equal(t, -1, Timestamp{}.Compare(Timestamp{Node: "a"}))
+}
+
+func TestParseTimestamp(t *testing.T) {
+	ts, err := ParseTimestamp("018bcfe56800-0000000a-phone1")
+	require.NoError(t, err)
+	assert.Equal(t, Timestamp{Wall: 1_700_000_000_000, Counter: 10, Node: "phone1"}, ts)
+
+	zero, err := ParseTimestamp("")
+	assert.NoError(t, err)
+	assert.True(t, zero.IsZero())
+
+	for _, s := range []string{"18bcfe56800-0000000a-a", "018bcfe56800-0000000a-", "018bcfe56800-0000000a-Phone", "018BCFE56800-0000000a-a", "x"} {
+		_, err := ParseTimestamp(s)
+		assert.ErrorIs(t, err, ErrInvalidTimestamp, s)
+	}
+}
+
+func TestClock(t *testing.T) {
+	physical := time.UnixMilli(1_700_000_000_000)
+	clock, err := NewClock("server")
+	require.NoError(t, err)
+	clock.now = func() time.Time { return physical }
+
+	first := clock.Now()
+	second := clock.Now()
+	assert.Equal(t, Timestamp{Wall: physical.UnixMilli(), Node: "server"}, first)
+	assert.Equal(t, 1, second.Compare(first))
+
+	// The physical clock goes back.
+	physical = physical.Add(-time.Second)
+	assert.Equal(t, 1, clock.Now().Compare(second))
+
+	// A remote node is a bit ahead.
+	remote := Timestamp{Wall: physical.Add(30 * time.Second).UnixMilli(), Counter: 4, Node: "phone"}
+	require.NoError(t, clock.Observe(remote))
+	assert.Equal(t, Timestamp{Wall: remote.Wall, Counter: 5, Node: "server"}, clock.Now())
+
+	assert.ErrorIs(t, clock.Observe(Timestamp{Wall: physical.Add(2 * MaxDrift).UnixMilli(), Node: "phone"}), ErrClockDrift)
+
+	_, err = NewClock("Server")
+	assert.ErrorIs(t, err, ErrInvalidNode)
+}
+
+func TestRandomNode(t *testing.T) {
+	node, err := RandomNode()
+	require.NoError(t, err)
+	_, err = NewClock(node)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,149 @@
+package crdt
+
+import (
+	"cmp"
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// keyDigits are the digits of position keys, in order.
+const keyDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var (
+	ErrInvalidKey = errors.New("position keys must be lowercase letters or digits and must not end in 0")
+	ErrKeyOrder   = errors.New("position keys are out of order")
+)
+
+// keyPattern matches position keys. A key never ends in the lowest digit,
+// so there is always room for a key before it.
+var keyPattern = regexp.MustCompile(`^[0-9a-z]*[1-9a-z]$`)
+
+// ValidKey reports whether key is a position key.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// Between returns a position key that sorts after prev and before next,
+// for an item that node places between them. An empty prev is the start
+// and an empty next the end of the sequence. The key ends with node, so
+// different nodes that place items into the same gap at the same time get
+// different keys.
+//
+// Keys are compared byte by byte. The key is the shortest midpoint of the
+// two keys in base 36, followed by node and "i".
+func Between(prev, next, node string) (string, error) {
+	if (prev != "" && !ValidKey(prev)) || (next != "" && !ValidKey(next)) {
+		return "", ErrInvalidKey
+	}
+	if !nodePattern.MatchString(node) {
+		return "", ErrInvalidNode
+	}
+	if prev != "" && next != "" && prev >= next {
+		return "", ErrKeyOrder
+	}
+	return midpoint(prev, next) + node + "i", nil
+}
+
+// midpoint returns a key between a and b that is not a prefix of b, so
+// that anything may be appended to it. b is "" for the end.
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			return b[:n] + midpoint(a[min(n, len(a)):], b[n:])
+		}
+	}
+
+	low := strings.IndexByte(keyDigits, digitAt(a, 0))
+	high := len(keyDigits)
+	if b != "" {
+		high = strings.IndexByte(keyDigits, b[0])
+	}
+	if high-low > 1 {
+		return string(keyDigits[(low+high)/2])
+	}
+	return string(keyDigits[low]) + midpoint(a[min(1, len(a)):], "")
+}
+
+// digitAt returns the i-th digit of key, which is followed by the lowest
+// digit indefinitely.
+func digitAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+	return keyDigits[0]
+}
+
+// Order is a sequence CRDT for a manual order of items identified by K.
+// Every item has a position key in a Register, and items are ordered by
+// key and then by ID. Moving an item writes a new key between those of its
+// new neighbours, so concurrent moves of different items all take effect
+// and concurrent moves of the same item end where the last one put it.
+type Order[K cmp.Ordered] struct {
+	positions map[K]Register[string]
+}
+
+func NewOrder[K cmp.Ordered]() *Order[K] {
+	return &Order[K]{positions: make(map[K]Register[string])}
+}
+
+// Set merges a write of the position key of id.
+func (o *Order[K]) Set(id K, position Register[string]) {
+	o.positions[id] = o.positions[id].Merge(position)
+}
+
+// Position returns the position of id, if it has one.
+func (o *Order[K]) Position(id K) (Register[string], bool) {
+	position, ok := o.positions[id]
+	return position, ok
+}
+
+// Merge merges every position of other into o.
+func (o *Order[K]) Merge(other *Order[K]) {
+	for id, position := range other.positions {
+		o.Set(id, position)
+	}
+}
+
+// Items returns the IDs of the items in order.
+func (o *Order[K]) Items() []K {
+	ids := make([]K, 0, len(o.positions))
+	for id := range o.positions {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b K) int {
+		return cmp.Or(strings.Compare(o.positions[a].Value, o.positions[b].Value), cmp.Compare(a, b))
+	})
+	return ids
+}
+
+// Move places id at index among the other items, with a timestamp of
+// clock, and returns the write for the other replicas to Set.
+func (o *Order[K]) Move(id K, index int, clock *Clock) (Register[string], error) {
+	items := slices.DeleteFunc(o.Items(), func(item K) bool { return item == id })
+	index = max(0, min(index, len(items)))
+	var prev, next string
+	if index > 0 {
+		prev = o.positions[items[index-1]].Value
+	}
+	// Concurrent writes may leave items with the same key, which no key
+	// fits between; id then goes after them.
+	for ; index < len(items); index++ {
+		if next = o.positions[items[index]].Value; next != prev {
+			break
+		}
+		next = ""
+	}
+	key, err := Between(prev, next, clock.Node())
+	if err != nil {
+		return Register[string]{}, err
+	}
+	position := Register[string]{Value: key, Stamp: clock.Now()}
+	o.Set(id, position)
+	return position, nil
+}
@@ -0,0 +1,159 @@
+package crdt
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomKey(r *rand.Rand) string {
+	key := make([]byte, 1+r.Intn(5))
+	for i := range key {
+		key[i] = keyDigits[r.Intn(len(keyDigits))]
+	}
+	key[len(key)-1] = keyDigits[1+r.Intn(len(keyDigits)-1)]
+	return string(key)
+}
+
+func TestBetween(t *testing.T) {
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		prev, next := randomKey(r), randomKey(r)
+		if prev > next {
+			prev, next = next, prev
+		}
+		switch r.Intn(4) {
+		case 0:
+			prev = ""
+		case 1:
+			next = ""
+		}
+		if prev == next && prev != "" {
+			return true
+		}
+
+		key, err := Between(prev, next, "n1")
+		return err == nil && ValidKey(key) && key > prev && (next == "" || key < next)
+	}
+	assert.NoError(t, quick.Check(property, &quick.Config{MaxCount: 2000}))
+}
+
+func TestBetween_Examples(t *testing.T) {
+	tests := []struct{ prev, next, want string }{
+		{"", "", "ini"},
+		{"", "1", "0ini"},
+		{"1", "2", "1ini"},
+		{"1z", "2", "1zini"},
+		{"1", "1005", "1002ni"},
+		{"a", "", "nni"},
+	}
+	for _, tt := range tests {
+		key, err := Between(tt.prev, tt.next, "n")
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, key, "%q..%q", tt.prev, tt.next)
+	}
+
+	_, err := Between("2", "1", "n")
+	assert.ErrorIs(t, err, ErrKeyOrder)
+	_, err = Between("10", "", "n")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = Between("", "", "N")
+	assert.ErrorIs(t, err, ErrInvalidNode)
+}
+
+func TestBetween_ConcurrentInsertsDiffer(t *testing.T) {
+	a, err := Between("1", "2", "phone")
+	require.NoError(t, err)
+	b, err := Between("1", "2", "laptop")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestOrder_Move(t *testing.T) {
+	clock, err := NewClock("a")
+	require.NoError(t, err)
+	order := NewOrder[int]()
+	for id := 1; id <= 4; id++ {
+		_, err := order.Move(id, id, clock)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4}, order.Items())
+
+	_, err = order.Move(4, 0, clock)
+	require.NoError(t, err)
+	_, err = order.Move(1, 2, clock)
+	require.NoError(t, err)
+	assert.Equal(t, []int{4, 2, 1, 3}, order.Items())
+}
+
+type orderReplica struct {
+	order *Order[int]
+	clock *Clock
+}
+
+type orderWrite struct {
+	id       int
+	position Register[string]
+}
+
+func (rep orderReplica) receive(w orderWrite) {
+	_ = rep.clock.Observe(w.position.Stamp)
+	rep.order.Set(w.id, w.position)
+}
+
+// TestOrder_Converges lets replicas with skewed clocks move items while
+// they exchange only some of their writes, then delivers every write to
+// every replica in a different order, with duplicates.
+func TestOrder_Converges(t *testing.T) {
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		physical := time.UnixMilli(1_700_000_000_000)
+		var replicas []orderReplica
+		for _, node := range []string{"a", "b", "c"} {
+			clock, _ := NewClock(node)
+			skew := time.Duration(r.Intn(2000)) * time.Millisecond
+			clock.now = func() time.Time { return physical.Add(skew) }
+			replicas = append(replicas, orderReplica{order: NewOrder[int](), clock: clock})
+		}
+
+		var log []orderWrite
+		for range 40 {
+			physical = physical.Add(time.Duration(r.Intn(3)) * time.Millisecond)
+			rep := replicas[r.Intn(len(replicas))]
+			if len(log) > 0 && r.Intn(3) == 0 {
+				for range 1 + r.Intn(len(log)) {
+					rep.receive(log[r.Intn(len(log))])
+				}
+				continue
+			}
+			id := 1 + r.Intn(6)
+			position, err := rep.order.Move(id, r.Intn(7), rep.clock)
+			if err != nil {
+				t.Log(err)
+				return false
+			}
+			log = append(log, orderWrite{id, position})
+		}
+
+		for _, rep := range replicas {
+			writes := append([]orderWrite{}, log...)
+			writes = append(writes, log[:r.Intn(len(log))]...)
+			r.Shuffle(len(writes), func(i, j int) { writes[i], writes[j] = writes[j], writes[i] })
+			for _, w := range writes {
+				rep.receive(w)
+			}
+		}
+		want := replicas[0].order.Items()
+		for _, rep := range replicas[1:] {
+			if !assert.ObjectsAreEqual(want, rep.order.Items()) || !assert.ObjectsAreEqual(replicas[0].order.positions, rep.order.positions) {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(property, &quick.Config{MaxCount: 500}))
+}
@@ -0,0 +1,21 @@
+// Package crdt provides the conflict-free replicated data types that tasks
+// are merged with: hybrid logical clocks, last-writer-wins registers and a
+// sequence for manual orders. Replicas that have received the same writes,
+// in any order and any number of times, hold the same state.
+package crdt
+
+// Register is a last-writer-wins register: of two writes, the one with the
+// later timestamp wins. The zero Register has never been written.
+type Register[T any] struct {
+	Value T
+	Stamp Timestamp
+}
+
+// Merge returns the later of r and other. Timestamps are unique, so Merge
+// is commutative, associative and idempotent.
+func (r Register[T]) Merge(other Register[T]) Register[T] {
+	if other.Stamp.Compare(r.Stamp) > 0 {
+		return other
+	}
+	return r
+}
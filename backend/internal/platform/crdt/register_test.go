@@ -0,0 +1,56 @@
+package crdt
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// deliver merges writes into a fresh register in a random order, with some
+// writes delivered more than once.
+func deliver[T any](r *rand.Rand, writes []Register[T]) Register[T] {
+	order := append([]Register[T]{}, writes...)
+	for range r.Intn(len(writes) + 1) {
+		order = append(order, writes[r.Intn(len(writes))])
+	}
+	r.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+
+	var reg Register[T]
+	for _, w := range order {
+		reg = reg.Merge(w)
+	}
+	return reg
+}
+
+func TestRegister_Converges(t *testing.T) {
+	property := func(seed int64) bool {
+		r := rand.New(rand.NewSource(seed))
+		writes := make([]Register[string], 1+r.Intn(8))
+		for i := range writes {
+			writes[i] = Register[string]{Value: string(rune('a' + r.Intn(26))), Stamp: Timestamp{}.Generate(r, 0).Interface().(Timestamp)}
+			// Timestamps are unique, so a node writes once per timestamp.
+			writes[i].Stamp.Counter = uint32(i)
+		}
+
+		want := deliver(r, writes)
+		for range 5 {
+			if deliver(r, writes) != want {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func TestRegister_Merge(t *testing.T) {
+	older := Register[bool]{Value: true, Stamp: Timestamp{Wall: 1, Node: "b"}}
+	newer := Register[bool]{Value: false, Stamp: Timestamp{Wall: 1, Counter: 1, Node: "a"}}
+
+	assert.Equal(t, newer, older.Merge(newer))
+	assert.Equal(t, newer, newer.Merge(older))
+	assert.Equal(t, older, Register[bool]{}.Merge(older))
+	assert.Equal(t, older, older.Merge(Register[bool]{}))
+}
@@ -0,0 +1,359 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineBytes limits the length of a physical line, so that a file
+// without line breaks cannot grow the buffer without bound.
+const maxLineBytes = 1 << 20
+
+// maxDepth limits how deeply the components a decoder skips may nest.
+const maxDepth = 16
+
+// SyntaxError reports a file that is not an iCalendar object. Decoding
+// cannot go on after one.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("ical: line %d: %s", e.Line, e.Msg)
+}
+
+// ValueError reports a property of a to-do whose value could not be read.
+// The to-do is returned with it, without the property, and decoding can go
+// on.
+type ValueError struct {
+	Line     int
+	Property string
+	Err      error
+}
+
+func (e *ValueError) Error() string {
+	return fmt.Sprintf("ical: line %d: invalid %s: %v", e.Line, e.Property, e.Err)
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// contentLine is an unfolded content line. Names of properties and
+// parameters are upper case.
+type contentLine struct {
+	number int
+	name   string
+	params map[string][]string
+	value  string
+}
+
+// Decoder reads the to-dos of an iCalendar object.
+type Decoder struct {
+	scanner *bufio.Scanner
+	number  int
+	// next is the physical line read ahead to find the end of a folded
+	// line, and nextNumber its number.
+	next       string
+	nextNumber int
+	hasNext    bool
+	started    bool
+	done       bool
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineBytes)
+	return &Decoder{scanner: scanner}
+}
+
+// peek returns the next physical line, without its line break, and keeps
+// it for the next call. Lines may end in CRLF or, as some producers write
+// them, in LF. It returns false at the end of the input.
+func (d *Decoder) peek() (string, bool, error) {
+	if d.hasNext {
+		return d.next, true, nil
+	}
+	if !d.scanner.Scan() {
+		err := d.scanner.Err()
+		if errors.Is(err, bufio.ErrTooLong) {
+			return "", false, &SyntaxError{Line: d.number + 1, Msg: "line too long"}
+		}
+		return "", false, err
+	}
+	d.number++
+	line := strings.TrimSuffix(d.scanner.Text(), "\r")
+	if d.number == 1 {
+		line = strings.TrimPrefix(line, "\ufeff")
+	}
+	d.next, d.nextNumber, d.hasNext = line, d.number, true
+	return line, true, nil
+}
+
+// readLine returns the next unfolded line and its number, or io.EOF. A line
+// that starts with a space or tab continues the one before. Empty lines
+// are skipped.
+func (d *Decoder) readLine() (string, int, error) {
+	for {
+		line, ok, err := d.peek()
+		if err != nil {
+			return "", 0, err
+		}
+		if !ok {
+			return "", 0, io.EOF
+		}
+		d.hasNext = false
+		number := d.nextNumber
+		if line == "" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return "", 0, &SyntaxError{Line: number, Msg: "continuation line without a line to continue"}
+		}
+
+		var b strings.Builder
+		b.WriteString(line)
+		for {
+			next, ok, err := d.peek()
+			if err != nil {
+				return "", 0, err
+			}
+			if !ok || next == "" || (next[0] != ' ' && next[0] != '\t') {
+				return b.String(), number, nil
+			}
+			d.hasNext = false
+			if b.Len()+len(next) > maxLineBytes {
+				return "", 0, &SyntaxError{Line: d.nextNumber, Msg: "line too long"}
+			}
+			b.WriteString(next[1:])
+		}
+	}
+}
+
+// readContentLine reads the next content line, or io.EOF.
+func (d *Decoder) readContentLine() (*contentLine, error) {
+	line, number, err := d.readLine()
+	if err != nil {
+		return nil, err
+	}
+	cl, ok := parseContentLine(line)
+	if !ok {
+		return nil, &SyntaxError{Line: number, Msg: "invalid content line"}
+	}
+	cl.number = number
+	return cl, nil
+}
+
+// parseContentLine parses name *(";" param) ":" value, where a param is
+// name "=" value *("," value) and a parameter value may be quoted.
+func parseContentLine(line string) (*contentLine, bool) {
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 || !validName(line[:end]) {
+		return nil, false
+	}
+	cl := &contentLine{name: strings.ToUpper(line[:end]), params: map[string][]string{}}
+	rest := line[end:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 || !validName(rest[:eq]) {
+			return nil, false
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		for {
+			var value string
+			if strings.HasPrefix(rest, `"`) {
+				quote := strings.IndexByte(rest[1:], '"')
+				if quote < 0 {
+					return nil, false
+				}
+				value, rest = rest[1:quote+1], rest[quote+2:]
+			} else {
+				end := strings.IndexAny(rest, ",;:")
+				if end < 0 {
+					return nil, false
+				}
+				value, rest = rest[:end], rest[end:]
+			}
+			cl.params[name] = append(cl.params[name], value)
+			if rest == "" {
+				return nil, false
+			}
+			if rest[0] != ',' {
+				break
+			}
+			rest = rest[1:]
+		}
+	}
+	if rest[0] != ':' {
+		return nil, false
+	}
+	cl.value = rest[1:]
+	return cl, true
+}
+
+// validName reports whether s is an iana-token or x-name: letters, digits
+// and "-".
+func validName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the next to-do, or io.EOF after the last one. If a value of
+// the to-do is invalid, the to-do is returned with a *ValueError. Any
+// other error is final.
+func (d *Decoder) Next() (Todo, error) {
+	if d.done {
+		return Todo{}, io.EOF
+	}
+	if !d.started {
+		cl, err := d.readContentLine()
+		if errors.Is(err, io.EOF) {
+			return Todo{}, &SyntaxError{Line: d.number + 1, Msg: "missing BEGIN:VCALENDAR"}
+		}
+		if err != nil {
+			return Todo{}, err
+		}
+		if cl.name != "BEGIN" || !strings.EqualFold(cl.value, "VCALENDAR") {
+			return Todo{}, &SyntaxError{Line: cl.number, Msg: "missing BEGIN:VCALENDAR"}
+		}
+		d.started = true
+	}
+
+	for {
+		cl, err := d.readContentLine()
+		if errors.Is(err, io.EOF) {
+			return Todo{}, &SyntaxError{Line: d.number + 1, Msg: "missing END:VCALENDAR"}
+		}
+		if err != nil {
+			return Todo{}, err
+		}
+		switch {
+		case cl.name == "END" && strings.EqualFold(cl.value, "VCALENDAR"):
+			d.done = true
+			return Todo{}, io.EOF
+		case cl.name == "END":
+			return Todo{}, &SyntaxError{Line: cl.number, Msg: "unexpected END:" + cl.value}
+		case cl.name == "BEGIN" && strings.EqualFold(cl.value, "VTODO"):
+			return d.readTodo()
+		case cl.name == "BEGIN":
+			if err := d.skipComponent(cl.value); err != nil {
+				return Todo{}, err
+			}
+		}
+	}
+}
+
+// skipComponent skips to the end of the component name, whose BEGIN line
+// was read, with the components nested in it.
+func (d *Decoder) skipComponent(name string) error {
+	open := []string{name}
+	for len(open) > 0 {
+		cl, err := d.readContentLine()
+		if errors.Is(err, io.EOF) {
+			return &SyntaxError{Line: d.number + 1, Msg: "missing END:" + open[len(open)-1]}
+		}
+		if err != nil {
+			return err
+		}
+		switch cl.name {
+		case "BEGIN":
+			if len(open) == maxDepth {
+				return &SyntaxError{Line: cl.number, Msg: "components nested too deeply"}
+			}
+			open = append(open, cl.value)
+		case "END":
+			if last := open[len(open)-1]; !strings.EqualFold(cl.value, last) {
+				return &SyntaxError{Line: cl.number, Msg: fmt.Sprintf("END:%s does not match BEGIN:%s", cl.value, last)}
+			}
+			open = open[:len(open)-1]
+		}
+	}
+	return nil
+}
+
+// readTodo reads the properties of a VTODO whose BEGIN line was read.
+// Nested components, such as VALARMs, are skipped.
+func (d *Decoder) readTodo() (Todo, error) {
+	var todo Todo
+	var valueErr error
+	for {
+		cl, err := d.readContentLine()
+		if errors.Is(err, io.EOF) {
+			return Todo{}, &SyntaxError{Line: d.number + 1, Msg: "missing END:VTODO"}
+		}
+		if err != nil {
+			return Todo{}, err
+		}
+		if err := setProperty(&todo, cl); err != nil && valueErr == nil {
+			valueErr = &ValueError{Line: cl.number, Property: cl.name, Err: err}
+		}
+		switch cl.name {
+		case "BEGIN":
+			if err := d.skipComponent(cl.value); err != nil {
+				return Todo{}, err
+			}
+		case "END":
+			if !strings.EqualFold(cl.value, "VTODO") {
+				return Todo{}, &SyntaxError{Line: cl.number, Msg: fmt.Sprintf("END:%s does not match BEGIN:VTODO", cl.value)}
+			}
+			return todo, valueErr
+		}
+	}
+}
+
+func setProperty(todo *Todo, cl *contentLine) error {
+	var err error
+	switch cl.name {
+	case "UID":
+		todo.UID = unescapeText(cl.value)
+	case "SUMMARY":
+		todo.Summary = unescapeText(cl.value)
+	case "STATUS":
+		todo.Status = Status(strings.ToUpper(cl.value))
+	case "CREATED":
+		todo.Created, err = parseDateTime(cl)
+	case "LAST-MODIFIED":
+		todo.LastModified, err = parseDateTime(cl)
+	case "DTSTAMP":
+		todo.Stamp, err = parseDateTime(cl)
+	case "DTSTART":
+		todo.Start, err = parseDateTime(cl)
+	case "COMPLETED":
+		todo.Completed, err = parseDateTime(cl)
+	}
+	return err
+}
+
+// parseDateTime parses a DATE-TIME value, or a DATE value as midnight. A
+// time with a TZID is in that zone; a TZID the Go time zone database does
+// not know, such as a Windows zone name, and floating times without one
+// are taken as UTC.
+func parseDateTime(cl *contentLine) (time.Time, error) {
+	loc := time.UTC
+	if tzid := cl.params["TZID"]; len(tzid) == 1 {
+		if zone, err := time.LoadLocation(strings.TrimPrefix(tzid[0], "/")); err == nil {
+			loc = zone
+		}
+	}
+	value := cl.value
+	switch {
+	case len(value) == len(dateFormat):
+		return time.ParseInLocation(dateFormat, value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse(dateTimeFormat, value)
+	default:
+		return time.ParseInLocation(localDateTimeFormat, value, loc)
+	}
+}
@@ -0,0 +1,168 @@
+package ical
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAll(t *testing.T, file string) ([]Todo, error) {
+	t.Helper()
+	dec := NewDecoder(strings.NewReader(file))
+	var todos []Todo
+	for {
+		todo, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			return todos, nil
+		}
+		var valueErr *ValueError
+		if err != nil && !errors.As(err, &valueErr) {
+			return todos, err
+		}
+		todos = append(todos, todo)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	file := "\ufeffBEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Other App//EN\r\n" +
+		"BEGIN:VTIMEZONE\r\n" +
+		"TZID:Europe/Berlin\r\n" +
+		"BEGIN:STANDARD\r\n" +
+		"DTSTART:19701025T030000\r\n" +
+		"END:STANDARD\r\n" +
+		"END:VTIMEZONE\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:event-1\r\n" +
+		"SUMMARY:Not a to-do\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:todo-1@other\r\n" +
+		"DTSTAMP:20260301T080000Z\r\n" +
+		"DTSTART;TZID=Europe/Berlin:20260302T090000\r\n" +
+		"SUMMARY;LANGUAGE=en:Buy milk\\, eggs and a very long list of other things th\r\n" +
+		" at a folded line continues\r\n" +
+		"STATUS:needs-action\r\n" +
+		"BEGIN:VALARM\r\n" +
+		"ACTION:DISPLAY\r\n" +
+		"END:VALARM\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\n" +
+		"uid:todo-2@other\n" +
+		"summary:Call \"Bob\"; maybe\n" +
+		"COMPLETED:20260303T101500Z\n" +
+		"DTSTART;VALUE=DATE:20260303\n" +
+		"END:VTODO\n" +
+		"END:VCALENDAR\r\n"
+
+	todos, err := decodeAll(t, file)
+
+	require.NoError(t, err)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	require.Len(t, todos, 2)
+	assert.Equal(t, Todo{
+		UID:     "todo-1@other",
+		Summary: "Buy milk, eggs and a very long list of other things that a folded line continues",
+		Status:  StatusNeedsAction,
+		Stamp:   time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		Start:   time.Date(2026, 3, 2, 9, 0, 0, 0, berlin),
+	}, todos[0])
+	assert.False(t, todos[0].Done())
+	assert.Equal(t, "Call \"Bob\"; maybe", todos[1].Summary)
+	assert.Equal(t, time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), todos[1].Start)
+	assert.True(t, todos[1].Done())
+}
+
+func TestDecoder_RoundTrip(t *testing.T) {
+	todo := Todo{
+		UID:          "task-7@example.com",
+		Summary:      "Ünïcödé, \\ and; line\nbreaks " + strings.Repeat("long ", 30),
+		Status:       StatusCompleted,
+		Created:      time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		LastModified: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+		Stamp:        time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+		Start:        time.Date(2026, 3, 5, 7, 0, 0, 0, time.UTC),
+		Completed:    time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+	}
+	var out strings.Builder
+	enc := NewEncoder(&out, "-//Example//Tasks//EN", "")
+	require.NoError(t, enc.Encode(todo))
+	require.NoError(t, enc.Encode(Todo{UID: "task-8@example.com", Stamp: todo.Stamp}))
+	require.NoError(t, enc.Close())
+
+	todos, err := decodeAll(t, out.String())
+
+	require.NoError(t, err)
+	assert.Equal(t, []Todo{todo, {UID: "task-8@example.com", Stamp: todo.Stamp}}, todos)
+}
+
+func TestDecoder_ValueError(t *testing.T) {
+	file := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:First\r\nDTSTART:tomorrow\r\nEND:VTODO\r\n" +
+		"BEGIN:VTODO\r\nSUMMARY:Second\r\nEND:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	dec := NewDecoder(strings.NewReader(file))
+
+	todo, err := dec.Next()
+	var valueErr *ValueError
+	require.ErrorAs(t, err, &valueErr)
+	assert.Equal(t, 4, valueErr.Line)
+	assert.Equal(t, "DTSTART", valueErr.Property)
+	assert.Equal(t, "First", todo.Summary)
+
+	todo, err = dec.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "Second", todo.Summary)
+	_, err = dec.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestDecoder_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		line int
+	}{
+		{"empty", "", 1},
+		{"not a calendar", "BEGIN:VTODO\r\nEND:VTODO\r\n", 1},
+		{"missing colon", "BEGIN:VCALENDAR\r\nSUMMARY\r\n", 2},
+		{"unterminated quote", "BEGIN:VCALENDAR\r\nX-A;P=\"x:y\r\n", 2},
+		{"continuation first", " BEGIN:VCALENDAR\r\n", 1},
+		{"unterminated todo", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:x\r\n", 4},
+		{"unterminated calendar", "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n", 3},
+		{"mismatched end", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nEND:VEVENT\r\n", 3},
+		{"nested too deeply", "BEGIN:VCALENDAR\r\n" + strings.Repeat("BEGIN:X\r\n", maxDepth+1), maxDepth + 2},
+		{"line too long", "BEGIN:VCALENDAR\r\nSUMMARY:" + strings.Repeat("x", maxLineBytes) + "\r\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeAll(t, tt.file)
+
+			var syntaxErr *SyntaxError
+			require.ErrorAs(t, err, &syntaxErr)
+			assert.Equal(t, tt.line, syntaxErr.Line)
+		})
+	}
+}
+
+func TestParseContentLine(t *testing.T) {
+	cl, ok := parseContentLine(`attendee;Role=REQ-PARTICIPANT;DELEGATED-FROM="mailto:a@x.com","mailto:b@x.com":mailto:c@x.com`)
+
+	require.True(t, ok)
+	assert.Equal(t, "ATTENDEE", cl.name)
+	assert.Equal(t, []string{"REQ-PARTICIPANT"}, cl.params["ROLE"])
+	assert.Equal(t, []string{"mailto:a@x.com", "mailto:b@x.com"}, cl.params["DELEGATED-FROM"])
+	assert.Equal(t, "mailto:c@x.com", cl.value)
+
+	for _, line := range []string{":value", "NA ME:value", "NAME;=x:value", "NAME;P=x", `NAME;P="x"y:value`} {
+		_, ok := parseContentLine(line)
+		assert.False(t, ok, line)
+	}
+}
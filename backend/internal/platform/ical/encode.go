@@ -0,0 +1,107 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is how long a content line may be, not counting the line
+// break. Longer lines are folded.
+const maxLineOctets = 75
+
+// Encoder writes a VCALENDAR with one VTODO per Encode call. Close ends
+// the calendar and must be called even if no to-do was written.
+type Encoder struct {
+	w       *bufio.Writer
+	prodID  string
+	name    string
+	started bool
+	now     func() time.Time
+}
+
+// NewEncoder returns an encoder of a calendar with the product identifier
+// prodID, such as "-//Example Corp//Tasks//EN", and a display name, which
+// may be empty.
+func NewEncoder(w io.Writer, prodID, name string) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), prodID: prodID, name: name, now: time.Now}
+}
+
+func (e *Encoder) start() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", escapeText(e.prodID))
+	e.line("CALSCALE", "GREGORIAN")
+	if e.name != "" {
+		e.line("X-WR-CALNAME", escapeText(e.name))
+	}
+}
+
+// Encode writes todo. DTSTAMP, which every to-do needs, is the current
+// time if todo.Stamp is zero.
+func (e *Encoder) Encode(todo Todo) error {
+	e.start()
+	stamp := todo.Stamp
+	if stamp.IsZero() {
+		stamp = e.now()
+	}
+	e.line("BEGIN", "VTODO")
+	e.line("UID", escapeText(todo.UID))
+	e.line("DTSTAMP", formatDateTime(stamp))
+	e.dateTime("CREATED", todo.Created)
+	e.dateTime("LAST-MODIFIED", todo.LastModified)
+	e.dateTime("DTSTART", todo.Start)
+	if todo.Summary != "" {
+		e.line("SUMMARY", escapeText(todo.Summary))
+	}
+	if todo.Status != "" {
+		e.line("STATUS", string(todo.Status))
+	}
+	e.dateTime("COMPLETED", todo.Completed)
+	e.line("END", "VTODO")
+	// Flush every to-do, so that a feed is streamed as it is encoded.
+	return e.w.Flush()
+}
+
+func (e *Encoder) Close() error {
+	e.start()
+	e.line("END", "VCALENDAR")
+	return e.w.Flush()
+}
+
+func (e *Encoder) dateTime(name string, t time.Time) {
+	if !t.IsZero() {
+		e.line(name, formatDateTime(t))
+	}
+}
+
+func formatDateTime(t time.Time) string {
+	return t.UTC().Format(dateTimeFormat)
+}
+
+// line writes the content line "name:value", folded after every 75 octets.
+// Continuation lines start with a space, which counts towards their
+// length. Lines are only folded between characters, never inside the
+// UTF-8 encoding of one. Errors stick in the buffered writer and are
+// returned by Flush.
+func (e *Encoder) line(name, value string) {
+	s := name + ":" + value
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.w.WriteString(s[:cut])
+		e.w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1
+	}
+	e.w.WriteString(s)
+	e.w.WriteString("\r\n")
+}
@@ -0,0 +1,111 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out, "-//Example//Tasks//EN", "Tasks")
+	created := time.Date(2026, 3, 1, 8, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	require.NoError(t, enc.Encode(Todo{
+		UID:     "task-1@example.com",
+		Summary: "Milk, eggs; bread\\jam\nand tea",
+		Status:  StatusCompleted,
+		Created: created,
+		Stamp:   created.Add(time.Hour),
+	}))
+	require.NoError(t, enc.Close())
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//Example//Tasks//EN\r\n"+
+		"CALSCALE:GREGORIAN\r\n"+
+		"X-WR-CALNAME:Tasks\r\n"+
+		"BEGIN:VTODO\r\n"+
+		"UID:task-1@example.com\r\n"+
+		"DTSTAMP:20260301T080000Z\r\n"+
+		"CREATED:20260301T070000Z\r\n"+
+		"SUMMARY:Milk\\, eggs\\; bread\\\\jam\\nand tea\r\n"+
+		"STATUS:COMPLETED\r\n"+
+		"END:VTODO\r\n"+
+		"END:VCALENDAR\r\n", out.String())
+}
+
+func TestEncoder_Empty(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, NewEncoder(&out, "-//Example//Tasks//EN", "").Close())
+
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Example//Tasks//EN\r\nCALSCALE:GREGORIAN\r\nEND:VCALENDAR\r\n", out.String())
+}
+
+func TestEncoder_StampsWithCurrentTime(t *testing.T) {
+	var out bytes.Buffer
+	enc := NewEncoder(&out, "-//Example//Tasks//EN", "")
+	enc.now = func() time.Time { return time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, enc.Encode(Todo{UID: "1"}))
+
+	assert.Contains(t, out.String(), "\r\nDTSTAMP:20260301T080000Z\r\n")
+}
+
+func TestEncoder_FoldsLongLines(t *testing.T) {
+	// Two-octet and four-octet characters make some folds fall inside a
+	// character, which must move them before it.
+	summary := strings.Repeat("ab", 30) + strings.Repeat("é", 40) + strings.Repeat("😀", 30) + strings.Repeat("x", 100)
+	var out bytes.Buffer
+	enc := NewEncoder(&out, "-//Example//Tasks//EN", "")
+	require.NoError(t, enc.Encode(Todo{UID: "1", Summary: summary}))
+	require.NoError(t, enc.Close())
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\r\n"), "\r\n")
+	folded := 0
+	for _, line := range lines {
+		assert.LessOrEqual(t, len(line), maxLineOctets, line)
+		assert.True(t, utf8.ValidString(line), line)
+		assert.NotContains(t, line, "\n")
+		if strings.HasPrefix(line, " ") {
+			folded++
+		}
+	}
+	assert.Greater(t, folded, 3)
+
+	todo, err := NewDecoder(&out).Next()
+	require.NoError(t, err)
+	assert.Equal(t, summary, todo.Summary)
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct{ text, want string }{
+		{"plain", "plain"},
+		{`a\b`, `a\\b`},
+		{"a,b;c", `a\,b\;c`},
+		{"line\r\nbreak\nand\rcr", `line\nbreak\nand\ncr`},
+		{"tab\there\x00\x1f\x7f", "tab\there"},
+		{"ünï©ødé: colons stay", "ünï©ødé: colons stay"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeText(tt.text), tt.text)
+	}
+}
+
+func TestUnescapeText(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{`a\\b`, `a\b`},
+		{`a\,b\;c`, "a,b;c"},
+		{`one\ntwo\Nthree`, "one\ntwo\nthree"},
+		// Backslashes that escape nothing are kept.
+		{`C:\temp\`, `C:\temp\`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, unescapeText(tt.value), tt.value)
+	}
+}
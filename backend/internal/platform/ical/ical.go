@@ -0,0 +1,91 @@
+// Package ical encodes and decodes to-dos in the iCalendar format of RFC
+// 5545. Only VTODO components and the properties of Todo are supported;
+// other components and properties are skipped when decoding.
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+// Status is the STATUS of a to-do.
+type Status string
+
+const (
+	StatusNeedsAction Status = "NEEDS-ACTION"
+	StatusCompleted   Status = "COMPLETED"
+	StatusInProcess   Status = "IN-PROCESS"
+	StatusCancelled   Status = "CANCELLED"
+)
+
+// Todo is a VTODO component. Zero values stand for absent properties.
+// Stamp is DTSTAMP, when the to-do was last revised, Start is DTSTART and
+// Completed is COMPLETED, when the to-do was completed.
+type Todo struct {
+	UID          string
+	Summary      string
+	Status       Status
+	Created      time.Time
+	LastModified time.Time
+	Stamp        time.Time
+	Start        time.Time
+	Completed    time.Time
+}
+
+// Done reports whether the to-do was completed.
+func (t Todo) Done() bool {
+	return t.Status == StatusCompleted || !t.Completed.IsZero()
+}
+
+// dateTimeFormat is the form of UTC date-times. dateFormat and
+// localDateTimeFormat are only decoded.
+const (
+	dateTimeFormat      = "20060102T150405Z"
+	localDateTimeFormat = "20060102T150405"
+	dateFormat          = "20060102"
+)
+
+// escapeText escapes a TEXT value. Line breaks become "\n"; other control
+// characters are not allowed in values and are dropped.
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == ';' || r == ',':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\r':
+			b.WriteString(`\n`)
+		case r == '\t' || (r >= 0x20 && r != 0x7f):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// unescapeText undoes escapeText. A backslash before any other character
+// is kept, since some producers do not escape it.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; next {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		case '\\', ';', ',':
+			b.WriteByte(next)
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(next)
+		}
+		i++
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// keysetFile is the JSON manifest read by LoadKeyset:
+//
+//	{
+//	  "issuer": "todo-app",
+//	  "active": "2026-10",
+//	  "keys": [
+//	    {"kid": "2026-10", "file": "2026-10.pem"},
+//	    {"kid": "2026-07", "file": "2026-07.pem", "retires_at": "2026-11-01T00:00:00Z"}
+//	  ]
+//	}
+//
+// Key files hold PKCS#8 (or PKCS#1 RSA) private keys in PEM format and are
+// resolved relative to the manifest.
+type keysetFile struct {
+	Issuer string `json:"issuer"`
+	Active string `json:"active"`
+	Keys   []struct {
+		KID       string     `json:"kid"`
+		File      string     `json:"file"`
+		RetiresAt *time.Time `json:"retires_at"`
+	} `json:"keys"`
+}
+
+// LoadKeyset reads a keyset manifest and its key files. issuer is used when
+// the manifest does not name one.
+func LoadKeyset(path, issuer string) (*Manager, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keyset: %w", err)
+	}
+
+	var manifest keysetFile
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error parsing keyset: %w", err)
+	}
+	if manifest.Issuer != "" {
+		issuer = manifest.Issuer
+	}
+
+	dir := filepath.Dir(path)
+	keys := make([]Key, 0, len(manifest.Keys))
+	for _, entry := range manifest.Keys {
+		keyPath := entry.File
+		if !filepath.IsAbs(keyPath) {
+			keyPath = filepath.Join(dir, keyPath)
+		}
+		signer, err := readPrivateKey(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", entry.KID, err)
+		}
+		k := Key{ID: entry.KID, PrivateKey: signer}
+		if entry.RetiresAt != nil {
+			k.RetiresAt = *entry.RetiresAt
+		}
+		keys = append(keys, k)
+	}
+
+	return NewManager(issuer, manifest.Active, keys)
+}
+
+func readPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	return signer, nil
+}
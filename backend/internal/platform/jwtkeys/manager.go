@@ -0,0 +1,187 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeys           = errors.New("no signing keys configured")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrUnsupportedKey   = errors.New("unsupported key type, expected RSA or Ed25519")
+	ErrActiveKeyRetired = errors.New("active signing key is retired")
+)
+
+// Key is a signing key identified by a key ID (kid).
+type Key struct {
+	ID         string
+	PrivateKey crypto.Signer
+	// RetiresAt is when the key stops being accepted for validation. The
+	// zero value means the key never retires.
+	RetiresAt time.Time
+}
+
+func (k Key) method() (jwt.SigningMethod, error) {
+	switch k.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func (k Key) retired(now time.Time) bool {
+	return !k.RetiresAt.IsZero() && !now.Before(k.RetiresAt)
+}
+
+// Manager signs tokens with the active key and validates tokens against
+// every key that has not retired yet.
+type Manager struct {
+	mu     sync.RWMutex
+	keys   map[string]Key
+	active string
+	issuer string
+	now    func() time.Time
+}
+
+// NewManager creates a Manager that signs with the key activeKID. issuer is
+// set as the "iss" claim of issued tokens.
+func NewManager(issuer, activeKID string, keys []Key) (*Manager, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	m := &Manager{keys: make(map[string]Key, len(keys)), issuer: issuer, now: time.Now}
+	for _, k := range keys {
+		if k.ID == "" {
+			return nil, errors.New("signing key without kid")
+		}
+		if _, err := k.method(); err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.ID, err)
+		}
+		if _, dup := m.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate kid %q", k.ID)
+		}
+		m.keys[k.ID] = k
+	}
+
+	if err := m.SetActive(activeKID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// NewEphemeralManager creates a Manager with a freshly generated Ed25519
+// key. Tokens do not survive a restart and are not shared between replicas,
+// so it is only suitable for development and tests.
+func NewEphemeralManager(issuer string) (*Manager, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid := fmt.Sprintf("ephemeral-%d", time.Now().Unix())
+	return NewManager(issuer, kid, []Key{{ID: kid, PrivateKey: priv}})
+}
+
+// SetActive switches signing to the key kid.
+func (m *Manager) SetActive(kid string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	if k.retired(m.now()) {
+		return fmt.Errorf("%w: %q", ErrActiveKeyRetired, kid)
+	}
+	m.active = kid
+	return nil
+}
+
+// Issuer returns the "iss" claim set on issued tokens.
+func (m *Manager) Issuer() string {
+	return m.issuer
+}
+
+// Sign signs claims with the active key and sets the "kid" header.
+func (m *Manager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	k := m.keys[m.active]
+	m.mu.RUnlock()
+
+	if k.retired(m.now()) {
+		return "", fmt.Errorf("%w: %q", ErrActiveKeyRetired, k.ID)
+	}
+
+	method, err := k.method()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = k.ID
+	return token.SignedString(k.PrivateKey)
+}
+
+// Keyfunc resolves the verification key for a token by its "kid" header. It
+// rejects retired keys and algorithms that do not match the key.
+func (m *Manager) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	m.mu.RLock()
+	k, ok := m.keys[kid]
+	m.mu.RUnlock()
+
+	if !ok || k.retired(m.now()) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+	}
+	method, err := k.method()
+	if err != nil {
+		return nil, err
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return k.PrivateKey.Public(), nil
+}
+
+// Algorithms lists the JWS algorithms of the configured keys, for use with
+// jwt.WithValidMethods.
+func (m *Manager) Algorithms() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var algs []string
+	for _, k := range m.keys {
+		method, _ := k.method()
+		if !seen[method.Alg()] {
+			seen[method.Alg()] = true
+			algs = append(algs, method.Alg())
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// Parse validates tokenString against the non-retired keys and the issuer
+// and decodes it into claims.
+func (m *Manager) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, m.Keyfunc,
+		jwt.WithValidMethods(m.Algorithms()),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now),
+	)
+}
@@ -0,0 +1,158 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClaims(issuer string) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Issuer:    issuer,
+		Subject:   "1",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func TestManager_SignAndParse(t *testing.T) {
+	for name, key := range map[string]Key{
+		"RS256": {ID: "rsa", PrivateKey: newRSAKey(t)},
+		"EdDSA": {ID: "ed", PrivateKey: newEd25519Key(t)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewManager("todo-app", key.ID, []Key{key})
+			require.NoError(t, err)
+
+			signed, err := m.Sign(testClaims("todo-app"))
+			require.NoError(t, err)
+
+			var claims jwt.RegisteredClaims
+			token, err := m.Parse(signed, &claims)
+			require.NoError(t, err)
+			assert.Equal(t, key.ID, token.Header["kid"])
+			assert.Equal(t, name, token.Method.Alg())
+			assert.Equal(t, "1", claims.Subject)
+		})
+	}
+}
+
+func TestManager_Rotation(t *testing.T) {
+	old := Key{ID: "old", PrivateKey: newRSAKey(t)}
+	current := Key{ID: "new", PrivateKey: newEd25519Key(t)}
+
+	m, err := NewManager("todo-app", "old", []Key{old, current})
+	require.NoError(t, err)
+	oldToken, err := m.Sign(testClaims("todo-app"))
+	require.NoError(t, err)
+
+	require.NoError(t, m.SetActive("new"))
+	newToken, err := m.Sign(testClaims("todo-app"))
+	require.NoError(t, err)
+
+	var claims jwt.RegisteredClaims
+	_, err = m.Parse(oldToken, &claims)
+	assert.NoError(t, err, "tokens signed with a key that has not retired stay valid")
+	_, err = m.Parse(newToken, &claims)
+	assert.NoError(t, err)
+
+	// Once the old key retires its tokens are rejected and it leaves the JWKS.
+	m.now = func() time.Time { return time.Now().Add(time.Minute) }
+	m.keys["old"] = Key{ID: "old", PrivateKey: old.PrivateKey, RetiresAt: time.Now()}
+	_, err = m.Parse(oldToken, &claims)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+	assert.Len(t, m.JWKS().Keys, 1)
+	assert.Equal(t, "new", m.JWKS().Keys[0].Kid)
+}
+
+func TestManager_RejectsForeignAndMismatchedTokens(t *testing.T) {
+	m, err := NewManager("todo-app", "a", []Key{{ID: "a", PrivateKey: newEd25519Key(t)}})
+	require.NoError(t, err)
+
+	other, err := NewManager("todo-app", "a", []Key{{ID: "a", PrivateKey: newEd25519Key(t)}})
+	require.NoError(t, err)
+	foreign, err := other.Sign(testClaims("todo-app"))
+	require.NoError(t, err)
+
+	var claims jwt.RegisteredClaims
+	_, err = m.Parse(foreign, &claims)
+	assert.Error(t, err)
+
+	wrongIssuer, err := m.Sign(testClaims("someone-else"))
+	require.NoError(t, err)
+	_, err = m.Parse(wrongIssuer, &claims)
+	assert.Error(t, err)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, testClaims("todo-app"))
+	hmac.Header["kid"] = "a"
+	hmacToken, err := hmac.SignedString([]byte(""))
+	require.NoError(t, err)
+	_, err = m.Parse(hmacToken, &claims)
+	assert.Error(t, err)
+}
+
+func TestNewManager_Validation(t *testing.T) {
+	_, err := NewManager("todo-app", "a", nil)
+	assert.ErrorIs(t, err, ErrNoKeys)
+
+	_, err = NewManager("todo-app", "missing", []Key{{ID: "a", PrivateKey: newEd25519Key(t)}})
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = NewManager("todo-app", "a", []Key{{ID: "a", PrivateKey: newEd25519Key(t), RetiresAt: time.Now().Add(-time.Hour)}})
+	assert.ErrorIs(t, err, ErrActiveKeyRetired)
+}
+
+func TestLoadKeyset(t *testing.T) {
+	dir := t.TempDir()
+
+	rsaDER, err := x509.MarshalPKCS8PrivateKey(newRSAKey(t))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rsa.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: rsaDER}), 0o600))
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(newEd25519Key(t))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ed.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}), 0o600))
+
+	manifest := `{
+		"issuer": "https://todo.example.com",
+		"active": "2026-10",
+		"keys": [
+			{"kid": "2026-10", "file": "ed.pem"},
+			{"kid": "2026-07", "file": "rsa.pem", "retires_at": "2099-01-01T00:00:00Z"}
+		]
+	}`
+	path := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(path, []byte(manifest), 0o600))
+
+	m, err := LoadKeyset(path, "todo-app")
+	require.NoError(t, err)
+	assert.Equal(t, "https://todo.example.com", m.Issuer())
+	assert.Equal(t, []string{"EdDSA", "RS256"}, m.Algorithms())
+
+	jwks := m.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, JWK{Kty: "RSA", Kid: "2026-07", Use: "sig", Alg: "RS256", N: jwks.Keys[0].N, E: "AQAB"}, jwks.Keys[0])
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+}
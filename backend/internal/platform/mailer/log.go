@@ -0,0 +1,20 @@
+package mailer
+
+import (
+	"context"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/logging"
+)
+
+// LogMailer writes outgoing email to the structured log instead of sending
+// it. It is meant for local development.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logging.ContextLogger(ctx).Info("Mail sent", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewFromEnv builds a Mailer based on the MAILER_DRIVER environment variable.
+// Supported drivers are "smtp", "file" and "log" (the default).
+func NewFromEnv() (Mailer, error) {
+	switch driver := os.Getenv("MAILER_DRIVER"); driver {
+	case "", "log":
+		return NewLogMailer(), nil
+	case "file":
+		dir := os.Getenv("MAILER_FILE_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFileMailer(dir)
+	case "smtp":
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", driver)
+	}
+}
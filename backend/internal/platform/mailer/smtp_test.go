@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveSMTP accepts one connection on a local listener and hands it to
+// handle. It returns the host and port to dial.
+func serveSMTP(t *testing.T, handle func(conn net.Conn)) (string, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+	host, port, err := net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return host, port
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	received := make(chan string, 1)
+	host, port := serveSMTP(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case inData && line == ".\r\n":
+				inData = false
+				received <- data.String()
+				reply("250 OK")
+			case inData:
+				data.WriteString(line)
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(line, "DATA"):
+				inData = true
+				reply("354 go ahead")
+			case strings.HasPrefix(line, "QUIT"):
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	})
+	m, err := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "noreply@example.com"})
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi", Body: "Hello"})
+
+	require.NoError(t, err)
+	assert.Contains(t, <-received, "Subject: Hi\r\n")
+}
+
+func TestSMTPMailer_Send_GivesUpOnSilentRelay(t *testing.T) {
+	host, port := serveSMTP(t, func(conn net.Conn) {
+		// Never greet; wait until the client hangs up.
+		_, _ = conn.Read(make([]byte, 1))
+	})
+	m, err := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "noreply@example.com", Timeout: time.Minute})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = m.Send(ctx, Message{To: "user@example.com", Subject: "Hi", Body: "Hello"})
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	m.timeout = 100 * time.Millisecond
+	start = time.Now()
+	err = m.Send(context.Background(), Message{To: "user@example.com", Subject: "Hi", Body: "Hello"})
+
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
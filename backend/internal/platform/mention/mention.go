@@ -0,0 +1,142 @@
+// Package mention finds @username mentions in Markdown text.
+package mention
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Parse returns the distinct usernames mentioned in the Markdown text body,
+// in order of first appearance. A mention is an "@" followed by letters,
+// digits, "_", "." or "-"; a trailing "." is treated as punctuation. Mentions
+// inside fenced code blocks and inline code spans, escaped ones ("\@") and
+// "@" signs directly after a letter or digit, such as in email addresses,
+// are ignored. Code spans are only recognised within a single line.
+func Parse(body string) []string {
+	var names []string
+	seen := map[string]bool{}
+	var fence string
+
+	for _, line := range strings.Split(body, "\n") {
+		if marker := fenceMarker(line); marker != "" {
+			switch {
+			case fence == "":
+				fence = marker
+				continue
+			case strings.HasPrefix(marker, fence[:1]) && len(marker) >= len(fence):
+				fence = ""
+				continue
+			}
+		}
+		if fence != "" {
+			continue
+		}
+
+		for _, name := range parseLine(line) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// fenceMarker returns the run of backticks or tildes that opens or closes a
+// fenced code block on line, or "" if the line is not a fence.
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	c := trimmed[0]
+	if c != '`' && c != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == c {
+		n++
+	}
+	if n < 3 {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+func parseLine(line string) []string {
+	var names []string
+	prev := ' '
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == '\\' && i+1 < len(line):
+			// The escaped character is literal text.
+			i += 2
+			prev = 'x'
+			continue
+		case c == '`':
+			if end := codeSpanEnd(line, i); end > 0 {
+				i = end
+				prev = '`'
+				continue
+			}
+			for i < len(line) && line[i] == '`' {
+				i++
+			}
+			prev = '`'
+			continue
+		case c == '@' && !unicode.IsLetter(prev) && !unicode.IsDigit(prev):
+			j := i + 1
+			for j < len(line) {
+				r, size := utf8.DecodeRuneInString(line[j:])
+				if !isNameRune(r) {
+					break
+				}
+				j += size
+			}
+			if name := strings.TrimRight(line[i+1:j], "."); name != "" {
+				names = append(names, name)
+			}
+			if j > i+1 {
+				r, _ := utf8.DecodeLastRuneInString(line[:j])
+				prev = r
+			} else {
+				prev = '@'
+			}
+			i = j
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[i:])
+		prev = r
+		i += size
+	}
+	return names
+}
+
+// codeSpanEnd returns the index after the backtick run that closes the code
+// span opened at start, or 0 if the span is not closed on this line.
+func codeSpanEnd(line string, start int) int {
+	n := 0
+	for start+n < len(line) && line[start+n] == '`' {
+		n++
+	}
+	for i := start + n; i < len(line); {
+		if line[i] != '`' {
+			i++
+			continue
+		}
+		m := 0
+		for i+m < len(line) && line[i+m] == '`' {
+			m++
+		}
+		if m == n {
+			return i + m
+		}
+		i += m
+	}
+	return 0
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
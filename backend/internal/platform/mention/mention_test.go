@@ -0,0 +1,36 @@
+package mention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"single", "@alice please check", []string{"alice"}},
+		{"several in order without duplicates", "@bob and @alice, then @bob again", []string{"bob", "alice"}},
+		{"trailing punctuation", "Thanks @alice.", []string{"alice"}},
+		{"dots and dashes inside names", "cc @jane.doe-2 (@x_y)", []string{"jane.doe-2", "x_y"}},
+		{"email addresses", "mail alice@example.com", nil},
+		{"escaped", `\@alice is not pinged`, nil},
+		{"lone at sign", "meet @ 5pm", nil},
+		{"markdown emphasis", "**@alice** and *@bob*", []string{"alice", "bob"}},
+		{"inline code", "run `@alice` but ping @bob", []string{"bob"}},
+		{"double backtick code", "``a ` @alice`` @bob", []string{"bob"}},
+		{"unclosed backtick", "` @alice", []string{"alice"}},
+		{"fenced code", "```\n@alice\n```\n@bob", []string{"bob"}},
+		{"tilde fence with longer close", "~~~go\n@alice\n~~~~\n@bob", []string{"bob"}},
+		{"backticks do not close tilde fence", "~~~\n```\n@alice\n~~~\n@bob", []string{"bob"}},
+		{"unicode", "@zoë", []string{"zoë"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.body))
+		})
+	}
+}
@@ -1,18 +1,50 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
 	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
 )
 
-// AuthMiddleware validates the JWT token from the request header.
-func AuthMiddleware() gin.HandlerFunc {
+// RevocationChecker reports whether a token issued to a third-party client
+// has been revoked. Tokens issued at login are not checked.
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// AccountChecker returns the current authorization state of a user, or
+// rbac.ErrAccountNotFound if the user no longer exists.
+type AccountChecker interface {
+	AccountStatus(ctx context.Context, userID int) (*rbac.Account, error)
+}
+
+// AuthMiddleware validates the JWT token from the request header against the
+// signing keys of keys. For tokens issued to third-party clients it also
+// consults revocations and records the client and granted scopes as
+// "clientID" and "scopes" in the context.
+//
+// The user's account is looked up through accounts on every request, so
+// disabled and deleted users are rejected even while their tokens are still
+// valid. The current role and permissions are recorded as "role" and
+// "permissions"; third-party clients never receive permissions.
+func AuthMiddleware(keys *jwtkeys.Manager, revocations RevocationChecker, accounts AccountChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
+		if tokenString == "" {
+			tokenString = websocketToken(c.Request)
+		}
 		if tokenString == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
@@ -20,17 +52,124 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		// Remove "Bearer " prefix
-		tokenString = tokenString[len("Bearer "):]
+		tokenString, ok := strings.CutPrefix(tokenString, "Bearer ")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
 
-		userID, err := utils.ValidateToken(tokenString)
+		claims, err := utils.ParseToken(keys, tokenString)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
-		slog.Info("Authenticated user", "userID", userID)
-		c.Set("userID", userID)
+		account, err := accounts.AccountStatus(c.Request.Context(), claims.UserID)
+		if errors.Is(err, rbac.ErrAccountNotFound) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to look up account", "userID", claims.UserID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.Abort()
+			return
+		}
+		if account.Disabled {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+			c.Abort()
+			return
+		}
+		c.Set("role", account.Role)
+
+		if claims.Delegated() {
+			revoked, err := revocations.IsTokenRevoked(c.Request.Context(), claims.ID)
+			if err != nil {
+				slog.Error("Failed to check token revocation", "error", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				c.Abort()
+				return
+			}
+			c.Set("clientID", claims.ClientID)
+			c.Set("scopes", scope.Parse(claims.Scope))
+		} else {
+			c.Set("permissions", account.Permissions)
+		}
+
+		slog.Info("Authenticated user", "userID", claims.UserID, "clientID", claims.ClientID)
+		c.Set("userID", claims.UserID)
+		c.Next()
+	}
+}
+
+// websocketToken returns the token of a WebSocket handshake that offers it
+// as a "bearer.<token>" subprotocol, as browsers cannot set the
+// Authorization header on handshakes, in the form of that header.
+func websocketToken(r *http.Request) string {
+	if !websocket.IsWebSocketUpgrade(r) {
+		return ""
+	}
+	for _, protocol := range websocket.Subprotocols(r) {
+		if token, ok := strings.CutPrefix(protocol, "bearer."); ok {
+			return "Bearer " + token
+		}
+	}
+	return ""
+}
+
+// HasScope reports whether the token of the request was granted s. Tokens
+// issued at login have every scope.
+func HasScope(c *gin.Context, s string) bool {
+	if _, delegated := c.Get("clientID"); !delegated {
+		return true
+	}
+	return slices.Contains(c.GetStringSlice("scopes"), s)
+}
+
+// RequireScope lets tokens issued to third-party clients through only if
+// they were granted s. Tokens issued at login have full access.
+func RequireScope(s string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !HasScope(c, s) {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, s))
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient_scope", "scope": s})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission lets a request through only if the user's role grants
+// permission p. Tokens issued to third-party clients are always rejected.
+func RequirePermission(p string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !rbac.Has(c.GetStringSlice("permissions"), p) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied", "permission": p})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// FirstPartyOnly rejects tokens issued to third-party clients, for routes
+// such as account management that no scope grants access to.
+func FirstPartyOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, delegated := c.Get("clientID"); delegated {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available to third-party applications"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/rbac"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/scope"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/utils"
+)
+
+type revocationList map[string]bool
+
+func (r revocationList) IsTokenRevoked(_ context.Context, jti string) (bool, error) {
+	return r[jti], nil
+}
+
+// accountList maps user IDs to accounts; missing users do not exist.
+type accountList map[int]*rbac.Account
+
+func (a accountList) AccountStatus(_ context.Context, userID int) (*rbac.Account, error) {
+	account, ok := a[userID]
+	if !ok {
+		return nil, rbac.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func delegatedToken(t *testing.T, keys *jwtkeys.Manager, userID int, jti, scopes string) string {
+	t.Helper()
+	token, err := keys.Sign(utils.Claims{
+		UserID:   userID,
+		ClientID: "client-1",
+		Scope:    scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    keys.Issuer(),
+			Subject:   strconv.Itoa(userID),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+	return token
+}
+
+func newTestRouter(keys *jwtkeys.Manager, revocations RevocationChecker, accounts AccountChecker) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("/api", AuthMiddleware(keys, revocations, accounts))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/tasks", RequireScope(scope.TasksRead), ok)
+	api.POST("/tasks", RequireScope(scope.TasksWrite), ok)
+	api.POST("/account/password", FirstPartyOnly(), ok)
+	api.GET("/admin/users", RequirePermission(rbac.UsersRead), ok)
+	return router
+}
+
+// activeUser is the account of user 1 in most tests.
+var activeUser = accountList{1: {Role: rbac.RoleUser}}
+
+func doRequest(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthMiddleware_ScopeRules(t *testing.T) {
+	keys, err := jwtkeys.NewEphemeralManager("test")
+	require.NoError(t, err)
+	router := newTestRouter(keys, revocationList{"revoked": true}, activeUser)
+
+	firstParty, err := utils.GenerateToken(keys, 1, rbac.RoleUser, nil)
+	require.NoError(t, err)
+	readOnly := delegatedToken(t, keys, 1, "read", scope.TasksRead)
+	revoked := delegatedToken(t, keys, 1, "revoked", scope.TasksRead+" "+scope.TasksWrite)
+	noJTI := delegatedToken(t, keys, 1, "", scope.TasksRead)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{"missing token", http.MethodGet, "/api/tasks", "", http.StatusUnauthorized},
+		{"first-party reads", http.MethodGet, "/api/tasks", firstParty, http.StatusOK},
+		{"first-party writes", http.MethodPost, "/api/tasks", firstParty, http.StatusOK},
+		{"first-party account", http.MethodPost, "/api/account/password", firstParty, http.StatusOK},
+		{"delegated read with scope", http.MethodGet, "/api/tasks", readOnly, http.StatusOK},
+		{"delegated write without scope", http.MethodPost, "/api/tasks", readOnly, http.StatusForbidden},
+		{"delegated account access", http.MethodPost, "/api/account/password", readOnly, http.StatusForbidden},
+		{"revoked delegated token", http.MethodGet, "/api/tasks", revoked, http.StatusUnauthorized},
+		{"delegated token without jti", http.MethodGet, "/api/tasks", noJTI, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(router, tt.method, tt.path, tt.token)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestRequireScope_WWWAuthenticate(t *testing.T) {
+	keys, err := jwtkeys.NewEphemeralManager("test")
+	require.NoError(t, err)
+	router := newTestRouter(keys, revocationList{}, activeUser)
+
+	w := doRequest(router, http.MethodPost, "/api/tasks", delegatedToken(t, keys, 1, "read", scope.TasksRead))
+
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="tasks:write"`, w.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuthMiddleware_AccountRules(t *testing.T) {
+	keys, err := jwtkeys.NewEphemeralManager("test")
+	require.NoError(t, err)
+	accounts := accountList{
+		1: {Role: rbac.RoleUser},
+		2: {Role: rbac.RoleAdmin, Permissions: []string{rbac.UsersRead}},
+		3: {Role: rbac.RoleAdmin, Permissions: []string{rbac.UsersRead}, Disabled: true},
+	}
+	router := newTestRouter(keys, revocationList{}, accounts)
+
+	token := func(userID int, role string, permissions ...string) string {
+		signed, err := utils.GenerateToken(keys, userID, role, permissions)
+		require.NoError(t, err)
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		token string
+		want  int
+	}{
+		{"user without permission", "/api/admin/users", token(1, rbac.RoleUser), http.StatusForbidden},
+		{"stale admin claims are ignored", "/api/admin/users", token(1, rbac.RoleAdmin, rbac.UsersRead), http.StatusForbidden},
+		{"admin with permission", "/api/admin/users", token(2, rbac.RoleAdmin, rbac.UsersRead), http.StatusOK},
+		{"disabled admin", "/api/admin/users", token(3, rbac.RoleAdmin, rbac.UsersRead), http.StatusForbidden},
+		{"disabled user on any route", "/api/tasks", token(3, rbac.RoleAdmin), http.StatusForbidden},
+		{"deleted user", "/api/tasks", token(4, rbac.RoleUser), http.StatusUnauthorized},
+		{"delegated token of admin", "/api/admin/users", delegatedToken(t, keys, 2, "admin-token", scope.TasksRead), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(router, http.MethodGet, tt.path, tt.token)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_WebSocketSubprotocolToken(t *testing.T) {
+	keys, err := jwtkeys.NewEphemeralManager("test")
+	require.NoError(t, err)
+	router := newTestRouter(keys, revocationList{}, activeUser)
+	token, err := utils.GenerateToken(keys, 1, rbac.RoleUser, nil)
+	require.NoError(t, err)
+
+	handshake := func(upgrade bool, protocols string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/api/tasks", nil)
+		if upgrade {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+		}
+		req.Header.Set("Sec-WebSocket-Protocol", protocols)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, handshake(true, "todo.v1, bearer."+token))
+	assert.Equal(t, http.StatusUnauthorized, handshake(true, "todo.v1"))
+	assert.Equal(t, http.StatusUnauthorized, handshake(false, "todo.v1, bearer."+token))
+}
+
+func TestHasScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.True(t, HasScope(c, scope.MembersRead), "login tokens have every scope")
+
+	c.Set("clientID", "planner")
+	c.Set("scopes", []string{scope.TasksRead})
+	assert.True(t, HasScope(c, scope.TasksRead))
+	assert.False(t, HasScope(c, scope.MembersRead))
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+)
+
+// WorkspaceHeader selects the workspace a request is scoped to.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// WorkspaceChecker reports whether a user belongs to a workspace.
+type WorkspaceChecker interface {
+	IsWorkspaceMember(ctx context.Context, workspaceID, userID int) (bool, error)
+}
+
+// WorkspaceScope scopes requests that carry the X-Workspace-ID header to that
+// workspace, see tenant.WithWorkspace, and records it as "workspaceID" in
+// the context. Workspaces the user does not belong to are reported as not
+// found. Requests without the header span all of the user's workspaces. It
+// must run after AuthMiddleware.
+func WorkspaceScope(workspaces WorkspaceChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader(WorkspaceHeader)
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		workspaceID, err := strconv.ParseUint(header, 10, 31)
+		if err != nil || workspaceID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + WorkspaceHeader + " header"})
+			c.Abort()
+			return
+		}
+		member, err := workspaces.IsWorkspaceMember(c.Request.Context(), int(workspaceID), c.GetInt("userID"))
+		if err != nil {
+			slog.Error("Failed to check workspace membership", "workspaceID", workspaceID, "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check workspace"})
+			c.Abort()
+			return
+		}
+		if !member {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+			c.Abort()
+			return
+		}
+
+		c.Set("workspaceID", int(workspaceID))
+		c.Request = c.Request.WithContext(tenant.WithWorkspace(c.Request.Context(), int(workspaceID)))
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/tenant"
+)
+
+// workspaceMembers maps workspace IDs to the IDs of their members.
+type workspaceMembers map[int][]int
+
+func (w workspaceMembers) IsWorkspaceMember(_ context.Context, workspaceID, userID int) (bool, error) {
+	if workspaceID == 99 {
+		return false, errors.New("database unavailable")
+	}
+	for _, id := range w[workspaceID] {
+		if id == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func TestWorkspaceScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/tasks", func(c *gin.Context) { c.Set("userID", 1) }, WorkspaceScope(workspaceMembers{3: {1, 2}, 4: {2}}),
+		func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"workspace": tenant.WorkspaceID(c.Request.Context())})
+		})
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		body   string
+	}{
+		{"no header spans all workspaces", "", http.StatusOK, `{"workspace":0}`},
+		{"member", "3", http.StatusOK, `{"workspace":3}`},
+		{"not a member", "4", http.StatusNotFound, ""},
+		{"unknown workspace", "5", http.StatusNotFound, ""},
+		{"invalid", "abc", http.StatusBadRequest, ""},
+		{"zero", "0", http.StatusBadRequest, ""},
+		{"lookup fails", "99", http.StatusInternalServerError, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set(WorkspaceHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.body != "" {
+				assert.JSONEq(t, tt.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package oidc_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc"
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/oidc/oidctest"
+)
+
+const redirectURL = "http://app.test/auth/oidc/fake/callback"
+
+func newFakeProvider(t *testing.T, secret string) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+	fake, err := oidctest.NewProvider("todo-client", secret)
+	require.NoError(t, err)
+	t.Cleanup(fake.Close)
+	return fake, oidc.NewProvider(fake.Config("fake", redirectURL), nil)
+}
+
+func TestProvider_AuthorizationCodeFlow(t *testing.T) {
+	for name, secret := range map[string]string{"confidential": "s3cret", "public": ""} {
+		t.Run(name, func(t *testing.T) {
+			fake, provider := newFakeProvider(t, secret)
+			fake.SetUser(oidctest.User{Subject: "abc", Email: "alice@example.com", EmailVerified: true, PreferredUsername: "alice"})
+			ctx := context.Background()
+
+			verifier, err := oidc.NewCodeVerifier()
+			require.NoError(t, err)
+			authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+			require.NoError(t, err)
+
+			callback, err := fake.Authorize(authURL)
+			require.NoError(t, err)
+			assert.Equal(t, "state-1", callback.Query().Get("state"))
+
+			token, err := provider.Exchange(ctx, callback.Query().Get("code"), verifier)
+			require.NoError(t, err)
+
+			claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+			require.NoError(t, err)
+			assert.Equal(t, "abc", claims.Subject)
+			assert.Equal(t, "alice@example.com", claims.Email)
+			assert.True(t, claims.EmailVerified)
+			assert.Equal(t, "alice", claims.PreferredUsername)
+		})
+	}
+}
+
+func TestProvider_ExchangeRejectsWrongVerifier(t *testing.T) {
+	fake, provider := newFakeProvider(t, "s3cret")
+	ctx := context.Background()
+
+	verifier, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", verifier)
+	require.NoError(t, err)
+	callback, err := fake.Authorize(authURL)
+	require.NoError(t, err)
+
+	other, err := oidc.NewCodeVerifier()
+	require.NoError(t, err)
+	_, err = provider.Exchange(ctx, callback.Query().Get("code"), other)
+	assert.ErrorIs(t, err, oidc.ErrTokenRequest)
+}
+
+func TestProvider_VerifyIDTokenRejects(t *testing.T) {
+	fake, provider := newFakeProvider(t, "s3cret")
+	ctx := context.Background()
+	now := time.Now()
+
+	valid := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   fake.Issuer(),
+			"sub":   "abc",
+			"aud":   "todo-client",
+			"exp":   now.Add(time.Hour).Unix(),
+			"nonce": "nonce",
+		}
+	}
+
+	raw, err := fake.SignIDToken(valid())
+	require.NoError(t, err)
+	_, err = provider.VerifyIDToken(ctx, raw, "nonce")
+	require.NoError(t, err, "baseline token must verify")
+
+	tests := map[string]func(jwt.MapClaims){
+		"wrong issuer":      func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"wrong audience":    func(c jwt.MapClaims) { c["aud"] = "someone-else" },
+		"expired":           func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"missing exp":       func(c jwt.MapClaims) { delete(c, "exp") },
+		"wrong nonce":       func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		"missing sub":       func(c jwt.MapClaims) { delete(c, "sub") },
+		"foreign azp":       func(c jwt.MapClaims) { c["azp"] = "someone-else" },
+		"multi aud, no azp": func(c jwt.MapClaims) { c["aud"] = []string{"todo-client", "other"} },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := valid()
+			mutate(claims)
+			raw, err := fake.SignIDToken(claims)
+			require.NoError(t, err)
+
+			_, err = provider.VerifyIDToken(ctx, raw, "nonce")
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+		})
+	}
+
+	t.Run("unsigned", func(t *testing.T) {
+		raw, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		require.NoError(t, err)
+		_, err = provider.VerifyIDToken(ctx, raw, "nonce")
+		assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+	})
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	fake, _ := newFakeProvider(t, "")
+	cfg := fake.Config("fake", redirectURL)
+	cfg.Issuer = fake.Issuer() + "/"
+
+	provider := oidc.NewProvider(cfg, http.DefaultClient)
+	_, err := provider.Metadata(context.Background())
+	assert.ErrorIs(t, err, oidc.ErrDiscovery)
+}
+
+func TestCodeChallengeS256(t *testing.T) {
+	// Appendix B of RFC 7636.
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		oidc.CodeChallengeS256("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
@@ -0,0 +1,89 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// BreachChecker reports how often a password has been seen in breaches.
+type BreachChecker interface {
+	BreachCount(ctx context.Context, password string) (int, error)
+}
+
+// RangeFileChecker looks passwords up in a local copy of the Have I Been
+// Pwned range data, without any network access. Passwords are hashed with
+// SHA-1 and, like the online k-anonymity API, only the data for the 5
+// character hash prefix is read.
+//
+// The path may be a directory containing one file per prefix (named
+// "ABCDE" or "ABCDE.txt") whose lines are "SUFFIX:COUNT", or a single file
+// whose lines are full "HASH:COUNT" entries.
+type RangeFileChecker struct {
+	path  string
+	isDir bool
+}
+
+func NewRangeFileChecker(path string) (*RangeFileChecker, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening breached password list: %w", err)
+	}
+	return &RangeFileChecker{path: path, isDir: info.IsDir()}, nil
+}
+
+func (c *RangeFileChecker) BreachCount(ctx context.Context, password string) (int, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	if !c.isDir {
+		return lookupRange(c.path, hash)
+	}
+
+	for _, name := range []string{prefix + ".txt", prefix} {
+		count, err := lookupRange(filepath.Join(c.path, name), suffix)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		return count, err
+	}
+	return 0, nil
+}
+
+// lookupRange scans a range file for a line starting with want and returns
+// its count.
+func lookupRange(path, want string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return scanRange(f, want)
+}
+
+func scanRange(r io.Reader, want string) (int, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		hash, count, ok := strings.Cut(line, ":")
+		if !ok || !strings.EqualFold(hash, want) {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return 0, fmt.Errorf("invalid count in breached password list: %q", line)
+		}
+		return n, nil
+	}
+	return 0, scanner.Err()
+}
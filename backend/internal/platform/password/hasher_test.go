@@ -0,0 +1,71 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func testArgon2Params() Argon2Params {
+	return Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params())
+
+	encoded, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.True(t, hasher.Supports(encoded))
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	ok, err := hasher.Verify("correct horse", encoded)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Verify("wrong horse", encoded)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	stronger := testArgon2Params()
+	stronger.Iterations = 2
+	assert.True(t, NewArgon2idHasher(stronger).NeedsRehash(encoded))
+}
+
+func TestArgon2idHasher_MalformedHash(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2Params())
+
+	_, err := hasher.Verify("x", "$argon2id$v=19$m=1024$salt$hash")
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+	assert.True(t, hasher.NeedsRehash("$argon2id$garbage"))
+}
+
+func TestMultiHasher_AcceptsLegacyBcrypt(t *testing.T) {
+	hasher := NewMultiHasher(NewArgon2idHasher(testArgon2Params()), NewBcryptHasher(bcrypt.MinCost))
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	ok, err := hasher.Verify("password123", string(legacy))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+
+	encoded, err := hasher.Hash("password123")
+	require.NoError(t, err)
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	_, err = hasher.Verify("password123", "plaintext")
+	assert.ErrorIs(t, err, ErrUnsupportedHash)
+}
+
+func TestBcryptHasher_NeedsRehashOnCostChange(t *testing.T) {
+	encoded, err := NewBcryptHasher(bcrypt.MinCost).Hash("password123")
+	require.NoError(t, err)
+
+	assert.False(t, NewBcryptHasher(bcrypt.MinCost).NeedsRehash(encoded))
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(encoded))
+}
@@ -0,0 +1,146 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rule names reported in violations. Clients use them to show a message per
+// rule.
+const (
+	RuleMinLength        = "min_length"
+	RuleMaxLength        = "max_length"
+	RuleLowercase        = "lowercase"
+	RuleUppercase        = "uppercase"
+	RuleDigit            = "digit"
+	RuleSymbol           = "symbol"
+	RuleContainsUsername = "contains_username"
+	RuleBreached         = "breached"
+)
+
+// Violation describes a single policy rule a password does not satisfy.
+type Violation struct {
+	Rule    string         `json:"rule"`
+	Message string         `json:"message"`
+	Params  map[string]any `json:"params,omitempty"`
+}
+
+// ValidationError lists every rule a password violates.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+	}
+	return "password violates policy: " + strings.Join(rules, ", ")
+}
+
+// Policy holds the password requirements.
+type Policy struct {
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// DisallowUsername rejects passwords that contain the username.
+	DisallowUsername bool
+	// Breached, if set, rejects passwords found in a breach corpus.
+	Breached BreachChecker
+}
+
+// DefaultPolicy returns the policy used unless configured otherwise.
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:        8,
+		MaxLength:        128,
+		DisallowUsername: true,
+	}
+}
+
+// Validate checks password against the policy. It returns a
+// *ValidationError listing all violations, or nil if the password is
+// acceptable. Other errors come from the breach checker.
+func (p Policy) Validate(ctx context.Context, password, username string) error {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMinLength,
+			Message: fmt.Sprintf("Password must be at least %d characters long", p.MinLength),
+			Params:  map[string]any{"min": p.MinLength},
+		})
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		violations = append(violations, Violation{
+			Rule:    RuleMaxLength,
+			Message: fmt.Sprintf("Password must be at most %d bytes long", p.MaxLength),
+			Params:  map[string]any{"max": p.MaxLength},
+		})
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase, Message: "Password must contain a lowercase letter"})
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase, Message: "Password must contain an uppercase letter"})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit, Message: "Password must contain a digit"})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol, Message: "Password must contain a symbol"})
+	}
+
+	if p.DisallowUsername && containsUsername(password, username) {
+		violations = append(violations, Violation{Rule: RuleContainsUsername, Message: "Password must not contain the username"})
+	}
+
+	if p.Breached != nil {
+		count, err := p.Breached.BreachCount(ctx, password)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			violations = append(violations, Violation{
+				Rule:    RuleBreached,
+				Message: "Password has appeared in a data breach and must not be used",
+				Params:  map[string]any{"count": count},
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// containsUsername reports whether password contains username, ignoring
+// case. Very short usernames are ignored to avoid spurious matches.
+func containsUsername(password, username string) bool {
+	if utf8.RuneCountInString(username) < 3 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(password), strings.ToLower(username))
+}
@@ -0,0 +1,78 @@
+package password
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rules(err error) []string {
+	var vErr *ValidationError
+	if err == nil || !errors.As(err, &vErr) {
+		return nil
+	}
+	var out []string
+	for _, v := range vErr.Violations {
+		out = append(out, v.Rule)
+	}
+	return out
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := Policy{
+		MinLength:        8,
+		MaxLength:        72,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+		DisallowUsername: true,
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, policy.Validate(ctx, "Correct-Horse-9", "alice"))
+	assert.ElementsMatch(t, []string{RuleMinLength, RuleUppercase, RuleDigit, RuleSymbol}, rules(policy.Validate(ctx, "a", "alice")))
+	assert.ElementsMatch(t, []string{RuleContainsUsername}, rules(policy.Validate(ctx, "xxALICExx-9", "alice")))
+	assert.ElementsMatch(t, []string{RuleMaxLength}, rules(policy.Validate(ctx, "Aa1!"+string(make([]byte, 80)), "alice")))
+}
+
+func TestPolicy_Validate_ShortUsernameIgnored(t *testing.T) {
+	policy := DefaultPolicy()
+
+	assert.NoError(t, policy.Validate(context.Background(), "bobsled-racing", "bo"))
+}
+
+func TestRangeFileChecker_Directory(t *testing.T) {
+	// SHA-1("password") = 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte(
+		"003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n"), 0o644))
+
+	checker, err := NewRangeFileChecker(dir)
+	require.NoError(t, err)
+
+	count, err := checker.BreachCount(context.Background(), "password")
+	require.NoError(t, err)
+	assert.Equal(t, 9545824, count)
+
+	count, err = checker.BreachCount(context.Background(), "not in the list")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestRangeFileChecker_SingleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte("5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:42\n"), 0o644))
+
+	checker, err := NewRangeFileChecker(path)
+	require.NoError(t, err)
+
+	policy := Policy{MinLength: 1, Breached: checker}
+	err = policy.Validate(context.Background(), "password", "")
+	assert.Equal(t, []string{RuleBreached}, rules(err))
+}
@@ -0,0 +1,51 @@
+// Package rbac defines roles and the permissions they grant.
+package rbac
+
+import (
+	"errors"
+	"slices"
+)
+
+// Built-in roles. Custom roles are stored in the database alongside them.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions that can be granted to roles.
+const (
+	// UsersRead allows listing and viewing user accounts.
+	UsersRead = "users:read"
+	// UsersManage allows disabling, enabling, deleting users and assigning roles.
+	UsersManage = "users:manage"
+	// RolesManage allows creating, changing and deleting custom roles.
+	RolesManage = "roles:manage"
+	// StatsRead allows viewing usage statistics.
+	StatsRead = "stats:read"
+)
+
+// ErrAccountNotFound is returned by account lookups for users that no longer
+// exist.
+var ErrAccountNotFound = errors.New("account not found")
+
+// Account is the current authorization state of a user.
+type Account struct {
+	Role        string
+	Permissions []string
+	Disabled    bool
+}
+
+// Supported returns all known permissions in a stable order.
+func Supported() []string {
+	return []string{UsersRead, UsersManage, RolesManage, StatsRead}
+}
+
+// IsSupported reports whether p is a known permission.
+func IsSupported(p string) bool {
+	return slices.Contains(Supported(), p)
+}
+
+// Has reports whether permissions includes p.
+func Has(permissions []string, p string) bool {
+	return slices.Contains(permissions, p)
+}
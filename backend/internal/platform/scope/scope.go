@@ -0,0 +1,68 @@
+// Package scope defines the OAuth scopes that third-party clients can be
+// granted.
+package scope
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	// TasksRead allows listing the user's tasks.
+	TasksRead = "tasks:read"
+	// TasksWrite allows creating, updating and deleting the user's tasks.
+	TasksWrite = "tasks:write"
+	// MembersRead allows seeing the other users in the user's lists and
+	// workspaces: their members, the authors of comments and who is
+	// working on a list.
+	MembersRead = "members:read"
+)
+
+var descriptions = map[string]string{
+	TasksRead:   "View your tasks and lists, with their comments",
+	TasksWrite:  "Create, change and delete your tasks",
+	MembersRead: "See who else is in your lists and workspaces and who is working on them",
+}
+
+// Supported returns all known scopes in a stable order.
+func Supported() []string {
+	return []string{TasksRead, TasksWrite, MembersRead}
+}
+
+// Describe returns a human-readable description for the consent screen.
+func Describe(s string) string {
+	return descriptions[s]
+}
+
+// IsSupported reports whether s is a known scope.
+func IsSupported(s string) bool {
+	_, ok := descriptions[s]
+	return ok
+}
+
+// Parse splits a space-delimited scope string (RFC 6749 section 3.3) into
+// its distinct scopes.
+func Parse(s string) []string {
+	var scopes []string
+	for _, f := range strings.Fields(s) {
+		if !slices.Contains(scopes, f) {
+			scopes = append(scopes, f)
+		}
+	}
+	return scopes
+}
+
+// Format joins scopes into a space-delimited scope string.
+func Format(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Subset reports whether every scope in requested is also in granted.
+func Subset(requested, granted []string) bool {
+	for _, s := range requested {
+		if !slices.Contains(granted, s) {
+			return false
+		}
+	}
+	return true
+}
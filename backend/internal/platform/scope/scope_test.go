@@ -0,0 +1,25 @@
+package scope
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, Parse("  tasks:read tasks:write tasks:read "))
+	assert.Empty(t, Parse(""))
+}
+
+func TestSubset(t *testing.T) {
+	assert.True(t, Subset([]string{TasksRead}, Supported()))
+	assert.True(t, Subset(nil, []string{TasksRead}))
+	assert.False(t, Subset([]string{TasksRead, TasksWrite}, []string{TasksRead}))
+}
+
+func TestIsSupported(t *testing.T) {
+	assert.True(t, IsSupported(TasksWrite))
+	assert.True(t, IsSupported(MembersRead))
+	assert.False(t, IsSupported("admin"))
+	assert.Equal(t, len(Supported()), len(descriptions))
+}
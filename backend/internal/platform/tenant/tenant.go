@@ -0,0 +1,18 @@
+// Package tenant carries the workspace a request is scoped to.
+package tenant
+
+import "context"
+
+type workspaceKey struct{}
+
+// WithWorkspace returns a copy of ctx scoped to workspaceID.
+func WithWorkspace(ctx context.Context, workspaceID int) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, workspaceID)
+}
+
+// WorkspaceID returns the workspace ctx is scoped to, or 0 if it is not
+// scoped and spans all workspaces of the user.
+func WorkspaceID(ctx context.Context) int {
+	id, _ := ctx.Value(workspaceKey{}).(int)
+	return id
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
+)
+
+// invitationAudience separates invitation tokens from access tokens, which
+// are signed with the same keys. Access tokens are also rejected by
+// ParseToken because invitation tokens carry no user ID.
+const invitationAudience = "invitation"
+
+// GenerateInvitationToken signs a token for invitation invitationID. tokenID
+// becomes the "jti" claim and must match the stored invitation when the
+// token is redeemed, which makes the token single-use.
+func GenerateInvitationToken(keys *jwtkeys.Manager, invitationID int, tokenID string, expiresAt time.Time) (string, error) {
+	claims := jwt.RegisteredClaims{
+		ID:        tokenID,
+		Issuer:    keys.Issuer(),
+		Subject:   strconv.Itoa(invitationID),
+		Audience:  jwt.ClaimStrings{invitationAudience},
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+	}
+	return keys.Sign(claims)
+}
+
+// ParseInvitationToken validates an invitation token and returns the
+// invitation ID and token ID it carries.
+func ParseInvitationToken(keys *jwtkeys.Manager, tokenString string) (int, string, error) {
+	var claims jwt.RegisteredClaims
+	token, err := keys.Parse(tokenString, &claims)
+	if err != nil {
+		return 0, "", err
+	}
+
+	invitationID, err := strconv.Atoi(claims.Subject)
+	if !token.Valid || err != nil || claims.ID == "" || !slices.Contains(claims.Audience, invitationAudience) {
+		return 0, "", errors.New("invalid invitation token claims")
+	}
+	return invitationID, claims.ID, nil
+}
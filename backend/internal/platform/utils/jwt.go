@@ -1,42 +1,76 @@
 package utils
 
 import (
-	"os"
+	"errors"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tamago/todo-with-gemini/backend/internal/platform/jwtkeys"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// Claims are the claims of access tokens. Tokens issued at login carry the
+// user with its role and permissions; tokens issued to third-party OAuth
+// clients carry the client, the granted scope and a token ID ("jti") used
+// for revocation instead of a role.
+type Claims struct {
+	UserID      int      `json:"user_id"`
+	Role        string   `json:"role,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	ClientID    string   `json:"client_id,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
 
-// GenerateToken generates a new JWT token for a given user ID.
-func GenerateToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+// Delegated reports whether the token was issued to a third-party client.
+func (c *Claims) Delegated() bool {
+	return c.ClientID != ""
+}
+
+// GenerateToken generates a new JWT token for a given user ID and its role,
+// signed with the active key of keys. The role and permissions are
+// informational; the auth middleware checks the current ones on every
+// request.
+func GenerateToken(keys *jwtkeys.Manager, userID int, role string, permissions []string) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		UserID:      userID,
+		Role:        role,
+		Permissions: permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    keys.Issuer(),
+			Subject:   strconv.Itoa(userID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour * 24)), // Token expires in 24 hours
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return keys.Sign(claims)
 }
 
-// ValidateToken validates a JWT token and returns the user ID if valid.
-func ValidateToken(tokenString string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return jwtSecret, nil
-	})
-
+// ValidateToken validates a JWT token against the keys of keys and returns
+// the user ID if valid.
+func ValidateToken(keys *jwtkeys.Manager, tokenString string) (int, error) {
+	claims, err := ParseToken(keys, tokenString)
 	if err != nil {
 		return 0, err
 	}
+	return claims.UserID, nil
+}
+
+// ParseToken validates a JWT token against the keys of keys and returns its
+// claims. Delegated tokens must carry a token ID so they can be revoked.
+func ParseToken(keys *jwtkeys.Manager, tokenString string) (*Claims, error) {
+	var claims Claims
+	token, err := keys.Parse(tokenString, &claims)
+	if err != nil {
+		return nil, err
+	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID := int(claims["user_id"].(float64))
-		return userID, nil
+	if !token.Valid || claims.UserID == 0 || (claims.Delegated() && claims.ID == "") {
+		return nil, errors.New("invalid token claims")
 	}
 
-	return 0, jwt.ErrSignatureInvalid
+	return &claims, nil
 }
@@ -0,0 +1,67 @@
+// Package webhook signs outgoing webhook requests and verifies their
+// signatures.
+//
+// The signature is the hex-encoded HMAC-SHA256 of the timestamp, a ".", and
+// the request body, keyed with the webhook's secret:
+//
+//	X-Webhook-Timestamp: 1767225600
+//	X-Webhook-Signature: sha256=<64 hex digits>
+//
+// Receivers recompute the signature over the raw body and reject requests
+// whose timestamp is too old, so that captured requests cannot be replayed.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers of a webhook request.
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	TimestampHeader = "X-Webhook-Timestamp"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+const signaturePrefix = "sha256="
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature does not match")
+	ErrInvalidTimestamp = errors.New("webhook timestamp is missing or outside the tolerance")
+)
+
+// Sign returns the signature header value for body sent at timestamp, in
+// seconds since the Unix epoch.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp header values of a request
+// received at now. Timestamps more than tolerance away from now are
+// rejected.
+func Verify(secret, signature, timestamp string, body []byte, now time.Time, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrInvalidTimestamp
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
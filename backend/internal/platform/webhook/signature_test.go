@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	assert.Equal(t, "sha256=8c84d1a52c62968d790bd2612fdea657174cf5ec06ce9a8da3217ac588edffe1",
+		Sign("secret", 1767225600, []byte(`{"id":"1"}`)))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1767225600, 0)
+	body := []byte(`{"id":"1"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	signature := Sign("secret", now.Unix(), body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		now       time.Time
+		want      error
+	}{
+		{"valid", "secret", signature, ts, body, now, nil},
+		{"within tolerance", "secret", signature, ts, body, now.Add(4 * time.Minute), nil},
+		{"wrong secret", "other", signature, ts, body, now, ErrInvalidSignature},
+		{"changed body", "secret", signature, ts, []byte(`{"id":"2"}`), now, ErrInvalidSignature},
+		{"changed timestamp", "secret", signature, strconv.FormatInt(now.Unix()+1, 10), body, now, ErrInvalidSignature},
+		{"missing prefix", "secret", signature[len("sha256="):], ts, body, now, ErrInvalidSignature},
+		{"too old", "secret", signature, ts, body, now.Add(6 * time.Minute), ErrInvalidTimestamp},
+		{"from the future", "secret", signature, ts, body, now.Add(-6 * time.Minute), ErrInvalidTimestamp},
+		{"not a number", "secret", signature, "yesterday", body, now, ErrInvalidTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, tt.now, 5*time.Minute)
+
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
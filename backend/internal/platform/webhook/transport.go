@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook URL leads to an address
+// that webhooks may not reach.
+var ErrForbiddenAddress = errors.New("webhook address is not allowed")
+
+// reservedPrefixes are ranges that are not reachable on the public
+// internet besides those the netip predicates cover.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// PublicAddr reports whether ip may be reached by webhooks of any user: it
+// is not a loopback, private, link-local, unspecified, multicast or other
+// reserved address.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allowlist is the set of hosts that webhooks may reach even though they
+// are internal. Hosts are compared case-insensitively and without port.
+type Allowlist map[string]bool
+
+// NewAllowlist returns an allowlist of hosts, such as "ci.internal" or
+// "10.0.0.5". Empty entries are ignored.
+func NewAllowlist(hosts []string) Allowlist {
+	allowed := make(Allowlist)
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			allowed[host] = true
+		}
+	}
+	return allowed
+}
+
+// Contains reports whether host, without port, is allowlisted.
+func (a Allowlist) Contains(host string) bool {
+	return a[strings.ToLower(host)]
+}
+
+// NewTransport returns a transport for sending webhooks. It refuses to
+// connect to addresses for which PublicAddr is false unless the host of the
+// URL is in allowed. The address is checked after DNS resolution, right
+// before connecting, so a host name that resolves to an internal address,
+// or starts to, is refused too. Proxies from the environment are not used.
+func NewTransport(allowed Allowlist) *http.Transport {
+	guarded := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: controlPublic}
+	open := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+		if host, _, err := net.SplitHostPort(address); err == nil && allowed.Contains(host) {
+			return open.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+	return transport
+}
+
+// controlPublic is a net.Dialer Control function that refuses addresses
+// for which PublicAddr is false.
+func controlPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !PublicAddr(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.215.14":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"::":               false,
+		"224.0.0.1":        false,
+		"100.64.0.1":       false,
+		"::ffff:127.0.0.1": false,
+	}
+	for addr, want := range tests {
+		assert.Equal(t, want, PublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+	u, err := url.Parse(receiver.URL)
+	require.NoError(t, err)
+
+	client := &http.Client{Transport: NewTransport(NewAllowlist(nil))}
+	_, err = client.Get(receiver.URL)
+	assert.ErrorIs(t, err, ErrForbiddenAddress, "loopback is refused")
+
+	client = &http.Client{Transport: NewTransport(NewAllowlist([]string{" " + u.Hostname() + " "}))}
+	resp, err := client.Get(receiver.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
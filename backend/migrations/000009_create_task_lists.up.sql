@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS task_lists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every user has at most one personal list. It receives tasks created
+-- without a list.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_task_lists_personal ON task_lists (owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS task_list_members (
+    list_id INTEGER NOT NULL REFERENCES task_lists(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'admin', 'owner')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_list_members_user_id ON task_list_members (user_id);
+
+-- Tasks belong to a list instead of a user. The former owner becomes the
+-- creator, and tasks of deleted users in shared lists are kept.
+ALTER TABLE tasks RENAME COLUMN user_id TO created_by;
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_user_id_fkey;
+ALTER TABLE tasks ADD CONSTRAINT tasks_created_by_fkey
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS list_id INTEGER REFERENCES task_lists(id) ON DELETE CASCADE;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+
+-- Move existing tasks into a personal list of their owner.
+INSERT INTO task_lists (name, owner_id, personal)
+SELECT DISTINCT 'My tasks', created_by, TRUE FROM tasks WHERE created_by IS NOT NULL
+ON CONFLICT (owner_id) WHERE personal DO NOTHING;
+
+INSERT INTO task_list_members (list_id, user_id, role)
+SELECT id, owner_id, 'owner' FROM task_lists
+ON CONFLICT (list_id, user_id) DO NOTHING;
+
+UPDATE tasks t SET list_id = l.id
+FROM task_lists l
+WHERE t.list_id IS NULL AND l.owner_id = t.created_by AND l.personal;
+
+-- Tasks without an owner were never visible to anyone.
+DELETE FROM tasks WHERE list_id IS NULL;
+
+ALTER TABLE tasks ALTER COLUMN list_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_list_id ON tasks (list_id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS list_invitations (
+    id SERIAL PRIMARY KEY,
+    list_id INTEGER NOT NULL REFERENCES task_lists(id) ON DELETE CASCADE,
+    inviter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255),
+    role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    -- The "jti" of the signed invitation token. A token is only accepted
+    -- while its invitation is pending and the IDs match.
+    token_id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    accepted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    declined_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_invitations_list_id ON list_invitations (list_id);
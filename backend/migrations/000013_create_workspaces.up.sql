@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    owner_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every user has at most one personal workspace. It holds their personal
+-- list and the lists created without choosing a workspace.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_personal ON workspaces (owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('member', 'admin', 'owner')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members (user_id);
+
+ALTER TABLE task_lists ADD COLUMN IF NOT EXISTS workspace_id INTEGER REFERENCES workspaces(id) ON DELETE CASCADE;
+
+-- Move existing lists into the personal workspace of their owner, and make
+-- everyone they are shared with a member of that workspace.
+INSERT INTO workspaces (name, owner_id, personal)
+SELECT 'Personal', owner_id, TRUE FROM task_lists WHERE workspace_id IS NULL
+ON CONFLICT (owner_id) WHERE personal DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, owner_id, 'owner' FROM workspaces
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+UPDATE task_lists l SET workspace_id = w.id
+FROM workspaces w
+WHERE l.workspace_id IS NULL AND w.owner_id = l.owner_id AND w.personal;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT DISTINCT l.workspace_id, m.user_id, 'member'
+FROM task_list_members m JOIN task_lists l ON l.id = m.list_id
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+ALTER TABLE task_lists ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_task_lists_workspace_id ON task_lists (workspace_id);
@@ -0,0 +1,22 @@
+-- Reminders fire once at fire_at, which is remind_at or the task's
+-- created_at plus offset_minutes. A scheduler claims due reminders by
+-- setting lease_token and lease_until; a lease that runs out lets another
+-- replica claim the reminder again.
+CREATE TABLE IF NOT EXISTS task_reminders (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    remind_at TIMESTAMP WITH TIME ZONE,
+    offset_minutes INTEGER CHECK (offset_minutes >= 0),
+    fire_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    lease_token VARCHAR(64),
+    lease_until TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    fired_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((remind_at IS NULL) <> (offset_minutes IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_reminders_task_id ON task_reminders (task_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_task_reminders_due ON task_reminders (fire_at) WHERE fired_at IS NULL;
@@ -0,0 +1,9 @@
+-- A snoozed task is hidden from task lists until snoozed_until. The snooze
+-- waker clears snoozed_until once it has passed.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_snoozed_until ON tasks (snoozed_until) WHERE snoozed_until IS NOT NULL;
+
+-- The IANA time zone snooze presets are resolved in, e.g. Europe/Berlin.
+-- NULL means UTC.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
@@ -0,0 +1,28 @@
+-- Domain events are written to the outbox in the same transaction as the
+-- change they describe, so an event exists if and only if its change was
+-- committed. The outbox relay claims unpublished events by setting
+-- lease_token and lease_until, hands them to every sink not yet listed in
+-- published_sinks, and retries the rest with backoff. Published events are
+-- kept for a while before they are purged.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    list_id INTEGER,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_sinks TEXT[] NOT NULL DEFAULT '{}',
+    published_at TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    lease_token VARCHAR(64),
+    lease_until TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_unpublished ON outbox_events (next_attempt_at, id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at) WHERE published_at IS NOT NULL;
+
+-- The relay may hand an event to the webhook sink more than once; each
+-- webhook still gets one delivery per event.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id);
@@ -0,0 +1,83 @@
+-- Delta sync hands clients a token that is the database snapshot their sync
+-- read. A row changed since the token if the transaction that last changed
+-- it is not visible in that snapshot, see pg_visible_in_snapshot. This also
+-- catches changes that were still in flight when the token was issued.
+-- title_xid and completed_xid record the last change of each field for
+-- per-field conflict resolution, created_xid the creation of the task.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS change_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS created_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS title_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+-- The client's own ID of a task created offline, so that a sync batch can
+-- be retried without creating the task twice.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS client_id VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_change_xid ON tasks (change_xid);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tasks_client_id ON tasks (created_by, client_id) WHERE client_id IS NOT NULL;
+
+-- Every change of a task is stamped, whichever code path makes it.
+CREATE OR REPLACE FUNCTION stamp_task_change() RETURNS trigger AS $$
+BEGIN
+    NEW.change_xid := pg_current_xact_id();
+    IF NEW.title IS DISTINCT FROM OLD.title THEN
+        NEW.title_xid := NEW.change_xid;
+    END IF;
+    IF NEW.completed IS DISTINCT FROM OLD.completed THEN
+        NEW.completed_xid := NEW.change_xid;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_stamp_change ON tasks;
+CREATE TRIGGER tasks_stamp_change BEFORE UPDATE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION stamp_task_change();
+
+-- Deleted tasks leave a tombstone, also when they go with their list.
+-- Task IDs are never reused. Tombstones are kept so that every sync token
+-- stays valid.
+CREATE TABLE IF NOT EXISTS task_tombstones (
+    task_id INTEGER PRIMARY KEY,
+    list_id INTEGER NOT NULL,
+    deleted_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tombstones_deleted_xid ON task_tombstones (deleted_xid);
+
+CREATE OR REPLACE FUNCTION record_task_tombstone() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_tombstones (task_id, list_id) VALUES (OLD.id, OLD.list_id)
+    ON CONFLICT (task_id) DO NOTHING;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS tasks_record_tombstone ON tasks;
+CREATE TRIGGER tasks_record_tombstone AFTER DELETE ON tasks
+    FOR EACH ROW EXECUTE FUNCTION record_task_tombstone();
+
+-- The tasks of a list appear to a user when they join it and disappear
+-- when they leave it or the list is deleted.
+ALTER TABLE task_list_members ADD COLUMN IF NOT EXISTS added_xid xid8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE TABLE IF NOT EXISTS task_list_member_removals (
+    list_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    removed_xid xid8 NOT NULL DEFAULT pg_current_xact_id(),
+    removed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_list_member_removals_user ON task_list_member_removals (user_id, removed_xid);
+
+CREATE OR REPLACE FUNCTION record_member_removal() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO task_list_member_removals (list_id, user_id) VALUES (OLD.list_id, OLD.user_id);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS task_list_members_record_removal ON task_list_members;
+CREATE TRIGGER task_list_members_record_removal AFTER DELETE ON task_list_members
+    FOR EACH ROW EXECUTE FUNCTION record_member_removal();
@@ -0,0 +1,20 @@
+-- Concurrent edits of a task are merged field by field: the write with the
+-- later hybrid logical clock timestamp wins, see the crdt package. The
+-- columns hold the text form of the timestamp of the last write of each
+-- field, which sorts like the timestamps under the "C" collation. '' is the
+-- zero timestamp of fields that were not written since.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS title_clock TEXT COLLATE "C" NOT NULL DEFAULT '';
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_clock TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+-- Every user has their own manual order of the tasks they see. position is
+-- a key of the order, see crdt.Order, and clock the timestamp of the move
+-- that wrote it.
+CREATE TABLE IF NOT EXISTS task_positions (
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id INTEGER NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    position TEXT COLLATE "C" NOT NULL,
+    clock TEXT COLLATE "C" NOT NULL,
+    PRIMARY KEY (user_id, task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_positions_task_id ON task_positions (task_id);
@@ -0,0 +1,28 @@
+-- Imports too large to apply within the request run as jobs. rows holds the
+-- validated rows to create, see models.ImportRow. A runner claims a job by
+-- setting lease_token and lease_until and extends the lease as it reports
+-- progress; a lease that runs out lets another replica claim the job again.
+-- The rows are created in one transaction, so a job that is taken over
+-- starts from scratch. workspace_id is the workspace the import was scoped
+-- to, 0 for none.
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    rows JSONB NOT NULL,
+    total INTEGER NOT NULL,
+    processed INTEGER NOT NULL DEFAULT 0,
+    created INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    lease_token VARCHAR(64),
+    lease_until TIMESTAMP WITH TIME ZONE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_jobs_user_id ON import_jobs (user_id);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_open ON import_jobs (created_at) WHERE status IN ('pending', 'running');
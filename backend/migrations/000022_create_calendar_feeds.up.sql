@@ -0,0 +1,8 @@
+-- A calendar feed serves a user's tasks as iCalendar to anyone who knows
+-- its URL, since calendar apps cannot log in. Only the hash of the secret
+-- token in the URL is stored.
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
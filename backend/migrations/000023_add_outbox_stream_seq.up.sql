@@ -0,0 +1,16 @@
+-- The event stream numbers events in the order their announcements commit,
+-- not in the order they were written to the outbox: a transaction that
+-- started earlier may commit later. Announcing an event increments
+-- stream_position.seq, whose row lock is held until the announcement is
+-- committed, so replicas receive events in the order of their stream_seq
+-- and a client that resumes after an event has seen every event numbered
+-- before it. Numbering starts above the outbox IDs that clients resumed
+-- from before.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS stream_seq BIGINT UNIQUE;
+
+CREATE TABLE IF NOT EXISTS stream_position (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    seq BIGINT NOT NULL
+);
+
+INSERT INTO stream_position (seq) SELECT COALESCE(MAX(id), 0) FROM outbox_events ON CONFLICT DO NOTHING;
@@ -5,6 +5,7 @@ describe('AuthForm', () => {
   test('renders signup form correctly', () => {
     render(<AuthForm isSignup={true} onSubmit={() => {}} />);
     expect(screen.getByLabelText(/username/i)).toBeInTheDocument();
+    expect(screen.getByLabelText(/email/i)).toBeInTheDocument();
     expect(screen.getByLabelText(/password/i)).toBeInTheDocument();
     expect(screen.getByRole('button', { name: /signup/i })).toBeInTheDocument();
   });
@@ -21,10 +22,11 @@ describe('AuthForm', () => {
     render(<AuthForm isSignup={true} onSubmit={handleSubmit} />);
 
     fireEvent.change(screen.getByLabelText(/username/i), { target: { value: 'testuser' } });
+    fireEvent.change(screen.getByLabelText(/email/i), { target: { value: 'test@example.com' } });
     fireEvent.change(screen.getByLabelText(/password/i), { target: { value: 'password123' } });
     fireEvent.click(screen.getByRole('button', { name: /signup/i }));
 
-    expect(handleSubmit).toHaveBeenCalledWith('testuser', 'password123');
+    expect(handleSubmit).toHaveBeenCalledWith('testuser', 'password123', 'test@example.com');
   });
 
   test('calls onSubmit with correct values on login', () => {
@@ -35,6 +37,6 @@ describe('AuthForm', () => {
     fireEvent.change(screen.getByLabelText(/password/i), { target: { value: 'password123' } });
     fireEvent.click(screen.getByRole('button', { name: /login/i }));
 
-    expect(handleSubmit).toHaveBeenCalledWith('testuser', 'password123');
+    expect(handleSubmit).toHaveBeenCalledWith('testuser', 'password123', '');
   });
 });
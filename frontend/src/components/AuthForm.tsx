@@ -2,22 +2,23 @@ import React, { useState } from 'react';
 
 interface AuthFormProps {
   isSignup: boolean;
-  onSubmit: (username: string, password: string) => void;
+  onSubmit: (username: string, password: string, email: string) => void;
 }
 
 const AuthForm: React.FC<AuthFormProps> = ({ isSignup, onSubmit }) => {
   const [username, setUsername] = useState('');
+  const [email, setEmail] = useState('');
   const [password, setPassword] = useState('');
 
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault();
-    onSubmit(username, password);
+    onSubmit(username, password, email);
   };
 
   return (
     <form onSubmit={handleSubmit}>
       <div>
-        <label htmlFor="username">Username:</label>
+        <label htmlFor="username">{isSignup ? 'Username:' : 'Username or email:'}</label>
         <input
           type="text"
           id="username"
@@ -26,6 +27,18 @@ const AuthForm: React.FC<AuthFormProps> = ({ isSignup, onSubmit }) => {
           required
         />
       </div>
+      {isSignup && (
+        <div>
+          <label htmlFor="email">Email:</label>
+          <input
+            type="email"
+            id="email"
+            value={email}
+            onChange={(e) => setEmail(e.target.value)}
+            required
+          />
+        </div>
+      )}
       <div>
         <label htmlFor="password">Password:</label>
         <input
@@ -6,10 +6,10 @@ import api from '../services/api';
 const SignupPage: React.FC = () => {
   const navigate = useNavigate();
 
-  const handleSignup = async (username: string, password: string) => {
+  const handleSignup = async (username: string, password: string, email: string) => {
     try {
-      await api.signup(username, password);
-      alert('Signup successful! Please login.');
+      await api.signup(username, email, password);
+      alert('Signup successful! Please check your email to verify your address, then login.');
       navigate('/login');
     } catch (error: any) {
       alert(`Signup failed: ${error.message}`);
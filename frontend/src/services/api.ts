@@ -11,13 +11,13 @@ interface Task {
 }
 
 const api = {
-  signup: async (username: string, password: string): Promise<void> => {
+  signup: async (username: string, email: string, password: string): Promise<void> => {
     const response = await fetch(`${API_BASE_URL}/signup`, {
       method: 'POST',
       headers: {
         'Content-Type': 'application/json',
       },
-      body: JSON.stringify({ username, password }),
+      body: JSON.stringify({ username, email, password }),
     });
     if (!response.ok) {
       const errorData = await response.json();